@@ -37,6 +37,7 @@ func newControllerCmd(configName, verbosity *string) *cobra.Command {
 				newEdgeNodeSetConfig(),
 				newEdgeNodeGetOptions(controllerMode),
 				newEdgeNodeSetOptions(controllerMode),
+				newEdgeNodeConfig(controllerMode),
 			},
 		},
 	}
@@ -48,7 +49,7 @@ func newControllerCmd(configName, verbosity *string) *cobra.Command {
 	controllerCmd.AddCommand(newControllerGetOptions())
 	controllerCmd.AddCommand(newControllerSetOptions())
 
-	controllerCmd.PersistentFlags().StringVarP(&controllerMode, "mode", "m", "", "mode to use [file|proto|adam|zedcloud]://<URL> (default is adam)")
+	controllerCmd.PersistentFlags().StringVarP(&controllerMode, "mode", "m", "", "mode to use [file|proto|adam|rest]://<URL> (default is adam)")
 
 	return controllerCmd
 }
@@ -261,6 +262,82 @@ func newEdgeNodeGetConfig(controllerMode string) *cobra.Command {
 	return edgeNodeGetConfig
 }
 
+func newEdgeNodeConfig(controllerMode string) *cobra.Command {
+	var edgeNodeConfig = &cobra.Command{
+		Use:   "config",
+		Short: "manage history of EVE configs",
+		Long:  `Manage history of configs pushed to EVE.`,
+	}
+
+	groups := CommandGroups{
+		{
+			Message: "Basic Commands",
+			Commands: []*cobra.Command{
+				newEdgeNodeConfigHistory(controllerMode),
+				newEdgeNodeConfigDiff(controllerMode),
+				newEdgeNodeConfigRollback(controllerMode),
+			},
+		},
+	}
+
+	groups.AddTo(edgeNodeConfig)
+
+	return edgeNodeConfig
+}
+
+func newEdgeNodeConfigHistory(controllerMode string) *cobra.Command {
+	var edgeNodeConfigHistory = &cobra.Command{
+		Use:   "history",
+		Short: "list configs pushed to EVE",
+		Long:  `List versions of configs pushed to EVE with time and eden command caused them.`,
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EdgeNodeConfigHistory(controllerMode); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	return edgeNodeConfigHistory
+}
+
+func newEdgeNodeConfigDiff(controllerMode string) *cobra.Command {
+	var edgeNodeConfigDiff = &cobra.Command{
+		Use:   "diff <version> [<version>]",
+		Short: "show difference between EVE configs",
+		Long: `Show difference between config of version and current config of EVE
+or between configs of two versions from history.`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			to := ""
+			if len(args) > 1 {
+				to = args[1]
+			}
+			if err := openEVEC.EdgeNodeConfigDiff(controllerMode, args[0], to); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	return edgeNodeConfigDiff
+}
+
+func newEdgeNodeConfigRollback(controllerMode string) *cobra.Command {
+	var edgeNodeConfigRollback = &cobra.Command{
+		Use:   "rollback <version>",
+		Short: "restore EVE config of version",
+		Long:  `Restore config of version from history and push it to EVE as the new version.`,
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EdgeNodeConfigRollback(controllerMode, args[0]); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	return edgeNodeConfigRollback
+}
+
 func newEdgeNodeSetConfig() *cobra.Command {
 	var fileWithConfig string
 
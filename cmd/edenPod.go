@@ -107,6 +107,7 @@ func newPodDeployCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	podDeployCmd.Flags().StringVar(&pc.Registry, "registry", "remote", "Select registry to use for containers (remote/local)")
 	podDeployCmd.Flags().BoolVar(&pc.DirectLoad, "direct", true, "Use direct download for image instead of eserver")
 	podDeployCmd.Flags().BoolVar(&pc.SftpLoad, "sftp", false, "Force use of sftp to load http/file image from eserver")
+	podDeployCmd.Flags().StringVar(&pc.DatastoreType, "datastore-type", "http", "Type of datastore to load http/file image from eserver (http, sftp, s3 or azure); s3 and azure imply --direct=false")
 	podDeployCmd.Flags().StringSliceVar(&pc.Disks, "disks", nil, `Additional disks to use. You can write it in notation <link> or <mount point>:<link>. Deprecated. Please use volumes instead.`)
 	podDeployCmd.Flags().StringArrayVar(&pc.Mount, "mount", nil, `Additional volumes to use. You can write it in notation src=<link>,dst=<mount point>.`)
 	podDeployCmd.Flags().StringVar(&pc.VolumeSize, "volume-size", humanize.IBytes(defaults.DefaultVolumeSize), "volume size")
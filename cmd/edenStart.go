@@ -52,6 +52,7 @@ func newStartCmd(configName, verbosity *string) *cobra.Command {
 	startCmd.Flags().IntVarP(&cfg.Eden.EServer.Port, "eserver-port", "", defaults.DefaultEserverPort, "eserver port")
 	startCmd.Flags().StringVarP(&cfg.Eden.EServer.Tag, "eserver-tag", "", defaults.DefaultEServerTag, "tag of eserver container to pull")
 	startCmd.Flags().BoolVarP(&cfg.Eden.EServer.Force, "eserver-force", "", cfg.Eden.EServer.Force, "eserver force rebuild")
+	startCmd.Flags().BoolVarP(&cfg.Eden.EServer.HTTPS, "eserver-https", "", cfg.Eden.EServer.HTTPS, "serve http of eserver over TLS")
 
 	startCmd.Flags().IntVarP(&cfg.Eve.QemuCpus, "cpus", "", defaults.DefaultCpus, "cpus count")
 	startCmd.Flags().IntVarP(&cfg.Eve.QemuMemory, "memory", "", defaults.DefaultMemory, "memory size (MB)")
@@ -12,7 +12,7 @@ import (
 
 func newStatusCmd(configName, verbosity *string) *cobra.Command {
 	cfg := &openevec.EdenSetupArgs{}
-	var allConfigs bool
+	var allConfigs, showDiff bool
 	var vmName string
 
 	var statusCmd = &cobra.Command{
@@ -21,7 +21,7 @@ func newStatusCmd(configName, verbosity *string) *cobra.Command {
 		Long:              `Status of harness.`,
 		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.Status(vmName, allConfigs); err != nil {
+			if err := openEVEC.Status(vmName, allConfigs, showDiff); err != nil {
 				log.Fatal(err)
 			}
 		},
@@ -33,6 +33,7 @@ func newStatusCmd(configName, verbosity *string) *cobra.Command {
 	}
 	statusCmd.Flags().StringVarP(&cfg.Eve.Pid, "eve-pid", "", filepath.Join(currentPath, defaults.DefaultDist, "eve.pid"), "file with EVE pid")
 	statusCmd.Flags().BoolVar(&allConfigs, "all", true, "show status for all configs")
+	statusCmd.Flags().BoolVar(&showDiff, "diff", false, "show difference between config intended by controller and state reported by EVE")
 	statusCmd.Flags().StringVarP(&vmName, "vmname", "", defaults.DefaultVBoxVMName, "vbox vmname required to create vm")
 
 	addSdnPidOpt(statusCmd, cfg)
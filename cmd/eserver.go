@@ -3,7 +3,9 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/lf-edge/eden/eserver/api"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/eden"
 	"github.com/lf-edge/eden/pkg/openevec"
@@ -27,6 +29,20 @@ func newEserverCmd(configName, verbosity *string) *cobra.Command {
 				newStatusEserverCmd(cfg),
 			},
 		},
+		{
+			Message: "Storage Commands",
+			Commands: []*cobra.Command{
+				newListEserverCmd(cfg),
+				newRemoveEserverCmd(cfg),
+				newGCEserverCmd(cfg),
+			},
+		},
+		{
+			Message: "Testing Commands",
+			Commands: []*cobra.Command{
+				newFaultEserverCmd(cfg),
+			},
+		},
 	}
 
 	groups.AddTo(eserverCmd)
@@ -47,10 +63,8 @@ func newStartEserverCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 			}
 			log.Infof("Executable path: %s", command)
 
-			if err := eden.StartEServer(cfg.Eden.EServer.Port, cfg.Eden.Images.EServerImageDist, cfg.Eden.EServer.Force, cfg.Eden.EServer.Tag); err != nil {
-				log.Errorf("cannot start eserver: %s", err)
-			} else {
-				log.Infof("Eserver is running and accesible on port %d", cfg.Eden.EServer.Port)
+			if err := openEVEC.StartEServer(); err != nil {
+				log.Error(err)
 			}
 		},
 	}
@@ -59,6 +73,7 @@ func newStartEserverCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	startEserverCmd.Flags().IntVarP(&cfg.Eden.EServer.Port, "eserver-port", "", defaults.DefaultEserverPort, "eserver port")
 	startEserverCmd.Flags().StringVarP(&cfg.Eden.EServer.Tag, "eserver-tag", "", defaults.DefaultEServerTag, "tag of eserver container to pull")
 	startEserverCmd.Flags().BoolVarP(&cfg.Eden.EServer.Force, "eserver-force", "", false, "eserver force rebuild")
+	startEserverCmd.Flags().BoolVarP(&cfg.Eden.EServer.HTTPS, "eserver-https", "", cfg.Eden.EServer.HTTPS, "serve http of eserver over TLS")
 
 	return startEserverCmd
 }
@@ -98,3 +113,156 @@ func newStatusEserverCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
 	}
 	return statusEserverCmd
 }
+
+func addEserverPortOpt(parentCmd *cobra.Command, cfg *openevec.EdenSetupArgs) {
+	parentCmd.Flags().IntVarP(&cfg.Eden.EServer.Port, "eserver-port", "", defaults.DefaultEserverPort, "eserver port")
+}
+
+func newListEserverCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var listEserverCmd = &cobra.Command{
+		Use:   "ls",
+		Short: "list files stored in eserver",
+		Long:  `List files stored in eserver with sha256 and size of their content.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EServerList(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	addEserverPortOpt(listEserverCmd, cfg)
+	return listEserverCmd
+}
+
+func newRemoveEserverCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var removeEserverCmd = &cobra.Command{
+		Use:   "rm <name>...",
+		Short: "remove files from eserver",
+		Long: `Remove files from eserver.
+Content of a file is removed as well unless it is referenced by another file name.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EServerRemove(args); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	addEserverPortOpt(removeEserverCmd, cfg)
+	return removeEserverCmd
+}
+
+func newGCEserverCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var keep []string
+	var dryRun, prune bool
+
+	var gcEserverCmd = &cobra.Command{
+		Use:   "gc",
+		Short: "remove content not used by any file",
+		Long: `Remove content from eserver which is not referenced by any file name.
+With --prune, remove also files which are not referenced by images in configs
+of devices registered in the controller (Adam), except of files of EVE uploaded
+for network boot. Pruning is refused if there are more contexts.
+Use --dry-run to only print what would be removed.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EServerGC(keep, dryRun, prune); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	addEserverPortOpt(gcEserverCmd, cfg)
+	gcEserverCmd.Flags().StringSliceVar(&keep, "keep", nil, "names of files to keep even if not referenced")
+	gcEserverCmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print what would be removed")
+	gcEserverCmd.Flags().BoolVar(&prune, "prune", false, "remove also files not referenced by device configs")
+	return gcEserverCmd
+}
+
+func newFaultEserverCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var faultEserverCmd = &cobra.Command{
+		Use:   "fault",
+		Short: "manage fault injection into transfers from eserver",
+		Long: `Manage fault profiles of eserver.
+Faults of the first profile matching the requested file and the client
+are injected into the transfer (both HTTP and SFTP).`,
+	}
+	faultEserverCmd.AddCommand(newFaultAddEserverCmd(cfg))
+	faultEserverCmd.AddCommand(newFaultListEserverCmd(cfg))
+	faultEserverCmd.AddCommand(newFaultSetEserverCmd(cfg))
+	faultEserverCmd.AddCommand(newFaultClearEserverCmd(cfg))
+	return faultEserverCmd
+}
+
+func newFaultAddEserverCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var profile api.FaultProfile
+	var ttfb time.Duration
+
+	var faultAddEserverCmd = &cobra.Command{
+		Use:   "add",
+		Short: "add fault profile",
+		Long: `Add fault profile into eserver.
+Example (every 3rd request for images from 192.168.0.0/24 fails
+and the rest is limited to 1 MiB/s):
+  eden eserver fault add --files "*.qcow2" --client 192.168.0.0/24 --fail-nth 3 --throttle 1048576`,
+		Run: func(cmd *cobra.Command, args []string) {
+			profile.TTFB = api.Duration(ttfb)
+			if err := openEVEC.EServerFaultAdd(profile); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	addEserverPortOpt(faultAddEserverCmd, cfg)
+	faultAddEserverCmd.Flags().StringVar(&profile.Files, "files", "", "glob pattern of file names (all files if empty)")
+	faultAddEserverCmd.Flags().StringVar(&profile.Client, "client", "", "IP address or subnet (CIDR) of clients (all clients if empty)")
+	faultAddEserverCmd.Flags().Uint64Var(&profile.Throttle, "throttle", 0, "limit transfer rate (bytes per second)")
+	faultAddEserverCmd.Flags().Uint32Var(&profile.FailNth, "fail-nth", 0, "fail every Nth request for a file")
+	faultAddEserverCmd.Flags().Uint32Var(&profile.ErrorBurst, "error-burst", 0, "fail the given number of consecutive requests for a file")
+	faultAddEserverCmd.Flags().Uint32Var(&profile.ErrorBurstPeriod, "error-burst-period", 0, "repeat error burst every given number of requests")
+	faultAddEserverCmd.Flags().IntVar(&profile.ErrorStatus, "error-status", 0, "HTTP status of failed requests (503 if not set)")
+	faultAddEserverCmd.Flags().Uint64Var(&profile.DropAfter, "drop-after", 0, "close connection after the given number of bytes")
+	faultAddEserverCmd.Flags().BoolVar(&profile.Corrupt, "corrupt", false, "corrupt one byte in every MiB of content")
+	faultAddEserverCmd.Flags().DurationVar(&ttfb, "ttfb", 0, "delay before the response (time to first byte)")
+	return faultAddEserverCmd
+}
+
+func newFaultListEserverCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var faultListEserverCmd = &cobra.Command{
+		Use:   "ls",
+		Short: "list fault profiles",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EServerFaultList(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	addEserverPortOpt(faultListEserverCmd, cfg)
+	return faultListEserverCmd
+}
+
+func newFaultSetEserverCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var faultSetEserverCmd = &cobra.Command{
+		Use:   "set <file>",
+		Short: "replace fault profiles with profiles from JSON file",
+		Long: `Replace fault profiles of eserver with the JSON list of profiles from the file.
+Request counters of profiles are reset.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EServerFaultSet(args[0]); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	addEserverPortOpt(faultSetEserverCmd, cfg)
+	return faultSetEserverCmd
+}
+
+func newFaultClearEserverCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var faultClearEserverCmd = &cobra.Command{
+		Use:   "clear",
+		Short: "remove all fault profiles",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EServerFaultClear(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+	addEserverPortOpt(faultClearEserverCmd, cfg)
+	return faultClearEserverCmd
+}
@@ -15,9 +15,10 @@ import (
 
 func newEveCmd(configName, verbosity *string) *cobra.Command {
 	cfg := &openevec.EdenSetupArgs{}
+	var eveInstance string
 	var eveCmd = &cobra.Command{
 		Use:               "eve",
-		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
+		PersistentPreRunE: preRunEveInstanceFunction(cfg, configName, verbosity, &eveInstance),
 	}
 	groups := CommandGroups{
 		{
@@ -40,6 +41,10 @@ func newEveCmd(configName, verbosity *string) *cobra.Command {
 
 	groups.AddTo(eveCmd)
 
+	eveCmd.PersistentFlags().StringVar(&eveInstance, "eve-instance", "",
+		"select EVE instance by its logical label (see sdn.eve-instance), "+
+			"overrides config selected with --config")
+
 	return eveCmd
 }
 
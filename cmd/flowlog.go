@@ -13,6 +13,8 @@ func newNetStatCmd(configName, verbosity *string) *cobra.Command {
 	var outputFormat types.OutputFormat
 	var follow bool
 	var printFields []string
+	var query string
+	var since, until string
 	var logTail uint
 
 	var netStatCmd = &cobra.Command{
@@ -22,7 +24,7 @@ func newNetStatCmd(configName, verbosity *string) *cobra.Command {
 (TCP and UDP flows with IP addresses, port numbers, counters, whether dropped or accepted)`,
 		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.EdenNetStat(outputFormat, follow, logTail, printFields, args); err != nil {
+			if err := openEVEC.EdenNetStat(outputFormat, follow, logTail, printFields, query, since, until, args); err != nil {
 				log.Fatalf("Setup eden failed: %s", err)
 			}
 		},
@@ -30,6 +32,9 @@ func newNetStatCmd(configName, verbosity *string) *cobra.Command {
 
 	netStatCmd.Flags().UintVar(&logTail, "tail", 0, "Show only last N lines")
 	netStatCmd.Flags().StringSliceVarP(&printFields, "out", "o", nil, "Fields to print. Whole message if empty.")
+	netStatCmd.Flags().StringVarP(&query, "query", "q", "", "Expression to filter by, combined with field:regexp arguments (see docs/data-from-eve.md)")
+	netStatCmd.Flags().StringVar(&since, "since", "", "Show items created since the time: RFC 3339, '2006-01-02 15:04', '15:04', duration ago like '30m' or 'boot' for the last boot of EVE")
+	netStatCmd.Flags().StringVar(&until, "until", "", "Show items created until the time: RFC 3339, '2006-01-02 15:04', '15:04' or duration ago like '30m'")
 	netStatCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Monitor changes in selected directory")
 	netStatCmd.Flags().Var(enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive), "format", "Format to print logs, supports: lines, json")
 
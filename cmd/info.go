@@ -12,13 +12,15 @@ func newInfoCmd() *cobra.Command {
 	var infoTail uint
 	var follow bool
 	var printFields []string
+	var query string
+	var since, until string
 
 	var infoCmd = &cobra.Command{
 		Use:   "info [field:regexp ...]",
 		Short: "Get information reports from a running EVE device",
 		Long:  ` Scans the ADAM Info for correspondence with regular expressions requests to json fields.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.EdenInfo(outputFormat, infoTail, follow, printFields, args); err != nil {
+			if err := openEVEC.EdenInfo(outputFormat, infoTail, follow, printFields, query, since, until, args); err != nil {
 				log.Fatal("Eden info failed ", err)
 			}
 		},
@@ -27,6 +29,9 @@ func newInfoCmd() *cobra.Command {
 	infoCmd.Flags().UintVar(&infoTail, "tail", 0, "Show only last N lines")
 	infoCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Monitor changes in selected directory")
 	infoCmd.Flags().StringSliceVarP(&printFields, "out", "o", nil, "Fields to print. Whole message if empty.")
+	infoCmd.Flags().StringVarP(&query, "query", "q", "", "Expression to filter by, combined with field:regexp arguments (see docs/data-from-eve.md)")
+	infoCmd.Flags().StringVar(&since, "since", "", "Show items created since the time: RFC 3339, '2006-01-02 15:04', '15:04', duration ago like '30m' or 'boot' for the last boot of EVE")
+	infoCmd.Flags().StringVar(&until, "until", "", "Show items created until the time: RFC 3339, '2006-01-02 15:04', '15:04' or duration ago like '30m'")
 
 	infoCmd.Flags().Var(
 		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
@@ -16,6 +16,8 @@ func newLogCmd() *cobra.Command {
 	var outputFormat types.OutputFormat
 	var follow bool
 	var printFields []string
+	var query string
+	var since, until string
 	var logTail uint
 
 	var logCmd = &cobra.Command{
@@ -23,7 +25,7 @@ func newLogCmd() *cobra.Command {
 		Short: "Get logs from a running EVE device",
 		Long:  ` Scans the ADAM logs for correspondence with regular expressions requests to json fields.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.EdenLog(outputFormat, follow, logTail, printFields, args); err != nil {
+			if err := openEVEC.EdenLog(outputFormat, follow, logTail, printFields, query, since, until, args); err != nil {
 				log.Fatalf("Log eden failed: %s", err)
 			}
 		},
@@ -31,6 +33,9 @@ func newLogCmd() *cobra.Command {
 
 	logCmd.Flags().UintVar(&logTail, "tail", 0, "Show only last N lines")
 	logCmd.Flags().StringSliceVarP(&printFields, "out", "o", nil, "Fields to print. Whole message if empty.")
+	logCmd.Flags().StringVarP(&query, "query", "q", "", "Expression to filter by, combined with field:regexp arguments (see docs/data-from-eve.md)")
+	logCmd.Flags().StringVar(&since, "since", "", "Show items created since the time: RFC 3339, '2006-01-02 15:04', '15:04', duration ago like '30m' or 'boot' for the last boot of EVE")
+	logCmd.Flags().StringVar(&until, "until", "", "Show items created until the time: RFC 3339, '2006-01-02 15:04', '15:04' or duration ago like '30m'")
 	logCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Monitor changes in selected directory")
 
 	logCmd.Flags().Var(
@@ -13,6 +13,8 @@ func newMetricCmd(configName, verbosity *string) *cobra.Command {
 	var outputFormat types.OutputFormat
 	var follow bool
 	var printFields []string
+	var query string
+	var since, until string
 	var metricTail uint
 
 	var metricCmd = &cobra.Command{
@@ -22,7 +24,7 @@ func newMetricCmd(configName, verbosity *string) *cobra.Command {
 Scans the ADAM metrics for correspondence with regular expressions requests to json fields.`,
 		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
 		Run: func(cmd *cobra.Command, args []string) {
-			if err := openEVEC.EdenMetric(outputFormat, follow, metricTail, printFields, args); err != nil {
+			if err := openEVEC.EdenMetric(outputFormat, follow, metricTail, printFields, query, since, until, args); err != nil {
 				log.Fatalf("Metric eden failed: %s", err)
 			}
 		},
@@ -30,11 +32,43 @@ Scans the ADAM metrics for correspondence with regular expressions requests to j
 
 	metricCmd.Flags().UintVar(&metricTail, "tail", 0, "Show only last N lines")
 	metricCmd.Flags().StringSliceVarP(&printFields, "out", "o", nil, "Fields to print. Whole message if empty.")
+	metricCmd.Flags().StringVarP(&query, "query", "q", "", "Expression to filter by, combined with field:regexp arguments (see docs/data-from-eve.md)")
+	metricCmd.Flags().StringVar(&since, "since", "", "Show items created since the time: RFC 3339, '2006-01-02 15:04', '15:04', duration ago like '30m' or 'boot' for the last boot of EVE")
+	metricCmd.Flags().StringVar(&until, "until", "", "Show items created until the time: RFC 3339, '2006-01-02 15:04', '15:04' or duration ago like '30m'")
 	metricCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Monitor changes in selected metrics")
 
 	metricCmd.Flags().Var(
 		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
 		"format",
 		"Format to print logs, supports: lines, json")
+
+	metricCmd.AddCommand(newMetricExportCmd())
 	return metricCmd
 }
+
+func newMetricExportCmd() *cobra.Command {
+	var listenAddr string
+	var allDevices bool
+	var query string
+	var since, until string
+
+	var metricExportCmd = &cobra.Command{
+		Use:   "export [field:regexp ...]",
+		Short: "Serve metrics of EVE device for Prometheus",
+		Long: `
+Serves the last metrics of EVE device received by controller on HTTP /metrics endpoint
+in Prometheus text format. Device, application, network instance and volume metrics are exported.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EdenMetricExport(listenAddr, allDevices, query, since, until, args); err != nil {
+				log.Fatalf("Metric export eden failed: %s", err)
+			}
+		},
+	}
+
+	metricExportCmd.Flags().StringVar(&listenAddr, "listen", ":9101", "Address to serve /metrics on")
+	metricExportCmd.Flags().BoolVar(&allDevices, "all", false, "Export metrics of all devices registered in controller")
+	metricExportCmd.Flags().StringVarP(&query, "query", "q", "", "Expression to filter by, combined with field:regexp arguments (see docs/data-from-eve.md)")
+	metricExportCmd.Flags().StringVar(&since, "since", "", "Ignore metrics created before the time: RFC 3339, '2006-01-02 15:04', '15:04', duration ago like '30m' or 'boot' for the last boot of EVE")
+	metricExportCmd.Flags().StringVar(&until, "until", "", "Ignore metrics created after the time: RFC 3339, '2006-01-02 15:04', '15:04' or duration ago like '30m'")
+	return metricExportCmd
+}
@@ -45,6 +45,7 @@ func NewEdenCommand() *cobra.Command {
 				newLogCmd(),
 				newNetStatCmd(&configName, &verbosity),
 				newMetricCmd(&configName, &verbosity),
+				newTelemetryCmd(&configName, &verbosity),
 				newAdamCmd(&configName, &verbosity),
 				newRegistryCmd(&configName, &verbosity),
 				newRedisCmd(&configName, &verbosity),
@@ -82,6 +83,21 @@ func preRunViperLoadFunction(cfg *openevec.EdenSetupArgs, configName, verbosity
 	}
 }
 
+// preRunEveInstanceFunction selects config (context) managing the given EVE instance
+// (if requested) and then loads it.
+func preRunEveInstanceFunction(cfg *openevec.EdenSetupArgs, configName, verbosity, eveInstance *string) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if *eveInstance != "" {
+			instanceConfig, err := openevec.FindEveInstanceConfig(*eveInstance)
+			if err != nil {
+				return err
+			}
+			*configName = instanceConfig
+		}
+		return preRunViperLoadFunction(cfg, configName, verbosity)(cmd, args)
+	}
+}
+
 // Execute primary function for cobra
 func Execute() {
 	rootCmd := NewEdenCommand()
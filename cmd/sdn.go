@@ -5,9 +5,11 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/openevec"
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
@@ -18,10 +20,11 @@ var (
 
 func newSdnCmd(configName, verbosity *string) *cobra.Command {
 	cfg := &openevec.EdenSetupArgs{}
+	var eveInstance string
 	var sdnCmd = &cobra.Command{
 		Use:               "sdn",
 		Short:             "Emulate and manage networks surrounding EVE VM using Eden-SDN",
-		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
+		PersistentPreRunE: preRunEveInstanceFunction(cfg, configName, verbosity, &eveInstance),
 	}
 
 	groups := CommandGroups{
@@ -35,7 +38,10 @@ func newSdnCmd(configName, verbosity *string) *cobra.Command {
 				newSdnLogsCmd(cfg),
 				newSdnMgmtIPCmd(cfg),
 				newSdnEndpointCmd(cfg),
-				newSdnFwdCmd(cfg),
+				newSdnFwdCmd(cfg, &eveInstance),
+				newSdnChaosCmd(cfg),
+				newSdnPcapCmd(cfg),
+				newSdnCaptivePortalCmd(cfg),
 			},
 		},
 	}
@@ -241,7 +247,7 @@ the EVE's port forwarding capability.`,
 	return sdnEpExecCmd
 }
 
-func newSdnFwdCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+func newSdnFwdCmd(cfg *openevec.EdenSetupArgs, eveInstance *string) *cobra.Command {
 	var sdnFwdCmd = &cobra.Command{
 		Use:   "fwd <target-eve-interface> <target-port> -- <command> [args...]",
 		Short: "Execute command aimed at a given EVE interface and a port",
@@ -262,6 +268,10 @@ The command can also be run from an endpoint deployed inside Eden-SDN, for examp
 	eden sdn fwd --from-ep my-client eth0 2222 nc FWD_IP FWD_PORT
 Note that in this case the command must be installed in Eden-SDN VM (see sdn/Dockerfile)!
 
+With multiple EVE instances connected to the same Eden-SDN, select the target instance
+by its logical label (or by the Eden config which manages it, using --config):
+	eden sdn fwd --eve-instance eve2 eth0 2222 ssh -I ./dist/tests/eclient/image/cert/id_rsa root@FWD_IP FWD_PORT
+
 The target interface should be referenced by its name inside the kernel of EVE VM (e.g. "eth0").
 This is currently limited to TCP port forwarding (i.e. not working with UDP)!`,
 		Args: cobra.MinimumNArgs(3),
@@ -285,10 +295,266 @@ This is currently limited to TCP port forwarding (i.e. not working with UDP)!`,
 	sdnFwdCmd.Flags().StringVarP(&sdnFwdFromEp, "from-ep", "", "",
 		"run port-forwarded command from inside of the given Eden-SDN endpoint "+
 			"(referenced by logical label)")
+	sdnFwdCmd.Flags().StringVarP(eveInstance, "eve-instance", "", "",
+		"target EVE instance referenced by its logical label (see sdn.eve-instance), "+
+			"overrides config selected with --config")
 
 	return sdnFwdCmd
 }
 
+func newSdnChaosCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var sdnChaosCmd = &cobra.Command{
+		Use:   "chaos",
+		Short: "Inject network faults into the running Eden-SDN according to a timeline",
+		Long: `Inject network faults into the running Eden-SDN according to a timeline.
+Chaos scenario is a list of steps, each injecting a fault (traffic control, link down,
+link flapping, blackhole) at a given time (relative to the scenario start) and for a given
+duration. Steps are executed by the SDN agent and the network model is not changed.
+See sdn/vm/api/chaos.go to learn about all kinds of supported faults.`,
+	}
+
+	groups := CommandGroups{
+		{
+			Message: "Basic Commands",
+			Commands: []*cobra.Command{
+				newSdnChaosRunCmd(cfg),
+				newSdnChaosStatusCmd(cfg),
+				newSdnChaosStopCmd(cfg),
+			},
+		},
+	}
+
+	groups.AddTo(sdnChaosCmd)
+
+	return sdnChaosCmd
+}
+
+func newSdnChaosRunCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var detach bool
+	var sdnChaosRunCmd = &cobra.Command{
+		Use:   "run <scenario.yaml>",
+		Short: "Run chaos scenario (stops the previously running scenario)",
+		Long: `Run chaos scenario (stops the previously running scenario).
+Scenario is loaded from a YAML (or JSON) file, for example:
+
+	name: uplink-failover
+	steps:
+	  - at: 30s
+	    for: 2m
+	    trafficControl:
+	      port: eth1
+	      lossProbability: 50
+	  - at: 2m30s
+	    for: 1m
+	    linkFlap:
+	      port: eth1
+	      interval: 10s
+	  - at: 3m30s
+	    for: 1m
+	    blackhole:
+	      endpoint: my-dns-server
+	      protocol: udp
+	      ports: [53]
+
+By default, the command waits for the scenario to finish and prints every executed step.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.SdnChaosRun(args[0], detach); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	addSdnPortOpts(sdnChaosRunCmd, cfg)
+	sdnChaosRunCmd.Flags().BoolVarP(&detach, "detach", "d", false,
+		"do not wait for the scenario to finish")
+
+	return sdnChaosRunCmd
+}
+
+func newSdnChaosStatusCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var sdnChaosStatusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "Get status and timeline of executed steps of the last chaos scenario",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.SdnChaosStatus(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	addSdnPortOpts(sdnChaosStatusCmd, cfg)
+
+	return sdnChaosStatusCmd
+}
+
+func newSdnChaosStopCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var sdnChaosStopCmd = &cobra.Command{
+		Use:   "stop",
+		Short: "Stop running chaos scenario and revert all injected faults",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.SdnChaosStop(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	addSdnPortOpts(sdnChaosStopCmd, cfg)
+
+	return sdnChaosStopCmd
+}
+
+func newSdnPcapCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var sdnPcapCmd = &cobra.Command{
+		Use:   "pcap",
+		Short: "Capture packets inside the running Eden-SDN",
+		Long: `Capture packets inside the running Eden-SDN.
+Packets can be captured on a port, bridge, network or an endpoint (selected by logical label).
+Capture is run by the SDN agent using tcpdump and stored inside the SDN VM until removed.
+Captured packets are retrieved over SSH.`,
+	}
+
+	groups := CommandGroups{
+		{
+			Message: "Basic Commands",
+			Commands: []*cobra.Command{
+				newSdnPcapStartCmd(cfg),
+				newSdnPcapListCmd(cfg),
+				newSdnPcapStopCmd(cfg),
+				newSdnPcapGetCmd(cfg),
+				newSdnPcapRemoveCmd(cfg),
+			},
+		},
+	}
+
+	groups.AddTo(sdnPcapCmd)
+
+	return sdnPcapCmd
+}
+
+func newSdnPcapStartCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var capture sdnapi.PacketCapture
+	var maxSize, outputFile string
+	var duration time.Duration
+	var sdnPcapStartCmd = &cobra.Command{
+		Use:   "start",
+		Short: "Start packet capture and print its ID",
+		Long: `Start packet capture and print its ID.
+Exactly one of --port, --bridge, --network, --endpoint must be specified.
+With --output, captured packets are streamed into the given file ("-" for stdout)
+until the capture stops (see --duration, --max-size, --max-packets), for example:
+
+	eden sdn pcap start --network network0 --filter "udp port 53" --duration 1m -o dns.pcap
+	eden sdn pcap start --port eth0 -o - | wireshark -k -i -`,
+		Run: func(cmd *cobra.Command, args []string) {
+			capture.Duration = sdnapi.Duration(duration)
+			if err := openEVEC.SdnPcapStart(capture, maxSize, outputFile); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	addSdnPortOpts(sdnPcapStartCmd, cfg)
+	sdnPcapStartCmd.Flags().StringVar(&capture.Port, "port", "",
+		"logical label of the port to capture packets on")
+	sdnPcapStartCmd.Flags().StringVar(&capture.Bridge, "bridge", "",
+		"logical label of the bridge to capture packets on")
+	sdnPcapStartCmd.Flags().StringVar(&capture.Network, "network", "",
+		"logical label of the network to capture packets on")
+	sdnPcapStartCmd.Flags().StringVar(&capture.Endpoint, "endpoint", "",
+		"logical label of the endpoint to capture packets on")
+	sdnPcapStartCmd.Flags().StringVarP(&capture.Filter, "filter", "f", "",
+		"packet filter expression (BPF syntax, see pcap-filter(7))")
+	sdnPcapStartCmd.Flags().Uint32Var(&capture.SnapLen, "snaplen", 0,
+		"number of bytes to capture from each packet (0 - tcpdump default)")
+	sdnPcapStartCmd.Flags().StringVar(&maxSize, "max-size", "",
+		"stop capture when the pcap file reaches this size (empty - 100MiB)")
+	sdnPcapStartCmd.Flags().Uint32Var(&capture.MaxPackets, "max-packets", 0,
+		"stop capture after this number of packets (0 - no limit)")
+	sdnPcapStartCmd.Flags().DurationVar(&duration, "duration", 0,
+		"stop capture after this duration (0 - no limit)")
+	sdnPcapStartCmd.Flags().StringVarP(&outputFile, "output", "o", "",
+		"stream captured packets into this file (\"-\" for stdout) until the capture stops")
+
+	return sdnPcapStartCmd
+}
+
+func newSdnPcapListCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var sdnPcapListCmd = &cobra.Command{
+		Use:   "ls",
+		Short: "List packet captures",
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.SdnPcapList(); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	addSdnPortOpts(sdnPcapListCmd, cfg)
+
+	return sdnPcapListCmd
+}
+
+func newSdnPcapStopCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var sdnPcapStopCmd = &cobra.Command{
+		Use:   "stop <id>",
+		Short: "Stop packet capture (captured packets are kept until removed)",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.SdnPcapStop(args[0]); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	addSdnPortOpts(sdnPcapStopCmd, cfg)
+
+	return sdnPcapStopCmd
+}
+
+func newSdnPcapGetCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var outputFile string
+	var follow bool
+	var sdnPcapGetCmd = &cobra.Command{
+		Use:   "get <id>",
+		Short: "Download captured packets (pcap file)",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if outputFile == "" {
+				outputFile = args[0] + ".pcap"
+			}
+			if err := openEVEC.SdnPcapGet(args[0], outputFile, follow); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	addSdnPortOpts(sdnPcapGetCmd, cfg)
+	sdnPcapGetCmd.Flags().StringVarP(&outputFile, "output", "o", "",
+		"output file (\"-\" for stdout, empty - <id>.pcap)")
+	sdnPcapGetCmd.Flags().BoolVar(&follow, "follow", false,
+		"keep streaming captured packets until the capture stops")
+
+	return sdnPcapGetCmd
+}
+
+func newSdnPcapRemoveCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var sdnPcapRemoveCmd = &cobra.Command{
+		Use:   "rm <id>",
+		Short: "Remove packet capture (stopping it first if still running)",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.SdnPcapRemove(args[0]); err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	addSdnPortOpts(sdnPcapRemoveCmd, cfg)
+
+	return sdnPcapRemoveCmd
+}
+
 func addSdnPidOpt(parentCmd *cobra.Command, cfg *openevec.EdenSetupArgs) {
 	currentPath, err := os.Getwd()
 	if err != nil {
@@ -306,6 +572,79 @@ func addSdnVmOpts(parentCmd *cobra.Command, cfg *openevec.EdenSetupArgs) {
 	parentCmd.Flags().IntVarP(&cfg.Sdn.CPU, "sdn-cpu", "", defaults.DefaultSdnCpus, "CPU count for SDN VM")
 }
 
+func newSdnCaptivePortalCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var sdnCaptivePortalCmd = &cobra.Command{
+		Use:   "captive-portal",
+		Short: "Log clients into (or out of) a captive portal endpoint",
+		Long: `Log clients into (or out of) a captive portal endpoint.
+Captive portal intercepts traffic of clients from networks referencing the portal
+until they log in. Client is identified by IP or MAC address. By default, the client
+is EVE, identified by the MAC address of the selected EVE interface.
+Logged-in clients are listed by "eden sdn status".`,
+	}
+
+	groups := CommandGroups{
+		{
+			Message: "Basic Commands",
+			Commands: []*cobra.Command{
+				newSdnCaptivePortalLoginCmd(cfg),
+				newSdnCaptivePortalLogoutCmd(cfg),
+			},
+		},
+	}
+
+	groups.AddTo(sdnCaptivePortalCmd)
+
+	return sdnCaptivePortalCmd
+}
+
+func newSdnCaptivePortalLoginCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var portalClient, eveIfName string
+	var sdnCaptivePortalLoginCmd = &cobra.Command{
+		Use:   "login <portal>",
+		Short: "Log client into the captive portal (stop intercepting its traffic)",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := openEVEC.SdnCaptivePortalLogin(args[0], portalClient, eveIfName)
+			if err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	addSdnPortOpts(sdnCaptivePortalLoginCmd, cfg)
+	addSdnCaptivePortalClientOpts(sdnCaptivePortalLoginCmd, &portalClient, &eveIfName)
+
+	return sdnCaptivePortalLoginCmd
+}
+
+func newSdnCaptivePortalLogoutCmd(cfg *openevec.EdenSetupArgs) *cobra.Command {
+	var portalClient, eveIfName string
+	var sdnCaptivePortalLogoutCmd = &cobra.Command{
+		Use:   "logout <portal>",
+		Short: "Log client out of the captive portal (resume intercepting its traffic)",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := openEVEC.SdnCaptivePortalLogout(args[0], portalClient, eveIfName)
+			if err != nil {
+				log.Fatal(err)
+			}
+		},
+	}
+
+	addSdnPortOpts(sdnCaptivePortalLogoutCmd, cfg)
+	addSdnCaptivePortalClientOpts(sdnCaptivePortalLogoutCmd, &portalClient, &eveIfName)
+
+	return sdnCaptivePortalLogoutCmd
+}
+
+func addSdnCaptivePortalClientOpts(parentCmd *cobra.Command, portalClient, eveIfName *string) {
+	parentCmd.Flags().StringVar(portalClient, "client", "",
+		"IP or MAC address of the client (default is MAC address of the EVE interface)")
+	parentCmd.Flags().StringVar(eveIfName, "eve-if", "eth0",
+		"EVE interface to log in/out (used if --client is not specified)")
+}
+
 func addSdnPortOpts(parentCmd *cobra.Command, cfg *openevec.EdenSetupArgs) {
 	parentCmd.Flags().IntVarP(&cfg.Sdn.TelnetPort, "sdn-telnet-port", "", defaults.DefaultSdnTelnetPort, "port for telnet (console access) to SDN VM")
 	parentCmd.Flags().IntVarP(&cfg.Sdn.MgmtPort, "sdn-mgmt-port", "", defaults.DefaultSdnMgmtPort, "port for access to the management agent running inside SDN VM")
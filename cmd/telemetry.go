@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/openevec"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/thediveo/enumflag"
+)
+
+func newTelemetryCmd(configName, verbosity *string) *cobra.Command {
+	cfg := &openevec.EdenSetupArgs{}
+	var telemetryCmd = &cobra.Command{
+		Use:               "telemetry",
+		Short:             "Work with telemetry bundles of EVE device",
+		PersistentPreRunE: preRunViperLoadFunction(cfg, configName, verbosity),
+	}
+
+	groups := CommandGroups{
+		{
+			Message: "Basic Commands",
+			Commands: []*cobra.Command{
+				newTelemetryRecordCmd(),
+				newTelemetryReplayCmd(verbosity),
+			},
+		},
+	}
+
+	groups.AddTo(telemetryCmd)
+
+	return telemetryCmd
+}
+
+func newTelemetryRecordCmd() *cobra.Command {
+	var bundleFile string
+	var follow time.Duration
+	var since, until string
+
+	var recordCmd = &cobra.Command{
+		Use:   "record",
+		Short: "Record logs, info, metrics, flow logs, requests and app logs of EVE device into bundle",
+		Long: `
+Saves every object of the device which controller holds into one compressed bundle with index.
+The bundle can be replayed later with "eden telemetry replay" or by setting adam.replay option of config to its path.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EdenTelemetryRecord(bundleFile, follow, since, until); err != nil {
+				log.Fatalf("Telemetry record failed: %s", err)
+			}
+		},
+	}
+
+	recordCmd.Flags().StringVarP(&bundleFile, "out", "o", "", "File to save bundle into, telemetry-<device>-<time>.tar.gz if empty")
+	recordCmd.Flags().DurationVarP(&follow, "follow", "f", 0, "Record new objects during this time after existing ones")
+	recordCmd.Flags().StringVar(&since, "since", "", "Record objects created since the time: RFC 3339, '2006-01-02 15:04', '15:04', duration ago like '30m' or 'boot' for the last boot of EVE")
+	recordCmd.Flags().StringVar(&until, "until", "", "Record objects created until the time: RFC 3339, '2006-01-02 15:04', '15:04' or duration ago like '30m'")
+	return recordCmd
+}
+
+func newTelemetryReplayCmd(verbosity *string) *cobra.Command {
+	var outputFormat types.OutputFormat
+	var telemetryType, appID string
+	var printFields []string
+	var query string
+	var since, until string
+
+	var replayCmd = &cobra.Command{
+		Use:   "replay <bundle> [field:regexp ...]",
+		Short: "Print logs, info, metrics, flow logs, requests or app logs from telemetry bundle",
+		Long: `
+Prints objects of the type from telemetry bundle recorded with "eden telemetry record" in order of their timestamps.
+Only the bundle is read, so neither controller nor EVE are required.`,
+		Args: cobra.MinimumNArgs(1),
+		// config of eden is not required to read the bundle
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			openEVEC = openevec.CreateOpenEVEC(&openevec.EdenSetupArgs{})
+			return openevec.SetUpLogs(*verbosity)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := openEVEC.EdenTelemetryReplay(args[0], telemetryType, appID, outputFormat, printFields, query, since, until, args[1:]); err != nil {
+				log.Fatalf("Telemetry replay failed: %s", err)
+			}
+		},
+	}
+
+	replayCmd.Flags().StringVarP(&telemetryType, "type", "t", openevec.TelemetryLog,
+		fmt.Sprintf("Type of objects to print: %s", strings.Join([]string{openevec.TelemetryLog, openevec.TelemetryInfo,
+			openevec.TelemetryMetric, openevec.TelemetryFlowLog, openevec.TelemetryRequest, openevec.TelemetryApp}, ", ")))
+	replayCmd.Flags().StringVar(&appID, "app", "", "UUID of application to print logs of with app type")
+	replayCmd.Flags().StringSliceVarP(&printFields, "out", "o", nil, "Fields to print. Whole message if empty.")
+	replayCmd.Flags().StringVarP(&query, "query", "q", "", "Expression to filter by, combined with field:regexp arguments (see docs/data-from-eve.md)")
+	replayCmd.Flags().StringVar(&since, "since", "", "Show items created since the time: RFC 3339, '2006-01-02 15:04', '15:04', duration ago like '30m' or 'boot' for the last boot of EVE")
+	replayCmd.Flags().StringVar(&until, "until", "", "Show items created until the time: RFC 3339, '2006-01-02 15:04', '15:04' or duration ago like '30m'")
+	replayCmd.Flags().Var(
+		enumflag.New(&outputFormat, "format", outputFormatIds, enumflag.EnumCaseInsensitive),
+		"format",
+		"Format to print objects, supports: lines, json")
+	return replayCmd
+}
@@ -0,0 +1,24 @@
+package api
+
+// Environment variables with credentials of eserver, used if not set with flags
+// to keep them out of the command line of the server
+const (
+	//EnvHTTPUser is user for basic authorization of file requests
+	EnvHTTPUser = "ESERVER_HTTP_USER"
+	//EnvHTTPPassword is password for basic authorization of file requests
+	EnvHTTPPassword = "ESERVER_HTTP_PASSWORD"
+	//EnvHTTPToken is token for bearer authorization of file requests
+	EnvHTTPToken = "ESERVER_HTTP_TOKEN"
+	//EnvAdminUser is user for basic authorization of admin requests
+	EnvAdminUser = "ESERVER_ADMIN_USER"
+	//EnvAdminPassword is password for basic authorization of admin requests
+	EnvAdminPassword = "ESERVER_ADMIN_PASSWORD"
+	//EnvS3AccessKey is access key for S3 emulation
+	EnvS3AccessKey = "ESERVER_S3_ACCESS_KEY"
+	//EnvS3SecretKey is secret key for S3 emulation
+	EnvS3SecretKey = "ESERVER_S3_SECRET_KEY"
+	//EnvAzureAccount is storage account name for Azure Blob emulation
+	EnvAzureAccount = "ESERVER_AZURE_ACCOUNT"
+	//EnvAzureKey is base64-encoded storage account key for Azure Blob emulation
+	EnvAzureKey = "ESERVER_AZURE_KEY"
+)
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// FaultProfile defines faults injected by eserver into transfers (HTTP and SFTP)
+// of matching files requested by matching clients
+type FaultProfile struct {
+	//Files is a glob pattern (see path.Match) of file names the profile applies to
+	//(all files if empty)
+	Files string `json:"files,omitempty"`
+	//Client is IP address or subnet (CIDR) of clients the profile applies to
+	//(all clients if empty)
+	Client string `json:"client,omitempty"`
+	//Throttle limits transfer rate of file content (bytes per second)
+	Throttle uint64 `json:"throttle,omitempty"`
+	//FailNth makes every Nth request for a file fail
+	//(requests are counted per profile, file and client)
+	FailNth uint32 `json:"failNth,omitempty"`
+	//ErrorBurst makes the given number of consecutive requests for a file fail,
+	//starting with the first request
+	ErrorBurst uint32 `json:"errorBurst,omitempty"`
+	//ErrorBurstPeriod repeats the error burst every given number of requests
+	//(burst is not repeated if zero)
+	ErrorBurstPeriod uint32 `json:"errorBurstPeriod,omitempty"`
+	//ErrorStatus is HTTP status of failed requests (503 by default)
+	//SFTP requests fail with a generic failure
+	ErrorStatus int `json:"errorStatus,omitempty"`
+	//DropAfter closes connection after the given number of bytes of file content
+	DropAfter uint64 `json:"dropAfter,omitempty"`
+	//Corrupt inverts one byte in every MiB of file content (starting with the first byte),
+	//so that the checksum of the transferred file does not match
+	Corrupt bool `json:"corrupt,omitempty"`
+	//TTFB delays the response (time to first byte)
+	TTFB Duration `json:"ttfb,omitempty"`
+}
+
+// Duration is time.Duration marshalled to JSON as a string (e.g. "1m30s")
+type Duration time.Duration
+
+// String returns duration formatted by time.Duration.
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalJSON marshals duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON un-marshals duration from a string (or a number of seconds).
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case float64:
+		*d = Duration(time.Duration(v * float64(time.Second)))
+		return nil
+	case string:
+		duration, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*d = Duration(duration)
+		return nil
+	default:
+		return fmt.Errorf("invalid duration: %s", string(b))
+	}
+}
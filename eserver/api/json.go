@@ -1,17 +1,34 @@
 package api
 
+import "time"
+
 //URLArg is packet to send into eserver for downloading of external file
 type URLArg struct {
 	//URL contains link to file
 	URL string `json:"url,omitempty"`
+	//Insecure disables verification of the server certificate
+	Insecure bool `json:"insecure,omitempty"`
+	//CACert is PEM-encoded certificate of CA to verify the server certificate with
+	CACert string `json:"caCert,omitempty"`
+	//Username and Password are used for basic authentication
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	//Token is used for bearer authentication
+	Token string `json:"token,omitempty"`
 }
 
 //FileInfo contains information about downloading or downloaded file
 type FileInfo struct {
 	//Sha256 of file
 	Sha256 string `json:"sha256,omitempty"`
-	//Size of file in bytes
+	//Size of file in bytes (downloaded bytes if not ready)
 	Size int64 `json:"size,omitempty"`
+	//TotalSize is expected size of file being downloaded (if known)
+	TotalSize int64 `json:"totalSize,omitempty"`
+	//ETASeconds is estimated time to finish download
+	ETASeconds int64 `json:"etaSeconds,omitempty"`
+	//JobID is ID of the download job
+	JobID string `json:"jobID,omitempty"`
 	//FileName is link for access file
 	FileName string `json:"filename,omitempty"`
 	//ISReady indicates status of image
@@ -19,3 +36,65 @@ type FileInfo struct {
 	//Error contains errors
 	Error string `json:"error,omitempty"`
 }
+
+//GCArg is packet to send into eserver for garbage collection
+type GCArg struct {
+	//Keep contains names of files to keep if Prune is set
+	Keep []string `json:"keep,omitempty"`
+	//Prune enables removal of files not listed in Keep
+	Prune bool `json:"prune,omitempty"`
+	//DryRun only reports what would be removed
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+//GCResult contains information about files and blobs removed by garbage collection
+type GCResult struct {
+	//RemovedFiles contains names of removed files
+	RemovedFiles []string `json:"removedFiles,omitempty"`
+	//RemovedBlobs contains sha256 of removed blobs
+	RemovedBlobs []string `json:"removedBlobs,omitempty"`
+	//FreedBytes is the total size of removed blobs
+	FreedBytes int64 `json:"freedBytes,omitempty"`
+}
+
+//JobState is state of download job
+type JobState string
+
+const (
+	//JobQueued : download waits for a free slot
+	JobQueued JobState = "queued"
+	//JobRunning : download is running (possibly retrying)
+	JobRunning JobState = "running"
+	//JobDone : file was downloaded
+	JobDone JobState = "done"
+	//JobFailed : download failed
+	JobFailed JobState = "failed"
+	//JobCanceled : download was canceled
+	JobCanceled JobState = "canceled"
+)
+
+//DownloadJob contains information about download of file from URL
+type DownloadJob struct {
+	//ID of the job
+	ID string `json:"id"`
+	//Name of the file
+	Name string `json:"name"`
+	//URL to download file from
+	URL string `json:"url"`
+	//State of the job
+	State JobState `json:"state"`
+	//Downloaded bytes
+	Downloaded int64 `json:"downloaded"`
+	//TotalSize of file (if known)
+	TotalSize int64 `json:"totalSize,omitempty"`
+	//ETASeconds is estimated time to finish download
+	ETASeconds int64 `json:"etaSeconds,omitempty"`
+	//Attempts to download file
+	Attempts int `json:"attempts,omitempty"`
+	//CreatedAt is time of job creation
+	CreatedAt time.Time `json:"createdAt"`
+	//FinishedAt is time when the job finished (succeeded, failed or was canceled)
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	//Error of failed job
+	Error string `json:"error,omitempty"`
+}
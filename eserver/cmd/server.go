@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/lf-edge/eden/eserver/api"
 	"github.com/lf-edge/eden/eserver/pkg/manager"
 	"github.com/lf-edge/eden/eserver/pkg/server"
 	"github.com/spf13/cobra"
@@ -18,6 +22,20 @@ var (
 	serverSFTPUser     string
 	serverSFTPPassword string
 	serverSFTPReadOnly bool
+	maxDownloads       int
+	downloadRetries    int
+	s3AccessKey        string
+	s3SecretKey        string
+	azureAccount       string
+	azureKey           string
+	sshHostKey         string
+	certFile           string
+	keyFile            string
+	httpUser           string
+	httpPassword       string
+	httpToken          string
+	adminUser          string
+	adminPassword      string
 )
 
 var serverCmd = &cobra.Command{
@@ -25,13 +43,50 @@ var serverCmd = &cobra.Command{
 	Short: "start a server",
 	Long:  `Start a server.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		// credentials not set with flags are taken from environment
+		for _, credential := range []struct {
+			value *string
+			env   string
+		}{
+			{&httpUser, api.EnvHTTPUser},
+			{&httpPassword, api.EnvHTTPPassword},
+			{&httpToken, api.EnvHTTPToken},
+			{&adminUser, api.EnvAdminUser},
+			{&adminPassword, api.EnvAdminPassword},
+			{&s3AccessKey, api.EnvS3AccessKey},
+			{&s3SecretKey, api.EnvS3SecretKey},
+			{&azureAccount, api.EnvAzureAccount},
+			{&azureKey, api.EnvAzureKey},
+		} {
+			if *credential.value == "" {
+				*credential.value = os.Getenv(credential.env)
+			}
+		}
 		server := &server.EServer{
 			Port:     port,
 			Address:  hostIP,
 			User:     serverSFTPUser,
 			Password: serverSFTPPassword,
 			ReadOnly: serverSFTPReadOnly,
-			Manager:  &manager.EServerManager{Dir: serverDir},
+
+			SSHHostKey:    sshHostKey,
+			CertFile:      certFile,
+			KeyFile:       keyFile,
+			HTTPUser:      httpUser,
+			HTTPPassword:  httpPassword,
+			HTTPToken:     httpToken,
+			AdminUser:     adminUser,
+			AdminPassword: adminPassword,
+
+			S3AccessKey:  s3AccessKey,
+			S3SecretKey:  s3SecretKey,
+			AzureAccount: azureAccount,
+			AzureKey:     azureKey,
+			Manager: &manager.EServerManager{
+				Dir:             serverDir,
+				MaxDownloads:    maxDownloads,
+				DownloadRetries: downloadRetries,
+			},
 		}
 		server.Start()
 	},
@@ -44,4 +99,18 @@ func serverInit() {
 	serverCmd.Flags().StringVar(&serverSFTPUser, "user", "user", "user for sftp")
 	serverCmd.Flags().StringVar(&serverSFTPPassword, "password", "password", "password for sftp")
 	serverCmd.Flags().BoolVar(&serverSFTPReadOnly, "readonly", true, "Read only access via sftp")
+	serverCmd.Flags().StringVar(&sshHostKey, "ssh-host-key", "/root/.ssh/id_rsa", "host key for sftp (generated if the file does not exist)")
+	serverCmd.Flags().StringVar(&certFile, "cert", "", "certificate file to serve http over TLS")
+	serverCmd.Flags().StringVar(&keyFile, "key", "", "key file of certificate to serve http over TLS")
+	serverCmd.Flags().StringVar(&httpUser, "http-user", "", fmt.Sprintf("user for basic authorization of file requests (/eserver/*) (or %s environment variable)", api.EnvHTTPUser))
+	serverCmd.Flags().StringVar(&httpPassword, "http-password", "", fmt.Sprintf("password for basic authorization of file requests (/eserver/*) (or %s environment variable)", api.EnvHTTPPassword))
+	serverCmd.Flags().StringVar(&httpToken, "http-token", "", fmt.Sprintf("token for bearer authorization of file requests (/eserver/*) (or %s environment variable)", api.EnvHTTPToken))
+	serverCmd.Flags().StringVar(&adminUser, "admin-user", "", fmt.Sprintf("user for basic authorization of admin requests (/admin/*) (or %s environment variable)", api.EnvAdminUser))
+	serverCmd.Flags().StringVar(&adminPassword, "admin-password", "", fmt.Sprintf("password for basic authorization of admin requests (/admin/*) (or %s environment variable)", api.EnvAdminPassword))
+	serverCmd.Flags().IntVar(&maxDownloads, "max-downloads", 4, "maximum number of concurrently running downloads")
+	serverCmd.Flags().StringVar(&s3AccessKey, "s3-access-key", "", fmt.Sprintf("access key for S3 emulation, disabled if not set (or %s environment variable)", api.EnvS3AccessKey))
+	serverCmd.Flags().StringVar(&s3SecretKey, "s3-secret-key", "", fmt.Sprintf("secret key for S3 emulation, disabled if not set (or %s environment variable)", api.EnvS3SecretKey))
+	serverCmd.Flags().StringVar(&azureAccount, "azure-account", "", fmt.Sprintf("storage account name for Azure Blob emulation, disabled if not set (or %s environment variable)", api.EnvAzureAccount))
+	serverCmd.Flags().StringVar(&azureKey, "azure-key", "", fmt.Sprintf("base64-encoded storage account key for Azure Blob emulation, disabled if not set (or %s environment variable)", api.EnvAzureKey))
+	serverCmd.Flags().IntVar(&downloadRetries, "download-retries", 5, "number of retries of failed download")
 }
@@ -0,0 +1,408 @@
+package manager
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eden/eserver/api"
+)
+
+const (
+	defaultMaxDownloads = 4
+	// Backoff between download retries grows exponentially up to maxRetryBackoff.
+	minRetryBackoff = time.Second
+	maxRetryBackoff = 30 * time.Second
+)
+
+// ErrJobNotFound is returned for unknown download job ID.
+var ErrJobNotFound = errors.New("download job not found")
+
+// errDownloadSuperseded is returned for download job replaced by another one
+// with the same file name.
+var errDownloadSuperseded = errors.New("download superseded")
+
+// permanentError is download error which is not worth retrying.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// downloadJob is an asynchronous download of a file from URL.
+type downloadJob struct {
+	arg    api.URLArg
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// mu protects info and progress tracking.
+	mu   sync.Mutex
+	info api.DownloadJob
+	// startedAt and startOffset of the current attempt (used to estimate ETA).
+	startedAt   time.Time
+	startOffset int64
+}
+
+func newJobID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		log.Fatal(err)
+	}
+	return hex.EncodeToString(id)
+}
+
+// status returns information about the job with up-to-date ETA.
+func (job *downloadJob) status() api.DownloadJob {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	info := job.info
+	if info.State == api.JobRunning && info.TotalSize > 0 {
+		elapsed := time.Since(job.startedAt).Seconds()
+		if transferred := info.Downloaded - job.startOffset; transferred > 0 && elapsed > 0 {
+			rate := float64(transferred) / elapsed
+			info.ETASeconds = int64(float64(info.TotalSize-info.Downloaded) / rate)
+		}
+	}
+	return info
+}
+
+func (job *downloadJob) active() bool {
+	state := job.status().State
+	return state == api.JobQueued || state == api.JobRunning
+}
+
+func (job *downloadJob) update(fn func(info *api.DownloadJob)) {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	fn(&job.info)
+}
+
+func (job *downloadJob) startAttempt(offset int64) {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	job.info.Attempts++
+	job.info.Downloaded = offset
+	job.startedAt = time.Now()
+	job.startOffset = offset
+}
+
+func (job *downloadJob) finish(state api.JobState, err error) {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	now := time.Now()
+	job.info.State = state
+	job.info.FinishedAt = &now
+	if err != nil {
+		job.info.Error = err.Error()
+	}
+}
+
+// httpClient returns client with TLS configuration requested for the job.
+func (job *downloadJob) httpClient() (*http.Client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: job.arg.Insecure}
+	if job.arg.CACert != "" {
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM([]byte(job.arg.CACert)) {
+			return nil, errors.New("failed to parse CA certificate")
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport}, nil
+}
+
+// AddFile starts file download and return name of file for fileinfo requests
+// File is downloaded again if the name was previously used for content from another URL.
+func (mgr *EServerManager) AddFile(arg api.URLArg) (string, error) {
+	log.Println("Starting download of image from ", arg.URL)
+	name := path.Base(arg.URL)
+	if err := checkName(name); err != nil {
+		return "", err
+	}
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	if job := mgr.downloads[name]; job != nil && job.active() {
+		if job.arg.URL == arg.URL {
+			log.Println("download already in progress ", name)
+			return name, nil
+		}
+		log.Printf("canceling download of %s from %s, superseded by %s",
+			name, job.arg.URL, arg.URL)
+		job.cancel()
+	}
+	if digest, err := mgr.readRef(name); err == nil {
+		urlDigest, err := os.ReadFile(mgr.urlIndexPath(arg.URL))
+		if err == nil && string(urlDigest) == digest {
+			log.Println("file already exists ", name)
+			mgr.forgetDownload(name)
+			return name, nil
+		}
+		log.Printf("file %s exists with content from another URL, downloading again", name)
+	}
+	if prevJob := mgr.downloads[name]; prevJob != nil {
+		delete(mgr.jobs, prevJob.info.ID)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &downloadJob{
+		arg:    arg,
+		ctx:    ctx,
+		cancel: cancel,
+		info: api.DownloadJob{
+			ID:        newJobID(),
+			Name:      name,
+			URL:       arg.URL,
+			State:     api.JobQueued,
+			CreatedAt: time.Now(),
+		},
+	}
+	mgr.jobs[job.info.ID] = job
+	mgr.downloads[name] = job
+	go mgr.runDownload(job)
+	return name, nil
+}
+
+// forgetDownload removes finished download job of the file.
+// Caller must hold mgr.mu.
+func (mgr *EServerManager) forgetDownload(name string) {
+	if job := mgr.downloads[name]; job != nil && !job.active() {
+		delete(mgr.downloads, name)
+		delete(mgr.jobs, job.info.ID)
+	}
+}
+
+// ListDownloads returns information about all download jobs.
+func (mgr *EServerManager) ListDownloads() (result []api.DownloadJob) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	for _, job := range mgr.jobs {
+		result = append(result, job.status())
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result
+}
+
+// GetDownload returns information about the download job.
+func (mgr *EServerManager) GetDownload(id string) (api.DownloadJob, error) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	job := mgr.jobs[id]
+	if job == nil {
+		return api.DownloadJob{}, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	return job.status(), nil
+}
+
+// CancelDownload cancels queued or running download job.
+func (mgr *EServerManager) CancelDownload(id string) (api.DownloadJob, error) {
+	mgr.mu.Lock()
+	job := mgr.jobs[id]
+	mgr.mu.Unlock()
+	if job == nil {
+		return api.DownloadJob{}, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	job.cancel()
+	return job.status(), nil
+}
+
+func (mgr *EServerManager) runDownload(job *downloadJob) {
+	defer job.cancel()
+	url := job.arg.URL
+	select {
+	case mgr.downloadSlots <- struct{}{}:
+		defer func() { <-mgr.downloadSlots }()
+	case <-job.ctx.Done():
+		log.Printf("Download of %s canceled", url)
+		job.finish(api.JobCanceled, job.ctx.Err())
+		return
+	}
+	job.update(func(info *api.DownloadJob) {
+		info.State = api.JobRunning
+	})
+	tmpPath := mgr.path(tmpDir, job.info.ID)
+	digest, err := mgr.download(job, tmpPath)
+	if err == nil {
+		err = mgr.commitDownload(job, tmpPath, digest)
+	}
+	switch {
+	case err == nil:
+		log.Println("Download done for ", url)
+		job.finish(api.JobDone, nil)
+	case job.ctx.Err() != nil:
+		log.Printf("Download of %s canceled", url)
+		job.finish(api.JobCanceled, job.ctx.Err())
+	case errors.Is(err, errDownloadSuperseded):
+		log.Printf("Download of %s superseded", url)
+		job.finish(api.JobCanceled, err)
+	default:
+		log.Printf("Download of %s failed: %v", url, err)
+		job.finish(api.JobFailed, err)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+	}
+}
+
+// commitDownload stores the downloaded file unless the job was canceled
+// or is no longer the download of its file name, both checked under mgr.mu
+// to not overwrite content of a newer download.
+func (mgr *EServerManager) commitDownload(job *downloadJob, tmpPath, digest string) error {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	if err := job.ctx.Err(); err != nil {
+		return err
+	}
+	if mgr.downloads[job.info.Name] != job {
+		return errDownloadSuperseded
+	}
+	if err := mgr.storeBlob(tmpPath, digest, job.info.Name); err != nil {
+		return err
+	}
+	return os.WriteFile(mgr.urlIndexPath(job.arg.URL), []byte(digest), 0644)
+}
+
+// download downloads the file into filePath and returns sha256 of the content.
+// Failed download is retried and resumed (using HTTP range request) if the server
+// supports it.
+func (mgr *EServerManager) download(job *downloadJob, filePath string) (string, error) {
+	client, err := job.httpClient()
+	if err != nil {
+		return "", &permanentError{err: err}
+	}
+	out, err := os.Create(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close()
+	hash := sha256.New()
+	var offset int64
+	backoff := minRetryBackoff
+	for attempt := 0; ; attempt++ {
+		offset, err = job.fetch(client, out, hash, offset)
+		if err == nil {
+			break
+		}
+		var permanent *permanentError
+		if job.ctx.Err() != nil || errors.As(err, &permanent) || attempt >= mgr.DownloadRetries {
+			return "", err
+		}
+		log.Printf("Download of %s interrupted after %d bytes: %v, retrying in %v",
+			job.arg.URL, offset, err, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-job.ctx.Done():
+			return "", job.ctx.Err()
+		}
+		if backoff *= 2; backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), out.Close()
+}
+
+// fetch requests the content from offset and appends it into out.
+// Returns offset after the last byte written.
+func (job *downloadJob) fetch(client *http.Client, out *os.File, hash hash.Hash,
+	offset int64) (int64, error) {
+	req, err := http.NewRequestWithContext(job.ctx, http.MethodGet, job.arg.URL, nil)
+	if err != nil {
+		return offset, &permanentError{err: err}
+	}
+	if job.arg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+job.arg.Token)
+	} else if job.arg.Username != "" {
+		req.SetBasicAuth(job.arg.Username, job.arg.Password)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	job.startAttempt(offset)
+	resp, err := client.Do(req)
+	if err != nil {
+		return offset, err
+	}
+	defer resp.Body.Close()
+	totalSize := resp.ContentLength
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		var start, end int64
+		if _, err = fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-%d/", &start, &end); err != nil ||
+			start != offset {
+			return offset, &permanentError{err: fmt.Errorf("unexpected Content-Range: %q",
+				resp.Header.Get("Content-Range"))}
+		}
+		if totalSize >= 0 {
+			totalSize += offset
+		}
+	case resp.StatusCode == http.StatusOK:
+		if offset > 0 {
+			// Range request is not supported, start from the beginning.
+			log.Printf("Server does not support range requests, restarting download of %s",
+				job.arg.URL)
+			if _, err = out.Seek(0, io.SeekStart); err != nil {
+				return offset, &permanentError{err: err}
+			}
+			if err = out.Truncate(0); err != nil {
+				return offset, &permanentError{err: err}
+			}
+			hash.Reset()
+			offset = 0
+			job.startAttempt(0)
+		}
+	default:
+		err = fmt.Errorf("unexpected status: %s", resp.Status)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout &&
+			resp.StatusCode != http.StatusTooManyRequests {
+			err = &permanentError{err: err}
+		}
+		return offset, err
+	}
+	if totalSize >= 0 {
+		job.update(func(info *api.DownloadJob) {
+			info.TotalSize = totalSize
+		})
+	}
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, err = out.Write(buf[:n]); err != nil {
+				return offset, &permanentError{err: err}
+			}
+			_, _ = hash.Write(buf[:n])
+			offset += int64(n)
+			job.update(func(info *api.DownloadJob) {
+				info.Downloaded = offset
+			})
+		}
+		if readErr == io.EOF {
+			return offset, nil
+		}
+		if readErr != nil {
+			return offset, readErr
+		}
+	}
+}
@@ -2,118 +2,339 @@ package manager
 
 import (
 	"crypto/sha256"
-	"crypto/tls"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
 	"mime/multipart"
-	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/lf-edge/eden/eserver/api"
 )
 
+const (
+	// storeDir is the directory (inside EServerManager.Dir) with content-addressed storage.
+	// Files are stored as blobs named by their sha256 digest, every served file name
+	// is a relative symlink pointing to the blob with its content (i.e. name->digest reference).
+	// Symlinks keep files accessible under their names for both HTTP and SFTP.
+	storeDir = ".store"
+	// blobsDir contains blobs named by sha256 digest of the content.
+	blobsDir = storeDir + "/blobs/sha256"
+	// urlsDir contains for every downloaded URL (named by sha256 of the URL)
+	// the digest of the downloaded content.
+	urlsDir = storeDir + "/urls"
+	// tmpDir contains files being downloaded or uploaded.
+	tmpDir = storeDir + "/tmp"
+)
+
+// ErrInvalidName is returned for file names which cannot be stored.
+var ErrInvalidName = errors.New("invalid file name")
+
 // EServerManager for process files
 type EServerManager struct {
 	Dir string
+
+	// MaxDownloads limits the number of concurrently running downloads.
+	MaxDownloads int
+	// DownloadRetries is the number of retries of failed download.
+	DownloadRetries int
+
+	// mu protects the storage and download jobs against concurrent modifications.
+	mu sync.Mutex
+	// jobs contains download jobs by ID.
+	jobs map[string]*downloadJob
+	// downloads contains the last download job for every file name.
+	downloads map[string]*downloadJob
+	// downloadSlots is a semaphore bounding the number of running downloads.
+	downloadSlots chan struct{}
 }
 
 // Init directories for EServerManager
 func (mgr *EServerManager) Init() {
-	if _, err := os.Stat(mgr.Dir); err != nil {
-		if err = os.MkdirAll(mgr.Dir, 0755); err != nil {
+	// Remove leftovers of transfers interrupted by the previous run.
+	if err := os.RemoveAll(mgr.path(tmpDir)); err != nil {
+		log.Fatal(err)
+	}
+	for _, dir := range []string{mgr.Dir, mgr.path(blobsDir), mgr.path(urlsDir), mgr.path(tmpDir)} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
 			log.Fatal(err)
 		}
 	}
+	mgr.jobs = make(map[string]*downloadJob)
+	mgr.downloads = make(map[string]*downloadJob)
+	if mgr.MaxDownloads <= 0 {
+		mgr.MaxDownloads = defaultMaxDownloads
+	}
+	mgr.downloadSlots = make(chan struct{}, mgr.MaxDownloads)
+	if err := mgr.migrateLegacyFiles(); err != nil {
+		log.Fatal(err)
+	}
 }
 
-// ListFileNames list downloaded files
-func (mgr *EServerManager) ListFileNames() (result []string) {
-	files, err := os.ReadDir(mgr.Dir)
-	if err != nil {
-		log.Fatal(err)
+func (mgr *EServerManager) path(elem ...string) string {
+	return filepath.Join(append([]string{mgr.Dir}, elem...)...)
+}
+
+func (mgr *EServerManager) blobPath(digest string) string {
+	return mgr.path(blobsDir, digest)
+}
+
+func (mgr *EServerManager) urlIndexPath(url string) string {
+	urlHash := sha256.Sum256([]byte(url))
+	return mgr.path(urlsDir, hex.EncodeToString(urlHash[:]))
+}
+
+// checkName ensures that the name is relative, does not escape the directory
+// and does not collide with the storage internals.
+func checkName(name string) error {
+	if name == "" || path.IsAbs(name) || path.Clean(name) != name {
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
 	}
-	for _, f := range files {
-		result = append(result, f.Name())
+	for _, elem := range strings.Split(name, "/") {
+		if elem == ".." || strings.HasPrefix(elem, ".") {
+			return fmt.Errorf("%w: %q", ErrInvalidName, name)
+		}
 	}
-	return
+	return nil
 }
 
-// getFileSize returns file size
-func getFileSize(filePath string) int64 {
-	fi, err := os.Stat(filePath)
+// readRef returns digest of the blob referenced by the name.
+func (mgr *EServerManager) readRef(name string) (digest string, err error) {
+	target, err := os.Readlink(mgr.path(name))
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
-	return fi.Size()
+	if filepath.Base(filepath.Dir(target)) != filepath.Base(blobsDir) {
+		return "", fmt.Errorf("%s is not a reference to a blob", name)
+	}
+	return filepath.Base(target), nil
 }
 
-// downloadFile downloads a url to a local file.
-func downloadFile(filePath, url string) error {
-	out, err := os.Create(filePath + ".tmp")
-	if err != nil {
+// writeRef (re)points the name to the blob with the given digest.
+// Blob previously referenced by the name is removed if it is not referenced anymore.
+func (mgr *EServerManager) writeRef(name, digest string) error {
+	refPath := mgr.path(name)
+	if err := os.MkdirAll(filepath.Dir(refPath), 0755); err != nil {
 		return err
 	}
-	resp, err := http.Get(url)
+	target, err := filepath.Rel(filepath.Dir(refPath), mgr.blobPath(digest))
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-	hash := sha256.New()
-	_, err = io.Copy(hash, io.TeeReader(resp.Body, out))
-	if err != nil {
+	prevDigest, _ := mgr.readRef(name)
+	mgr.forgetDownload(name)
+	tmpRefPath := refPath + ".ref"
+	_ = os.Remove(tmpRefPath)
+	if err = os.Symlink(target, tmpRefPath); err != nil {
 		return err
 	}
-	if err = os.WriteFile(fmt.Sprintf("%s.sha256", filePath), []byte(hex.EncodeToString(hash.Sum(nil))), 0666); err != nil {
+	if err = os.Rename(tmpRefPath, refPath); err != nil {
 		return err
 	}
-	if err = os.Rename(filePath+".tmp", filePath); err != nil {
+	if prevDigest != "" && prevDigest != digest {
+		_, err = mgr.releaseBlob(prevDigest)
+	}
+	return err
+}
+
+// commitBlob moves the temporary file into the blob storage and points the name to it.
+func (mgr *EServerManager) commitBlob(tmpPath, digest, name string) error {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	return mgr.storeBlob(tmpPath, digest, name)
+}
+
+// storeBlob is commitBlob for callers holding mgr.mu.
+func (mgr *EServerManager) storeBlob(tmpPath, digest, name string) error {
+	blobPath := mgr.blobPath(digest)
+	if _, err := os.Stat(blobPath); err == nil {
+		// Deduplicated, content is already stored.
+		if err = os.Remove(tmpPath); err != nil {
+			return err
+		}
+	} else if err = os.Rename(tmpPath, blobPath); err != nil {
 		return err
 	}
-	return out.Close()
+	return mgr.writeRef(name, digest)
 }
 
-// AddFile starts file download and return name of file for fileinfo requests
-func (mgr *EServerManager) AddFile(url string) (string, error) {
-	log.Println("Starting download of image from ", url)
-	filePath := filepath.Join(mgr.Dir, path.Base(url))
-	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
-		log.Println("file already exists ", filePath)
-	} else {
-		go func() {
-			http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
-			if err := downloadFile(filePath, url); err != nil {
-				log.Fatal(err)
+// walkRefs calls fn for every stored file name with the digest of the referenced blob.
+func (mgr *EServerManager) walkRefs(fn func(name, digest string) error) error {
+	return filepath.WalkDir(mgr.Dir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(mgr.Dir, filePath)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name == storeDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+		name = filepath.ToSlash(name)
+		digest, err := mgr.readRef(name)
+		if err != nil {
+			log.Printf("skipping %s: %v", name, err)
+			return nil
+		}
+		return fn(name, digest)
+	})
+}
+
+// refCounts returns the number of names referencing every blob.
+func (mgr *EServerManager) refCounts() (map[string]int, error) {
+	counts := make(map[string]int)
+	err := mgr.walkRefs(func(_, digest string) error {
+		counts[digest]++
+		return nil
+	})
+	return counts, err
+}
+
+// releaseBlob removes the blob if it is not referenced by any name.
+func (mgr *EServerManager) releaseBlob(digest string) (freed int64, err error) {
+	counts, err := mgr.refCounts()
+	if err != nil {
+		return 0, err
+	}
+	if counts[digest] > 0 {
+		return 0, nil
+	}
+	blobPath := mgr.blobPath(digest)
+	fi, err := os.Stat(blobPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if err = os.Remove(blobPath); err != nil {
+		return 0, err
+	}
+	log.Printf("removed unreferenced blob %s", digest)
+	return fi.Size(), nil
+}
+
+// removeEmptyParents removes empty directories between the file and mgr.Dir.
+func (mgr *EServerManager) removeEmptyParents(name string) {
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		if err := os.Remove(mgr.path(dir)); err != nil {
+			return
+		}
+	}
+}
+
+// migrateLegacyFiles moves files stored by name (with .sha256 sidecar)
+// into the blob storage and replaces them with references.
+func (mgr *EServerManager) migrateLegacyFiles() error {
+	var legacyFiles []string
+	err := filepath.WalkDir(mgr.Dir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && filePath == mgr.path(storeDir) {
+			return filepath.SkipDir
+		}
+		if d.Type().IsRegular() {
+			legacyFiles = append(legacyFiles, filePath)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, filePath := range legacyFiles {
+		if strings.HasSuffix(filePath, ".sha256") || strings.HasSuffix(filePath, ".tmp") {
+			if err = os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+				return err
 			}
-			log.Println("Download done for ", url)
-		}()
+			continue
+		}
+		name, err := filepath.Rel(mgr.Dir, filePath)
+		if err != nil {
+			return err
+		}
+		name = filepath.ToSlash(name)
+		if checkName(name) != nil {
+			continue
+		}
+		digest, err := fileSha256(filePath)
+		if err != nil {
+			return err
+		}
+		if err = mgr.commitBlob(filePath, digest, name); err != nil {
+			return err
+		}
+		log.Printf("migrated %s into blob %s", name, digest)
 	}
-	return path.Base(url), nil
+	return nil
+}
+
+func fileSha256(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err = io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ListFileNames list downloaded files
+func (mgr *EServerManager) ListFileNames() (result []string) {
+	err := mgr.walkRefs(func(name, _ string) error {
+		result = append(result, name)
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	return
+}
+
+// ListFiles returns information about all stored files
+func (mgr *EServerManager) ListFiles() (result []*api.FileInfo, err error) {
+	err = mgr.walkRefs(func(name, digest string) error {
+		fi, err := os.Stat(mgr.blobPath(digest))
+		if err != nil {
+			return err
+		}
+		result = append(result, &api.FileInfo{
+			Sha256:   digest,
+			Size:     fi.Size(),
+			FileName: path.Join("eserver", name),
+			ISReady:  true,
+		})
+		return nil
+	})
+	return result, err
 }
 
 // AddFileFromMultipart adds file from multipart.Part and returns information
 func (mgr *EServerManager) AddFileFromMultipart(part *multipart.Part) *api.FileInfo {
 	result := &api.FileInfo{ISReady: false}
 	log.Println("Starting copy image from ", part.FileName())
-	filePath := filepath.Join(mgr.Dir, part.FileName())
-	if err := os.MkdirAll(filepath.Dir(filePath), os.ModeDir); err != nil {
-		log.Println("cannot create dir for ", filePath)
+	if err := checkName(part.FileName()); err != nil {
 		result.Error = err.Error()
 		return result
 	}
-	if _, err := os.Stat(filePath); !os.IsNotExist(err) {
-		log.Println("file already exists ", filePath)
-		// remove file if exists, we have new file in request
-		if err := os.Remove(filePath); err != nil {
-			result.Error = err.Error()
-			return result
-		}
-	}
-	filePathTemp := filepath.Join(mgr.Dir, fmt.Sprintf("%s.tmp", part.FileName()))
-	out, err := os.Create(filePathTemp)
+	out, err := os.CreateTemp(mgr.path(tmpDir), "upload-*")
 	if err != nil {
 		result.Error = err.Error()
 		return result
@@ -121,15 +342,14 @@ func (mgr *EServerManager) AddFileFromMultipart(part *multipart.Part) *api.FileI
 	defer out.Close()
 	hash := sha256.New()
 	_, err = io.Copy(hash, io.TeeReader(part, out))
-	if err != nil {
-		result.Error = err.Error()
-		return result
+	if err == nil {
+		err = out.Close()
 	}
-	if err = os.WriteFile(fmt.Sprintf("%s.sha256", filePath), []byte(hex.EncodeToString(hash.Sum(nil))), 0666); err != nil {
-		result.Error = err.Error()
-		return result
+	if err == nil {
+		err = mgr.commitBlob(out.Name(), hex.EncodeToString(hash.Sum(nil)), part.FileName())
 	}
-	if err = os.Rename(filePathTemp, filePath); err != nil {
+	if err != nil {
+		_ = os.Remove(out.Name())
 		result.Error = err.Error()
 		return result
 	}
@@ -139,28 +359,45 @@ func (mgr *EServerManager) AddFileFromMultipart(part *multipart.Part) *api.FileI
 // GetFileInfo checks status of file and returns information
 func (mgr *EServerManager) GetFileInfo(name string) *api.FileInfo {
 	result := &api.FileInfo{ISReady: false}
-	filePath := filepath.Join(mgr.Dir, name)
-	filePathTMP := filePath + ".tmp"
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		if _, err := os.Stat(filePathTMP); os.IsNotExist(err) {
-			result.Error = err.Error()
-			return result
-		}
-		fileSize := getFileSize(filePathTMP)
-		return &api.FileInfo{
-			Size:    fileSize,
-			ISReady: false,
+	if err := checkName(name); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	mgr.mu.Lock()
+	job := mgr.downloads[name]
+	mgr.mu.Unlock()
+	if job != nil {
+		status := job.status()
+		switch status.State {
+		case api.JobQueued, api.JobRunning:
+			return &api.FileInfo{
+				Size:       status.Downloaded,
+				TotalSize:  status.TotalSize,
+				ETASeconds: status.ETASeconds,
+				JobID:      status.ID,
+				ISReady:    false,
+			}
+		case api.JobFailed, api.JobCanceled:
+			return &api.FileInfo{
+				JobID:   status.ID,
+				ISReady: false,
+				Error:   fmt.Sprintf("download of %s %s: %s", status.URL, status.State, status.Error),
+			}
 		}
 	}
-	fileSize := getFileSize(filePath)
-	sha, err := os.ReadFile(fmt.Sprintf("%s.sha256", filePath))
+	digest, err := mgr.readRef(name)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	fi, err := os.Stat(mgr.blobPath(digest))
 	if err != nil {
 		result.Error = err.Error()
 		return result
 	}
 	return &api.FileInfo{
-		Sha256:   string(sha),
-		Size:     fileSize,
+		Sha256:   digest,
+		Size:     fi.Size(),
 		FileName: path.Join("eserver", name),
 		ISReady:  true,
 	}
@@ -168,9 +405,106 @@ func (mgr *EServerManager) GetFileInfo(name string) *api.FileInfo {
 
 // GetFilePath returns path to file for serve
 func (mgr *EServerManager) GetFilePath(name string) (string, error) {
-	filePath := filepath.Join(mgr.Dir, name)
+	if err := checkName(name); err != nil {
+		return "", err
+	}
+	filePath := mgr.path(name)
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		return "", err
 	}
 	return filePath, nil
 }
+
+// DeleteFile removes the file name and the referenced blob if it is not
+// referenced by any other name. Returns number of bytes freed.
+func (mgr *EServerManager) DeleteFile(name string) (int64, error) {
+	if err := checkName(name); err != nil {
+		return 0, err
+	}
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	digest, err := mgr.readRef(name)
+	if err != nil {
+		return 0, err
+	}
+	if err = os.Remove(mgr.path(name)); err != nil {
+		return 0, err
+	}
+	mgr.forgetDownload(name)
+	mgr.removeEmptyParents(name)
+	log.Printf("removed %s", name)
+	return mgr.releaseBlob(digest)
+}
+
+// CollectGarbage removes blobs which are not referenced by any name,
+// and stale entries of the URL index.
+// If arg.Prune is set, names not listed in arg.Keep are removed first.
+func (mgr *EServerManager) CollectGarbage(arg api.GCArg) (*api.GCResult, error) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	result := &api.GCResult{}
+	keep := make(map[string]bool)
+	for _, name := range arg.Keep {
+		keep[name] = true
+	}
+	counts := make(map[string]int)
+	err := mgr.walkRefs(func(name, digest string) error {
+		if arg.Prune && !keep[name] {
+			result.RemovedFiles = append(result.RemovedFiles, name)
+			return nil
+		}
+		counts[digest]++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !arg.DryRun {
+		for _, name := range result.RemovedFiles {
+			if err = os.Remove(mgr.path(name)); err != nil {
+				return nil, err
+			}
+			mgr.removeEmptyParents(name)
+			log.Printf("removed %s", name)
+		}
+	}
+	blobs, err := os.ReadDir(mgr.path(blobsDir))
+	if err != nil {
+		return nil, err
+	}
+	for _, blob := range blobs {
+		digest := blob.Name()
+		if counts[digest] > 0 {
+			continue
+		}
+		fi, err := blob.Info()
+		if err != nil {
+			return nil, err
+		}
+		result.RemovedBlobs = append(result.RemovedBlobs, digest)
+		result.FreedBytes += fi.Size()
+		if !arg.DryRun {
+			if err = os.Remove(mgr.blobPath(digest)); err != nil {
+				return nil, err
+			}
+			log.Printf("removed unreferenced blob %s", digest)
+		}
+	}
+	if arg.DryRun {
+		return result, nil
+	}
+	urls, err := os.ReadDir(mgr.path(urlsDir))
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range urls {
+		digest, err := os.ReadFile(mgr.path(urlsDir, entry.Name()))
+		if err != nil || counts[string(digest)] == 0 {
+			if err = os.Remove(mgr.path(urlsDir, entry.Name())); err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.Strings(result.RemovedFiles)
+	return result, nil
+}
@@ -3,8 +3,10 @@ package server
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
 
 	"github.com/gorilla/mux"
 	"github.com/lf-edge/eden/eserver/api"
@@ -14,6 +16,7 @@ import (
 
 type adminHandler struct {
 	manager *manager.EServerManager
+	faults  *faultInjector
 }
 
 func (h *adminHandler) list(w http.ResponseWriter, _ *http.Request) {
@@ -37,7 +40,7 @@ func (h *adminHandler) addFromURL(w http.ResponseWriter, r *http.Request) {
 		wrapError(err, w)
 		return
 	}
-	name, err := h.manager.AddFile(data.URL)
+	name, err := h.manager.AddFile(data)
 	if err != nil {
 		wrapError(err, w)
 		return
@@ -89,3 +92,136 @@ func (h *adminHandler) getFileStatus(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write(out)
 }
+
+func (h *adminHandler) listFiles(w http.ResponseWriter, _ *http.Request) {
+	files, err := h.manager.ListFiles()
+	if err != nil {
+		wrapError(err, w)
+		return
+	}
+	out, err := json.Marshal(files)
+	if err != nil {
+		wrapError(err, w)
+		return
+	}
+	w.Header().Add(contentType, mimeJSON)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(out)
+}
+
+func (h *adminHandler) deleteFile(w http.ResponseWriter, r *http.Request) {
+	u := mux.Vars(r)["filename"]
+	freed, err := h.manager.DeleteFile(u)
+	if err != nil {
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			wrapErrorWithCode(err, w, http.StatusNotFound)
+		case errors.Is(err, manager.ErrInvalidName):
+			wrapErrorWithCode(err, w, http.StatusBadRequest)
+		default:
+			wrapError(err, w)
+		}
+		return
+	}
+	out, err := json.Marshal(api.GCResult{RemovedFiles: []string{u}, FreedBytes: freed})
+	if err != nil {
+		wrapError(err, w)
+		return
+	}
+	w.Header().Add(contentType, mimeJSON)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(out)
+}
+
+func (h *adminHandler) collectGarbage(w http.ResponseWriter, r *http.Request) {
+	decoder := json.NewDecoder(r.Body)
+	var data api.GCArg
+	if err := decoder.Decode(&data); err != nil {
+		wrapErrorWithCode(err, w, http.StatusBadRequest)
+		return
+	}
+	result, err := h.manager.CollectGarbage(data)
+	if err != nil {
+		wrapError(err, w)
+		return
+	}
+	out, err := json.Marshal(result)
+	if err != nil {
+		wrapError(err, w)
+		return
+	}
+	w.Header().Add(contentType, mimeJSON)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(out)
+}
+
+func (h *adminHandler) listDownloads(w http.ResponseWriter, _ *http.Request) {
+	h.writeJSON(w, h.manager.ListDownloads())
+}
+
+func (h *adminHandler) getDownload(w http.ResponseWriter, r *http.Request) {
+	job, err := h.manager.GetDownload(mux.Vars(r)["id"])
+	if err != nil {
+		wrapErrorWithCode(err, w, http.StatusNotFound)
+		return
+	}
+	h.writeJSON(w, job)
+}
+
+func (h *adminHandler) cancelDownload(w http.ResponseWriter, r *http.Request) {
+	job, err := h.manager.CancelDownload(mux.Vars(r)["id"])
+	if err != nil {
+		wrapErrorWithCode(err, w, http.StatusNotFound)
+		return
+	}
+	h.writeJSON(w, job)
+}
+
+func (h *adminHandler) writeJSON(w http.ResponseWriter, obj interface{}) {
+	out, err := json.Marshal(obj)
+	if err != nil {
+		wrapError(err, w)
+		return
+	}
+	w.Header().Add(contentType, mimeJSON)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(out)
+}
+
+func (h *adminHandler) getFaults(w http.ResponseWriter, _ *http.Request) {
+	h.writeJSON(w, h.faults.getProfiles())
+}
+
+func (h *adminHandler) setFaults(w http.ResponseWriter, r *http.Request) {
+	var profiles []api.FaultProfile
+	if err := json.NewDecoder(r.Body).Decode(&profiles); err != nil {
+		wrapErrorWithCode(err, w, http.StatusBadRequest)
+		return
+	}
+	if err := h.faults.setProfiles(profiles); err != nil {
+		wrapErrorWithCode(err, w, http.StatusBadRequest)
+		return
+	}
+	log.Infof("Fault profiles set: %d", len(profiles))
+	h.writeJSON(w, h.faults.getProfiles())
+}
+
+func (h *adminHandler) addFault(w http.ResponseWriter, r *http.Request) {
+	var profile api.FaultProfile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		wrapErrorWithCode(err, w, http.StatusBadRequest)
+		return
+	}
+	if err := h.faults.addProfile(profile); err != nil {
+		wrapErrorWithCode(err, w, http.StatusBadRequest)
+		return
+	}
+	log.Infof("Fault profile added: %+v", profile)
+	h.writeJSON(w, h.faults.getProfiles())
+}
+
+func (h *adminHandler) clearFaults(w http.ResponseWriter, _ *http.Request) {
+	_ = h.faults.setProfiles(nil)
+	log.Info("Fault profiles cleared")
+	h.writeJSON(w, h.faults.getProfiles())
+}
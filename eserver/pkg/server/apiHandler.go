@@ -1,14 +1,20 @@
 package server
 
 import (
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/lf-edge/eden/eserver/pkg/manager"
+	log "github.com/sirupsen/logrus"
 )
 
 type apiHandler struct {
 	manager *manager.EServerManager
+	faults  *faultInjector
+	s3      *s3Credentials
+	azure   *azureCredentials
 }
 
 func (h *apiHandler) getFile(w http.ResponseWriter, r *http.Request) {
@@ -18,5 +24,31 @@ func (h *apiHandler) getFile(w http.ResponseWriter, r *http.Request) {
 		wrapError(err, w)
 		return
 	}
-	http.ServeFile(w, r, filePath)
+	h.withFaults(w, r, u, func(w http.ResponseWriter) {
+		http.ServeFile(w, r, filePath)
+	}, func(w http.ResponseWriter, status int) {
+		http.Error(w, "injected fault", status)
+	})
+}
+
+// withFaults responds to the request for the file using serve and injects faults
+// of the matching profile. Failed request is responded using fail.
+func (h *apiHandler) withFaults(w http.ResponseWriter, r *http.Request, name string,
+	serve func(w http.ResponseWriter), fail func(w http.ResponseWriter, status int)) {
+	clientIP, _, _ := net.SplitHostPort(r.RemoteAddr)
+	faults := h.faults.forRequest(name, net.ParseIP(clientIP))
+	if faults == nil {
+		serve(w)
+		return
+	}
+	if faults.TTFB != 0 {
+		time.Sleep(time.Duration(faults.TTFB))
+	}
+	if faults.fail {
+		log.Infof("Injected fault (status %d) into request #%d for %s from %s",
+			faults.errorStatus(), faults.requestNum, name, clientIP)
+		fail(w, faults.errorStatus())
+		return
+	}
+	serve(newFaultyResponseWriter(w, r, faults))
 }
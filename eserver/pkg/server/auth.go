@@ -0,0 +1,68 @@
+package server
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// httpCredentials are accepted for part of HTTP API of eserver
+// with basic (user and password) or bearer (token) authorization.
+type httpCredentials struct {
+	user     string
+	password string
+	token    string
+}
+
+// enabled returns true if requests must be authorized.
+func (c *httpCredentials) enabled() bool {
+	return c.user != "" || c.token != ""
+}
+
+// authorized checks if the request has basic or bearer authorization matching the credentials.
+func (c *httpCredentials) authorized(r *http.Request) bool {
+	if c.token != "" {
+		auth := r.Header.Get("Authorization")
+		if strings.HasPrefix(auth, "Bearer ") && secureCompare(strings.TrimPrefix(auth, "Bearer "), c.token) {
+			return true
+		}
+	}
+	if c.user != "" {
+		if user, password, ok := r.BasicAuth(); ok &&
+			secureCompare(user, c.user) && secureCompare(password, c.password) {
+			return true
+		}
+	}
+	return false
+}
+
+// requireAuth returns middleware rejecting requests not authorized with the credentials.
+// Requests are passed without checks if no credentials are set.
+func requireAuth(creds *httpCredentials, realm string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if !creds.enabled() {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !creds.authorized(r) {
+				if creds.user != "" {
+					w.Header().Add("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", realm))
+				}
+				if creds.token != "" {
+					w.Header().Add("WWW-Authenticate", fmt.Sprintf("Bearer realm=%q", realm))
+				}
+				wrapErrorWithCode(fmt.Errorf("unauthorized request %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr),
+					w, http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func secureCompare(given, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(given), []byte(expected)) == 1
+}
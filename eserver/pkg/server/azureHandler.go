@@ -0,0 +1,349 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	azureVersion    = "2021-08-06"
+	azureMaxResults = 5000
+	azureRequestID  = "eserver"
+	azureBlobType   = "BlockBlob"
+)
+
+// azureCredentials are name and (base64-encoded) key of the only storage account
+// of Azure Blob emulation.
+type azureCredentials struct {
+	Account string
+	Key     string
+}
+
+// azureError is an error response of Azure Blob API.
+type azureError struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+	status  int
+}
+
+func (e *azureError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func newAzureError(status int, code, message string) *azureError {
+	return &azureError{Code: code, Message: message, status: status}
+}
+
+type azureProperties struct {
+	LastModified  string `xml:"Last-Modified"`
+	Etag          string `xml:"Etag"`
+	ContentLength *int64 `xml:"Content-Length,omitempty"`
+	ContentType   string `xml:"Content-Type,omitempty"`
+	BlobType      string `xml:"BlobType,omitempty"`
+	LeaseStatus   string `xml:"LeaseStatus"`
+	LeaseState    string `xml:"LeaseState"`
+}
+
+type azureBlob struct {
+	Name       string          `xml:"Name"`
+	Properties azureProperties `xml:"Properties"`
+}
+
+type azureBlobPrefix struct {
+	Name string `xml:"Name"`
+}
+
+type azureListBlobsResult struct {
+	XMLName         xml.Name          `xml:"EnumerationResults"`
+	ServiceEndpoint string            `xml:"ServiceEndpoint,attr"`
+	ContainerName   string            `xml:"ContainerName,attr"`
+	Prefix          string            `xml:"Prefix,omitempty"`
+	Marker          string            `xml:"Marker,omitempty"`
+	MaxResults      int               `xml:"MaxResults,omitempty"`
+	Delimiter       string            `xml:"Delimiter,omitempty"`
+	Blobs           []azureBlob       `xml:"Blobs>Blob"`
+	BlobPrefixes    []azureBlobPrefix `xml:"Blobs>BlobPrefix"`
+	NextMarker      string            `xml:"NextMarker"`
+}
+
+type azureContainer struct {
+	Name       string          `xml:"Name"`
+	Properties azureProperties `xml:"Properties"`
+}
+
+type azureListContainersResult struct {
+	XMLName         xml.Name         `xml:"EnumerationResults"`
+	ServiceEndpoint string           `xml:"ServiceEndpoint,attr"`
+	Containers      []azureContainer `xml:"Containers>Container"`
+	NextMarker      string           `xml:"NextMarker"`
+}
+
+// isAzureRequest matches requests of Azure Blob API.
+func isAzureRequest(r *http.Request, _ *mux.RouteMatch) bool {
+	return strings.HasPrefix(r.Header.Get("Authorization"), "SharedKey ") ||
+		r.Header.Get("x-ms-version") != ""
+}
+
+// azureAPI handles requests of Azure Blob API for the only container containing
+// files of eserver. Path-style URLs (as used by the storage emulator,
+// http://<host>:<port>/<account>/<container>/<blob>) are expected.
+// Only read operations are supported.
+func (h *apiHandler) azureAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("x-ms-request-id", azureRequestID)
+	w.Header().Set("x-ms-version", azureVersion)
+	if err := h.azure.verify(r); err != nil {
+		h.azureError(w, r, err)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		h.azureError(w, r, newAzureError(http.StatusMethodNotAllowed, "UnsupportedHttpVerb",
+			"Only read operations are supported"))
+		return
+	}
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 3)
+	for len(parts) < 3 {
+		parts = append(parts, "")
+	}
+	account, container, blob := parts[0], parts[1], parts[2]
+	query := r.URL.Query()
+	switch {
+	case account != h.azure.Account:
+		h.azureError(w, r, newAzureError(http.StatusNotFound, "ResourceNotFound",
+			"The specified resource does not exist"))
+	case container == "":
+		if query.Get("comp") != "list" {
+			h.azureError(w, r, newAzureError(http.StatusNotImplemented, "NotImplemented",
+				"The requested operation is not implemented"))
+			return
+		}
+		h.azureListContainers(w, r)
+	case container != objectStoreBucket:
+		h.azureError(w, r, newAzureError(http.StatusNotFound, "ContainerNotFound",
+			"The specified container does not exist"))
+	case blob != "":
+		h.azureGetBlob(w, r, blob)
+	case query.Get("restype") == "container" && query.Get("comp") == "list":
+		h.azureListBlobs(w, r)
+	case query.Get("restype") == "container" && query.Get("comp") == "":
+		modTime := h.bucketCreationTime()
+		w.Header().Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+		w.Header().Set("ETag", fmt.Sprintf("\"%x\"", modTime.UnixNano()))
+		w.WriteHeader(http.StatusOK)
+	default:
+		h.azureError(w, r, newAzureError(http.StatusNotImplemented, "NotImplemented",
+			"The requested operation is not implemented"))
+	}
+}
+
+func (h *apiHandler) azureError(w http.ResponseWriter, r *http.Request, err error) {
+	var azureErr *azureError
+	if !errors.As(err, &azureErr) {
+		azureErr = newAzureError(http.StatusInternalServerError, "InternalError", err.Error())
+	}
+	log.Errorf("Azure Blob request %s %s failed: %v", r.Method, r.URL.Path, azureErr)
+	w.Header().Set("x-ms-error-code", azureErr.Code)
+	writeXML(w, azureErr.status, azureErr)
+}
+
+func (h *apiHandler) azureServiceEndpoint(r *http.Request) string {
+	return fmt.Sprintf("http://%s/%s/", r.Host, h.azure.Account)
+}
+
+func (h *apiHandler) azureListContainers(w http.ResponseWriter, r *http.Request) {
+	modTime := h.bucketCreationTime()
+	result := &azureListContainersResult{ServiceEndpoint: h.azureServiceEndpoint(r)}
+	if strings.HasPrefix(objectStoreBucket, r.URL.Query().Get("prefix")) {
+		result.Containers = append(result.Containers, azureContainer{
+			Name: objectStoreBucket,
+			Properties: azureProperties{
+				LastModified: modTime.UTC().Format(http.TimeFormat),
+				Etag:         fmt.Sprintf("\"%x\"", modTime.UnixNano()),
+				LeaseStatus:  "unlocked",
+				LeaseState:   "available",
+			},
+		})
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+// azureListBlobs handles List Blobs requests.
+func (h *apiHandler) azureListBlobs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	result := &azureListBlobsResult{
+		ServiceEndpoint: h.azureServiceEndpoint(r),
+		ContainerName:   objectStoreBucket,
+		Prefix:          query.Get("prefix"),
+		Marker:          query.Get("marker"),
+		Delimiter:       query.Get("delimiter"),
+	}
+	maxResults := azureMaxResults
+	if value := query.Get("maxresults"); value != "" {
+		var err error
+		if result.MaxResults, err = strconv.Atoi(value); err != nil || result.MaxResults <= 0 {
+			h.azureError(w, r, newAzureError(http.StatusBadRequest, "OutOfRangeQueryParameterValue",
+				"Invalid maxresults"))
+			return
+		}
+		if result.MaxResults < maxResults {
+			maxResults = result.MaxResults
+		}
+	}
+	page, err := h.listObjects(result.Prefix, result.Delimiter, result.Marker, maxResults)
+	if err != nil {
+		h.azureError(w, r, err)
+		return
+	}
+	for _, object := range page.objects {
+		size := object.size
+		result.Blobs = append(result.Blobs, azureBlob{
+			Name: object.name,
+			Properties: azureProperties{
+				LastModified:  object.modTime.UTC().Format(http.TimeFormat),
+				Etag:          object.etag(),
+				ContentLength: &size,
+				ContentType:   "application/octet-stream",
+				BlobType:      azureBlobType,
+				LeaseStatus:   "unlocked",
+				LeaseState:    "available",
+			},
+		})
+	}
+	for _, prefix := range page.prefixes {
+		result.BlobPrefixes = append(result.BlobPrefixes, azureBlobPrefix{Name: prefix})
+	}
+	if page.truncated {
+		result.NextMarker = page.last
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+// azureGetBlob handles Get Blob (with ranges) and Get Blob Properties requests.
+func (h *apiHandler) azureGetBlob(w http.ResponseWriter, r *http.Request, name string) {
+	file, object, err := h.openObject(name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = newAzureError(http.StatusNotFound, "BlobNotFound", "The specified blob does not exist")
+		}
+		h.azureError(w, r, err)
+		return
+	}
+	defer file.Close()
+	if xmsRange := r.Header.Get("x-ms-range"); xmsRange != "" {
+		// x-ms-range takes precedence over Range
+		r.Header.Set("Range", xmsRange)
+	}
+	w.Header().Set("ETag", object.etag())
+	w.Header().Set(contentType, "application/octet-stream")
+	w.Header().Set("x-ms-blob-type", azureBlobType)
+	w.Header().Set("x-ms-lease-status", "unlocked")
+	w.Header().Set("x-ms-lease-state", "available")
+	w.Header().Set("x-ms-server-encrypted", "false")
+	serve := func(w http.ResponseWriter) {
+		http.ServeContent(w, r, name, object.modTime, file)
+	}
+	if r.Method == http.MethodHead {
+		serve(w)
+		return
+	}
+	h.withFaults(w, r, name, serve, func(w http.ResponseWriter, status int) {
+		code := strings.ReplaceAll(http.StatusText(status), " ", "")
+		switch status {
+		case http.StatusInternalServerError:
+			code = "InternalError"
+		case http.StatusServiceUnavailable:
+			code = "ServerBusy"
+		}
+		h.azureError(w, r, newAzureError(status, code, "injected fault"))
+	})
+}
+
+// verify checks Shared Key authorization of the request.
+func (c *azureCredentials) verify(r *http.Request) error {
+	auth := strings.TrimPrefix(r.Header.Get("Authorization"), "SharedKey ")
+	i := strings.LastIndex(auth, ":")
+	if i < 0 || i == len(auth)-1 {
+		return newAzureError(http.StatusForbidden, "AuthenticationFailed",
+			"Missing or malformed Authorization header")
+	}
+	account, signature := auth[:i], auth[i+1:]
+	if c.Account == "" || c.Key == "" || account != c.Account {
+		return newAzureError(http.StatusForbidden, "AuthenticationFailed",
+			fmt.Sprintf("Unknown account %s", account))
+	}
+	key, err := base64.StdEncoding.DecodeString(c.Key)
+	if err != nil {
+		return fmt.Errorf("invalid account key: %w", err)
+	}
+
+	var msHeaders []string
+	for name := range r.Header {
+		if name = strings.ToLower(name); strings.HasPrefix(name, "x-ms-") {
+			msHeaders = append(msHeaders, name)
+		}
+	}
+	sort.Strings(msHeaders)
+	var canonicalHeaders strings.Builder
+	for _, name := range msHeaders {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(r.Header.Get(name)) + "\n")
+	}
+
+	var canonicalResource strings.Builder
+	canonicalResource.WriteString("/" + c.Account + r.URL.EscapedPath())
+	query := r.URL.Query()
+	params := make(map[string][]string)
+	var paramNames []string
+	for name, values := range query {
+		name = strings.ToLower(name)
+		if _, ok := params[name]; !ok {
+			paramNames = append(paramNames, name)
+		}
+		params[name] = append(params[name], values...)
+	}
+	sort.Strings(paramNames)
+	for _, name := range paramNames {
+		values := params[name]
+		sort.Strings(values)
+		canonicalResource.WriteString("\n" + name + ":" + strings.Join(values, ","))
+	}
+
+	contentLength := r.Header.Get("Content-Length")
+	if contentLength == "0" {
+		contentLength = ""
+	}
+	stringToSign := strings.Join([]string{
+		r.Method,
+		r.Header.Get("Content-Encoding"),
+		r.Header.Get("Content-Language"),
+		contentLength,
+		r.Header.Get("Content-MD5"),
+		r.Header.Get("Content-Type"),
+		r.Header.Get("Date"),
+		r.Header.Get("If-Modified-Since"),
+		r.Header.Get("If-Match"),
+		r.Header.Get("If-None-Match"),
+		r.Header.Get("If-Unmodified-Since"),
+		r.Header.Get("Range"),
+		canonicalHeaders.String() + canonicalResource.String(),
+	}, "\n")
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(stringToSign))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return newAzureError(http.StatusForbidden, "AuthenticationFailed",
+			"The MAC signature found in the HTTP request is not the same as any computed signature")
+	}
+	return nil
+}
@@ -9,11 +9,17 @@ import (
 const (
 	contentType   = "Content-Type"
 	mimeTextPlain = "text/plain"
+	mimeJSON      = "application/json"
+	mimeXML       = "application/xml"
 )
 
 func wrapError(err error, w http.ResponseWriter) {
+	wrapErrorWithCode(err, w, http.StatusInternalServerError)
+}
+
+func wrapErrorWithCode(err error, w http.ResponseWriter, code int) {
 	log.Error(err)
 	w.Header().Add(contentType, mimeTextPlain)
-	w.WriteHeader(http.StatusInternalServerError)
+	w.WriteHeader(code)
 	_, _ = w.Write([]byte(err.Error()))
 }
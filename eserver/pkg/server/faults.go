@@ -0,0 +1,315 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eden/eserver/api"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// One byte in every corruptInterval bytes of file content is inverted
+	// if corruption is requested.
+	corruptInterval = 1 << 20
+	// Throttled content is sent in chunks, each followed by a pause.
+	throttleChunksPerSec = 10
+	defaultFaultStatus   = http.StatusServiceUnavailable
+)
+
+var errInjectedFault = errors.New("transfer interrupted by injected fault")
+
+// faultInjector holds fault profiles and counts requests matching them.
+type faultInjector struct {
+	sync.Mutex
+	profiles []api.FaultProfile
+	// clients contains parsed FaultProfile.Client for every profile (nil for all clients).
+	clients []*net.IPNet
+	// requests counts requests for every profile, file and client.
+	requests map[faultRequestKey]uint32
+}
+
+type faultRequestKey struct {
+	profile int
+	file    string
+	client  string
+}
+
+// requestFaults are faults to inject into a single request.
+type requestFaults struct {
+	api.FaultProfile
+	// fail is set if the request should fail.
+	fail bool
+	// requestNum is the ordinal number of the request.
+	requestNum uint32
+}
+
+func newFaultInjector() *faultInjector {
+	return &faultInjector{requests: make(map[faultRequestKey]uint32)}
+}
+
+func parseFaultClient(client string) (*net.IPNet, error) {
+	if client == "" {
+		return nil, nil
+	}
+	if _, subnet, err := net.ParseCIDR(client); err == nil {
+		return subnet, nil
+	}
+	ip := net.ParseIP(client)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid client IP address or subnet: %s", client)
+	}
+	bits := 8 * net.IPv6len
+	if ip.To4() != nil {
+		ip = ip.To4()
+		bits = 8 * net.IPv4len
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
+func validateFaultProfile(profile api.FaultProfile) (*net.IPNet, error) {
+	if _, err := path.Match(profile.Files, ""); err != nil {
+		return nil, fmt.Errorf("invalid file pattern %q: %w", profile.Files, err)
+	}
+	if profile.ErrorStatus != 0 &&
+		(profile.ErrorStatus < 400 || profile.ErrorStatus > 599) {
+		return nil, fmt.Errorf("invalid error status: %d", profile.ErrorStatus)
+	}
+	if profile.ErrorBurstPeriod != 0 && profile.ErrorBurstPeriod <= profile.ErrorBurst {
+		return nil, fmt.Errorf("error burst period (%d) must be longer than the burst (%d)",
+			profile.ErrorBurstPeriod, profile.ErrorBurst)
+	}
+	if profile.TTFB < 0 {
+		return nil, fmt.Errorf("negative TTFB: %v", profile.TTFB)
+	}
+	return parseFaultClient(profile.Client)
+}
+
+// setProfiles replaces all fault profiles (and resets request counters).
+func (f *faultInjector) setProfiles(profiles []api.FaultProfile) error {
+	var clients []*net.IPNet
+	for _, profile := range profiles {
+		client, err := validateFaultProfile(profile)
+		if err != nil {
+			return err
+		}
+		clients = append(clients, client)
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.profiles = profiles
+	f.clients = clients
+	f.requests = make(map[faultRequestKey]uint32)
+	return nil
+}
+
+// addProfile appends fault profile.
+func (f *faultInjector) addProfile(profile api.FaultProfile) error {
+	client, err := validateFaultProfile(profile)
+	if err != nil {
+		return err
+	}
+	f.Lock()
+	defer f.Unlock()
+	f.profiles = append(f.profiles, profile)
+	f.clients = append(f.clients, client)
+	return nil
+}
+
+func (f *faultInjector) getProfiles() []api.FaultProfile {
+	f.Lock()
+	defer f.Unlock()
+	return append([]api.FaultProfile{}, f.profiles...)
+}
+
+// forRequest returns faults to inject into the request for the file made by the client.
+// The first matching profile is used. Returns nil if no profile matches.
+func (f *faultInjector) forRequest(file string, client net.IP) *requestFaults {
+	f.Lock()
+	defer f.Unlock()
+	for i, profile := range f.profiles {
+		if profile.Files != "" {
+			if match, _ := path.Match(profile.Files, file); !match {
+				continue
+			}
+		}
+		if f.clients[i] != nil && !f.clients[i].Contains(client) {
+			continue
+		}
+		key := faultRequestKey{profile: i, file: file, client: client.String()}
+		f.requests[key]++
+		faults := &requestFaults{FaultProfile: profile, requestNum: f.requests[key]}
+		if profile.FailNth != 0 && faults.requestNum%profile.FailNth == 0 {
+			faults.fail = true
+		}
+		if profile.ErrorBurst != 0 {
+			pos := faults.requestNum - 1
+			if profile.ErrorBurstPeriod != 0 {
+				pos %= profile.ErrorBurstPeriod
+			}
+			if pos < profile.ErrorBurst {
+				faults.fail = true
+			}
+		}
+		return faults
+	}
+	return nil
+}
+
+func (faults *requestFaults) errorStatus() int {
+	if faults.ErrorStatus != 0 {
+		return faults.ErrorStatus
+	}
+	return defaultFaultStatus
+}
+
+// corruptContent inverts bytes of buf (containing file content from the given offset)
+// which are at offsets divisible by corruptInterval.
+func corruptContent(buf []byte, offset int64) {
+	first := (offset + corruptInterval - 1) / corruptInterval * corruptInterval
+	for pos := first; pos < offset+int64(len(buf)); pos += corruptInterval {
+		buf[pos-offset] ^= 0xFF
+	}
+}
+
+// throttler limits transfer rate.
+type throttler struct {
+	rate  uint64
+	start time.Time
+	sent  uint64
+}
+
+// wait blocks until n more bytes can be sent.
+func (t *throttler) wait(n int) {
+	if t.start.IsZero() {
+		t.start = time.Now()
+	}
+	t.sent += uint64(n)
+	expected := time.Duration(float64(t.sent) / float64(t.rate) * float64(time.Second))
+	if delay := expected - time.Since(t.start); delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+type connContextKey struct{}
+
+// saveConnInContext is used as http.Server.ConnContext to make the underlying
+// connection available to handlers (needed to inject connection faults).
+func saveConnInContext(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, conn)
+}
+
+func connFromContext(ctx context.Context) net.Conn {
+	conn, _ := ctx.Value(connContextKey{}).(net.Conn)
+	return conn
+}
+
+// faultyResponseWriter wraps http.ResponseWriter and injects faults
+// into the transfer of file content.
+type faultyResponseWriter struct {
+	http.ResponseWriter
+	faults   *requestFaults
+	conn     net.Conn
+	reqURL   string
+	throttle *throttler
+	// offset of the next byte of file content
+	offset  int64
+	written uint64
+	failed  error
+}
+
+func newFaultyResponseWriter(w http.ResponseWriter, r *http.Request,
+	faults *requestFaults) *faultyResponseWriter {
+	writer := &faultyResponseWriter{
+		ResponseWriter: w,
+		faults:         faults,
+		conn:           connFromContext(r.Context()),
+		reqURL:         r.URL.String(),
+	}
+	if faults.Throttle != 0 {
+		writer.throttle = &throttler{rate: faults.Throttle}
+	}
+	return writer
+}
+
+// WriteHeader learns the offset of partial content.
+func (w *faultyResponseWriter) WriteHeader(statusCode int) {
+	if statusCode == http.StatusPartialContent {
+		var start, end int64
+		if _, err := fmt.Sscanf(w.Header().Get("Content-Range"), "bytes %d-%d/",
+			&start, &end); err == nil {
+			w.offset = start
+		}
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write sends (part of) the file content, possibly throttled, corrupted
+// or interrupted by closed connection.
+func (w *faultyResponseWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		if w.failed != nil {
+			return n, w.failed
+		}
+		chunk := p
+		if w.throttle != nil {
+			maxChunk := w.faults.Throttle / throttleChunksPerSec
+			if maxChunk == 0 {
+				maxChunk = 1
+			}
+			if uint64(len(chunk)) > maxChunk {
+				chunk = chunk[:maxChunk]
+			}
+		}
+		drop := w.faults.DropAfter != 0 && w.written+uint64(len(chunk)) >= w.faults.DropAfter
+		if drop {
+			chunk = chunk[:w.faults.DropAfter-w.written]
+		}
+		if w.throttle != nil {
+			w.throttle.wait(len(chunk))
+		}
+		if len(chunk) > 0 {
+			out := chunk
+			if w.faults.Corrupt {
+				out = append([]byte{}, chunk...)
+				corruptContent(out, w.offset)
+			}
+			var written int
+			written, err = w.ResponseWriter.Write(out)
+			n += written
+			w.written += uint64(written)
+			w.offset += int64(written)
+			if err != nil {
+				return n, err
+			}
+		}
+		if drop {
+			w.drop()
+			return n, w.failed
+		}
+		p = p[len(chunk):]
+	}
+	return n, nil
+}
+
+func (w *faultyResponseWriter) drop() {
+	w.failed = errInjectedFault
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	if w.conn == nil {
+		log.Errorf("Missing connection for request %s, cannot inject fault", w.reqURL)
+		return
+	}
+	log.Infof("Injected fault (connection closed) after %d bytes of content for request %s",
+		w.written, w.reqURL)
+	if err := w.conn.Close(); err != nil {
+		log.Errorf("Failed to close connection for request %s: %v", w.reqURL, err)
+	}
+}
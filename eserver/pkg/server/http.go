@@ -2,6 +2,7 @@ package server
 
 import (
 	"fmt"
+	"log"
 	"net"
 	"net/http"
 
@@ -11,10 +12,30 @@ import (
 func (s *EServer) serveHTTP(listener net.Listener, errorChan chan error) {
 	api := &apiHandler{
 		manager: s.Manager,
+		faults:  s.faults,
+	}
+	// object storage emulation is enabled only with explicitly configured credentials,
+	// otherwise signed requests would bypass authorization of /eserver/*
+	if s.S3AccessKey != "" && s.S3SecretKey != "" {
+		api.s3 = &s3Credentials{
+			AccessKey: s.S3AccessKey,
+			SecretKey: s.S3SecretKey,
+		}
+	} else {
+		log.Println("S3 emulation is disabled, access and secret keys are not set")
+	}
+	if s.AzureAccount != "" && s.AzureKey != "" {
+		api.azure = &azureCredentials{
+			Account: s.AzureAccount,
+			Key:     s.AzureKey,
+		}
+	} else {
+		log.Println("Azure Blob emulation is disabled, account and key are not set")
 	}
 
 	admin := &adminHandler{
 		manager: s.Manager,
+		faults:  s.faults,
 	}
 
 	router := mux.NewRouter()
@@ -22,17 +43,46 @@ func (s *EServer) serveHTTP(listener net.Listener, errorChan chan error) {
 	ad := router.PathPrefix("/admin").Subrouter()
 
 	router.Use(logRequest)
+	ad.Use(requireAuth(&httpCredentials{
+		user:     s.AdminUser,
+		password: s.AdminPassword,
+	}, "eserver admin"))
 
 	ad.HandleFunc("/list", admin.list).Methods("GET")
 	ad.HandleFunc("/add-from-url", admin.addFromURL).Methods("POST")
 	ad.HandleFunc("/add-from-file", admin.addFromFile).Methods("POST")
 	ad.HandleFunc("/status/{filename:[A-Za-z0-9_\\-.\\/]*}", admin.getFileStatus).Methods("GET")
+	ad.HandleFunc("/files", admin.listFiles).Methods("GET")
+	ad.HandleFunc("/files/{filename:[A-Za-z0-9_\\-.\\/]*}", admin.deleteFile).Methods("DELETE")
+	ad.HandleFunc("/gc", admin.collectGarbage).Methods("POST")
+	ad.HandleFunc("/downloads", admin.listDownloads).Methods("GET")
+	ad.HandleFunc("/downloads/{id}", admin.getDownload).Methods("GET")
+	ad.HandleFunc("/downloads/{id}", admin.cancelDownload).Methods("DELETE")
+	ad.HandleFunc("/faults", admin.getFaults).Methods("GET")
+	ad.HandleFunc("/faults", admin.setFaults).Methods("PUT")
+	ad.HandleFunc("/faults", admin.addFault).Methods("POST")
+	ad.HandleFunc("/faults", admin.clearFaults).Methods("DELETE")
+
+	// S3 and Azure Blob requests are recognized by their authorization
+	if api.s3 != nil {
+		router.MatcherFunc(isS3Request).HandlerFunc(api.s3API)
+	}
+	if api.azure != nil {
+		router.MatcherFunc(isAzureRequest).HandlerFunc(api.azureAPI)
+	}
 
-	router.HandleFunc("/eserver/{filename:[A-Za-z0-9_\\-.\\/]*}", api.getFile).Methods("GET")
+	es := router.PathPrefix("/eserver").Subrouter()
+	es.Use(requireAuth(&httpCredentials{
+		user:     s.HTTPUser,
+		password: s.HTTPPassword,
+		token:    s.HTTPToken,
+	}, "eserver"))
+	es.HandleFunc("/{filename:[A-Za-z0-9_\\-.\\/]*}", api.getFile).Methods("GET")
 
 	server := &http.Server{
-		Handler: router,
-		Addr:    fmt.Sprintf("%s:%s", s.Address, s.Port),
+		Handler:     router,
+		Addr:        fmt.Sprintf("%s:%s", s.Address, s.Port),
+		ConnContext: saveConnInContext,
 	}
 	errorChan <- server.Serve(listener)
 }
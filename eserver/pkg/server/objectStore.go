@@ -0,0 +1,161 @@
+package server
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Files of eserver are available via S3 and Azure Blob emulation
+// as objects in the only bucket (container) with this name.
+const objectStoreBucket = "eserver"
+
+// objectInfo describes file of eserver as an object of bucket (container).
+type objectInfo struct {
+	name    string
+	digest  string
+	size    int64
+	modTime time.Time
+}
+
+// etag returns entity tag of the object (based on sha256 of its content).
+func (o *objectInfo) etag() string {
+	return fmt.Sprintf("%q", o.digest)
+}
+
+// objectPage is a page of listed objects.
+type objectPage struct {
+	objects []*objectInfo
+	// prefixes are common prefixes of names (up to the delimiter)
+	// standing in place of objects with these prefixes.
+	prefixes  []string
+	truncated bool
+	// last is the last object name or prefix on the page.
+	last string
+}
+
+// openObject opens file with the name and returns information about it.
+func (h *apiHandler) openObject(name string) (*os.File, *objectInfo, error) {
+	info := h.manager.GetFileInfo(name)
+	if !info.ISReady {
+		return nil, nil, os.ErrNotExist
+	}
+	filePath, err := h.manager.GetFilePath(name)
+	if err != nil {
+		return nil, nil, os.ErrNotExist
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	fi, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, nil, err
+	}
+	if fi.IsDir() {
+		_ = file.Close()
+		return nil, nil, os.ErrNotExist
+	}
+	return file, &objectInfo{
+		name:    name,
+		digest:  info.Sha256,
+		size:    fi.Size(),
+		modTime: fi.ModTime(),
+	}, nil
+}
+
+// listObjects returns page of objects with names starting with prefix, sorted by name.
+// Only names (or common prefixes) after the marker are returned.
+// If delimiter is set, objects with the same part of name between the prefix
+// and the delimiter are replaced with the common prefix.
+func (h *apiHandler) listObjects(prefix, delimiter, marker string, maxKeys int) (*objectPage, error) {
+	files, err := h.manager.ListFiles()
+	if err != nil {
+		return nil, err
+	}
+	var objects []*objectInfo
+	for _, file := range files {
+		name := strings.TrimPrefix(file.FileName, objectStoreBucket+"/")
+		if !strings.HasPrefix(name, prefix) || name <= marker {
+			continue
+		}
+		if delimiter != "" && strings.HasSuffix(marker, delimiter) && strings.HasPrefix(name, marker) {
+			// already listed as common prefix
+			continue
+		}
+		filePath, err := h.manager.GetFilePath(name)
+		if err != nil {
+			continue
+		}
+		fi, err := os.Stat(filePath)
+		if err != nil {
+			continue
+		}
+		objects = append(objects, &objectInfo{
+			name:    name,
+			digest:  file.Sha256,
+			size:    file.Size,
+			modTime: fi.ModTime(),
+		})
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].name < objects[j].name
+	})
+	page := &objectPage{}
+	for _, object := range objects {
+		commonPrefix := ""
+		if delimiter != "" {
+			if i := strings.Index(object.name[len(prefix):], delimiter); i >= 0 {
+				commonPrefix = object.name[:len(prefix)+i+len(delimiter)]
+			}
+		}
+		if commonPrefix != "" && commonPrefix == page.last {
+			continue
+		}
+		if len(page.objects)+len(page.prefixes) >= maxKeys {
+			page.truncated = true
+			break
+		}
+		if commonPrefix != "" {
+			page.prefixes = append(page.prefixes, commonPrefix)
+			page.last = commonPrefix
+		} else {
+			page.objects = append(page.objects, object)
+			page.last = object.name
+		}
+	}
+	return page, nil
+}
+
+// bucketCreationTime returns time to report as creation time of the bucket.
+func (h *apiHandler) bucketCreationTime() time.Time {
+	fi, err := os.Stat(h.manager.Dir)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
+
+// hasParam checks if the query contains the parameter (possibly without value).
+func hasParam(query url.Values, name string) bool {
+	_, ok := query[name]
+	return ok
+}
+
+func writeXML(w http.ResponseWriter, code int, obj interface{}) {
+	out, err := xml.Marshal(obj)
+	if err != nil {
+		wrapError(err, w)
+		return
+	}
+	w.Header().Set(contentType, mimeXML)
+	w.WriteHeader(code)
+	_, _ = w.Write([]byte(xml.Header))
+	_, _ = w.Write(out)
+}
@@ -0,0 +1,447 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3DateFormat      = "20060102T150405Z"
+	s3LastModified    = "2006-01-02T15:04:05.000Z"
+	s3Namespace       = "http://s3.amazonaws.com/doc/2006-03-01/"
+	s3EmptySha256     = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	s3MaxKeys         = 1000
+	s3RequestID       = "eserver"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	// s3MaxClockSkew is the maximum difference between time of header-signed request and current time
+	s3MaxClockSkew = 15 * time.Minute
+)
+
+// s3Credentials are credentials of the only user of S3 emulation.
+type s3Credentials struct {
+	AccessKey string
+	SecretKey string
+}
+
+// s3Error is an error response of S3 API.
+type s3Error struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string   `xml:"Code"`
+	Message   string   `xml:"Message"`
+	Resource  string   `xml:"Resource"`
+	RequestID string   `xml:"RequestId"`
+	status    int
+}
+
+func (e *s3Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func newS3Error(status int, code, message string) *s3Error {
+	return &s3Error{Code: code, Message: message, status: status}
+}
+
+type s3Object struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         int64  `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type s3CommonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+type s3ListBucketResult struct {
+	XMLName               xml.Name         `xml:"ListBucketResult"`
+	Xmlns                 string           `xml:"xmlns,attr"`
+	Name                  string           `xml:"Name"`
+	Prefix                string           `xml:"Prefix"`
+	Delimiter             string           `xml:"Delimiter,omitempty"`
+	MaxKeys               int              `xml:"MaxKeys"`
+	EncodingType          string           `xml:"EncodingType,omitempty"`
+	IsTruncated           bool             `xml:"IsTruncated"`
+	Marker                *string          `xml:"Marker"`
+	NextMarker            string           `xml:"NextMarker,omitempty"`
+	KeyCount              *int             `xml:"KeyCount"`
+	ContinuationToken     string           `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string           `xml:"NextContinuationToken,omitempty"`
+	StartAfter            string           `xml:"StartAfter,omitempty"`
+	Contents              []s3Object       `xml:"Contents"`
+	CommonPrefixes        []s3CommonPrefix `xml:"CommonPrefixes"`
+}
+
+type s3Bucket struct {
+	Name         string `xml:"Name"`
+	CreationDate string `xml:"CreationDate"`
+}
+
+type s3ListAllMyBucketsResult struct {
+	XMLName xml.Name   `xml:"ListAllMyBucketsResult"`
+	Xmlns   string     `xml:"xmlns,attr"`
+	OwnerID string     `xml:"Owner>ID"`
+	Buckets []s3Bucket `xml:"Buckets>Bucket"`
+}
+
+type s3ListMultipartUploadsResult struct {
+	XMLName        xml.Name `xml:"ListMultipartUploadsResult"`
+	Xmlns          string   `xml:"xmlns,attr"`
+	Bucket         string   `xml:"Bucket"`
+	KeyMarker      string   `xml:"KeyMarker"`
+	UploadIDMarker string   `xml:"UploadIdMarker"`
+	Prefix         string   `xml:"Prefix"`
+	MaxUploads     int      `xml:"MaxUploads"`
+	IsTruncated    bool     `xml:"IsTruncated"`
+}
+
+type s3LocationConstraint struct {
+	XMLName xml.Name `xml:"LocationConstraint"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Region  string   `xml:",chardata"`
+}
+
+// isS3Request matches requests signed with AWS Signature Version 4.
+func isS3Request(r *http.Request, _ *mux.RouteMatch) bool {
+	return strings.HasPrefix(r.Header.Get("Authorization"), s3Algorithm) ||
+		r.URL.Query().Get("X-Amz-Algorithm") == s3Algorithm
+}
+
+// s3API handles requests of S3 API (path-style) for the only bucket containing
+// files of eserver. Only read operations are supported.
+func (h *apiHandler) s3API(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("x-amz-request-id", s3RequestID)
+	region, err := h.s3.verify(r)
+	if err != nil {
+		h.s3Error(w, r, err)
+		return
+	}
+	bucket, key := r.URL.Path, ""
+	bucket = strings.TrimPrefix(bucket, "/")
+	if i := strings.Index(bucket, "/"); i >= 0 {
+		bucket, key = bucket[:i], bucket[i+1:]
+	}
+	query := r.URL.Query()
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		h.s3Error(w, r, newS3Error(http.StatusNotImplemented, "NotImplemented",
+			"Only read operations are supported"))
+		return
+	}
+	switch {
+	case bucket == "":
+		h.s3ListBuckets(w)
+	case bucket != objectStoreBucket:
+		h.s3Error(w, r, newS3Error(http.StatusNotFound, "NoSuchBucket",
+			"The specified bucket does not exist"))
+	case key != "":
+		if hasParam(query, "uploadId") {
+			h.s3Error(w, r, newS3Error(http.StatusNotFound, "NoSuchUpload",
+				"The specified multipart upload does not exist"))
+			return
+		}
+		h.s3GetObject(w, r, key)
+	case r.Method == http.MethodHead:
+		w.Header().Set("x-amz-bucket-region", region)
+		w.WriteHeader(http.StatusOK)
+	case hasParam(query, "location"):
+		writeXML(w, http.StatusOK, &s3LocationConstraint{Xmlns: s3Namespace})
+	case hasParam(query, "uploads"):
+		writeXML(w, http.StatusOK, &s3ListMultipartUploadsResult{
+			Xmlns:      s3Namespace,
+			Bucket:     bucket,
+			KeyMarker:  query.Get("key-marker"),
+			Prefix:     query.Get("prefix"),
+			MaxUploads: s3MaxKeys,
+		})
+	default:
+		h.s3ListObjects(w, r)
+	}
+}
+
+func (h *apiHandler) s3Error(w http.ResponseWriter, r *http.Request, err error) {
+	var s3Err *s3Error
+	if !errors.As(err, &s3Err) {
+		s3Err = newS3Error(http.StatusInternalServerError, "InternalError", err.Error())
+	}
+	s3Err.Resource = r.URL.Path
+	s3Err.RequestID = s3RequestID
+	log.Errorf("S3 request %s %s failed: %v", r.Method, r.URL.Path, s3Err)
+	writeXML(w, s3Err.status, s3Err)
+}
+
+func (h *apiHandler) s3ListBuckets(w http.ResponseWriter) {
+	writeXML(w, http.StatusOK, &s3ListAllMyBucketsResult{
+		Xmlns:   s3Namespace,
+		OwnerID: h.s3.AccessKey,
+		Buckets: []s3Bucket{{
+			Name:         objectStoreBucket,
+			CreationDate: h.bucketCreationTime().UTC().Format(s3LastModified),
+		}},
+	})
+}
+
+// s3ListObjects handles ListObjects and ListObjectsV2 requests.
+func (h *apiHandler) s3ListObjects(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	result := &s3ListBucketResult{
+		Xmlns:        s3Namespace,
+		Name:         objectStoreBucket,
+		Prefix:       query.Get("prefix"),
+		Delimiter:    query.Get("delimiter"),
+		MaxKeys:      s3MaxKeys,
+		EncodingType: query.Get("encoding-type"),
+	}
+	if maxKeys := query.Get("max-keys"); maxKeys != "" {
+		value, err := strconv.Atoi(maxKeys)
+		if err != nil || value < 0 {
+			h.s3Error(w, r, newS3Error(http.StatusBadRequest, "InvalidArgument",
+				"Invalid max-keys"))
+			return
+		}
+		if value < s3MaxKeys {
+			result.MaxKeys = value
+		}
+	}
+	listV2 := query.Get("list-type") == "2"
+	marker := query.Get("marker")
+	if listV2 {
+		result.ContinuationToken = query.Get("continuation-token")
+		result.StartAfter = query.Get("start-after")
+		marker = result.StartAfter
+		if result.ContinuationToken != "" {
+			token, err := base64.URLEncoding.DecodeString(result.ContinuationToken)
+			if err != nil {
+				h.s3Error(w, r, newS3Error(http.StatusBadRequest, "InvalidArgument",
+					"The continuation token provided is incorrect"))
+				return
+			}
+			marker = string(token)
+		}
+	} else {
+		result.Marker = &marker
+	}
+	page, err := h.listObjects(result.Prefix, result.Delimiter, marker, result.MaxKeys)
+	if err != nil {
+		h.s3Error(w, r, err)
+		return
+	}
+	encode := func(s string) string {
+		if result.EncodingType == "url" {
+			return s3Escape(s, true)
+		}
+		return s
+	}
+	for _, object := range page.objects {
+		result.Contents = append(result.Contents, s3Object{
+			Key:          encode(object.name),
+			LastModified: object.modTime.UTC().Format(s3LastModified),
+			ETag:         object.etag(),
+			Size:         object.size,
+			StorageClass: "STANDARD",
+		})
+	}
+	for _, prefix := range page.prefixes {
+		result.CommonPrefixes = append(result.CommonPrefixes, s3CommonPrefix{Prefix: encode(prefix)})
+	}
+	result.IsTruncated = page.truncated
+	if listV2 {
+		keyCount := len(page.objects) + len(page.prefixes)
+		result.KeyCount = &keyCount
+		if page.truncated {
+			result.NextContinuationToken = base64.URLEncoding.EncodeToString([]byte(page.last))
+		}
+	} else if page.truncated {
+		result.NextMarker = encode(page.last)
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+// s3GetObject handles GetObject (with ranges) and HeadObject requests.
+func (h *apiHandler) s3GetObject(w http.ResponseWriter, r *http.Request, key string) {
+	file, object, err := h.openObject(key)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			err = newS3Error(http.StatusNotFound, "NoSuchKey", "The specified key does not exist")
+		}
+		h.s3Error(w, r, err)
+		return
+	}
+	defer file.Close()
+	w.Header().Set("ETag", object.etag())
+	w.Header().Set(contentType, "binary/octet-stream")
+	serve := func(w http.ResponseWriter) {
+		http.ServeContent(w, r, key, object.modTime, file)
+	}
+	if r.Method == http.MethodHead {
+		serve(w)
+		return
+	}
+	h.withFaults(w, r, key, serve, func(w http.ResponseWriter, status int) {
+		code := strings.ReplaceAll(http.StatusText(status), " ", "")
+		switch status {
+		case http.StatusInternalServerError:
+			code = "InternalError"
+		case http.StatusServiceUnavailable:
+			code = "SlowDown"
+		}
+		h.s3Error(w, r, newS3Error(status, code, "injected fault"))
+	})
+}
+
+// verify checks AWS Signature Version 4 of the request (signed using
+// the Authorization header or presigned URL) and returns the region from the signature.
+func (c *s3Credentials) verify(r *http.Request) (region string, err error) {
+	query := r.URL.Query()
+	var credential, signedHeaders, signature, date, payloadHash string
+	presigned := query.Get("X-Amz-Algorithm") == s3Algorithm
+	if presigned {
+		credential = query.Get("X-Amz-Credential")
+		signedHeaders = query.Get("X-Amz-SignedHeaders")
+		signature = query.Get("X-Amz-Signature")
+		date = query.Get("X-Amz-Date")
+		payloadHash = s3UnsignedPayload
+	} else {
+		auth := strings.TrimPrefix(r.Header.Get("Authorization"), s3Algorithm)
+		for _, field := range strings.Split(auth, ",") {
+			field = strings.TrimSpace(field)
+			if i := strings.Index(field, "="); i > 0 {
+				switch field[:i] {
+				case "Credential":
+					credential = field[i+1:]
+				case "SignedHeaders":
+					signedHeaders = field[i+1:]
+				case "Signature":
+					signature = field[i+1:]
+				}
+			}
+		}
+		date = r.Header.Get("X-Amz-Date")
+		payloadHash = r.Header.Get("X-Amz-Content-Sha256")
+		if payloadHash == "" {
+			payloadHash = s3EmptySha256
+		}
+	}
+	// credential is <access key>/<date>/<region>/<service>/aws4_request
+	scope := strings.Split(credential, "/")
+	if len(scope) != 5 || scope[4] != "aws4_request" || signedHeaders == "" || signature == "" {
+		return "", newS3Error(http.StatusBadRequest, "AuthorizationHeaderMalformed",
+			"The authorization header is malformed")
+	}
+	if c.AccessKey == "" || c.SecretKey == "" || scope[0] != c.AccessKey {
+		return "", newS3Error(http.StatusForbidden, "InvalidAccessKeyId",
+			"The AWS Access Key Id you provided does not exist in our records")
+	}
+	signedAt, err := time.Parse(s3DateFormat, date)
+	if err != nil {
+		return "", newS3Error(http.StatusForbidden, "AccessDenied", "Invalid date")
+	}
+	if presigned {
+		expires, err := strconv.Atoi(query.Get("X-Amz-Expires"))
+		if err != nil {
+			return "", newS3Error(http.StatusForbidden, "AccessDenied", "Invalid expiration")
+		}
+		if time.Now().After(signedAt.Add(time.Duration(expires) * time.Second)) {
+			return "", newS3Error(http.StatusForbidden, "AccessDenied", "Request has expired")
+		}
+	} else if skew := time.Since(signedAt); skew > s3MaxClockSkew || skew < -s3MaxClockSkew {
+		return "", newS3Error(http.StatusForbidden, "RequestTimeTooSkewed",
+			"The difference between the request time and the current time is too large")
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, name := range strings.Split(signedHeaders, ";") {
+		var value string
+		if name == "host" {
+			value = r.Host
+		} else {
+			var values []string
+			for _, v := range r.Header.Values(name) {
+				values = append(values, strings.Join(strings.Fields(v), " "))
+			}
+			value = strings.Join(values, ",")
+		}
+		canonicalHeaders.WriteString(name + ":" + value + "\n")
+	}
+	// parameters are sorted by name and then by value
+	var canonicalQuery [][2]string
+	for name, values := range query {
+		if presigned && name == "X-Amz-Signature" {
+			continue
+		}
+		for _, value := range values {
+			canonicalQuery = append(canonicalQuery, [2]string{s3Escape(name, false), s3Escape(value, false)})
+		}
+	}
+	sort.Slice(canonicalQuery, func(i, j int) bool {
+		if canonicalQuery[i][0] != canonicalQuery[j][0] {
+			return canonicalQuery[i][0] < canonicalQuery[j][0]
+		}
+		return canonicalQuery[i][1] < canonicalQuery[j][1]
+	})
+	var queryParams []string
+	for _, param := range canonicalQuery {
+		queryParams = append(queryParams, param[0]+"="+param[1])
+	}
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		s3Escape(r.URL.Path, true),
+		strings.Join(queryParams, "&"),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		date,
+		strings.Join(scope[1:], "/"),
+		hex.EncodeToString(canonicalHash[:]),
+	}, "\n")
+	key := []byte("AWS4" + c.SecretKey)
+	for _, part := range scope[1:] {
+		key = hmacSha256(key, part)
+	}
+	expected := hex.EncodeToString(hmacSha256(key, stringToSign))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return "", newS3Error(http.StatusForbidden, "SignatureDoesNotMatch",
+			"The request signature we calculated does not match the signature you provided")
+	}
+	return scope[2], nil
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Escape encodes string as required for the canonical request
+// (all bytes except unreserved characters are percent-encoded).
+func s3Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (keepSlash && c == '/') {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
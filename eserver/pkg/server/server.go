@@ -1,6 +1,7 @@
 package server
 
 import (
+	"crypto/tls"
 	"fmt"
 	"log"
 	"net"
@@ -17,6 +18,25 @@ type EServer struct {
 	User     string
 	Password string
 	ReadOnly bool
+	// SSHHostKey is the file with host key for SFTP (generated if not exists)
+	SSHHostKey string
+	// CertFile and KeyFile enable TLS for HTTP if set
+	CertFile string
+	KeyFile  string
+	// Credentials for /eserver/* (basic or bearer authorization), not checked if empty
+	HTTPUser     string
+	HTTPPassword string
+	HTTPToken    string
+	// Credentials for /admin/*, not checked if empty
+	AdminUser     string
+	AdminPassword string
+	// Credentials for S3 and Azure Blob emulation, emulation is disabled if not set
+	S3AccessKey  string
+	S3SecretKey  string
+	AzureAccount string
+	AzureKey     string
+
+	faults *faultInjector
 }
 
 // log the request and client
@@ -31,14 +51,26 @@ func logRequest(next http.Handler) http.Handler {
 //  /admin/list endpoint returns list of files
 //  /admin/add-from-url endpoint fires download
 //  /admin/status/{filename} returns fileinfo
+//  /admin/files returns fileinfo of all files
+//  /admin/files/{filename} (DELETE) removes file
+//  /admin/gc removes unreferenced blobs (and files not in keep list if pruning)
+//  /admin/downloads returns download jobs
+//  /admin/downloads/{id} returns download job, DELETE cancels it
+//  /admin/faults returns (GET), replaces (PUT), adds (POST) or clears (DELETE) fault profiles
 //  /eserver/{filename} returns file
+//  /admin/* and /eserver/* require authorization if credentials are set
+//  S3 (path-style, bucket "eserver") and Azure Blob (/{account}/eserver/{blob})
+//  requests are recognized by their authorization and return files as well,
+//  only if credentials of the emulation are set
 func (s *EServer) Start() {
 
 	s.Manager.Init()
+	s.faults = newFaultInjector()
 
 	log.Println("Starting eserver:")
 	log.Printf("\tIP:Port: %s:%s\n", s.Address, s.Port)
 	log.Printf("\tDirectory: %s\n", s.Manager.Dir)
+	log.Printf("\tMax downloads: %d\n", s.Manager.MaxDownloads)
 
 	// server both services (sftp and http) on the same port
 	l, err := net.Listen("tcp", fmt.Sprintf("%s:%s", s.Address, s.Port))
@@ -46,6 +78,16 @@ func (s *EServer) Start() {
 		log.Fatalf("net.Listen error: %s", err)
 	}
 	sshListener, httpListener := MuxListener(l)
+	if s.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(s.CertFile, s.KeyFile)
+		if err != nil {
+			log.Fatalf("cannot load TLS certificate: %s", err)
+		}
+		httpListener = tls.NewListener(httpListener, &tls.Config{
+			Certificates: []tls.Certificate{cert},
+		})
+		log.Println("\tTLS: enabled")
+	}
 	errorChan := make(chan error)
 	go s.serveHTTP(httpListener, errorChan)
 	go s.serveSFTP(sshListener, errorChan)
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
 	"fmt"
 	"io"
 	"log"
@@ -22,23 +24,17 @@ func (s *EServer) serveSFTP(listener net.Listener, errorChan chan error) {
 		},
 	}
 
-	serverOptions := []sftp.ServerOption{
-		sftp.WithDebug(os.Stderr),
-	}
-
-	if s.ReadOnly {
-		serverOptions = append(serverOptions, sftp.ReadOnly())
-	}
-
-	privateBytes, err := os.ReadFile("/root/.ssh/id_rsa")
+	// Paths relative to the working directory are resolved
+	// in the same way as by sftp.NewServer.
+	workDir, err := os.Getwd()
 	if err != nil {
-		errorChan <- fmt.Errorf("serveSFTP: failed load private key: %s", err)
+		errorChan <- fmt.Errorf("serveSFTP: failed to get working directory: %s", err)
 		return
 	}
 
-	private, err := ssh.ParsePrivateKey(privateBytes)
+	private, err := loadSSHHostKey(s.SSHHostKey)
 	if err != nil {
-		errorChan <- fmt.Errorf("serveSFTP: failed to parse private key: %s", err)
+		errorChan <- fmt.Errorf("serveSFTP: %s", err)
 		return
 	}
 
@@ -92,14 +88,11 @@ func (s *EServer) serveSFTP(listener net.Listener, errorChan chan error) {
 					}
 				}(requests)
 
-				server, err := sftp.NewServer(
+				server := sftp.NewRequestServer(
 					channel,
-					serverOptions...,
+					newSFTPHandlers(s, conn),
+					sftp.WithStartDirectory(workDir),
 				)
-				if err != nil {
-					log.Printf("serveSFTP: NewServer error: %s\n", err)
-					return
-				}
 				if err := server.Serve(); err == io.EOF {
 					if err := server.Close(); err != nil {
 						log.Printf("serveSFTP: cannot close server: %s\n", err)
@@ -112,3 +105,25 @@ func (s *EServer) serveSFTP(listener net.Listener, errorChan chan error) {
 		}(nConn)
 	}
 }
+
+// loadSSHHostKey reads host key from the file
+// or generates a new one if the file does not exist
+func loadSSHHostKey(keyFile string) (ssh.Signer, error) {
+	privateBytes, err := os.ReadFile(keyFile)
+	if err == nil {
+		private, err := ssh.ParsePrivateKey(privateBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %s", err)
+		}
+		return private, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed load private key: %s", err)
+	}
+	log.Printf("serveSFTP: %s does not exist, generating host key\n", keyFile)
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate private key: %s", err)
+	}
+	return ssh.NewSignerFromKey(key)
+}
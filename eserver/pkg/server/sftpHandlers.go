@@ -0,0 +1,285 @@
+package server
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	log "github.com/sirupsen/logrus"
+)
+
+// sftpHandlers serve SFTP requests from the local filesystem
+// and inject faults into reads of files matching fault profiles.
+type sftpHandlers struct {
+	readOnly bool
+	// filesDir is the absolute path of the directory with served files,
+	// used to get file names for matching against fault profiles.
+	filesDir string
+	faults   *faultInjector
+	conn     net.Conn
+	clientIP net.IP
+}
+
+func newSFTPHandlers(s *EServer, conn net.Conn) sftp.Handlers {
+	filesDir, err := filepath.Abs(s.Manager.Dir)
+	if err != nil {
+		log.Errorf("serveSFTP: cannot get absolute path of %s: %v", s.Manager.Dir, err)
+	}
+	var clientIP net.IP
+	if tcpAddr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		clientIP = tcpAddr.IP
+	}
+	handlers := &sftpHandlers{
+		readOnly: s.ReadOnly,
+		filesDir: filesDir,
+		faults:   s.faults,
+		conn:     conn,
+		clientIP: clientIP,
+	}
+	return sftp.Handlers{
+		FileGet:  handlers,
+		FilePut:  handlers,
+		FileCmd:  handlers,
+		FileList: handlers,
+	}
+}
+
+// fileName returns name of the served file (relative to filesDir)
+// or empty string if the path is outside of filesDir.
+func (h *sftpHandlers) fileName(filePath string) string {
+	if h.filesDir == "" {
+		return ""
+	}
+	name, err := filepath.Rel(h.filesDir, filePath)
+	if err != nil || name == ".." || strings.HasPrefix(name, "../") {
+		return ""
+	}
+	return filepath.ToSlash(name)
+}
+
+// Fileread opens file for reading.
+func (h *sftpHandlers) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	file, err := os.Open(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+	name := h.fileName(r.Filepath)
+	if name == "" {
+		return file, nil
+	}
+	faults := h.faults.forRequest(name, h.clientIP)
+	if faults == nil {
+		return file, nil
+	}
+	if faults.fail {
+		_ = file.Close()
+		log.Infof("Injected fault (failure) into SFTP request #%d for %s from %s",
+			faults.requestNum, name, h.clientIP)
+		return nil, sftp.ErrSSHFxFailure
+	}
+	reader := &faultyReaderAt{
+		file:   file,
+		name:   name,
+		faults: faults,
+		conn:   h.conn,
+	}
+	if faults.Throttle != 0 {
+		reader.throttle = &throttler{rate: faults.Throttle}
+	}
+	return reader, nil
+}
+
+// Filewrite opens file for writing.
+func (h *sftpHandlers) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	if h.readOnly {
+		return nil, sftp.ErrSSHFxPermissionDenied
+	}
+	pflags := r.Pflags()
+	flags := os.O_WRONLY
+	if pflags.Read {
+		flags = os.O_RDWR
+	}
+	if pflags.Creat {
+		flags |= os.O_CREATE
+	}
+	if pflags.Trunc {
+		flags |= os.O_TRUNC
+	}
+	if pflags.Excl {
+		flags |= os.O_EXCL
+	}
+	return os.OpenFile(r.Filepath, flags, 0644)
+}
+
+// Filecmd handles file modifications.
+func (h *sftpHandlers) Filecmd(r *sftp.Request) error {
+	if h.readOnly {
+		return sftp.ErrSSHFxPermissionDenied
+	}
+	switch r.Method {
+	case "Setstat":
+		attrFlags := r.AttrFlags()
+		attrs := r.Attributes()
+		if attrFlags.Size {
+			if err := os.Truncate(r.Filepath, int64(attrs.Size)); err != nil {
+				return err
+			}
+		}
+		if attrFlags.Permissions {
+			if err := os.Chmod(r.Filepath, attrs.FileMode()); err != nil {
+				return err
+			}
+		}
+		if attrFlags.Acmodtime {
+			if err := os.Chtimes(r.Filepath, time.Unix(int64(attrs.Atime), 0),
+				time.Unix(int64(attrs.Mtime), 0)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "Rename":
+		return os.Rename(r.Filepath, r.Target)
+	case "Rmdir", "Remove":
+		return os.Remove(r.Filepath)
+	case "Mkdir":
+		return os.Mkdir(r.Filepath, 0755)
+	case "Link":
+		return os.Link(r.Filepath, r.Target)
+	case "Symlink":
+		// r.Filepath is the target, and r.Target is the link path.
+		return os.Symlink(r.Filepath, r.Target)
+	}
+	return sftp.ErrSSHFxOpUnsupported
+}
+
+// Filelist handles listing of directories and stat of files.
+func (h *sftpHandlers) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	switch r.Method {
+	case "List":
+		entries, err := os.ReadDir(r.Filepath)
+		if err != nil {
+			return nil, err
+		}
+		var infos listerAt
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			infos = append(infos, info)
+		}
+		return infos, nil
+	case "Stat":
+		info, err := os.Stat(r.Filepath)
+		if err != nil {
+			return nil, err
+		}
+		return listerAt{info}, nil
+	case "Readlink":
+		target, err := os.Readlink(r.Filepath)
+		if err != nil {
+			return nil, err
+		}
+		return listerAt{linkTarget(target)}, nil
+	}
+	return nil, sftp.ErrSSHFxOpUnsupported
+}
+
+// Lstat returns information about file without following symlinks.
+func (h *sftpHandlers) Lstat(r *sftp.Request) (sftp.ListerAt, error) {
+	info, err := os.Lstat(r.Filepath)
+	if err != nil {
+		return nil, err
+	}
+	return listerAt{info}, nil
+}
+
+type listerAt []os.FileInfo
+
+// ListAt copies file infos starting from offset into f.
+func (l listerAt) ListAt(f []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(f, l[offset:])
+	if n < len(f) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// linkTarget is returned for Readlink (only the name is used by the SFTP server).
+type linkTarget string
+
+func (t linkTarget) Name() string       { return string(t) }
+func (t linkTarget) Size() int64        { return 0 }
+func (t linkTarget) Mode() os.FileMode  { return 0 }
+func (t linkTarget) ModTime() time.Time { return time.Time{} }
+func (t linkTarget) IsDir() bool        { return false }
+func (t linkTarget) Sys() interface{}   { return nil }
+
+// faultyReaderAt reads file content and injects faults.
+type faultyReaderAt struct {
+	file   *os.File
+	name   string
+	faults *requestFaults
+	conn   net.Conn
+
+	mu       sync.Mutex
+	started  bool
+	read     uint64
+	drop     bool
+	throttle *throttler
+	failed   error
+}
+
+// ReadAt reads file content, possibly delayed, throttled, corrupted
+// or interrupted by closed connection.
+func (r *faultyReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failed != nil {
+		return 0, r.failed
+	}
+	if r.drop {
+		// DropAfter bytes were already sent, as with HTTP.
+		r.failed = errInjectedFault
+		log.Infof("Injected fault (connection closed) after %d bytes of content for SFTP request for %s",
+			r.read, r.name)
+		if closeErr := r.conn.Close(); closeErr != nil {
+			log.Errorf("Failed to close SFTP connection: %v", closeErr)
+		}
+		return 0, r.failed
+	}
+	if !r.started {
+		r.started = true
+		if r.faults.TTFB != 0 {
+			time.Sleep(time.Duration(r.faults.TTFB))
+		}
+	}
+	n, err := r.file.ReadAt(p, off)
+	if r.faults.DropAfter != 0 && r.read+uint64(n) >= r.faults.DropAfter {
+		// Send content up to DropAfter, the connection is closed with the next read.
+		n = int(r.faults.DropAfter - r.read)
+		r.drop = true
+		err = nil
+	}
+	r.read += uint64(n)
+	if r.throttle != nil {
+		r.throttle.wait(n)
+	}
+	if r.faults.Corrupt {
+		corruptContent(p[:n], off)
+	}
+	return n, err
+}
+
+// Close closes the file.
+func (r *faultyReaderAt) Close() error {
+	return r.file.Close()
+}
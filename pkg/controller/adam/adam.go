@@ -47,6 +47,9 @@ type Ctx struct {
 	AdamCaching       bool   // enable caching of adam`s logs/info
 	AdamCachingRedis  bool   // caching to redis instead of files
 	AdamCachingPrefix string // custom prefix for file or stream naming for cache
+	AdamReplay        string // telemetry bundle to obtain logs and info from instead of adam
+
+	replayBundle *loaders.Bundle
 }
 
 // parseRedisURL try to use string from config to obtain redis url
@@ -74,6 +77,10 @@ func parseRedisURL(s string) (addr, password string, databaseID int, err error)
 
 // getLoader return loader object from Adam`s config
 func (adam *Ctx) getLoader() (loader loaders.Loader) {
+	if adam.replayBundle != nil {
+		log.Debug("will use replay loader")
+		return loaders.NewReplayLoader(adam.replayBundle)
+	}
 	if adam.AdamRemote {
 		log.Debug("will use remote adam loader")
 		if adam.AdamRemoteRedis {
@@ -95,7 +102,9 @@ func (adam *Ctx) getLoader() (loader loaders.Loader) {
 				URLLogs:    adam.getLogsURL,
 				URLInfo:    adam.getInfoURL,
 				URLMetrics: adam.getMetricsURL,
+				URLFlowLog: adam.getFlowLogURL,
 				URLRequest: adam.getRequestURL,
+				URLApps:    adam.getAppsLogsURL,
 			}
 			loader = loaders.NewRemoteLoader(adam.getHTTPClient, urlGetters)
 		}
@@ -105,7 +114,9 @@ func (adam *Ctx) getLoader() (loader loaders.Loader) {
 			LogsGetter:    adam.getLogsDir,
 			InfoGetter:    adam.getInfoDir,
 			MetricsGetter: adam.getMetricsDir,
+			FlowLogGetter: adam.getFlowLogDir,
 			RequestGetter: adam.getRequestDir,
+			AppsGetter:    adam.getAppsLogsDir,
 		}
 		loader = loaders.NewFileLoader(dirGetters)
 	}
@@ -120,7 +131,9 @@ func (adam *Ctx) getLoader() (loader loaders.Loader) {
 				StreamLogs:    adam.getLogsRedisStreamCache,
 				StreamInfo:    adam.getInfoRedisStreamCache,
 				StreamMetrics: adam.getMetricsRedisStreamCache,
+				StreamFlowLog: adam.getFlowLogRedisStreamCache,
 				StreamRequest: adam.getRequestRedisStreamCache,
+				StreamApps:    adam.getAppsLogsRedisStreamCache,
 			}
 			cache = cachers.NewRedisCache(addr, password, databaseID, streamGetters)
 		} else {
@@ -128,7 +141,9 @@ func (adam *Ctx) getLoader() (loader loaders.Loader) {
 				LogsGetter:    adam.getLogsDirCache,
 				InfoGetter:    adam.getInfoDirCache,
 				MetricsGetter: adam.getMetricsDirCache,
+				FlowLogGetter: adam.getFlowLogDirCache,
 				RequestGetter: adam.getRequestDirCache,
+				AppsGetter:    adam.getAppsLogsDirCache,
 			}
 			cache = cachers.NewFileCache(dirGetters)
 		}
@@ -137,6 +152,11 @@ func (adam *Ctx) getLoader() (loader loaders.Loader) {
 	return
 }
 
+// GetLoader returns loader of objects sent by devices to controller
+func (adam *Ctx) GetLoader() loaders.Loader {
+	return adam.getLoader()
+}
+
 // InitWithVars use variables from viper for init controller
 func (adam *Ctx) InitWithVars(vars *utils.ConfigVars) error {
 	adam.dir = vars.AdamDir
@@ -149,9 +169,29 @@ func (adam *Ctx) InitWithVars(vars *utils.ConfigVars) error {
 	adam.AdamCachingRedis = vars.AdamCachingRedis
 	adam.AdamCachingPrefix = vars.AdamCachingPrefix
 	adam.AdamRedisURLEden = vars.AdamRedisURLEden
+	adam.AdamReplay = vars.AdamReplay
+	adam.replayBundle = nil
+	if adam.AdamReplay != "" {
+		bundle, err := loaders.OpenBundle(adam.AdamReplay)
+		if err != nil {
+			return fmt.Errorf("cannot open telemetry bundle %s: %w", adam.AdamReplay, err)
+		}
+		adam.replayBundle = bundle
+	}
 	return nil
 }
 
+// InitLocal init controller to use Adam-compatible server on url
+// which stores data inside dir accessible by eden
+func (adam *Ctx) InitLocal(dir string, url string) {
+	adam.dir = dir
+	adam.url = url
+	adam.insecureTLS = true
+	adam.serverCA = ""
+	adam.AdamRemote = false
+	adam.AdamCaching = false
+}
+
 // GetDir return dir
 func (adam *Ctx) GetDir() (dir string) {
 	return adam.dir
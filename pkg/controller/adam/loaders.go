@@ -72,6 +72,22 @@ func (adam *Ctx) getRequestRedisStreamCache(devUUID uuid.UUID) (dir string) {
 	return fmt.Sprintf("%s%s_%s", defaults.DefaultRequestsRedisPrefix, adam.AdamCachingPrefix, devUUID.String())
 }
 
+//getFlowLogRedisStreamCache return flowLog stream for devUUID for caching in redis
+func (adam *Ctx) getFlowLogRedisStreamCache(devUUID uuid.UUID) (dir string) {
+	if adam.AdamCachingPrefix == "" {
+		return adam.getFlowLogRedisStream(devUUID)
+	}
+	return fmt.Sprintf("%s%s_%s", defaults.DefaultFlowLogRedisPrefix, adam.AdamCachingPrefix, devUUID.String())
+}
+
+//getAppsLogsRedisStreamCache return app logs stream for devUUID for caching in redis
+func (adam *Ctx) getAppsLogsRedisStreamCache(devUUID uuid.UUID, appUUID uuid.UUID) (dir string) {
+	if adam.AdamCachingPrefix == "" {
+		return adam.getAppsLogsRedisStream(devUUID, appUUID)
+	}
+	return fmt.Sprintf("%s%s_%s_%s", defaults.DefaultAppsLogsRedisPrefix, adam.AdamCachingPrefix, devUUID.String(), appUUID.String())
+}
+
 //getRedisStreamCache return logs stream for devUUID for caching in redis
 func (adam *Ctx) getLogsDirCache(devUUID uuid.UUID) (dir string) {
 	if adam.AdamCachingPrefix == "" {
@@ -104,6 +120,22 @@ func (adam *Ctx) getRequestDirCache(devUUID uuid.UUID) (dir string) {
 	return path.Join(adam.dir, adam.AdamCachingPrefix, devUUID.String(), "requests")
 }
 
+//getFlowLogDirCache return flowLog directory for devUUID for caching
+func (adam *Ctx) getFlowLogDirCache(devUUID uuid.UUID) (dir string) {
+	if adam.AdamCachingPrefix == "" {
+		return adam.getFlowLogDir(devUUID)
+	}
+	return path.Join(adam.dir, adam.AdamCachingPrefix, devUUID.String(), "flowMessage")
+}
+
+//getAppsLogsDirCache return app logs directory for devUUID and appUUID for caching
+func (adam *Ctx) getAppsLogsDirCache(devUUID uuid.UUID, appUUID uuid.UUID) (dir string) {
+	if adam.AdamCachingPrefix == "" {
+		return adam.getAppsLogsDir(devUUID, appUUID)
+	}
+	return path.Join(adam.dir, adam.AdamCachingPrefix, devUUID.String(), "apps", appUUID.String())
+}
+
 //getLogsDir return logs directory for devUUID
 func (adam *Ctx) getLogsDir(devUUID uuid.UUID) (dir string) {
 	return path.Join(adam.dir, "run", "adam", "device", devUUID.String(), "logs")
@@ -124,6 +156,16 @@ func (adam *Ctx) getRequestDir(devUUID uuid.UUID) (dir string) {
 	return path.Join(adam.dir, "run", "adam", "device", devUUID.String(), "requests")
 }
 
+//getFlowLogDir return flowLog directory for devUUID
+func (adam *Ctx) getFlowLogDir(devUUID uuid.UUID) (dir string) {
+	return path.Join(adam.dir, "run", "adam", "device", devUUID.String(), "flowMessage")
+}
+
+//getAppsLogsDir return app logs directory for devUUID and appUUID
+func (adam *Ctx) getAppsLogsDir(devUUID uuid.UUID, appUUID uuid.UUID) (dir string) {
+	return path.Join(adam.dir, "run", "adam", "device", devUUID.String(), "apps", appUUID.String())
+}
+
 //getLogsURL return logs url for devUUID
 func (adam *Ctx) getLogsURL(devUUID uuid.UUID) string {
 	resURL, err := utils.ResolveURL(adam.url, path.Join("/admin/device", devUUID.String(), "logs"))
@@ -159,3 +201,21 @@ func (adam *Ctx) getRequestURL(devUUID uuid.UUID) string {
 	}
 	return resURL
 }
+
+//getFlowLogURL return flowLog url for devUUID
+func (adam *Ctx) getFlowLogURL(devUUID uuid.UUID) string {
+	resURL, err := utils.ResolveURL(adam.url, path.Join("/admin/device", devUUID.String(), "flowMessage"))
+	if err != nil {
+		log.Fatalf("ResolveURL: %s", err)
+	}
+	return resURL
+}
+
+//getAppsLogsURL return app logs url for devUUID and appUUID
+func (adam *Ctx) getAppsLogsURL(devUUID uuid.UUID, appUUID uuid.UUID) string {
+	resURL, err := utils.ResolveURL(adam.url, path.Join("/admin/device", devUUID.String(), "apps", "instances", "id", appUUID.String(), "logs"))
+	if err != nil {
+		log.Fatalf("ResolveURL: %s", err)
+	}
+	return resURL
+}
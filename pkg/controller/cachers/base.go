@@ -1,11 +1,84 @@
 package cachers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
 	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eve-api/go/flowlog"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/logs"
+	"github.com/lf-edge/eve-api/go/metrics"
 	uuid "github.com/satori/go.uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 //CacheProcessor for processing objects and save into cache
 type CacheProcessor interface {
-	CheckAndSave(uuid.UUID, types.LoaderObjectType, []byte) error
+	CheckAndSave(devUUID uuid.UUID, appUUID uuid.UUID, typeToProcess types.LoaderObjectType, data []byte) error
+}
+
+// unmarshalItem parses data in JSON or binary form into msg
+func unmarshalItem(data []byte, msg proto.Message) error {
+	if err := protojson.Unmarshal(data, msg); err == nil {
+		return nil
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+// itemKey returns the key used to store data of typeToProcess in cache
+// together with timestamp of item if it can be defined
+// keys are based on timestamps of items if they contain ones
+// and on hash of data for FlowMessage which may contain no time
+func itemKey(typeToProcess types.LoaderObjectType, data []byte) (string, error) {
+	var itemTimeStamp *timestamppb.Timestamp
+	switch typeToProcess {
+	case types.LogsType:
+		var emp logs.LogBundle
+		if err := protojson.Unmarshal(data, &emp); err != nil {
+			return "", err
+		}
+		itemTimeStamp = emp.Timestamp
+	case types.InfoType:
+		var emp info.ZInfoMsg
+		if err := unmarshalItem(data, &emp); err != nil {
+			return "", err
+		}
+		itemTimeStamp = emp.AtTimeStamp
+	case types.MetricsType:
+		var emp metrics.ZMetricMsg
+		if err := unmarshalItem(data, &emp); err != nil {
+			return "", err
+		}
+		itemTimeStamp = emp.AtTimeStamp
+	case types.AppsType:
+		var emp logs.LogEntry
+		if err := protojson.Unmarshal(data, &emp); err != nil {
+			return "", err
+		}
+		itemTimeStamp = emp.Timestamp
+	case types.RequestType:
+		var emp types.APIRequest
+		if err := json.Unmarshal(data, &emp); err != nil {
+			return "", err
+		}
+		itemTimeStamp = timestamppb.New(emp.Timestamp)
+	case types.FlowLogType:
+		var emp flowlog.FlowMessage
+		if err := proto.Unmarshal(data, &emp); err != nil {
+			return "", err
+		}
+		hash := sha256.Sum256(data)
+		return hex.EncodeToString(hash[:]), nil
+	default:
+		return "", fmt.Errorf("not implemented type %d", typeToProcess)
+	}
+	if itemTimeStamp == nil {
+		return "", fmt.Errorf("nil timestamp for data: %s", string(data))
+	}
+	return fmt.Sprintf("%d:%09d", itemTimeStamp.GetSeconds(), itemTimeStamp.GetNanos()), nil
 }
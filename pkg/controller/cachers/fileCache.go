@@ -1,18 +1,12 @@
 package cachers
 
 import (
-	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
 
 	"github.com/lf-edge/eden/pkg/controller/types"
-	"github.com/lf-edge/eve-api/go/info"
-	"github.com/lf-edge/eve-api/go/logs"
-	"github.com/lf-edge/eve-api/go/metrics"
 	uuid "github.com/satori/go.uuid"
-	"google.golang.org/protobuf/encoding/protojson"
-	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // FileCache object provides caching objects from controller into directory
@@ -27,41 +21,44 @@ func NewFileCache(dirGetters types.DirGetters) *FileCache {
 	}
 }
 
-// CheckAndSave process LoaderObjectType from data
-func (cacher *FileCache) CheckAndSave(devUUID uuid.UUID, typeToProcess types.LoaderObjectType, data []byte) error {
-	var pathToCheck string
-	var itemTimeStamp *timestamppb.Timestamp
-	var buf bytes.Buffer
-	buf.Write(data)
+func (cacher *FileCache) getDir(devUUID uuid.UUID, appUUID uuid.UUID, typeToProcess types.LoaderObjectType) (string, error) {
+	var getter func(uuid.UUID) string
 	switch typeToProcess {
 	case types.LogsType:
-		pathToCheck = cacher.dirGetters.LogsGetter(devUUID)
-		var emp logs.LogBundle
-		if err := protojson.Unmarshal(buf.Bytes(), &emp); err != nil {
-			return err
-		}
-		itemTimeStamp = emp.Timestamp
+		getter = cacher.dirGetters.LogsGetter
 	case types.InfoType:
-		pathToCheck = cacher.dirGetters.InfoGetter(devUUID)
-		var emp info.ZInfoMsg
-		if err := protojson.Unmarshal(buf.Bytes(), &emp); err != nil {
-			return err
-		}
-		itemTimeStamp = emp.AtTimeStamp
+		getter = cacher.dirGetters.InfoGetter
 	case types.MetricsType:
-		pathToCheck = cacher.dirGetters.MetricsGetter(devUUID)
-		var emp metrics.ZMetricMsg
-		if err := protojson.Unmarshal(buf.Bytes(), &emp); err != nil {
-			return err
+		getter = cacher.dirGetters.MetricsGetter
+	case types.FlowLogType:
+		getter = cacher.dirGetters.FlowLogGetter
+	case types.RequestType:
+		getter = cacher.dirGetters.RequestGetter
+	case types.AppsType:
+		if cacher.dirGetters.AppsGetter == nil {
+			return "", fmt.Errorf("no directory defined for type %d", typeToProcess)
 		}
-		itemTimeStamp = emp.AtTimeStamp
+		return cacher.dirGetters.AppsGetter(devUUID, appUUID), nil
 	default:
-		return fmt.Errorf("not implemented type %d", typeToProcess)
+		return "", fmt.Errorf("not implemented type %d", typeToProcess)
+	}
+	if getter == nil {
+		return "", fmt.Errorf("no directory defined for type %d", typeToProcess)
+	}
+	return getter(devUUID), nil
+}
+
+// CheckAndSave process LoaderObjectType from data
+func (cacher *FileCache) CheckAndSave(devUUID uuid.UUID, appUUID uuid.UUID, typeToProcess types.LoaderObjectType, data []byte) error {
+	pathToCheck, err := cacher.getDir(devUUID, appUUID, typeToProcess)
+	if err != nil {
+		return err
 	}
-	if itemTimeStamp == nil {
-		return fmt.Errorf("nil timestamp for data: %s", string(data))
+	key, err := itemKey(typeToProcess, data)
+	if err != nil {
+		return err
 	}
-	pathToCheck = filepath.Join(pathToCheck, fmt.Sprintf("%d:%09d", itemTimeStamp.GetSeconds(), itemTimeStamp.GetNanos()))
+	pathToCheck = filepath.Join(pathToCheck, key)
 	if err := os.MkdirAll(filepath.Dir(pathToCheck), 0755); err != nil {
 		return err
 	}
@@ -1,19 +1,13 @@
 package cachers
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 
 	"github.com/go-redis/redis/v9"
 	"github.com/lf-edge/eden/pkg/controller/types"
-	"github.com/lf-edge/eve-api/go/info"
-	"github.com/lf-edge/eve-api/go/logs"
-	"github.com/lf-edge/eve-api/go/metrics"
 	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
-	"google.golang.org/protobuf/encoding/protojson"
-	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // RedisCache object provides caching objects from controller into redis
@@ -45,71 +39,64 @@ func (cacher *RedisCache) newRedisClient() (*redis.Client, error) {
 	return client, err
 }
 
+func (cacher *RedisCache) getStream(devUUID uuid.UUID, appUUID uuid.UUID, typeToProcess types.LoaderObjectType) (string, error) {
+	var getter func(uuid.UUID) string
+	switch typeToProcess {
+	case types.LogsType:
+		getter = cacher.streamGetters.StreamLogs
+	case types.InfoType:
+		getter = cacher.streamGetters.StreamInfo
+	case types.MetricsType:
+		getter = cacher.streamGetters.StreamMetrics
+	case types.FlowLogType:
+		getter = cacher.streamGetters.StreamFlowLog
+	case types.RequestType:
+		getter = cacher.streamGetters.StreamRequest
+	case types.AppsType:
+		if cacher.streamGetters.StreamApps == nil {
+			return "", fmt.Errorf("no stream defined for type %d", typeToProcess)
+		}
+		return cacher.streamGetters.StreamApps(devUUID, appUUID), nil
+	default:
+		return "", fmt.Errorf("not implemented type %d", typeToProcess)
+	}
+	if getter == nil {
+		return "", fmt.Errorf("no stream defined for type %d", typeToProcess)
+	}
+	return getter(devUUID), nil
+}
+
 // CheckAndSave process LoaderObjectType from data
-func (cacher *RedisCache) CheckAndSave(devUUID uuid.UUID, typeToProcess types.LoaderObjectType, data []byte) (err error) {
+func (cacher *RedisCache) CheckAndSave(devUUID uuid.UUID, appUUID uuid.UUID, typeToProcess types.LoaderObjectType, data []byte) (err error) {
 	if cacher.client == nil {
 		if cacher.client, err = cacher.newRedisClient(); err != nil {
 			return err
 		}
 	}
 
-	var streamToWrite string
-	var itemTimeStamp *timestamppb.Timestamp
-	var buf bytes.Buffer
-	buf.Write(data)
-	switch typeToProcess {
-	case types.LogsType:
-		streamToWrite = cacher.streamGetters.StreamLogs(devUUID)
-		var emp logs.LogBundle
-		if err := protojson.Unmarshal(buf.Bytes(), &emp); err != nil {
-			return err
-		}
-		itemTimeStamp = emp.Timestamp
-	case types.InfoType:
-		streamToWrite = cacher.streamGetters.StreamInfo(devUUID)
-		var emp info.ZInfoMsg
-		if err := protojson.Unmarshal(buf.Bytes(), &emp); err != nil {
-			return err
-		}
-		itemTimeStamp = emp.AtTimeStamp
-	case types.MetricsType:
-		streamToWrite = cacher.streamGetters.StreamMetrics(devUUID)
-		var emp metrics.ZMetricMsg
-		if err := protojson.Unmarshal(buf.Bytes(), &emp); err != nil {
-			return err
-		}
-		itemTimeStamp = emp.AtTimeStamp
-	default:
-		return fmt.Errorf("not implemented type %d", typeToProcess)
+	streamToWrite, err := cacher.getStream(devUUID, appUUID, typeToProcess)
+	if err != nil {
+		return err
+	}
+	key, err := itemKey(typeToProcess, data)
+	if err != nil {
+		return err
 	}
 	rr, err := cacher.client.XRange(context.Background(), streamToWrite, "-", "+").Result()
 	if err != nil {
 		return err
 	}
 	for _, r := range rr {
-		switch typeToProcess {
-		case types.LogsType:
-			var buf bytes.Buffer
-			buf.Write([]byte(r.Values["object"].(string)))
-			var emp logs.LogBundle
-			if err := protojson.Unmarshal(buf.Bytes(), &emp); err != nil {
-				return err
-			}
-			if emp.Timestamp.GetSeconds() == itemTimeStamp.GetSeconds() && emp.Timestamp.GetNanos() == itemTimeStamp.GetNanos() {
-				return
-			}
-		case types.InfoType:
-			var buf bytes.Buffer
-			buf.Write([]byte(r.Values["object"].(string)))
-			var emp info.ZInfoMsg
-			if err := protojson.Unmarshal(buf.Bytes(), &emp); err != nil {
-				return err
-			}
-			if emp.AtTimeStamp.GetSeconds() == itemTimeStamp.GetSeconds() && emp.AtTimeStamp.GetNanos() == itemTimeStamp.GetNanos() {
-				return
-			}
-		default:
-			return fmt.Errorf("not implemented type %d", typeToProcess)
+		dataString, ok := r.Values["object"].(string)
+		if !ok {
+			continue
+		}
+		existingKey, err := itemKey(typeToProcess, []byte(dataString))
+		if err != nil {
+			return err
+		}
+		if existingKey == key {
+			return nil
 		}
 	}
 
@@ -119,10 +106,10 @@ func (cacher *RedisCache) CheckAndSave(devUUID uuid.UUID, typeToProcess types.Lo
 			"object": data,
 		},
 	})
-	var key string
-	if key, err = strCMD.Result(); err != nil {
+	var streamKey string
+	if streamKey, err = strCMD.Result(); err != nil {
 		return fmt.Errorf("error in XAdd:%v", err)
 	}
-	log.Debugf("ready with write to redis %s: %s", key, data)
+	log.Debugf("ready with write to redis %s: %s", streamKey, data)
 	return nil
 }
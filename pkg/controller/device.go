@@ -256,6 +256,9 @@ func (cloud *CloudCtx) ConfigSync(dev *device.Ctx) (err error) {
 		if err = cloud.ConfigSet(dev.GetID(), devConfig); err != nil {
 			return err
 		}
+		if err = SaveConfigHistory(dev.GetID(), devConfig); err != nil {
+			log.Warnf("cannot save config history: %s", err)
+		}
 		time.Sleep(time.Second)
 		return cloud.StateUpdate(dev)
 	}
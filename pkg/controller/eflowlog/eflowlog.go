@@ -7,10 +7,10 @@ import (
 	"fmt"
 	"os"
 	"reflect"
-	"regexp"
 	"strings"
 	"time"
 
+	"github.com/lf-edge/eden/pkg/controller/equery"
 	"github.com/lf-edge/eden/pkg/controller/loaders"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/utils"
@@ -63,33 +63,10 @@ func FlowLogItemPrint(le *flowlog.FlowMessage, query []string) *types.PrintResul
 	return &result
 }
 
-// FlowLogItemFind find FlowMessage records by reqexps in 'query' corresponded to FlowMessage structure.
+// FlowLogItemFind find FlowMessage records corresponded to FlowMessage structure by 'query'
+// in the format supported by equery.Compile (field:regexp pairs and expression).
 func FlowLogItemFind(le *flowlog.FlowMessage, query map[string]string) bool {
-	matched := true
-	for k, v := range query {
-		// Uppercase of filed's name first letter
-		var n []string
-		caser := cases.Title(language.English, cases.NoLower)
-		for _, pathElement := range strings.Split(k, ".") {
-			n = append(n, caser.String(pathElement))
-		}
-		var clb = func(inp reflect.Value) {
-			f := fmt.Sprint(inp)
-			newMatched, err := regexp.Match(v, []byte(f))
-			if err != nil {
-				log.Debug(err)
-			}
-			if !matched && newMatched {
-				matched = newMatched
-			}
-		}
-		matched = false
-		utils.LookupWithCallback(reflect.Indirect(reflect.ValueOf(le)).Interface(), strings.Join(n, "."), clb)
-		if !matched {
-			return matched
-		}
-	}
-	return matched
+	return equery.Match(le, query)
 }
 
 // HandleFactory implements HandlerFunc which prints log in the provided format
@@ -150,12 +127,20 @@ func flowLogProcess(query map[string]string, handler HandlerFunc) loaders.Proces
 // FlowLogWatch monitors the change of FlowLog files in the 'filepath' directory
 // according to the 'query' reqexps and processing using the 'handler' function.
 func FlowLogWatch(loader loaders.Loader, query map[string]string, handler HandlerFunc, timeoutSeconds time.Duration) error {
+	if err := equery.Validate(query); err != nil {
+		return err
+	}
+	loader.SetTimeRange(equery.TimeRange(query))
 	return loader.ProcessStream(flowLogProcess(query, handler), types.FlowLogType, timeoutSeconds)
 }
 
 // FlowLogLast function process FlowLog files in the 'filepath' directory
 // according to the 'query' reqexps and return last founded item
 func FlowLogLast(loader loaders.Loader, query map[string]string, handler HandlerFunc) error {
+	if err := equery.Validate(query); err != nil {
+		return err
+	}
+	loader.SetTimeRange(equery.TimeRange(query))
 	return loader.ProcessExisting(flowLogProcess(query, handler), types.FlowLogType)
 }
 
@@ -5,10 +5,10 @@ package einfo
 import (
 	"fmt"
 	"reflect"
-	"regexp"
 	"strings"
 	"time"
 
+	"github.com/lf-edge/eden/pkg/controller/equery"
 	"github.com/lf-edge/eden/pkg/controller/loaders"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/utils"
@@ -64,37 +64,6 @@ func HandleFactory(format types.OutputFormat, once bool) HandlerFunc {
 	}
 }
 
-func processElem(value reflect.Value, query map[string]string) bool {
-	matched := true
-	for k, v := range query {
-		// Uppercase of filed's name first letter
-		var n []string
-		caser := cases.Title(language.English, cases.NoLower)
-		for _, pathElement := range strings.Split(k, ".") {
-			n = append(n, caser.String(pathElement))
-		}
-		var clb = func(inp reflect.Value) {
-			f := fmt.Sprint(inp)
-			if b, ok := inp.Interface().([]byte); ok {
-				f = fmt.Sprintf("%s", b)
-			}
-			newMatched, err := regexp.Match(v, []byte(f))
-			if err != nil {
-				log.Debug(err)
-			}
-			if !matched && newMatched {
-				matched = newMatched
-			}
-		}
-		matched = false
-		utils.LookupWithCallback(reflect.Indirect(value).Interface(), strings.Join(n, "."), clb)
-		if !matched {
-			break
-		}
-	}
-	return matched
-}
-
 // ZInfoPrintFiltered finds ZInfoMsg records by path in 'query'
 func ZInfoPrintFiltered(im *info.ZInfoMsg, query []string) *types.PrintResult {
 	result := make(types.PrintResult)
@@ -117,13 +86,10 @@ func ZInfoPrintFiltered(im *info.ZInfoMsg, query []string) *types.PrintResult {
 	return &result
 }
 
-// ZInfoFind finds ZInfoMsg records with 'devid' and ZInfoDevSWF structure fields
-// by reqexps in 'query'
+// ZInfoFind finds ZInfoMsg records by 'query'
+// in the format supported by equery.Compile (field:regexp pairs and expression).
 func ZInfoFind(im *info.ZInfoMsg, query map[string]string) bool {
-	if processElem(reflect.ValueOf(im), query) {
-		return true
-	}
-	return false
+	return equery.Match(im, query)
 }
 
 // InfoCheckerMode is InfoExist, InfoNew and InfoAny
@@ -158,11 +124,19 @@ func infoProcess(query map[string]string, qhandler QHandlerFunc, handler Handler
 
 // InfoLast search Info files in the 'filepath' directory according to the 'query' parameters accepted by the 'qhandler' function and subsequent process using the 'handler' function.
 func InfoLast(loader loaders.Loader, query map[string]string, qhandler QHandlerFunc, handler HandlerFunc) error {
+	if err := equery.Validate(query); err != nil {
+		return err
+	}
+	loader.SetTimeRange(equery.TimeRange(query))
 	return loader.ProcessExisting(infoProcess(query, qhandler, handler), types.InfoType)
 }
 
 // InfoWatch monitors the change of Info files in the 'filepath' directory according to the 'query' parameters accepted by the 'qhandler' function and subsequent processing using the 'handler' function with 'timeoutSeconds'.
 func InfoWatch(loader loaders.Loader, query map[string]string, qhandler QHandlerFunc, handler HandlerFunc, timeoutSeconds time.Duration) error {
+	if err := equery.Validate(query); err != nil {
+		return err
+	}
+	loader.SetTimeRange(equery.TimeRange(query))
 	return loader.ProcessStream(infoProcess(query, qhandler, handler), types.InfoType, timeoutSeconds)
 }
 
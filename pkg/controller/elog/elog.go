@@ -5,10 +5,10 @@ package elog
 import (
 	"fmt"
 	"reflect"
-	"regexp"
 	"strings"
 	"time"
 
+	"github.com/lf-edge/eden/pkg/controller/equery"
 	"github.com/lf-edge/eden/pkg/controller/loaders"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/utils"
@@ -68,33 +68,10 @@ func LogItemPrint(le *FullLogEntry, _ types.OutputFormat, query []string) *types
 	return &result
 }
 
-// LogItemFind find LogItem records by reqexps in 'query' corresponded to LogItem structure.
+// LogItemFind find LogItem records corresponded to LogItem structure by 'query'
+// in the format supported by equery.Compile (field:regexp pairs and expression).
 func LogItemFind(le *FullLogEntry, query map[string]string) bool {
-	matched := true
-	for k, v := range query {
-		// Uppercase of filed's name first letter
-		var n []string
-		caser := cases.Title(language.English, cases.NoLower)
-		for _, pathElement := range strings.Split(k, ".") {
-			n = append(n, caser.String(pathElement))
-		}
-		var clb = func(inp reflect.Value) {
-			f := fmt.Sprint(inp)
-			newMatched, err := regexp.Match(v, []byte(f))
-			if err != nil {
-				log.Debug(err)
-			}
-			if !matched && newMatched {
-				matched = newMatched
-			}
-		}
-		matched = false
-		utils.LookupWithCallback(reflect.Indirect(reflect.ValueOf(le)).Interface(), strings.Join(n, "."), clb)
-		if !matched {
-			return matched
-		}
-	}
-	return matched
+	return equery.Match(le, query)
 }
 
 // HandleFactory implements HandlerFunc which prints log in the provided format
@@ -161,12 +138,20 @@ func logProcess(query map[string]string, handler HandlerFunc) loaders.ProcessFun
 // LogWatch monitors the change of Log files in the 'filepath' directory
 // according to the 'query' reqexps and processing using the 'handler' function.
 func LogWatch(loader loaders.Loader, query map[string]string, handler HandlerFunc, timeoutSeconds time.Duration) error {
+	if err := equery.Validate(query); err != nil {
+		return err
+	}
+	loader.SetTimeRange(equery.TimeRange(query))
 	return loader.ProcessStream(logProcess(query, handler), types.LogsType, timeoutSeconds)
 }
 
 // LogLast function process Log files in the 'filepath' directory
 // according to the 'query' reqexps and return last founded item
 func LogLast(loader loaders.Loader, query map[string]string, handler HandlerFunc) error {
+	if err := equery.Validate(query); err != nil {
+		return err
+	}
+	loader.SetTimeRange(equery.TimeRange(query))
 	return loader.ProcessExisting(logProcess(query, handler), types.LogsType)
 }
 
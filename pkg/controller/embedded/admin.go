@@ -0,0 +1,324 @@
+package embedded
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eve-api/go/flowlog"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/metrics"
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+type adminHandler struct {
+	store *store
+}
+
+func deviceUUIDFromRequest(r *http.Request) (uuid.UUID, error) {
+	devUUID, err := uuid.FromString(mux.Vars(r)["uuid"])
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("bad device UUID: %w", err)
+	}
+	return devUUID, nil
+}
+
+func (h *adminHandler) onboardAdd(w http.ResponseWriter, r *http.Request) {
+	var onboard types.OnboardCert
+	if err := json.NewDecoder(r.Body).Decode(&onboard); err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	if err := h.store.addOnboard(onboard.Cert, onboard.Serial); err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *adminHandler) onboardRemove(w http.ResponseWriter, r *http.Request) {
+	if err := h.store.removeOnboard(mux.Vars(r)["cn"]); err != nil {
+		wrapError(err, http.StatusNotFound, w)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *adminHandler) deviceList(w http.ResponseWriter, _ *http.Request) {
+	devices, err := h.store.listDevices()
+	if err != nil {
+		wrapError(err, http.StatusInternalServerError, w)
+		return
+	}
+	w.Header().Set(contentType, mimeTextPlain)
+	w.WriteHeader(http.StatusOK)
+	for _, devUUID := range devices {
+		_, _ = fmt.Fprintln(w, devUUID.String())
+	}
+}
+
+func (h *adminHandler) deviceAdd(w http.ResponseWriter, r *http.Request) {
+	var devCert types.DeviceCert
+	if err := json.NewDecoder(r.Body).Decode(&devCert); err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	if _, err := h.store.addDevice(devCert.Cert, devCert.Onboard, devCert.Serial); err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *adminHandler) deviceGet(w http.ResponseWriter, r *http.Request) {
+	devUUID, err := deviceUUIDFromRequest(r)
+	if err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	devCert, err := h.store.getDevice(devUUID)
+	if err != nil {
+		wrapError(err, http.StatusNotFound, w)
+		return
+	}
+	w.Header().Set(contentType, mimeJSON)
+	_ = json.NewEncoder(w).Encode(devCert)
+}
+
+func (h *adminHandler) deviceRemove(w http.ResponseWriter, r *http.Request) {
+	devUUID, err := deviceUUIDFromRequest(r)
+	if err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	if err := h.store.removeDevice(devUUID); err != nil {
+		wrapError(err, http.StatusNotFound, w)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// getDeviceFile returns content of file of device from request
+func (h *adminHandler) getDeviceFile(w http.ResponseWriter, r *http.Request, name string, mime string) {
+	devUUID, err := deviceUUIDFromRequest(r)
+	if err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	data, err := h.store.readDeviceFile(devUUID, name)
+	if err != nil {
+		wrapError(err, http.StatusNotFound, w)
+		return
+	}
+	w.Header().Set(contentType, mime)
+	_, _ = w.Write(data)
+}
+
+// setDeviceFile saves body of request into file of device from request
+func (h *adminHandler) setDeviceFile(w http.ResponseWriter, r *http.Request, name string) {
+	devUUID, err := deviceUUIDFromRequest(r)
+	if err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	if err := h.store.writeDeviceFile(devUUID, name, data); err != nil {
+		wrapError(err, http.StatusNotFound, w)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *adminHandler) configGet(w http.ResponseWriter, r *http.Request) {
+	h.getDeviceFile(w, r, deviceConfigFile, mimeProto)
+}
+
+func (h *adminHandler) configSet(w http.ResponseWriter, r *http.Request) {
+	h.setDeviceFile(w, r, deviceConfigFile)
+}
+
+func (h *adminHandler) certsGet(w http.ResponseWriter, r *http.Request) {
+	h.getDeviceFile(w, r, deviceCertsFile, mimeJSON)
+}
+
+func (h *adminHandler) deviceOptionsGet(w http.ResponseWriter, r *http.Request) {
+	devUUID, err := deviceUUIDFromRequest(r)
+	if err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	data, err := h.store.readDeviceFile(devUUID, deviceOptsFile)
+	if os.IsNotExist(err) {
+		data, err = json.Marshal(&types.DeviceOptions{})
+	}
+	if err != nil {
+		wrapError(err, http.StatusInternalServerError, w)
+		return
+	}
+	w.Header().Set(contentType, mimeJSON)
+	_, _ = w.Write(data)
+}
+
+func (h *adminHandler) deviceOptionsSet(w http.ResponseWriter, r *http.Request) {
+	h.setDeviceFile(w, r, deviceOptsFile)
+}
+
+func (h *adminHandler) globalOptionsGet(w http.ResponseWriter, _ *http.Request) {
+	data, err := h.store.readGlobalOptions()
+	if err != nil {
+		wrapError(err, http.StatusInternalServerError, w)
+		return
+	}
+	w.Header().Set(contentType, mimeJSON)
+	_, _ = w.Write(data)
+}
+
+func (h *adminHandler) globalOptionsSet(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	if err := h.store.writeGlobalOptions(data); err != nil {
+		wrapError(err, http.StatusInternalServerError, w)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// itemToJSON converts stored object into JSON representation expected by loaders.RemoteLoader
+func itemToJSON(kind string, data []byte) ([]byte, error) {
+	var msg proto.Message
+	switch kind {
+	case infoDir:
+		msg = &info.ZInfoMsg{}
+	case metricsDir:
+		msg = &metrics.ZMetricMsg{}
+	case flowLogDir:
+		msg = &flowlog.FlowMessage{}
+	default:
+		// logs, app logs and requests are stored in JSON
+		return data, nil
+	}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	return protojson.Marshal(msg)
+}
+
+func (h *adminHandler) itemsGet(w http.ResponseWriter, r *http.Request) {
+	h.sendItems(w, r, mux.Vars(r)["kind"])
+}
+
+func (h *adminHandler) appLogsGet(w http.ResponseWriter, r *http.Request) {
+	appUUID, err := uuid.FromString(mux.Vars(r)["appuuid"])
+	if err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	h.sendItems(w, r, filepath.Join(appsDir, appUUID.String()))
+}
+
+// timeRangeFromRequest parses sinceParam and untilParam of request
+func timeRangeFromRequest(r *http.Request) (types.TimeRange, error) {
+	var timeRange types.TimeRange
+	var err error
+	if since := r.URL.Query().Get(sinceParam); since != "" {
+		if timeRange.Since, err = time.Parse(time.RFC3339Nano, since); err != nil {
+			return timeRange, fmt.Errorf("bad %s: %w", sinceParam, err)
+		}
+	}
+	if until := r.URL.Query().Get(untilParam); until != "" {
+		if timeRange.Until, err = time.Parse(time.RFC3339Nano, until); err != nil {
+			return timeRange, fmt.Errorf("bad %s: %w", untilParam, err)
+		}
+	}
+	return timeRange, nil
+}
+
+// mayContain checks if item stored in file may be inside of timeRange
+// using time of receiving of the item encoded in the file name
+// and taking into account delay of delivery and clock skew of device
+func mayContain(timeRange types.TimeRange, file string) bool {
+	received, ok := itemReceiveTime(filepath.Base(file))
+	if !ok {
+		return true
+	}
+	if !timeRange.Since.IsZero() && received.Before(timeRange.Since.Add(-defaults.DefaultDeliveryDelay)) {
+		return false
+	}
+	if !timeRange.Until.IsZero() && received.After(timeRange.Until.Add(defaults.DefaultDeliveryDelay)) {
+		return false
+	}
+	return true
+}
+
+// sendItems writes objects of kind stored for device from request
+// and waits for the new ones if streaming requested
+func (h *adminHandler) sendItems(w http.ResponseWriter, r *http.Request, kind string) {
+	devUUID, err := deviceUUIDFromRequest(r)
+	if err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	if _, err := h.store.getDevice(devUUID); err != nil {
+		wrapError(err, http.StatusNotFound, w)
+		return
+	}
+	timeRange, err := timeRangeFromRequest(r)
+	if err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	w.Header().Set(contentType, mimeJSON)
+	w.WriteHeader(http.StatusOK)
+	sent := make(map[string]struct{})
+	stream := r.Header.Get(streamHeader) != ""
+	for {
+		files, _ := h.store.listItems(devUUID, kind)
+		for _, file := range files {
+			if _, ok := sent[file]; ok {
+				continue
+			}
+			sent[file] = struct{}{}
+			if !mayContain(timeRange, file) {
+				continue
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				continue
+			}
+			out, err := itemToJSON(kind, data)
+			if err != nil {
+				continue
+			}
+			if _, err := w.Write(append(out, '\n')); err != nil {
+				return
+			}
+		}
+		if !stream {
+			return
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
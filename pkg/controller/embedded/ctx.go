@@ -0,0 +1,70 @@
+package embedded
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/lf-edge/eden/pkg/controller/adam"
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// Ctx implements controller.Controller using in-process Server
+// it works the same way as adam.Ctx with local Adam
+type Ctx struct {
+	*adam.Ctx
+	server *Server
+}
+
+// InitWithVars starts in-process Server which stores data in AdamDir
+// and init controller to use it
+func (ctx *Ctx) InitWithVars(vars *utils.ConfigVars) error {
+	if vars.AdamDir == "" {
+		return fmt.Errorf("adam.dist is not defined")
+	}
+	var signingCert []byte
+	if vars.AdamCA != "" {
+		// the same directory contains signing certificate generated by eden
+		signingCertPath := filepath.Join(filepath.Dir(vars.AdamCA), "signing.pem")
+		if data, err := os.ReadFile(signingCertPath); err == nil {
+			signingCert = data
+		} else {
+			log.Debugf("embedded controller will not use signing certificate: %s", err)
+		}
+	}
+	return ctx.Start(vars.AdamDir, signingCert)
+}
+
+// Start starts in-process Server which stores data in dir and init controller to use it
+func (ctx *Ctx) Start(dir string, signingCert []byte) error {
+	if err := ctx.Close(); err != nil {
+		return err
+	}
+	server, err := NewServer(dir, signingCert)
+	if err != nil {
+		return err
+	}
+	if err := server.Start("127.0.0.1:0"); err != nil {
+		return err
+	}
+	ctx.server = server
+	ctx.Ctx = &adam.Ctx{}
+	ctx.Ctx.InitLocal(dir, server.URL())
+	return nil
+}
+
+// Server returns in-process Server used by controller
+func (ctx *Ctx) Server() *Server {
+	return ctx.server
+}
+
+// Close stops in-process Server
+func (ctx *Ctx) Close() error {
+	if ctx.server == nil {
+		return nil
+	}
+	err := ctx.server.Close()
+	ctx.server = nil
+	return err
+}
@@ -0,0 +1,366 @@
+package embedded
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eve-api/go/attest"
+	"github.com/lf-edge/eve-api/go/auth"
+	"github.com/lf-edge/eve-api/go/certs"
+	"github.com/lf-edge/eve-api/go/config"
+	eveuuid "github.com/lf-edge/eve-api/go/eveuuid"
+	"github.com/lf-edge/eve-api/go/flowlog"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/logs"
+	"github.com/lf-edge/eve-api/go/metrics"
+	"github.com/lf-edge/eve-api/go/register"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// deviceHandler implements EVE device API v2
+// signatures of AuthContainer are not verified and responses are not signed
+// as the handler is intended for hermetic tests
+type deviceHandler struct {
+	store       *store
+	signingCert []byte
+}
+
+// readAuthContainer reads AuthContainer from body of request
+func readAuthContainer(r *http.Request) (*auth.AuthContainer, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	container := &auth.AuthContainer{}
+	if err := proto.Unmarshal(body, container); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal AuthContainer: %w", err)
+	}
+	if container.ProtectedPayload == nil {
+		return nil, fmt.Errorf("no payload in AuthContainer")
+	}
+	return container, nil
+}
+
+// readPayload reads message wrapped into AuthContainer from body of request
+func readPayload(r *http.Request, msg proto.Message) (*auth.AuthContainer, error) {
+	container, err := readAuthContainer(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := proto.Unmarshal(container.ProtectedPayload.Payload, msg); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal payload: %w", err)
+	}
+	return container, nil
+}
+
+// writePayload writes message wrapped into AuthContainer
+func writePayload(w http.ResponseWriter, status int, msg proto.Message) {
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		wrapError(err, http.StatusInternalServerError, w)
+		return
+	}
+	out, err := proto.Marshal(&auth.AuthContainer{ProtectedPayload: &auth.AuthBody{Payload: payload}})
+	if err != nil {
+		wrapError(err, http.StatusInternalServerError, w)
+		return
+	}
+	w.Header().Set(contentType, mimeProto)
+	w.WriteHeader(status)
+	_, _ = w.Write(out)
+}
+
+// knownDevice returns UUID of device from request if it is known
+func (h *deviceHandler) knownDevice(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	devUUID, err := deviceUUIDFromRequest(r)
+	if err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return uuid.Nil, false
+	}
+	if _, err := h.store.getDevice(devUUID); err != nil {
+		wrapError(err, http.StatusNotFound, w)
+		return uuid.Nil, false
+	}
+	return devUUID, true
+}
+
+// recordRequest saves information about requests of known devices
+func (h *deviceHandler) recordRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		devUUID, err := deviceUUIDFromRequest(r)
+		if err != nil {
+			return
+		}
+		req := &types.APIRequest{
+			Timestamp: time.Now(),
+			UUID:      devUUID,
+			ClientIP:  r.RemoteAddr,
+			Forwarded: r.Header.Get("X-Forwarded-For"),
+			Method:    r.Method,
+			URL:       r.URL.String(),
+		}
+		data, err := json.Marshal(req)
+		if err != nil {
+			return
+		}
+		if err := h.store.addItem(devUUID, requestsDir, data); err != nil {
+			log.Debugf("cannot save request: %s", err)
+		}
+	})
+}
+
+func (h *deviceHandler) ping(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *deviceHandler) certs(w http.ResponseWriter, _ *http.Request) {
+	ctrlCert := &certs.ZControllerCert{}
+	if len(h.signingCert) > 0 {
+		hash := sha256.Sum256(h.signingCert)
+		ctrlCert.Certs = append(ctrlCert.Certs, &certs.ZCert{
+			Type:     certs.ZCertType_CERT_TYPE_CONTROLLER_SIGNING,
+			Cert:     h.signingCert,
+			CertHash: hash[:],
+		})
+	}
+	writePayload(w, http.StatusOK, ctrlCert)
+}
+
+func (h *deviceHandler) register(w http.ResponseWriter, r *http.Request) {
+	msg := &register.ZRegisterMsg{}
+	container, err := readPayload(r, msg)
+	if err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	serial := msg.Serial
+	if serial == "" {
+		serial = msg.SoftSerial
+	}
+	if err := h.store.checkOnboard(container.SenderCert, serial); err != nil {
+		wrapError(err, http.StatusUnauthorized, w)
+		return
+	}
+	if _, err := h.store.addDevice(msg.PemCert, container.SenderCert, serial); err != nil {
+		wrapError(err, http.StatusInternalServerError, w)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *deviceHandler) uuid(w http.ResponseWriter, r *http.Request) {
+	container, err := readPayload(r, &eveuuid.UuidRequest{})
+	if err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	devUUID, err := h.store.findDeviceByCert(container.SenderCert)
+	if err != nil {
+		wrapError(err, http.StatusNotFound, w)
+		return
+	}
+	writePayload(w, http.StatusOK, &eveuuid.UuidResponse{Uuid: devUUID.String()})
+}
+
+func (h *deviceHandler) config(w http.ResponseWriter, r *http.Request) {
+	devUUID, ok := h.knownDevice(w, r)
+	if !ok {
+		return
+	}
+	req := &config.ConfigRequest{}
+	if _, err := readPayload(r, req); err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	data, err := h.store.readDeviceFile(devUUID, deviceConfigFile)
+	if err != nil {
+		wrapError(err, http.StatusInternalServerError, w)
+		return
+	}
+	devConfig := &config.EdgeDevConfig{}
+	if err := proto.Unmarshal(data, devConfig); err != nil {
+		wrapError(err, http.StatusInternalServerError, w)
+		return
+	}
+	hash := sha256.Sum256(data)
+	configHash := hex.EncodeToString(hash[:])
+	if req.ConfigHash == configHash {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writePayload(w, http.StatusOK, &config.ConfigResponse{Config: devConfig, ConfigHash: configHash})
+}
+
+// saveBinary saves payload of request as is
+func (h *deviceHandler) saveBinary(w http.ResponseWriter, r *http.Request, msg proto.Message, subDir string) {
+	devUUID, ok := h.knownDevice(w, r)
+	if !ok {
+		return
+	}
+	container, err := readPayload(r, msg)
+	if err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	if err := h.store.addItem(devUUID, subDir, container.ProtectedPayload.Payload); err != nil {
+		wrapError(err, http.StatusInternalServerError, w)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *deviceHandler) info(w http.ResponseWriter, r *http.Request) {
+	h.saveBinary(w, r, &info.ZInfoMsg{}, infoDir)
+}
+
+func (h *deviceHandler) metrics(w http.ResponseWriter, r *http.Request) {
+	h.saveBinary(w, r, &metrics.ZMetricMsg{}, metricsDir)
+}
+
+func (h *deviceHandler) flowLog(w http.ResponseWriter, r *http.Request) {
+	h.saveBinary(w, r, &flowlog.FlowMessage{}, flowLogDir)
+}
+
+// saveEntries saves log entries in JSON one per file
+func (h *deviceHandler) saveEntries(w http.ResponseWriter, devUUID uuid.UUID, subDir string, entries []*logs.LogEntry) {
+	for _, entry := range entries {
+		data, err := protojson.Marshal(entry)
+		if err != nil {
+			wrapError(err, http.StatusInternalServerError, w)
+			return
+		}
+		if err := h.store.addItem(devUUID, subDir, data); err != nil {
+			wrapError(err, http.StatusInternalServerError, w)
+			return
+		}
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *deviceHandler) logs(w http.ResponseWriter, r *http.Request) {
+	devUUID, ok := h.knownDevice(w, r)
+	if !ok {
+		return
+	}
+	bundle := &logs.LogBundle{}
+	if _, err := readPayload(r, bundle); err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	h.saveEntries(w, devUUID, logsDir, bundle.Log)
+}
+
+func (h *deviceHandler) appLogs(w http.ResponseWriter, r *http.Request) {
+	devUUID, ok := h.knownDevice(w, r)
+	if !ok {
+		return
+	}
+	appUUID, err := uuid.FromString(mux.Vars(r)["appuuid"])
+	if err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	bundle := &logs.AppInstanceLogBundle{}
+	if _, err := readPayload(r, bundle); err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	h.saveEntries(w, devUUID, filepath.Join(appsDir, appUUID.String()), bundle.Log)
+}
+
+func (h *deviceHandler) attest(w http.ResponseWriter, r *http.Request) {
+	devUUID, ok := h.knownDevice(w, r)
+	if !ok {
+		return
+	}
+	req := &attest.ZAttestReq{}
+	if _, err := readPayload(r, req); err != nil {
+		wrapError(err, http.StatusBadRequest, w)
+		return
+	}
+	options := &types.DeviceOptions{}
+	if data, err := h.store.readDeviceFile(devUUID, deviceOptsFile); err == nil {
+		if err := json.Unmarshal(data, options); err != nil {
+			wrapError(err, http.StatusInternalServerError, w)
+			return
+		}
+	}
+	resp := &attest.ZAttestResponse{}
+	switch req.ReqType {
+	case attest.ZAttestReqType_ATTEST_REQ_CERT:
+		data, err := json.Marshal(&types.Zcerts{Certs: req.Certs})
+		if err != nil {
+			wrapError(err, http.StatusInternalServerError, w)
+			return
+		}
+		if err := h.store.writeDeviceFile(devUUID, deviceCertsFile, data); err != nil {
+			wrapError(err, http.StatusInternalServerError, w)
+			return
+		}
+		resp.RespType = attest.ZAttestRespType_ATTEST_RESP_CERT
+	case attest.ZAttestReqType_ATTEST_REQ_NONCE:
+		nonce := make([]byte, 32)
+		if _, err := rand.Read(nonce); err != nil {
+			wrapError(err, http.StatusInternalServerError, w)
+			return
+		}
+		options.Nonce = hex.EncodeToString(nonce)
+		resp.RespType = attest.ZAttestRespType_ATTEST_RESP_NONCE
+		resp.Nonce = &attest.ZAttestNonceResp{Nonce: nonce}
+	case attest.ZAttestReqType_ATTEST_REQ_QUOTE:
+		// signature and PCRs of quote are not validated,
+		// we only check that attestation data contains the nonce we sent
+		quoteResp := &attest.ZAttestQuoteResp{Response: attest.ZAttestResponseCode_Z_ATTEST_RESPONSE_CODE_SUCCESS}
+		nonce, err := hex.DecodeString(options.Nonce)
+		switch {
+		case req.Quote == nil:
+			quoteResp.Response = attest.ZAttestResponseCode_Z_ATTEST_RESPONSE_CODE_QUOTE_FAILED
+		case err != nil || len(nonce) == 0 || !bytes.Contains(req.Quote.AttestData, nonce):
+			quoteResp.Response = attest.ZAttestResponseCode_Z_ATTEST_RESPONSE_CODE_NONCE_MISMATCH
+		default:
+			token := make([]byte, 16)
+			if _, err := rand.Read(token); err != nil {
+				wrapError(err, http.StatusInternalServerError, w)
+				return
+			}
+			options.IntegrityToken = hex.EncodeToString(token)
+			options.Attested = true
+			options.EventLog = req.Quote.EventLog
+			quoteResp.IntegrityToken = []byte(options.IntegrityToken)
+		}
+		resp.RespType = attest.ZAttestRespType_ATTEST_RESP_QUOTE_RESP
+		resp.QuoteResp = quoteResp
+	case attest.ZAttestReqType_Z_ATTEST_REQ_TYPE_STORE_KEYS:
+		resp.RespType = attest.ZAttestRespType_Z_ATTEST_RESP_TYPE_STORE_KEYS
+		resp.StorageKeysResp = &attest.AttestStorageKeysResp{
+			Response: attest.AttestStorageKeysResponseCode_ATTEST_STORAGE_KEYS_RESPONSE_CODE_SUCCESS,
+		}
+	default:
+		wrapError(fmt.Errorf("unsupported attest request type %s", req.ReqType), http.StatusBadRequest, w)
+		return
+	}
+	data, err := json.Marshal(options)
+	if err != nil {
+		wrapError(err, http.StatusInternalServerError, w)
+		return
+	}
+	if err := h.store.writeDeviceFile(devUUID, deviceOptsFile, data); err != nil {
+		wrapError(err, http.StatusInternalServerError, w)
+		return
+	}
+	writePayload(w, http.StatusOK, resp)
+}
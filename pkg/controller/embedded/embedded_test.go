@@ -0,0 +1,110 @@
+package embedded_test
+
+import (
+	"bytes"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller/embedded"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/auth"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/register"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// postToController sends msg wrapped into AuthContainer as EVE does
+func postToController(t *testing.T, url string, senderCert []byte, msg proto.Message) int {
+	payload, err := proto.Marshal(msg)
+	require.NoError(t, err)
+	body, err := proto.Marshal(&auth.AuthContainer{
+		ProtectedPayload: &auth.AuthBody{Payload: payload},
+		SenderCert:       senderCert,
+	})
+	require.NoError(t, err)
+	resp, err := http.Post(url, "application/x-proto-binary", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestEmbeddedController(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	rootCert, rootKey := utils.GenCARoot()
+	genCert := func(cn string) []byte {
+		cert, _ := utils.GenServerCertElliptic(rootCert, rootKey, big.NewInt(2), nil, nil, cn)
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+	onboardUUID := uuid.Must(uuid.NewV4())
+	onboardCert := genCert(onboardUUID.String())
+	deviceCert := genCert("device")
+	onboardCertPath := filepath.Join(dir, "onboard.cert.pem")
+	require.NoError(t, os.WriteFile(onboardCertPath, onboardCert, 0644))
+
+	ctrl := &embedded.Ctx{}
+	require.NoError(t, ctrl.Start(dir, nil))
+	defer ctrl.Close()
+	apiURL := fmt.Sprintf("%s/api/v2/edgedevice", ctrl.Server().URL())
+
+	dev := device.CreateEdgeNode()
+	dev.SetOnboardKey(onboardCertPath)
+	dev.SetSerial("serial")
+	require.NoError(t, ctrl.Register(dev))
+
+	// wrong serial must be rejected
+	assert.Equal(t, http.StatusUnauthorized, postToController(t, apiURL+"/register", onboardCert,
+		&register.ZRegisterMsg{PemCert: deviceCert, Serial: "wrong"}))
+	// concurrent registrations with the same certificate must create one device
+	var wg sync.WaitGroup
+	codes := make(chan int, 8)
+	for i := 0; i < cap(codes); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- postToController(t, apiURL+"/register", onboardCert,
+				&register.ZRegisterMsg{PemCert: deviceCert, Serial: "serial"})
+		}()
+	}
+	wg.Wait()
+	close(codes)
+	for code := range codes {
+		assert.Equal(t, http.StatusCreated, code)
+	}
+
+	devices, err := ctrl.DeviceList(types.AllDevicesFilter)
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	devUUID, err := ctrl.DeviceGetByOnboard(onboardCertPath)
+	require.NoError(t, err)
+	assert.Equal(t, devices[0], devUUID.String())
+
+	assert.Equal(t, http.StatusCreated, postToController(t, fmt.Sprintf("%s/id/%s/info", apiURL, devUUID), deviceCert,
+		&info.ZInfoMsg{DevId: devUUID.String(), Ztype: info.ZInfoTypes_ZiDevice}))
+
+	found := false
+	require.NoError(t, ctrl.InfoLastCallback(devUUID, map[string]string{"devId": devUUID.String()}, func(im *info.ZInfoMsg) bool {
+		found = true
+		return true
+	}))
+	assert.True(t, found)
+
+	requestsFound := false
+	require.NoError(t, ctrl.RequestLastCallback(devUUID, nil, func(req *types.APIRequest) bool {
+		requestsFound = true
+		return true
+	}))
+	assert.True(t, requestsFound)
+}
@@ -0,0 +1,137 @@
+// Package embedded provides in-process implementation of EVE controller.
+// It serves the same admin API as Adam and the EVE device API,
+// and stores data in the same directory layout as Adam,
+// so it can be used by adam.Ctx and loaders.FileLoader without docker.
+package embedded
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	contentType   = "Content-Type"
+	mimeProto     = "application/x-proto-binary"
+	mimeTextPlain = "text/plain"
+	mimeJSON      = "application/json"
+
+	// streamHeader must be in sync with loaders.StreamHeader
+	streamHeader = "X-Stream"
+	// sinceParam and untilParam must be in sync with loaders.SinceParam and loaders.UntilParam
+	sinceParam = "since"
+	untilParam = "until"
+)
+
+// Server is an in-process EVE controller
+type Server struct {
+	store       *store
+	signingCert []byte
+	listener    net.Listener
+	server      *http.Server
+	wg          sync.WaitGroup
+}
+
+// NewServer creates Server which stores data inside dir
+// signingCert is returned to EVE as controller signing certificate if defined
+func NewServer(dir string, signingCert []byte) (*Server, error) {
+	s, err := newStore(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot init store in %s: %w", dir, err)
+	}
+	return &Server{store: s, signingCert: signingCert}, nil
+}
+
+// Handler returns http.Handler with admin and device API of controller
+func (s *Server) Handler() http.Handler {
+	admin := &adminHandler{store: s.store}
+	dev := &deviceHandler{store: s.store, signingCert: s.signingCert}
+
+	router := mux.NewRouter()
+
+	ad := router.PathPrefix("/admin").Subrouter()
+	ad.HandleFunc("/onboard", admin.onboardAdd).Methods("POST")
+	ad.HandleFunc("/onboard/{cn}", admin.onboardRemove).Methods("DELETE")
+	ad.HandleFunc("/device", admin.deviceList).Methods("GET")
+	ad.HandleFunc("/device", admin.deviceAdd).Methods("POST")
+	ad.HandleFunc("/device/{uuid}", admin.deviceGet).Methods("GET")
+	ad.HandleFunc("/device/{uuid}", admin.deviceRemove).Methods("DELETE")
+	ad.HandleFunc("/device/{uuid}/config", admin.configGet).Methods("GET")
+	ad.HandleFunc("/device/{uuid}/config", admin.configSet).Methods("PUT")
+	ad.HandleFunc("/device/{uuid}/certs", admin.certsGet).Methods("GET")
+	ad.HandleFunc("/device/{uuid}/options", admin.deviceOptionsGet).Methods("GET")
+	ad.HandleFunc("/device/{uuid}/options", admin.deviceOptionsSet).Methods("PUT")
+	ad.HandleFunc("/device/{uuid}/apps/instances/id/{appuuid}/logs", admin.appLogsGet).Methods("GET")
+	ad.HandleFunc("/device/{uuid}/{kind:logs|info|metrics|requests|flowMessage}", admin.itemsGet).Methods("GET")
+	ad.HandleFunc("/options", admin.globalOptionsGet).Methods("GET")
+	ad.HandleFunc("/options", admin.globalOptionsSet).Methods("PUT")
+
+	api := router.PathPrefix("/api/v2/edgedevice").Subrouter()
+	api.Use(dev.recordRequest)
+	api.HandleFunc("/ping", dev.ping).Methods("GET")
+	api.HandleFunc("/certs", dev.certs).Methods("GET")
+	api.HandleFunc("/register", dev.register).Methods("POST")
+	api.HandleFunc("/uuid", dev.uuid).Methods("POST")
+	api.HandleFunc("/id/{uuid}/config", dev.config).Methods("POST")
+	api.HandleFunc("/id/{uuid}/info", dev.info).Methods("POST")
+	api.HandleFunc("/id/{uuid}/metrics", dev.metrics).Methods("POST")
+	api.HandleFunc("/id/{uuid}/logs", dev.logs).Methods("POST")
+	api.HandleFunc("/id/{uuid}/flowlog", dev.flowLog).Methods("POST")
+	api.HandleFunc("/id/{uuid}/apps/instanceid/{appuuid}/logs", dev.appLogs).Methods("POST")
+	api.HandleFunc("/id/{uuid}/attest", dev.attest).Methods("POST")
+
+	return router
+}
+
+// Start starts serving on address (e.g. 127.0.0.1:0 to use random port)
+func (s *Server) Start(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	s.server = &http.Server{Handler: s.Handler()}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Errorf("embedded controller serve error: %s", err)
+		}
+	}()
+	log.Debugf("embedded controller started on %s", listener.Addr())
+	return nil
+}
+
+// URL returns url of started Server
+func (s *Server) URL() string {
+	if s.listener == nil {
+		return ""
+	}
+	return fmt.Sprintf("http://%s", s.listener.Addr())
+}
+
+// Dir returns directory where Server stores data
+func (s *Server) Dir() string {
+	return s.store.dir
+}
+
+// Close stops Server
+func (s *Server) Close() error {
+	if s.server == nil {
+		return nil
+	}
+	err := s.server.Close()
+	s.wg.Wait()
+	return err
+}
+
+func wrapError(err error, status int, w http.ResponseWriter) {
+	log.Debugf("embedded controller: %s", err)
+	w.Header().Set(contentType, mimeTextPlain)
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(err.Error()))
+}
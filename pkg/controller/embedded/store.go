@@ -0,0 +1,359 @@
+package embedded
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	onboardCertFile  = "cert.pem"
+	onboardSerials   = "serials"
+	deviceCertFile   = "device-cert.pem"
+	deviceOnboard    = "onboard-cert.pem"
+	deviceSerialFile = "serial"
+	deviceConfigFile = "config"
+	deviceOptsFile   = "options.json"
+	deviceCertsFile  = "certs.json"
+	globalOptsFile   = "options.json"
+
+	// directories of objects inside device directory
+	// they must be in sync with the ones FileLoader reads
+	logsDir     = "logs"
+	infoDir     = "info"
+	metricsDir  = "metrics"
+	requestsDir = "requests"
+	flowLogDir  = "flowMessage"
+	appsDir     = "apps"
+)
+
+// store keeps objects of controller in directory
+// with the same layout as Adam uses
+type store struct {
+	sync.RWMutex
+	dir string
+}
+
+func newStore(dir string) (*store, error) {
+	s := &store{dir: dir}
+	for _, d := range []string{s.onboardRoot(), s.deviceRoot()} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *store) onboardRoot() string {
+	return filepath.Join(s.dir, "run", "adam", "onboard")
+}
+
+func (s *store) deviceRoot() string {
+	return filepath.Join(s.dir, "run", "adam", "device")
+}
+
+func (s *store) deviceDir(devUUID uuid.UUID) string {
+	return filepath.Join(s.deviceRoot(), devUUID.String())
+}
+
+// commonName returns CN of the first certificate in PEM block
+func commonName(cert []byte) (string, error) {
+	c, err := utils.ParseFirstCertFromBlock(cert)
+	if err != nil {
+		return "", err
+	}
+	if c.Subject.CommonName == "" {
+		return "", fmt.Errorf("empty common name in certificate")
+	}
+	return c.Subject.CommonName, nil
+}
+
+// addOnboard saves onboard certificate with allowed serial
+func (s *store) addOnboard(cert []byte, serial string) error {
+	cn, err := commonName(cert)
+	if err != nil {
+		return err
+	}
+	s.Lock()
+	defer s.Unlock()
+	onboardDir := filepath.Join(s.onboardRoot(), cn)
+	if err := os.MkdirAll(onboardDir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(onboardDir, onboardCertFile), cert, 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(onboardDir, onboardSerials), []byte(serial), 0644)
+}
+
+// removeOnboard removes onboard certificate with provided CN
+func (s *store) removeOnboard(cn string) error {
+	s.Lock()
+	defer s.Unlock()
+	onboardDir := filepath.Join(s.onboardRoot(), cn)
+	if _, err := os.Stat(onboardDir); err != nil {
+		return err
+	}
+	return os.RemoveAll(onboardDir)
+}
+
+// checkOnboard checks if onboard certificate is known and serial is allowed for it
+func (s *store) checkOnboard(cert []byte, serial string) error {
+	cn, err := commonName(cert)
+	if err != nil {
+		return err
+	}
+	s.RLock()
+	defer s.RUnlock()
+	onboardDir := filepath.Join(s.onboardRoot(), cn)
+	known, err := os.ReadFile(filepath.Join(onboardDir, onboardCertFile))
+	if err != nil {
+		return fmt.Errorf("unknown onboard certificate %s", cn)
+	}
+	if !bytes.Equal(bytes.TrimSpace(known), bytes.TrimSpace(cert)) {
+		return fmt.Errorf("onboard certificate %s mismatch", cn)
+	}
+	serials, err := os.ReadFile(filepath.Join(onboardDir, onboardSerials))
+	if err != nil {
+		return err
+	}
+	allowed := strings.Fields(strings.ReplaceAll(string(serials), ",", " "))
+	if len(allowed) == 0 {
+		return nil
+	}
+	for _, el := range allowed {
+		if el == "*" || el == serial {
+			return nil
+		}
+	}
+	return fmt.Errorf("serial %s is not allowed for onboard certificate %s", serial, cn)
+}
+
+// listDevices returns UUIDs of devices
+func (s *store) listDevices() ([]uuid.UUID, error) {
+	s.RLock()
+	defer s.RUnlock()
+	return s.readDevices()
+}
+
+// readDevices returns UUIDs of devices, caller must hold the lock
+func (s *store) readDevices() ([]uuid.UUID, error) {
+	entries, err := os.ReadDir(s.deviceRoot())
+	if err != nil {
+		return nil, err
+	}
+	var result []uuid.UUID
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		devUUID, err := uuid.FromString(entry.Name())
+		if err != nil {
+			continue
+		}
+		result = append(result, devUUID)
+	}
+	return result, nil
+}
+
+// addDevice creates device with provided certificates and default config
+func (s *store) addDevice(devCert, onboardCert []byte, serial string) (uuid.UUID, error) {
+	s.Lock()
+	defer s.Unlock()
+	// lookup and creation are done under one lock to not create two devices
+	// on concurrent register requests with the same certificate
+	if existing, err := s.lookupDeviceByCert(devCert); err == nil {
+		return existing, nil
+	}
+	devUUID, err := uuid.NewV4()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	devConfig, err := proto.Marshal(&config.EdgeDevConfig{
+		Id: &config.UUIDandVersion{Uuid: devUUID.String(), Version: "1"},
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	devDir := s.deviceDir(devUUID)
+	for _, d := range []string{logsDir, infoDir, metricsDir, requestsDir, flowLogDir, appsDir} {
+		if err := os.MkdirAll(filepath.Join(devDir, d), 0755); err != nil {
+			return uuid.Nil, err
+		}
+	}
+	files := map[string][]byte{
+		deviceCertFile:   devCert,
+		deviceOnboard:    onboardCert,
+		deviceSerialFile: []byte(serial),
+		deviceConfigFile: devConfig,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(devDir, name), content, 0644); err != nil {
+			return uuid.Nil, err
+		}
+	}
+	return devUUID, nil
+}
+
+// getDevice returns certificates and serial of device
+func (s *store) getDevice(devUUID uuid.UUID) (*types.DeviceCert, error) {
+	s.RLock()
+	defer s.RUnlock()
+	return s.readDevice(devUUID)
+}
+
+// readDevice returns certificates and serial of device, caller must hold the lock
+func (s *store) readDevice(devUUID uuid.UUID) (*types.DeviceCert, error) {
+	devDir := s.deviceDir(devUUID)
+	cert, err := os.ReadFile(filepath.Join(devDir, deviceCertFile))
+	if err != nil {
+		return nil, fmt.Errorf("device %s not found", devUUID)
+	}
+	// onboard certificate and serial are optional
+	onboard, _ := os.ReadFile(filepath.Join(devDir, deviceOnboard))
+	serial, _ := os.ReadFile(filepath.Join(devDir, deviceSerialFile))
+	return &types.DeviceCert{Cert: cert, Onboard: onboard, Serial: string(serial)}, nil
+}
+
+// findDeviceByCert returns UUID of device with provided device certificate
+func (s *store) findDeviceByCert(devCert []byte) (uuid.UUID, error) {
+	s.RLock()
+	defer s.RUnlock()
+	return s.lookupDeviceByCert(devCert)
+}
+
+// lookupDeviceByCert returns UUID of device with provided device certificate, caller must hold the lock
+func (s *store) lookupDeviceByCert(devCert []byte) (uuid.UUID, error) {
+	devices, err := s.readDevices()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	for _, devUUID := range devices {
+		dev, err := s.readDevice(devUUID)
+		if err != nil {
+			continue
+		}
+		if bytes.Equal(bytes.TrimSpace(dev.Cert), bytes.TrimSpace(devCert)) {
+			return devUUID, nil
+		}
+	}
+	return uuid.Nil, fmt.Errorf("no device found")
+}
+
+// removeDevice removes device with all collected objects
+func (s *store) removeDevice(devUUID uuid.UUID) error {
+	s.Lock()
+	defer s.Unlock()
+	devDir := s.deviceDir(devUUID)
+	if _, err := os.Stat(devDir); err != nil {
+		return err
+	}
+	return os.RemoveAll(devDir)
+}
+
+// readDeviceFile returns content of file of device
+func (s *store) readDeviceFile(devUUID uuid.UUID, name string) ([]byte, error) {
+	s.RLock()
+	defer s.RUnlock()
+	return os.ReadFile(filepath.Join(s.deviceDir(devUUID), name))
+}
+
+// writeDeviceFile saves content into file of device
+func (s *store) writeDeviceFile(devUUID uuid.UUID, name string, content []byte) error {
+	s.Lock()
+	defer s.Unlock()
+	devDir := s.deviceDir(devUUID)
+	if _, err := os.Stat(devDir); err != nil {
+		return fmt.Errorf("device %s not found", devUUID)
+	}
+	return os.WriteFile(filepath.Join(devDir, name), content, 0644)
+}
+
+// readGlobalOptions returns global options of controller
+func (s *store) readGlobalOptions() ([]byte, error) {
+	s.RLock()
+	defer s.RUnlock()
+	data, err := os.ReadFile(filepath.Join(s.dir, "run", "adam", globalOptsFile))
+	if os.IsNotExist(err) {
+		return json.Marshal(&types.GlobalOptions{})
+	}
+	return data, err
+}
+
+// writeGlobalOptions saves global options of controller
+func (s *store) writeGlobalOptions(content []byte) error {
+	s.Lock()
+	defer s.Unlock()
+	return os.WriteFile(filepath.Join(s.dir, "run", "adam", globalOptsFile), content, 0644)
+}
+
+// addItem saves object into subdirectory of device named with timestamp of receiving
+func (s *store) addItem(devUUID uuid.UUID, subDir string, data []byte) error {
+	s.Lock()
+	defer s.Unlock()
+	devDir := s.deviceDir(devUUID)
+	if _, err := os.Stat(devDir); err != nil {
+		return fmt.Errorf("device %s not found", devUUID)
+	}
+	itemDir := filepath.Join(devDir, subDir)
+	if err := os.MkdirAll(itemDir, 0755); err != nil {
+		return err
+	}
+	for {
+		now := time.Now()
+		fileName := filepath.Join(itemDir, fmt.Sprintf("%d:%09d", now.Unix(), now.Nanosecond()))
+		f, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}
+}
+
+// itemReceiveTime parses time encoded in the name of item file by addItem
+func itemReceiveTime(name string) (time.Time, bool) {
+	var sec, nsec int64
+	if _, err := fmt.Sscanf(name, "%d:%d", &sec, &nsec); err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(sec, nsec), true
+}
+
+// listItems returns names of files with objects inside subdirectory of device in order of receiving
+func (s *store) listItems(devUUID uuid.UUID, subDir string) ([]string, error) {
+	s.RLock()
+	defer s.RUnlock()
+	itemDir := filepath.Join(s.deviceDir(devUUID), subDir)
+	entries, err := os.ReadDir(itemDir)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		result = append(result, filepath.Join(itemDir, entry.Name()))
+	}
+	sort.Strings(result)
+	return result, nil
+}
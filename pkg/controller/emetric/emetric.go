@@ -5,10 +5,10 @@ package emetric
 import (
 	"fmt"
 	"reflect"
-	"regexp"
 	"strings"
 	"time"
 
+	"github.com/lf-edge/eden/pkg/controller/equery"
 	"github.com/lf-edge/eden/pkg/controller/loaders"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/utils"
@@ -66,38 +66,10 @@ func MetricItemPrint(mm *metrics.ZMetricMsg, query []string) *types.PrintResult
 	return &result
 }
 
-// MetricItemFind find ZMetricMsg records by reqexps in 'query' corresponded to ZMetricMsg structure.
+// MetricItemFind find ZMetricMsg records corresponded to ZMetricMsg structure by 'query'
+// in the format supported by equery.Compile (field:regexp pairs and expression).
 func MetricItemFind(mm *metrics.ZMetricMsg, query map[string]string) bool {
-	matched := true
-	for k, v := range query {
-		splitRequest := strings.Split(k, ".")
-		if len(splitRequest) > 0 && strings.ToLower(splitRequest[0]) == "dm" { //dm is located in MetricContent
-			query[strings.Join(append([]string{"MetricContent"}, splitRequest...), ".")] = v
-			delete(query, k)
-		}
-		// Uppercase of filed's name first letter
-		var n []string
-		caser := cases.Title(language.English, cases.NoLower)
-		for _, pathElement := range strings.Split(k, ".") {
-			n = append(n, caser.String(pathElement))
-		}
-		var clb = func(inp reflect.Value) {
-			f := fmt.Sprint(inp)
-			newMatched, err := regexp.Match(v, []byte(f))
-			if err != nil {
-				log.Debug(err)
-			}
-			if !matched && newMatched {
-				matched = newMatched
-			}
-		}
-		matched = false
-		utils.LookupWithCallback(reflect.Indirect(reflect.ValueOf(mm)).Interface(), strings.Join(n, "."), clb)
-		if !matched {
-			return matched
-		}
-	}
-	return matched
+	return equery.Match(mm, query)
 }
 
 // MetricPrn print Metric data
@@ -148,12 +120,20 @@ func metricProcess(query map[string]string, handler HandlerFunc) loaders.Process
 // MetricWatch monitors the change of Metric files in the 'filepath' directory
 // according to the 'query' reqexps and processing using the 'handler' function.
 func MetricWatch(loader loaders.Loader, query map[string]string, handler HandlerFunc, timeoutSeconds time.Duration) error {
+	if err := equery.Validate(query); err != nil {
+		return err
+	}
+	loader.SetTimeRange(equery.TimeRange(query))
 	return loader.ProcessStream(metricProcess(query, handler), types.MetricsType, timeoutSeconds)
 }
 
 // MetricLast function process Metric files in the 'filepath' directory
 // according to the 'query' reqexps and return last founded item
 func MetricLast(loader loaders.Loader, query map[string]string, handler HandlerFunc) error {
+	if err := equery.Validate(query); err != nil {
+		return err
+	}
+	loader.SetTimeRange(equery.TimeRange(query))
 	return loader.ProcessExisting(metricProcess(query, handler), types.MetricsType)
 }
 
@@ -0,0 +1,271 @@
+package emetric
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/lf-edge/eve-api/go/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "eve"
+	// values reported by EVE in MBytes
+	mb = 1024 * 1024
+)
+
+var (
+	deviceLabels   = []string{"device"}
+	devIfLabels    = []string{"device", "interface"}
+	devDiskLabels  = []string{"device", "disk", "mount_path"}
+	appLabels      = []string{"device", "app_id", "app_name"}
+	appIfLabels    = []string{"device", "app_id", "app_name", "interface"}
+	appDiskLabels  = []string{"device", "app_id", "app_name", "disk"}
+	netInstLabels  = []string{"device", "network_id", "network_name"}
+	netInstIfLabel = []string{"device", "network_id", "network_name", "interface"}
+	volumeLabels   = []string{"device", "volume_id", "volume_name"}
+)
+
+func newDesc(subsystem, name, help string, labels []string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, name), help, labels, nil)
+}
+
+// networkDescs describes counters of NetworkMetric
+type networkDescs struct {
+	txBytes, rxBytes, txPkts, rxPkts, txDrops, rxDrops, txErrors, rxErrors *prometheus.Desc
+}
+
+func newNetworkDescs(subsystem string, labels []string) networkDescs {
+	return networkDescs{
+		txBytes:  newDesc(subsystem, "network_transmit_bytes_total", "Bytes transmitted by interface.", labels),
+		rxBytes:  newDesc(subsystem, "network_receive_bytes_total", "Bytes received by interface.", labels),
+		txPkts:   newDesc(subsystem, "network_transmit_packets_total", "Packets transmitted by interface.", labels),
+		rxPkts:   newDesc(subsystem, "network_receive_packets_total", "Packets received by interface.", labels),
+		txDrops:  newDesc(subsystem, "network_transmit_drops_total", "Outgoing packets dropped by interface.", labels),
+		rxDrops:  newDesc(subsystem, "network_receive_drops_total", "Incoming packets dropped by interface.", labels),
+		txErrors: newDesc(subsystem, "network_transmit_errors_total", "Transmit errors of interface.", labels),
+		rxErrors: newDesc(subsystem, "network_receive_errors_total", "Receive errors of interface.", labels),
+	}
+}
+
+func (d networkDescs) all() []*prometheus.Desc {
+	return []*prometheus.Desc{d.txBytes, d.rxBytes, d.txPkts, d.rxPkts, d.txDrops, d.rxDrops, d.txErrors, d.rxErrors}
+}
+
+func (d networkDescs) collect(ch chan<- prometheus.Metric, nm *metrics.NetworkMetric, labels ...string) {
+	counter(ch, d.txBytes, float64(nm.TxBytes), labels...)
+	counter(ch, d.rxBytes, float64(nm.RxBytes), labels...)
+	counter(ch, d.txPkts, float64(nm.TxPkts), labels...)
+	counter(ch, d.rxPkts, float64(nm.RxPkts), labels...)
+	counter(ch, d.txDrops, float64(nm.TxDrops), labels...)
+	counter(ch, d.rxDrops, float64(nm.RxDrops), labels...)
+	counter(ch, d.txErrors, float64(nm.TxErrors), labels...)
+	counter(ch, d.rxErrors, float64(nm.RxErrors), labels...)
+}
+
+var (
+	metricTimestamp = newDesc("", "metric_timestamp_seconds", "Time when EVE collected the last metrics of device.", deviceLabels)
+
+	deviceMemoryUsed      = newDesc("device", "memory_used_bytes", "Memory used by device.", deviceLabels)
+	deviceMemoryAvailable = newDesc("device", "memory_available_bytes", "Memory available on device.", deviceLabels)
+	deviceCPU             = newDesc("device", "cpu_seconds_total", "CPU time consumed by device.", deviceLabels)
+	deviceStorageOverhead = newDesc("device", "runtime_storage_overhead_bytes", "Storage used by EVE runtime.", deviceLabels)
+	deviceNetwork         = newNetworkDescs("device", devIfLabels)
+	deviceDiskRead        = newDesc("device", "disk_read_bytes_total", "Bytes read from disk.", devDiskLabels)
+	deviceDiskWritten     = newDesc("device", "disk_written_bytes_total", "Bytes written to disk.", devDiskLabels)
+	deviceDiskReads       = newDesc("device", "disk_reads_completed_total", "Read operations completed on disk.", devDiskLabels)
+	deviceDiskWrites      = newDesc("device", "disk_writes_completed_total", "Write operations completed on disk.", devDiskLabels)
+	deviceDiskTotal       = newDesc("device", "disk_size_bytes", "Size of filesystem.", devDiskLabels)
+	deviceDiskUsed        = newDesc("device", "disk_used_bytes", "Space used on filesystem.", devDiskLabels)
+	deviceDiskFree        = newDesc("device", "disk_free_bytes", "Space free on filesystem.", devDiskLabels)
+
+	appCPU             = newDesc("app", "cpu_seconds_total", "CPU time consumed by application.", appLabels)
+	appMemoryUsed      = newDesc("app", "memory_used_bytes", "Memory used by application.", appLabels)
+	appMemoryAllocated = newDesc("app", "memory_allocated_bytes", "Memory allocated to application.", appLabels)
+	appNetwork         = newNetworkDescs("app", appIfLabels)
+	appDiskProvisioned = newDesc("app", "disk_provisioned_bytes", "Provisioned size of application disk.", appDiskLabels)
+	appDiskUsed        = newDesc("app", "disk_used_bytes", "Space used on application disk.", appDiskLabels)
+
+	netInstActivated = newDesc("network_instance", "activated", "Forwarding is enabled for network instance.", netInstLabels)
+	netInstNetwork   = newNetworkDescs("network_instance", netInstIfLabel)
+	netInstRxBytes   = newDesc("network_instance", "receive_bytes_total", "Bytes received by network instance.", netInstLabels)
+	netInstTxBytes   = newDesc("network_instance", "transmit_bytes_total", "Bytes transmitted by network instance.", netInstLabels)
+	netInstRxPkts    = newDesc("network_instance", "receive_packets_total", "Packets received by network instance.", netInstLabels)
+	netInstTxPkts    = newDesc("network_instance", "transmit_packets_total", "Packets transmitted by network instance.", netInstLabels)
+
+	volumeRead    = newDesc("volume", "read_bytes_total", "Bytes read from volume.", volumeLabels)
+	volumeWritten = newDesc("volume", "written_bytes_total", "Bytes written to volume.", volumeLabels)
+	volumeReads   = newDesc("volume", "reads_completed_total", "Read operations completed on volume.", volumeLabels)
+	volumeWrites  = newDesc("volume", "writes_completed_total", "Write operations completed on volume.", volumeLabels)
+	volumeTotal   = newDesc("volume", "size_bytes", "Size of volume.", volumeLabels)
+	volumeUsed    = newDesc("volume", "used_bytes", "Space used on volume.", volumeLabels)
+	volumeFree    = newDesc("volume", "free_bytes", "Space free on volume.", volumeLabels)
+)
+
+func counter(ch chan<- prometheus.Metric, desc *prometheus.Desc, value float64, labels ...string) {
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value, labels...)
+}
+
+func gauge(ch chan<- prometheus.Metric, desc *prometheus.Desc, value float64, labels ...string) {
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labels...)
+}
+
+// Exporter keeps the last ZMetricMsg of every device and exposes it
+// as Prometheus metrics, it implements prometheus.Collector
+type Exporter struct {
+	mu     sync.RWMutex
+	latest map[string]*metrics.ZMetricMsg
+}
+
+// NewExporter returns Exporter without metrics
+func NewExporter() *Exporter {
+	return &Exporter{latest: make(map[string]*metrics.ZMetricMsg)}
+}
+
+// Update stores mm as the last metrics of its device,
+// metrics collected before the stored ones are ignored
+func (e *Exporter) Update(mm *metrics.ZMetricMsg) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if prev, ok := e.latest[mm.DevID]; ok && mm.AtTimeStamp.AsTime().Before(prev.AtTimeStamp.AsTime()) {
+		return
+	}
+	e.latest[mm.DevID] = mm
+}
+
+// Handler returns HandlerFunc for MetricChecker which updates Exporter
+func (e *Exporter) Handler() HandlerFunc {
+	return func(mm *metrics.ZMetricMsg) bool {
+		e.Update(mm)
+		return false
+	}
+}
+
+// HTTPHandler returns handler which serves metrics of Exporter in Prometheus text format
+func (e *Exporter) HTTPHandler() http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(e)
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+}
+
+// Describe implements prometheus.Collector
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	descs := []*prometheus.Desc{
+		metricTimestamp,
+		deviceMemoryUsed, deviceMemoryAvailable, deviceCPU, deviceStorageOverhead,
+		deviceDiskRead, deviceDiskWritten, deviceDiskReads, deviceDiskWrites,
+		deviceDiskTotal, deviceDiskUsed, deviceDiskFree,
+		appCPU, appMemoryUsed, appMemoryAllocated, appDiskProvisioned, appDiskUsed,
+		netInstActivated, netInstRxBytes, netInstTxBytes, netInstRxPkts, netInstTxPkts,
+		volumeRead, volumeWritten, volumeReads, volumeWrites, volumeTotal, volumeUsed, volumeFree,
+	}
+	descs = append(descs, deviceNetwork.all()...)
+	descs = append(descs, appNetwork.all()...)
+	descs = append(descs, netInstNetwork.all()...)
+	for _, desc := range descs {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for dev, mm := range e.latest {
+		if mm.AtTimeStamp != nil {
+			gauge(ch, metricTimestamp, float64(mm.AtTimeStamp.AsTime().UnixNano())/1e9, dev)
+		}
+		if dm := mm.GetDm(); dm != nil {
+			collectDevice(ch, dev, dm)
+		}
+		for _, am := range mm.GetAm() {
+			collectApp(ch, dev, am)
+		}
+		for _, nm := range mm.GetNm() {
+			collectNetworkInstance(ch, dev, nm)
+		}
+		for _, vm := range mm.GetVm() {
+			collectVolume(ch, dev, vm)
+		}
+	}
+}
+
+func collectDevice(ch chan<- prometheus.Metric, dev string, dm *metrics.DeviceMetric) {
+	if dm.Memory != nil {
+		gauge(ch, deviceMemoryUsed, float64(dm.Memory.UsedMem)*mb, dev)
+		gauge(ch, deviceMemoryAvailable, float64(dm.Memory.AvailMem)*mb, dev)
+	}
+	if dm.CpuMetric != nil {
+		counter(ch, deviceCPU, float64(dm.CpuMetric.Total), dev)
+	}
+	gauge(ch, deviceStorageOverhead, float64(dm.RuntimeStorageOverheadMB)*mb, dev)
+	for _, nm := range dm.Network {
+		deviceNetwork.collect(ch, nm, dev, nm.IName)
+	}
+	for _, disk := range dm.Disk {
+		labels := []string{dev, disk.Disk, disk.MountPath}
+		counter(ch, deviceDiskRead, float64(disk.ReadBytes)*mb, labels...)
+		counter(ch, deviceDiskWritten, float64(disk.WriteBytes)*mb, labels...)
+		counter(ch, deviceDiskReads, float64(disk.ReadCount), labels...)
+		counter(ch, deviceDiskWrites, float64(disk.WriteCount), labels...)
+		// sizes are known only for mounted filesystems
+		if disk.MountPath != "" {
+			gauge(ch, deviceDiskTotal, float64(disk.Total)*mb, labels...)
+			gauge(ch, deviceDiskUsed, float64(disk.Used)*mb, labels...)
+			gauge(ch, deviceDiskFree, float64(disk.Free)*mb, labels...)
+		}
+	}
+}
+
+func collectApp(ch chan<- prometheus.Metric, dev string, am *metrics.AppMetric) {
+	if am.Cpu != nil {
+		counter(ch, appCPU, float64(am.Cpu.Total), dev, am.AppID, am.AppName)
+	}
+	if am.AppMemory != nil {
+		gauge(ch, appMemoryUsed, float64(am.AppMemory.UsedMB)*mb, dev, am.AppID, am.AppName)
+		gauge(ch, appMemoryAllocated, float64(am.AppMemory.AllocatedMB)*mb, dev, am.AppID, am.AppName)
+	} else if am.Memory != nil {
+		gauge(ch, appMemoryUsed, float64(am.Memory.UsedMem)*mb, dev, am.AppID, am.AppName)
+	}
+	for _, nm := range am.Network {
+		appNetwork.collect(ch, nm, dev, am.AppID, am.AppName, nm.IName)
+	}
+	for _, disk := range am.Disk {
+		gauge(ch, appDiskProvisioned, float64(disk.Provisioned)*mb, dev, am.AppID, am.AppName, disk.Disk)
+		gauge(ch, appDiskUsed, float64(disk.Used)*mb, dev, am.AppID, am.AppName, disk.Disk)
+	}
+}
+
+func collectNetworkInstance(ch chan<- prometheus.Metric, dev string, nm *metrics.ZMetricNetworkInstance) {
+	labels := []string{dev, nm.NetworkID, nm.Displayname}
+	var activated float64
+	if nm.Activated {
+		activated = 1
+	}
+	gauge(ch, netInstActivated, activated, labels...)
+	if stats := nm.NetworkStats; stats != nil {
+		if stats.Rx != nil {
+			counter(ch, netInstRxBytes, float64(stats.Rx.TotalBytes), labels...)
+			counter(ch, netInstRxPkts, float64(stats.Rx.TotalPackets), labels...)
+		}
+		if stats.Tx != nil {
+			counter(ch, netInstTxBytes, float64(stats.Tx.TotalBytes), labels...)
+			counter(ch, netInstTxPkts, float64(stats.Tx.TotalPackets), labels...)
+		}
+	}
+	for _, ifMetric := range nm.Network {
+		netInstNetwork.collect(ch, ifMetric, append(labels, ifMetric.IName)...)
+	}
+}
+
+func collectVolume(ch chan<- prometheus.Metric, dev string, vm *metrics.ZMetricVolume) {
+	labels := []string{dev, vm.Uuid, vm.DisplayName}
+	counter(ch, volumeRead, float64(vm.ReadBytes), labels...)
+	counter(ch, volumeWritten, float64(vm.WriteBytes), labels...)
+	counter(ch, volumeReads, float64(vm.ReadCount), labels...)
+	counter(ch, volumeWrites, float64(vm.WriteCount), labels...)
+	gauge(ch, volumeTotal, float64(vm.TotalBytes), labels...)
+	gauge(ch, volumeUsed, float64(vm.UsedBytes), labels...)
+	gauge(ch, volumeFree, float64(vm.FreeBytes), labels...)
+}
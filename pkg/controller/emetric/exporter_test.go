@@ -0,0 +1,54 @@
+package emetric_test
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eve-api/go/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestExporter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	exporter := emetric.NewExporter()
+	handler := exporter.Handler()
+	handler(&metrics.ZMetricMsg{
+		DevID:       "dev1",
+		AtTimeStamp: timestamppb.New(now),
+		MetricContent: &metrics.ZMetricMsg_Dm{Dm: &metrics.DeviceMetric{
+			Memory:  &metrics.MemoryMetric{UsedMem: 2, AvailMem: 3},
+			Network: []*metrics.NetworkMetric{{IName: "eth0", TxBytes: 100, RxBytes: 200}},
+		}},
+		Am: []*metrics.AppMetric{{AppID: "app-id", AppName: "nginx", Cpu: &metrics.AppCpuMetric{Total: 42}}},
+		Vm: []*metrics.ZMetricVolume{{Uuid: "vol-id", DisplayName: "vol", UsedBytes: 1024}},
+		Nm: []*metrics.ZMetricNetworkInstance{{NetworkID: "ni-id", Displayname: "local", Activated: true}},
+	})
+	// older metrics must not replace newer ones
+	handler(&metrics.ZMetricMsg{
+		DevID:         "dev1",
+		AtTimeStamp:   timestamppb.New(now.Add(-time.Minute)),
+		MetricContent: &metrics.ZMetricMsg_Dm{Dm: &metrics.DeviceMetric{Memory: &metrics.MemoryMetric{UsedMem: 1}}},
+	})
+
+	rec := httptest.NewRecorder()
+	exporter.HTTPHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, 200, rec.Code)
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	for _, line := range []string{
+		`eve_device_memory_used_bytes{device="dev1"} 2.097152e+06`,
+		`eve_device_network_transmit_bytes_total{device="dev1",interface="eth0"} 100`,
+		`eve_app_cpu_seconds_total{app_id="app-id",app_name="nginx",device="dev1"} 42`,
+		`eve_volume_used_bytes{device="dev1",volume_id="vol-id",volume_name="vol"} 1024`,
+		`eve_network_instance_activated{device="dev1",network_id="ni-id",network_name="local"} 1`,
+	} {
+		assert.Contains(t, string(body), line)
+	}
+}
@@ -0,0 +1,546 @@
+// Package equery implements a small expression language used to filter
+// messages (info, metrics, logs, flow logs, requests) received from EVE.
+//
+// Grammar:
+//
+//	expr   := and { ("||" | "OR") and }
+//	and    := unary { ("&&" | "AND") unary }
+//	unary  := ("!" | "NOT") unary | "(" expr ")" | quant | cmp | path
+//	quant  := ("any" | "all") "(" path "," expr ")"
+//	cmp    := path op value
+//	op     := "==" | "=" | "!=" | ">" | ">=" | "<" | "<=" | "~" | "!~"
+//	value  := number | bool | time | word | "quoted string" | 'quoted string'
+//
+// Path elements are case-insensitive field names separated by dots. Repeated
+// fields are expanded automatically, so a comparison is true if it holds for
+// any element; use any()/all() to evaluate a sub-expression per element with
+// paths relative to that element. Fields inside oneof wrappers can be
+// addressed directly (e.g. dm.memory.usedMem for metrics). A bare path is
+// true if the field is present and not zero. Time fields are compared with
+// RFC3339 or local date and time (YYYY-MM-DD, 'YYYY-MM-DD HH:MM[:SS]')
+// values or with now[+-]duration (e.g. now-15m), which is resolved every
+// time the query is evaluated. Numbers are not treated as times.
+//
+// Examples:
+//
+//	dm.memory.usedMem > 800 && dm.memory.usedMem <= 1000
+//	severity ~ "err|warn" && !(source == zedagent)
+//	timestamp >= now-1h
+//	any(dm.network, iName == eth0 && txBytes > 0)
+package equery
+
+import (
+	"container/list"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// ExprKey is the key in the query map which holds an expression; the rest of
+// the map except of SinceKey and UntilKey is treated as field:regexp pairs combined with AND
+const ExprKey = "@expr"
+
+// Query is a compiled expression
+type Query struct {
+	root node
+}
+
+// Match returns true if obj satisfies the query
+func (q *Query) Match(obj interface{}) bool {
+	if q == nil || q.root == nil {
+		return true
+	}
+	return q.root.eval(reflect.ValueOf(obj))
+}
+
+type compiled struct {
+	q   *Query
+	err error
+}
+
+// maxCachedQueries limits number of compiled queries kept in cache,
+// the least recently used query is evicted when the limit is reached
+const maxCachedQueries = 1024
+
+// cacheEntry is an element of cacheLRU
+type cacheEntry struct {
+	key string
+	c   *compiled
+}
+
+var (
+	cacheMu  sync.Mutex
+	cache    = map[string]*list.Element{}
+	cacheLRU = list.New()
+)
+
+// Parse compiles the expression
+func Parse(expr string) (*Query, error) {
+	p := &parser{lex: &lexer{input: expr}}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokEOF {
+		return &Query{}, nil
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", p.tok.text, p.tok.pos)
+	}
+	return &Query{root: root}, nil
+}
+
+// Compile builds Query from the map of field:regexp pairs and optional
+// expression stored with ExprKey. Results are cached, so the same query
+// is usually parsed only once; relative times in the query are resolved
+// on evaluation, so cached queries do not depend on the time of compilation.
+func Compile(query map[string]string) (*Query, error) {
+	key := cacheKey(query)
+	cacheMu.Lock()
+	if el, ok := cache[key]; ok {
+		cacheLRU.MoveToFront(el)
+		c := el.Value.(*cacheEntry).c
+		cacheMu.Unlock()
+		return c.q, c.err
+	}
+	cacheMu.Unlock()
+	q, err := compile(query)
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if _, ok := cache[key]; !ok {
+		if cacheLRU.Len() >= maxCachedQueries {
+			oldest := cacheLRU.Back()
+			cacheLRU.Remove(oldest)
+			delete(cache, oldest.Value.(*cacheEntry).key)
+		}
+		cache[key] = cacheLRU.PushFront(&cacheEntry{key: key, c: &compiled{q: q, err: err}})
+	}
+	return q, err
+}
+
+// Validate returns error if query cannot be compiled with Compile
+func Validate(query map[string]string) error {
+	if _, err := Compile(query); err != nil {
+		return fmt.Errorf("invalid query: %w", err)
+	}
+	return nil
+}
+
+// Match checks obj against query compiled with Compile;
+// queries which cannot be compiled do not match anything,
+// so they must be checked with Validate before processing of objects
+func Match(obj interface{}, query map[string]string) bool {
+	if len(query) == 0 {
+		return true
+	}
+	q, err := Compile(query)
+	if err != nil {
+		return false
+	}
+	return q.Match(obj)
+}
+
+// FromArgs builds query map from field:regexp arguments and expression
+func FromArgs(args []string, expr string) (map[string]string, error) {
+	q := make(map[string]string)
+	for _, a := range args {
+		s := strings.SplitN(a, ":", 2)
+		if len(s) != 2 {
+			return nil, fmt.Errorf("incorrect query %q: field:regexp expected", a)
+		}
+		q[s[0]] = s[1]
+	}
+	if expr != "" {
+		q[ExprKey] = expr
+	}
+	if err := Validate(q); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func compile(query map[string]string) (*Query, error) {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var nodes []node
+	for _, k := range keys {
+		if k == SinceKey || k == UntilKey {
+			// time range is applied by loaders
+			continue
+		}
+		if k == ExprKey {
+			q, err := Parse(query[k])
+			if err != nil {
+				return nil, fmt.Errorf("%q: %w", query[k], err)
+			}
+			if q.root != nil {
+				nodes = append(nodes, q.root)
+			}
+			continue
+		}
+		lit, err := newLiteral(query[k], opMatch)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+		nodes = append(nodes, &cmpNode{path: parsePath(k), op: opMatch, lit: lit})
+	}
+	switch len(nodes) {
+	case 0:
+		return &Query{}, nil
+	case 1:
+		return &Query{root: nodes[0]}, nil
+	}
+	return &Query{root: &andNode{nodes: nodes}}, nil
+}
+
+func cacheKey(query map[string]string) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteByte(0)
+		sb.WriteString(query[k])
+		sb.WriteByte(0)
+	}
+	return sb.String()
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+type lexer struct {
+	input string
+	pos   int
+}
+
+const specialChars = "()=!<>~&|,\"'"
+
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.input) && unicode.IsSpace(rune(l.input[l.pos])) {
+		l.pos++
+	}
+	start := l.pos
+	if l.pos >= len(l.input) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+	rest := l.input[l.pos:]
+	for _, op := range []string{"&&", "||", "==", "!=", "!~", ">=", "<="} {
+		if strings.HasPrefix(rest, op) {
+			l.pos += len(op)
+			switch op {
+			case "&&":
+				return token{kind: tokAnd, text: op, pos: start}, nil
+			case "||":
+				return token{kind: tokOr, text: op, pos: start}, nil
+			}
+			return token{kind: tokOp, text: op, pos: start}, nil
+		}
+	}
+	c := rest[0]
+	switch c {
+	case '(':
+		l.pos++
+		return token{kind: tokLParen, text: "(", pos: start}, nil
+	case ')':
+		l.pos++
+		return token{kind: tokRParen, text: ")", pos: start}, nil
+	case ',':
+		l.pos++
+		return token{kind: tokComma, text: ",", pos: start}, nil
+	case '!':
+		l.pos++
+		return token{kind: tokNot, text: "!", pos: start}, nil
+	case '=', '<', '>', '~':
+		l.pos++
+		return token{kind: tokOp, text: string(c), pos: start}, nil
+	case '"':
+		end := l.pos + 1
+		for end < len(l.input) && l.input[end] != '"' {
+			if l.input[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(l.input) {
+			return token{}, fmt.Errorf("unterminated string at %d", start)
+		}
+		s, err := strconv.Unquote(l.input[start : end+1])
+		if err != nil {
+			return token{}, fmt.Errorf("bad string at %d: %w", start, err)
+		}
+		l.pos = end + 1
+		return token{kind: tokString, text: s, pos: start}, nil
+	case '\'':
+		end := strings.IndexByte(l.input[l.pos+1:], '\'')
+		if end < 0 {
+			return token{}, fmt.Errorf("unterminated string at %d", start)
+		}
+		l.pos += end + 2
+		return token{kind: tokString, text: l.input[start+1 : l.pos-1], pos: start}, nil
+	case '&', '|':
+		return token{}, fmt.Errorf("unexpected %q at %d", c, start)
+	}
+	for l.pos < len(l.input) && !unicode.IsSpace(rune(l.input[l.pos])) &&
+		!strings.ContainsRune(specialChars, rune(l.input[l.pos])) {
+		l.pos++
+	}
+	word := l.input[start:l.pos]
+	switch strings.ToUpper(word) {
+	case "AND":
+		return token{kind: tokAnd, text: word, pos: start}, nil
+	case "OR":
+		return token{kind: tokOr, text: word, pos: start}, nil
+	case "NOT":
+		return token{kind: tokNot, text: word, pos: start}, nil
+	}
+	return token{kind: tokWord, text: word, pos: start}, nil
+}
+
+type parser struct {
+	lex *lexer
+	tok token
+}
+
+func (p *parser) next() (err error) {
+	p.tok, err = p.lex.next()
+	return err
+}
+
+func (p *parser) expect(kind tokenKind, what string) error {
+	if p.tok.kind != kind {
+		return fmt.Errorf("%s expected at %d", what, p.tok.pos)
+	}
+	return p.next()
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []node{left}
+	for p.tok.kind == tokOr {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, right)
+	}
+	if len(nodes) == 1 {
+		return left, nil
+	}
+	return &orNode{nodes: nodes}, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []node{left}
+	for p.tok.kind == tokAnd {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, right)
+	}
+	if len(nodes) == 1 {
+		return left, nil
+	}
+	return &andNode{nodes: nodes}, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	switch p.tok.kind {
+	case tokNot:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{node: n}, nil
+	case tokLParen:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return n, p.expect(tokRParen, "')'")
+	case tokWord:
+	default:
+		return nil, fmt.Errorf("field expected at %d", p.tok.pos)
+	}
+	word := p.tok
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if fn := strings.ToLower(word.text); (fn == "any" || fn == "all") && p.tok.kind == tokLParen {
+		return p.parseQuant(fn == "all")
+	}
+	path := parsePath(word.text)
+	if p.tok.kind != tokOp {
+		return &existsNode{path: path}, nil
+	}
+	op, neg := operators[p.tok.text], strings.HasPrefix(p.tok.text, "!")
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokWord && p.tok.kind != tokString {
+		return nil, fmt.Errorf("value expected at %d", p.tok.pos)
+	}
+	lit, err := newLiteral(p.tok.text, op)
+	if err != nil {
+		return nil, fmt.Errorf("value at %d: %w", p.tok.pos, err)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	var n node = &cmpNode{path: path, op: op, lit: lit}
+	if neg {
+		n = &notNode{node: n}
+	}
+	return n, nil
+}
+
+func (p *parser) parseQuant(all bool) (node, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokWord {
+		return nil, fmt.Errorf("field expected at %d", p.tok.pos)
+	}
+	path := parsePath(p.tok.text)
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokComma, "','"); err != nil {
+		return nil, err
+	}
+	sub, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	return &quantNode{all: all, path: path, node: sub}, p.expect(tokRParen, "')'")
+}
+
+type operator int
+
+const (
+	opEq operator = iota
+	opLt
+	opLe
+	opGt
+	opGe
+	opMatch
+)
+
+// operators maps text to operator; != and !~ are negations of == and ~
+var operators = map[string]operator{
+	"=": opEq, "==": opEq, "!=": opEq,
+	"<": opLt, "<=": opLe, ">": opGt, ">=": opGe,
+	"~": opMatch, "!~": opMatch,
+}
+
+type literal struct {
+	raw     string
+	re      *regexp.Regexp
+	isInt   bool
+	i       int64
+	isUint  bool
+	u       uint64
+	isFloat bool
+	f       float64
+	isBool  bool
+	b       bool
+	isTime  bool
+	t       time.Time
+	// relative is set for now[+-]duration, the time is now+offset
+	relative bool
+	offset   time.Duration
+}
+
+// time returns time of the literal resolving relative one against the current time
+func (lit *literal) time() time.Time {
+	if lit.relative {
+		return time.Now().Add(lit.offset)
+	}
+	return lit.t
+}
+
+func newLiteral(raw string, op operator) (*literal, error) {
+	lit := &literal{raw: raw}
+	if op == opMatch {
+		re, err := regexp.Compile(raw)
+		if err != nil {
+			return nil, err
+		}
+		lit.re = re
+		return lit, nil
+	}
+	if i, err := strconv.ParseInt(raw, 0, 64); err == nil {
+		lit.isInt, lit.i = true, i
+	}
+	if u, err := strconv.ParseUint(raw, 0, 64); err == nil {
+		lit.isUint, lit.u = true, u
+	}
+	if f, err := strconv.ParseFloat(raw, 64); err == nil {
+		lit.isFloat, lit.f = true, f
+	}
+	if b, err := strconv.ParseBool(raw); err == nil && !lit.isFloat {
+		lit.isBool, lit.b = true, b
+	}
+	if offset, ok, err := parseRelativeTime(raw); ok {
+		if err != nil {
+			return nil, err
+		}
+		lit.isTime, lit.relative, lit.offset = true, true, offset
+	} else if t, ok := parseAbsoluteTime(raw); ok {
+		lit.isTime, lit.t = true, t
+	}
+	return lit, nil
+}
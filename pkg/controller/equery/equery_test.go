@@ -0,0 +1,128 @@
+package equery_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/controller/equery"
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/logs"
+	"github.com/lf-edge/eve-api/go/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestMetricExpressions(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	mm := &metrics.ZMetricMsg{
+		DevID:       "a9ee33b7-a5f7-4a5b-b1c3-fce73fbabd6f",
+		AtTimeStamp: timestamppb.New(now.Add(-10 * time.Minute)),
+		MetricContent: &metrics.ZMetricMsg_Dm{Dm: &metrics.DeviceMetric{
+			Memory: &metrics.MemoryMetric{UsedMem: 900, UsedPercentage: 12.5},
+			Network: []*metrics.NetworkMetric{
+				{IName: "eth0", TxBytes: 100},
+				{IName: "eth1", TxBytes: 0},
+			},
+		}},
+	}
+
+	testMatrix := map[string]bool{
+		"":                        true,
+		"dm.memory.usedMem > 800": true,
+		"dm.memory.usedMem > 800 && dm.memory.usedMem <= 850": false,
+		"dm.memory.usedMem < 800 || devID ~ '^a9ee'":          true,
+		"NOT dm.memory.usedMem == 900":                        false,
+		"dm.memory.usedMem != 1":                              true,
+		"dm.memory.usedPercentage >= 12.5":                    true,
+		"dm.network.iName == eth1":                            true,
+		"dm.network.iName == eth2":                            false,
+		"any(dm.network, iName == eth0 && txBytes > 0)":       true,
+		"any(dm.network, iName == eth1 && txBytes > 0)":       false,
+		"all(dm.network, txBytes > 0)":                        false,
+		"all(dm.network, iName ~ \"^eth\")":                   true,
+		"atTimeStamp >= now-1h && atTimeStamp < now":          true,
+		"atTimeStamp > now-5m":                                false,
+		"atTimeStamp > 2020-01-01":                            true,
+		"atTimeStamp > 1600000000":                            false,
+		"atTimeStamp > now-xyz":                               false,
+		"dm.memory":                                           true,
+		"dm.cpu":                                              false,
+		"(dm.memory.usedMem > 1000 or dm.memory.usedMem < 1000) and not dm.cpu": true,
+	}
+	for expr, expected := range testMatrix {
+		q, err := equery.Parse(expr)
+		if expr == "atTimeStamp > now-xyz" {
+			require.Error(t, err, expr)
+			continue
+		}
+		require.NoError(t, err, expr)
+		assert.Equal(t, expected, q.Match(mm), expr)
+	}
+}
+
+func TestRelativeTimeResolvedOnMatch(t *testing.T) {
+	t.Parallel()
+
+	mm := &metrics.ZMetricMsg{AtTimeStamp: timestamppb.New(time.Now().Add(200 * time.Millisecond))}
+	query := map[string]string{equery.ExprKey: "atTimeStamp < now"}
+	assert.False(t, equery.Match(mm, query))
+	time.Sleep(300 * time.Millisecond)
+	// the same (cached) query is evaluated against the current time
+	assert.True(t, equery.Match(mm, query))
+}
+
+func TestLegacyQuery(t *testing.T) {
+	t.Parallel()
+
+	im := &info.ZInfoMsg{
+		DevId: "a9ee33b7",
+		InfoContent: &info.ZInfoMsg_Dinfo{Dinfo: &info.ZInfoDevice{
+			State: info.ZDeviceState_ZDEVICE_STATE_ONLINE,
+		}},
+	}
+	assert.True(t, equery.Match(im, map[string]string{"InfoContent.dinfo.state": "ONLINE"}))
+	assert.True(t, equery.Match(im, map[string]string{"devId": "a9ee", equery.ExprKey: "dinfo.state == ZDEVICE_STATE_ONLINE"}))
+	assert.True(t, equery.Match(im, map[string]string{equery.ExprKey: "dinfo.state == 1 && !(dinfo.state > 1)"}))
+
+	le := &elog.FullLogEntry{LogEntry: logs.LogEntry{Source: "zedagent", Severity: "error", Msgid: 3}}
+	assert.True(t, equery.Match(le, map[string]string{"source": "zed"}))
+	assert.True(t, equery.Match(le, map[string]string{equery.ExprKey: "severity ~ 'err|warn' && msgid >= 3"}))
+
+	_, err := equery.FromArgs([]string{"source:zed"}, "msgid >")
+	assert.Error(t, err)
+	_, err = equery.FromArgs([]string{"source"}, "")
+	assert.Error(t, err)
+	q, err := equery.FromArgs([]string{"content:a:b"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, "a:b", q["content"])
+
+	// invalid expression must be reported instead of matching nothing
+	err = elog.LogLast(loaders.NewFileLoader(types.DirGetters{}), map[string]string{equery.ExprKey: "msgid >"},
+		func(*elog.FullLogEntry) bool { return true })
+	assert.ErrorContains(t, err, "invalid query")
+}
+
+func TestTimeRange(t *testing.T) {
+	t.Parallel()
+
+	q := map[string]string{"devId": "a9ee"}
+	require.NoError(t, equery.AddTimeRange(q, "2021-05-17T14:49:46Z", "2h"))
+	timeRange := equery.TimeRange(q)
+	assert.Equal(t, time.Date(2021, 5, 17, 14, 49, 46, 0, time.UTC), timeRange.Since.UTC())
+	assert.WithinDuration(t, time.Now().Add(-2*time.Hour), timeRange.Until, time.Minute)
+	assert.True(t, equery.Match(&info.ZInfoMsg{DevId: "a9ee"}, q))
+
+	require.Error(t, equery.AddTimeRange(map[string]string{}, "now", "now-1h"))
+	require.Error(t, equery.AddTimeRange(map[string]string{}, "yesterday", ""))
+
+	clock, err := equery.ParseTime("02:10")
+	require.NoError(t, err)
+	assert.Equal(t, 2, clock.Hour())
+	assert.Equal(t, 10, clock.Minute())
+}
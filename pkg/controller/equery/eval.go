@@ -0,0 +1,373 @@
+package equery
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type node interface {
+	eval(v reflect.Value) bool
+}
+
+type andNode struct {
+	nodes []node
+}
+
+func (n *andNode) eval(v reflect.Value) bool {
+	for _, el := range n.nodes {
+		if !el.eval(v) {
+			return false
+		}
+	}
+	return true
+}
+
+type orNode struct {
+	nodes []node
+}
+
+func (n *orNode) eval(v reflect.Value) bool {
+	for _, el := range n.nodes {
+		if el.eval(v) {
+			return true
+		}
+	}
+	return false
+}
+
+type notNode struct {
+	node node
+}
+
+func (n *notNode) eval(v reflect.Value) bool {
+	return !n.node.eval(v)
+}
+
+// existsNode is true if the field is found and not zero
+type existsNode struct {
+	path []pathElement
+}
+
+func (n *existsNode) eval(v reflect.Value) (found bool) {
+	resolve(v, n.path, func(leaf reflect.Value) bool {
+		found = !indirect(leaf).IsZero()
+		return found
+	})
+	return
+}
+
+// quantNode evaluates sub-expression against every element of repeated field
+type quantNode struct {
+	all  bool
+	path []pathElement
+	node node
+}
+
+func (n *quantNode) eval(v reflect.Value) bool {
+	matched, total := 0, 0
+	resolve(v, n.path, func(leaf reflect.Value) bool {
+		for _, el := range elements(leaf) {
+			total++
+			if n.node.eval(el) {
+				matched++
+				if !n.all {
+					return true
+				}
+			} else if n.all {
+				return true
+			}
+		}
+		return false
+	})
+	if n.all {
+		return total > 0 && matched == total
+	}
+	return matched > 0
+}
+
+type cmpNode struct {
+	path []pathElement
+	op   operator
+	lit  *literal
+}
+
+func (n *cmpNode) eval(v reflect.Value) (matched bool) {
+	resolve(v, n.path, func(leaf reflect.Value) bool {
+		matched = n.match(leaf)
+		return matched
+	})
+	return
+}
+
+func (n *cmpNode) match(v reflect.Value) bool {
+	if n.op == opMatch && n.lit.re.MatchString(stringOf(v)) {
+		return true
+	}
+	iv := indirect(v)
+	if !iv.IsValid() {
+		return false
+	}
+	if isRepeated(iv) {
+		for i := 0; i < iv.Len(); i++ {
+			if n.match(iv.Index(i)) {
+				return true
+			}
+		}
+		return false
+	}
+	if n.op == opMatch {
+		return false
+	}
+	c, ok := compare(iv, n.lit)
+	if !ok {
+		return false
+	}
+	switch n.op {
+	case opEq:
+		return c == 0
+	case opLt:
+		return c < 0
+	case opLe:
+		return c <= 0
+	case opGt:
+		return c > 0
+	case opGe:
+		return c >= 0
+	}
+	return false
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	stringerType  = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	timestampName = "google.golang.org/protobuf/types/known/timestamppb.Timestamp"
+)
+
+// compare returns -1, 0 or 1 comparing v with lit and false if they are not comparable
+func compare(v reflect.Value, lit *literal) (int, bool) {
+	if t, ok := timeOf(v); ok {
+		if !lit.isTime {
+			return 0, false
+		}
+		return t.Compare(lit.time()), true
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if !lit.isBool {
+			return 0, false
+		}
+		return boolToInt(v.Bool()) - boolToInt(lit.b), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if lit.isInt {
+			return cmpOrdered(v.Int(), lit.i), true
+		}
+		if lit.isFloat {
+			return cmpOrdered(float64(v.Int()), lit.f), true
+		}
+		// enums are compared by their names
+		if v.Type().Implements(stringerType) {
+			return strings.Compare(stringOf(v), lit.raw), true
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if lit.isUint {
+			return cmpOrdered(v.Uint(), lit.u), true
+		}
+		if lit.isFloat {
+			return cmpOrdered(float64(v.Uint()), lit.f), true
+		}
+	case reflect.Float32, reflect.Float64:
+		if lit.isFloat {
+			return cmpOrdered(v.Float(), lit.f), true
+		}
+	case reflect.String:
+		return strings.Compare(v.String(), lit.raw), true
+	default:
+		return strings.Compare(stringOf(v), lit.raw), true
+	}
+	return 0, false
+}
+
+func cmpOrdered[T int64 | uint64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func timeOf(v reflect.Value) (time.Time, bool) {
+	if v.Type() == timeType {
+		return v.Interface().(time.Time), true
+	}
+	if v.Kind() == reflect.Struct && v.Type().PkgPath()+"."+v.Type().Name() == timestampName {
+		return time.Unix(v.FieldByName("Seconds").Int(), v.FieldByName("Nanos").Int()), true
+	}
+	return time.Time{}, false
+}
+
+func stringOf(v reflect.Value) string {
+	if !v.IsValid() || !v.CanInterface() {
+		return ""
+	}
+	if b, ok := v.Interface().([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+type pathElement struct {
+	name  string
+	index int
+}
+
+const (
+	noIndex  = -1
+	anyIndex = -2
+)
+
+// parsePath splits path to elements, supporting "field[]" and "field[N]" forms
+func parsePath(path string) []pathElement {
+	var result []pathElement
+	for _, part := range strings.Split(strings.Trim(path, "."), ".") {
+		if part == "" {
+			continue
+		}
+		el := pathElement{name: part, index: noIndex}
+		if start := strings.Index(part, "["); start > 0 && strings.HasSuffix(part, "]") {
+			el.name = part[:start]
+			el.index = anyIndex
+			if i, err := strconv.Atoi(part[start+1 : len(part)-1]); err == nil {
+				el.index = i
+			}
+		}
+		result = append(result, el)
+	}
+	return result
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func isRepeated(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice:
+		return v.Type().Elem().Kind() != reflect.Uint8
+	case reflect.Array:
+		return true
+	}
+	return false
+}
+
+func elements(v reflect.Value) []reflect.Value {
+	iv := indirect(v)
+	if !iv.IsValid() {
+		return nil
+	}
+	if !isRepeated(iv) {
+		return []reflect.Value{v}
+	}
+	result := make([]reflect.Value, 0, iv.Len())
+	for i := 0; i < iv.Len(); i++ {
+		result = append(result, iv.Index(i))
+	}
+	return result
+}
+
+// resolve calls fn for every value found by path, expanding repeated fields
+// on the way; fn returns true to stop the search
+func resolve(v reflect.Value, path []pathElement, fn func(reflect.Value) bool) bool {
+	if len(path) == 0 {
+		return fn(v)
+	}
+	v = indirect(v)
+	if !v.IsValid() {
+		return false
+	}
+	if isRepeated(v) {
+		for i := 0; i < v.Len(); i++ {
+			if resolve(v.Index(i), path, fn) {
+				return true
+			}
+		}
+		return false
+	}
+	var next reflect.Value
+	switch v.Kind() {
+	case reflect.Struct:
+		next = field(v, path[0].name)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return false
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			if strings.EqualFold(iter.Key().String(), path[0].name) {
+				next = iter.Value()
+				break
+			}
+		}
+	}
+	if !next.IsValid() {
+		return false
+	}
+	if path[0].index >= 0 {
+		next = indirect(next)
+		if !isRepeated(next) || path[0].index >= next.Len() {
+			return false
+		}
+		next = next.Index(path[0].index)
+	}
+	return resolve(next, path[1:], fn)
+}
+
+// field returns exported field of struct by case-insensitive name,
+// looking into embedded structs and oneof wrappers
+func field(v reflect.Value, name string) reflect.Value {
+	var found *reflect.StructField
+	for _, f := range reflect.VisibleFields(v.Type()) {
+		if !f.IsExported() || !strings.EqualFold(f.Name, name) {
+			continue
+		}
+		if found == nil || len(f.Index) < len(found.Index) {
+			f := f
+			found = &f
+		}
+	}
+	if found != nil {
+		fv, err := v.FieldByIndexErr(found.Index)
+		if err != nil {
+			return reflect.Value{}
+		}
+		return fv
+	}
+	for i := 0; i < v.NumField(); i++ {
+		if !v.Type().Field(i).IsExported() || v.Field(i).Kind() != reflect.Interface {
+			continue
+		}
+		if inner := indirect(v.Field(i)); inner.IsValid() && inner.Kind() == reflect.Struct {
+			if fv := field(inner, name); fv.IsValid() {
+				return fv
+			}
+		}
+	}
+	return reflect.Value{}
+}
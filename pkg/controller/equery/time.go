@@ -0,0 +1,137 @@
+package equery
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+)
+
+const (
+	// SinceKey is the key in the query map which holds the lower bound of timestamps of objects
+	SinceKey = "@since"
+	// UntilKey is the key in the query map which holds the upper bound of timestamps of objects
+	UntilKey = "@until"
+)
+
+var relativeTime = regexp.MustCompile(`^now(([+-])(.+))?$`)
+
+// layouts of absolute time without time zone, local time zone is used for them
+var localLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// layouts of time of current day
+var clockLayouts = []string{
+	"15:04:05",
+	"15:04",
+}
+
+// ParseTime parses absolute or relative time. Supported forms are:
+// RFC 3339 ("2021-05-17T14:49:46Z"), local date and time ("2021-05-17 14:49"),
+// time of the current day ("02:10"), unix seconds, "now" with optional
+// offset ("now-15m") and duration meaning time before now ("15m").
+func ParseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	now := time.Now()
+	if offset, ok, err := parseRelativeTime(s); ok {
+		if err != nil {
+			return time.Time{}, err
+		}
+		return now.Add(offset), nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		sec := int64(f)
+		return time.Unix(sec, int64((f-float64(sec))*float64(time.Second))), nil
+	}
+	if t, ok := parseAbsoluteTime(s); ok {
+		return t, nil
+	}
+	for _, layout := range clockLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			year, month, day := now.Date()
+			return time.Date(year, month, day, t.Hour(), t.Minute(), t.Second(), 0, time.Local), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse time %q", s)
+}
+
+// parseRelativeTime parses "now" with optional offset ("now-15m")
+// and returns the offset; ok is false if s is not in this form
+func parseRelativeTime(s string) (offset time.Duration, ok bool, err error) {
+	m := relativeTime.FindStringSubmatch(strings.ToLower(s))
+	if m == nil {
+		return 0, false, nil
+	}
+	if m[1] == "" {
+		return 0, true, nil
+	}
+	d, err := time.ParseDuration(m[3])
+	if err != nil {
+		return 0, true, err
+	}
+	if m[2] == "-" {
+		d = -d
+	}
+	return d, true, nil
+}
+
+// parseAbsoluteTime parses RFC 3339 or local date and time
+func parseAbsoluteTime(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, true
+	}
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// AddTimeRange resolves since and until with ParseTime and stores them in query,
+// empty values are ignored
+func AddTimeRange(query map[string]string, since, until string) error {
+	timeRange := TimeRange(query)
+	if since != "" {
+		t, err := ParseTime(since)
+		if err != nil {
+			return fmt.Errorf("since: %w", err)
+		}
+		timeRange.Since = t
+		query[SinceKey] = t.Format(time.RFC3339Nano)
+	}
+	if until != "" {
+		t, err := ParseTime(until)
+		if err != nil {
+			return fmt.Errorf("until: %w", err)
+		}
+		timeRange.Until = t
+		query[UntilKey] = t.Format(time.RFC3339Nano)
+	}
+	if !timeRange.Since.IsZero() && !timeRange.Until.IsZero() && timeRange.Until.Before(timeRange.Since) {
+		return fmt.Errorf("until (%s) is before since (%s)", timeRange.Until, timeRange.Since)
+	}
+	return nil
+}
+
+// TimeRange returns range of timestamps stored in query with AddTimeRange
+func TimeRange(query map[string]string) types.TimeRange {
+	var timeRange types.TimeRange
+	if since, ok := query[SinceKey]; ok {
+		timeRange.Since, _ = time.Parse(time.RFC3339Nano, since)
+	}
+	if until, ok := query[UntilKey]; ok {
+		timeRange.Until, _ = time.Parse(time.RFC3339Nano, until)
+	}
+	return timeRange
+}
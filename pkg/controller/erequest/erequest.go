@@ -6,16 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"reflect"
-	"regexp"
-	"strings"
+	"time"
 
+	"github.com/lf-edge/eden/pkg/controller/equery"
 	"github.com/lf-edge/eden/pkg/controller/loaders"
 	"github.com/lf-edge/eden/pkg/controller/types"
-	"github.com/lf-edge/eden/pkg/utils"
 	log "github.com/sirupsen/logrus"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
 )
 
 // RequestFormat the format to print output Requests
@@ -35,33 +31,10 @@ func ParseRequestItem(data []byte) (logItem *types.APIRequest, err error) {
 	return &le, err
 }
 
-// RequestItemFind find APIRequest records by reqexps in 'query' corresponded to APIRequest structure.
+// RequestItemFind find APIRequest records corresponded to APIRequest structure by 'query'
+// in the format supported by equery.Compile (field:regexp pairs and expression).
 func RequestItemFind(le *types.APIRequest, query map[string]string) bool {
-	matched := true
-	for k, v := range query {
-		// Uppercase of filed's name first letter
-		var n []string
-		caser := cases.Title(language.English, cases.NoLower)
-		for _, pathElement := range strings.Split(k, ".") {
-			n = append(n, caser.String(pathElement))
-		}
-		var clb = func(inp reflect.Value) {
-			f := fmt.Sprint(inp)
-			newMatched, err := regexp.Match(v, []byte(f))
-			if err != nil {
-				log.Debug(err)
-			}
-			if !matched && newMatched {
-				matched = newMatched
-			}
-		}
-		matched = false
-		utils.LookupWithCallback(reflect.Indirect(reflect.ValueOf(le)).Interface(), strings.Join(n, "."), clb)
-		if !matched {
-			return matched
-		}
-	}
-	return matched
+	return equery.Match(le, query)
 }
 
 // RequestPrn print APIRequest data
@@ -102,8 +75,22 @@ func requestProcess(query map[string]string, handler HandlerFunc) loaders.Proces
 	}
 }
 
+// RequestWatch processes Request files in the 'filepath' directory
+// according to the 'query' reqexps from the oldest to the newest one and new files until timeout
+func RequestWatch(loader loaders.Loader, query map[string]string, handler HandlerFunc, timeoutSeconds time.Duration) error {
+	if err := equery.Validate(query); err != nil {
+		return err
+	}
+	loader.SetTimeRange(equery.TimeRange(query))
+	return loader.ProcessStream(requestProcess(query, handler), types.RequestType, timeoutSeconds)
+}
+
 // RequestLast function process Request files in the 'filepath' directory
 // according to the 'query' reqexps and return last founded item
 func RequestLast(loader loaders.Loader, query map[string]string, handler HandlerFunc) error {
+	if err := equery.Validate(query); err != nil {
+		return err
+	}
+	loader.SetTimeRange(equery.TimeRange(query))
 	return loader.ProcessExisting(requestProcess(query, handler), types.RequestType)
 }
@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"github.com/lf-edge/eden/pkg/controller/adam"
+	"github.com/lf-edge/eden/pkg/controller/embedded"
+	"github.com/lf-edge/eden/pkg/controller/rest"
 	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/models"
 	"github.com/lf-edge/eden/pkg/utils"
@@ -22,13 +24,37 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+// NewControllerByType returns Controller implementation for controllerType
+// empty controllerType means adam
+func NewControllerByType(controllerType string) (Controller, error) {
+	switch controllerType {
+	case "", "adam":
+		return &adam.Ctx{}, nil
+	case "rest":
+		return &rest.Ctx{}, nil
+	case "embedded":
+		return &embedded.Ctx{}, nil
+	default:
+		return nil, fmt.Errorf("not implemented controller type %s", controllerType)
+	}
+}
+
 // CloudPrepare is for init controller connection and obtain device list
 func CloudPrepare() (Cloud, error) {
+	return CloudPrepareWithType("adam")
+}
+
+// CloudPrepareWithType is for init connection with controller of controllerType and obtain device list
+func CloudPrepareWithType(controllerType string) (Cloud, error) {
 	vars, err := utils.InitVars()
 	if err != nil {
 		return nil, fmt.Errorf("utils.InitVars: %s", err)
 	}
-	ctx := &CloudCtx{vars: vars, Controller: &adam.Ctx{}}
+	ctrl, err := NewControllerByType(controllerType)
+	if err != nil {
+		return nil, err
+	}
+	ctx := &CloudCtx{vars: vars, Controller: ctrl}
 	if err := ctx.InitWithVars(vars); err != nil {
 		return nil, fmt.Errorf("cloud.InitWithVars: %s", err)
 	}
@@ -0,0 +1,200 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ConfigHistoryEntry describes config pushed to device
+type ConfigHistoryEntry struct {
+	Version string          `json:"version"`
+	Time    time.Time       `json:"time"`
+	Command string          `json:"command"`
+	Config  json.RawMessage `json:"config"`
+}
+
+// EdgeDevConfig returns config stored in entry
+func (entry *ConfigHistoryEntry) EdgeDevConfig() (*config.EdgeDevConfig, error) {
+	var devConfig config.EdgeDevConfig
+	if err := protojson.Unmarshal(entry.Config, &devConfig); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal config: %w", err)
+	}
+	return &devConfig, nil
+}
+
+// subcommandRe matches names of subcommands, arguments after them are not stored
+// in history as they may contain secrets
+var subcommandRe = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// historyCommand returns command with subcommands from args without flags and arguments
+func historyCommand(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	command := []string{filepath.Base(args[0])}
+	for _, arg := range args[1:] {
+		if !subcommandRe.MatchString(arg) {
+			break
+		}
+		command = append(command, arg)
+	}
+	return strings.Join(command, " ")
+}
+
+func configHistoryDir(devUUID uuid.UUID) (string, error) {
+	edenDir, err := utils.DefaultEdenDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(edenDir, defaults.DefaultConfigHistoryDir, devUUID.String()), nil
+}
+
+// NewConfigHistoryEntry returns entry of history for config in proto format
+func NewConfigHistoryEntry(devConfig []byte) (*ConfigHistoryEntry, error) {
+	var deviceConfig config.EdgeDevConfig
+	if err := proto.Unmarshal(devConfig, &deviceConfig); err != nil {
+		return nil, fmt.Errorf("unmarshal error: %w", err)
+	}
+	data, err := protojson.MarshalOptions{Multiline: true, Indent: "    "}.Marshal(&deviceConfig)
+	if err != nil {
+		return nil, fmt.Errorf("marshal error: %w", err)
+	}
+	return &ConfigHistoryEntry{
+		Version: deviceConfig.GetId().GetVersion(),
+		Time:    time.Now().UTC(),
+		Command: historyCommand(os.Args),
+		Config:  data,
+	}, nil
+}
+
+// SaveConfigHistory stores config in proto format pushed to device into local history
+// together with eden command caused it (without flags and arguments)
+func SaveConfigHistory(devUUID uuid.UUID, devConfig []byte) error {
+	entry, err := NewConfigHistoryEntry(devConfig)
+	if err != nil {
+		return err
+	}
+	dir, err := configHistoryDir(devUUID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	entryData, err := json.MarshalIndent(entry, "", "    ")
+	if err != nil {
+		return err
+	}
+	// use timestamp to keep order of entries even if version repeats
+	fileName := filepath.Join(dir, fmt.Sprintf("%d-%s.json", entry.Time.UnixNano(), entry.Version))
+	return os.WriteFile(fileName, entryData, 0644)
+}
+
+// ConfigHistory returns history of configs pushed to device from the oldest to the newest
+func ConfigHistory(devUUID uuid.UUID) ([]*ConfigHistoryEntry, error) {
+	dir, err := configHistoryDir(devUUID)
+	if err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var result []*ConfigHistoryEntry
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var entry ConfigHistoryEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("cannot parse %s: %w", file.Name(), err)
+		}
+		result = append(result, &entry)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result, nil
+}
+
+// ConfigHistoryVersion returns the last entry with version from history of configs of device
+func ConfigHistoryVersion(devUUID uuid.UUID, version string) (*ConfigHistoryEntry, error) {
+	history, err := ConfigHistory(devUUID)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Version == version {
+			return history[i], nil
+		}
+	}
+	return nil, fmt.Errorf("version %s not found in config history of %s", version, devUUID)
+}
+
+// ConfigRestore replaces config of dev in ctrl with devConfig from history,
+// config version of dev is kept to push restored config with the next version
+func ConfigRestore(ctrl Cloud, dev *device.Ctx, devConfig *config.EdgeDevConfig) (*device.Ctx, error) {
+	// objects of current config shadow ones with the same UUIDs from history
+	// and ConfigParse merges config items and keeps BaseOS if not set in config
+	for _, id := range dev.GetApplicationInstances() {
+		_ = ctrl.RemoveApplicationInstanceConfig(id)
+	}
+	for _, id := range dev.GetVolumes() {
+		_ = ctrl.RemoveVolume(id)
+	}
+	for _, id := range dev.GetContentTrees() {
+		_ = ctrl.RemoveContentTree(id)
+	}
+	for _, id := range dev.GetNetworkInstances() {
+		_ = ctrl.RemoveNetworkInstanceConfig(id)
+	}
+	for _, id := range dev.GetNetworks() {
+		_ = ctrl.RemoveNetworkConfig(id)
+	}
+	for _, id := range dev.GetBaseOSConfigs() {
+		_ = ctrl.RemoveBaseOsConfig(id)
+	}
+	var dataStores []string
+	for _, el := range ctrl.ListDataStore() {
+		dataStores = append(dataStores, el.Id)
+	}
+	for _, id := range dataStores {
+		_ = ctrl.RemoveDataStore(id)
+	}
+	for key := range dev.GetConfigItems() {
+		dev.DelConfigItem(key)
+	}
+	dev.SetBaseOSContentTree("")
+	dev.SetBaseOSActivate(false)
+	dev.SetBaseOSVersion("")
+	dev.SetBaseOSRetryCounter(0)
+	currentVersion := dev.GetConfigVersion()
+	dev, err := ctrl.ConfigParse(devConfig)
+	if err != nil {
+		return nil, fmt.Errorf("ConfigParse: %w", err)
+	}
+	// keep versions growing
+	dev.SetConfigVersion(currentVersion)
+	return dev, nil
+}
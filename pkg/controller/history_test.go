@@ -0,0 +1,104 @@
+package controller_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestConfigHistory(t *testing.T) {
+	devUUID := uuid.Must(uuid.NewV4())
+	edenDir, err := utils.DefaultEdenDir()
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.RemoveAll(filepath.Join(edenDir, defaults.DefaultConfigHistoryDir, devUUID.String()))
+	})
+	args := os.Args
+	t.Cleanup(func() { os.Args = args })
+	os.Args = []string{"/usr/bin/eden", "controller", "edge-node", "update", "--config", "token=secret"}
+
+	history, err := controller.ConfigHistory(devUUID)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	for i, version := range []string{"1", "2", "1"} {
+		data, err := proto.Marshal(&config.EdgeDevConfig{
+			Id:          &config.UUIDandVersion{Uuid: devUUID.String(), Version: version},
+			ConfigItems: []*config.ConfigItem{{Key: "order", Value: string(rune('a' + i))}},
+		})
+		require.NoError(t, err)
+		require.NoError(t, controller.SaveConfigHistory(devUUID, data))
+	}
+
+	history, err = controller.ConfigHistory(devUUID)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	for i, version := range []string{"1", "2", "1"} {
+		assert.Equal(t, version, history[i].Version)
+		assert.Equal(t, "eden controller edge-node update", history[i].Command)
+	}
+
+	entry, err := controller.ConfigHistoryVersion(devUUID, "1")
+	require.NoError(t, err)
+	devConfig, err := entry.EdgeDevConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "c", devConfig.GetConfigItems()[0].GetValue())
+
+	_, err = controller.ConfigHistoryVersion(devUUID, "3")
+	assert.Error(t, err)
+}
+
+func TestConfigRestore(t *testing.T) {
+	devUUID := uuid.Must(uuid.NewV4())
+	contentTree := func(url string) *config.ContentTree {
+		return &config.ContentTree{Uuid: "ct", DsId: "ds", URL: url}
+	}
+	volume := &config.Volume{Uuid: "vol", Origin: &config.VolumeContentOrigin{DownloadContentTreeID: "ct"}}
+	oldConfig := &config.EdgeDevConfig{
+		Id:          &config.UUIDandVersion{Uuid: devUUID.String(), Version: "1"},
+		ConfigItems: []*config.ConfigItem{{Key: "old", Value: "1"}},
+		Datastores:  []*config.DatastoreConfig{{Id: "ds", Fqdn: "http://old"}},
+		ContentInfo: []*config.ContentTree{contentTree("old")},
+		Volumes:     []*config.Volume{volume},
+		Networks:    []*config.NetworkConfig{{Id: "net"}},
+	}
+	currentConfig := &config.EdgeDevConfig{
+		Id:          &config.UUIDandVersion{Uuid: devUUID.String(), Version: "5"},
+		ConfigItems: []*config.ConfigItem{{Key: "current", Value: "2"}},
+		Datastores:  []*config.DatastoreConfig{{Id: "ds", Fqdn: "http://current"}},
+		ContentInfo: []*config.ContentTree{contentTree("current")},
+		Volumes:     []*config.Volume{volume},
+		Baseos:      &config.BaseOS{ContentTreeUuid: "ct", BaseOsVersion: "current", Activate: true},
+	}
+
+	ctrl := &controller.CloudCtx{}
+	ctrl.SetVars(&utils.ConfigVars{})
+	dev, err := ctrl.ConfigParse(currentConfig)
+	require.NoError(t, err)
+	dev, err = controller.ConfigRestore(ctrl, dev, oldConfig)
+	require.NoError(t, err)
+	assert.Equal(t, 5, dev.GetConfigVersion())
+
+	data, err := ctrl.GetConfigBytes(dev, false)
+	require.NoError(t, err)
+	var restored config.EdgeDevConfig
+	require.NoError(t, proto.Unmarshal(data, &restored))
+	assert.Nil(t, restored.GetBaseos())
+	require.Len(t, restored.GetConfigItems(), 1)
+	assert.Equal(t, "old", restored.GetConfigItems()[0].GetKey())
+	require.Len(t, restored.GetContentInfo(), 1)
+	assert.Equal(t, "old", restored.GetContentInfo()[0].GetURL())
+	require.Len(t, restored.GetDatastores(), 1)
+	assert.Equal(t, "http://old", restored.GetDatastores()[0].GetFqdn())
+	require.Len(t, restored.GetNetworks(), 1)
+	assert.Equal(t, "net", restored.GetNetworks()[0].GetId())
+}
@@ -1,16 +1,22 @@
 package loaders
 
 import (
+	"errors"
+	"time"
+
 	"github.com/lf-edge/eden/pkg/controller/cachers"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	uuid "github.com/satori/go.uuid"
-	"time"
 )
 
+// ErrTimeout is returned by ProcessStream when timeout expires
+var ErrTimeout = errors.New("timeout")
+
 //Loader interface fo controller
 type Loader interface {
 	SetAppUUID(devUUID uuid.UUID)
 	SetUUID(devUUID uuid.UUID)
+	SetTimeRange(timeRange types.TimeRange)
 	ProcessStream(process ProcessFunction, typeToProcess types.LoaderObjectType, timeoutSeconds time.Duration) error
 	ProcessExisting(process ProcessFunction, typeToProcess types.LoaderObjectType) error
 	SetRemoteCache(cache cachers.CacheProcessor)
@@ -19,3 +25,9 @@ type Loader interface {
 
 //ProcessFunction is prototype of processing function
 type ProcessFunction func(bytes []byte) (bool, error)
+
+// AppsLister is implemented by loaders which can list applications
+// of device with stored logs, including applications removed from config
+type AppsLister interface {
+	ListApps() ([]uuid.UUID, error)
+}
@@ -0,0 +1,240 @@
+package loaders
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	// BundleVersion is the version of format of telemetry bundle
+	BundleVersion = 1
+	// BundleIndexName is the name of index file inside of telemetry bundle
+	BundleIndexName = "index.json"
+)
+
+var bundleTypeNames = map[types.LoaderObjectType]string{
+	types.LogsType:    "logs",
+	types.InfoType:    "info",
+	types.MetricsType: "metrics",
+	types.RequestType: "requests",
+	types.AppsType:    "apps",
+	types.FlowLogType: "flowlog",
+}
+
+// BundleItem describes object stored inside of telemetry bundle
+type BundleItem struct {
+	Type    string    `json:"type"`
+	AppUUID string    `json:"appUUID,omitempty"`
+	Name    string    `json:"name"`
+	Time    time.Time `json:"time"`
+	Size    int       `json:"size"`
+}
+
+// BundleIndex describes content of telemetry bundle,
+// items are sorted by their timestamps
+type BundleIndex struct {
+	Version int          `json:"version"`
+	DevUUID string       `json:"devUUID"`
+	Created time.Time    `json:"created"`
+	Items   []BundleItem `json:"items"`
+}
+
+// Bundle is telemetry bundle loaded into memory
+type Bundle struct {
+	Index BundleIndex
+	data  map[string][]byte
+}
+
+// Data returns content of item
+func (bundle *Bundle) Data(item BundleItem) []byte {
+	return bundle.data[item.Name]
+}
+
+// ReadBundle reads gzipped tar with telemetry bundle from r
+func ReadBundle(r io.Reader) (*Bundle, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	bundle := &Bundle{data: make(map[string][]byte)}
+	indexFound := false
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Name == BundleIndexName {
+			if err := json.Unmarshal(data, &bundle.Index); err != nil {
+				return nil, fmt.Errorf("cannot parse index: %w", err)
+			}
+			indexFound = true
+			continue
+		}
+		bundle.data[hdr.Name] = data
+	}
+	if !indexFound {
+		return nil, fmt.Errorf("no %s in bundle", BundleIndexName)
+	}
+	if bundle.Index.Version > BundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", bundle.Index.Version)
+	}
+	for _, item := range bundle.Index.Items {
+		if _, ok := bundle.data[item.Name]; !ok {
+			return nil, fmt.Errorf("no %s in bundle", item.Name)
+		}
+	}
+	return bundle, nil
+}
+
+// OpenBundle reads telemetry bundle from file
+func OpenBundle(fileName string) (*Bundle, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadBundle(f)
+}
+
+// BundleWriter writes objects seen by loaders into telemetry bundle
+type BundleWriter struct {
+	mu     sync.Mutex
+	gz     *gzip.Writer
+	tw     *tar.Writer
+	index  BundleIndex
+	seen   map[[sha256.Size]byte]struct{}
+	closed bool
+}
+
+// NewBundleWriter returns BundleWriter which writes gzipped tar with objects of device into w
+func NewBundleWriter(w io.Writer, devUUID uuid.UUID) *BundleWriter {
+	gz := gzip.NewWriter(w)
+	return &BundleWriter{
+		gz: gz,
+		tw: tar.NewWriter(gz),
+		index: BundleIndex{
+			Version: BundleVersion,
+			DevUUID: devUUID.String(),
+			Created: time.Now().UTC(),
+		},
+		seen: make(map[[sha256.Size]byte]struct{}),
+	}
+}
+
+// Add stores data of typeToProcess into bundle, objects already stored are skipped
+func (bw *BundleWriter) Add(typeToProcess types.LoaderObjectType, appUUID uuid.UUID, data []byte) error {
+	typeName, ok := bundleTypeNames[typeToProcess]
+	if !ok {
+		return fmt.Errorf("not implemented type %d", typeToProcess)
+	}
+	item := BundleItem{Type: typeName, Size: len(data)}
+	if typeToProcess == types.AppsType {
+		item.AppUUID = appUUID.String()
+	}
+	item.Time, _ = itemTime(typeToProcess, data)
+	key := sha256.Sum256(append([]byte(item.Type+item.AppUUID), data...))
+
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	if bw.closed {
+		return fmt.Errorf("bundle is closed")
+	}
+	if _, ok := bw.seen[key]; ok {
+		return nil
+	}
+	item.Name = path.Join(item.Type, item.AppUUID, fmt.Sprintf("%08d", len(bw.index.Items)))
+	if err := bw.tw.WriteHeader(&tar.Header{
+		Name:    item.Name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: item.Time,
+	}); err != nil {
+		return err
+	}
+	if _, err := bw.tw.Write(data); err != nil {
+		return err
+	}
+	bw.seen[key] = struct{}{}
+	bw.index.Items = append(bw.index.Items, item)
+	return nil
+}
+
+// Record stores objects of typeToProcess which loader processes,
+// existing objects are stored if timeout is zero, otherwise new objects
+// are stored until timeout
+func (bw *BundleWriter) Record(loader Loader, appUUID uuid.UUID, typeToProcess types.LoaderObjectType, timeout time.Duration) error {
+	process := func(data []byte) (bool, error) {
+		return true, bw.Add(typeToProcess, appUUID, data)
+	}
+	if timeout == 0 {
+		return loader.ProcessExisting(process, typeToProcess)
+	}
+	if err := loader.ProcessStream(process, typeToProcess, timeout); err != nil && !errors.Is(err, ErrTimeout) {
+		return err
+	}
+	return nil
+}
+
+// Len returns count of objects stored in bundle
+func (bw *BundleWriter) Len() int {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	return len(bw.index.Items)
+}
+
+// Close writes index of bundle and flushes it, it does not close underlying writer
+func (bw *BundleWriter) Close() error {
+	bw.mu.Lock()
+	defer bw.mu.Unlock()
+	if bw.closed {
+		return nil
+	}
+	bw.closed = true
+	sort.SliceStable(bw.index.Items, func(i, j int) bool {
+		return bw.index.Items[i].Time.Before(bw.index.Items[j].Time)
+	})
+	data, err := json.MarshalIndent(bw.index, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := bw.tw.WriteHeader(&tar.Header{
+		Name:    BundleIndexName,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: bw.index.Created,
+	}); err != nil {
+		return err
+	}
+	if _, err := bw.tw.Write(data); err != nil {
+		return err
+	}
+	if err := bw.tw.Close(); err != nil {
+		return err
+	}
+	return bw.gz.Close()
+}
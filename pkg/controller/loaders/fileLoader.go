@@ -1,6 +1,7 @@
 package loaders
 
 import (
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
@@ -17,10 +18,11 @@ import (
 
 // FileLoader implements loader from file backend of controller
 type FileLoader struct {
-	appUUID uuid.UUID
-	devUUID uuid.UUID
-	getters types.DirGetters
-	cache   cachers.CacheProcessor
+	appUUID   uuid.UUID
+	devUUID   uuid.UUID
+	getters   types.DirGetters
+	cache     cachers.CacheProcessor
+	timeRange types.TimeRange
 }
 
 // NewFileLoader return loader from files
@@ -37,10 +39,11 @@ func (loader *FileLoader) SetRemoteCache(cache cachers.CacheProcessor) {
 // Clone create copy
 func (loader *FileLoader) Clone() Loader {
 	return &FileLoader{
-		getters: loader.getters,
-		devUUID: loader.devUUID,
-		appUUID: loader.appUUID,
-		cache:   loader.cache,
+		getters:   loader.getters,
+		devUUID:   loader.devUUID,
+		appUUID:   loader.appUUID,
+		cache:     loader.cache,
+		timeRange: loader.timeRange,
 	}
 }
 
@@ -73,6 +76,32 @@ func (loader *FileLoader) SetAppUUID(appUUID uuid.UUID) {
 	loader.appUUID = appUUID
 }
 
+// SetTimeRange set range of timestamps of objects to process
+func (loader *FileLoader) SetTimeRange(timeRange types.TimeRange) {
+	loader.timeRange = timeRange
+}
+
+// ListApps returns UUIDs of applications with directories of logs
+func (loader *FileLoader) ListApps() ([]uuid.UUID, error) {
+	entries, err := os.ReadDir(path.Dir(loader.getters.AppsGetter(loader.devUUID, uuid.Nil)))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var result []uuid.UUID
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if appUUID, err := uuid.FromString(entry.Name()); err == nil {
+			result = append(result, appUUID)
+		}
+	}
+	return result, nil
+}
+
 // ProcessExisting for observe existing files
 func (loader *FileLoader) ProcessExisting(process ProcessFunction, typeToProcess types.LoaderObjectType) error {
 	entries, err := os.ReadDir(loader.getFilePath(typeToProcess))
@@ -95,6 +124,10 @@ func (loader *FileLoader) ProcessExisting(process ProcessFunction, typeToProcess
 		if file.IsDir() {
 			continue
 		}
+		// skip files which cannot contain objects from the range without reading them
+		if receivedBefore(loader.timeRange, file.ModTime()) || receivedAfter(loader.timeRange, file.ModTime()) {
+			continue
+		}
 		fileFullPath := path.Join(loader.getFilePath(typeToProcess), file.Name())
 		log.Debugf("local controller parse %s", fileFullPath)
 		data, err := os.ReadFile(fileFullPath)
@@ -102,8 +135,11 @@ func (loader *FileLoader) ProcessExisting(process ProcessFunction, typeToProcess
 			log.Error("Can't open ", fileFullPath)
 			continue
 		}
+		if !inTimeRange(loader.timeRange, typeToProcess, data) {
+			continue
+		}
 		if loader.cache != nil {
-			if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, data); err != nil {
+			if err = loader.cache.CheckAndSave(loader.devUUID, loader.appUUID, typeToProcess, data); err != nil {
 				log.Errorf("error in cache: %s", err)
 			}
 		}
@@ -120,6 +156,10 @@ func (loader *FileLoader) ProcessExisting(process ProcessFunction, typeToProcess
 
 // ProcessStream for observe new files
 func (loader *FileLoader) ProcessStream(process ProcessFunction, typeToProcess types.LoaderObjectType, timeoutSeconds time.Duration) error {
+	if receivedAfter(loader.timeRange, time.Now()) {
+		// new objects cannot fit the range
+		return nil
+	}
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return err
@@ -129,7 +169,7 @@ func (loader *FileLoader) ProcessStream(process ProcessFunction, typeToProcess t
 
 	if timeoutSeconds != 0 {
 		time.AfterFunc(timeoutSeconds, func() {
-			done <- fmt.Errorf("timeout")
+			done <- ErrTimeout
 		})
 	}
 	go func() {
@@ -149,8 +189,11 @@ func (loader *FileLoader) ProcessStream(process ProcessFunction, typeToProcess t
 						continue
 					}
 					log.Debugf("local controller parse %s", event.Name)
+					if !inTimeRange(loader.timeRange, typeToProcess, data) {
+						continue
+					}
 					if loader.cache != nil {
-						if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, data); err != nil {
+						if err = loader.cache.CheckAndSave(loader.devUUID, loader.appUUID, typeToProcess, data); err != nil {
 							log.Errorf("error in cache: %s", err)
 						}
 					}
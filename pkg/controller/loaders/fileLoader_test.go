@@ -0,0 +1,75 @@
+package loaders_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eve-api/go/info"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestFileLoaderTimeRange(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	now := time.Now()
+	// created and received times of items
+	items := map[string][2]time.Time{
+		"old":      {now.Add(-3 * time.Hour), now.Add(-3 * time.Hour)},
+		"in-range": {now.Add(-90 * time.Minute), now.Add(-90 * time.Minute)},
+		"delayed":  {now.Add(-65 * time.Minute), now.Add(-55 * time.Minute)},
+		"late":     {now.Add(-30 * time.Minute), now.Add(-30 * time.Minute)},
+	}
+	for name, times := range items {
+		data, err := proto.Marshal(&info.ZInfoMsg{DevId: name, AtTimeStamp: timestamppb.New(times[0])})
+		require.NoError(t, err)
+		fileName := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(fileName, data, 0644))
+		require.NoError(t, os.Chtimes(fileName, times[1], times[1]))
+	}
+
+	loader := loaders.NewFileLoader(types.DirGetters{
+		InfoGetter: func(uuid.UUID) string { return dir },
+	})
+	loader.SetTimeRange(types.TimeRange{Since: now.Add(-2 * time.Hour), Until: now.Add(-time.Hour)})
+	var found []string
+	err := loader.ProcessExisting(func(data []byte) (bool, error) {
+		var im info.ZInfoMsg
+		require.NoError(t, proto.Unmarshal(data, &im))
+		found = append(found, im.DevId)
+		return true, nil
+	}, types.InfoType)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"in-range", "delayed"}, found)
+}
+
+func TestFileLoaderListApps(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	appUUID := uuid.Must(uuid.NewV4())
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "apps", appUUID.String()), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "apps", "not-uuid"), 0755))
+
+	loader := loaders.NewFileLoader(types.DirGetters{
+		AppsGetter: func(_ uuid.UUID, appUUID uuid.UUID) string { return filepath.Join(dir, "apps", appUUID.String()) },
+	})
+	apps, err := loader.ListApps()
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{appUUID}, apps)
+
+	empty := loaders.NewFileLoader(types.DirGetters{
+		AppsGetter: func(uuid.UUID, uuid.UUID) string { return filepath.Join(dir, "absent", "app") },
+	})
+	apps, err = empty.ListApps()
+	require.NoError(t, err)
+	assert.Empty(t, apps)
+}
@@ -26,6 +26,7 @@ type RedisLoader struct {
 	cache         cachers.CacheProcessor
 	devUUID       uuid.UUID
 	appUUID       uuid.UUID
+	timeRange     types.TimeRange
 }
 
 // NewRedisLoader return loader from redis
@@ -55,6 +56,7 @@ func (loader *RedisLoader) Clone() Loader {
 		cache:         loader.cache,
 		devUUID:       loader.devUUID,
 		appUUID:       loader.appUUID,
+		timeRange:     loader.timeRange,
 	}
 }
 
@@ -87,13 +89,32 @@ func (loader *RedisLoader) SetAppUUID(appUUID uuid.UUID) {
 	loader.appUUID = appUUID
 }
 
+// SetTimeRange set range of timestamps of objects to process
+func (loader *RedisLoader) SetTimeRange(timeRange types.TimeRange) {
+	loader.timeRange = timeRange
+}
+
+// streamRange returns bounds of IDs for XRANGE to get objects from timeRange,
+// IDs of stream entries are based on time of receiving of objects, so bounds
+// are extended with delay of delivery as in receivedBefore and receivedAfter
+func (loader *RedisLoader) streamRange() (start, end string) {
+	start, end = "-", "+"
+	if !loader.timeRange.Since.IsZero() {
+		start = fmt.Sprintf("%d-0", loader.timeRange.Since.Add(-defaults.DefaultDeliveryDelay).UnixMilli())
+	}
+	if !loader.timeRange.Until.IsZero() {
+		end = strconv.FormatInt(loader.timeRange.Until.Add(defaults.DefaultDeliveryDelay).UnixMilli(), 10)
+	}
+	return start, end
+}
+
 func (loader *RedisLoader) process(process ProcessFunction, typeToProcess types.LoaderObjectType, stream bool) (processed, found bool, err error) {
 	OrderStream := loader.getStream(typeToProcess)
 	log.Debugf("XRead from %s", OrderStream)
 	if !stream {
-		start := "-"
+		start, end := loader.streamRange()
 		for {
-			rr, err := loader.client.XRangeN(context.Background(), OrderStream, start, "+", 10).Result()
+			rr, err := loader.client.XRangeN(context.Background(), OrderStream, start, end, 10).Result()
 			if err != nil {
 				return false, false, fmt.Errorf("XRange error: %s", err)
 			}
@@ -109,12 +130,15 @@ func (loader *RedisLoader) process(process ProcessFunction, typeToProcess types.
 					continue
 				}
 				data := []byte(dataString)
+				if !inTimeRange(loader.timeRange, typeToProcess, data) {
+					continue
+				}
 				tocontinue, err := process(data)
 				if err != nil {
 					return false, false, fmt.Errorf("process: %s", err)
 				}
 				if loader.cache != nil {
-					if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, data); err != nil {
+					if err = loader.cache.CheckAndSave(loader.devUUID, loader.appUUID, typeToProcess, data); err != nil {
 						log.Errorf("error in cache: %s", err)
 					}
 				}
@@ -144,12 +168,15 @@ func (loader *RedisLoader) process(process ProcessFunction, typeToProcess types.
 				continue
 			}
 			data := []byte(dataString)
+			if !inTimeRange(loader.timeRange, typeToProcess, data) {
+				continue
+			}
 			tocontinue, err := process(data)
 			if err != nil {
 				return false, false, fmt.Errorf("process first: %s", err)
 			}
 			if loader.cache != nil {
-				if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, data); err != nil {
+				if err = loader.cache.CheckAndSave(loader.devUUID, loader.appUUID, typeToProcess, data); err != nil {
 					log.Errorf("error in cache: %s", err)
 				}
 			}
@@ -173,12 +200,15 @@ func (loader *RedisLoader) process(process ProcessFunction, typeToProcess types.
 					continue
 				}
 				data := []byte(dataString)
+				if !inTimeRange(loader.timeRange, typeToProcess, data) {
+					continue
+				}
 				tocontinue, err := process(data)
 				if err != nil {
 					return false, false, fmt.Errorf("process: %s", err)
 				}
 				if loader.cache != nil {
-					if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, data); err != nil {
+					if err = loader.cache.CheckAndSave(loader.devUUID, loader.appUUID, typeToProcess, data); err != nil {
 						log.Errorf("error in cache: %s", err)
 					}
 				}
@@ -216,6 +246,22 @@ func (loader *RedisLoader) getOrCreateClient() (*redis.Client, error) {
 	return loader.client, err
 }
 
+// ListApps returns UUIDs of applications with streams of logs
+func (loader *RedisLoader) ListApps() ([]uuid.UUID, error) {
+	if _, err := loader.getOrCreateClient(); err != nil {
+		return nil, err
+	}
+	prefix := strings.TrimSuffix(loader.streamGetters.StreamApps(loader.devUUID, uuid.Nil), uuid.Nil.String())
+	var result []uuid.UUID
+	iter := loader.client.Scan(context.Background(), 0, prefix+"*", 0).Iterator()
+	for iter.Next(context.Background()) {
+		if appUUID, err := uuid.FromString(strings.TrimPrefix(iter.Val(), prefix)); err == nil {
+			result = append(result, appUUID)
+		}
+	}
+	return result, iter.Err()
+}
+
 // ProcessExisting for observe existing files
 func (loader *RedisLoader) ProcessExisting(process ProcessFunction, typeToProcess types.LoaderObjectType) error {
 	if _, err := loader.getOrCreateClient(); err != nil {
@@ -226,13 +272,17 @@ func (loader *RedisLoader) ProcessExisting(process ProcessFunction, typeToProces
 
 // ProcessStream for observe new files
 func (loader *RedisLoader) ProcessStream(process ProcessFunction, typeToProcess types.LoaderObjectType, timeoutSeconds time.Duration) (err error) {
+	if receivedAfter(loader.timeRange, time.Now()) {
+		// new objects cannot fit the range
+		return nil
+	}
 	if _, err := loader.getOrCreateClient(); err != nil {
 		return err
 	}
 	done := make(chan error)
 	if timeoutSeconds != 0 {
 		time.AfterFunc(timeoutSeconds, func() {
-			done <- fmt.Errorf("timeout")
+			done <- ErrTimeout
 		})
 	}
 
@@ -5,16 +5,20 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/lf-edge/eden/pkg/controller/cachers"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eve-api/go/flowlog"
 	"github.com/lf-edge/eve-api/go/info"
 	"github.com/lf-edge/eve-api/go/logs"
+	"github.com/lf-edge/eve-api/go/metrics"
 	uuid "github.com/satori/go.uuid"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -23,6 +27,10 @@ const (
 	StreamHeader = "X-Stream"
 	//StreamValue enable stream
 	StreamValue = "true"
+	//SinceParam is URL parameter to request objects created after the time in RFC 3339 format
+	SinceParam = "since"
+	//UntilParam is URL parameter to request objects created before the time in RFC 3339 format
+	UntilParam = "until"
 )
 
 type getClient = func() *http.Client
@@ -39,6 +47,7 @@ type RemoteLoader struct {
 	getClient    getClient
 	client       *http.Client
 	cache        cachers.CacheProcessor
+	timeRange    types.TimeRange
 }
 
 // NewRemoteLoader return loader from files
@@ -69,6 +78,7 @@ func (loader *RemoteLoader) Clone() Loader {
 		appUUID:      loader.appUUID,
 		client:       loader.getClient(),
 		cache:        loader.cache,
+		timeRange:    loader.timeRange,
 	}
 }
 
@@ -101,32 +111,92 @@ func (loader *RemoteLoader) SetAppUUID(appUUID uuid.UUID) {
 	loader.appUUID = appUUID
 }
 
-func (loader *RemoteLoader) processNext(decoder *json.Decoder, process ProcessFunction, typeToProcess types.LoaderObjectType, stream bool) (processed, tocontinue bool, err error) {
-	var buf []byte
+// SetTimeRange set range of timestamps of objects to process
+func (loader *RemoteLoader) SetTimeRange(timeRange types.TimeRange) {
+	loader.timeRange = timeRange
+}
+
+// rangeURL adds parameters of time range to u to let controller skip objects out of range
+func (loader *RemoteLoader) rangeURL(u string) string {
+	if loader.timeRange.IsZero() {
+		return u
+	}
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	params := parsed.Query()
+	if !loader.timeRange.Since.IsZero() {
+		params.Set(SinceParam, loader.timeRange.Since.UTC().Format(time.RFC3339Nano))
+	}
+	if !loader.timeRange.Until.IsZero() {
+		params.Set(UntilParam, loader.timeRange.Until.UTC().Format(time.RFC3339Nano))
+	}
+	parsed.RawQuery = params.Encode()
+	return parsed.String()
+}
+
+// convertRemoteItem converts JSON object received from controller into
+// representation expected by processing functions of typeToProcess
+func convertRemoteItem(typeToProcess types.LoaderObjectType, raw json.RawMessage) ([]byte, error) {
 	switch typeToProcess {
 	case types.LogsType:
 		var emp logs.LogBundle
-		if err := decoder.Decode(&emp); err == io.EOF {
-			return false, false, nil
-		} else if err != nil {
-			return false, false, err
-		}
-		if buf, err = protojson.Marshal(&emp); err != nil {
-			return false, false, err
+		if err := protojson.Unmarshal(raw, &emp); err != nil {
+			return nil, err
 		}
+		return protojson.Marshal(&emp)
 	case types.InfoType:
+		// ZInfoMsg stored by controller in binary form
 		var emp info.ZInfoMsg
-		if err := decoder.Decode(&emp); err == io.EOF {
-			return false, false, nil
-		} else if err != nil {
-			return false, false, err
+		if err := protojson.Unmarshal(raw, &emp); err != nil {
+			return nil, err
 		}
-		if buf, err = protojson.Marshal(&emp); err != nil {
-			return false, false, err
+		return proto.Marshal(&emp)
+	case types.MetricsType:
+		// ZMetricMsg stored by controller in binary form
+		var emp metrics.ZMetricMsg
+		if err := protojson.Unmarshal(raw, &emp); err != nil {
+			return nil, err
 		}
+		return proto.Marshal(&emp)
+	case types.AppsType:
+		var emp logs.LogEntry
+		if err := protojson.Unmarshal(raw, &emp); err != nil {
+			return nil, err
+		}
+		return protojson.Marshal(&emp)
+	case types.FlowLogType:
+		// FlowMessage stored by controller in binary form
+		var emp flowlog.FlowMessage
+		if err := protojson.Unmarshal(raw, &emp); err != nil {
+			return nil, err
+		}
+		return proto.Marshal(&emp)
+	case types.RequestType:
+		var emp types.APIRequest
+		if err := json.Unmarshal(raw, &emp); err != nil {
+			return nil, err
+		}
+		return json.Marshal(&emp)
+	default:
+		return nil, fmt.Errorf("not implemented type %d", typeToProcess)
+	}
+}
+
+func (loader *RemoteLoader) processNext(decoder *json.Decoder, process ProcessFunction, typeToProcess types.LoaderObjectType, stream bool) (processed, tocontinue bool, err error) {
+	var raw json.RawMessage
+	if err := decoder.Decode(&raw); err == io.EOF {
+		return false, false, nil
+	} else if err != nil {
+		return false, false, err
+	}
+	buf, err := convertRemoteItem(typeToProcess, raw)
+	if err != nil {
+		return false, false, err
 	}
 	if loader.cache != nil {
-		if err = loader.cache.CheckAndSave(loader.devUUID, typeToProcess, buf); err != nil {
+		if err = loader.cache.CheckAndSave(loader.devUUID, loader.appUUID, typeToProcess, buf); err != nil {
 			log.Errorf("error in cache: %s", err)
 		}
 	}
@@ -134,6 +204,11 @@ func (loader *RemoteLoader) processNext(decoder *json.Decoder, process ProcessFu
 		loader.curCount++
 		return false, true, nil
 	}
+	if !inTimeRange(loader.timeRange, typeToProcess, buf) {
+		loader.curCount++
+		loader.lastCount = loader.curCount
+		return false, true, nil
+	}
 	tocontinue, err = process(buf)
 	if stream {
 		time.Sleep(1 * time.Second) //wait for load all data from buffer
@@ -144,7 +219,7 @@ func (loader *RemoteLoader) processNext(decoder *json.Decoder, process ProcessFu
 }
 
 func (loader *RemoteLoader) process(process ProcessFunction, typeToProcess types.LoaderObjectType, stream bool) (processed, found bool, err error) {
-	u := loader.getURL(typeToProcess)
+	u := loader.rangeURL(loader.getURL(typeToProcess))
 	log.Debugf("remote controller request %s", u)
 	req, _ := http.NewRequest("GET", u, nil)
 	if stream {
@@ -224,10 +299,14 @@ func (loader *RemoteLoader) ProcessExisting(process ProcessFunction, typeToProce
 
 // ProcessStream for observe new files
 func (loader *RemoteLoader) ProcessStream(process ProcessFunction, typeToProcess types.LoaderObjectType, timeoutSeconds time.Duration) (err error) {
+	if receivedAfter(loader.timeRange, time.Now()) {
+		// new objects cannot fit the range
+		return nil
+	}
 	done := make(chan error)
 	if timeoutSeconds != 0 {
 		time.AfterFunc(timeoutSeconds, func() {
-			done <- fmt.Errorf("timeout")
+			done <- ErrTimeout
 		})
 	}
 
@@ -0,0 +1,138 @@
+package loaders
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/cachers"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// ReplayLoader implements loader from telemetry bundle recorded with BundleWriter.
+// Bundle contains objects of one device, so they are served for any device UUID.
+type ReplayLoader struct {
+	appUUID   uuid.UUID
+	devUUID   uuid.UUID
+	bundle    *Bundle
+	cache     cachers.CacheProcessor
+	timeRange types.TimeRange
+}
+
+// NewReplayLoader return loader from telemetry bundle
+func NewReplayLoader(bundle *Bundle) *ReplayLoader {
+	log.Debugf("NewReplayLoader init")
+	return &ReplayLoader{bundle: bundle}
+}
+
+// SetRemoteCache add cache layer
+func (loader *ReplayLoader) SetRemoteCache(cache cachers.CacheProcessor) {
+	loader.cache = cache
+}
+
+// Clone create copy
+func (loader *ReplayLoader) Clone() Loader {
+	return &ReplayLoader{
+		bundle:    loader.bundle,
+		devUUID:   loader.devUUID,
+		appUUID:   loader.appUUID,
+		cache:     loader.cache,
+		timeRange: loader.timeRange,
+	}
+}
+
+// SetUUID set device UUID
+func (loader *ReplayLoader) SetUUID(devUUID uuid.UUID) {
+	loader.devUUID = devUUID
+}
+
+// SetAppUUID set app UUID
+func (loader *ReplayLoader) SetAppUUID(appUUID uuid.UUID) {
+	loader.appUUID = appUUID
+}
+
+// SetTimeRange set range of timestamps of objects to process
+func (loader *ReplayLoader) SetTimeRange(timeRange types.TimeRange) {
+	loader.timeRange = timeRange
+}
+
+// ListApps returns UUIDs of applications with logs in the bundle
+func (loader *ReplayLoader) ListApps() ([]uuid.UUID, error) {
+	var result []uuid.UUID
+	seen := make(map[string]bool)
+	for _, item := range loader.bundle.Index.Items {
+		if item.Type != bundleTypeNames[types.AppsType] || seen[item.AppUUID] {
+			continue
+		}
+		seen[item.AppUUID] = true
+		appUUID, err := uuid.FromString(item.AppUUID)
+		if err != nil {
+			return nil, fmt.Errorf("incorrect app UUID %s in bundle: %w", item.AppUUID, err)
+		}
+		result = append(result, appUUID)
+	}
+	return result, nil
+}
+
+// items returns items of typeToProcess from the bundle in order of their timestamps
+func (loader *ReplayLoader) items(typeToProcess types.LoaderObjectType) []BundleItem {
+	var result []BundleItem
+	for _, item := range loader.bundle.Index.Items {
+		if item.Type != bundleTypeNames[typeToProcess] {
+			continue
+		}
+		if typeToProcess == types.AppsType && item.AppUUID != loader.appUUID.String() {
+			continue
+		}
+		if !item.Time.IsZero() && !loader.timeRange.IsZero() && !loader.timeRange.Contains(item.Time) {
+			continue
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+func (loader *ReplayLoader) process(process ProcessFunction, typeToProcess types.LoaderObjectType, item BundleItem) (bool, error) {
+	data := loader.bundle.Data(item)
+	if loader.cache != nil {
+		if err := loader.cache.CheckAndSave(loader.devUUID, loader.appUUID, typeToProcess, data); err != nil {
+			log.Errorf("error in cache: %s", err)
+		}
+	}
+	return process(data)
+}
+
+// ProcessExisting for observe objects of the bundle from the newest to the oldest
+func (loader *ReplayLoader) ProcessExisting(process ProcessFunction, typeToProcess types.LoaderObjectType) error {
+	items := loader.items(typeToProcess)
+	for i := len(items) - 1; i >= 0; i-- {
+		doContinue, err := loader.process(process, typeToProcess, items[i])
+		if err != nil {
+			return err
+		}
+		if !doContinue {
+			return nil
+		}
+	}
+	return nil
+}
+
+// ProcessStream replays objects of the bundle from the oldest to the newest
+// as if they arrive one by one. When objects run out, it returns timeout error
+// if timeoutSeconds is set to not wait for objects which never come.
+func (loader *ReplayLoader) ProcessStream(process ProcessFunction, typeToProcess types.LoaderObjectType, timeoutSeconds time.Duration) error {
+	for _, item := range loader.items(typeToProcess) {
+		doContinue, err := loader.process(process, typeToProcess, item)
+		if err != nil {
+			return err
+		}
+		if !doContinue {
+			return nil
+		}
+	}
+	if timeoutSeconds != 0 {
+		return ErrTimeout
+	}
+	return nil
+}
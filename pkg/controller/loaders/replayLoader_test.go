@@ -0,0 +1,88 @@
+package loaders_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eve-api/go/info"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestReplayLoader(t *testing.T) {
+	t.Parallel()
+
+	devUUID := uuid.Must(uuid.NewV4())
+	appUUID := uuid.Must(uuid.NewV4())
+	now := time.Now()
+	var buf bytes.Buffer
+	writer := loaders.NewBundleWriter(&buf, devUUID)
+	// items are added from the newest as loaders do in ProcessExisting
+	for _, name := range []string{"third", "second", "first"} {
+		now = now.Add(-time.Minute)
+		data, err := proto.Marshal(&info.ZInfoMsg{DevId: name, AtTimeStamp: timestamppb.New(now)})
+		require.NoError(t, err)
+		require.NoError(t, writer.Add(types.InfoType, uuid.Nil, data))
+		// duplicates are skipped
+		require.NoError(t, writer.Add(types.InfoType, uuid.Nil, data))
+	}
+	appLog := []byte(`{"content":"app started","timestamp":"2021-05-17T14:49:46Z"}`)
+	require.NoError(t, writer.Add(types.AppsType, appUUID, appLog))
+	require.Equal(t, 4, writer.Len())
+	require.NoError(t, writer.Close())
+
+	bundle, err := loaders.ReadBundle(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, devUUID.String(), bundle.Index.DevUUID)
+	require.Len(t, bundle.Index.Items, 4)
+
+	loader := loaders.NewReplayLoader(bundle)
+	loader.SetUUID(uuid.Must(uuid.NewV4()))
+	collect := func(stream bool, limit int) []string {
+		var found []string
+		process := func(data []byte) (bool, error) {
+			var im info.ZInfoMsg
+			require.NoError(t, proto.Unmarshal(data, &im))
+			found = append(found, im.DevId)
+			return len(found) < limit, nil
+		}
+		if stream {
+			err := loader.ProcessStream(process, types.InfoType, time.Minute)
+			if len(found) < limit {
+				assert.ErrorIs(t, err, loaders.ErrTimeout)
+			}
+		} else {
+			require.NoError(t, loader.ProcessExisting(process, types.InfoType))
+		}
+		return found
+	}
+	assert.Equal(t, []string{"third", "second", "first"}, collect(false, 10))
+	assert.Equal(t, []string{"first", "second", "third"}, collect(true, 10))
+	assert.Equal(t, []string{"first"}, collect(true, 1))
+
+	loader.SetTimeRange(types.TimeRange{Since: now.Add(time.Second)})
+	assert.Equal(t, []string{"second", "third"}, collect(true, 10))
+
+	appLoader := loader.Clone()
+	appLoader.SetTimeRange(types.TimeRange{})
+	var appLogs [][]byte
+	processApp := func(data []byte) (bool, error) {
+		appLogs = append(appLogs, data)
+		return true, nil
+	}
+	require.NoError(t, appLoader.ProcessExisting(processApp, types.AppsType))
+	assert.Empty(t, appLogs)
+	appLoader.SetAppUUID(appUUID)
+	require.NoError(t, appLoader.ProcessExisting(processApp, types.AppsType))
+	assert.Equal(t, [][]byte{appLog}, appLogs)
+
+	apps, err := loader.ListApps()
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{appUUID}, apps)
+}
@@ -0,0 +1,124 @@
+package loaders
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eve-api/go/flowlog"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/metrics"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// receivedBefore checks if object received by controller at receiveTime
+// cannot be inside of timeRange because it was received before timeRange.Since
+// taking into account clock of device running ahead of the controller
+func receivedBefore(timeRange types.TimeRange, receiveTime time.Time) bool {
+	return !timeRange.Since.IsZero() && receiveTime.Before(timeRange.Since.Add(-defaults.DefaultDeliveryDelay))
+}
+
+// receivedAfter checks if object received by controller at receiveTime
+// cannot be inside of timeRange because it was received too late
+// taking into account delay of delivery
+func receivedAfter(timeRange types.TimeRange, receiveTime time.Time) bool {
+	return !timeRange.Until.IsZero() && receiveTime.After(timeRange.Until.Add(defaults.DefaultDeliveryDelay))
+}
+
+// inTimeRange checks if data of typeToProcess fits timeRange,
+// objects without timestamps are not filtered out
+func inTimeRange(timeRange types.TimeRange, typeToProcess types.LoaderObjectType, data []byte) bool {
+	if timeRange.IsZero() {
+		return true
+	}
+	t, ok := itemTime(typeToProcess, data)
+	if !ok {
+		return true
+	}
+	return timeRange.Contains(t)
+}
+
+// unmarshalProto parses data in JSON or binary form into msg
+func unmarshalProto(data []byte, msg proto.Message) error {
+	if err := protojson.Unmarshal(data, msg); err == nil {
+		return nil
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+// itemTime returns timestamp of object of typeToProcess
+func itemTime(typeToProcess types.LoaderObjectType, data []byte) (time.Time, bool) {
+	switch typeToProcess {
+	case types.InfoType:
+		var im info.ZInfoMsg
+		if err := unmarshalProto(data, &im); err != nil || im.AtTimeStamp == nil {
+			return time.Time{}, false
+		}
+		return im.AtTimeStamp.AsTime(), true
+	case types.MetricsType:
+		var mm metrics.ZMetricMsg
+		if err := unmarshalProto(data, &mm); err != nil || mm.AtTimeStamp == nil {
+			return time.Time{}, false
+		}
+		return mm.AtTimeStamp.AsTime(), true
+	case types.FlowLogType:
+		// FlowMessage has no own timestamp, so use the last event in it
+		var fm flowlog.FlowMessage
+		if err := proto.Unmarshal(data, &fm); err != nil {
+			return time.Time{}, false
+		}
+		var last *timestamppb.Timestamp
+		for _, flow := range fm.Flows {
+			if flow.EndTime != nil && (last == nil || flow.EndTime.AsTime().After(last.AsTime())) {
+				last = flow.EndTime
+			}
+		}
+		for _, dnsReq := range fm.DnsReqs {
+			if dnsReq.RequestTime != nil && (last == nil || dnsReq.RequestTime.AsTime().After(last.AsTime())) {
+				last = dnsReq.RequestTime
+			}
+		}
+		if last == nil {
+			return time.Time{}, false
+		}
+		return last.AsTime(), true
+	case types.LogsType, types.AppsType, types.RequestType:
+		var item struct {
+			Timestamp json.RawMessage `json:"timestamp"`
+		}
+		if err := json.Unmarshal(data, &item); err != nil || len(item.Timestamp) == 0 {
+			return time.Time{}, false
+		}
+		return parseJSONTime(item.Timestamp)
+	}
+	return time.Time{}, false
+}
+
+// parseJSONTime parses timestamp encoded as RFC 3339 string
+// or as object with seconds and nanos
+func parseJSONTime(raw json.RawMessage) (time.Time, bool) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		t, err := time.Parse(time.RFC3339Nano, s)
+		return t, err == nil
+	}
+	var ts struct {
+		Seconds json.Number `json:"seconds"`
+		Nanos   int64       `json:"nanos"`
+	}
+	if err := json.Unmarshal(raw, &ts); err != nil {
+		return time.Time{}, false
+	}
+	var seconds int64
+	if ts.Seconds != "" {
+		var err error
+		if seconds, err = strconv.ParseInt(ts.Seconds.String(), 10, 64); err != nil {
+			return time.Time{}, false
+		}
+	}
+	return time.Unix(seconds, ts.Nanos), true
+}
@@ -0,0 +1,118 @@
+package rest
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// tokenTransport adds token authorization header into every request
+type tokenTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.token != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", t.token))
+	}
+	return t.base.RoundTrip(req)
+}
+
+// http client with correct config
+func (rest *Ctx) getHTTPClient() *http.Client {
+	tlsConfig := &tls.Config{}
+	if rest.serverCA != "" {
+		caCert, err := os.ReadFile(rest.serverCA)
+		if err != nil {
+			log.Fatalf("unable to read server CA file at %s: %v", rest.serverCA, err)
+		}
+		caCertPool := x509.NewCertPool()
+		caCertPool.AppendCertsFromPEM(caCert)
+		tlsConfig.RootCAs = caCertPool
+	}
+	if rest.insecureTLS {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	var client = &http.Client{
+		Timeout: time.Second * 10,
+		Transport: &tokenTransport{
+			token: rest.token,
+			base: &http.Transport{
+				TLSClientConfig:       tlsConfig,
+				TLSHandshakeTimeout:   10 * time.Second,
+				ResponseHeaderTimeout: 10 * time.Second,
+			},
+		},
+	}
+	return client
+}
+
+// doRequest sends request with body to path and returns body of response
+func (rest *Ctx) doRequest(method string, path string, body []byte, contentType string, acceptMime string) ([]byte, error) {
+	u, err := utils.ResolveURL(rest.url, path)
+	if err != nil {
+		return nil, fmt.Errorf("error constructing URL: %w", err)
+	}
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewBuffer(body)
+	}
+	req, err := http.NewRequest(method, u, reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create new http request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if acceptMime != "" {
+		req.Header.Set("Accept", acceptMime)
+	}
+	// do not use utils.RepeatableAttempt here to fail fast on authorization errors
+	response, err := rest.getHTTPClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to send request: %w", err)
+	}
+	defer response.Body.Close()
+	buf, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read data from URL %s: %w", u, err)
+	}
+	switch response.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusAccepted, http.StatusNoContent:
+		return buf, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return nil, fmt.Errorf("%s %s: not authorized (status code %d), check rest.token", method, u, response.StatusCode)
+	default:
+		return nil, fmt.Errorf("%s %s: status code %d: %s", method, u, response.StatusCode, string(buf))
+	}
+}
+
+func (rest *Ctx) getObj(path string, acceptMime string) ([]byte, error) {
+	return rest.doRequest(http.MethodGet, path, nil, "", acceptMime)
+}
+
+func (rest *Ctx) postObj(path string, obj []byte, mimeType string) error {
+	_, err := rest.doRequest(http.MethodPost, path, obj, mimeType, "")
+	return err
+}
+
+func (rest *Ctx) putObj(path string, obj []byte, mimeType string) error {
+	_, err := rest.doRequest(http.MethodPut, path, obj, mimeType, "")
+	return err
+}
+
+func (rest *Ctx) deleteObj(path string) error {
+	_, err := rest.doRequest(http.MethodDelete, path, nil, "", "")
+	return err
+}
@@ -0,0 +1,49 @@
+package rest
+
+import (
+	"path"
+
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/utils"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// getLoader return loader object for REST controller
+func (rest *Ctx) getLoader() loaders.Loader {
+	urlGetters := types.URLGetters{
+		URLLogs:    rest.getDeviceURLFunc("logs"),
+		URLInfo:    rest.getDeviceURLFunc("info"),
+		URLMetrics: rest.getDeviceURLFunc("metrics"),
+		URLFlowLog: rest.getDeviceURLFunc("flowlog"),
+		URLRequest: rest.getDeviceURLFunc("requests"),
+		URLApps:    rest.getAppsLogsURL,
+	}
+	return loaders.NewRemoteLoader(rest.getHTTPClient, urlGetters)
+}
+
+// GetLoader returns loader of objects sent by devices to controller
+func (rest *Ctx) GetLoader() loaders.Loader {
+	return rest.getLoader()
+}
+
+// getDeviceURLFunc return function to construct url of kind of objects for devUUID
+func (rest *Ctx) getDeviceURLFunc(kind string) func(devUUID uuid.UUID) string {
+	return func(devUUID uuid.UUID) string {
+		resURL, err := utils.ResolveURL(rest.url, path.Join(devicePath(devUUID), kind))
+		if err != nil {
+			log.Fatalf("ResolveURL: %s", err)
+		}
+		return resURL
+	}
+}
+
+// getAppsLogsURL return app logs url for devUUID and appUUID
+func (rest *Ctx) getAppsLogsURL(devUUID uuid.UUID, appUUID uuid.UUID) string {
+	resURL, err := utils.ResolveURL(rest.url, path.Join(devicePath(devUUID), "apps", "id", appUUID.String(), "logs"))
+	if err != nil {
+		log.Fatalf("ResolveURL: %s", err)
+	}
+	return resURL
+}
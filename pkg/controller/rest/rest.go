@@ -0,0 +1,375 @@
+// Package rest implements controller.Controller for remote controllers
+// serving REST API defined by eden (see docs/rest-controller.md) with token authorization.
+// The API is modelled on the admin API of Adam and is not the API of zedcloud.
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/eapps"
+	"github.com/lf-edge/eden/pkg/controller/eflowlog"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/controller/erequest"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/auth"
+	"github.com/lf-edge/eve-api/go/certs"
+	uuid "github.com/satori/go.uuid"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	mimeProto = "application/x-proto-binary"
+	mimeJSON  = "application/json"
+
+	apiPrefix     = "/api/v1"
+	devicesPath   = apiPrefix + "/devices"
+	onboardPath   = devicesPath + "/onboard"
+	certsPath     = devicesPath + "/certs"
+	optionsPath   = apiPrefix + "/options"
+	edgeCertsPath = "/api/v2/edgedevice/certs"
+)
+
+// Ctx stores settings of REST controller
+type Ctx struct {
+	url         string
+	token       string
+	serverCA    string
+	insecureTLS bool
+}
+
+// DeviceListItem is an element of device list returned by controller
+type DeviceListItem struct {
+	ID        string `json:"id"`
+	Onboarded bool   `json:"onboarded"`
+}
+
+// DeviceList is a device list returned by controller
+type DeviceList struct {
+	List []*DeviceListItem `json:"list"`
+}
+
+// devicePath returns path of API for devUUID
+func devicePath(devUUID uuid.UUID) string {
+	return path.Join(devicesPath, "id", devUUID.String())
+}
+
+// InitWithVars use variables from viper for init controller
+func (rest *Ctx) InitWithVars(vars *utils.ConfigVars) error {
+	if vars.RestURL == "" {
+		return fmt.Errorf("rest.url is not defined")
+	}
+	rest.url = vars.RestURL
+	rest.token = vars.RestToken
+	rest.serverCA = vars.RestCA
+	rest.insecureTLS = vars.RestInsecure
+	return nil
+}
+
+// GetDir return dir
+// REST controller is remote one, so there is no local dir
+func (rest *Ctx) GetDir() (dir string) {
+	return ""
+}
+
+// Register device in controller
+func (rest *Ctx) Register(device *device.Ctx) error {
+	b, err := os.ReadFile(device.GetOnboardKey())
+	if err != nil {
+		return fmt.Errorf("error reading cert file %s: %w", device.GetOnboardKey(), err)
+	}
+	body, err := json.Marshal(types.OnboardCert{
+		Cert:   b,
+		Serial: device.GetSerial(),
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding json: %w", err)
+	}
+	return rest.postObj(onboardPath, body, mimeJSON)
+}
+
+// DeviceList return device list
+func (rest *Ctx) DeviceList(filter types.DeviceStateFilter) (out []string, err error) {
+	data, err := rest.getObj(devicesPath, mimeJSON)
+	if err != nil {
+		return nil, err
+	}
+	var list DeviceList
+	if err = json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal device list: %w", err)
+	}
+	for _, el := range list.List {
+		switch filter {
+		case types.RegisteredDeviceFilter:
+			if !el.Onboarded {
+				continue
+			}
+		case types.NotRegisteredDeviceFilter:
+			if el.Onboarded {
+				continue
+			}
+		}
+		out = append(out, el.ID)
+	}
+	return out, nil
+}
+
+// ConfigSet set config for devID
+func (rest *Ctx) ConfigSet(devUUID uuid.UUID, devConfig []byte) (err error) {
+	return rest.putObj(path.Join(devicePath(devUUID), "config"), devConfig, mimeProto)
+}
+
+// ConfigGet get config for devID
+func (rest *Ctx) ConfigGet(devUUID uuid.UUID) (out string, err error) {
+	data, err := rest.getObj(path.Join(devicePath(devUUID), "config"), mimeProto)
+	return string(data), err
+}
+
+// GetECDHCert get cert for ECDH exchange for devID
+func (rest *Ctx) GetECDHCert(devUUID uuid.UUID) ([]byte, error) {
+	attestData, err := rest.getObj(path.Join(devicePath(devUUID), "certs"), mimeJSON)
+	if err != nil {
+		return nil, fmt.Errorf("cannot get attestation certificates from cloud for %s: %w", devUUID, err)
+	}
+	req := &types.Zcerts{}
+	if err := json.Unmarshal(attestData, req); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal attest: %w", err)
+	}
+	for _, c := range req.Certs {
+		if c.Type == certs.ZCertType_CERT_TYPE_DEVICE_ECDH_EXCHANGE {
+			return c.Cert, nil
+		}
+	}
+	return nil, fmt.Errorf("no DEVICE_ECDH_EXCHANGE certificate")
+}
+
+// RequestLastCallback check request by pattern from existence files with callback
+func (rest *Ctx) RequestLastCallback(devUUID uuid.UUID, q map[string]string, handler erequest.HandlerFunc) (err error) {
+	var loader = rest.getLoader()
+	loader.SetUUID(devUUID)
+	return erequest.RequestLast(loader, q, handler)
+}
+
+// LogAppsChecker check app logs by pattern from existence files with LogLast and use LogWatchWithTimeout with timeout for observe new files
+func (rest *Ctx) LogAppsChecker(devUUID uuid.UUID, appUUID uuid.UUID, q map[string]string, handler eapps.HandlerFunc, mode eapps.LogCheckerMode, timeout time.Duration) (err error) {
+	return eapps.LogChecker(rest.getLoader(), devUUID, appUUID, q, handler, mode, timeout)
+}
+
+// LogAppsLastCallback check app logs by pattern from existence files with callback
+func (rest *Ctx) LogAppsLastCallback(devUUID uuid.UUID, appUUID uuid.UUID, q map[string]string, handler eapps.HandlerFunc) (err error) {
+	var loader = rest.getLoader()
+	loader.SetUUID(devUUID)
+	loader.SetAppUUID(appUUID)
+	return eapps.LogLast(loader, q, handler)
+}
+
+// LogChecker check logs by pattern from existence files with LogLast and use LogWatchWithTimeout with timeout for observe new files
+func (rest *Ctx) LogChecker(devUUID uuid.UUID, q map[string]string, handler elog.HandlerFunc, mode elog.LogCheckerMode, timeout time.Duration) (err error) {
+	return elog.LogChecker(rest.getLoader(), devUUID, q, handler, mode, timeout)
+}
+
+// LogLastCallback check logs by pattern from existence files with callback
+func (rest *Ctx) LogLastCallback(devUUID uuid.UUID, q map[string]string, handler elog.HandlerFunc) (err error) {
+	var loader = rest.getLoader()
+	loader.SetUUID(devUUID)
+	return elog.LogLast(loader, q, handler)
+}
+
+// FlowLogChecker check FlowLogs by pattern from existence files with FlowLogLast and use FlowLogWatchWithTimeout with timeout for observe new files
+func (rest *Ctx) FlowLogChecker(devUUID uuid.UUID, q map[string]string, handler eflowlog.HandlerFunc, mode eflowlog.FlowLogCheckerMode, timeout time.Duration) (err error) {
+	return eflowlog.FlowLogChecker(rest.getLoader(), devUUID, q, handler, mode, timeout)
+}
+
+// FlowLogLastCallback check FlowLogs by pattern from existence files with callback
+func (rest *Ctx) FlowLogLastCallback(devUUID uuid.UUID, q map[string]string, handler eflowlog.HandlerFunc) (err error) {
+	var loader = rest.getLoader()
+	loader.SetUUID(devUUID)
+	return eflowlog.FlowLogLast(loader, q, handler)
+}
+
+// InfoChecker checks the information in the regular expression pattern 'query' and processes the info.ZInfoMsg found by the function 'handler' from existing files (mode=einfo.InfoExist), new files (mode=einfo.InfoNew) or any of them (mode=einfo.InfoAny) with timeout.
+func (rest *Ctx) InfoChecker(devUUID uuid.UUID, q map[string]string, handler einfo.HandlerFunc, mode einfo.InfoCheckerMode, timeout time.Duration) (err error) {
+	return einfo.InfoChecker(rest.getLoader(), devUUID, q, handler, mode, timeout)
+}
+
+// InfoLastCallback check info by pattern from existence files with callback
+func (rest *Ctx) InfoLastCallback(devUUID uuid.UUID, q map[string]string, handler einfo.HandlerFunc) (err error) {
+	var loader = rest.getLoader()
+	loader.SetUUID(devUUID)
+	return einfo.InfoLast(loader, q, einfo.ZInfoFind, handler)
+}
+
+// MetricChecker check metrics by pattern from existence files with LogLast and use LogWatchWithTimeout with timeout for observe new files
+func (rest *Ctx) MetricChecker(devUUID uuid.UUID, q map[string]string, handler emetric.HandlerFunc, mode emetric.MetricCheckerMode, timeout time.Duration) (err error) {
+	return emetric.MetricChecker(rest.getLoader(), devUUID, q, handler, mode, timeout)
+}
+
+// MetricLastCallback check metrics by pattern from existence files with callback
+func (rest *Ctx) MetricLastCallback(devUUID uuid.UUID, q map[string]string, handler emetric.HandlerFunc) (err error) {
+	var loader = rest.getLoader()
+	loader.SetUUID(devUUID)
+	return emetric.MetricLast(loader, q, handler)
+}
+
+// OnboardRemove remove onboard by onboardUUID
+func (rest *Ctx) OnboardRemove(onboardUUID string) (err error) {
+	return rest.deleteObj(path.Join(onboardPath, onboardUUID))
+}
+
+// DeviceRemove remove device by devUUID
+func (rest *Ctx) DeviceRemove(devUUID uuid.UUID) (err error) {
+	return rest.deleteObj(devicePath(devUUID))
+}
+
+// getDeviceCert returns DeviceCert of devUUID
+func (rest *Ctx) getDeviceCert(devUUID uuid.UUID) (*types.DeviceCert, error) {
+	devInfo, err := rest.getObj(devicePath(devUUID), mimeJSON)
+	if err != nil {
+		return nil, err
+	}
+	var devCert types.DeviceCert
+	if err = json.Unmarshal(devInfo, &devCert); err != nil {
+		return nil, err
+	}
+	return &devCert, nil
+}
+
+// DeviceGetOnboard get device onboardUUID for devUUID
+func (rest *Ctx) DeviceGetOnboard(devUUID uuid.UUID) (onboardUUID uuid.UUID, err error) {
+	devCert, err := rest.getDeviceCert(devUUID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	cert, err := utils.ParseFirstCertFromBlock(devCert.Onboard)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return uuid.FromString(cert.Subject.CommonName)
+}
+
+// DeviceGetByOnboard try to get device by onboard eveCert
+func (rest *Ctx) DeviceGetByOnboard(eveCert string) (devUUID uuid.UUID, err error) {
+	b, err := os.ReadFile(eveCert)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error reading cert file %s: %w", eveCert, err)
+	}
+	cert, err := utils.ParseFirstCertFromBlock(b)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	uuidToFound, err := uuid.FromString(cert.Subject.CommonName)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return rest.DeviceGetByOnboardUUID(uuidToFound.String())
+}
+
+// DeviceGetByOnboardUUID try to get device by onboard uuid
+func (rest *Ctx) DeviceGetByOnboardUUID(onboardUUID string) (devUUID uuid.UUID, err error) {
+	devIDs, err := rest.DeviceList(types.RegisteredDeviceFilter)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	for _, devID := range devIDs {
+		devUUID, err := uuid.FromString(devID)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		id, err := rest.DeviceGetOnboard(devUUID)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if id.String() == onboardUUID {
+			return devUUID, nil
+		}
+	}
+	return uuid.Nil, fmt.Errorf("no device found")
+}
+
+// GetDeviceCert gets deviceCert contains certificates and serial
+func (rest *Ctx) GetDeviceCert(device *device.Ctx) (*types.DeviceCert, error) {
+	return rest.getDeviceCert(device.GetID())
+}
+
+// UploadDeviceCert upload deviceCert into controller
+func (rest *Ctx) UploadDeviceCert(deviceCert types.DeviceCert) error {
+	body, err := json.Marshal(deviceCert)
+	if err != nil {
+		return err
+	}
+	return rest.postObj(certsPath, body, mimeJSON)
+}
+
+// SetDeviceOptions sets options for provided devUUID
+func (rest *Ctx) SetDeviceOptions(devUUID uuid.UUID, options *types.DeviceOptions) error {
+	body, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	return rest.putObj(path.Join(devicePath(devUUID), "options"), body, mimeJSON)
+}
+
+// GetDeviceOptions returns DeviceOptions for provided devUUID
+func (rest *Ctx) GetDeviceOptions(devUUID uuid.UUID) (*types.DeviceOptions, error) {
+	devInfo, err := rest.getObj(path.Join(devicePath(devUUID), "options"), mimeJSON)
+	if err != nil {
+		return nil, err
+	}
+	var devOptions types.DeviceOptions
+	if err = json.Unmarshal(devInfo, &devOptions); err != nil {
+		return nil, err
+	}
+	return &devOptions, nil
+}
+
+// SetGlobalOptions sets global options for controller
+func (rest *Ctx) SetGlobalOptions(options *types.GlobalOptions) error {
+	body, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	return rest.putObj(optionsPath, body, mimeJSON)
+}
+
+// GetGlobalOptions returns global options from controller
+func (rest *Ctx) GetGlobalOptions() (*types.GlobalOptions, error) {
+	data, err := rest.getObj(optionsPath, mimeJSON)
+	if err != nil {
+		return nil, err
+	}
+	var globalOptions types.GlobalOptions
+	if err = json.Unmarshal(data, &globalOptions); err != nil {
+		return nil, err
+	}
+	return &globalOptions, nil
+}
+
+// SigningCertGet gets signing certificate from controller
+func (rest *Ctx) SigningCertGet() (signCert []byte, err error) {
+	certsData, err := rest.getObj(edgeCertsPath, mimeProto)
+	if err != nil {
+		return nil, err
+	}
+	zcloudMsg := &auth.AuthContainer{}
+	if err = proto.Unmarshal(certsData, zcloudMsg); err != nil {
+		return nil, err
+	}
+	ctrlCert := &certs.ZControllerCert{}
+	if err = proto.Unmarshal(zcloudMsg.ProtectedPayload.Payload, ctrlCert); err != nil {
+		return nil, err
+	}
+	for _, c := range ctrlCert.Certs {
+		// there should be only one signing certificate, so we return the first one we find
+		if c.Type == certs.ZCertType_CERT_TYPE_CONTROLLER_SIGNING {
+			return c.Cert, nil
+		}
+	}
+	return nil, fmt.Errorf("no signing certificate found")
+}
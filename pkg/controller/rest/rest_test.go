@@ -0,0 +1,78 @@
+package rest_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/controller/rest"
+	"github.com/lf-edge/eden/pkg/utils"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+const testToken = "secret"
+
+// newMockServer returns server which emulates REST controller API with one onboarded and one not onboarded device
+func newMockServer(t *testing.T, onboarded, notOnboarded uuid.UUID) *httptest.Server {
+	configs := map[string][]byte{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/devices", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(rest.DeviceList{List: []*rest.DeviceListItem{
+			{ID: onboarded.String(), Onboarded: true},
+			{ID: notOnboarded.String()},
+		}})
+	})
+	mux.HandleFunc("/api/v1/devices/id/"+onboarded.String()+"/config", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			b, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			configs[onboarded.String()] = b
+		case http.MethodGet:
+			_, _ = w.Write(configs[onboarded.String()])
+		}
+	})
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+}
+
+func TestRestController(t *testing.T) {
+	t.Parallel()
+
+	onboarded := uuid.Must(uuid.NewV4())
+	notOnboarded := uuid.Must(uuid.NewV4())
+	server := newMockServer(t, onboarded, notOnboarded)
+	defer server.Close()
+
+	ctrl := &rest.Ctx{}
+	assert.NoError(t, ctrl.InitWithVars(&utils.ConfigVars{RestURL: server.URL, RestToken: testToken}))
+
+	all, err := ctrl.DeviceList(types.AllDevicesFilter)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{onboarded.String(), notOnboarded.String()}, all)
+
+	registered, err := ctrl.DeviceList(types.RegisteredDeviceFilter)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{onboarded.String()}, registered)
+
+	assert.NoError(t, ctrl.ConfigSet(onboarded, []byte("config")))
+	cfg, err := ctrl.ConfigGet(onboarded)
+	assert.NoError(t, err)
+	assert.Equal(t, "config", cfg)
+
+	unauthorized := &rest.Ctx{}
+	assert.NoError(t, unauthorized.InitWithVars(&utils.ConfigVars{RestURL: server.URL, RestToken: "wrong"}))
+	_, err = unauthorized.DeviceList(types.AllDevicesFilter)
+	assert.ErrorContains(t, err, "not authorized")
+
+	assert.Error(t, (&rest.Ctx{}).InitWithVars(&utils.ConfigVars{}))
+}
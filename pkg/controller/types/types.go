@@ -123,6 +123,29 @@ var AppsType LoaderObjectType = 5
 // FlowLogType for observe FlowMessages
 var FlowLogType LoaderObjectType = 6
 
+// TimeRange limits objects processed by loaders with their timestamps,
+// zero Since or Until means that the range is not bounded from this side
+type TimeRange struct {
+	Since time.Time
+	Until time.Time
+}
+
+// IsZero returns true if the range is not bounded
+func (r TimeRange) IsZero() bool {
+	return r.Since.IsZero() && r.Until.IsZero()
+}
+
+// Contains checks if t is inside of the range
+func (r TimeRange) Contains(t time.Time) bool {
+	if !r.Since.IsZero() && t.Before(r.Since) {
+		return false
+	}
+	if !r.Until.IsZero() && t.After(r.Until) {
+		return false
+	}
+	return true
+}
+
 // APIRequest stores information about requests from EVE
 type APIRequest struct {
 	Timestamp time.Time `json:"timestamp"`
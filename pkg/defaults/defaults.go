@@ -24,6 +24,7 @@ const (
 	DefaultContextFile      = "context.yml"      //file for saving current context inside DefaultEdenHomeDir
 	DefaultContextDirectory = "contexts"         //directory for saving contexts inside DefaultEdenHomeDir
 	DefaultQemuFileToSave   = "qemu.conf"        //qemu config file inside DefaultEdenHomeDir
+	DefaultConfigHistoryDir = "history"          //directory for saving history of configs of devices inside DefaultEdenHomeDir
 	DefaultSSHKey           = "certs/id_rsa.pub" //file for save ssh key
 	DefaultConfigHidden     = ".eden-config.yml" //file to save config get --all
 	DefaultConfigSaved      = "config_saved.yml" //file to save config during 'eden setup'
@@ -74,11 +75,23 @@ const (
 	DefaultSFTPPassword  = "password"
 	DefaultSFTPDirPrefix = "/eserver/run"
 
+	// Default credentials for S3 and Azure Blob emulation in eserver, they are public
+	// and therefore not used if authorization of files of eserver is configured
+	DefaultS3AccessKey   = "eserver"
+	DefaultS3SecretKey   = "eserver-secret"
+	DefaultS3Region      = "us-east-1"
+	DefaultAzureAccount  = "eserver"
+	DefaultAzureKey      = "ZXNlcnZlci1henVyZS1rZXk="
+	DefaultEServerBucket = "eserver" //bucket (container) with eserver files in S3 and Azure Blob emulation
+
 	DefaultEVEPlatform = "none"
 
 	DefaultRedisPasswordFile = "redis.pass"
 
-	DefaultEServerTag          = "4b71e2c"
+	DefaultEServerCert = "eserver.pem"     //certificate of eserver signed by eden CA (in certs dir)
+	DefaultEServerKey  = "eserver-key.pem" //key of eserver certificate (in certs dir)
+
+	DefaultEServerTag          = "2dba63f"
 	DefaultEServerContainerRef = "lfedge/eden-http-server"
 
 	DefaultEClientTag          = "b1c1de6"
@@ -87,7 +100,9 @@ const (
 	//DefaultRepeatCount is repeat count for requests
 	DefaultRepeatCount = 20
 	//DefaultRepeatTimeout is time wait for next attempt
-	DefaultRepeatTimeout         = 5 * time.Second
+	DefaultRepeatTimeout = 5 * time.Second
+	//DefaultDeliveryDelay is maximum expected delay between creation of object on EVE and receiving it by controller
+	DefaultDeliveryDelay         = 10 * time.Minute
 	DefaultUUID                  = "1"
 	DefaultFileToSave            = "./test.tar"
 	DefaultIsLocal               = false
@@ -102,7 +117,7 @@ const (
 	DefaultTestProg              = "eden.escript.test"
 	DefaultTestScenario          = ""
 	DefaultRootFSVersionPattern  = `^.*-(xen|kvm|acrn|rpi|rpi-xen|rpi-kvm)-(amd64|arm64)$`
-	DefaultControllerModePattern = `^(?P<Type>(file|proto|adam|zedcloud)):\/\/(?P<URL>.*)$`
+	DefaultControllerModePattern = `^(?P<Type>(file|proto|adam|rest|embedded)):\/\/(?P<URL>.*)$`
 	DefaultPodLinkPattern        = `^(?P<TYPE>(oci|docker|http[s]{0,1}|file|directory)):\/\/(?P<TAG>[^:]+):*(?P<VERSION>.*)$`
 	DefaultRedisContainerName    = "eden_redis"
 	DefaultAdamContainerName     = "eden_adam"
@@ -218,6 +218,26 @@ eden:
         #force eserver rebuild
         force: {{parse "eden.eserver.force"}}
 
+        #serve http over TLS with certificate signed by eden CA
+        https: {{parse "eden.eserver.https"}}
+
+        #credentials for files (basic authorization with user and password
+        #used by EVE or bearer authorization with token), not checked if empty
+        user: '{{parse "eden.eserver.user"}}'
+        password: '{{parse "eden.eserver.password"}}'
+        token: '{{parse "eden.eserver.token"}}'
+
+        #credentials for admin API used by eden, not checked if empty
+        admin-user: '{{parse "eden.eserver.admin-user"}}'
+        admin-password: '{{parse "eden.eserver.admin-password"}}'
+
+        #credentials for S3 and Azure Blob emulation, emulation is disabled if empty
+        #or if the default ones are used together with authorization of files
+        s3-access-key: '{{parse "eden.eserver.s3-access-key"}}'
+        s3-secret-key: '{{parse "eden.eserver.s3-secret-key"}}'
+        azure-account: '{{parse "eden.eserver.azure-account"}}'
+        azure-key: '{{parse "eden.eserver.azure-key"}}'
+
     #eclient is tool we use in tests
     eclient:
         #tag of eclient container
@@ -251,6 +271,19 @@ packet:
     #path to the key to interact with packet
     key: '{{parse "packet.key"}}'
 
+rest:
+    #URL of remote controller with REST API of eden (used with rest:// mode)
+    url: '{{parse "rest.url"}}'
+
+    #token for API of controller
+    token: '{{parse "rest.token"}}'
+
+    #CA certificate of controller
+    ca: '{{parse "rest.ca"}}'
+
+    #skip verification of controller certificate
+    insecure: {{parse "rest.insecure"}}
+
 redis:
     #port for access redis
     port: {{parse "redis.port"}}
@@ -315,6 +348,11 @@ sdn:
     #path to JSON file with network model to apply into SDN
     #leave empty for default network model
     network-model: '{{parse "sdn.network-model"}}'
+
+    #logical label of the EVE instance managed by this context
+    #(see EVEConnect.EVEInstance of ports in the network model)
+    #leave empty if only one EVE instance is connected to SDN
+    eve-instance: '{{parse "sdn.eve-instance"}}'
 `
 
 //DefaultQemuTemplate is configuration template for qemu
@@ -120,6 +120,9 @@ func (cfg *Ctx) GetConfigItems() map[string]string { return cfg.configItems }
 // SetConfigItem set ConfigItem of device
 func (cfg *Ctx) SetConfigItem(key, val string) { cfg.configItems[key] = val }
 
+// DelConfigItem remove ConfigItem of device
+func (cfg *Ctx) DelConfigItem(key string) { delete(cfg.configItems, key) }
+
 // GetDevModel return devModel of device
 func (cfg *Ctx) GetDevModel() string { return cfg.devModel }
 
@@ -3,6 +3,7 @@ package eden
 import (
 	"bytes"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
@@ -12,6 +13,7 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"runtime"
 	"strconv"
@@ -311,19 +313,42 @@ func StatusRegistry() (status string, err error) {
 	return state, nil
 }
 
+// eserverCertsDir is the directory inside of eserver container with certificate and key for https
+const eserverCertsDir = "/eserver/certs"
+
 // StartEServer function run eserver in docker
 // if eserverForce is set, it recreates container
-func StartEServer(serverPort int, imageDist string, eserverForce bool, eserverTag string) (err error) {
+// if https is set, eserver serves http over TLS with certificate generated by GenerateEveCerts
+// envs are set in eserver container (in form of KEY=VALUE) to pass credentials
+func StartEServer(serverPort int, imageDist string, eserverForce bool, eserverTag string, https bool, envs ...string) (err error) {
 	portMap := map[string]string{"8888": strconv.Itoa(serverPort)}
 	volumeMap := map[string]string{"/eserver/run/eserver/": imageDist}
 	eserverServerCommand := strings.Fields("server")
+	if https {
+		edenHome, err := utils.DefaultEdenDir()
+		if err != nil {
+			return fmt.Errorf("StartEServer: %s", err)
+		}
+		globalCertsDir := filepath.Join(edenHome, defaults.DefaultCertsDist)
+		certPath := filepath.Join(globalCertsDir, defaults.DefaultEServerCert)
+		keyPath := filepath.Join(globalCertsDir, defaults.DefaultEServerKey)
+		if _, err := tls.LoadX509KeyPair(certPath, keyPath); err != nil {
+			return fmt.Errorf("StartEServer: cannot load certificate for https (generated by eden setup): %s", err)
+		}
+		// mount only the certificate and the key of eserver, the certs directory contains keys of CA
+		containerCertPath := path.Join(eserverCertsDir, defaults.DefaultEServerCert)
+		containerKeyPath := path.Join(eserverCertsDir, defaults.DefaultEServerKey)
+		volumeMap[containerCertPath] = certPath
+		volumeMap[containerKeyPath] = keyPath
+		eserverServerCommand = append(eserverServerCommand, "--cert", containerCertPath, "--key", containerKeyPath)
+	}
 	// lets make sure eserverImageDist exists
 	if imageDist != "" && os.MkdirAll(imageDist, os.ModePerm) != nil {
 		return fmt.Errorf("StartEServer: %s does not exist and can not be created", imageDist)
 	}
 	if eserverForce {
 		_ = utils.StopContainer(defaults.DefaultEServerContainerName, true)
-		if err := utils.CreateAndRunContainer(defaults.DefaultEServerContainerName, defaults.DefaultEServerContainerRef+":"+eserverTag, portMap, volumeMap, eserverServerCommand, nil); err != nil {
+		if err := utils.CreateAndRunContainer(defaults.DefaultEServerContainerName, defaults.DefaultEServerContainerRef+":"+eserverTag, portMap, volumeMap, eserverServerCommand, envs); err != nil {
 			return fmt.Errorf("StartEServer: error in create eserver container: %s", err)
 		}
 	} else {
@@ -332,7 +357,7 @@ func StartEServer(serverPort int, imageDist string, eserverForce bool, eserverTa
 			return fmt.Errorf("StartEServer: error in get state of eserver container: %s", err)
 		}
 		if state == "" {
-			if err := utils.CreateAndRunContainer(defaults.DefaultEServerContainerName, defaults.DefaultEServerContainerRef+":"+eserverTag, portMap, volumeMap, eserverServerCommand, nil); err != nil {
+			if err := utils.CreateAndRunContainer(defaults.DefaultEServerContainerName, defaults.DefaultEServerContainerRef+":"+eserverTag, portMap, volumeMap, eserverServerCommand, envs); err != nil {
 				return fmt.Errorf("StartEServer: error in create eserver container: %s", err)
 			}
 		} else if !strings.Contains(state, "running") {
@@ -433,6 +458,16 @@ func GenerateEveCerts(certsDir, domain, ip, eveIP, uuid, devModel, ssid, passwor
 			return fmt.Errorf("GenerateEveCerts: %s", err)
 		}
 	}
+	eserverCertPath := filepath.Join(globalCertsDir, defaults.DefaultEServerCert)
+	eserverKeyPath := filepath.Join(globalCertsDir, defaults.DefaultEServerKey)
+	if _, err := tls.LoadX509KeyPair(eserverCertPath, eserverKeyPath); err != nil {
+		log.Debug("generating EServer cert and key")
+		ips := []net.IP{net.ParseIP(ip), net.ParseIP(eveIP), net.ParseIP("127.0.0.1")}
+		eserverCert, eserverKey := utils.GenServerCertElliptic(rootCert, rootKey, big.NewInt(1), ips, []string{domain}, domain)
+		if err := utils.WriteToFiles(eserverCert, eserverKey, eserverCertPath, eserverKeyPath); err != nil {
+			return fmt.Errorf("GenerateEveCerts eserver: %s", err)
+		}
+	}
 	if !apiV1 {
 		signingCertPath := filepath.Join(globalCertsDir, "signing.pem")
 		signingKeyPath := filepath.Join(globalCertsDir, "signing-key.pem")
@@ -828,7 +863,7 @@ func CleanContext(eveDist, certsDist, imagesDist, evePID, eveUUID, sdnPID, vmNam
 				log.Infof("swtpm is stopping")
 			}
 		}
-		StopSDN(devModel, sdnPID)
+		StopSharedSDN(devModel, sdnPID, evePID)
 	}
 	if _, err = os.Stat(eveDist); !os.IsNotExist(err) {
 		if err = os.RemoveAll(eveDist); err != nil {
@@ -910,6 +945,16 @@ func StopEve(evePidFile, swtpmPidFile, sdnPidFile, devModel, vmName string) {
 			}
 		}
 	}
+	StopSharedSDN(devModel, sdnPidFile, evePidFile)
+}
+
+// StopSharedSDN stops SDN unless it is still used by EVE instance(s)
+// of other Eden contexts.
+func StopSharedSDN(devModel, sdnPidFile, evePidFile string) {
+	if devModel == defaults.DefaultQemuModel && OtherEVEQemuRunning(evePidFile) {
+		log.Infof("SDN is kept running, it is used by other EVE instance(s)")
+		return
+	}
 	StopSDN(devModel, sdnPidFile)
 }
 
@@ -1015,26 +1060,82 @@ func CleanEden(eveDist, adamDist, certsDist, imagesDist, eserverDist, redisDist,
 type EServer struct {
 	EServerIP   string
 	EServerPort string
+	// EServerHTTPS is set if eserver serves http over TLS,
+	// certificate of eserver is verified with CA from EServerCA file
+	EServerHTTPS bool
+	EServerCA    string
+	// credentials for admin API of eserver
+	EServerAdminUser     string
+	EServerAdminPassword string
 }
 
-func (server *EServer) getHTTPClient(timeout time.Duration) *http.Client {
-	return &http.Client{
-		Timeout: timeout,
-		Transport: &http.Transport{
-			ResponseHeaderTimeout: defaults.DefaultRepeatTimeout * defaults.DefaultRepeatCount,
-		},
+// url returns base URL of eserver
+func (server *EServer) url() string {
+	scheme := "http"
+	if server.EServerHTTPS {
+		scheme = "https"
 	}
+	return fmt.Sprintf("%s://%s:%s", scheme, server.EServerIP, server.EServerPort)
+}
+
+func (server *EServer) getHTTPClient(timeout time.Duration) (*http.Client, error) {
+	transport := &http.Transport{
+		ResponseHeaderTimeout: defaults.DefaultRepeatTimeout * defaults.DefaultRepeatCount,
+	}
+	if server.EServerHTTPS && server.EServerCA != "" {
+		caCert, err := os.ReadFile(server.EServerCA)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read CA certificate of eserver: %w", err)
+		}
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificates found in CA certificate of eserver %s", server.EServerCA)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: caCertPool}
+	}
+	client := &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+	if server.EServerAdminUser != "" {
+		client.Transport = &basicAuthTransport{
+			user:     server.EServerAdminUser,
+			password: server.EServerAdminPassword,
+			next:     transport,
+		}
+	}
+	return client, nil
+}
+
+// basicAuthTransport adds basic authorization into requests
+type basicAuthTransport struct {
+	user     string
+	password string
+	next     http.RoundTripper
+}
+
+// RoundTrip sends copy of the request with authorization
+func (t *basicAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.SetBasicAuth(t.user, t.password)
+	return t.next.RoundTrip(req)
 }
 
 // EServerAddFileURL send url to download image into eserver
 func (server *EServer) EServerAddFileURL(url string) (name string) {
-	u, err := utils.ResolveURL(fmt.Sprintf("http://%s:%s", server.EServerIP, server.EServerPort), "admin/add-from-url")
+	u, err := utils.ResolveURL(server.url(), "admin/add-from-url")
 	if err != nil {
 		log.Fatalf("error constructing URL: %v", err)
 	}
-	client := server.getHTTPClient(defaults.DefaultRepeatTimeout)
+	client, err := server.getHTTPClient(defaults.DefaultRepeatTimeout)
+	if err != nil {
+		log.Fatalf("EServerAddFileURL: %v", err)
+	}
 	objToSend := api.URLArg{
 		URL: url,
+		// eserver always skipped verification of server certificates
+		// for downloads requested by eden, keep it this way.
+		Insecure: true,
 	}
 	body, err := json.Marshal(objToSend)
 	if err != nil {
@@ -1058,11 +1159,14 @@ func (server *EServer) EServerAddFileURL(url string) (name string) {
 
 // EServerCheckStatus checks status of image in eserver
 func (server *EServer) EServerCheckStatus(name string) (fileInfo *api.FileInfo) {
-	u, err := utils.ResolveURL(fmt.Sprintf("http://%s:%s", server.EServerIP, server.EServerPort), fmt.Sprintf("admin/status/%s", name))
+	u, err := utils.ResolveURL(server.url(), fmt.Sprintf("admin/status/%s", name))
 	if err != nil {
 		log.Fatalf("EServerAddFileURL: error constructing URL: %v", err)
 	}
-	client := server.getHTTPClient(defaults.DefaultRepeatTimeout * defaults.DefaultRepeatCount)
+	client, err := server.getHTTPClient(defaults.DefaultRepeatTimeout * defaults.DefaultRepeatCount)
+	if err != nil {
+		log.Fatalf("EServerCheckStatus: %v", err)
+	}
 	req, err := http.NewRequest("GET", u, nil)
 	if err != nil {
 		log.Fatalf("EServerAddFileURL: unable to create new http request: %v", err)
@@ -1084,11 +1188,14 @@ func (server *EServer) EServerCheckStatus(name string) (fileInfo *api.FileInfo)
 
 // EServerAddFile send file with image into eserver
 func (server *EServer) EServerAddFile(filepath, prefix string) (fileInfo *api.FileInfo) {
-	u, err := utils.ResolveURL(fmt.Sprintf("http://%s:%s", server.EServerIP, server.EServerPort), "admin/add-from-file")
+	u, err := utils.ResolveURL(server.url(), "admin/add-from-file")
 	if err != nil {
 		log.Fatalf("EServerAddFile: error constructing URL: %v", err)
 	}
-	client := server.getHTTPClient(0)
+	client, err := server.getHTTPClient(0)
+	if err != nil {
+		log.Fatalf("EServerAddFile: %v", err)
+	}
 	response, err := utils.UploadFile(client, u, filepath, prefix)
 	if err != nil {
 		log.Fatalf("EServerAddFile: %s", err)
@@ -1103,6 +1210,99 @@ func (server *EServer) EServerAddFile(filepath, prefix string) (fileInfo *api.Fi
 	return
 }
 
+// EServerListFiles returns information about all files stored in eserver
+func (server *EServer) EServerListFiles() (files []*api.FileInfo, err error) {
+	err = server.eserverAdminRequest(http.MethodGet, "admin/files", nil, &files)
+	return files, err
+}
+
+// EServerDeleteFile removes file from eserver
+// content of the file is removed as well if it is not referenced by another name
+func (server *EServer) EServerDeleteFile(name string) (result *api.GCResult, err error) {
+	err = server.eserverAdminRequest(http.MethodDelete, path.Join("admin/files", name), nil, &result)
+	return result, err
+}
+
+// EServerGC runs garbage collection in eserver
+func (server *EServer) EServerGC(arg api.GCArg) (result *api.GCResult, err error) {
+	err = server.eserverAdminRequest(http.MethodPost, "admin/gc", arg, &result)
+	return result, err
+}
+
+// EServerGetFaults returns fault profiles active in eserver
+func (server *EServer) EServerGetFaults() (profiles []api.FaultProfile, err error) {
+	err = server.eserverAdminRequest(http.MethodGet, "admin/faults", nil, &profiles)
+	return profiles, err
+}
+
+// EServerSetFaults replaces fault profiles in eserver
+func (server *EServer) EServerSetFaults(profiles []api.FaultProfile) (result []api.FaultProfile, err error) {
+	if profiles == nil {
+		profiles = []api.FaultProfile{}
+	}
+	err = server.eserverAdminRequest(http.MethodPut, "admin/faults", profiles, &result)
+	return result, err
+}
+
+// EServerAddFault adds fault profile into eserver
+func (server *EServer) EServerAddFault(profile api.FaultProfile) (result []api.FaultProfile, err error) {
+	err = server.eserverAdminRequest(http.MethodPost, "admin/faults", profile, &result)
+	return result, err
+}
+
+// EServerClearFaults removes all fault profiles from eserver
+func (server *EServer) EServerClearFaults() error {
+	var result []api.FaultProfile
+	return server.eserverAdminRequest(http.MethodDelete, "admin/faults", nil, &result)
+}
+
+// eserverAdminRequest sends request with (optional) JSON body into eserver
+// and decodes JSON response into out
+func (server *EServer) eserverAdminRequest(method, reqPath string, in, out interface{}) error {
+	u, err := utils.ResolveURL(server.url(), reqPath)
+	if err != nil {
+		return fmt.Errorf("error constructing URL: %w", err)
+	}
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("error encoding json: %w", err)
+		}
+		body = bytes.NewBuffer(data)
+	}
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return fmt.Errorf("unable to create new http request: %w", err)
+	}
+	client, err := server.getHTTPClient(defaults.DefaultRepeatTimeout * defaults.DefaultRepeatCount)
+	if err != nil {
+		return err
+	}
+	response, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send request: %w", err)
+	}
+	defer response.Body.Close()
+	buf, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read data from URL %s: %w", u, err)
+	}
+	if response.StatusCode == http.StatusNotFound && strings.TrimSpace(string(buf)) == "404 page not found" {
+		// no such route in eserver, unlike errors of known routes returned with message
+		return fmt.Errorf("%s %s is not supported by eserver, its image is probably too old: "+
+			"set eden.eserver.tag to %s and restart eserver", method, u, defaults.DefaultEServerTag)
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s failed: %s (%s)", method, u, response.Status,
+			strings.TrimSpace(string(buf)))
+	}
+	if err := json.Unmarshal(buf, out); err != nil {
+		return fmt.Errorf("unable to decode response: %w", err)
+	}
+	return nil
+}
+
 // ReadFileInSquashFS returns the content of a single file (filePath) inside squashfs (squashFSPath)
 func ReadFileInSquashFS(squashFSPath, filePath string) (content []byte, err error) {
 	tmpdir, err := os.MkdirTemp("", "squashfs-unpack")
@@ -74,7 +74,7 @@ func startQMPLogger(qmpSockFile string, qmpLogFile string) error {
 func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, imageFormat string, isInstaller bool,
 	qemuSMBIOSSerial string, eveTelnetPort, qemuMonitorPort, netDevBasePort int,
 	qemuHostFwd map[string]string, qemuAccel bool, qemuConfigFile, logFile, pidFile string,
-	netModel sdnapi.NetworkModel, withSDN bool, tapInterface, usbImagePath string,
+	netModel sdnapi.NetworkModel, withSDN bool, eveInstance, tapInterface, usbImagePath string,
 	swtpm, foreground bool) (err error) {
 	var qemuCommand, qemuOptions string
 	qemuOptions += "-nodefaults -no-user-config "
@@ -121,14 +121,24 @@ func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, imageFormat string, isInstalle
 		qemuOptions += fmt.Sprintf("-monitor tcp:localhost:%d,server,nowait  ", qemuMonitorPort)
 	}
 
+	var ethCount int
 	if withSDN {
 		// Ports connecting SDN VM with EVE VM.
-		socketPort := netDevBasePort
+		// SDN VM listens on a separate socket port for every port of the network model,
+		// connect only those ports which are assigned to this EVE instance.
 		for i, port := range netModel.Ports {
-			qemuOptions += fmt.Sprintf("-netdev socket,id=eth%d,connect=:%d", i, socketPort)
-			qemuOptions += fmt.Sprintf(" -device %s,netdev=eth%d,mac=%s ", netDev, i,
+			if port.EVEConnect.EVEInstance != eveInstance {
+				continue
+			}
+			socketPort := netDevBasePort + i
+			qemuOptions += fmt.Sprintf("-netdev socket,id=eth%d,connect=:%d", ethCount, socketPort)
+			qemuOptions += fmt.Sprintf(" -device %s,netdev=eth%d,mac=%s ", netDev, ethCount,
 				port.EVEConnect.MAC)
-			socketPort++
+			ethCount++
+		}
+		if ethCount == 0 {
+			return fmt.Errorf("StartEVEQemu: no port of the network model is connected "+
+				"to EVE instance %q", eveInstance)
 		}
 	} else {
 		// Use SLIRP networking to connect QEMU VM with the host.
@@ -165,11 +175,12 @@ func StartEVEQemu(qemuARCH, qemuOS, eveImageFile, imageFormat string, isInstalle
 			}
 			qemuOptions += fmt.Sprintf(" -device %s,netdev=eth%d,mac=%s ", netDev, i,
 				port.EVEConnect.MAC)
+			ethCount++
 		}
 	}
 
 	if tapInterface != "" {
-		tapIdx := len(netModel.Ports)
+		tapIdx := ethCount
 		qemuOptions += fmt.Sprintf("-netdev tap,id=eth%d,ifname=%s", tapIdx, tapInterface)
 		qemuOptions += fmt.Sprintf(" -device %s,netdev=eth%d ", netDev, tapIdx)
 	}
@@ -265,6 +276,32 @@ func StatusEVEQemu(pidFile string) (status string, err error) {
 	return utils.StatusCommandWithPid(pidFile)
 }
 
+// OtherEVEQemuRunning returns true if EVE VM of another Eden context is running.
+// Such EVE instance may be connected to the same SDN.
+// EVE pid files of all contexts are expected to be stored in the same directory.
+func OtherEVEQemuRunning(evePidFile string) bool {
+	evePidFile, err := filepath.Abs(evePidFile)
+	if err != nil {
+		log.Errorf("OtherEVEQemuRunning: %s", err)
+		return false
+	}
+	pidFiles, err := filepath.Glob(filepath.Join(filepath.Dir(evePidFile), "*eve.pid"))
+	if err != nil {
+		log.Errorf("OtherEVEQemuRunning: %s", err)
+		return false
+	}
+	for _, pidFile := range pidFiles {
+		if pidFile == evePidFile {
+			continue
+		}
+		status, err := StatusEVEQemu(pidFile)
+		if err == nil && strings.HasPrefix(status, "running") {
+			return true
+		}
+	}
+	return false
+}
+
 // SetLinkStateQemu changes the link state of the given interface.
 func SetLinkStateQemu(qemuMonitorPort int, ifName string, up bool) error {
 	tcpAddr, _ := net.ResolveTCPAddr("tcp", fmt.Sprintf("localhost:%d", qemuMonitorPort))
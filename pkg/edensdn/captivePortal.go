@@ -0,0 +1,24 @@
+package edensdn
+
+import (
+	"net/http"
+	"net/url"
+
+	model "github.com/lf-edge/eden/sdn/vm/api"
+)
+
+// LoginCaptivePortal : log client (IP or MAC address) into the captive portal,
+// i.e. stop intercepting traffic of the client.
+func (client *SdnClient) LoginCaptivePortal(portal, portalClient string) error {
+	return client.agentRequest(http.MethodPost,
+		"/captive-portal/"+url.PathEscape(portal)+"/login",
+		model.CaptivePortalLogin{Client: portalClient}, nil)
+}
+
+// LogoutCaptivePortal : log client (IP or MAC address) out of the captive portal,
+// i.e. resume intercepting traffic of the client.
+func (client *SdnClient) LogoutCaptivePortal(portal, portalClient string) error {
+	return client.agentRequest(http.MethodPost,
+		"/captive-portal/"+url.PathEscape(portal)+"/logout",
+		model.CaptivePortalLogin{Client: portalClient}, nil)
+}
@@ -0,0 +1,197 @@
+package edensdn
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	model "github.com/lf-edge/eden/sdn/vm/api"
+	"gopkg.in/yaml.v2"
+)
+
+// LoadChaosScenarioFromFile : load chaos scenario from a YAML (or JSON) file.
+// Field names are the same as in the JSON representation of model.ChaosScenario.
+func LoadChaosScenarioFromFile(filepath string) (scenario model.ChaosScenario, err error) {
+	content, err := os.ReadFile(filepath)
+	if err != nil {
+		err = fmt.Errorf("failed to read chaos scenario from file '%s': %w",
+			filepath, err)
+		return
+	}
+	// YAML is a superset of JSON. Convert YAML to JSON to reuse JSON tags
+	// and unmarshallers defined for the model.
+	var yamlContent interface{}
+	if err = yaml.Unmarshal(content, &yamlContent); err != nil {
+		err = fmt.Errorf("failed to parse chaos scenario from file '%s': %w",
+			filepath, err)
+		return
+	}
+	jsonContent, err := json.Marshal(yamlToJSONCompatible(yamlContent))
+	if err != nil {
+		err = fmt.Errorf("failed to convert chaos scenario from file '%s' to JSON: %w",
+			filepath, err)
+		return
+	}
+	if err = json.Unmarshal(jsonContent, &scenario); err != nil {
+		err = fmt.Errorf("failed to unmarshal chaos scenario from file '%s': %w",
+			filepath, err)
+		return
+	}
+	return
+}
+
+// yamlToJSONCompatible converts maps with interface{} keys (as produced by yaml.v2)
+// to maps with string keys, which can be marshalled to JSON.
+func yamlToJSONCompatible(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			m[fmt.Sprint(key)] = yamlToJSONCompatible(val)
+		}
+		return m
+	case []interface{}:
+		for i, val := range v {
+			v[i] = yamlToJSONCompatible(val)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// RunChaosScenario : submit chaos scenario to Eden-SDN to execute.
+// Previously running scenario (if any) is stopped.
+func (client *SdnClient) RunChaosScenario(scenario model.ChaosScenario) (err error) {
+	json, err := json.Marshal(scenario)
+	if err != nil {
+		err = fmt.Errorf("failed to marshal chaos scenario: %w", err)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPut,
+		fmt.Sprintf("http://localhost:%d/chaos.json", client.MgmtPort),
+		bytes.NewBuffer(json))
+	if err != nil {
+		err = fmt.Errorf("failed to build HTTP request: %w", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	httpClient := &http.Client{}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("request to PUT chaos scenario failed: %w", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var respBytes []byte
+		var response string
+		respBytes, err = io.ReadAll(resp.Body)
+		if err == nil {
+			response = string(respBytes)
+		} else {
+			response = fmt.Sprintf("failed to read response: %v", err)
+		}
+		err = fmt.Errorf("request to PUT chaos scenario failed with code=%d, "+
+			"response: %s", resp.StatusCode, response)
+		return
+	}
+	return
+}
+
+// GetChaosStatus : get status of the last chaos scenario submitted to Eden-SDN.
+func (client *SdnClient) GetChaosStatus() (status model.ChaosStatus, err error) {
+	req, err := http.NewRequest(http.MethodGet,
+		fmt.Sprintf("http://localhost:%d/chaos.json", client.MgmtPort), nil)
+	if err != nil {
+		err = fmt.Errorf("failed to build HTTP request: %w", err)
+		return
+	}
+	httpClient := &http.Client{}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("request to GET chaos status failed: %w", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("request to GET chaos status failed with resp: %s",
+			resp.Status)
+		return
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		err = fmt.Errorf("failed to read retrieved chaos status data: %w", err)
+		return
+	}
+	err = json.Unmarshal(data, &status)
+	if err != nil {
+		err = fmt.Errorf("failed to unmarshal retrieved chaos status data: %w", err)
+		return
+	}
+	return
+}
+
+// StopChaosScenario : stop running chaos scenario and revert all injected faults.
+func (client *SdnClient) StopChaosScenario() (err error) {
+	req, err := http.NewRequest(http.MethodDelete,
+		fmt.Sprintf("http://localhost:%d/chaos.json", client.MgmtPort), nil)
+	if err != nil {
+		err = fmt.Errorf("failed to build HTTP request: %w", err)
+		return
+	}
+	httpClient := &http.Client{}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("request to DELETE chaos scenario failed: %w", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("request to DELETE chaos scenario failed with resp: %s",
+			resp.Status)
+		return
+	}
+	return
+}
+
+// WaitForChaosScenario : wait for the running chaos scenario to finish.
+// Callback (optional) is called for every new event reported by Eden-SDN.
+// Zero timeout means to wait without time limit. If timeout expires,
+// the last obtained status is returned (with Running still set) and no error.
+func (client *SdnClient) WaitForChaosScenario(timeout time.Duration,
+	callback func(model.ChaosEvent)) (status model.ChaosStatus, err error) {
+	const pollPeriod = time.Second
+	var reported int
+	var deadline time.Time
+	if timeout != 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	for {
+		status, err = client.GetChaosStatus()
+		if err != nil {
+			return status, err
+		}
+		if reported > len(status.Events) {
+			// Scenario was replaced in the meantime.
+			reported = 0
+		}
+		if callback != nil {
+			for _, event := range status.Events[reported:] {
+				callback(event)
+			}
+		}
+		reported = len(status.Events)
+		if !status.Running {
+			return status, nil
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return status, nil
+		}
+		time.Sleep(pollPeriod)
+	}
+}
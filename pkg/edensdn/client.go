@@ -30,6 +30,9 @@ type SdnClient struct {
 	SSHPort    uint16
 	SSHKeyPath string
 	MgmtPort   uint16
+	// EVEInstance : logical label of the EVE instance, which methods like GetEveIfMAC
+	// and GetEveIfIP refer to. Empty if only one EVE instance is connected to SDN.
+	EVEInstance string
 }
 
 // GetNetworkModel : get network model currently applied to Eden-SDN.
@@ -272,12 +275,13 @@ func (client *SdnClient) GetEveIfMAC(eveIfName string) (mac string, err error) {
 	if err != nil {
 		return
 	}
-	if eveIfIndex < 0 || eveIfIndex >= len(netModel.Ports) {
+	evePorts := GetEVEPorts(netModel, client.EVEInstance)
+	if eveIfIndex < 0 || eveIfIndex >= len(evePorts) {
 		err = fmt.Errorf("EVE interface index is out-of-range: %d <%d-%d)",
-			eveIfIndex, 0, len(netModel.Ports))
+			eveIfIndex, 0, len(evePorts))
 		return
 	}
-	return netModel.Ports[eveIfIndex].EVEConnect.MAC, nil
+	return evePorts[eveIfIndex].EVEConnect.MAC, nil
 }
 
 // GetEveIfIP : get IP address assigned to the given EVE interface.
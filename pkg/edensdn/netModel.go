@@ -153,6 +153,18 @@ func generatePortMAC(logicalLabel string, sdnSide bool) string {
 	return hwAddr.String()
 }
 
+// GetEVEPorts returns ports of the network model connected to the given EVE instance.
+// Port order is preserved, i.e. the first returned port is connected to eth0
+// of the EVE instance, the second to eth1, etc.
+func GetEVEPorts(netModel sdnapi.NetworkModel, eveInstance string) (ports []sdnapi.Port) {
+	for _, port := range netModel.Ports {
+		if port.EVEConnect.EVEInstance == eveInstance {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
 // addMissingMACs generates and inserts MAC addresses into the model for ports
 // which were defined without MAC address included.
 func addMissingMACs(model *sdnapi.NetworkModel) {
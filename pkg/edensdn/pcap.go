@@ -0,0 +1,146 @@
+package edensdn
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"time"
+
+	model "github.com/lf-edge/eden/sdn/vm/api"
+	log "github.com/sirupsen/logrus"
+)
+
+// StartPacketCapture : start capturing packets inside Eden-SDN.
+// Returned status contains ID assigned to the capture.
+func (client *SdnClient) StartPacketCapture(capture model.PacketCapture) (
+	status model.PacketCaptureStatus, err error) {
+	err = client.agentRequest(http.MethodPost, "/pcap.json", capture, &status)
+	return
+}
+
+// ListPacketCaptures : list all packet captures started (and not yet removed)
+// inside Eden-SDN.
+func (client *SdnClient) ListPacketCaptures() (statuses []model.PacketCaptureStatus, err error) {
+	err = client.agentRequest(http.MethodGet, "/pcap.json", nil, &statuses)
+	return
+}
+
+// GetPacketCapture : get status of a packet capture.
+func (client *SdnClient) GetPacketCapture(id string) (status model.PacketCaptureStatus, err error) {
+	err = client.agentRequest(http.MethodGet, "/pcap/"+id, nil, &status)
+	return
+}
+
+// StopPacketCapture : stop packet capture. Captured packets are kept inside Eden-SDN
+// until the capture is removed.
+func (client *SdnClient) StopPacketCapture(id string) (status model.PacketCaptureStatus, err error) {
+	err = client.agentRequest(http.MethodPost, "/pcap/"+id+"/stop", nil, &status)
+	return
+}
+
+// RemovePacketCapture : stop packet capture (if still running) and remove the pcap file.
+func (client *SdnClient) RemovePacketCapture(id string) (err error) {
+	return client.agentRequest(http.MethodDelete, "/pcap/"+id, nil, nil)
+}
+
+// DownloadPacketCapture : stream pcap file of the given capture from Eden-SDN
+// into the writer. The file is transferred over SSH.
+// With follow enabled, packets are streamed as they are captured until
+// the capture stops.
+func (client *SdnClient) DownloadPacketCapture(id string, w io.Writer, follow bool) error {
+	status, err := client.GetPacketCapture(id)
+	if err != nil {
+		return err
+	}
+	if !follow || !status.Running {
+		command := exec.Command("ssh", client.sshArgs("cat", status.File)...)
+		command.Stdout = w
+		var stderr bytes.Buffer
+		command.Stderr = &stderr
+		if err = command.Run(); err != nil {
+			return fmt.Errorf("failed to download pcap file %s: %v (%s)",
+				status.File, err, stderr.String())
+		}
+		return nil
+	}
+	command := exec.Command("ssh", client.sshArgs("tail", "-c", "+1", "-f", status.File)...)
+	command.Stdout = w
+	if err = command.Start(); err != nil {
+		return fmt.Errorf("failed to stream pcap file %s: %v", status.File, err)
+	}
+	exited := make(chan error, 1)
+	go func() {
+		exited <- command.Wait()
+	}()
+	// Stream until the capture stops. Tail periodically checks for new data,
+	// give it some extra time to transfer the last captured packets.
+	const pollPeriod = time.Second
+	const lastDataCushion = 2 * time.Second
+	for {
+		select {
+		case err = <-exited:
+			return fmt.Errorf("streaming of pcap file %s stopped unexpectedly: %v",
+				status.File, err)
+		case <-time.After(pollPeriod):
+		}
+		status, err = client.GetPacketCapture(id)
+		if err != nil {
+			log.Warnf("failed to get status of packet capture %s: %v", id, err)
+			continue
+		}
+		if !status.Running {
+			break
+		}
+	}
+	time.Sleep(lastDataCushion)
+	if err = command.Process.Kill(); err != nil {
+		log.Errorf("failed to kill %s: %v", command, err)
+	}
+	<-exited
+	return nil
+}
+
+// agentRequest sends request (JSON-encoded reqBody, if not nil) to the SDN agent
+// and decodes JSON response into respBody (if not nil).
+func (client *SdnClient) agentRequest(method, path string, reqBody, respBody interface{}) error {
+	var body io.Reader
+	if reqBody != nil {
+		reqJSON, err := json.Marshal(reqBody)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewBuffer(reqJSON)
+	}
+	req, err := http.NewRequest(method,
+		fmt.Sprintf("http://localhost:%d%s", client.MgmtPort, path), body)
+	if err != nil {
+		return fmt.Errorf("failed to build HTTP request: %w", err)
+	}
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	httpClient := &http.Client{}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response to %s %s: %w", method, path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %s %s failed with code=%d, response: %s",
+			method, path, resp.StatusCode, string(bytes.TrimSpace(data)))
+	}
+	if respBody != nil {
+		if err = json.Unmarshal(data, respBody); err != nil {
+			return fmt.Errorf("failed to unmarshal response to %s %s: %w",
+				method, path, err)
+		}
+	}
+	return nil
+}
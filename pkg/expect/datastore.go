@@ -34,8 +34,13 @@ func (exp *AppExpectation) createDataStore() (*config.DatastoreConfig, error) {
 	case dockerApp:
 		return exp.createDataStoreDocker(id), nil
 	case httpApp, httpsApp, fileApp:
-		if exp.sftpLoad {
+		switch exp.datastoreType {
+		case DatastoreSFTP:
 			return exp.createDataStoreSFTP(id), nil
+		case DatastoreS3:
+			return exp.createDataStoreS3(id), nil
+		case DatastoreAzure:
+			return exp.createDataStoreAzure(id), nil
 		}
 		return exp.createDataStoreHTTP(id), nil
 	case directoryApp:
@@ -75,7 +75,7 @@ type AppExpectation struct {
 	oldAppName string
 
 	httpDirectLoad bool // use eserver for SHA calculation only
-	sftpLoad       bool
+	datastoreType  DatastoreType
 
 	disks []string
 	acl   ACLs
@@ -138,6 +138,7 @@ func AppExpectationFromURL(ctrl controller.Cloud, device *device.Ctx, appLink st
 		uplinkAdapter: adapter,
 		device:        device,
 		volumesType:   VolumeQcow2,
+		datastoreType: DatastoreHTTP,
 	}
 	switch expectation.ctrl.GetVars().ZArch {
 	case "amd64":
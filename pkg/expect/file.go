@@ -4,8 +4,6 @@ import (
 	"path/filepath"
 
 	"github.com/dustin/go-humanize"
-	"github.com/lf-edge/eden/pkg/defaults"
-	"github.com/lf-edge/eden/pkg/eden"
 	"github.com/lf-edge/eden/pkg/utils"
 	"github.com/lf-edge/eve-api/go/config"
 	uuid "github.com/satori/go.uuid"
@@ -14,10 +12,7 @@ import (
 
 // createImageFile uploads image into EServer from file and calculates size and sha256 of image
 func (exp *AppExpectation) createImageFile(id uuid.UUID, dsID string) *config.Image {
-	server := &eden.EServer{
-		EServerIP:   exp.ctrl.GetVars().EServerIP,
-		EServerPort: exp.ctrl.GetVars().EServerPort,
-	}
+	server := exp.eserverClient()
 	var fileSize int64
 	sha256 := ""
 	filePath := ""
@@ -36,9 +31,7 @@ func (exp *AppExpectation) createImageFile(id uuid.UUID, dsID string) *config.Im
 	if filePath == "" {
 		log.Fatal("Not uploaded")
 	}
-	if exp.sftpLoad {
-		filePath = filepath.Join(defaults.DefaultSFTPDirPrefix, filePath)
-	}
+	filePath = exp.imageNameInDatastore(filePath)
 	return &config.Image{
 		Uuidandversion: &config.UUIDandVersion{
 			Uuid:    id.String(),
@@ -3,12 +3,14 @@ package expect
 import (
 	"fmt"
 	"net/url"
+	"os"
 	"path"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/dustin/go-humanize"
+	"github.com/lf-edge/eden/eserver/api"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/eden"
 	"github.com/lf-edge/eve-api/go/config"
@@ -19,10 +21,7 @@ import (
 // createImageHTTP downloads image into EServer directory from http/https endpoint and calculates size and sha256 of image
 func (exp *AppExpectation) createImageHTTP(id uuid.UUID, dsID string) *config.Image {
 	log.Infof("Starting download of image from %s", exp.appLink)
-	server := &eden.EServer{
-		EServerIP:   exp.ctrl.GetVars().EServerIP,
-		EServerPort: exp.ctrl.GetVars().EServerPort,
-	}
+	server := exp.eserverClient()
 	var fileSize int64
 	sha256 := ""
 	filePath := ""
@@ -37,8 +36,11 @@ func (exp *AppExpectation) createImageHTTP(id uuid.UUID, dsID string) *config.Im
 
 		for {
 			status := server.EServerCheckStatus(name)
+			if status.Error != "" {
+				log.Fatalf("Download of %s into eserver failed: %s", exp.appLink, status.Error)
+			}
 			if !status.ISReady {
-				log.Infof("Downloading... Ready %s", humanize.Bytes(uint64(status.Size)))
+				log.Infof("Downloading... %s", downloadProgress(status))
 			} else {
 				sha256 = status.Sha256
 				fileSize = status.Size
@@ -52,8 +54,8 @@ func (exp *AppExpectation) createImageHTTP(id uuid.UUID, dsID string) *config.Im
 			log.Fatal("Not downloaded")
 		}
 	}
-	if exp.sftpLoad {
-		filePath = filepath.Join(defaults.DefaultSFTPDirPrefix, filePath)
+	if exp.datastoreType != DatastoreHTTP {
+		filePath = exp.imageNameInDatastore(filePath)
 	} else if exp.httpDirectLoad {
 		u, err := url.Parse(exp.appLink)
 		if err != nil {
@@ -74,6 +76,66 @@ func (exp *AppExpectation) createImageHTTP(id uuid.UUID, dsID string) *config.Im
 	}
 }
 
+// downloadProgress returns human-readable progress of download into eserver
+func downloadProgress(status *api.FileInfo) string {
+	progress := fmt.Sprintf("Ready %s", humanize.Bytes(uint64(status.Size)))
+	if status.TotalSize > 0 {
+		progress += fmt.Sprintf(" of %s (%d%%)", humanize.Bytes(uint64(status.TotalSize)),
+			status.Size*100/status.TotalSize)
+	}
+	if status.ETASeconds > 0 {
+		progress += fmt.Sprintf(", ETA %s", time.Duration(status.ETASeconds)*time.Second)
+	}
+	return progress
+}
+
+// eserverClient returns client of EServer to manage its files
+func (exp *AppExpectation) eserverClient() *eden.EServer {
+	vars := exp.ctrl.GetVars()
+	return &eden.EServer{
+		EServerIP:            vars.EServerIP,
+		EServerPort:          vars.EServerPort,
+		EServerHTTPS:         vars.EServerHTTPS,
+		EServerCA:            vars.AdamCA,
+		EServerAdminUser:     vars.EServerAdminUser,
+		EServerAdminPassword: vars.EServerAdminPass,
+	}
+}
+
+// eserverFQDN returns address of EServer http endpoint for EVE
+func (exp *AppExpectation) eserverFQDN() string {
+	scheme := "http"
+	if exp.ctrl.GetVars().EServerHTTPS {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%s", scheme, exp.ctrl.GetVars().AdamDomain, exp.ctrl.GetVars().EServerPort)
+}
+
+// eserverCertPEM returns certificates for EVE to verify EServer http endpoint
+// (CA of eden signing certificate of EServer) or nil if EServer uses plain http
+func (exp *AppExpectation) eserverCertPEM() [][]byte {
+	if !exp.ctrl.GetVars().EServerHTTPS {
+		return nil
+	}
+	caCert, err := os.ReadFile(exp.ctrl.GetVars().AdamCA)
+	if err != nil {
+		log.Fatalf("cannot read CA certificate of eserver: %s", err)
+	}
+	return [][]byte{caCert}
+}
+
+// imageNameInDatastore returns name of image in datastore of eserver
+// for file name returned by eserver ("eserver/<name>")
+func (exp *AppExpectation) imageNameInDatastore(fileName string) string {
+	switch exp.datastoreType {
+	case DatastoreSFTP:
+		return filepath.Join(defaults.DefaultSFTPDirPrefix, fileName)
+	case DatastoreS3, DatastoreAzure:
+		return strings.TrimPrefix(fileName, defaults.DefaultEServerBucket+"/")
+	}
+	return fileName
+}
+
 // checkImageHTTP checks if provided img match expectation
 func (exp *AppExpectation) checkImageHTTP(img *config.Image, dsID string) bool {
 	if img.DsId == dsID && img.Name == path.Join("eserver", path.Base(exp.appURL)) && img.Iformat == config.Format_QCOW2 {
@@ -84,12 +146,15 @@ func (exp *AppExpectation) checkImageHTTP(img *config.Image, dsID string) bool {
 
 // checkDataStoreHTTP checks if provided ds match expectation
 func (exp *AppExpectation) checkDataStoreHTTP(ds *config.DatastoreConfig) bool {
-	if exp.sftpLoad && ds.DType == config.DsType_DsSFTP {
+	if exp.datastoreType == DatastoreS3 || exp.datastoreType == DatastoreAzure {
+		return exp.checkDataStoreObjectStorage(ds)
+	}
+	if exp.datastoreType == DatastoreSFTP && ds.DType == config.DsType_DsSFTP {
 		if ds.Fqdn == fmt.Sprintf("%s:%s", exp.ctrl.GetVars().AdamDomain, exp.ctrl.GetVars().EServerPort) {
 			return true
 		}
 	} else if ds.DType == config.DsType_DsHttp || ds.DType == config.DsType_DsHttps {
-		if !exp.httpDirectLoad && ds.Fqdn == fmt.Sprintf("http://%s:%s", exp.ctrl.GetVars().AdamDomain, exp.ctrl.GetVars().EServerPort) {
+		if !exp.httpDirectLoad && ds.Fqdn == exp.eserverFQDN() {
 			return true
 		}
 		u, err := url.Parse(exp.appLink)
@@ -121,7 +186,7 @@ func (exp *AppExpectation) createDataStoreSFTP(id uuid.UUID) *config.DatastoreCo
 	return ds
 }
 
-// createDataStoreHTTP creates datastore, pointed onto EServer http endpoint
+// createDataStoreHTTP creates datastore, pointed onto EServer http (or https) endpoint
 func (exp *AppExpectation) createDataStoreHTTP(id uuid.UUID) *config.DatastoreConfig {
 	ds := &config.DatastoreConfig{
 		Id:         id.String(),
@@ -146,7 +211,14 @@ func (exp *AppExpectation) createDataStoreHTTP(id uuid.UUID) *config.DatastoreCo
 		// we want to preserve it.
 		ds.Fqdn = fmt.Sprintf("%s://%s", u.Scheme, u.Host)
 	} else {
-		ds.Fqdn = fmt.Sprintf("http://%s:%s", exp.ctrl.GetVars().AdamDomain, exp.ctrl.GetVars().EServerPort)
+		// credentials are moved into cipher block if device supports encryption
+		ds.Fqdn = exp.eserverFQDN()
+		ds.ApiKey = exp.ctrl.GetVars().EServerUser
+		ds.Password = exp.ctrl.GetVars().EServerPassword
+		ds.DsCertPEM = exp.eserverCertPEM()
+		if exp.ctrl.GetVars().EServerHTTPS {
+			ds.DType = config.DsType_DsHttps
+		}
 	}
 	return ds
 }
@@ -0,0 +1,62 @@
+package expect
+
+import (
+	"fmt"
+
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eve-api/go/config"
+	uuid "github.com/satori/go.uuid"
+)
+
+// objectStorageFQDN returns address of S3 or Azure Blob emulation of EServer
+func (exp *AppExpectation) objectStorageFQDN() string {
+	fqdn := exp.eserverFQDN()
+	if exp.datastoreType == DatastoreAzure {
+		// path-style URL of storage account as used by storage emulators
+		fqdn = fmt.Sprintf("%s/%s", fqdn, exp.ctrl.GetVars().EServerAzureAcc)
+	}
+	if exp.datastoreOverride != "" {
+		fqdn = exp.datastoreOverride
+	}
+	return fqdn
+}
+
+// checkDataStoreObjectStorage checks if provided ds match expectation
+func (exp *AppExpectation) checkDataStoreObjectStorage(ds *config.DatastoreConfig) bool {
+	dsType := config.DsType_DsS3
+	if exp.datastoreType == DatastoreAzure {
+		dsType = config.DsType_DsAzureBlob
+	}
+	return ds.DType == dsType && ds.Fqdn == exp.objectStorageFQDN() &&
+		ds.Dpath == defaults.DefaultEServerBucket
+}
+
+// createDataStoreS3 creates datastore, pointed onto EServer S3 emulation
+func (exp *AppExpectation) createDataStoreS3(id uuid.UUID) *config.DatastoreConfig {
+	return &config.DatastoreConfig{
+		Id:         id.String(),
+		DType:      config.DsType_DsS3,
+		ApiKey:     exp.ctrl.GetVars().EServerS3Key,
+		Password:   exp.ctrl.GetVars().EServerS3Secret,
+		Fqdn:       exp.objectStorageFQDN(),
+		Dpath:      defaults.DefaultEServerBucket,
+		Region:     defaults.DefaultS3Region,
+		DsCertPEM:  exp.eserverCertPEM(),
+		CipherData: nil,
+	}
+}
+
+// createDataStoreAzure creates datastore, pointed onto EServer Azure Blob emulation
+func (exp *AppExpectation) createDataStoreAzure(id uuid.UUID) *config.DatastoreConfig {
+	return &config.DatastoreConfig{
+		Id:         id.String(),
+		DType:      config.DsType_DsAzureBlob,
+		ApiKey:     exp.ctrl.GetVars().EServerAzureAcc,
+		Password:   exp.ctrl.GetVars().EServerAzureKey,
+		Fqdn:       exp.objectStorageFQDN(),
+		Dpath:      defaults.DefaultEServerBucket,
+		Region:     "",
+		DsCertPEM:  exp.eserverCertPEM(),
+		CipherData: nil,
+	}
+}
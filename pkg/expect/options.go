@@ -1,6 +1,7 @@
 package expect
 
 import (
+	"fmt"
 	"os"
 	"strings"
 
@@ -35,6 +36,31 @@ var VolumeISO VolumeType = "iso"
 // VolumeNone use no volumes
 var VolumeNone VolumeType = "none"
 
+// DatastoreType defines type of datastore used to serve images from eserver
+type DatastoreType string
+
+// DatastoreHTTP serves images from eserver via HTTP
+var DatastoreHTTP DatastoreType = "http"
+
+// DatastoreSFTP serves images from eserver via SFTP
+var DatastoreSFTP DatastoreType = "sftp"
+
+// DatastoreS3 serves images from S3 emulation of eserver
+var DatastoreS3 DatastoreType = "s3"
+
+// DatastoreAzure serves images from Azure Blob emulation of eserver
+var DatastoreAzure DatastoreType = "azure"
+
+// DatastoreTypeByName returns DatastoreType by name
+func DatastoreTypeByName(name string) (DatastoreType, error) {
+	for _, dsType := range []DatastoreType{DatastoreHTTP, DatastoreSFTP, DatastoreS3, DatastoreAzure} {
+		if name == string(dsType) {
+			return dsType, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported datastore type %q (expected http, sftp, s3 or azure)", name)
+}
+
 // VolumeTypeByName returns VolumeType by name
 func VolumeTypeByName(name string) VolumeType {
 	switch name {
@@ -244,7 +270,18 @@ func WithHTTPDirectLoad(direct bool) ExpectationOption {
 // WithSFTPLoad force eserver to serve image via sftp
 func WithSFTPLoad(sftp bool) ExpectationOption {
 	return func(expectation *AppExpectation) {
-		expectation.sftpLoad = sftp
+		if sftp {
+			expectation.datastoreType = DatastoreSFTP
+		} else if expectation.datastoreType == DatastoreSFTP {
+			expectation.datastoreType = DatastoreHTTP
+		}
+	}
+}
+
+// WithDatastoreType sets type of datastore used to serve image from eserver
+func WithDatastoreType(dsType DatastoreType) ExpectationOption {
+	return func(expectation *AppExpectation) {
+		expectation.datastoreType = dsType
 	}
 }
 
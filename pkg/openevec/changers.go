@@ -11,6 +11,7 @@ import (
 	"github.com/lf-edge/eden/pkg/projects"
 	"github.com/lf-edge/eve-api/go/config"
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 )
 
 type configChanger interface {
@@ -40,7 +41,8 @@ func changerByControllerMode(controllerMode string) (configChanger, error) {
 		changer = &fileChanger{fileConfig: modeURL}
 	case "adam":
 		changer = &adamChanger{adamURL: modeURL}
-
+	case "rest":
+		changer = &restChanger{restURL: modeURL}
 	default:
 		return nil, fmt.Errorf("not implemented type: %s", modeType)
 	}
@@ -156,3 +158,54 @@ func (ctx *adamChanger) setControllerAndDev(ctrl controller.Cloud, dev *device.C
 	}
 	return nil
 }
+
+type restChanger struct {
+	restURL string
+}
+
+func (ctx *restChanger) getController() (controller.Cloud, error) {
+	if ctx.restURL != "" {
+		viper.Set("rest.url", fmt.Sprintf("https://%s", ctx.restURL))
+	}
+	ctrl, err := controller.CloudPrepareWithType("rest")
+	if err != nil {
+		return nil, fmt.Errorf("CloudPrepareWithType error: %w", err)
+	}
+	return ctrl, nil
+}
+
+func (ctx *restChanger) getControllerAndDev() (controller.Cloud, *device.Ctx, error) {
+	ctrl, err := ctx.getController()
+	if err != nil {
+		return nil, nil, fmt.Errorf("getController error: %w", err)
+	}
+	devFirst, err := ctrl.GetDeviceCurrent()
+	if err != nil {
+		return nil, nil, fmt.Errorf("GetDeviceCurrent error: %w", err)
+	}
+	return ctrl, devFirst, nil
+}
+
+func (ctx *restChanger) getControllerAndDevFromConfig(cfg *EdenSetupArgs) (controller.Cloud, *device.Ctx, error) {
+	ctrl, err := ctx.getController()
+	if err != nil {
+		return nil, nil, fmt.Errorf("getController error: %w", err)
+	}
+	vars, err := InitVarsFromConfig(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("InitVarsFromConfig error: %w", err)
+	}
+	ctrl.SetVars(vars)
+	devFirst, err := ctrl.GetDeviceCurrent()
+	if err != nil {
+		return nil, nil, fmt.Errorf("GetDeviceCurrent error: %w", err)
+	}
+	return ctrl, devFirst, nil
+}
+
+func (ctx *restChanger) setControllerAndDev(ctrl controller.Cloud, dev *device.Ctx) error {
+	if err := ctrl.ConfigSync(dev); err != nil {
+		return fmt.Errorf("configSync error: %w", err)
+	}
+	return nil
+}
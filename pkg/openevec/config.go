@@ -21,6 +21,18 @@ type EServerConfig struct {
 	Tag    string       `mapstructure:"tag" cobraflag:"eserver-tag"`
 	IP     string       `mapstructure:"ip"`
 	Images ImagesConfig `mapstructure:"images"`
+
+	HTTPS         bool   `mapstructure:"https" cobraflag:"eserver-https"`
+	User          string `mapstructure:"user"`
+	Password      string `mapstructure:"password"`
+	Token         string `mapstructure:"token"`
+	AdminUser     string `mapstructure:"admin-user"`
+	AdminPassword string `mapstructure:"admin-password"`
+
+	S3AccessKey  string `mapstructure:"s3-access-key"`
+	S3SecretKey  string `mapstructure:"s3-secret-key"`
+	AzureAccount string `mapstructure:"azure-account"`
+	AzureKey     string `mapstructure:"azure-key"`
 }
 
 type ImagesConfig struct {
@@ -73,6 +85,7 @@ type AdamConfig struct {
 	CertsEVEIP  string `mapstructure:"eve-ip" cobraflag:"eve-ip"`
 	APIv1       bool   `mapstructure:"v1" cobrafalg:"force"`
 	Force       bool   `mapstructure:"force" cobraflag:"force"`
+	Replay      string `mapstructure:"replay" resolvepath:""`
 
 	Redis   RedisConfig   `mapstructure:"redis"`
 	Remote  RemoteConfig  `mapstructure:"remote"`
@@ -165,6 +178,7 @@ type SdnConfig struct {
 	MgmtPort       int    `mapstructure:"mgmt-port" cobraflag:"sdn-mgmt-port"`
 	PidFile        string `mapstructure:"pid" cobraflag:"sdn-pid" resolvepath:""`
 	SSHPort        int    `mapstructure:"ssh-port" cobraflag:"sdn-ssh-port"`
+	EVEInstance    string `mapstructure:"eve-instance"`
 }
 
 type EdenSetupArgs struct {
@@ -206,6 +220,7 @@ type PodConfig struct {
 	PinCpus           bool
 	ImageFormat       string
 	SftpLoad          bool
+	DatastoreType     string
 	DirectLoad        bool
 	OpenStackMetadata bool
 	DatastoreOverride string
@@ -27,6 +27,11 @@ func GetDefaultConfig() *EdenSetupArgs {
 				Port:  defaults.DefaultEserverPort,
 				Force: false,
 				Tag:   defaults.DefaultEServerTag,
+
+				S3AccessKey:  defaults.DefaultS3AccessKey,
+				S3SecretKey:  defaults.DefaultS3SecretKey,
+				AzureAccount: defaults.DefaultAzureAccount,
+				AzureKey:     defaults.DefaultAzureKey,
 			},
 		},
 
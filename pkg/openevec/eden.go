@@ -8,6 +8,7 @@ import (
 	"html/template"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"os/exec"
 	"path"
@@ -15,11 +16,14 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/lf-edge/eden/pkg/controller"
 	"github.com/lf-edge/eden/pkg/controller/eflowlog"
 	"github.com/lf-edge/eden/pkg/controller/einfo"
 	"github.com/lf-edge/eden/pkg/controller/elog"
 	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/controller/equery"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/eden"
@@ -197,17 +201,18 @@ func setupEve(netboot, installer bool, softSerial, ipxeOverride string, cfg Eden
 		if err := utils.DownloadEveNetBoot(eveDesc, filepath.Dir(cfg.Eve.ImageFile)); err != nil {
 			return fmt.Errorf("cannot download EVE: %w", err)
 		}
-		if err := eden.StartEServer(cfg.Eden.EServer.Port, cfg.Eden.EServer.Images.EServerImageDist, cfg.Eden.EServer.Force, cfg.Eden.EServer.Tag); err != nil {
+		if cfg.Eden.EServer.HTTPS || cfg.Eden.EServer.User != "" || cfg.Eden.EServer.Token != "" {
+			log.Warn("ipxe loads EVE from eserver over http without authorization, disable https and credentials of eserver for netboot")
+		}
+		if err := eden.StartEServer(cfg.Eden.EServer.Port, cfg.Eden.EServer.Images.EServerImageDist, cfg.Eden.EServer.Force, cfg.Eden.EServer.Tag,
+			cfg.Eden.EServer.HTTPS, eserverEnvs(&cfg.Eden.EServer)...); err != nil {
 			log.Errorf("cannot start eserver: %s", err.Error())
 		} else {
 			log.Infof("Eserver is running and accessible on port %d", cfg.Eden.EServer.Port)
 		}
 		eServerIP := cfg.Adam.CertsEVEIP
 		eServerPort := strconv.Itoa(cfg.Eden.EServer.Port)
-		server := &eden.EServer{
-			EServerIP:   eServerIP,
-			EServerPort: eServerPort,
-		}
+		server := newEServerClient(&cfg.Eden.EServer, eServerIP)
 		// we should uncompress kernel for arm64
 		if cfg.Eve.Arch == "arm64" {
 			// rename to temp file
@@ -527,17 +532,68 @@ func (openEVEC *OpenEVEC) EdenClean(configName, configDist, vmName string, curre
 	return nil
 }
 
-func (openEVEC *OpenEVEC) EdenInfo(outputFormat types.OutputFormat, infoTail uint, follow bool, printFields []string, args []string) error {
+// sinceBoot is a value of since to get objects from the last boot of EVE
+const sinceBoot = "boot"
+
+// buildQuery makes query from field:regexp arguments, expression and time range
+func buildQuery(ctrl controller.Cloud, devUUID uuid.UUID, args []string, expr, since, until string) (map[string]string, error) {
+	return buildQueryWithInfo(func(q map[string]string, handler einfo.HandlerFunc) error {
+		return ctrl.InfoLastCallback(devUUID, q, handler)
+	}, args, expr, since, until)
+}
+
+// buildQueryWithInfo makes query from field:regexp arguments, expression and time range
+// using infoLast to find the last boot of EVE in info messages
+func buildQueryWithInfo(infoLast infoLastFunc, args []string, expr, since, until string) (map[string]string, error) {
+	q, err := equery.FromArgs(args, expr)
+	if err != nil {
+		return nil, err
+	}
+	if since == sinceBoot {
+		bootTime, err := lastBootTime(infoLast)
+		if err != nil {
+			return nil, err
+		}
+		since = bootTime.Format(time.RFC3339Nano)
+	}
+	if err := equery.AddTimeRange(q, since, until); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// infoLastFunc processes existing info messages matching q with handler
+type infoLastFunc func(q map[string]string, handler einfo.HandlerFunc) error
+
+// lastBootTime returns the latest boot time of EVE reported in info messages
+func lastBootTime(infoLast infoLastFunc) (time.Time, error) {
+	var bootTime time.Time
+	handler := func(im *info.ZInfoMsg) bool {
+		if t := im.GetDinfo().GetLastRebootTime(); t != nil && t.AsTime().After(bootTime) {
+			bootTime = t.AsTime()
+		}
+		return false
+	}
+	q := map[string]string{equery.ExprKey: "dinfo.lastRebootTime"}
+	if err := infoLast(q, handler); err != nil {
+		return bootTime, fmt.Errorf("InfoLastCallback: %w", err)
+	}
+	if bootTime.IsZero() {
+		return bootTime, fmt.Errorf("no info about boot time of EVE")
+	}
+	return bootTime, nil
+}
+
+func (openEVEC *OpenEVEC) EdenInfo(outputFormat types.OutputFormat, infoTail uint, follow bool, printFields []string, query, since, until string, args []string) error {
 	changer := &adamChanger{}
 	ctrl, devFirst, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
 		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
 	}
 	devUUID := devFirst.GetID()
-	q := make(map[string]string)
-	for _, a := range args[0:] {
-		s := strings.Split(a, ":")
-		q[s[0]] = s[1]
+	q, err := buildQuery(ctrl, devUUID, args, query, since, until)
+	if err != nil {
+		return fmt.Errorf("incorrect query: %w", err)
 	}
 
 	handleInfo := func(im *info.ZInfoMsg) bool {
@@ -566,7 +622,7 @@ func (openEVEC *OpenEVEC) EdenInfo(outputFormat types.OutputFormat, infoTail uin
 	return nil
 }
 
-func (openEVEC *OpenEVEC) EdenLog(outputFormat types.OutputFormat, follow bool, logTail uint, printFields, args []string) error {
+func (openEVEC *OpenEVEC) EdenLog(outputFormat types.OutputFormat, follow bool, logTail uint, printFields []string, query, since, until string, args []string) error {
 	changer := &adamChanger{}
 	ctrl, devFirst, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
@@ -574,11 +630,9 @@ func (openEVEC *OpenEVEC) EdenLog(outputFormat types.OutputFormat, follow bool,
 	}
 	devUUID := devFirst.GetID()
 
-	q := make(map[string]string)
-
-	for _, a := range args[0:] {
-		s := strings.Split(a, ":")
-		q[s[0]] = s[1]
+	q, err := buildQuery(ctrl, devUUID, args, query, since, until)
+	if err != nil {
+		return fmt.Errorf("incorrect query: %w", err)
 	}
 
 	handleFunc := func(le *elog.FullLogEntry) bool {
@@ -609,7 +663,7 @@ func (openEVEC *OpenEVEC) EdenLog(outputFormat types.OutputFormat, follow bool,
 	return nil
 }
 
-func (openEVEC *OpenEVEC) EdenNetStat(outputFormat types.OutputFormat, follow bool, logTail uint, printFields, args []string) error {
+func (openEVEC *OpenEVEC) EdenNetStat(outputFormat types.OutputFormat, follow bool, logTail uint, printFields []string, query, since, until string, args []string) error {
 	changer := &adamChanger{}
 	ctrl, devFirst, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
@@ -617,11 +671,9 @@ func (openEVEC *OpenEVEC) EdenNetStat(outputFormat types.OutputFormat, follow bo
 	}
 	devUUID := devFirst.GetID()
 
-	q := make(map[string]string)
-
-	for _, a := range args[0:] {
-		s := strings.Split(a, ":")
-		q[s[0]] = s[1]
+	q, err := buildQuery(ctrl, devUUID, args, query, since, until)
+	if err != nil {
+		return fmt.Errorf("incorrect query: %w", err)
 	}
 
 	handleFunc := func(le *flowlog.FlowMessage) bool {
@@ -652,7 +704,7 @@ func (openEVEC *OpenEVEC) EdenNetStat(outputFormat types.OutputFormat, follow bo
 	return nil
 }
 
-func (openEVEC *OpenEVEC) EdenMetric(outputFormat types.OutputFormat, follow bool, metricTail uint, printFields, args []string) error {
+func (openEVEC *OpenEVEC) EdenMetric(outputFormat types.OutputFormat, follow bool, metricTail uint, printFields []string, query, since, until string, args []string) error {
 	changer := &adamChanger{}
 	ctrl, devFirst, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
 	if err != nil {
@@ -660,11 +712,9 @@ func (openEVEC *OpenEVEC) EdenMetric(outputFormat types.OutputFormat, follow boo
 	}
 	devUUID := devFirst.GetID()
 
-	q := make(map[string]string)
-
-	for _, a := range args[0:] {
-		s := strings.Split(a, ":")
-		q[s[0]] = s[1]
+	q, err := buildQuery(ctrl, devUUID, args, query, since, until)
+	if err != nil {
+		return fmt.Errorf("incorrect query: %w", err)
 	}
 
 	handleFunc := func(le *metrics.ZMetricMsg) bool {
@@ -695,6 +745,58 @@ func (openEVEC *OpenEVEC) EdenMetric(outputFormat types.OutputFormat, follow boo
 	return nil
 }
 
+// EdenMetricExport serves the last metrics of the device (or of all registered
+// devices if allDevices is set) on listenAddr in Prometheus text format
+func (openEVEC *OpenEVEC) EdenMetricExport(listenAddr string, allDevices bool, query, since, until string, args []string) error {
+	changer := &adamChanger{}
+	ctrl, devFirst, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	devUUIDs := []uuid.UUID{devFirst.GetID()}
+	if allDevices {
+		devIDs, err := ctrl.DeviceList(types.RegisteredDeviceFilter)
+		if err != nil {
+			return fmt.Errorf("DeviceList: %w", err)
+		}
+		devUUIDs = devUUIDs[:0]
+		for _, devID := range devIDs {
+			devUUID, err := uuid.FromString(devID)
+			if err != nil {
+				return fmt.Errorf("incorrect device UUID %s: %w", devID, err)
+			}
+			devUUIDs = append(devUUIDs, devUUID)
+		}
+	}
+
+	exporter := emetric.NewExporter()
+	errs := make(chan error, len(devUUIDs)+1)
+	for _, devUUID := range devUUIDs {
+		q, err := buildQuery(ctrl, devUUID, args, query, since, until)
+		if err != nil {
+			return fmt.Errorf("incorrect query: %w", err)
+		}
+		if err = ctrl.MetricLastCallback(devUUID, q, exporter.Handler()); err != nil {
+			return fmt.Errorf("MetricLastCallback: %w", err)
+		}
+		go func(devUUID uuid.UUID) {
+			// Monitoring of new files
+			if err := ctrl.MetricChecker(devUUID, q, exporter.Handler(), emetric.MetricNew, 0); err != nil {
+				errs <- fmt.Errorf("MetricChecker for %s: %w", devUUID, err)
+			}
+		}(devUUID)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter.HTTPHandler())
+	server := &http.Server{Addr: listenAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+	log.Infof("Serving metrics of %d device(s) on http://%s/metrics", len(devUUIDs), listenAddr)
+	return <-errs
+}
+
 func (openEVEC *OpenEVEC) EdenExport(tarFile string) error {
 	cfg := openEVEC.cfg
 	changer := &adamChanger{}
@@ -54,6 +54,27 @@ func ReloadConfigDetails(cfg *EdenSetupArgs) error {
 	return nil
 }
 
+// FindEveInstanceConfig returns name of the config (context) which manages EVE instance
+// with the given logical label (see sdn.eve-instance).
+func FindEveInstanceConfig(eveInstance string) (string, error) {
+	context, err := utils.ContextLoad()
+	if err != nil {
+		return "", fmt.Errorf("load context error: %w", err)
+	}
+	for _, configName := range context.ListContexts() {
+		localViper := viper.New()
+		localViper.SetConfigFile(utils.GetConfig(configName))
+		if err := localViper.ReadInConfig(); err != nil {
+			log.Debugf("failed to read config %s: %v", configName, err)
+			continue
+		}
+		if localViper.GetString("sdn.eve-instance") == eveInstance {
+			return configName, nil
+		}
+	}
+	return "", fmt.Errorf("no config found for EVE instance %s", eveInstance)
+}
+
 func ConfigAdd(cfg *EdenSetupArgs, currentContext, contextFile string, force bool) error {
 	var err error
 	if cfg.ConfigFile == "" {
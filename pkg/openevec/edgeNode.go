@@ -314,6 +314,9 @@ func (openEVEC *OpenEVEC) EdgeNodeSetConfig(fileWithConfig string) error {
 	if err = ctrl.ConfigSet(devUUID, cfg); err != nil {
 		return fmt.Errorf("ConfigSet: %w", err)
 	}
+	if err = controller.SaveConfigHistory(devUUID, cfg); err != nil {
+		log.Warnf("cannot save config history: %s", err)
+	}
 	log.Info("Config loaded")
 	return nil
 }
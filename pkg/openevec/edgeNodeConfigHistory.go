@@ -0,0 +1,136 @@
+package openevec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/pmezard/go-difflib/difflib"
+	log "github.com/sirupsen/logrus"
+)
+
+// EdgeNodeConfigHistory prints configs pushed to device from the oldest to the newest
+func (openEVEC *OpenEVEC) EdgeNodeConfigHistory(controllerMode string) error {
+	changer, err := changerByControllerMode(controllerMode)
+	if err != nil {
+		return err
+	}
+	_, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+	history, err := controller.ConfigHistory(dev.GetID())
+	if err != nil {
+		return fmt.Errorf("ConfigHistory: %w", err)
+	}
+	if len(history) == 0 {
+		log.Infof("no config history for %s", dev.GetID())
+		return nil
+	}
+	w := new(tabwriter.Writer)
+	w.Init(os.Stdout, 0, 8, 1, '\t', 0)
+	if _, err = fmt.Fprintln(w, "VERSION\tTIME\tCOMMAND"); err != nil {
+		return err
+	}
+	for _, entry := range history {
+		if _, err = fmt.Fprintf(w, "%s\t%s\t%s\n", entry.Version, entry.Time.Local().Format(time.RFC3339), entry.Command); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// configHistoryText returns config of entry in form suitable for comparison
+func configHistoryText(entry *controller.ConfigHistoryEntry) (string, error) {
+	var compacted, indented bytes.Buffer
+	if err := json.Compact(&compacted, entry.Config); err != nil {
+		return "", err
+	}
+	if err := json.Indent(&indented, compacted.Bytes(), "", "    "); err != nil {
+		return "", err
+	}
+	return indented.String() + "\n", nil
+}
+
+// EdgeNodeConfigDiff prints difference between configs of versions from and to,
+// current config of device is used if to is empty
+func (openEVEC *OpenEVEC) EdgeNodeConfigDiff(controllerMode, from, to string) error {
+	changer, err := changerByControllerMode(controllerMode)
+	if err != nil {
+		return err
+	}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+	fromEntry, err := controller.ConfigHistoryVersion(dev.GetID(), from)
+	if err != nil {
+		return err
+	}
+	toName := fmt.Sprintf("version %s", to)
+	var toEntry *controller.ConfigHistoryEntry
+	if to == "" {
+		res, err := ctrl.GetConfigBytes(dev, false)
+		if err != nil {
+			return fmt.Errorf("GetConfigBytes error: %w", err)
+		}
+		if toEntry, err = controller.NewConfigHistoryEntry(res); err != nil {
+			return err
+		}
+		toName = "current"
+	} else if toEntry, err = controller.ConfigHistoryVersion(dev.GetID(), to); err != nil {
+		return err
+	}
+	fromText, err := configHistoryText(fromEntry)
+	if err != nil {
+		return err
+	}
+	toText, err := configHistoryText(toEntry)
+	if err != nil {
+		return err
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(fromText),
+		B:        difflib.SplitLines(toText),
+		FromFile: fmt.Sprintf("version %s", from),
+		ToFile:   toName,
+		Context:  3,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Print(diff)
+	return nil
+}
+
+// EdgeNodeConfigRollback restores config of version from history and pushes it to device with new version
+func (openEVEC *OpenEVEC) EdgeNodeConfigRollback(controllerMode, version string) error {
+	changer, err := changerByControllerMode(controllerMode)
+	if err != nil {
+		return err
+	}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig error: %w", err)
+	}
+	entry, err := controller.ConfigHistoryVersion(dev.GetID(), version)
+	if err != nil {
+		return err
+	}
+	devConfig, err := entry.EdgeDevConfig()
+	if err != nil {
+		return err
+	}
+	if dev, err = controller.ConfigRestore(ctrl, dev, devConfig); err != nil {
+		return err
+	}
+	if err = changer.setControllerAndDev(ctrl, dev); err != nil {
+		return fmt.Errorf("setControllerAndDev: %w", err)
+	}
+	log.Infof("config of version %s restored", version)
+	return nil
+}
@@ -0,0 +1,349 @@
+package openevec
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dustin/go-humanize"
+	"github.com/lf-edge/eden/eserver/api"
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/eden"
+	"github.com/lf-edge/eden/pkg/utils"
+	"github.com/lf-edge/eve-api/go/config"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+)
+
+func (openEVEC *OpenEVEC) eserverClient() *eden.EServer {
+	return newEServerClient(&openEVEC.cfg.Eden.EServer, openEVEC.cfg.Eden.EServer.IP)
+}
+
+// newEServerClient returns client of eserver accessible on ip
+// with TLS and admin credentials from config
+func newEServerClient(cfg *EServerConfig, ip string) *eden.EServer {
+	server := &eden.EServer{
+		EServerIP:            ip,
+		EServerPort:          strconv.Itoa(cfg.Port),
+		EServerHTTPS:         cfg.HTTPS,
+		EServerAdminUser:     cfg.AdminUser,
+		EServerAdminPassword: cfg.AdminPassword,
+	}
+	if cfg.HTTPS {
+		edenHome, err := utils.DefaultEdenDir()
+		if err != nil {
+			log.Errorf("cannot get eden dir to find CA certificate: %s", err)
+		} else {
+			server.EServerCA = filepath.Join(edenHome, defaults.DefaultCertsDist, "root-certificate.pem")
+		}
+	}
+	return server
+}
+
+// eserverEnvs returns environment variables of eserver container with credentials from config,
+// they are not passed as arguments to not expose them in the command line
+func eserverEnvs(cfg *EServerConfig) []string {
+	type env struct {
+		name  string
+		value string
+	}
+	var envs []string
+	credentials := []env{
+		{api.EnvHTTPUser, cfg.User},
+		{api.EnvHTTPPassword, cfg.Password},
+		{api.EnvHTTPToken, cfg.Token},
+		{api.EnvAdminUser, cfg.AdminUser},
+		{api.EnvAdminPassword, cfg.AdminPassword},
+	}
+	// default credentials of object storage emulation are public,
+	// signed requests would bypass authorization of files with them
+	filesAuth := cfg.User != "" || cfg.Token != ""
+	if filesAuth && cfg.S3SecretKey == defaults.DefaultS3SecretKey {
+		log.Warn("S3 emulation of eserver is disabled: set eden.eserver.s3-access-key " +
+			"and eden.eserver.s3-secret-key to use it with authorization of files")
+	} else {
+		credentials = append(credentials, env{api.EnvS3AccessKey, cfg.S3AccessKey},
+			env{api.EnvS3SecretKey, cfg.S3SecretKey})
+	}
+	if filesAuth && cfg.AzureKey == defaults.DefaultAzureKey {
+		log.Warn("Azure Blob emulation of eserver is disabled: set eden.eserver.azure-account " +
+			"and eden.eserver.azure-key to use it with authorization of files")
+	} else {
+		credentials = append(credentials, env{api.EnvAzureAccount, cfg.AzureAccount},
+			env{api.EnvAzureKey, cfg.AzureKey})
+	}
+	for _, credential := range credentials {
+		if credential.value != "" {
+			envs = append(envs, fmt.Sprintf("%s=%s", credential.name, credential.value))
+		}
+	}
+	return envs
+}
+
+// EServerList prints files stored in eserver
+func (openEVEC *OpenEVEC) EServerList() error {
+	files, err := openEVEC.eserverClient().EServerListFiles()
+	if err != nil {
+		return fmt.Errorf("failed to list eserver files: %w", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
+	if _, err = fmt.Fprintln(w, "NAME\tSHA256\tSIZE"); err != nil {
+		return err
+	}
+	for _, file := range files {
+		if _, err = fmt.Fprintf(w, "%s\t%s\t%s\n", eserverFileName(file.FileName),
+			file.Sha256, humanize.IBytes(uint64(file.Size))); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// EServerRemove removes files from eserver
+func (openEVEC *OpenEVEC) EServerRemove(names []string) error {
+	server := openEVEC.eserverClient()
+	for _, name := range names {
+		result, err := server.EServerDeleteFile(name)
+		if err != nil {
+			return fmt.Errorf("failed to remove %s: %w", name, err)
+		}
+		log.Infof("Removed %s (freed %s)", name, humanize.IBytes(uint64(result.FreedBytes)))
+	}
+	return nil
+}
+
+// EServerGC removes blobs which are not referenced by any file.
+// If prune is set, it also removes files which are not referenced by configs of devices
+// registered in the controller, except of files listed in keep and files of EVE uploaded
+// for network boot. Pruning is refused if there are more contexts, because files
+// used by other contexts are not known.
+func (openEVEC *OpenEVEC) EServerGC(keep []string, dryRun, prune bool) error {
+	arg := api.GCArg{DryRun: dryRun}
+	if prune {
+		context, err := utils.ContextLoad()
+		if err != nil {
+			return fmt.Errorf("load context error: %w", err)
+		}
+		if contexts := context.ListContexts(); len(contexts) > 1 {
+			return fmt.Errorf("cannot prune files of eserver shared by contexts %s, "+
+				"remove files with 'eden eserver rm' instead", strings.Join(contexts, ", "))
+		}
+		changer := &adamChanger{}
+		ctrl, err := changer.getController()
+		if err != nil {
+			return err
+		}
+		inUse, err := eserverFilesInUse(ctrl)
+		if err != nil {
+			return fmt.Errorf("failed to get files referenced by device configs: %w", err)
+		}
+		arg.Keep = append(inUse, keep...)
+		arg.Keep = append(arg.Keep, eserverNetbootFiles(openEVEC.cfg)...)
+		arg.Prune = true
+	}
+	result, err := openEVEC.eserverClient().EServerGC(arg)
+	if err != nil {
+		return fmt.Errorf("garbage collection failed: %w", err)
+	}
+	action := "Removed"
+	if dryRun {
+		action = "Would remove"
+	}
+	for _, name := range result.RemovedFiles {
+		fmt.Printf("%s file %s\n", action, name)
+	}
+	for _, digest := range result.RemovedBlobs {
+		fmt.Printf("%s blob %s\n", action, digest)
+	}
+	fmt.Printf("%s %d file(s) and %d blob(s), %s in total\n", action,
+		len(result.RemovedFiles), len(result.RemovedBlobs),
+		humanize.IBytes(uint64(result.FreedBytes)))
+	return nil
+}
+
+// eserverNetbootFiles returns names of eserver files of EVE uploaded by setup
+// for network boot (see setupEve)
+func eserverNetbootFiles(cfg *EdenSetupArgs) (names []string) {
+	configPrefix := cfg.ConfigName
+	if configPrefix == defaults.DefaultContext {
+		configPrefix = ""
+	}
+	items, _ := os.ReadDir(filepath.Dir(cfg.Eve.ImageFile))
+	for _, item := range items {
+		if !item.IsDir() {
+			names = append(names, path.Join(configPrefix, item.Name()))
+		}
+	}
+	return names
+}
+
+// eserverFilesInUse returns names of eserver files referenced by images
+// in configs of all devices registered in the controller.
+func eserverFilesInUse(ctrl controller.Cloud) (names []string, err error) {
+	devIDs, err := ctrl.DeviceList(types.RegisteredDeviceFilter)
+	if err != nil {
+		return nil, fmt.Errorf("DeviceList: %w", err)
+	}
+	for _, devID := range devIDs {
+		devUUID, err := uuid.FromString(devID)
+		if err != nil {
+			return nil, fmt.Errorf("incorrect device UUID %s: %w", devID, err)
+		}
+		configString, err := ctrl.ConfigGet(devUUID)
+		if err != nil {
+			return nil, fmt.Errorf("ConfigGet for %s: %w", devID, err)
+		}
+		var devConfig config.EdgeDevConfig
+		if err = proto.Unmarshal([]byte(configString), &devConfig); err != nil {
+			return nil, fmt.Errorf("unmarshal config of %s: %w", devID, err)
+		}
+		// images in S3 and Azure Blob emulation are named by file name
+		objectStorages := make(map[string]bool)
+		for _, ds := range devConfig.Datastores {
+			if (ds.DType == config.DsType_DsS3 || ds.DType == config.DsType_DsAzureBlob) &&
+				ds.Dpath == defaults.DefaultEServerBucket {
+				objectStorages[ds.Id] = true
+			}
+		}
+		var drives []*config.Drive
+		for _, app := range devConfig.Apps {
+			drives = append(drives, app.Drives...)
+		}
+		for _, baseOS := range devConfig.Base {
+			drives = append(drives, baseOS.Drives...)
+		}
+		for _, drive := range drives {
+			image := drive.GetImage()
+			if objectStorages[image.GetDsId()] {
+				names = append(names, image.GetName())
+			} else if name := eserverFileName(image.GetName()); name != "" {
+				names = append(names, name)
+			}
+		}
+		for _, contentTree := range devConfig.ContentInfo {
+			if objectStorages[contentTree.DsId] {
+				names = append(names, contentTree.URL)
+			} else if name := eserverFileName(contentTree.URL); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// eserverFileName returns name of the file inside eserver for image path
+// as used with HTTP ("eserver/<name>") or SFTP ("/eserver/run/eserver/<name>")
+// datastore. Returns empty string if the path does not point into eserver.
+func eserverFileName(imagePath string) string {
+	for _, prefix := range []string{path.Join(defaults.DefaultSFTPDirPrefix, "eserver"), "eserver"} {
+		if strings.HasPrefix(imagePath, prefix+"/") {
+			return strings.TrimPrefix(imagePath, prefix+"/")
+		}
+	}
+	return ""
+}
+
+// EServerFaultList prints fault profiles active in eserver
+func (openEVEC *OpenEVEC) EServerFaultList() error {
+	profiles, err := openEVEC.eserverClient().EServerGetFaults()
+	if err != nil {
+		return fmt.Errorf("failed to get fault profiles: %w", err)
+	}
+	return printFaultProfiles(profiles)
+}
+
+// EServerFaultAdd adds fault profile into eserver
+func (openEVEC *OpenEVEC) EServerFaultAdd(profile api.FaultProfile) error {
+	profiles, err := openEVEC.eserverClient().EServerAddFault(profile)
+	if err != nil {
+		return fmt.Errorf("failed to add fault profile: %w", err)
+	}
+	return printFaultProfiles(profiles)
+}
+
+// EServerFaultSet replaces fault profiles in eserver with profiles
+// from JSON file (list of profiles)
+func (openEVEC *OpenEVEC) EServerFaultSet(profilesFile string) error {
+	data, err := os.ReadFile(profilesFile)
+	if err != nil {
+		return err
+	}
+	var profiles []api.FaultProfile
+	if err = json.Unmarshal(data, &profiles); err != nil {
+		return fmt.Errorf("cannot parse fault profiles from %s: %w", profilesFile, err)
+	}
+	profiles, err = openEVEC.eserverClient().EServerSetFaults(profiles)
+	if err != nil {
+		return fmt.Errorf("failed to set fault profiles: %w", err)
+	}
+	return printFaultProfiles(profiles)
+}
+
+// EServerFaultClear removes all fault profiles from eserver
+func (openEVEC *OpenEVEC) EServerFaultClear() error {
+	if err := openEVEC.eserverClient().EServerClearFaults(); err != nil {
+		return fmt.Errorf("failed to clear fault profiles: %w", err)
+	}
+	log.Info("Fault profiles cleared")
+	return nil
+}
+
+func printFaultProfiles(profiles []api.FaultProfile) error {
+	w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
+	if _, err := fmt.Fprintln(w, "#\tFILES\tCLIENT\tFAULTS"); err != nil {
+		return err
+	}
+	orAll := func(s string) string {
+		if s == "" {
+			return "*"
+		}
+		return s
+	}
+	for i, profile := range profiles {
+		if _, err := fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i, orAll(profile.Files),
+			orAll(profile.Client), describeFaults(profile)); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func describeFaults(profile api.FaultProfile) string {
+	var faults []string
+	if profile.Throttle != 0 {
+		faults = append(faults, fmt.Sprintf("throttle=%s/s", humanize.IBytes(profile.Throttle)))
+	}
+	if profile.FailNth != 0 {
+		faults = append(faults, fmt.Sprintf("fail-nth=%d", profile.FailNth))
+	}
+	if profile.ErrorBurst != 0 {
+		faults = append(faults, fmt.Sprintf("error-burst=%d", profile.ErrorBurst))
+		if profile.ErrorBurstPeriod != 0 {
+			faults = append(faults, fmt.Sprintf("error-burst-period=%d", profile.ErrorBurstPeriod))
+		}
+	}
+	if profile.ErrorStatus != 0 {
+		faults = append(faults, fmt.Sprintf("error-status=%d", profile.ErrorStatus))
+	}
+	if profile.DropAfter != 0 {
+		faults = append(faults, fmt.Sprintf("drop-after=%d", profile.DropAfter))
+	}
+	if profile.Corrupt {
+		faults = append(faults, "corrupt")
+	}
+	if profile.TTFB != 0 {
+		faults = append(faults, fmt.Sprintf("ttfb=%s", profile.TTFB))
+	}
+	if len(faults) == 0 {
+		return "none"
+	}
+	return strings.Join(faults, " ")
+}
@@ -75,68 +75,22 @@ func (openEVEC *OpenEVEC) StartEveQemu(tapInterface string) error {
 		netModel.Host.ControllerPort = 443
 	}
 	if isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
-		nets, err := utils.GetSubnetsNotUsed(1)
-		if err != nil {
-			return fmt.Errorf("failed to get unused IP subnet: %w", err)
-		}
-		imageDir := filepath.Dir(cfg.Sdn.ImageFile)
-		firmware := []string{"OVMF_CODE.fd", "OVMF_VARS.fd"}
-		for i := range firmware {
-			firmware[i] = utils.ResolveAbsPath(
-				filepath.Join(imageDir, "firmware", firmware[i]))
-		}
-		sdnConfig := edensdn.SdnVMConfig{
-			Architecture: cfg.Eve.Arch,
-			Acceleration: cfg.Eve.Accel,
-			HostOS:       cfg.Eve.QemuOS,
-			ImagePath:    cfg.Sdn.ImageFile,
-			ConfigDir:    cfg.Sdn.ConfigDir,
-			CPU:          cfg.Sdn.CPU,
-			RAM:          cfg.Sdn.RAM,
-			Firmware:     firmware,
-			NetModel:     netModel,
-			TelnetPort:   uint16(cfg.Sdn.TelnetPort),
-			SSHPort:      uint16(cfg.Sdn.SSHPort),
-			SSHKeyPath:   sdnSSHKeyPath(cfg.Sdn.SourceDir),
-			MgmtPort:     uint16(cfg.Sdn.MgmtPort),
-			MgmtSubnet: edensdn.SdnMgmtSubnet{
-				IPNet:     nets[0].Subnet,
-				DHCPStart: nets[0].FirstAddress,
-			},
-			NetDevBasePort: uint16(cfg.Eve.QemuConfig.NetDevSocketPort),
-			PidFile:        cfg.Sdn.PidFile,
-			ConsoleLogFile: cfg.Sdn.ConsoleLogFile,
-		}
-		sdnVmRunner, err := edensdn.GetSdnVMRunner(cfg.Eve.DevModel, sdnConfig)
-		if err != nil {
-			return fmt.Errorf("failed to get SDN VM runner: %w", err)
-		}
-		// Start SDN.
-		err = sdnVmRunner.Start()
-		if err != nil {
-			return fmt.Errorf("cannot start SDN: %w", err)
-		}
-		log.Infof("SDN is starting")
-		// Wait for SDN to start and apply network model.
-		startTime := time.Now()
 		client := &edensdn.SdnClient{
 			SSHPort:  uint16(cfg.Sdn.SSHPort),
 			MgmtPort: uint16(cfg.Sdn.MgmtPort),
 		}
-		for time.Since(startTime) < SdnStartTimeout {
-			time.Sleep(2 * time.Second)
-			if _, err = client.GetSdnStatus(); err == nil {
-				break
+		if _, err = client.GetSdnStatus(); err == nil {
+			// SDN was already started for another EVE instance (Eden context).
+			// Connect to it using the network model which is currently applied.
+			netModel, err = client.GetNetworkModel()
+			if err != nil {
+				return fmt.Errorf("failed to get network model from running SDN: %w", err)
 			}
+			log.Infof("SDN is already running, connecting EVE instance %q to it",
+				cfg.Sdn.EVEInstance)
+		} else if err = openEVEC.startSdn(netModel); err != nil {
+			return err
 		}
-		if err != nil {
-			return fmt.Errorf("timeout waiting for SDN to start: %w", err)
-		}
-		err = client.ApplyNetworkModel(netModel)
-		if err != nil {
-			return fmt.Errorf("failed to apply network model: %w", err)
-		}
-		log.Infof("SDN started, network model was submitted.")
 	}
 	// Create USB network config override image if requested.
 	var usbImagePath string
@@ -172,7 +126,7 @@ func (openEVEC *OpenEVEC) StartEveQemu(tapInterface string) error {
 	// Start EVE VM.
 	if err = eden.StartEVEQemu(cfg.Eve.Arch, cfg.Eve.QemuOS, imageFile, imageFormat, isInstaller, cfg.Eve.Serial, cfg.Eve.TelnetPort,
 		cfg.Eve.QemuConfig.MonitorPort, cfg.Eve.QemuConfig.NetDevSocketPort, cfg.Eve.HostFwd, cfg.Eve.Accel, cfg.Eve.QemuFileToSave, cfg.Eve.Log,
-		cfg.Eve.Pid, netModel, isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel), tapInterface, usbImagePath, cfg.Eve.TPM, false); err != nil {
+		cfg.Eve.Pid, netModel, isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel), cfg.Sdn.EVEInstance, tapInterface, usbImagePath, cfg.Eve.TPM, false); err != nil {
 		log.Errorf("cannot start eve: %s", err.Error())
 	} else {
 		log.Infof("EVE is starting")
@@ -180,6 +134,74 @@ func (openEVEC *OpenEVEC) StartEveQemu(tapInterface string) error {
 	return nil
 }
 
+// startSdn starts SDN VM and applies the network model.
+func (openEVEC *OpenEVEC) startSdn(netModel sdnapi.NetworkModel) error {
+	cfg := openEVEC.cfg
+	nets, err := utils.GetSubnetsNotUsed(1)
+	if err != nil {
+		return fmt.Errorf("failed to get unused IP subnet: %w", err)
+	}
+	imageDir := filepath.Dir(cfg.Sdn.ImageFile)
+	firmware := []string{"OVMF_CODE.fd", "OVMF_VARS.fd"}
+	for i := range firmware {
+		firmware[i] = utils.ResolveAbsPath(
+			filepath.Join(imageDir, "firmware", firmware[i]))
+	}
+	sdnConfig := edensdn.SdnVMConfig{
+		Architecture: cfg.Eve.Arch,
+		Acceleration: cfg.Eve.Accel,
+		HostOS:       cfg.Eve.QemuOS,
+		ImagePath:    cfg.Sdn.ImageFile,
+		ConfigDir:    cfg.Sdn.ConfigDir,
+		CPU:          cfg.Sdn.CPU,
+		RAM:          cfg.Sdn.RAM,
+		Firmware:     firmware,
+		NetModel:     netModel,
+		TelnetPort:   uint16(cfg.Sdn.TelnetPort),
+		SSHPort:      uint16(cfg.Sdn.SSHPort),
+		SSHKeyPath:   sdnSSHKeyPath(cfg.Sdn.SourceDir),
+		MgmtPort:     uint16(cfg.Sdn.MgmtPort),
+		MgmtSubnet: edensdn.SdnMgmtSubnet{
+			IPNet:     nets[0].Subnet,
+			DHCPStart: nets[0].FirstAddress,
+		},
+		NetDevBasePort: uint16(cfg.Eve.QemuConfig.NetDevSocketPort),
+		PidFile:        cfg.Sdn.PidFile,
+		ConsoleLogFile: cfg.Sdn.ConsoleLogFile,
+	}
+	sdnVmRunner, err := edensdn.GetSdnVMRunner(cfg.Eve.DevModel, sdnConfig)
+	if err != nil {
+		return fmt.Errorf("failed to get SDN VM runner: %w", err)
+	}
+	// Start SDN.
+	err = sdnVmRunner.Start()
+	if err != nil {
+		return fmt.Errorf("cannot start SDN: %w", err)
+	}
+	log.Infof("SDN is starting")
+	// Wait for SDN to start and apply network model.
+	startTime := time.Now()
+	client := &edensdn.SdnClient{
+		SSHPort:  uint16(cfg.Sdn.SSHPort),
+		MgmtPort: uint16(cfg.Sdn.MgmtPort),
+	}
+	for time.Since(startTime) < SdnStartTimeout {
+		time.Sleep(2 * time.Second)
+		if _, err = client.GetSdnStatus(); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("timeout waiting for SDN to start: %w", err)
+	}
+	err = client.ApplyNetworkModel(netModel)
+	if err != nil {
+		return fmt.Errorf("failed to apply network model: %w", err)
+	}
+	log.Infof("SDN started, network model was submitted.")
+	return nil
+}
+
 func (openEVEC *OpenEVEC) StopEve(vmName string) error {
 	cfg := openEVEC.cfg
 	if cfg.Eve.Remote {
@@ -213,7 +235,7 @@ func (openEVEC *OpenEVEC) StopEve(vmName string) error {
 			}
 		}
 	}
-	eden.StopSDN(cfg.Eve.DevModel, cfg.Sdn.PidFile)
+	eden.StopSharedSDN(cfg.Eve.DevModel, cfg.Sdn.PidFile, cfg.Eve.Pid)
 	return nil
 }
 
@@ -248,7 +270,7 @@ func (openEVEC *OpenEVEC) StatusEve(vmName string) error {
 	cfg := openEVEC.cfg
 	statusAdam, err := eden.StatusAdam()
 	if err == nil && statusAdam != "container doesn't exist" {
-		if err := openEVEC.eveStatusRemote(); err != nil {
+		if err := openEVEC.eveStatusRemote(false); err != nil {
 			return err
 		}
 	}
@@ -276,9 +298,10 @@ func (openEVEC *OpenEVEC) GetEveIP(ifName string) string {
 			ifName = "eth0"
 		}
 		client := &edensdn.SdnClient{
-			SSHPort:    uint16(cfg.Sdn.SSHPort),
-			SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
-			MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+			SSHPort:     uint16(cfg.Sdn.SSHPort),
+			SSHKeyPath:  sdnSSHKeyPath(cfg.Sdn.SourceDir),
+			MgmtPort:    uint16(cfg.Sdn.MgmtPort),
+			EVEInstance: cfg.Sdn.EVEInstance,
 		}
 		ip, err := client.GetEveIfIP(ifName)
 		if err != nil {
@@ -436,15 +459,17 @@ func (openEVEC *OpenEVEC) NewLinkEve(command, eveInterfaceName, vmName string) e
 	} else {
 		if isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
 			client := &edensdn.SdnClient{
-				SSHPort:    uint16(cfg.Sdn.SSHPort),
-				SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
-				MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+				SSHPort:     uint16(cfg.Sdn.SSHPort),
+				SSHKeyPath:  sdnSSHKeyPath(cfg.Sdn.SourceDir),
+				MgmtPort:    uint16(cfg.Sdn.MgmtPort),
+				EVEInstance: cfg.Sdn.EVEInstance,
 			}
 			netModel, err := client.GetNetworkModel()
 			if err != nil {
 				return fmt.Errorf("failed to get network model: %w", err)
 			}
-			for i := range netModel.Ports {
+			// only ports connected to this EVE instance are its interfaces
+			for i := range edensdn.GetEVEPorts(netModel, cfg.Sdn.EVEInstance) {
 				eveIfNames = append(eveIfNames, fmt.Sprintf("eth%d", i))
 			}
 		} else {
@@ -118,8 +118,20 @@ func (openEVEC *OpenEVEC) PodDeploy(appLink string, pc PodConfig, cfg *EdenSetup
 		return err
 	}
 	opts = append(opts, expect.WithVLANs(vlansParsed))
-	opts = append(opts, expect.WithSFTPLoad(pc.SftpLoad))
-	if !pc.SftpLoad {
+	dsType := expect.DatastoreHTTP
+	if pc.DatastoreType != "" {
+		if dsType, err = expect.DatastoreTypeByName(pc.DatastoreType); err != nil {
+			return err
+		}
+	}
+	if pc.SftpLoad {
+		if dsType != expect.DatastoreHTTP && dsType != expect.DatastoreSFTP {
+			return fmt.Errorf("--sftp cannot be used with datastore type %s", dsType)
+		}
+		dsType = expect.DatastoreSFTP
+	}
+	opts = append(opts, expect.WithDatastoreType(dsType))
+	if dsType == expect.DatastoreHTTP {
 		opts = append(opts, expect.WithHTTPDirectLoad(pc.DirectLoad))
 	}
 	opts = append(opts, expect.WithAdditionalDisks(append(pc.Disks, pc.Mount...)))
@@ -3,10 +3,15 @@ package openevec
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/edensdn"
 	"github.com/lf-edge/eden/pkg/utils"
@@ -205,9 +210,111 @@ func (openEVEC *OpenEVEC) SdnStatus() error {
 		fmt.Printf("\tHave configuration errors: %v\n", status.ConfigErrors)
 	}
 	fmt.Printf("\tManagement IPs: %v\n", strings.Join(status.MgmtIPs, ", "))
+	printSdnStatusDetails(status)
 	return nil
 }
 
+func printSdnStatusDetails(status sdnapi.SDNStatus) {
+	ifCounters := func(c sdnapi.IfCounters) string {
+		return fmt.Sprintf("rx %s/%d pkts (%d dropped, %d errors), "+
+			"tx %s/%d pkts (%d dropped, %d errors)",
+			humanize.Bytes(c.RxBytes), c.RxPackets, c.RxDropped, c.RxErrors,
+			humanize.Bytes(c.TxBytes), c.TxPackets, c.TxDropped, c.TxErrors)
+	}
+	countsByKey := func(counts map[string]uint64) string {
+		var keys []string
+		for key := range counts {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var items []string
+		for _, key := range keys {
+			items = append(items, fmt.Sprintf("%s: %d", key, counts[key]))
+		}
+		return strings.Join(items, ", ")
+	}
+	if len(status.Ports) > 0 {
+		fmt.Printf("\tPorts:\n")
+	}
+	for _, port := range status.Ports {
+		state := "down"
+		if port.OperUp {
+			state = "up"
+		}
+		fmt.Printf("\t\t%s (%s): %s, %s\n", port.LogicalLabel, port.IfName, state,
+			ifCounters(port.Counters))
+		for _, qdisc := range port.Qdiscs {
+			fmt.Printf("\t\t\tqdisc %s %s sent %s/%d pkts (%d dropped, %d overlimits, "+
+				"%d requeues), backlog %s/%d pkts\n", qdisc.Kind, qdisc.Handle,
+				humanize.Bytes(qdisc.Bytes), qdisc.Packets, qdisc.Drops, qdisc.Overlimits,
+				qdisc.Requeues, humanize.Bytes(qdisc.Backlog), qdisc.Qlen)
+		}
+	}
+	if len(status.Networks) > 0 {
+		fmt.Printf("\tNetworks:\n")
+	}
+	for _, network := range status.Networks {
+		fmt.Printf("\t\t%s: %s\n", network.LogicalLabel, ifCounters(network.Counters))
+		for _, lease := range network.DHCPLeases {
+			details := []string{}
+			if lease.MAC != "" {
+				details = append(details, "MAC "+lease.MAC)
+			}
+			if lease.Hostname != "" {
+				details = append(details, "hostname "+lease.Hostname)
+			}
+			if lease.Expiry.IsZero() {
+				details = append(details, "never expires")
+			} else {
+				details = append(details, "expires "+lease.Expiry.Format(time.RFC3339))
+			}
+			fmt.Printf("\t\t\tDHCP lease %s (%s)\n", lease.IP, strings.Join(details, ", "))
+		}
+	}
+	if len(status.DNSServers) > 0 {
+		fmt.Printf("\tDNS servers:\n")
+	}
+	for _, dnsSrv := range status.DNSServers {
+		fmt.Printf("\t\t%s: %d queries", dnsSrv.LogicalLabel, dnsSrv.Queries)
+		if dnsSrv.Queries > 0 {
+			fmt.Printf(" (%s; by client: %s)", countsByKey(dnsSrv.QueriesByType),
+				countsByKey(dnsSrv.QueriesByClient))
+		}
+		fmt.Println()
+	}
+	if len(status.Proxies) > 0 {
+		fmt.Printf("\tProxies:\n")
+	}
+	for _, proxy := range status.Proxies {
+		fmt.Printf("\t\t%s: %d requests\n", proxy.LogicalLabel, proxy.Requests)
+		for _, req := range proxy.RecentRequests {
+			target := req.URL
+			if target == "" {
+				target = req.Host
+			}
+			fmt.Printf("\t\t\t%s %s %s %s\n", req.Time.Format(time.RFC3339),
+				req.Client, req.Method, target)
+		}
+	}
+	if len(status.CaptivePortals) > 0 {
+		fmt.Printf("\tCaptive portals:\n")
+	}
+	for _, portal := range status.CaptivePortals {
+		fmt.Printf("\t\t%s: %d clients logged in\n", portal.LogicalLabel,
+			len(portal.Clients))
+		for _, client := range portal.Clients {
+			fmt.Printf("\t\t\t%s (since %s)\n", client.Client,
+				client.LoginTime.Format(time.RFC3339))
+		}
+	}
+	fmt.Printf("\tConntrack: %d entries", status.Conntrack.Entries)
+	if status.Conntrack.Entries > 0 {
+		fmt.Printf(" (%s)", countsByKey(status.Conntrack.EntriesByProto))
+	}
+	fmt.Printf(", %d packets, %s\n", status.Conntrack.Packets,
+		humanize.Bytes(status.Conntrack.Bytes))
+}
+
 func (openEVEC *OpenEVEC) SdnNetModelGet() (string, error) {
 	cfg := openEVEC.cfg
 	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
@@ -364,3 +471,284 @@ func (openEVEC *OpenEVEC) SdnEpExec(epName, command string, args []string) error
 	}
 	return nil
 }
+
+func (openEVEC *OpenEVEC) SdnChaosRun(scenarioFile string, detach bool) error {
+	cfg := openEVEC.cfg
+	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		return fmt.Errorf("SDN is not enabled")
+	}
+	scenario, err := edensdn.LoadChaosScenarioFromFile(scenarioFile)
+	if err != nil {
+		return err
+	}
+	client := &edensdn.SdnClient{
+		SSHPort:    uint16(cfg.Sdn.SSHPort),
+		SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
+		MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+	}
+	if err = client.RunChaosScenario(scenario); err != nil {
+		return fmt.Errorf("failed to run chaos scenario: %w", err)
+	}
+	fmt.Printf("Submitted chaos scenario: %s\n", scenarioFile)
+	if detach {
+		return nil
+	}
+	status, err := client.WaitForChaosScenario(0, printChaosEvent)
+	if err != nil {
+		return err
+	}
+	if status.Running {
+		return fmt.Errorf("chaos scenario is still running")
+	}
+	return nil
+}
+
+func (openEVEC *OpenEVEC) SdnChaosStatus() error {
+	cfg := openEVEC.cfg
+	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		return fmt.Errorf("SDN is not enabled")
+	}
+	client := &edensdn.SdnClient{
+		SSHPort:    uint16(cfg.Sdn.SSHPort),
+		SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
+		MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+	}
+	status, err := client.GetChaosStatus()
+	if err != nil {
+		return fmt.Errorf("failed to get chaos status: %w", err)
+	}
+	if status.Scenario == nil {
+		fmt.Println("No chaos scenario was run yet.")
+		return nil
+	}
+	state := "finished"
+	if status.Running {
+		state = "running"
+	}
+	fmt.Printf("Chaos scenario %q (%d steps) started at %s is %s\n", status.Scenario.Name,
+		len(status.Scenario.Steps), status.StartedAt.Format(time.RFC3339), state)
+	for _, event := range status.Events {
+		printChaosEvent(event)
+	}
+	return nil
+}
+
+func (openEVEC *OpenEVEC) SdnChaosStop() error {
+	cfg := openEVEC.cfg
+	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		return fmt.Errorf("SDN is not enabled")
+	}
+	client := &edensdn.SdnClient{
+		SSHPort:    uint16(cfg.Sdn.SSHPort),
+		SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
+		MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+	}
+	if err := client.StopChaosScenario(); err != nil {
+		return fmt.Errorf("failed to stop chaos scenario: %w", err)
+	}
+	fmt.Println("Chaos scenario stopped")
+	return nil
+}
+
+func printChaosEvent(event sdnapi.ChaosEvent) {
+	step := "-"
+	if event.Step >= 0 {
+		step = strconv.Itoa(event.Step)
+	}
+	line := fmt.Sprintf("%s\tstep %s\t%s\t%s", event.Time.Format(time.RFC3339Nano),
+		step, event.Action, event.Message)
+	if event.Error != "" {
+		line += fmt.Sprintf(" (error: %s)", event.Error)
+	}
+	fmt.Println(line)
+}
+
+func (openEVEC *OpenEVEC) SdnPcapStart(capture sdnapi.PacketCapture, maxSize string,
+	outputFile string) error {
+	cfg := openEVEC.cfg
+	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		return fmt.Errorf("SDN is not enabled")
+	}
+	if maxSize != "" {
+		maxSizeParsed, err := humanize.ParseBytes(maxSize)
+		if err != nil {
+			return fmt.Errorf("failed to parse max-size: %w", err)
+		}
+		capture.MaxSize = maxSizeParsed
+	}
+	client := &edensdn.SdnClient{
+		SSHPort:    uint16(cfg.Sdn.SSHPort),
+		SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
+		MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+	}
+	status, err := client.StartPacketCapture(capture)
+	if err != nil {
+		return fmt.Errorf("failed to start packet capture: %w", err)
+	}
+	if outputFile == "" {
+		fmt.Println(status.ID)
+		return nil
+	}
+	log.Infof("Started packet capture %s on interface %s (netns %s)",
+		status.ID, status.Interface, status.NetNamespace)
+	return openEVEC.sdnPcapDownload(client, status.ID, outputFile, true)
+}
+
+func (openEVEC *OpenEVEC) SdnPcapList() error {
+	cfg := openEVEC.cfg
+	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		return fmt.Errorf("SDN is not enabled")
+	}
+	client := &edensdn.SdnClient{
+		SSHPort:    uint16(cfg.Sdn.SSHPort),
+		SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
+		MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+	}
+	statuses, err := client.ListPacketCaptures()
+	if err != nil {
+		return fmt.Errorf("failed to list packet captures: %w", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 1, 1, 1, ' ', 0)
+	if _, err = fmt.Fprintln(w, "ID\tINTERFACE\tNETNS\tFILTER\tSTARTED\tSTATE\tSIZE"); err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		state := "running"
+		if !status.Running {
+			state = "stopped (" + status.StopReason + ")"
+		}
+		if status.Error != "" {
+			state = "failed (" + status.Error + ")"
+		}
+		if _, err = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", status.ID,
+			status.Interface, status.NetNamespace, status.Request.Filter,
+			status.StartedAt.Format(time.RFC3339), state,
+			humanize.IBytes(status.Size)); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func (openEVEC *OpenEVEC) SdnPcapStop(id string) error {
+	cfg := openEVEC.cfg
+	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		return fmt.Errorf("SDN is not enabled")
+	}
+	client := &edensdn.SdnClient{
+		SSHPort:    uint16(cfg.Sdn.SSHPort),
+		SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
+		MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+	}
+	status, err := client.StopPacketCapture(id)
+	if err != nil {
+		return fmt.Errorf("failed to stop packet capture: %w", err)
+	}
+	fmt.Printf("Packet capture %s stopped (%s), captured %s\n", id, status.StopReason,
+		humanize.IBytes(status.Size))
+	return nil
+}
+
+func (openEVEC *OpenEVEC) SdnPcapGet(id, outputFile string, follow bool) error {
+	cfg := openEVEC.cfg
+	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		return fmt.Errorf("SDN is not enabled")
+	}
+	client := &edensdn.SdnClient{
+		SSHPort:    uint16(cfg.Sdn.SSHPort),
+		SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
+		MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+	}
+	return openEVEC.sdnPcapDownload(client, id, outputFile, follow)
+}
+
+func (openEVEC *OpenEVEC) SdnPcapRemove(id string) error {
+	cfg := openEVEC.cfg
+	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		return fmt.Errorf("SDN is not enabled")
+	}
+	client := &edensdn.SdnClient{
+		SSHPort:    uint16(cfg.Sdn.SSHPort),
+		SSHKeyPath: sdnSSHKeyPath(cfg.Sdn.SourceDir),
+		MgmtPort:   uint16(cfg.Sdn.MgmtPort),
+	}
+	if err := client.RemovePacketCapture(id); err != nil {
+		return fmt.Errorf("failed to remove packet capture: %w", err)
+	}
+	fmt.Printf("Packet capture %s removed\n", id)
+	return nil
+}
+
+// sdnPcapDownload downloads pcap file into outputFile ("-" for stdout).
+func (openEVEC *OpenEVEC) sdnPcapDownload(client *edensdn.SdnClient, id, outputFile string,
+	follow bool) error {
+	out := os.Stdout
+	if outputFile != "-" {
+		var err error
+		out, err = os.Create(outputFile)
+		if err != nil {
+			return fmt.Errorf("failed to create output file %s: %w", outputFile, err)
+		}
+		defer out.Close()
+	}
+	if err := client.DownloadPacketCapture(id, out, follow); err != nil {
+		return fmt.Errorf("failed to download packet capture: %w", err)
+	}
+	if outputFile != "-" {
+		log.Infof("Packet capture %s saved to %s", id, outputFile)
+	}
+	return nil
+}
+
+// SdnCaptivePortalLogin logs client into the captive portal.
+// If portalClient is empty, the client is EVE, identified by the MAC address
+// of the given interface.
+func (openEVEC *OpenEVEC) SdnCaptivePortalLogin(portal, portalClient, eveIfName string) error {
+	client, portalClient, err := openEVEC.sdnCaptivePortalClient(portalClient, eveIfName)
+	if err != nil {
+		return err
+	}
+	if err = client.LoginCaptivePortal(portal, portalClient); err != nil {
+		return fmt.Errorf("failed to login into captive portal %s: %w", portal, err)
+	}
+	fmt.Printf("Client %s logged into captive portal %s\n", portalClient, portal)
+	return nil
+}
+
+// SdnCaptivePortalLogout logs client out of the captive portal.
+// If portalClient is empty, the client is EVE, identified by the MAC address
+// of the given interface.
+func (openEVEC *OpenEVEC) SdnCaptivePortalLogout(portal, portalClient, eveIfName string) error {
+	client, portalClient, err := openEVEC.sdnCaptivePortalClient(portalClient, eveIfName)
+	if err != nil {
+		return err
+	}
+	if err = client.LogoutCaptivePortal(portal, portalClient); err != nil {
+		return fmt.Errorf("failed to logout of captive portal %s: %w", portal, err)
+	}
+	fmt.Printf("Client %s logged out of captive portal %s\n", portalClient, portal)
+	return nil
+}
+
+func (openEVEC *OpenEVEC) sdnCaptivePortalClient(portalClient, eveIfName string) (
+	*edensdn.SdnClient, string, error) {
+	cfg := openEVEC.cfg
+	if !isSdnEnabled(cfg.Sdn.Disable, cfg.Eve.Remote, cfg.Eve.DevModel) {
+		return nil, "", fmt.Errorf("SDN is not enabled")
+	}
+	client := &edensdn.SdnClient{
+		SSHPort:     uint16(cfg.Sdn.SSHPort),
+		SSHKeyPath:  sdnSSHKeyPath(cfg.Sdn.SourceDir),
+		MgmtPort:    uint16(cfg.Sdn.MgmtPort),
+		EVEInstance: cfg.Sdn.EVEInstance,
+	}
+	if portalClient != "" {
+		return client, portalClient, nil
+	}
+	mac, err := client.GetEveIfMAC(eveIfName)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get MAC address of EVE interface %s: %w",
+			eveIfName, err)
+	}
+	return client, mac, nil
+}
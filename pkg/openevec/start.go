@@ -66,7 +66,8 @@ func (openEVEC *OpenEVEC) StartRegistry() error {
 
 func (openEVEC *OpenEVEC) StartEServer() error {
 	cfg := openEVEC.cfg
-	if err := eden.StartEServer(cfg.Eden.EServer.Port, cfg.Eden.Images.EServerImageDist, cfg.Eden.EServer.Force, cfg.Eden.EServer.Tag); err != nil {
+	if err := eden.StartEServer(cfg.Eden.EServer.Port, cfg.Eden.Images.EServerImageDist, cfg.Eden.EServer.Force, cfg.Eden.EServer.Tag,
+		cfg.Eden.EServer.HTTPS, eserverEnvs(&cfg.Eden.EServer)...); err != nil {
 		return fmt.Errorf("cannot start eserver: %w", err)
 	}
 	log.Infof("Eserver is running and accesible on port %d", cfg.Eden.EServer.Port)
@@ -9,9 +9,12 @@ import (
 
 	"github.com/dustin/go-humanize"
 	"github.com/fatih/color"
+	"github.com/lf-edge/eden/pkg/controller"
 	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/eden"
 	"github.com/lf-edge/eden/pkg/eve"
+	"github.com/lf-edge/eden/pkg/projects"
 	"github.com/lf-edge/eden/pkg/utils"
 	log "github.com/sirupsen/logrus"
 )
@@ -22,7 +25,7 @@ const (
 	xmark    = "✘"
 )
 
-func (openEVEC *OpenEVEC) Status(vmName string, allConfigs bool) error {
+func (openEVEC *OpenEVEC) Status(vmName string, allConfigs, showDiff bool) error {
 	cfg := openEVEC.cfg
 	statusAdam, err := eden.StatusAdam()
 	if err != nil {
@@ -53,7 +56,11 @@ func (openEVEC *OpenEVEC) Status(vmName string, allConfigs bool) error {
 		return fmt.Errorf("%s cannot obtain status of redis: %s", statusWarn(), err)
 	} else {
 		fmt.Printf("%s EServer process status: %s\n", representContainerStatus(lastWord(statusEServer)), statusEServer)
-		fmt.Printf("\tEServer is expected at http://%s:%d from EVE\n", cfg.Eden.EServer.IP, cfg.Eden.EServer.Port)
+		scheme := "http"
+		if cfg.Eden.EServer.HTTPS {
+			scheme = "https"
+		}
+		fmt.Printf("\tEServer is expected at %s://%s:%d from EVE\n", scheme, cfg.Eden.EServer.IP, cfg.Eden.EServer.Port)
 		fmt.Printf("\tFor local EServer you can run 'docker logs %s' to see logs\n", defaults.DefaultEServerContainerName)
 	}
 	fmt.Println()
@@ -91,7 +98,7 @@ func (openEVEC *OpenEVEC) Status(vmName string, allConfigs bool) error {
 			}
 			fmt.Println()
 			if statusAdam != "container doesn't exist" {
-				if err := localOpenEVEC.eveStatusRemote(); err != nil {
+				if err := localOpenEVEC.eveStatusRemote(showDiff); err != nil {
 					return err
 				}
 			}
@@ -126,7 +133,7 @@ func (openEVEC *OpenEVEC) eveRequestsAdam() {
 	}
 }
 
-func (openEVEC *OpenEVEC) eveStatusRemote() error {
+func (openEVEC *OpenEVEC) eveStatusRemote(showDiff bool) error {
 	log.Debugf("Will try to obtain info from ADAM")
 	changer := &adamChanger{}
 	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
@@ -172,6 +179,37 @@ func (openEVEC *OpenEVEC) eveStatusRemote() error {
 	} else {
 		fmt.Printf("%s EVE memory: %s\n", statusWarn(), "waiting for info...")
 	}
+	if showDiff {
+		return eveStatusDiff(ctrl, dev, eveState.InfoAndMetrics())
+	}
+	return nil
+}
+
+// eveStatusDiff prints differences between config intended by controller and state reported by EVE
+func eveStatusDiff(ctrl controller.Cloud, dev *device.Ctx, state *projects.State) error {
+	desired, err := projects.GetDesiredConfig(ctrl, dev)
+	if err != nil {
+		return err
+	}
+	diffs := state.Diff(desired)
+	converged := 0
+	for _, od := range diffs {
+		status := statusOK()
+		switch {
+		case len(od.Errors) > 0:
+			status = statusBad()
+		case !od.Converged:
+			status = statusWarn()
+		default:
+			converged++
+		}
+		fmt.Printf("%s %s\n", status, od)
+	}
+	if converged == len(diffs) {
+		fmt.Printf("%s EVE state: converged (%d objects)\n", statusOK(), len(diffs))
+	} else {
+		fmt.Printf("%s EVE state: %d of %d objects converged\n", statusWarn(), converged, len(diffs))
+	}
 	return nil
 }
 
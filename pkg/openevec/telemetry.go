@@ -0,0 +1,234 @@
+package openevec
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/eapps"
+	"github.com/lf-edge/eden/pkg/controller/eflowlog"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/controller/equery"
+	"github.com/lf-edge/eden/pkg/controller/erequest"
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eve-api/go/flowlog"
+	"github.com/lf-edge/eve-api/go/info"
+	"github.com/lf-edge/eve-api/go/logs"
+	"github.com/lf-edge/eve-api/go/metrics"
+	uuid "github.com/satori/go.uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// telemetryStream is stream of objects of device to record
+type telemetryStream struct {
+	typeToProcess types.LoaderObjectType
+	appUUID       uuid.UUID
+}
+
+// telemetryApps returns UUIDs of applications to record logs of: applications from config
+// and, if loader supports it, applications with stored logs which may be removed from config
+func telemetryApps(loader loaders.Loader, appIDs []string) ([]uuid.UUID, error) {
+	var result []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, appID := range appIDs {
+		appUUID, err := uuid.FromString(appID)
+		if err != nil {
+			return nil, fmt.Errorf("incorrect app UUID %s: %w", appID, err)
+		}
+		seen[appUUID] = true
+		result = append(result, appUUID)
+	}
+	lister, ok := loader.(loaders.AppsLister)
+	if !ok {
+		return result, nil
+	}
+	stored, err := lister.ListApps()
+	if err != nil {
+		return nil, fmt.Errorf("cannot list apps with logs: %w", err)
+	}
+	for _, appUUID := range stored {
+		if !seen[appUUID] {
+			seen[appUUID] = true
+			result = append(result, appUUID)
+		}
+	}
+	return result, nil
+}
+
+// EdenTelemetryRecord saves objects sent by the device to controller into telemetry bundle.
+// If follow is not zero, new objects are saved during follow after existing ones.
+func (openEVEC *OpenEVEC) EdenTelemetryRecord(bundleFile string, follow time.Duration, since, until string) error {
+	changer := &adamChanger{}
+	ctrl, dev, err := changer.getControllerAndDevFromConfig(openEVEC.cfg)
+	if err != nil {
+		return fmt.Errorf("getControllerAndDevFromConfig: %w", err)
+	}
+	devUUID := dev.GetID()
+
+	q, err := buildQuery(ctrl, devUUID, nil, "", since, until)
+	if err != nil {
+		return fmt.Errorf("incorrect time range: %w", err)
+	}
+
+	streams := []telemetryStream{
+		{typeToProcess: types.LogsType},
+		{typeToProcess: types.InfoType},
+		{typeToProcess: types.MetricsType},
+		{typeToProcess: types.FlowLogType},
+		{typeToProcess: types.RequestType},
+	}
+	loader := ctrl.GetLoader()
+	loader.SetUUID(devUUID)
+	loader.SetTimeRange(equery.TimeRange(q))
+	appUUIDs, err := telemetryApps(loader, dev.GetApplicationInstances())
+	if err != nil {
+		return err
+	}
+	for _, appUUID := range appUUIDs {
+		streams = append(streams, telemetryStream{typeToProcess: types.AppsType, appUUID: appUUID})
+	}
+
+	if bundleFile == "" {
+		bundleFile = fmt.Sprintf("telemetry-%s-%s.tar.gz", devUUID, time.Now().Format("20060102-150405"))
+	}
+	f, err := os.Create(bundleFile)
+	if err != nil {
+		return fmt.Errorf("cannot create bundle: %w", err)
+	}
+	defer f.Close()
+	writer := loaders.NewBundleWriter(f, devUUID)
+
+	record := func(stream telemetryStream, timeout time.Duration) {
+		streamLoader := loader.Clone()
+		streamLoader.SetAppUUID(stream.appUUID)
+		// some kinds of objects may be absent, so do not stop on errors
+		if err := writer.Record(streamLoader, stream.appUUID, stream.typeToProcess, timeout); err != nil {
+			log.Warnf("cannot record objects of type %d: %s", stream.typeToProcess, err)
+		}
+	}
+	for _, stream := range streams {
+		record(stream, 0)
+	}
+	if follow > 0 {
+		log.Infof("Recording new objects during %s", follow)
+		var wg sync.WaitGroup
+		for _, stream := range streams {
+			wg.Add(1)
+			go func(stream telemetryStream) {
+				defer wg.Done()
+				record(stream, follow)
+			}(stream)
+		}
+		wg.Wait()
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("cannot write bundle: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("cannot write bundle: %w", err)
+	}
+	log.Infof("%d objects of device %s saved into %s", writer.Len(), devUUID, bundleFile)
+	return nil
+}
+
+// Telemetry types supported by EdenTelemetryReplay
+const (
+	TelemetryLog     = "log"
+	TelemetryInfo    = "info"
+	TelemetryMetric  = "metric"
+	TelemetryFlowLog = "flowlog"
+	TelemetryRequest = "request"
+	TelemetryApp     = "app"
+)
+
+// EdenTelemetryReplay prints objects of telemetryType from telemetry bundle matching query
+// from the oldest to the newest one. It reads only the bundle, so it works without controller and EVE.
+// appID selects application for TelemetryApp type.
+func (openEVEC *OpenEVEC) EdenTelemetryReplay(bundleFile, telemetryType, appID string, outputFormat types.OutputFormat, printFields []string, query, since, until string, args []string) error {
+	bundle, err := loaders.OpenBundle(bundleFile)
+	if err != nil {
+		return fmt.Errorf("cannot open telemetry bundle %s: %w", bundleFile, err)
+	}
+	devUUID, err := uuid.FromString(bundle.Index.DevUUID)
+	if err != nil {
+		return fmt.Errorf("incorrect device UUID %s in bundle: %w", bundle.Index.DevUUID, err)
+	}
+	loader := loaders.NewReplayLoader(bundle)
+	loader.SetUUID(devUUID)
+
+	q, err := buildQueryWithInfo(func(q map[string]string, handler einfo.HandlerFunc) error {
+		return einfo.InfoLast(loader.Clone(), q, einfo.ZInfoFind, handler)
+	}, args, query, since, until)
+	if err != nil {
+		return fmt.Errorf("incorrect query: %w", err)
+	}
+
+	switch telemetryType {
+	case TelemetryLog:
+		return elog.LogWatch(loader, q, func(le *elog.FullLogEntry) bool {
+			if printFields == nil {
+				elog.LogPrn(le, outputFormat)
+			} else {
+				elog.LogItemPrint(le, outputFormat, printFields).Print()
+			}
+			return false
+		}, 0)
+	case TelemetryInfo:
+		return einfo.InfoWatch(loader, q, einfo.ZInfoFind, func(im *info.ZInfoMsg) bool {
+			if printFields == nil {
+				einfo.ZInfoPrn(im, outputFormat)
+			} else {
+				einfo.ZInfoPrintFiltered(im, printFields).Print()
+			}
+			return false
+		}, 0)
+	case TelemetryMetric:
+		return emetric.MetricWatch(loader, q, func(mm *metrics.ZMetricMsg) bool {
+			if printFields == nil {
+				emetric.MetricPrn(mm, outputFormat)
+			} else {
+				emetric.MetricItemPrint(mm, printFields).Print()
+			}
+			return false
+		}, 0)
+	case TelemetryFlowLog:
+		return eflowlog.FlowLogWatch(loader, q, func(fm *flowlog.FlowMessage) bool {
+			if printFields == nil {
+				eflowlog.FlowLogPrn(fm, outputFormat)
+			} else {
+				eflowlog.FlowLogItemPrint(fm, printFields).Print()
+			}
+			return false
+		}, 0)
+	case TelemetryRequest:
+		requestFormat := erequest.RequestLines
+		if outputFormat == types.OutputFormatJSON {
+			requestFormat = erequest.RequestJSON
+		}
+		return erequest.RequestWatch(loader, q, func(req *types.APIRequest) bool {
+			erequest.RequestPrn(req, requestFormat)
+			return false
+		}, 0)
+	case TelemetryApp:
+		appUUID, err := uuid.FromString(appID)
+		if err != nil {
+			return fmt.Errorf("incorrect app UUID %q: %w", appID, err)
+		}
+		loader.SetAppUUID(appUUID)
+		return eapps.LogWatch(loader, q, func(le *logs.LogEntry) bool {
+			if printFields == nil {
+				eapps.LogPrn(le, outputFormat)
+			} else {
+				eapps.LogItemPrint(le, outputFormat, printFields).Print()
+			}
+			return false
+		}, 0)
+	default:
+		return fmt.Errorf("not implemented telemetry type %s", telemetryType)
+	}
+}
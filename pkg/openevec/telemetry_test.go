@@ -0,0 +1,48 @@
+package openevec_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller/loaders"
+	"github.com/lf-edge/eden/pkg/controller/types"
+	"github.com/lf-edge/eden/pkg/openevec"
+	"github.com/lf-edge/eve-api/go/info"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestEdenTelemetryReplay(t *testing.T) {
+	t.Parallel()
+
+	bundleFile := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	f, err := os.Create(bundleFile)
+	require.NoError(t, err)
+	writer := loaders.NewBundleWriter(f, uuid.Must(uuid.NewV4()))
+	data, err := proto.Marshal(&info.ZInfoMsg{
+		AtTimeStamp: timestamppb.New(time.Now()),
+		InfoContent: &info.ZInfoMsg_Dinfo{Dinfo: &info.ZInfoDevice{LastRebootTime: timestamppb.New(time.Now().Add(-time.Hour))}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, writer.Add(types.InfoType, uuid.Nil, data))
+	require.NoError(t, writer.Close())
+	require.NoError(t, f.Close())
+
+	// no controller is configured, only the bundle is used
+	openEVEC := openevec.CreateOpenEVEC(&openevec.EdenSetupArgs{})
+	assert.NoError(t, openEVEC.EdenTelemetryReplay(bundleFile, openevec.TelemetryInfo, "", types.OutputFormatJSON,
+		nil, "dinfo.lastRebootTime", "boot", "", nil))
+	assert.NoError(t, openEVEC.EdenTelemetryReplay(bundleFile, openevec.TelemetryLog, "", types.OutputFormatLines,
+		nil, "", "", "", nil))
+	assert.Error(t, openEVEC.EdenTelemetryReplay(bundleFile, openevec.TelemetryInfo, "", types.OutputFormatJSON,
+		nil, "dinfo >", "", "", nil))
+	assert.Error(t, openEVEC.EdenTelemetryReplay(bundleFile, openevec.TelemetryApp, "", types.OutputFormatJSON,
+		nil, "", "", "", nil))
+	assert.Error(t, openEVEC.EdenTelemetryReplay(bundleFile, "unknown", "", types.OutputFormatJSON,
+		nil, "", "", "", nil))
+}
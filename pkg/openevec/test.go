@@ -52,6 +52,7 @@ func InitVarsFromConfig(cfg *EdenSetupArgs) (*utils.ConfigVars, error) {
 	cv.AdamCaching = cfg.Adam.Caching.Enabled
 	cv.AdamCachingPrefix = cfg.Adam.Caching.Prefix
 	cv.AdamCachingRedis = cfg.Adam.Caching.Redis
+	cv.AdamReplay = utils.ResolveAbsPath(cfg.Adam.Replay)
 
 	cv.SSHKey = utils.ResolveAbsPath(cfg.Eden.SSHKey)
 	cv.EdenBinDir = cfg.Eden.BinDir
@@ -61,6 +62,11 @@ func InitVarsFromConfig(cfg *EdenSetupArgs) (*utils.ConfigVars, error) {
 	cv.EServerImageDist = utils.ResolveAbsPath(cfg.Eden.Images.EServerImageDist)
 	cv.EServerPort = strconv.Itoa(cfg.Eden.EServer.Port)
 	cv.EServerIP = cfg.Eden.EServer.IP
+	cv.EServerHTTPS = cfg.Eden.EServer.HTTPS
+	cv.EServerUser = cfg.Eden.EServer.User
+	cv.EServerPassword = cfg.Eden.EServer.Password
+	cv.EServerAdminUser = cfg.Eden.EServer.AdminUser
+	cv.EServerAdminPass = cfg.Eden.EServer.AdminPassword
 
 	cv.EveCert = utils.ResolveAbsPath(cfg.Eve.Cert)
 	cv.EveDeviceCert = utils.ResolveAbsPath(cfg.Eve.DeviceCert)
@@ -1,17 +1,22 @@
 package projects
 
-import "github.com/lf-edge/eden/pkg/device"
+import (
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/device"
+)
 
 //EdgeNodeDescription must be defined in config file
 type EdgeNodeDescription struct {
 	Key    string
 	Serial string
 	Model  string
+	// Controller of node in form of test.controller, the common one is used if empty
+	Controller string
 }
 
 //GetEdgeNode returns EdgeNode for provided EdgeNodeDescription based on onboarding key (if exists) or name
 func (nodeDescription *EdgeNodeDescription) GetEdgeNode(tc *TestContext) *device.Ctx {
-	ctrl := tc.GetController()
+	ctrl := tc.getModeController(nodeDescription.Controller)
 	if nodeDescription.Key != "" {
 		id, err := ctrl.DeviceGetByOnboard(nodeDescription.Key)
 		if err != nil {
@@ -38,6 +43,15 @@ func (tc *TestContext) WithNodeDescription(nodeDescription *EdgeNodeDescription)
 	}
 }
 
+//WithController sets controller to use for device instead of the common one
+func (tc *TestContext) WithController(ctrl controller.Cloud) EdgeNodeOption {
+	return func(d *device.Ctx) {
+		tc.mu.Lock()
+		tc.controllers[d] = ctrl
+		tc.mu.Unlock()
+	}
+}
+
 //WithCurrentProject sets project info
 func (tc *TestContext) WithCurrentProject() EdgeNodeOption {
 	return func(d *device.Ctx) {
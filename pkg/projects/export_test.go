@@ -0,0 +1,20 @@
+package projects
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/device"
+)
+
+// NewTestContextWithController creates TestContext using ctrl without loading of config
+func NewTestContextWithController(ctrl controller.Cloud) *TestContext {
+	tc := &TestContext{
+		cloud:       ctrl,
+		tests:       map[*device.Ctx]*testing.T{},
+		controllers: map[*device.Ctx]controller.Cloud{},
+		modeClouds:  map[string]controller.Cloud{},
+	}
+	tc.procBus = initBus(tc)
+	return tc
+}
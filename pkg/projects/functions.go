@@ -118,3 +118,28 @@ func SendFileSCP(ip *string, port *int, user, password, filename, destpath strin
 		return nil
 	}
 }
+
+// CheckConverged checks that EVE reports intended versions of all objects of edgeNode
+// it returns error (which means done) when state converged
+// StartTrackingState must be called before, otherwise the test of edgeNode fails
+func (tc *TestContext) CheckConverged(edgeNode *device.Ctx, callbacks ...Callback) ProcTimerFunc {
+	return func() error {
+		state := tc.GetState(edgeNode)
+		if state == nil {
+			tc.fail(edgeNode, "CheckConverged: state of %s is not tracked", edgeNode.GetID())
+			return nil
+		}
+		desired, err := GetDesiredConfig(tc.GetNodeController(edgeNode), edgeNode)
+		if err != nil {
+			tc.fail(edgeNode, "CheckConverged: %s", err)
+			return nil
+		}
+		if !state.Converged(desired) {
+			return nil
+		}
+		for _, clb := range callbacks {
+			clb()
+		}
+		return fmt.Errorf("state of %s converged", edgeNode.GetID())
+	}
+}
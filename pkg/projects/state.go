@@ -2,6 +2,7 @@ package projects
 
 import (
 	"reflect"
+	"sync"
 
 	"github.com/lf-edge/eden/pkg/controller/einfo"
 	"github.com/lf-edge/eden/pkg/controller/emetric"
@@ -29,7 +30,10 @@ type infoState struct {
 }
 
 // State aggregates device state
+// it is updated by info and metric processing functions and may be read concurrently,
+// slices returned by getters are not modified by later updates
 type State struct {
+	mu         sync.RWMutex
 	device     *device.Ctx
 	deviceInfo *infoState
 }
@@ -43,6 +47,8 @@ func (state *State) processInfo(infoMsg *info.ZInfoMsg) error {
 	if infoMsg.DevId != state.device.GetID().String() {
 		return nil
 	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
 	state.deviceInfo.LastInfoMessageTime = infoMsg.AtTimeStamp
 	switch infoMsg.GetZtype() {
 	case info.ZInfoTypes_ZiDevice:
@@ -51,6 +57,7 @@ func (state *State) processInfo(infoMsg *info.ZInfoMsg) error {
 		aInfo := infoMsg.GetAinfo()
 		for ind, app := range state.deviceInfo.Ainfo {
 			if app.AppID == aInfo.AppID {
+				state.deviceInfo.Ainfo = append([]*info.ZInfoApp(nil), state.deviceInfo.Ainfo...)
 				state.deviceInfo.Ainfo[ind] = aInfo
 				return nil
 			}
@@ -60,6 +67,7 @@ func (state *State) processInfo(infoMsg *info.ZInfoMsg) error {
 		niInfo := infoMsg.GetNiinfo()
 		for ind, ni := range state.deviceInfo.Niinfo {
 			if ni.NetworkID == niInfo.NetworkID {
+				state.deviceInfo.Niinfo = append([]*info.ZInfoNetworkInstance(nil), state.deviceInfo.Niinfo...)
 				state.deviceInfo.Niinfo[ind] = niInfo
 				return nil
 			}
@@ -69,6 +77,7 @@ func (state *State) processInfo(infoMsg *info.ZInfoMsg) error {
 		vInfo := infoMsg.GetVinfo()
 		for ind, volume := range state.deviceInfo.Vinfo {
 			if volume.Uuid == vInfo.Uuid {
+				state.deviceInfo.Vinfo = append([]*info.ZInfoVolume(nil), state.deviceInfo.Vinfo...)
 				state.deviceInfo.Vinfo[ind] = vInfo
 				return nil
 			}
@@ -78,6 +87,7 @@ func (state *State) processInfo(infoMsg *info.ZInfoMsg) error {
 		cInfo := infoMsg.GetCinfo()
 		for ind, contentTree := range state.deviceInfo.Cinfo {
 			if contentTree.Uuid == cInfo.Uuid {
+				state.deviceInfo.Cinfo = append([]*info.ZInfoContentTree(nil), state.deviceInfo.Cinfo...)
 				state.deviceInfo.Cinfo[ind] = cInfo
 				return nil
 			}
@@ -85,6 +95,7 @@ func (state *State) processInfo(infoMsg *info.ZInfoMsg) error {
 		state.deviceInfo.Cinfo = append(state.deviceInfo.Cinfo, cInfo)
 	case info.ZInfoTypes_ZiBlobList:
 		bInfoList := infoMsg.GetBinfo()
+		state.deviceInfo.Binfo = append([]*info.ZInfoBlob(nil), state.deviceInfo.Binfo...)
 	blobsLoop:
 		for _, newBlob := range bInfoList.Blob {
 			for ind, blob := range state.deviceInfo.Binfo {
@@ -118,6 +129,8 @@ func (state *State) processMetric(metricMsg *metrics.ZMetricMsg) error {
 	if metricMsg.DevID != state.device.GetID().String() {
 		return nil
 	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
 	state.deviceInfo.AppMetrics = metricMsg.GetAm()
 	state.deviceInfo.NetworkInstanceMetrics = metricMsg.GetNm()
 	state.deviceInfo.VolumeMetrics = metricMsg.GetVm()
@@ -142,56 +155,78 @@ func (state *State) GetMetricProcessingFunction() ProcMetricFunc {
 
 // GetDinfo get *info.ZInfoDevice from obtained info
 func (state *State) GetDinfo() *info.ZInfoDevice {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
 	return state.deviceInfo.Dinfo
 }
 
 // GetAinfoSlice get []*info.ZInfoApp from obtained info
 func (state *State) GetAinfoSlice() []*info.ZInfoApp {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
 	return state.deviceInfo.Ainfo
 }
 
 // GetNiinfoSlice get []*info.ZInfoNetworkInstance from obtained info
 func (state *State) GetNiinfoSlice() []*info.ZInfoNetworkInstance {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
 	return state.deviceInfo.Niinfo
 }
 
 // GetVinfoSlice get []*info.ZInfoVolume from obtained info
 func (state *State) GetVinfoSlice() []*info.ZInfoVolume {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
 	return state.deviceInfo.Vinfo
 }
 
 // GetCinfoSlice get []*info.ZInfoContentTree from obtained info
 func (state *State) GetCinfoSlice() []*info.ZInfoContentTree {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
 	return state.deviceInfo.Cinfo
 }
 
 // GetBinfoSlice get []*info.ZInfoBlob from obtained info
 func (state *State) GetBinfoSlice() []*info.ZInfoBlob {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
 	return state.deviceInfo.Binfo
 }
 
 // GetAppMetrics get []*metrics.AppMetric from obtained metrics
 func (state *State) GetAppMetrics() []*metrics.AppMetric {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
 	return state.deviceInfo.AppMetrics
 }
 
 // GetNetworkInstanceMetrics get []*metrics.ZMetricNetworkInstance from obtained metrics
 func (state *State) GetNetworkInstanceMetrics() []*metrics.ZMetricNetworkInstance {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
 	return state.deviceInfo.NetworkInstanceMetrics
 }
 
 // GetVolumeMetrics get []*metrics.ZMetricVolume from obtained metrics
 func (state *State) GetVolumeMetrics() []*metrics.ZMetricVolume {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
 	return state.deviceInfo.VolumeMetrics
 }
 
 // GetDeviceMetrics get *metrics.DeviceMetric from obtained metrics
 func (state *State) GetDeviceMetrics() *metrics.DeviceMetric {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
 	return state.deviceInfo.DeviceMetrics
 }
 
 // GetLastInfoTime get *timestamp.Timestamp for last received info
 func (state *State) GetLastInfoTime() *timestamppb.Timestamp {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
 	return state.deviceInfo.LastInfoMessageTime
 }
 
@@ -211,12 +246,16 @@ func (state *State) GetLastInfoTime() *timestamppb.Timestamp {
 // VolumeMetrics []*metrics.ZMetricVolume
 // DeviceMetrics *metrics.DeviceMetric
 func (state *State) LookUp(path string) (value reflect.Value, err error) {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
 	value, err = utils.LookUp(state.deviceInfo, path)
 	return
 }
 
 // CheckReady returns true in all needed information obtained from controller
 func (state *State) CheckReady() bool {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
 	if state.deviceInfo.Dinfo == nil {
 		return false
 	}
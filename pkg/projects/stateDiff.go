@@ -0,0 +1,364 @@
+package projects
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/lf-edge/eve-api/go/info"
+	"google.golang.org/protobuf/proto"
+)
+
+// ObjectKind is kind of object compared by State.Diff
+type ObjectKind string
+
+const (
+	// KindApp is application instance
+	KindApp ObjectKind = "app"
+	// KindVolume is volume
+	KindVolume ObjectKind = "volume"
+	// KindNetworkInstance is network instance
+	KindNetworkInstance ObjectKind = "network-instance"
+	// KindContentTree is content tree
+	KindContentTree ObjectKind = "content-tree"
+	// KindBaseOS is base OS of device
+	KindBaseOS ObjectKind = "baseos"
+)
+
+// ObjectDiff describes difference between intended and reported state of object
+type ObjectDiff struct {
+	Kind ObjectKind
+	UUID string
+	Name string
+	// Desired is false for objects reported by EVE, but absent in intended config
+	Desired bool
+	// Reported is false for objects EVE not reported yet
+	Reported    bool
+	WantVersion string
+	GotVersion  string
+	State       string
+	Progress    uint32
+	Errors      []string
+	// Converged is true if EVE reports intended version of object without errors
+	Converged bool
+}
+
+// String returns one-line description of difference
+func (od *ObjectDiff) String() string {
+	status := "converged"
+	switch {
+	case !od.Desired:
+		status = "not in config"
+	case !od.Reported:
+		status = "not reported"
+	case od.WantVersion != od.GotVersion:
+		status = fmt.Sprintf("version %s, want %s", od.GotVersion, od.WantVersion)
+	case len(od.Errors) > 0:
+		status = "error: " + strings.Join(od.Errors, "; ")
+	case !od.Converged:
+		status = fmt.Sprintf("in progress (%s %d%%)", od.State, od.Progress)
+	}
+	return fmt.Sprintf("%s %s (%s): %s", od.Kind, od.Name, od.UUID, status)
+}
+
+func errorDescriptions(errs ...*info.ErrorInfo) (result []string) {
+	for _, el := range errs {
+		if el != nil && el.Description != "" {
+			result = append(result, el.Description)
+		}
+	}
+	return
+}
+
+func (od *ObjectDiff) check(stateOk bool) {
+	od.Converged = od.Desired && od.Reported && od.WantVersion == od.GotVersion && len(od.Errors) == 0 && stateOk
+}
+
+// appDeleted returns true if EVE reports deletion of application
+func appDeleted(ai *info.ZInfoApp) bool {
+	return ai.State == info.ZSwState_INVALID
+}
+
+// volumeDeleted returns true if EVE reports deletion of volume
+func volumeDeleted(vi *info.ZInfoVolume) bool {
+	return vi.DisplayName == "" || vi.State == info.ZSwState_INVALID
+}
+
+// contentTreeDeleted returns true if EVE reports deletion of content tree
+func contentTreeDeleted(cti *info.ZInfoContentTree) bool {
+	return cti.DisplayName == "" || cti.State == info.ZSwState_INVALID
+}
+
+// networkInstanceDeleted returns true if EVE reports deletion of network instance
+func networkInstanceDeleted(nii *info.ZInfoNetworkInstance) bool {
+	return nii.State == info.ZNetworkInstanceState_ZNETINST_STATE_UNSPECIFIED ||
+		(!nii.Activated && nii.State != info.ZNetworkInstanceState_ZNETINST_STATE_INIT)
+}
+
+func (state *State) diffApps(desired []*config.AppInstanceConfig) (result []*ObjectDiff) {
+	reported := map[string]*info.ZInfoApp{}
+	for _, el := range state.deviceInfo.Ainfo {
+		reported[el.AppID] = el
+	}
+	for _, app := range desired {
+		od := &ObjectDiff{
+			Kind:        KindApp,
+			UUID:        app.Uuidandversion.GetUuid(),
+			Name:        app.Displayname,
+			Desired:     true,
+			WantVersion: app.Uuidandversion.GetVersion(),
+		}
+		if ai, ok := reported[od.UUID]; ok {
+			delete(reported, od.UUID)
+			od.Reported = true
+			od.GotVersion = ai.AppVersion
+			od.State = ai.State.String()
+			od.Errors = errorDescriptions(ai.AppErr...)
+			if app.Activate {
+				od.check(ai.State == info.ZSwState_RUNNING)
+			} else {
+				od.check(ai.State == info.ZSwState_HALTED || ai.State == info.ZSwState_INSTALLED)
+			}
+		}
+		result = append(result, od)
+	}
+	for _, ai := range reported {
+		if appDeleted(ai) {
+			continue
+		}
+		result = append(result, &ObjectDiff{
+			Kind:       KindApp,
+			UUID:       ai.AppID,
+			Name:       ai.AppName,
+			Reported:   true,
+			GotVersion: ai.AppVersion,
+			State:      ai.State.String(),
+			Errors:     errorDescriptions(ai.AppErr...),
+		})
+	}
+	return
+}
+
+func (state *State) diffVolumes(desired []*config.Volume) (result []*ObjectDiff) {
+	reported := map[string]*info.ZInfoVolume{}
+	for _, el := range state.deviceInfo.Vinfo {
+		reported[el.Uuid] = el
+	}
+	for _, volume := range desired {
+		od := &ObjectDiff{
+			Kind:        KindVolume,
+			UUID:        volume.Uuid,
+			Name:        volume.DisplayName,
+			Desired:     true,
+			WantVersion: strconv.FormatInt(volume.GenerationCount, 10),
+		}
+		if vi, ok := reported[od.UUID]; ok {
+			delete(reported, od.UUID)
+			od.Reported = true
+			od.GotVersion = strconv.FormatInt(vi.GenerationCount, 10)
+			od.State = vi.State.String()
+			od.Progress = vi.ProgressPercentage
+			od.Errors = errorDescriptions(vi.VolumeErr)
+			od.check(vi.State == info.ZSwState_CREATED_VOLUME)
+		}
+		result = append(result, od)
+	}
+	for _, vi := range reported {
+		if volumeDeleted(vi) {
+			continue
+		}
+		result = append(result, &ObjectDiff{
+			Kind:       KindVolume,
+			UUID:       vi.Uuid,
+			Name:       vi.DisplayName,
+			Reported:   true,
+			GotVersion: strconv.FormatInt(vi.GenerationCount, 10),
+			State:      vi.State.String(),
+			Progress:   vi.ProgressPercentage,
+			Errors:     errorDescriptions(vi.VolumeErr),
+		})
+	}
+	return
+}
+
+func (state *State) diffNetworkInstances(desired []*config.NetworkInstanceConfig) (result []*ObjectDiff) {
+	reported := map[string]*info.ZInfoNetworkInstance{}
+	for _, el := range state.deviceInfo.Niinfo {
+		reported[el.NetworkID] = el
+	}
+	for _, ni := range desired {
+		od := &ObjectDiff{
+			Kind:        KindNetworkInstance,
+			UUID:        ni.Uuidandversion.GetUuid(),
+			Name:        ni.Displayname,
+			Desired:     true,
+			WantVersion: ni.Uuidandversion.GetVersion(),
+		}
+		if nii, ok := reported[od.UUID]; ok {
+			delete(reported, od.UUID)
+			od.Reported = true
+			od.GotVersion = nii.NetworkVersion
+			od.State = nii.State.String()
+			od.Errors = errorDescriptions(nii.NetworkErr...)
+			if ni.Activate {
+				od.check(nii.Activated && nii.State == info.ZNetworkInstanceState_ZNETINST_STATE_ONLINE)
+			} else {
+				od.check(!nii.Activated)
+			}
+		}
+		result = append(result, od)
+	}
+	for _, nii := range reported {
+		if networkInstanceDeleted(nii) {
+			continue
+		}
+		result = append(result, &ObjectDiff{
+			Kind:       KindNetworkInstance,
+			UUID:       nii.NetworkID,
+			Name:       nii.Displayname,
+			Reported:   true,
+			GotVersion: nii.NetworkVersion,
+			State:      nii.State.String(),
+			Errors:     errorDescriptions(nii.NetworkErr...),
+		})
+	}
+	return
+}
+
+func (state *State) diffContentTrees(desired []*config.ContentTree) (result []*ObjectDiff) {
+	reported := map[string]*info.ZInfoContentTree{}
+	for _, el := range state.deviceInfo.Cinfo {
+		reported[el.Uuid] = el
+	}
+	for _, ct := range desired {
+		od := &ObjectDiff{
+			Kind:        KindContentTree,
+			UUID:        ct.Uuid,
+			Name:        ct.DisplayName,
+			Desired:     true,
+			WantVersion: strconv.FormatInt(ct.GenerationCount, 10),
+		}
+		if cti, ok := reported[od.UUID]; ok {
+			delete(reported, od.UUID)
+			od.Reported = true
+			od.GotVersion = strconv.FormatInt(cti.GenerationCount, 10)
+			od.State = cti.State.String()
+			od.Progress = cti.ProgressPercentage
+			od.Errors = errorDescriptions(cti.Err)
+			od.check(cti.State == info.ZSwState_LOADED || cti.State == info.ZSwState_INSTALLED)
+		}
+		result = append(result, od)
+	}
+	for _, cti := range reported {
+		if contentTreeDeleted(cti) {
+			continue
+		}
+		result = append(result, &ObjectDiff{
+			Kind:       KindContentTree,
+			UUID:       cti.Uuid,
+			Name:       cti.DisplayName,
+			Reported:   true,
+			GotVersion: strconv.FormatInt(cti.GenerationCount, 10),
+			State:      cti.State.String(),
+			Progress:   cti.ProgressPercentage,
+			Errors:     errorDescriptions(cti.Err),
+		})
+	}
+	return
+}
+
+// diffBaseOS compares intended version of EVE with partitions reported in device info
+func (state *State) diffBaseOS(id, version string, activate bool) *ObjectDiff {
+	od := &ObjectDiff{
+		Kind:        KindBaseOS,
+		UUID:        id,
+		Name:        version,
+		Desired:     true,
+		WantVersion: version,
+	}
+	if state.deviceInfo.Dinfo == nil {
+		return od
+	}
+	for _, sw := range state.deviceInfo.Dinfo.SwList {
+		if sw.ShortVersion != version {
+			continue
+		}
+		od.Reported = true
+		od.GotVersion = sw.ShortVersion
+		od.State = sw.Status.String()
+		od.Progress = sw.DownloadProgress
+		od.Errors = errorDescriptions(sw.SwErr)
+		if activate {
+			od.check(sw.Activated)
+		} else {
+			od.check(sw.Status >= info.ZSwState_INSTALLED)
+		}
+		if od.Converged {
+			break
+		}
+	}
+	if !od.Reported {
+		// report version of active partition
+		for _, sw := range state.deviceInfo.Dinfo.SwList {
+			if sw.Activated {
+				od.GotVersion = sw.ShortVersion
+			}
+		}
+	}
+	return od
+}
+
+// Diff compares intended config of device with state reported by EVE
+// and returns differences for every object sorted by kind and name.
+// Objects reported by EVE, but absent in desired are returned as not Desired,
+// except of objects which EVE reports as deleted (detected in the same way as in pkg/eve).
+func (state *State) Diff(desired *config.EdgeDevConfig) []*ObjectDiff {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+	var result []*ObjectDiff
+	result = append(result, state.diffApps(desired.GetApps())...)
+	result = append(result, state.diffVolumes(desired.GetVolumes())...)
+	result = append(result, state.diffNetworkInstances(desired.GetNetworkInstances())...)
+	result = append(result, state.diffContentTrees(desired.GetContentInfo())...)
+	if baseOS := desired.GetBaseos(); baseOS != nil {
+		result = append(result, state.diffBaseOS(baseOS.ContentTreeUuid, baseOS.BaseOsVersion, baseOS.Activate))
+	}
+	for _, baseOSConfig := range desired.GetBase() {
+		result = append(result, state.diffBaseOS(baseOSConfig.Uuidandversion.GetUuid(), baseOSConfig.BaseOSVersion, baseOSConfig.Activate))
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Kind != result[j].Kind {
+			return result[i].Kind < result[j].Kind
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// Converged returns true if EVE reports intended versions of all objects
+// of desired config without errors and no other objects
+func (state *State) Converged(desired *config.EdgeDevConfig) bool {
+	for _, od := range state.Diff(desired) {
+		if !od.Converged {
+			return false
+		}
+	}
+	return true
+}
+
+// GetDesiredConfig returns config of device intended by controller
+func GetDesiredConfig(ctrl controller.Cloud, dev *device.Ctx) (*config.EdgeDevConfig, error) {
+	data, err := ctrl.GetConfigBytes(dev, false)
+	if err != nil {
+		return nil, fmt.Errorf("GetConfigBytes: %w", err)
+	}
+	var desired config.EdgeDevConfig
+	if err := proto.Unmarshal(data, &desired); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal config: %w", err)
+	}
+	return &desired, nil
+}
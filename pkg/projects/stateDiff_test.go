@@ -0,0 +1,139 @@
+package projects_test
+
+import (
+	"testing"
+
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/projects"
+	"github.com/lf-edge/eve-api/go/config"
+	"github.com/lf-edge/eve-api/go/info"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateDiff(t *testing.T) {
+	dev := device.CreateEdgeNode()
+	dev.SetID(uuid.Must(uuid.NewV4()))
+	state := projects.InitState(dev)
+	process := state.GetInfoProcessingFunction()
+
+	appID := uuid.Must(uuid.NewV4()).String()
+	volumeID := uuid.Must(uuid.NewV4()).String()
+	staleVolumeID := uuid.Must(uuid.NewV4()).String()
+	desired := &config.EdgeDevConfig{
+		Apps: []*config.AppInstanceConfig{{
+			Uuidandversion: &config.UUIDandVersion{Uuid: appID, Version: "2"},
+			Displayname:    "app",
+			Activate:       true,
+		}},
+		Volumes: []*config.Volume{{Uuid: volumeID, DisplayName: "volume", GenerationCount: 1}},
+	}
+
+	diffs := state.Diff(desired)
+	require.Len(t, diffs, 2)
+	for _, od := range diffs {
+		assert.False(t, od.Reported)
+		assert.False(t, od.Converged)
+	}
+
+	infoMsg := func(ztype info.ZInfoTypes, content interface{}) *info.ZInfoMsg {
+		msg := &info.ZInfoMsg{DevId: dev.GetID().String(), Ztype: ztype}
+		switch el := content.(type) {
+		case *info.ZInfoApp:
+			msg.InfoContent = &info.ZInfoMsg_Ainfo{Ainfo: el}
+		case *info.ZInfoVolume:
+			msg.InfoContent = &info.ZInfoMsg_Vinfo{Vinfo: el}
+		}
+		return msg
+	}
+	require.NoError(t, process(infoMsg(info.ZInfoTypes_ZiApp, &info.ZInfoApp{
+		AppID: appID, AppVersion: "1", AppName: "app", State: info.ZSwState_RUNNING,
+	})))
+	require.NoError(t, process(infoMsg(info.ZInfoTypes_ZiVolume, &info.ZInfoVolume{
+		Uuid: volumeID, DisplayName: "volume", GenerationCount: 1,
+		State: info.ZSwState_DOWNLOAD_STARTED, ProgressPercentage: 40,
+	})))
+	require.NoError(t, process(infoMsg(info.ZInfoTypes_ZiVolume, &info.ZInfoVolume{
+		Uuid: staleVolumeID, DisplayName: "stale", State: info.ZSwState_CREATED_VOLUME,
+	})))
+
+	diffs = state.Diff(desired)
+	require.Len(t, diffs, 3)
+	byID := map[string]*projects.ObjectDiff{}
+	for _, od := range diffs {
+		byID[od.UUID] = od
+	}
+	assert.Equal(t, "1", byID[appID].GotVersion)
+	assert.False(t, byID[appID].Converged)
+	assert.Equal(t, uint32(40), byID[volumeID].Progress)
+	assert.False(t, byID[volumeID].Converged)
+	assert.False(t, byID[staleVolumeID].Desired)
+	assert.False(t, state.Converged(desired))
+
+	require.NoError(t, process(infoMsg(info.ZInfoTypes_ZiApp, &info.ZInfoApp{
+		AppID: appID, AppVersion: "2", AppName: "app", State: info.ZSwState_RUNNING,
+		AppErr: []*info.ErrorInfo{{Description: "failed"}},
+	})))
+	require.NoError(t, process(infoMsg(info.ZInfoTypes_ZiVolume, &info.ZInfoVolume{
+		Uuid: volumeID, DisplayName: "volume", GenerationCount: 1, State: info.ZSwState_CREATED_VOLUME,
+	})))
+	desired.Volumes = append(desired.Volumes, &config.Volume{Uuid: staleVolumeID, DisplayName: "stale"})
+	for _, od := range state.Diff(desired) {
+		if od.UUID == appID {
+			assert.Equal(t, []string{"failed"}, od.Errors)
+			assert.False(t, od.Converged)
+		} else {
+			assert.True(t, od.Converged, od.String())
+		}
+	}
+
+	require.NoError(t, process(infoMsg(info.ZInfoTypes_ZiApp, &info.ZInfoApp{
+		AppID: appID, AppVersion: "2", AppName: "app", State: info.ZSwState_RUNNING,
+	})))
+	assert.True(t, state.Converged(desired))
+
+	// remove app and volume from config, EVE still reports them until deletion is done
+	desired.Apps = nil
+	desired.Volumes = desired.Volumes[:1]
+	assert.False(t, state.Converged(desired))
+	require.NoError(t, process(infoMsg(info.ZInfoTypes_ZiApp, &info.ZInfoApp{
+		AppID: appID, AppVersion: "2", AppName: "app", State: info.ZSwState_INVALID,
+	})))
+	require.NoError(t, process(infoMsg(info.ZInfoTypes_ZiVolume, &info.ZInfoVolume{
+		Uuid: staleVolumeID, State: info.ZSwState_INITIAL,
+	})))
+	diffs = state.Diff(desired)
+	require.Len(t, diffs, 1)
+	assert.Equal(t, volumeID, diffs[0].UUID)
+	assert.True(t, state.Converged(desired))
+}
+
+func TestStateConcurrentAccess(t *testing.T) {
+	dev := device.CreateEdgeNode()
+	dev.SetID(uuid.Must(uuid.NewV4()))
+	state := projects.InitState(dev)
+	process := state.GetInfoProcessingFunction()
+	appID := uuid.Must(uuid.NewV4()).String()
+	desired := &config.EdgeDevConfig{Apps: []*config.AppInstanceConfig{{
+		Uuidandversion: &config.UUIDandVersion{Uuid: appID, Version: "1"},
+		Activate:       true,
+	}}}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			_ = process(&info.ZInfoMsg{DevId: dev.GetID().String(), Ztype: info.ZInfoTypes_ZiApp,
+				InfoContent: &info.ZInfoMsg_Ainfo{Ainfo: &info.ZInfoApp{AppID: appID, AppVersion: "1", State: info.ZSwState_RUNNING}}})
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		state.Converged(desired)
+		for _, app := range state.GetAinfoSlice() {
+			assert.Equal(t, appID, app.AppID)
+		}
+	}
+	<-done
+	assert.True(t, state.Converged(desired))
+}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -13,11 +14,11 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/lf-edge/eden/pkg/controller"
-	"github.com/lf-edge/eden/pkg/controller/adam"
 	"github.com/lf-edge/eden/pkg/defaults"
 	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/edensdn"
 	"github.com/lf-edge/eden/pkg/utils"
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
 	"github.com/spf13/viper"
 )
 
@@ -25,38 +26,90 @@ import (
 func GetControllerMode(controllerMode string) (modeType, modeURL string, err error) {
 	params := utils.GetParams(controllerMode, defaults.DefaultControllerModePattern)
 	if len(params) == 0 {
-		return "", "", fmt.Errorf("cannot parse mode (not [file|proto|adam|zedcloud]://<URL>): %s", controllerMode)
+		return "", "", fmt.Errorf("cannot parse mode (not [file|proto|adam|rest|embedded]://<URL>): %s", controllerMode)
 	}
 	ok := false
 	if modeType, ok = params["Type"]; !ok {
-		return "", "", fmt.Errorf("cannot parse modeType (not [file|proto|adam|zedcloud]://<URL>): %s", controllerMode)
+		return "", "", fmt.Errorf("cannot parse modeType (not [file|proto|adam|rest|embedded]://<URL>): %s", controllerMode)
 	}
 	if modeURL, ok = params["URL"]; !ok {
-		return "", "", fmt.Errorf("cannot parse modeURL (not [file|proto|adam|zedcloud]://<URL>): %s", controllerMode)
+		return "", "", fmt.Errorf("cannot parse modeURL (not [file|proto|adam|rest|embedded]://<URL>): %s", controllerMode)
 	}
 	return
 }
 
+// NewCloud creates controller for controllerMode ([file|proto|adam|rest|embedded]://<URL>)
+// taking other parameters from config
+func NewCloud(controllerMode string) (controller.Cloud, error) {
+	modeType, modeURL, err := GetControllerMode(controllerMode)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := utils.InitVars()
+	if err != nil {
+		return nil, fmt.Errorf("utils.InitVars: %w", err)
+	}
+	switch modeType {
+	case "", "adam":
+		if modeURL != "" {
+			ipPort := strings.Split(modeURL, ":")
+			if ipPort[0] == "" {
+				return nil, fmt.Errorf("cannot get ip/hostname from %s", modeURL)
+			}
+			vars.AdamIP = ipPort[0]
+			vars.AdamPort = "80"
+			if len(ipPort) > 1 {
+				vars.AdamPort = ipPort[1]
+			}
+		}
+	case "rest":
+		if modeURL != "" {
+			vars.RestURL = fmt.Sprintf("https://%s", modeURL)
+		}
+	case "embedded":
+		if modeURL != "" {
+			vars.AdamDir = modeURL
+		}
+	default:
+		return nil, fmt.Errorf("not implemented controller type %s", modeType)
+	}
+	ctrl, err := controller.NewControllerByType(modeType)
+	if err != nil {
+		return nil, fmt.Errorf("controller.NewControllerByType: %w", err)
+	}
+	ctx := &controller.CloudCtx{Controller: ctrl}
+	ctx.SetVars(vars)
+	if err := ctx.InitWithVars(vars); err != nil {
+		return nil, fmt.Errorf("cloud.InitWithVars: %w", err)
+	}
+	ctx.GetAllNodes()
+	return ctx, nil
+}
+
 //TestContext is main structure for running tests
 type TestContext struct {
-	cloud     controller.Cloud
-	project   *Project
-	nodes     []*device.Ctx
-	sdnClient *edensdn.SdnClient
-	withSdn   bool
-	procBus   *processingBus
-	tests     map[*device.Ctx]*testing.T
-	states    map[*device.Ctx]*State
-	stopTime  time.Time
-	addTime   time.Duration
+	cloud       controller.Cloud
+	project     *Project
+	nodes       []*device.Ctx
+	sdnClient   *edensdn.SdnClient
+	withSdn     bool
+	procBus     *processingBus
+	tests       map[*device.Ctx]*testing.T
+	states      map[*device.Ctx]*State
+	addTime     time.Duration
+	mu          sync.Mutex
+	controllers map[*device.Ctx]controller.Cloud
+	modeClouds  map[string]controller.Cloud
+	report      []ProcResult
 }
 
 //NewTestContext creates new TestContext
 func NewTestContext() *TestContext {
 	var (
-		err       error
-		sdnClient *edensdn.SdnClient
-		withSdn   bool
+		err            error
+		sdnClient      *edensdn.SdnClient
+		withSdn        bool
+		controllerType string
 	)
 	viperLoaded := false
 	if edenConfigEnv := os.Getenv(defaults.DefaultConfigEnv); edenConfigEnv != "" {
@@ -72,24 +125,33 @@ func NewTestContext() *TestContext {
 		if err != nil {
 			log.Debug(err)
 		}
-		if modeType != "" {
-			if modeType != "adam" {
-				log.Fatalf("Not implemented controller type %s", modeType)
+		switch modeType {
+		case "", "adam":
+			if modeURL != "" { //overwrite config only if url defined
+				ipPort := strings.Split(modeURL, ":")
+				ip := ipPort[0]
+				if ip == "" {
+					log.Fatalf("cannot get ip/hostname from %s", modeURL)
+				}
+				port := "80"
+				if len(ipPort) > 1 {
+					port = ipPort[1]
+				}
+				viper.Set("adam.ip", ip)
+				viper.Set("adam.port", port)
 			}
-		}
-		if modeURL != "" { //overwrite config only if url defined
-			ipPort := strings.Split(modeURL, ":")
-			ip := ipPort[0]
-			if ip == "" {
-				log.Fatalf("cannot get ip/hostname from %s", modeURL)
+		case "rest":
+			if modeURL != "" { //overwrite config only if url defined
+				viper.Set("rest.url", fmt.Sprintf("https://%s", modeURL))
 			}
-			port := "80"
-			if len(ipPort) > 1 {
-				port = ipPort[1]
+		case "embedded":
+			if modeURL != "" { //overwrite directory to store data only if url defined
+				viper.Set("adam.dist", modeURL)
 			}
-			viper.Set("adam.ip", ip)
-			viper.Set("adam.port", port)
+		default:
+			log.Fatalf("Not implemented controller type %s", modeType)
 		}
+		controllerType = modeType
 		devModel := viper.GetString("eve.devmodel")
 		eveRemote := viper.GetBool("eve.remote")
 		withSdn = !viper.GetBool("sdn.disable") &&
@@ -101,9 +163,10 @@ func NewTestContext() *TestContext {
 			sdnSourceDir := utils.ResolveAbsPath(viper.GetString("sdn.source-dir"))
 			sdnSSHKeyPath := filepath.Join(sdnSourceDir, "vm/cert/ssh/id_rsa")
 			sdnClient = &edensdn.SdnClient{
-				SSHPort:    uint16(sdnSSHPort),
-				SSHKeyPath: sdnSSHKeyPath,
-				MgmtPort:   uint16(sdnMgmtPort),
+				SSHPort:     uint16(sdnSSHPort),
+				SSHKeyPath:  sdnSSHKeyPath,
+				MgmtPort:    uint16(sdnMgmtPort),
+				EVEInstance: viper.GetString("sdn.eve-instance"),
 			}
 		}
 	}
@@ -111,17 +174,23 @@ func NewTestContext() *TestContext {
 	if err != nil {
 		log.Fatalf("utils.InitVars: %s", err)
 	}
-	ctx := &controller.CloudCtx{Controller: &adam.Ctx{}}
+	ctrl, err := controller.NewControllerByType(controllerType)
+	if err != nil {
+		log.Fatalf("controller.NewControllerByType: %s", err)
+	}
+	ctx := &controller.CloudCtx{Controller: ctrl}
 	ctx.SetVars(vars)
 	if err := ctx.InitWithVars(vars); err != nil {
 		log.Fatalf("cloud.InitWithVars: %s", err)
 	}
 	ctx.GetAllNodes()
 	tstCtx := &TestContext{
-		cloud:     ctx,
-		tests:     map[*device.Ctx]*testing.T{},
-		sdnClient: sdnClient,
-		withSdn:   withSdn,
+		cloud:       ctx,
+		tests:       map[*device.Ctx]*testing.T{},
+		sdnClient:   sdnClient,
+		withSdn:     withSdn,
+		controllers: map[*device.Ctx]controller.Cloud{},
+		modeClouds:  map[string]controller.Cloud{},
 	}
 	tstCtx.procBus = initBus(tstCtx)
 	return tstCtx
@@ -134,7 +203,8 @@ func (tc *TestContext) GetNodeDescriptions() (nodes []*EdgeNodeDescription) {
 			eveKey := viper.GetString(fmt.Sprintf("test.eve.%s.onboard-cert", name))
 			eveSerial := viper.GetString(fmt.Sprintf("test.eve.%s.serial", name))
 			eveModel := viper.GetString(fmt.Sprintf("test.eve.%s.model", name))
-			nodes = append(nodes, &EdgeNodeDescription{Key: eveKey, Serial: eveSerial, Model: eveModel})
+			eveController := viper.GetString(fmt.Sprintf("test.eve.%s.controller", name))
+			nodes = append(nodes, &EdgeNodeDescription{Key: eveKey, Serial: eveSerial, Model: eveModel, Controller: eveController})
 		}
 	} else {
 		log.Debug("NodeDescriptions not found. Will use default one.")
@@ -155,6 +225,66 @@ func (tc *TestContext) GetController() controller.Cloud {
 	return tc.cloud
 }
 
+//GetNodeController returns controller of edgeNode
+//or the common one if edgeNode has no own controller
+func (tc *TestContext) GetNodeController(edgeNode *device.Ctx) controller.Cloud {
+	tc.mu.Lock()
+	ctrl, ok := tc.controllers[edgeNode]
+	tc.mu.Unlock()
+	if ok {
+		return ctrl
+	}
+	return tc.GetController()
+}
+
+//getModeController returns controller for controllerMode creating it if needed,
+//empty mode means the common controller
+func (tc *TestContext) getModeController(controllerMode string) controller.Cloud {
+	if controllerMode == "" {
+		return tc.GetController()
+	}
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	if ctrl, ok := tc.modeClouds[controllerMode]; ok {
+		return ctrl
+	}
+	ctrl, err := NewCloud(controllerMode)
+	if err != nil {
+		log.Fatalf("NewCloud %s: %s", controllerMode, err)
+	}
+	tc.modeClouds[controllerMode] = ctrl
+	return ctrl
+}
+
+//getTest returns *testing.T assigned to edgeNode or nil
+func (tc *TestContext) getTest(edgeNode *device.Ctx) *testing.T {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.tests[edgeNode]
+}
+
+//fail fails test assigned to edgeNode or exits if there is no one
+//and wakes up WaitForProc to stop waiting
+func (tc *TestContext) fail(edgeNode *device.Ctx, format string, args ...interface{}) {
+	t := tc.getTest(edgeNode)
+	if t == nil {
+		log.Fatalf(format, args...)
+	}
+	t.Errorf(format, args...)
+	tc.procBus.notify()
+}
+
+//getTests returns copy of map of *testing.T assigned to nodes
+func (tc *TestContext) getTests() map[*device.Ctx]*testing.T {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tests := make(map[*device.Ctx]*testing.T, len(tc.tests))
+	for node, t := range tc.tests {
+		tests[node] = t
+	}
+	return tests
+}
+
 //InitProject init project object with defined name
 func (tc *TestContext) InitProject(name string) {
 	tc.project = &Project{name: name}
@@ -163,10 +293,12 @@ func (tc *TestContext) InitProject(name string) {
 //AddEdgeNodesFromDescription adds EdgeNodes from description in test.eve param
 func (tc *TestContext) AddEdgeNodesFromDescription() {
 	for _, node := range tc.GetNodeDescriptions() {
+		ctrl := tc.getModeController(node.Controller)
 		edgeNode := node.GetEdgeNode(tc)
 		if edgeNode == nil {
-			edgeNode = tc.NewEdgeNode(tc.WithNodeDescription(node), tc.WithCurrentProject())
+			edgeNode = tc.NewEdgeNode(tc.WithController(ctrl), tc.WithNodeDescription(node), tc.WithCurrentProject())
 		} else {
+			tc.WithController(ctrl)(edgeNode)
 			edgeNode.SetProject(tc.project.name)
 		}
 
@@ -186,7 +318,9 @@ type GetEdgeNodeOpts func(*device.Ctx) bool
 //WithTest assign *testing.T for device
 func (tc *TestContext) WithTest(t *testing.T) GetEdgeNodeOpts {
 	return func(d *device.Ctx) bool {
+		tc.mu.Lock()
 		tc.tests[d] = t
+		tc.mu.Unlock()
 		return true
 	}
 }
@@ -207,6 +341,8 @@ Node:
 
 //AddNode add node to test context
 func (tc *TestContext) AddNode(node *device.Ctx) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
 	tc.nodes = append(tc.nodes, node)
 }
 
@@ -234,13 +370,13 @@ func (tc *TestContext) NewEdgeNode(opts ...EdgeNodeOption) *device.Ctx {
 //ConfigSync send config to controller
 func (tc *TestContext) ConfigSync(edgeNode *device.Ctx) {
 	if edgeNode.GetState() == device.NotOnboarded {
-		if err := tc.GetController().OnBoardDev(edgeNode); err != nil {
+		if err := tc.GetNodeController(edgeNode).OnBoardDev(edgeNode); err != nil {
 			log.Fatalf("OnBoardDev %s", err)
 		}
 	} else {
 		log.Debugf("Device %s onboarded", edgeNode.GetID().String())
 	}
-	if err := tc.GetController().ConfigSync(edgeNode); err != nil {
+	if err := tc.GetNodeController(edgeNode).ConfigSync(edgeNode); err != nil {
 		log.Fatalf("Cannot send config of %s", edgeNode.GetID())
 	}
 }
@@ -250,44 +386,124 @@ func (tc *TestContext) ExpandOnSuccess(secs int) {
 	tc.addTime = time.Duration(secs) * time.Second
 }
 
+//SetNodeTimeout sets time to wait for Procs of edgeNode in WaitForProc
+//instead of the common one
+func (tc *TestContext) SetNodeTimeout(edgeNode *device.Ctx, secs int) {
+	nb := tc.procBus.getNode(edgeNode)
+	nb.Lock()
+	nb.timeout = time.Duration(secs) * time.Second
+	nb.Unlock()
+}
+
+//GetProcReport returns results of Procs of all nodes from the last WaitForProc
+func (tc *TestContext) GetProcReport() []ProcResult {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	return tc.report
+}
+
 //WaitForProcWithErrorCallback blocking execution until the time elapses or all Procs gone
-//and fires callback in case of timeout
+//and fires callback in case of timeout. Every node waits for its Procs until its own
+//deadline (see SetNodeTimeout), tests of nodes which did not finish in time fail
+//with report of their Procs
 func (tc *TestContext) WaitForProcWithErrorCallback(secs int, callback Callback) {
 	defer func() { tc.addTime = 0 }() //reset addTime on exit
 	defer tc.procBus.clean()
 	timeout := time.Duration(secs) * time.Second
-	tc.stopTime = time.Now().Add(timeout)
+	startTime := time.Now()
+	// failures of tests are not signaled, so we check them periodically
 	ticker := time.NewTicker(defaults.DefaultRepeatTimeout)
 	defer ticker.Stop()
-	waitChan := make(chan struct{}, 1)
-	go func() {
-		tc.procBus.wg.Wait()
-		waitChan <- struct{}{}
-	}()
+	timedOut := map[*device.Ctx]bool{}
 	for {
-		select {
-		case <-waitChan:
-			for node, el := range tc.tests {
-				el.Logf("done for device %s", node.GetID())
+		done := true
+		var nextDeadline time.Time
+		for node, nb := range tc.procBus.getNodes() {
+			if timedOut[node] {
+				continue
 			}
-			return
-		case <-ticker.C:
-			for _, el := range tc.tests {
-				if el.Failed() {
-					// if one of tests failed, we are failed
-					callback()
-					return
+			nb.Lock()
+			if nb.deadline.IsZero() {
+				if nb.timeout != 0 {
+					nb.deadline = startTime.Add(nb.timeout)
+				} else {
+					nb.deadline = startTime.Add(timeout)
 				}
 			}
-			if time.Now().After(tc.stopTime) {
+			pending, deadline := nb.pending, nb.deadline
+			nb.Unlock()
+			if pending <= 0 {
+				continue
+			}
+			if time.Now().After(deadline) {
+				timedOut[node] = true
+				continue
+			}
+			done = false
+			if nextDeadline.IsZero() || deadline.Before(nextDeadline) {
+				nextDeadline = deadline
+			}
+		}
+		if done {
+			break
+		}
+		timer := time.NewTimer(time.Until(nextDeadline))
+		select {
+		case <-tc.procBus.changed:
+		case <-timer.C:
+		case <-ticker.C:
+		}
+		timer.Stop()
+		for _, el := range tc.getTests() {
+			if el.Failed() {
+				// if one of tests failed, we are failed
+				tc.saveReport()
 				callback()
-				for _, el := range tc.tests {
-					el.Errorf("WaitForProcWithErrorCallback terminated by timeout %s", timeout)
-				}
 				return
 			}
 		}
 	}
+	report := tc.saveReport()
+	tests := tc.getTests()
+	for node, el := range tests {
+		if !timedOut[node] {
+			el.Logf("done for device %s", node.GetID())
+		}
+	}
+	if len(timedOut) == 0 {
+		return
+	}
+	for _, result := range report {
+		log.Info(result)
+	}
+	callback()
+	for node := range timedOut {
+		var nodeReport []string
+		for _, result := range report {
+			if result.Node == node {
+				nodeReport = append(nodeReport, result.String())
+			}
+		}
+		msg := fmt.Sprintf("WaitForProcWithErrorCallback terminated by timeout for device %s:\n%s",
+			node.GetID(), strings.Join(nodeReport, "\n"))
+		if t, ok := tests[node]; ok {
+			t.Error(msg)
+			continue
+		}
+		// node without own test fails all of them
+		for _, el := range tests {
+			el.Error(msg)
+		}
+	}
+}
+
+//saveReport saves report of Procs to return in GetProcReport
+func (tc *TestContext) saveReport() []ProcResult {
+	report := tc.procBus.report()
+	tc.mu.Lock()
+	tc.report = report
+	tc.mu.Unlock()
+	return report
 }
 
 //WaitForProc blocking execution until the time elapses or all Procs gone
@@ -295,12 +511,9 @@ func (tc *TestContext) WaitForProcWithErrorCallback(secs int, callback Callback)
 func (tc *TestContext) WaitForProc(secs int) {
 	timeout := time.Duration(secs) * time.Second
 	callback := func() {
-		if len(tc.tests) == 0 {
+		if len(tc.getTests()) == 0 {
 			log.Fatalf("WaitForProc terminated by timeout %s", timeout)
 		}
-		for _, el := range tc.tests {
-			el.Errorf("WaitForProc terminated by timeout %s", timeout)
-		}
 	}
 	tc.WaitForProcWithErrorCallback(secs, callback)
 }
@@ -344,14 +557,11 @@ func (tc *TestContext) StartTrackingState(onlyNewElements bool) {
 		tc.states[dev] = curState
 		if !onlyNewElements {
 			//process all events from controller
-			_ = tc.GetController().InfoLastCallback(dev.GetID(), map[string]string{}, curState.getProcessorInfo())
-			_ = tc.GetController().MetricLastCallback(dev.GetID(), map[string]string{}, curState.getProcessorMetric())
-		}
-		if _, exists := tc.procBus.proc[dev]; !exists {
-			tc.procBus.initCheckers(dev)
+			_ = tc.GetNodeController(dev).InfoLastCallback(dev.GetID(), map[string]string{}, curState.getProcessorInfo())
+			_ = tc.GetNodeController(dev).MetricLastCallback(dev.GetID(), map[string]string{}, curState.getProcessorMetric())
 		}
-		tc.procBus.proc[dev] = append(tc.procBus.proc[dev], &absFunc{proc: curState.GetInfoProcessingFunction(), disabled: false, states: true})
-		tc.procBus.proc[dev] = append(tc.procBus.proc[dev], &absFunc{proc: curState.GetMetricProcessingFunction(), disabled: false, states: true})
+		tc.procBus.addStateProc(dev, curState.GetInfoProcessingFunction())
+		tc.procBus.addStateProc(dev, curState.GetMetricProcessingFunction())
 	}
 }
 
@@ -374,17 +584,18 @@ func (tc *TestContext) WaitForState(edgeNode *device.Ctx, secs int) {
 	}()
 	select {
 	case <-waitChan:
-		if el, isOk := tc.tests[edgeNode]; !isOk {
+		if el := tc.getTest(edgeNode); el == nil {
 			log.Println("done waiting for State")
 		} else {
 			el.Logf("done waiting for State")
 		}
 		return
 	case <-time.After(timeout):
-		if len(tc.tests) == 0 {
+		tests := tc.getTests()
+		if len(tests) == 0 {
 			log.Fatalf("WaitForState terminated by timeout %s", timeout)
 		}
-		for _, el := range tc.tests {
+		for _, el := range tests {
 			el.Fatalf("WaitForState terminated by timeout %s", timeout)
 		}
 		return
@@ -453,3 +664,76 @@ func (tc *TestContext) PortForwardCommand(cmd func(fwdPort uint16) error,
 	defer closeTunnel()
 	return cmd(localPort)
 }
+
+// RunChaosScenario loads chaos scenario from the given YAML file, submits it to Eden-SDN
+// and waits for it to finish, logging every executed step.
+// If the scenario does not finish within the timeout (zero means no limit), it is stopped
+// (i.e. all injected faults are reverted) and an error is returned.
+func (tc *TestContext) RunChaosScenario(scenarioFile string, timeout time.Duration) error {
+	if !tc.withSdn {
+		return fmt.Errorf("chaos scenario requires Eden-SDN, which is not enabled")
+	}
+	scenario, err := edensdn.LoadChaosScenarioFromFile(scenarioFile)
+	if err != nil {
+		return err
+	}
+	if err = tc.sdnClient.RunChaosScenario(scenario); err != nil {
+		return err
+	}
+	status, err := tc.sdnClient.WaitForChaosScenario(timeout,
+		func(event sdnapi.ChaosEvent) {
+			if event.Error != "" {
+				log.Warnf("Chaos %s (step %d): %s (%s)",
+					event.Action, event.Step, event.Message, event.Error)
+				return
+			}
+			log.Infof("Chaos %s (step %d): %s", event.Action, event.Step, event.Message)
+		})
+	if err != nil {
+		return err
+	}
+	if status.Running {
+		if err = tc.sdnClient.StopChaosScenario(); err != nil {
+			log.Errorf("failed to stop chaos scenario: %v", err)
+		}
+		return fmt.Errorf("chaos scenario did not finish within %v", timeout)
+	}
+	return nil
+}
+
+// StartPacketCapture starts capturing packets inside Eden-SDN.
+// Returned function stops the capture and saves captured packets into the given file
+// (e.g. to attach the capture to a failed test), then removes the capture from Eden-SDN.
+// Call the function with empty outputFile to drop captured packets.
+func (tc *TestContext) StartPacketCapture(capture sdnapi.PacketCapture) (
+	stop func(outputFile string) error, err error) {
+	if !tc.withSdn {
+		return nil, fmt.Errorf("packet capture requires Eden-SDN, which is not enabled")
+	}
+	status, err := tc.sdnClient.StartPacketCapture(capture)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Started packet capture %s on interface %s (netns %s)",
+		status.ID, status.Interface, status.NetNamespace)
+	stop = func(outputFile string) error {
+		defer func() {
+			if err := tc.sdnClient.RemovePacketCapture(status.ID); err != nil {
+				log.Errorf("failed to remove packet capture %s: %v", status.ID, err)
+			}
+		}()
+		if _, err := tc.sdnClient.StopPacketCapture(status.ID); err != nil {
+			return err
+		}
+		if outputFile == "" {
+			return nil
+		}
+		file, err := os.Create(outputFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		return tc.sdnClient.DownloadPacketCapture(status.ID, file, false)
+	}
+	return stop, nil
+}
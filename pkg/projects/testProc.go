@@ -2,6 +2,10 @@ package projects
 
 import (
 	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -48,74 +52,209 @@ type absFunc struct {
 	states   bool
 	proc     interface{}
 	appUUID  uuid.UUID
+	added    time.Time
+	doneAt   time.Time
+	result   string
+}
+
+// name returns name of function to use in reports
+func (f *absFunc) name() string {
+	kind := strings.TrimPrefix(fmt.Sprintf("%T", f.proc), "projects.")
+	if fn := runtime.FuncForPC(reflect.ValueOf(f.proc).Pointer()); fn != nil {
+		return fmt.Sprintf("%s %s", kind, fn.Name())
+	}
+	return kind
+}
+
+// nodeBus holds functions of one node, functions of different nodes
+// are processed concurrently
+type nodeBus struct {
+	sync.Mutex
+	proc     []*absFunc
+	apps     map[uuid.UUID]struct{}
+	pending  int
+	timeout  time.Duration
+	deadline time.Time
 }
 
 type processingBus struct {
-	tc   *TestContext
-	wg   *sync.WaitGroup
-	proc map[*device.Ctx][]*absFunc
+	tc    *TestContext
+	mu    sync.Mutex
+	nodes map[*device.Ctx]*nodeBus
+	// changed is signaled when one of functions is done
+	changed chan struct{}
 }
 
 func initBus(tc *TestContext) *processingBus {
-	return &processingBus{tc: tc, proc: map[*device.Ctx][]*absFunc{}, wg: &sync.WaitGroup{}}
+	return &processingBus{tc: tc, nodes: map[*device.Ctx]*nodeBus{}, changed: make(chan struct{}, 1)}
+}
+
+// notify wakes up waiter of functions without blocking
+func (lb *processingBus) notify() {
+	select {
+	case lb.changed <- struct{}{}:
+	default:
+	}
+}
+
+// getNode returns nodeBus of edgeNode starting checkers for it if not started
+func (lb *processingBus) getNode(edgeNode *device.Ctx) *nodeBus {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	nb, exists := lb.nodes[edgeNode]
+	if !exists {
+		nb = &nodeBus{apps: map[uuid.UUID]struct{}{}}
+		lb.nodes[edgeNode] = nb
+		lb.initCheckers(edgeNode)
+	}
+	return nb
+}
+
+// lookupNode returns nodeBus of edgeNode or nil
+func (lb *processingBus) lookupNode(edgeNode *device.Ctx) *nodeBus {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	return lb.nodes[edgeNode]
+}
+
+// getNodes returns copy of map of nodes
+func (lb *processingBus) getNodes() map[*device.Ctx]*nodeBus {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	nodes := make(map[*device.Ctx]*nodeBus, len(lb.nodes))
+	for node, nb := range lb.nodes {
+		nodes[node] = nb
+	}
+	return nodes
+}
+
+// active returns enabled functions of node
+func (nb *nodeBus) active() []*absFunc {
+	nb.Lock()
+	defer nb.Unlock()
+	var result []*absFunc
+	for _, el := range nb.proc {
+		if !el.disabled {
+			result = append(result, el)
+		}
+	}
+	return result
 }
 
 func (lb *processingBus) clean() {
-	for _, funcs := range lb.proc {
-		for _, el := range funcs {
-			if el.states || el.disabled {
+	for _, nb := range lb.getNodes() {
+		nb.Lock()
+		var states []*absFunc
+		for _, el := range nb.proc {
+			if el.states {
+				states = append(states, el)
 				continue
 			}
 			el.disabled = true
 		}
+		nb.proc = states
+		nb.pending = 0
+		nb.deadline = time.Time{}
+		nb.Unlock()
+	}
+}
+
+// ProcResult describes result of function added to TestContext for node
+type ProcResult struct {
+	Node    *device.Ctx
+	Name    string
+	Done    bool
+	Result  string
+	Elapsed time.Duration
+}
+
+func (r ProcResult) String() string {
+	if r.Done {
+		return fmt.Sprintf("device %s: %s done in %s: %s", r.Node.GetID(), r.Name, r.Elapsed.Round(time.Second), r.Result)
 	}
-	lb.wg = &sync.WaitGroup{}
+	return fmt.Sprintf("device %s: %s not done in %s", r.Node.GetID(), r.Name, r.Elapsed.Round(time.Second))
 }
 
-func (lb *processingBus) processReturn(edgeNode *device.Ctx, procFunc *absFunc, result error) {
+// report returns results of functions of nodes sorted by node
+func (lb *processingBus) report() []ProcResult {
+	var results []ProcResult
+	now := time.Now()
+	for node, nb := range lb.getNodes() {
+		nb.Lock()
+		for _, el := range nb.proc {
+			if el.states {
+				continue
+			}
+			result := ProcResult{Node: node, Name: el.name(), Done: !el.doneAt.IsZero(), Result: el.result}
+			if result.Done {
+				result.Elapsed = el.doneAt.Sub(el.added)
+			} else {
+				result.Elapsed = now.Sub(el.added)
+			}
+			results = append(results, result)
+		}
+		nb.Unlock()
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Node.GetID().String() < results[j].Node.GetID().String()
+	})
+	return results
+}
+
+func (lb *processingBus) processReturn(edgeNode *device.Ctx, nb *nodeBus, procFunc *absFunc, result error) {
 	if result != nil {
-		if lb.tc.addTime != 0 {
-			log.Infof("Expand timewait by %s", lb.tc.addTime)
-			lb.tc.stopTime.Add(lb.tc.addTime)
+		nb.Lock()
+		if procFunc.disabled {
+			// cleaned during processing
+			nb.Unlock()
+			return
 		}
 		procFunc.disabled = true
+		procFunc.doneAt = time.Now()
+		procFunc.result = result.Error()
+		if !procFunc.states {
+			nb.pending--
+		}
+		if addTime := lb.tc.addTime; addTime != 0 && !nb.deadline.IsZero() {
+			log.Infof("Expand timewait of device %s by %s", edgeNode.GetID(), addTime)
+			nb.deadline = nb.deadline.Add(addTime)
+		}
+		nb.Unlock()
+		lb.notify()
 		toRet := utils.AddTimestamp(fmt.Sprintf("%T done with return: %s", procFunc.proc, result.Error()))
-		if t, ok := lb.tc.tests[edgeNode]; ok {
+		if t := lb.tc.getTest(edgeNode); t != nil {
 			t.Log(toRet)
 		}
 		log.Info(toRet)
-		lb.wg.Done()
 	}
 }
 
 func (lb *processingBus) process(edgeNode *device.Ctx, inp interface{}) bool {
-	for node, functions := range lb.proc {
-		if node == edgeNode {
-			for _, procFunc := range functions {
-				if !procFunc.disabled {
-					switch pf := procFunc.proc.(type) {
-					case ProcInfoFunc:
-						el, match := inp.(*info.ZInfoMsg)
-						if match {
-							lb.processReturn(edgeNode, procFunc, pf(el))
-						}
-					case ProcLogFunc:
-						el, match := inp.(*elog.FullLogEntry)
-						if match {
-							lb.processReturn(edgeNode, procFunc, pf(el))
-						}
-					case ProcMetricFunc:
-						el, match := inp.(*metrics.ZMetricMsg)
-						if match {
-							lb.processReturn(edgeNode, procFunc, pf(el))
-						}
-					case ProcLogFlowFunc:
-						el, match := inp.(*flowlog.FlowMessage)
-						if match {
-							lb.processReturn(edgeNode, procFunc, pf(el))
-						}
-					}
-				}
+	nb := lb.lookupNode(edgeNode)
+	if nb == nil {
+		return false
+	}
+	for _, procFunc := range nb.active() {
+		switch pf := procFunc.proc.(type) {
+		case ProcInfoFunc:
+			el, match := inp.(*info.ZInfoMsg)
+			if match {
+				lb.processReturn(edgeNode, nb, procFunc, pf(el))
+			}
+		case ProcLogFunc:
+			el, match := inp.(*elog.FullLogEntry)
+			if match {
+				lb.processReturn(edgeNode, nb, procFunc, pf(el))
+			}
+		case ProcMetricFunc:
+			el, match := inp.(*metrics.ZMetricMsg)
+			if match {
+				lb.processReturn(edgeNode, nb, procFunc, pf(el))
+			}
+		case ProcLogFlowFunc:
+			el, match := inp.(*flowlog.FlowMessage)
+			if match {
+				lb.processReturn(edgeNode, nb, procFunc, pf(el))
 			}
 		}
 	}
@@ -123,21 +262,19 @@ func (lb *processingBus) process(edgeNode *device.Ctx, inp interface{}) bool {
 }
 
 func (lb *processingBus) processApp(edgeNode *device.Ctx, appUUID uuid.UUID, inp interface{}) bool {
-	for node, functions := range lb.proc {
-		if node == edgeNode {
-			for _, procFunc := range functions {
-				if procFunc.appUUID != appUUID {
-					continue
-				}
-				if !procFunc.disabled {
-					switch pf := procFunc.proc.(type) {
-					case ProcAppLogFunc:
-						el, match := inp.(*logs.LogEntry)
-						if match {
-							lb.processReturn(edgeNode, procFunc, pf(el))
-						}
-					}
-				}
+	nb := lb.lookupNode(edgeNode)
+	if nb == nil {
+		return false
+	}
+	for _, procFunc := range nb.active() {
+		if procFunc.appUUID != appUUID {
+			continue
+		}
+		switch pf := procFunc.proc.(type) {
+		case ProcAppLogFunc:
+			el, match := inp.(*logs.LogEntry)
+			if match {
+				lb.processReturn(edgeNode, nb, procFunc, pf(el))
 			}
 		}
 	}
@@ -145,18 +282,14 @@ func (lb *processingBus) processApp(edgeNode *device.Ctx, appUUID uuid.UUID, inp
 }
 
 func (lb *processingBus) processTimers(edgeNode *device.Ctx) bool {
-	for node, functions := range lb.proc {
-		if node != edgeNode {
-			continue
-		}
-		for _, procFunc := range functions {
-			if procFunc.disabled {
-				continue
-			}
-			switch pf := procFunc.proc.(type) {
-			case ProcTimerFunc:
-				lb.processReturn(edgeNode, procFunc, pf())
-			}
+	nb := lb.lookupNode(edgeNode)
+	if nb == nil {
+		return false
+	}
+	for _, procFunc := range nb.active() {
+		switch pf := procFunc.proc.(type) {
+		case ProcTimerFunc:
+			lb.processReturn(edgeNode, nb, procFunc, pf())
 		}
 	}
 	return false
@@ -194,25 +327,25 @@ func (lb *processingBus) getMainProcessorAppLog(dev *device.Ctx, appUUID uuid.UU
 
 func (lb *processingBus) initCheckers(dev *device.Ctx) {
 	go func() {
-		err := lb.tc.GetController().LogChecker(dev.GetID(), map[string]string{}, lb.getMainProcessorLog(dev), elog.LogNew, 0)
+		err := lb.tc.GetNodeController(dev).LogChecker(dev.GetID(), map[string]string{}, lb.getMainProcessorLog(dev), elog.LogNew, 0)
 		if err != nil {
 			log.Errorf("LogChecker for dev %s error %s", dev.GetID(), err)
 		}
 	}()
 	go func() {
-		err := lb.tc.GetController().FlowLogChecker(dev.GetID(), map[string]string{}, lb.getMainProcessorFlowLog(dev), eflowlog.FlowLogNew, 0)
+		err := lb.tc.GetNodeController(dev).FlowLogChecker(dev.GetID(), map[string]string{}, lb.getMainProcessorFlowLog(dev), eflowlog.FlowLogNew, 0)
 		if err != nil {
 			log.Errorf("FlowLogChecker for dev %s error %s", dev.GetID(), err)
 		}
 	}()
 	go func() {
-		err := lb.tc.GetController().InfoChecker(dev.GetID(), map[string]string{}, lb.getMainProcessorInfo(dev), einfo.InfoNew, 0)
+		err := lb.tc.GetNodeController(dev).InfoChecker(dev.GetID(), map[string]string{}, lb.getMainProcessorInfo(dev), einfo.InfoNew, 0)
 		if err != nil {
 			log.Errorf("InfoChecker for dev %s error %s", dev.GetID(), err)
 		}
 	}()
 	go func() {
-		err := lb.tc.GetController().MetricChecker(dev.GetID(), map[string]string{}, lb.getMainProcessorMetric(dev), emetric.MetricNew, 0)
+		err := lb.tc.GetNodeController(dev).MetricChecker(dev.GetID(), map[string]string{}, lb.getMainProcessorMetric(dev), emetric.MetricNew, 0)
 		if err != nil {
 			log.Errorf("MetricChecker for dev %s error %s", dev.GetID(), err)
 		}
@@ -226,33 +359,41 @@ func (lb *processingBus) initCheckers(dev *device.Ctx) {
 	}()
 }
 
-func (lb *processingBus) initAppChecker(dev *device.Ctx, appUUID uuid.UUID) {
-	for _, el := range lb.proc[dev] {
-		if el.appUUID == appUUID {
-			return
-		}
+func (lb *processingBus) initAppChecker(dev *device.Ctx, nb *nodeBus, appUUID uuid.UUID) {
+	nb.Lock()
+	defer nb.Unlock()
+	if _, exists := nb.apps[appUUID]; exists {
+		return
 	}
+	nb.apps[appUUID] = struct{}{}
 	go func() {
-		err := lb.tc.GetController().LogAppsChecker(dev.GetID(), appUUID, map[string]string{}, lb.getMainProcessorAppLog(dev, appUUID), eapps.LogNew, 0)
+		err := lb.tc.GetNodeController(dev).LogAppsChecker(dev.GetID(), appUUID, map[string]string{}, lb.getMainProcessorAppLog(dev, appUUID), eapps.LogNew, 0)
 		if err != nil {
 			log.Errorf("AppLogChecker for dev %s error %s", dev.GetID(), err)
 		}
 	}()
 }
 
-func (lb *processingBus) addProc(dev *device.Ctx, procFunc interface{}) {
-	if _, exists := lb.proc[dev]; !exists {
-		lb.initCheckers(dev)
+func (nb *nodeBus) add(procFunc *absFunc) {
+	nb.Lock()
+	defer nb.Unlock()
+	procFunc.added = time.Now()
+	nb.proc = append(nb.proc, procFunc)
+	if !procFunc.states {
+		nb.pending++
 	}
-	lb.proc[dev] = append(lb.proc[dev], &absFunc{proc: procFunc, disabled: false})
-	lb.wg.Add(1)
+}
+
+func (lb *processingBus) addProc(dev *device.Ctx, procFunc interface{}) {
+	lb.getNode(dev).add(&absFunc{proc: procFunc, disabled: false})
 }
 
 func (lb *processingBus) addAppProc(dev *device.Ctx, appUUID uuid.UUID, procFunc interface{}) {
-	if _, exists := lb.proc[dev]; !exists {
-		lb.initCheckers(dev)
-	}
-	lb.initAppChecker(dev, appUUID)
-	lb.proc[dev] = append(lb.proc[dev], &absFunc{proc: procFunc, disabled: false, appUUID: appUUID})
-	lb.wg.Add(1)
+	nb := lb.getNode(dev)
+	lb.initAppChecker(dev, nb, appUUID)
+	nb.add(&absFunc{proc: procFunc, disabled: false, appUUID: appUUID})
+}
+
+func (lb *processingBus) addStateProc(dev *device.Ctx, procFunc interface{}) {
+	lb.getNode(dev).add(&absFunc{proc: procFunc, disabled: false, states: true})
 }
@@ -0,0 +1,147 @@
+package projects_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lf-edge/eden/pkg/controller"
+	"github.com/lf-edge/eden/pkg/controller/eflowlog"
+	"github.com/lf-edge/eden/pkg/controller/einfo"
+	"github.com/lf-edge/eden/pkg/controller/elog"
+	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/defaults"
+	"github.com/lf-edge/eden/pkg/device"
+	"github.com/lf-edge/eden/pkg/projects"
+	"github.com/lf-edge/eve-api/go/info"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCloud sends info messages to handlers of InfoChecker until stopped
+type fakeCloud struct {
+	controller.Cloud
+	stop chan struct{}
+}
+
+func newFakeCloud(t *testing.T) *fakeCloud {
+	c := &fakeCloud{stop: make(chan struct{})}
+	t.Cleanup(func() { close(c.stop) })
+	return c
+}
+
+func (c *fakeCloud) InfoChecker(_ uuid.UUID, _ map[string]string, handler einfo.HandlerFunc, _ einfo.InfoCheckerMode, _ time.Duration) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return nil
+		case <-ticker.C:
+			handler(&info.ZInfoMsg{})
+		}
+	}
+}
+
+func (c *fakeCloud) LogChecker(uuid.UUID, map[string]string, elog.HandlerFunc, elog.LogCheckerMode, time.Duration) error {
+	return nil
+}
+
+func (c *fakeCloud) FlowLogChecker(uuid.UUID, map[string]string, eflowlog.HandlerFunc, eflowlog.FlowLogCheckerMode, time.Duration) error {
+	return nil
+}
+
+func (c *fakeCloud) MetricChecker(uuid.UUID, map[string]string, emetric.HandlerFunc, emetric.MetricCheckerMode, time.Duration) error {
+	return nil
+}
+
+func newNode(t *testing.T) *device.Ctx {
+	dev := device.CreateEdgeNode()
+	id, err := uuid.NewV4()
+	require.NoError(t, err)
+	dev.SetID(id)
+	return dev
+}
+
+// doneAfter returns function which is done after receiving of count infos
+func doneAfter(count int32) projects.ProcInfoFunc {
+	var received int32
+	return func(*info.ZInfoMsg) error {
+		if atomic.AddInt32(&received, 1) >= count {
+			return errDone
+		}
+		return nil
+	}
+}
+
+var errDone = errors.New("done")
+
+func TestWaitForProcConcurrentNodes(t *testing.T) {
+	tc := projects.NewTestContextWithController(newFakeCloud(t))
+	var nodes []*device.Ctx
+	for i := 0; i < 8; i++ {
+		node := newNode(t)
+		tc.WithTest(t)(node)
+		tc.AddProcInfo(node, doneAfter(3))
+		tc.AddProcInfo(node, doneAfter(int32(5+i)))
+		nodes = append(nodes, node)
+	}
+	start := time.Now()
+	tc.WaitForProcWithErrorCallback(60, func() {
+		t.Error("unexpected callback")
+	})
+	// waiter must be woken up by done functions, not by periodic checks
+	assert.Less(t, int64(time.Since(start)), int64(defaults.DefaultRepeatTimeout))
+	report := tc.GetProcReport()
+	require.Len(t, report, 2*len(nodes))
+	for _, result := range report {
+		assert.True(t, result.Done, result.String())
+		assert.Equal(t, "done", result.Result)
+	}
+}
+
+func TestWaitForProcNodeDeadline(t *testing.T) {
+	tc := projects.NewTestContextWithController(newFakeCloud(t))
+	fast := newNode(t)
+	slow := newNode(t)
+	tc.AddProcInfo(fast, doneAfter(1))
+	tc.AddProcInfo(slow, func(*info.ZInfoMsg) error { return nil })
+	tc.SetNodeTimeout(slow, 1)
+	callbacks := 0
+	start := time.Now()
+	tc.WaitForProcWithErrorCallback(60, func() { callbacks++ })
+	elapsed := time.Since(start)
+	assert.Equal(t, 1, callbacks)
+	assert.GreaterOrEqual(t, int64(elapsed), int64(time.Second))
+	assert.Less(t, int64(elapsed), int64(defaults.DefaultRepeatTimeout))
+	report := tc.GetProcReport()
+	require.Len(t, report, 2)
+	for _, result := range report {
+		switch result.Node {
+		case fast:
+			assert.True(t, result.Done, result.String())
+		case slow:
+			assert.False(t, result.Done, result.String())
+		default:
+			t.Errorf("unexpected node in report: %s", result)
+		}
+	}
+}
+
+func TestWaitForProcCleansProcs(t *testing.T) {
+	tc := projects.NewTestContextWithController(newFakeCloud(t))
+	node := newNode(t)
+	tc.AddProcInfo(node, func(*info.ZInfoMsg) error { return nil })
+	tc.SetNodeTimeout(node, 1)
+	tc.WaitForProcWithErrorCallback(60, func() {})
+	// functions and deadline of previous wait must not affect the next one
+	tc.AddProcInfo(node, doneAfter(2))
+	tc.WaitForProcWithErrorCallback(60, func() {
+		t.Error("unexpected callback")
+	})
+	report := tc.GetProcReport()
+	require.Len(t, report, 1)
+	assert.True(t, report[0].Done, report[0].String())
+}
@@ -32,9 +32,14 @@ type ConfigVars struct {
 	AdamCaching       bool
 	AdamCachingRedis  bool
 	AdamCachingPrefix string
+	AdamReplay        string
 	AdamRemoteRedis   bool
 	AdamRedisURLEden  string
 	AdamRedisURLAdam  string
+	RestURL           string
+	RestToken         string
+	RestCA            string
+	RestInsecure      bool
 	EveHV             string
 	EveSSID           string
 	EveUUID           string
@@ -58,6 +63,15 @@ type ConfigVars struct {
 	EServerImageDist  string
 	EServerPort       string
 	EServerIP         string
+	EServerHTTPS      bool
+	EServerUser       string
+	EServerPassword   string
+	EServerAdminUser  string
+	EServerAdminPass  string
+	EServerS3Key      string
+	EServerS3Secret   string
+	EServerAzureAcc   string
+	EServerAzureKey   string
 	RegistryIP        string
 	RegistryPort      string
 	LogLevel          string
@@ -97,6 +111,10 @@ func InitVars() (*ConfigVars, error) {
 			AdamDir:           ResolveAbsPath(viper.GetString("adam.dist")),
 			AdamCA:            caCertPath,
 			AdamRedisURLEden:  viper.GetString("adam.redis.eden"),
+			RestURL:           viper.GetString("rest.url"),
+			RestToken:         viper.GetString("rest.token"),
+			RestCA:            ResolveAbsPath(viper.GetString("rest.ca")),
+			RestInsecure:      viper.GetBool("rest.insecure"),
 			SSHKey:            ResolveAbsPath(viper.GetString("eden.ssh-key")),
 			EveCert:           ResolveAbsPath(viper.GetString("eve.cert")),
 			EveDeviceCert:     ResolveAbsPath(viper.GetString("eve.device-cert")),
@@ -118,6 +136,7 @@ func InitVars() (*ConfigVars, error) {
 			AdamCaching:       viper.GetBool("adam.caching.enabled"),
 			AdamCachingPrefix: viper.GetString("adam.caching.prefix"),
 			AdamCachingRedis:  viper.GetBool("adam.caching.redis"),
+			AdamReplay:        ResolveAbsPath(viper.GetString("adam.replay")),
 			EdenBinDir:        viper.GetString("eden.bin-dist"),
 			EdenProg:          viper.GetString("eden.eden-bin"),
 			TestProg:          viper.GetString("eden.test-bin"),
@@ -125,6 +144,15 @@ func InitVars() (*ConfigVars, error) {
 			EServerImageDist:  ResolveAbsPath(viper.GetString("eden.images.dist")),
 			EServerPort:       viper.GetString("eden.eserver.port"),
 			EServerIP:         viper.GetString("eden.eserver.ip"),
+			EServerHTTPS:      viper.GetBool("eden.eserver.https"),
+			EServerUser:       viper.GetString("eden.eserver.user"),
+			EServerPassword:   viper.GetString("eden.eserver.password"),
+			EServerAdminUser:  viper.GetString("eden.eserver.admin-user"),
+			EServerAdminPass:  viper.GetString("eden.eserver.admin-password"),
+			EServerS3Key:      viper.GetString("eden.eserver.s3-access-key"),
+			EServerS3Secret:   viper.GetString("eden.eserver.s3-secret-key"),
+			EServerAzureAcc:   viper.GetString("eden.eserver.azure-account"),
+			EServerAzureKey:   viper.GetString("eden.eserver.azure-key"),
 			RegistryIP:        viper.GetString("registry.ip"),
 			RegistryPort:      viper.GetString("registry.port"),
 			LogLevel:          viper.GetString("eve.log-level"),
@@ -450,6 +478,26 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 			return defaults.DefaultEServerTag
 		case "eden.eserver.force":
 			return true
+		case "eden.eserver.https":
+			return false
+		case "eden.eserver.user":
+			return ""
+		case "eden.eserver.password":
+			return ""
+		case "eden.eserver.token":
+			return ""
+		case "eden.eserver.admin-user":
+			return ""
+		case "eden.eserver.admin-password":
+			return ""
+		case "eden.eserver.s3-access-key":
+			return defaults.DefaultS3AccessKey
+		case "eden.eserver.s3-secret-key":
+			return defaults.DefaultS3SecretKey
+		case "eden.eserver.azure-account":
+			return defaults.DefaultAzureAccount
+		case "eden.eserver.azure-key":
+			return defaults.DefaultAzureKey
 		case "eden.eclient.tag":
 			return defaults.DefaultEClientTag
 		case "eden.eclient.image":
@@ -473,6 +521,15 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 		case "packet.key":
 			return ""
 
+		case "rest.url":
+			return ""
+		case "rest.token":
+			return ""
+		case "rest.ca":
+			return ""
+		case "rest.insecure":
+			return false
+
 		case "redis.port":
 			return defaults.DefaultRedisPort
 		case "redis.tag":
@@ -515,6 +572,8 @@ func generateConfigFileFromTemplate(filePath string, templateString string, cont
 			return defaults.DefaultSdnMgmtPort
 		case "sdn.network-model":
 			return ""
+		case "sdn.eve-instance":
+			return ""
 
 		default:
 			log.Fatalf("Not found argument %s in config", inp)
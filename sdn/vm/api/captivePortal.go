@@ -0,0 +1,27 @@
+package api
+
+import "time"
+
+// CaptivePortalLogin : request to log a client in (or out of) a captive portal.
+// Submitted to the SDN agent, which then stops (or resumes) intercepting
+// traffic of the client.
+type CaptivePortalLogin struct {
+	// Client : IP or MAC address of the client (e.g. EVE interface).
+	Client string `json:"client"`
+}
+
+// CaptivePortalStatus : status of a captive portal endpoint.
+type CaptivePortalStatus struct {
+	// LogicalLabel : logical label of the captive portal.
+	LogicalLabel string `json:"logicalLabel"`
+	// Clients : clients currently logged in.
+	Clients []CaptivePortalClient `json:"clients,omitempty"`
+}
+
+// CaptivePortalClient : client logged in a captive portal.
+type CaptivePortalClient struct {
+	// Client : IP or MAC address of the client.
+	Client string `json:"client"`
+	// LoginTime : time when the client logged in.
+	LoginTime time.Time `json:"loginTime"`
+}
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ChaosScenario : timeline of network faults injected into the running Eden-SDN
+// without changing the network model. Every step is executed by the SDN agent
+// at the scheduled time and reverted once its duration elapses.
+// When the last step is reverted, the scenario ends and the network is back
+// to the state described by the network model.
+// Applying a new network model does not stop a running scenario, but faults
+// referencing items which are no longer in the model are skipped.
+type ChaosScenario struct {
+	// Name : optional name of the scenario (used for logging purposes).
+	Name string `json:"name,omitempty"`
+	// Steps : faults to inject, each scheduled relative to the scenario start.
+	// Steps may overlap in time.
+	Steps []ChaosStep `json:"steps"`
+}
+
+// ChaosStep : a single fault injected for a limited period of time.
+// Exactly one fault type (TrafficControl, LinkDown, LinkFlap, Blackhole) should be
+// defined.
+type ChaosStep struct {
+	// Description : optional human-readable description of the step.
+	Description string `json:"description,omitempty"`
+	// At : when to inject the fault, relative to the start of the scenario.
+	At Duration `json:"at"`
+	// For : how long the fault should last. Must be non-zero.
+	For Duration `json:"for"`
+	// TrafficControl : temporarily replace traffic control parameters of a port.
+	TrafficControl *ChaosTrafficControl `json:"trafficControl,omitempty"`
+	// LinkDown : temporarily put a port administratively down.
+	LinkDown *ChaosLinkDown `json:"linkDown,omitempty"`
+	// LinkFlap : repeatedly put a port down and up.
+	LinkFlap *ChaosLinkFlap `json:"linkFlap,omitempty"`
+	// Blackhole : silently drop matching traffic.
+	Blackhole *ChaosBlackhole `json:"blackhole,omitempty"`
+}
+
+// ChaosTrafficControl : traffic control parameters to temporarily apply for a port
+// (instead of Port.TC).
+type ChaosTrafficControl struct {
+	// Port : logical label of the port.
+	Port string `json:"port"`
+	// TrafficControl : parameters to apply.
+	TrafficControl
+}
+
+// ChaosLinkDown : put port administratively down.
+type ChaosLinkDown struct {
+	// Port : logical label of the port.
+	Port string `json:"port"`
+}
+
+// ChaosLinkFlap : toggle port between down and up states.
+type ChaosLinkFlap struct {
+	// Port : logical label of the port.
+	Port string `json:"port"`
+	// Interval : how long the port stays in each state (down, then up, then down, ...).
+	// Must be non-zero.
+	Interval Duration `json:"interval"`
+}
+
+// ChaosBlackhole : drop traffic matching the given destination and optionally
+// also the source network, protocol and ports.
+// Exactly one destination (Endpoint, Controller, DstSubnet) should be defined.
+type ChaosBlackhole struct {
+	// Endpoint : logical label of an endpoint to which the traffic should be dropped.
+	Endpoint string `json:"endpoint,omitempty"`
+	// Controller : drop traffic going towards the controller.
+	Controller bool `json:"controller,omitempty"`
+	// DstSubnet : drop traffic going towards this subnet.
+	DstSubnet string `json:"dstSubnet,omitempty"`
+	// Network : logical label of a network. If defined, only traffic coming
+	// from this network is dropped.
+	Network string `json:"network,omitempty"`
+	// Protocol : drop only traffic of this protocol.
+	Protocol FwProto `json:"protocol"`
+	// Ports : list of destination ports to which the blackhole applies.
+	// For a non empty list, Protocol must be either TCP or UDP.
+	// Empty = any.
+	Ports []uint16 `json:"ports,omitempty"`
+}
+
+// ChaosStatus : status of the (last) chaos scenario run by the SDN agent.
+type ChaosStatus struct {
+	// Scenario : the last started scenario. Nil if none was run yet.
+	Scenario *ChaosScenario `json:"scenario,omitempty"`
+	// StartedAt : time when the scenario was started.
+	StartedAt time.Time `json:"startedAt"`
+	// Running : true if the scenario is still being executed.
+	Running bool `json:"running"`
+	// Events : log of executed actions, ordered by time.
+	Events []ChaosEvent `json:"events,omitempty"`
+}
+
+// ChaosEvent : action taken by the SDN agent while executing chaos scenario.
+type ChaosEvent struct {
+	// Time : when the action was taken.
+	Time time.Time `json:"time"`
+	// Step : index of the step to which the action belongs.
+	// Negative value is used for actions related to the scenario as a whole.
+	Step int `json:"step"`
+	// Action : taken action.
+	Action ChaosAction `json:"action"`
+	// Message : human-readable description of the action.
+	Message string `json:"message"`
+	// Error : non-empty if the action failed or was skipped.
+	Error string `json:"error,omitempty"`
+}
+
+// ChaosAction : action taken by the SDN agent while executing chaos scenario.
+type ChaosAction string
+
+const (
+	// ChaosStart : scenario was started.
+	ChaosStart ChaosAction = "start"
+	// ChaosInject : fault was injected.
+	ChaosInject ChaosAction = "inject"
+	// ChaosLinkToggle : link was toggled as part of link flapping.
+	ChaosLinkToggle ChaosAction = "link-toggle"
+	// ChaosRevert : fault was reverted.
+	ChaosRevert ChaosAction = "revert"
+	// ChaosEnd : scenario finished.
+	ChaosEnd ChaosAction = "end"
+	// ChaosStop : scenario was stopped prematurely.
+	ChaosStop ChaosAction = "stop"
+)
+
+// String returns human-readable description of the fault injected by the step.
+func (s ChaosStep) String() string {
+	if s.Description != "" {
+		return s.Description
+	}
+	switch {
+	case s.TrafficControl != nil:
+		return fmt.Sprintf("traffic control on port %s: %+v",
+			s.TrafficControl.Port, s.TrafficControl.TrafficControl)
+	case s.LinkDown != nil:
+		return fmt.Sprintf("link down on port %s", s.LinkDown.Port)
+	case s.LinkFlap != nil:
+		return fmt.Sprintf("link flapping on port %s every %v",
+			s.LinkFlap.Port, s.LinkFlap.Interval)
+	case s.Blackhole != nil:
+		return fmt.Sprintf("blackhole %+v", *s.Blackhole)
+	}
+	return "no fault"
+}
+
+// Duration : time.Duration (un)marshalled from/to a string in the format
+// accepted by time.ParseDuration (e.g. "30s", "2m", "1h30m").
+// Plain number is also accepted and interpreted as the number of seconds.
+type Duration time.Duration
+
+// String returns duration formatted by time.Duration.
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalJSON marshals duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON un-marshals duration from a string (or a number of seconds).
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case float64:
+		*d = Duration(time.Duration(v * float64(time.Second)))
+		return nil
+	case string:
+		duration, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		*d = Duration(duration)
+		return nil
+	default:
+		return fmt.Errorf("invalid duration: %s", string(b))
+	}
+}
@@ -27,6 +27,9 @@ type Endpoints struct {
 	// NetbootServers : HTTP/TFTP servers providing artifacts needed to boot EVE OS
 	// over a network (using netboot/PXE + iPXE).
 	NetbootServers []NetbootServer `json:"netbootServers,omitempty"`
+	// CaptivePortals : captive portals intercepting HTTP(S) traffic of clients until
+	// they log in. Can be referenced in Network.CaptivePortal.
+	CaptivePortals []CaptivePortal `json:"captivePortals,omitempty"`
 }
 
 // GetAll : returns all endpoints as one list.
@@ -51,6 +54,9 @@ func (eps Endpoints) GetAll() (all []Endpoint) {
 	for _, tProxy := range eps.TransparentProxies {
 		all = append(all, tProxy.Endpoint)
 	}
+	for _, portal := range eps.CaptivePortals {
+		all = append(all, portal.Endpoint)
+	}
 	for _, netBootSrv := range eps.NetbootServers {
 		all = append(all, netBootSrv.Endpoint)
 	}
@@ -113,6 +119,13 @@ type DNSServer struct {
 	// UpstreamServers : list of IP addresses of public DNS servers to forward
 	// requests to (unless there is a static entry).
 	UpstreamServers []string `json:"upstreamServers"`
+	// TTL : time-to-live (in seconds) of records returned for static entries
+	// (unless overridden by DNSEntry.TTL). Zero value is used by default.
+	TTL uint32 `json:"ttl,omitempty"`
+	// Faults : faults injected by the server into responses to DNS queries.
+	// Can be used to test DNS fallbacks of EVE under broken resolver conditions.
+	// For every query, the first matching (and not yet exhausted) fault is applied.
+	Faults []DNSFault `json:"faults,omitempty"`
 }
 
 // ItemCategory
@@ -133,6 +146,15 @@ func (e DNSServer) ReferencesFromItem() []LogicalLabelRef {
 				RefKey:           refKey,
 			})
 		}
+		if strings.HasPrefix(entry.Target, EndpointFQDNRefPrefix) {
+			refKey := fmt.Sprintf("dns-server-%s-entry-%d-target", e.LogicalLabel, i)
+			logicalLabel := strings.TrimPrefix(entry.Target, EndpointFQDNRefPrefix)
+			refs = append(refs, LogicalLabelRef{
+				ItemType:         Endpoint{}.ItemType(),
+				ItemLogicalLabel: logicalLabel,
+				RefKey:           refKey,
+			})
+		}
 		if strings.HasPrefix(entry.IP, EndpointIPRefPrefix) {
 			refKey := fmt.Sprintf("dns-server-%s-entry-%d-ip", e.LogicalLabel, i)
 			logicalLabel := strings.TrimPrefix(entry.IP, EndpointIPRefPrefix)
@@ -170,8 +192,81 @@ type DNSEntry struct {
 	// to the corresponding IP address:
 	//  - "endpoint-ip.<endpoint-logical-label>" - translated to IP address of the endpoint
 	//  - "adam-ip" - translated to IP address on which Adam (open-source controller) is deployed and accessible
-	IP string `json:"ip"`
-}
+	// Used only with A and AAAA records.
+	IP string `json:"ip,omitempty"`
+	// Type : type of the DNS record.
+	// Leave empty to select A or AAAA based on the IP address.
+	Type DNSRecordType `json:"type,omitempty"`
+	// Target : canonical name (CNAME record) or target host (SRV record).
+	// Can be a reference to endpoint FQDN (see FQDN).
+	// Note that CNAME record is returned only if the target is also defined
+	// as a static A/AAAA entry with TTL.
+	Target string `json:"target,omitempty"`
+	// Port : port of the service (SRV record).
+	Port uint16 `json:"port,omitempty"`
+	// Priority : priority of the target host (SRV record).
+	Priority uint16 `json:"priority,omitempty"`
+	// Weight : relative weight of the target host (SRV record).
+	Weight uint16 `json:"weight,omitempty"`
+	// Text : strings of the TXT record.
+	Text []string `json:"text,omitempty"`
+	// TTL : time-to-live (in seconds) of the record, overrides DNSServer.TTL.
+	// Supported only for A, AAAA and CNAME records. A/AAAA record matches also
+	// all subdomains of FQDN, these are however answered with DNSServer.TTL.
+	TTL uint32 `json:"ttl,omitempty"`
+}
+
+// DNSRecordType : type of the DNS record.
+type DNSRecordType string
+
+const (
+	// DNSRecordA : IPv4 address.
+	DNSRecordA DNSRecordType = "A"
+	// DNSRecordAAAA : IPv6 address.
+	DNSRecordAAAA DNSRecordType = "AAAA"
+	// DNSRecordCNAME : canonical name (alias).
+	DNSRecordCNAME DNSRecordType = "CNAME"
+	// DNSRecordSRV : service location.
+	// DNSEntry.FQDN should be in the format _service._protocol.domain
+	DNSRecordSRV DNSRecordType = "SRV"
+	// DNSRecordTXT : text strings.
+	DNSRecordTXT DNSRecordType = "TXT"
+)
+
+// DNSFault : fault injected by DNS server into responses.
+type DNSFault struct {
+	// FQDN : domain name for which the fault is injected (subdomains included).
+	// Leave empty to inject the fault for all queries.
+	FQDN string `json:"fqdn,omitempty"`
+	// Response : response returned instead of the actual answer.
+	// Leave empty to only delay the actual answer.
+	Response DNSFaultResponse `json:"response,omitempty"`
+	// Delay : delay the response by the given duration.
+	Delay Duration `json:"delay,omitempty"`
+	// DelayJitter : delay the response by additional random duration
+	// from the interval [0, DelayJitter].
+	DelayJitter Duration `json:"delayJitter,omitempty"`
+	// FaultyQueries : number of matching queries (counted since the server started)
+	// affected by the fault. Subsequent queries are not affected.
+	// Zero value means that all matching queries are affected.
+	FaultyQueries uint32 `json:"faultyQueries,omitempty"`
+}
+
+// DNSFaultResponse : faulty response returned by DNS server.
+type DNSFaultResponse string
+
+const (
+	// DNSFaultServFail : respond with SERVFAIL.
+	DNSFaultServFail DNSFaultResponse = "servfail"
+	// DNSFaultNXDomain : respond with NXDOMAIN.
+	DNSFaultNXDomain DNSFaultResponse = "nxdomain"
+	// DNSFaultTimeout : do not respond at all.
+	DNSFaultTimeout DNSFaultResponse = "timeout"
+	// DNSFaultTruncated : respond over UDP with empty truncated (TC) response,
+	// forcing the client to retry the query over TCP. Queries received over TCP
+	// are answered normally and are not counted by FaultyQueries.
+	DNSFaultTruncated DNSFaultResponse = "truncated"
+)
 
 // HTTPServer : HTTP(s) server.
 type HTTPServer struct {
@@ -189,6 +284,18 @@ type HTTPServer struct {
 	CertPEM string `json:"certPEM"`
 	// KeyPEM : Server key in the PEM format. Required for HTTPS.
 	KeyPEM string `json:"keyPEM"`
+	// CACertPEM : optionally, certificate of the CA which issued the server certificate
+	// (in the PEM format). Used together with CAKeyPEM to sign the expired server
+	// certificate (see TLSCertExpired). Without CA, the expired certificate is self-signed.
+	CACertPEM string `json:"caCertPEM,omitempty"`
+	// CAKeyPEM : key of the CA which issued the server certificate (in the PEM format).
+	CAKeyPEM string `json:"caKeyPEM,omitempty"`
+	// TLSFaultPorts : additional ports to listen for HTTPS requests, each presenting
+	// a faulty server certificate (derived from CertPEM).
+	// Server certificate is presented during the TLS handshake, i.e. before the request
+	// path is known. Paths with HTTPFaults.TLSCert are therefore served only
+	// on the port defined here for the given TLS certificate fault.
+	TLSFaultPorts map[TLSCertFault]uint16 `json:"tlsFaultPorts,omitempty"`
 	// Maps URL Path to a content to be returned inside the HTTP(s) response body.
 	Paths map[string]HTTPContent `json:"paths"`
 }
@@ -214,21 +321,86 @@ func (e HTTPServer) ReferencesFromItem() []LogicalLabelRef {
 }
 
 // HTTPContent : content returned by an HTTP(s) handler.
+// Range requests are supported, allowing clients to resume interrupted downloads.
 type HTTPContent struct {
 	// ContentType : HTTP(S) Content-Type.
 	ContentType string `json:"contentType"`
 	// Content : content returned inside a HTTP(s) response body.
 	// It is a string, so binary content is not possible for now.
 	Content string `json:"content"`
-}
+	// GeneratedSize : if non-zero, Content is ignored and the response body
+	// is generated instead, consisting of the given number of pseudo-random
+	// (but for every request the same) bytes.
+	// Use to emulate downloads of large (binary) files.
+	GeneratedSize uint64 `json:"generatedSize,omitempty"`
+	// Faults : optionally, inject faults into responses with this content.
+	Faults *HTTPFaults `json:"faults,omitempty"`
+}
+
+// HTTPFaults : faults injected by HTTP server into responses.
+// Can be used to test download retry and resume logic of EVE.
+// Multiple faults can be combined (e.g. throttle and then reset the connection).
+// HTTPS is served with HTTP/1.1 only, so that connection faults affect single request.
+type HTTPFaults struct {
+	// FaultyRequests : number of requests (counted since the server started)
+	// affected by the faults. Subsequent requests are served without faults.
+	// Zero value means that all requests are affected.
+	// Does not apply to TLSCert, which is used for all requests.
+	FaultyRequests uint32 `json:"faultyRequests,omitempty"`
+	// Delay : delay the response by the given duration.
+	Delay Duration `json:"delay,omitempty"`
+	// StatusCode : respond with this HTTP status code (e.g. 503) instead
+	// of returning the content.
+	StatusCode int `json:"statusCode,omitempty"`
+	// ThrottleRate : limit the rate at which the response body is transferred
+	// (in bytes per second).
+	ThrottleRate uint64 `json:"throttleRate,omitempty"`
+	// TruncateAfter : close the connection after the given number of bytes
+	// of the response body was sent (Content-Length still announces the full size).
+	TruncateAfter uint64 `json:"truncateAfter,omitempty"`
+	// ResetAfter : reset the TCP connection (i.e. send RST) after the given number
+	// of bytes of the response body was sent.
+	ResetAfter uint64 `json:"resetAfter,omitempty"`
+	// ContentLength : announce this value in the Content-Length header instead
+	// of the actual size of the response body.
+	// With smaller value, the body is cut short. With larger value, the connection
+	// is closed after the actual body is sent.
+	ContentLength *uint64 `json:"contentLength,omitempty"`
+	// TLSCert : present faulty server certificate when serving this content over HTTPS.
+	// The content is served only on the port configured for this fault
+	// in HTTPServer.TLSFaultPorts.
+	TLSCert TLSCertFault `json:"tlsCert,omitempty"`
+}
+
+// TLSCertFault : fault of the server certificate presented by HTTPS server.
+type TLSCertFault string
+
+const (
+	// TLSCertExpired : server certificate with the same subject and key as the valid
+	// certificate, but with validity period that ended in the past.
+	// Signed by the CA (HTTPServer.CACertPEM), or self-signed if CA is not provided.
+	TLSCertExpired TLSCertFault = "expired"
+	// TLSCertSelfSigned : server certificate with the same subject, key and validity
+	// as the valid certificate, but self-signed.
+	TLSCertSelfSigned TLSCertFault = "self-signed"
+)
 
 // NTPServer : NTP server.
 type NTPServer struct {
 	// Endpoint configuration.
 	Endpoint
+	// DNSClientConfig : DNS configuration to be applied for the NTP server.
+	// Needed to resolve upstream servers referenced by FQDN.
+	DNSClientConfig
 	// List of (public) NTP servers to synchronize with, each referenced
 	// by an IP address or a FQDN.
+	// Leave empty to serve time from the clock of the SDN VM instead.
 	UpstreamServers []string `json:"upstreamServers"`
+	// FakeClock : optionally, serve time from a fake clock, derived from the upstream
+	// (or local) time by applying a constant offset and/or a drift.
+	// This can be used to test EVE behaviour under clock skew and certificate
+	// validity edge cases.
+	FakeClock *FakeClock `json:"fakeClock,omitempty"`
 }
 
 // ItemCategory
@@ -236,6 +408,34 @@ func (e NTPServer) ItemCategory() string {
 	return "ntp-server"
 }
 
+// ReferencesFromItem
+func (e NTPServer) ReferencesFromItem() []LogicalLabelRef {
+	var refs []LogicalLabelRef
+	for _, dns := range e.PrivateDNS {
+		refs = append(refs, LogicalLabelRef{
+			ItemType:         Endpoint{}.ItemType(),
+			ItemCategory:     DNSServer{}.ItemCategory(),
+			ItemLogicalLabel: dns,
+			// Avoids duplicate DNS servers within the same NTP server.
+			RefKey: "ntp-server-" + e.LogicalLabel,
+		})
+	}
+	return refs
+}
+
+// FakeClock : time served by NTP server is shifted from the real time
+// by a constant offset and gradually diverges further if drift is configured.
+// Served time is calculated as "realTime + Offset + (realTime - startTime) * DriftPPM / 10^6",
+// where startTime is the time when the NTP server was (re)started.
+type FakeClock struct {
+	// Offset : constant offset (in seconds) to apply. Can be negative
+	// to serve time from the past.
+	Offset int64 `json:"offset"`
+	// DriftPPM : clock drift in parts per million, i.e. the number of microseconds
+	// the fake clock gains (positive value) or loses (negative value) every second.
+	DriftPPM int64 `json:"driftPPM"`
+}
+
 // ExplicitProxy : HTTP(S) proxy configured explicitly.
 type ExplicitProxy struct {
 	// Endpoint configuration.
@@ -504,3 +704,34 @@ func (s *ProxyListenProto) UnmarshalJSON(b []byte) error {
 	*s = ProxyListenProtoToID[j]
 	return nil
 }
+
+// CaptivePortal : endpoint emulating captive portal (e.g. hotel or enterprise
+// WiFi login page), which intercepts traffic of clients from networks referencing
+// the portal (see Network.CaptivePortal) until they log in.
+// Before login, clients can only use DHCP, DNS and access the portal itself
+// and destinations from WalledGarden. HTTP requests (including connectivity probes)
+// are redirected to the login page. HTTPS connections are either terminated by
+// the portal (presenting its own certificate, i.e. failing server verification)
+// or blocked (if the portal has no certificate). All other traffic is dropped.
+// Clients log in using the SDN agent API (see CaptivePortalLogin).
+type CaptivePortal struct {
+	// Endpoint configuration.
+	Endpoint
+	// LoginPage : HTML content of the login page.
+	// Leave empty to use a simple default page.
+	LoginPage string `json:"loginPage,omitempty"`
+	// CertPEM : portal certificate in the PEM format, presented to intercepted
+	// HTTPS connections. Leave empty to block HTTPS traffic instead.
+	CertPEM string `json:"certPEM,omitempty"`
+	// KeyPEM : portal key in the PEM format.
+	KeyPEM string `json:"keyPEM,omitempty"`
+	// WalledGarden : destinations accessible without logging in.
+	// Each item is either IP address, subnet in the CIDR format or "adam-ip"
+	// (IP address on which Adam is deployed).
+	WalledGarden []string `json:"walledGarden,omitempty"`
+}
+
+// ItemCategory
+func (e CaptivePortal) ItemCategory() string {
+	return "captive-portal"
+}
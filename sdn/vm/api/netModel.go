@@ -146,13 +146,14 @@ func (p Port) ReferencesFromItem() []LogicalLabelRef {
 
 // EVEConnect : connects Port to a given EVE instance.
 type EVEConnect struct {
-	// EVEInstance : name of the EVE instance to which a given port is connected.
-	// In the future, Eden may support running multiple EVE instances connected
-	// to the same SDN and controller. It is likely that each such instance
-	// will be assigned a unique logical label, which this field will reference.
-	// However, currently Eden is only able to manage a single EVE instance.
-	// For the time being it is therefore expected that this field is empty
-	// and EVEConnect refers to the one and only EVE instance.
+	// EVEInstance : logical label of the EVE instance to which a given port is connected.
+	// Eden is able to run multiple EVE instances connected to the same SDN
+	// and controller, each managed by a separate Eden config (context).
+	// The label is assigned to EVE instance using the config option "sdn.eve-instance".
+	// Ports are connected to EVE interfaces in the order in which they are defined,
+	// i.e. the first port connected to a given EVE instance appears as eth0 inside EVE,
+	// the second as eth1, etc.
+	// Leave empty if there is only one EVE instance connected to SDN.
 	EVEInstance string `json:"eveInstance"`
 	// MAC address assigned to the interface on the EVE side.
 	// If not specified by the user, Eden will generate a random MAC address.
@@ -215,6 +216,12 @@ type Network struct {
 	Subnet string `json:"subnet"`
 	// GwIP should be inside the Subnet.
 	GwIP string `json:"gwIP"`
+	// IPv6Subnet : IPv6 network address + netmask, configured on top of the IPv4 Subnet
+	// to make the network dual-stack. Leave empty for a single-stack network.
+	// To create IPv6-only network, put IPv6 subnet into Subnet instead.
+	IPv6Subnet string `json:"ipv6Subnet,omitempty"`
+	// IPv6GwIP should be inside the IPv6Subnet.
+	IPv6GwIP string `json:"ipv6GwIP,omitempty"`
 	// MTU : Maximum transmission unit size set for this network.
 	// If not defined (zero value), the default MTU for Ethernet, which is 1500 bytes,
 	// will be set.
@@ -231,6 +238,10 @@ type Network struct {
 	//        OR
 	//        -> Outside-of-SDN-VM
 	TransparentProxy string `json:"transparentProxy,omitempty"`
+	// CaptivePortal : Logical label of a CaptivePortal endpoint, intercepting
+	// traffic from this network until the client logs in.
+	// Cannot be combined with TransparentProxy.
+	CaptivePortal string `json:"captivePortal,omitempty"`
 	// Router configuration. Every network has a separate routing context.
 	// Undefined (nil) means that everything should be routed and accessible.
 	// That includes all networks, endpoints and the outside of Eden SDN.
@@ -321,6 +332,15 @@ func (n Network) ReferencesFromItem() []LogicalLabelRef {
 			RefKey:           "network-tproxy-" + n.LogicalLabel,
 		})
 	}
+	// Reference to a CaptivePortal.
+	if n.CaptivePortal != "" {
+		refs = append(refs, LogicalLabelRef{
+			ItemType:         Endpoint{}.ItemType(),
+			ItemCategory:     CaptivePortal{}.ItemCategory(),
+			ItemLogicalLabel: n.CaptivePortal,
+			RefKey:           "network-captive-portal-" + n.LogicalLabel,
+		})
+	}
 	return refs
 }
 
@@ -328,12 +348,21 @@ func (n Network) ReferencesFromItem() []LogicalLabelRef {
 // Note that for IPv6 we prefer to use Router Advertisement over DHCPv6 to publish
 // all this information to hosts on the network.
 // But DHCPv6 is still needed and used to convey NTP and netboot configuration (if provided).
+// With IPv6 subnet, DNS servers and domain name are announced using both Router
+// Advertisement (RDNSS and DNSSL options) and DHCPv6.
 type DHCP struct {
 	// Enable DHCP. Set to false to use EVE with static IP addressing.
 	Enable bool `json:"enable"`
 	// IPRange : a range of IP addresses to allocate from.
-	// Not applicable for IPv6.
+	// Not applicable for IPv6 (see IPv6Range).
 	IPRange IPRange `json:"ipRange"`
+	// IPv6AddrAssignment : method used to assign IPv6 addresses to hosts.
+	// Only applicable to network with IPv6 subnet.
+	IPv6AddrAssignment IPv6AddrAssignment `json:"ipv6AddrAssignment"`
+	// IPv6Range : a range of IPv6 addresses to allocate from using stateful DHCPv6.
+	// Only applicable with IPv6AddrAssignment set to IPv6DHCP.
+	// Leave empty to allocate from the entire IPv6 subnet.
+	IPv6Range IPRange `json:"ipv6Range"`
 	// StaticEntries : list of MAC->IP entries statically configured for the DHCP server.
 	StaticEntries []MACToIP `json:"staticEntries"`
 	// WithoutDefaultRoute : do not advertise default route to DHCP clients.
@@ -515,6 +544,52 @@ func (s *NetworkType) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// IPv6AddrAssignment : method used to assign IPv6 addresses to hosts.
+type IPv6AddrAssignment uint8
+
+const (
+	// IPv6SLAAC : Stateless Address Autoconfiguration (default).
+	// Router Advertisement published by radvd allows hosts to autoconfigure
+	// addresses from the subnet (which must be /64). Other configuration (NTP,
+	// netboot, etc.) is provided using stateless DHCPv6.
+	IPv6SLAAC IPv6AddrAssignment = iota
+	// IPv6DHCP : addresses are allocated using stateful DHCPv6.
+	// Router Advertisement is still used to announce the default gateway.
+	IPv6DHCP
+)
+
+// IPv6AddrAssignmentToString : convert IPv6AddrAssignment to string representation
+// used in JSON.
+var IPv6AddrAssignmentToString = map[IPv6AddrAssignment]string{
+	IPv6SLAAC: "slaac",
+	IPv6DHCP:  "dhcpv6",
+}
+
+// IPv6AddrAssignmentToID : get IPv6AddrAssignment from a string representation.
+var IPv6AddrAssignmentToID = map[string]IPv6AddrAssignment{
+	"":       IPv6SLAAC, // default value
+	"slaac":  IPv6SLAAC,
+	"dhcpv6": IPv6DHCP,
+}
+
+// MarshalJSON marshals the enum as a quoted json string.
+func (s IPv6AddrAssignment) MarshalJSON() ([]byte, error) {
+	buffer := bytes.NewBufferString(`"`)
+	buffer.WriteString(IPv6AddrAssignmentToString[s])
+	buffer.WriteString(`"`)
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalJSON un-marshals a quoted json string to the enum value.
+func (s *IPv6AddrAssignment) UnmarshalJSON(b []byte) error {
+	var j string
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	*s = IPv6AddrAssignmentToID[j]
+	return nil
+}
+
 // FwAction : firewall action.
 type FwAction uint8
 
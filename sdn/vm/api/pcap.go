@@ -0,0 +1,65 @@
+package api
+
+import "time"
+
+// PacketCapture : request to capture packets on an interface of Eden-SDN.
+// Capture is run by the SDN agent using tcpdump, storing packets into a pcap file
+// inside the SDN VM, from where it can be retrieved (e.g. over SSH).
+type PacketCapture struct {
+	// Port : logical label of the port on which to capture packets.
+	// Traffic is captured as seen on the physical interface, i.e. before
+	// being processed by bonds, bridges and VLAN filtering.
+	Port string `json:"port,omitempty"`
+	// Bridge : logical label of the bridge on which to capture packets.
+	Bridge string `json:"bridge,omitempty"`
+	// Network : logical label of the network on which to capture packets.
+	// Traffic is captured inside the network namespace, on the interface connecting
+	// the network with the bridge (i.e. after VLAN untagging).
+	Network string `json:"network,omitempty"`
+	// Endpoint : logical label of the endpoint on which to capture packets.
+	// Traffic is captured inside the endpoint namespace.
+	Endpoint string `json:"endpoint,omitempty"`
+	// Filter : optional packet filter expression in the BPF syntax (see pcap-filter(7)).
+	Filter string `json:"filter,omitempty"`
+	// SnapLen : number of bytes to capture from each packet.
+	// Zero value means to use the tcpdump default (262144 bytes).
+	SnapLen uint32 `json:"snapLen,omitempty"`
+	// MaxSize : once the capture file reaches this size (in bytes), capture is stopped.
+	// Zero value means to use the default limit (see DefaultPcapMaxSize).
+	MaxSize uint64 `json:"maxSize,omitempty"`
+	// MaxPackets : stop capture after receiving this number of packets.
+	// Zero value means no limit.
+	MaxPackets uint32 `json:"maxPackets,omitempty"`
+	// Duration : stop capture after the given duration.
+	// Zero value means no time limit.
+	Duration Duration `json:"duration,omitempty"`
+}
+
+// DefaultPcapMaxSize : default limit for the size of a pcap file (100 MB).
+const DefaultPcapMaxSize = 100 << 20
+
+// PacketCaptureStatus : status of a packet capture started by the SDN agent.
+type PacketCaptureStatus struct {
+	// ID : identifier assigned to the capture by the SDN agent.
+	ID string `json:"id"`
+	// Request : capture request.
+	Request PacketCapture `json:"request"`
+	// NetNamespace : network namespace in which the capture runs.
+	NetNamespace string `json:"netNamespace"`
+	// Interface : name of the captured network interface.
+	Interface string `json:"interface"`
+	// File : path to the pcap file inside the SDN VM.
+	File string `json:"file"`
+	// StartedAt : time when the capture was started.
+	StartedAt time.Time `json:"startedAt"`
+	// StoppedAt : time when the capture was stopped (zero if still running).
+	StoppedAt time.Time `json:"stoppedAt,omitempty"`
+	// Running : true if packets are still being captured.
+	Running bool `json:"running"`
+	// StopReason : why the capture was stopped (empty if still running).
+	StopReason string `json:"stopReason,omitempty"`
+	// Size : current size of the pcap file in bytes.
+	Size uint64 `json:"size"`
+	// Error : non-empty if tcpdump failed.
+	Error string `json:"error,omitempty"`
+}
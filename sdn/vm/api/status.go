@@ -1,6 +1,8 @@
 package api
 
 import (
+	"time"
+
 	"github.com/lf-edge/eve/libs/depgraph"
 )
 
@@ -10,7 +12,18 @@ type SDNStatus struct {
 	MgmtIPs []string `json:"mgmtIPs"`
 	// ConfigErrors : a set of current configuration errors. Normally this should be empty.
 	ConfigErrors []ConfigError `json:"configErrors,omitempty"`
-	// TODO: more fields...
+	// Ports : status and traffic counters of network ports.
+	Ports []PortStatus `json:"ports,omitempty"`
+	// Networks : status and traffic counters of networks.
+	Networks []NetworkStatus `json:"networks,omitempty"`
+	// DNSServers : statistics of DNS queries received by DNS server endpoints.
+	DNSServers []DNSServerStatus `json:"dnsServers,omitempty"`
+	// Proxies : requests received by (explicit and transparent) proxy endpoints.
+	Proxies []ProxyStatus `json:"proxies,omitempty"`
+	// CaptivePortals : clients logged in captive portal endpoints.
+	CaptivePortals []CaptivePortalStatus `json:"captivePortals,omitempty"`
+	// Conntrack : summary of connections tracked by the SDN VM (the router).
+	Conntrack ConntrackSummary `json:"conntrack"`
 }
 
 // ConfigError : error returned if the SDN agent failed to configure some configuration item.
@@ -20,3 +33,121 @@ type ConfigError struct {
 	// ErrMsg : error message
 	ErrMsg string
 }
+
+// IfCounters : traffic counters of a network interface.
+type IfCounters struct {
+	RxBytes   uint64 `json:"rxBytes"`
+	RxPackets uint64 `json:"rxPackets"`
+	RxDropped uint64 `json:"rxDropped"`
+	RxErrors  uint64 `json:"rxErrors"`
+	TxBytes   uint64 `json:"txBytes"`
+	TxPackets uint64 `json:"txPackets"`
+	TxDropped uint64 `json:"txDropped"`
+	TxErrors  uint64 `json:"txErrors"`
+}
+
+// QdiscStats : statistics of a queueing discipline (as reported by "tc -s qdisc").
+type QdiscStats struct {
+	// Kind : qdisc type (e.g. netem, tbf).
+	Kind string `json:"kind"`
+	// Handle : qdisc handle (e.g. "1:").
+	Handle     string `json:"handle"`
+	Bytes      uint64 `json:"bytes"`
+	Packets    uint64 `json:"packets"`
+	Drops      uint64 `json:"drops"`
+	Overlimits uint64 `json:"overlimits"`
+	Requeues   uint64 `json:"requeues"`
+	Backlog    uint64 `json:"backlog"`
+	Qlen       uint64 `json:"qlen"`
+}
+
+// PortStatus : status of a network port.
+type PortStatus struct {
+	// LogicalLabel : logical label of the port.
+	LogicalLabel string `json:"logicalLabel"`
+	// IfName : name of the interface inside the SDN VM.
+	IfName string `json:"ifName"`
+	// OperUp : true if the link is operationally up.
+	OperUp bool `json:"operUp"`
+	// Counters : traffic counters.
+	Counters IfCounters `json:"counters"`
+	// Qdiscs : statistics of traffic control queueing disciplines.
+	Qdiscs []QdiscStats `json:"qdiscs,omitempty"`
+}
+
+// NetworkStatus : status of a network.
+type NetworkStatus struct {
+	// LogicalLabel : logical label of the network.
+	LogicalLabel string `json:"logicalLabel"`
+	// Counters : traffic counters of the interface connecting the network with the bridge.
+	// Rx = traffic sent by the network clients (EVE), Tx = traffic sent to the clients.
+	Counters IfCounters `json:"counters"`
+	// DHCPLeases : IP leases granted by the DHCP server(s) of the network.
+	DHCPLeases []DHCPLease `json:"dhcpLeases,omitempty"`
+}
+
+// DHCPLease : IP address leased by a DHCP server.
+type DHCPLease struct {
+	// MAC : MAC address of the client (empty for DHCPv6).
+	MAC string `json:"mac,omitempty"`
+	// IP : leased IP address.
+	IP string `json:"ip"`
+	// Hostname : hostname provided by the client (if any).
+	Hostname string `json:"hostname,omitempty"`
+	// ClientID : client identifier (DUID for DHCPv6).
+	ClientID string `json:"clientID,omitempty"`
+	// Expiry : when the lease expires (zero for infinite lease).
+	Expiry time.Time `json:"expiry,omitempty"`
+}
+
+// DNSServerStatus : statistics of a DNS server endpoint.
+type DNSServerStatus struct {
+	// LogicalLabel : logical label of the DNS server endpoint.
+	LogicalLabel string `json:"logicalLabel"`
+	// Queries : total number of received DNS queries.
+	Queries uint64 `json:"queries"`
+	// QueriesByType : number of received queries per record type (A, AAAA, ...).
+	QueriesByType map[string]uint64 `json:"queriesByType,omitempty"`
+	// QueriesByClient : number of received queries per client IP address.
+	QueriesByClient map[string]uint64 `json:"queriesByClient,omitempty"`
+}
+
+// ProxyStatus : requests received by a proxy endpoint.
+type ProxyStatus struct {
+	// LogicalLabel : logical label of the proxy endpoint.
+	LogicalLabel string `json:"logicalLabel"`
+	// Requests : total number of received requests.
+	Requests uint64 `json:"requests"`
+	// RecentRequests : the most recent requests (see MaxRecentProxyRequests).
+	RecentRequests []ProxyRequest `json:"recentRequests,omitempty"`
+}
+
+// MaxRecentProxyRequests : maximum number of recent proxy requests reported
+// in ProxyStatus.
+const MaxRecentProxyRequests = 20
+
+// ProxyRequest : request received by a proxy (one entry of the proxy access log).
+type ProxyRequest struct {
+	// Time : when the request was received.
+	Time time.Time `json:"time"`
+	// Client : address (IP:port) of the client.
+	Client string `json:"client"`
+	// Method : HTTP method (CONNECT for tunneled requests).
+	Method string `json:"method"`
+	// Host : requested host (and port).
+	Host string `json:"host"`
+	// URL : requested URL (empty for CONNECT).
+	URL string `json:"url,omitempty"`
+}
+
+// ConntrackSummary : summary of the connection tracking table.
+type ConntrackSummary struct {
+	// Entries : total number of tracked connections.
+	Entries uint64 `json:"entries"`
+	// EntriesByProto : number of tracked connections per L4 protocol.
+	EntriesByProto map[string]uint64 `json:"entriesByProto,omitempty"`
+	// Packets : number of packets of all tracked connections (both directions).
+	Packets uint64 `json:"packets"`
+	// Bytes : number of bytes of all tracked connections (both directions).
+	Bytes uint64 `json:"bytes"`
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/lf-edge/eden/sdn/vm/cmd/captiveportal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Captive portal answers every intercepted HTTP(S) request with a redirect
+// to the login page, the same way real captive portals do. This includes
+// connectivity probes (e.g. /generate_204), which therefore fail to return
+// the expected response. Whether a client is intercepted or not (i.e. logged in)
+// is decided by the SDN agent (using iptables), not by the portal itself.
+func main() {
+	log.SetReportCaller(true)
+	configFile := flag.String("c", "/etc/captiveportal.conf", "Captive portal config file")
+	flag.Parse()
+
+	// Read and parse config file.
+	configBytes, err := os.ReadFile(*configFile)
+	if err != nil {
+		log.Fatalf("failed to read config file %s: %v", *configFile, err)
+	}
+	var portalConfig config.CaptivePortalConfig
+	if err = json.Unmarshal(configBytes, &portalConfig); err != nil {
+		log.Fatalf("failed to unmarshal captive portal config: %v", err)
+	}
+
+	// Process captive portal config.
+	if portalConfig.LogFile != "" {
+		logFile, err := os.OpenFile(portalConfig.LogFile, os.O_WRONLY|os.O_CREATE, 0755)
+		if err != nil {
+			log.Fatalf("failed to open log file %s: %v", portalConfig.LogFile, err)
+		}
+		log.SetOutput(logFile)
+	}
+	if portalConfig.Verbose {
+		log.SetLevel(log.DebugLevel)
+	} else {
+		log.SetLevel(log.InfoLevel)
+	}
+	if portalConfig.PidFile != "" {
+		pidBytes := []byte(fmt.Sprintf("%d", os.Getpid()))
+		err = os.WriteFile(portalConfig.PidFile, pidBytes, 0664)
+		if err != nil {
+			log.Fatalf("failed to write PID file %s: %v", portalConfig.PidFile, err)
+		}
+		defer os.Remove(portalConfig.PidFile)
+	}
+	if len(portalConfig.PortalHosts) == 0 {
+		log.Fatal("no portal hosts configured")
+	}
+	portal := &portalHandler{
+		portalHosts: portalConfig.PortalHosts,
+		loginPage:   portalConfig.LoginPage,
+	}
+
+	srvAddr := net.JoinHostPort(portalConfig.ListenIP, "80")
+	go listenAndServe(srvAddr, portal, nil)
+	if portalConfig.CertPEM != "" {
+		cert, err := tls.X509KeyPair([]byte(portalConfig.CertPEM),
+			[]byte(portalConfig.KeyPEM))
+		if err != nil {
+			log.Fatalf("failed to load portal certificate: %v", err)
+		}
+		srvAddr = net.JoinHostPort(portalConfig.ListenIP, "443")
+		go listenAndServe(srvAddr, portal, &cert)
+	}
+
+	cancelChan := make(chan os.Signal, 1)
+	// Catch termination or interrupt signal.
+	signal.Notify(cancelChan, syscall.SIGTERM, syscall.SIGINT)
+	sig := <-cancelChan
+	log.Infof("Caught terimation/interrupt signal: %v, exiting...", sig)
+}
+
+// portalHandler serves the login page and redirects all other requests to it.
+type portalHandler struct {
+	portalHosts []string
+	loginPage   string
+}
+
+func (h *portalHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Debugf("Received request: %+v", r)
+	// Prevent clients (and proxies) from caching responses, so that
+	// the access is restored immediately after login.
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	host := r.Host
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	for _, portalHost := range h.portalHosts {
+		if strings.EqualFold(host, portalHost) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			if _, err := w.Write([]byte(h.loginPage)); err != nil {
+				log.Warnf("Failed to write login page for %s: %v", r.RemoteAddr, err)
+			}
+			return
+		}
+	}
+	origURL := url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path, RawQuery: r.URL.RawQuery}
+	if r.TLS != nil {
+		origURL.Scheme = "https"
+	}
+	loginURL := url.URL{
+		Scheme:   "http",
+		Host:     h.portalHosts[0],
+		Path:     "/login",
+		RawQuery: url.Values{"redirect": []string{origURL.String()}}.Encode(),
+	}
+	log.Infof("Redirecting request %s %s from %s to the login page",
+		r.Method, origURL.String(), r.RemoteAddr)
+	http.Redirect(w, r, loginURL.String(), http.StatusFound)
+}
+
+func listenAndServe(srvAddr string, handler http.Handler, cert *tls.Certificate) {
+	server := &http.Server{
+		Addr:    srvAddr,
+		Handler: handler,
+	}
+	if cert == nil {
+		log.Debugf("Captive portal listening for HTTP on %s", srvAddr)
+		log.Fatalln(server.ListenAndServe())
+	}
+	server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{*cert}}
+	log.Debugf("Captive portal listening for HTTPS on %s", srvAddr)
+	log.Fatalln(server.ListenAndServeTLS("", ""))
+}
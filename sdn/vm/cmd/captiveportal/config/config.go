@@ -0,0 +1,27 @@
+package config
+
+// CaptivePortalConfig : captive portal configuration formatted with JSON
+// and passed to captiveportal using the "-c" command line argument.
+type CaptivePortalConfig struct {
+	// ListenIP : IP address to listen on.
+	// Leave empty to listen on all available interfaces instead of just
+	// the interface with the given host address.
+	ListenIP string `json:"listenIP"`
+	// LogFile : file to write all log messages into.
+	LogFile string `json:"logFile"`
+	// PidFile : file to write captiveportal process PID.
+	PidFile string `json:"pidFile"`
+	// Verbose : enable to have all requests logged.
+	Verbose bool `json:"verbose"`
+	// PortalHosts : host names (FQDN, IP) under which the portal is accessed.
+	// Requests for these hosts are answered with the login page, all other
+	// (intercepted) requests are redirected to the login page.
+	PortalHosts []string `json:"portalHosts"`
+	// LoginPage : HTML content of the login page.
+	LoginPage string `json:"loginPage"`
+	// CertPEM : portal certificate in the PEM format.
+	// Leave empty to not listen for HTTPS requests.
+	CertPEM string `json:"certPEM,omitempty"`
+	// KeyPEM : portal key in the PEM format.
+	KeyPEM string `json:"keyPEM,omitempty"`
+}
@@ -0,0 +1,21 @@
+package config
+
+// Dns64ProxyConfig : DNS64 proxy configuration formatted with JSON and passed
+// to dns64proxy using the "-c" command line argument.
+type Dns64ProxyConfig struct {
+	// ListenIP : IP address to listen on.
+	// Leave empty to listen on all available interfaces instead of just
+	// the interface with the given host address.
+	ListenIP string `json:"listenIP"`
+	// LogFile : file to write all log messages into.
+	LogFile string `json:"logFile"`
+	// PidFile : file to write dns64proxy process PID.
+	PidFile string `json:"pidFile"`
+	// Verbose : enable to have all requests logged.
+	Verbose bool `json:"verbose"`
+	// Prefix : NAT64 prefix (/96) used to synthesize AAAA records from A records.
+	Prefix string `json:"prefix"`
+	// UpstreamServers : IP addresses of DNS servers to forward queries to.
+	// Servers are tried in the order as listed.
+	UpstreamServers []string `json:"upstreamServers"`
+}
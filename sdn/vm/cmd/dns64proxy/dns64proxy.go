@@ -1,13 +1,101 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
-	"time"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/lf-edge/eden/sdn/vm/cmd/dns64proxy/config"
+	log "github.com/sirupsen/logrus"
 )
 
-// TODO: Implement DNS64 Proxy
-// It will be used to enable running Eden-SDN with IPv6 networks on IPv4-only hosts.
+// DNS64 Proxy (RFC 6147) forwards DNS queries to upstream servers and synthesizes
+// AAAA records from A records for names without IPv6 addresses.
+// It is used to enable running Eden-SDN with IPv6 networks on IPv4-only hosts
+// (together with NAT64).
 func main() {
-	fmt.Println("DNS64 Proxy: hello world")
-	time.Sleep(time.Hour)
+	log.SetReportCaller(true)
+	configFile := flag.String("c", "/etc/dns64proxy.conf", "DNS64 proxy config file")
+	flag.Parse()
+
+	// Read and parse config file.
+	configBytes, err := os.ReadFile(*configFile)
+	if err != nil {
+		log.Fatalf("failed to read config file %s: %v", *configFile, err)
+	}
+	var proxyConfig config.Dns64ProxyConfig
+	if err = json.Unmarshal(configBytes, &proxyConfig); err != nil {
+		log.Fatalf("failed to unmarshal DNS64 proxy config: %v", err)
+	}
+
+	// Process DNS64 proxy config.
+	if proxyConfig.LogFile != "" {
+		logFile, err := os.OpenFile(proxyConfig.LogFile, os.O_WRONLY|os.O_CREATE, 0755)
+		if err != nil {
+			log.Fatalf("failed to open log file %s: %v", proxyConfig.LogFile, err)
+		}
+		log.SetOutput(logFile)
+	}
+	if proxyConfig.Verbose {
+		log.SetLevel(log.DebugLevel)
+	} else {
+		log.SetLevel(log.InfoLevel)
+	}
+	if proxyConfig.PidFile != "" {
+		pidBytes := []byte(fmt.Sprintf("%d", os.Getpid()))
+		err = os.WriteFile(proxyConfig.PidFile, pidBytes, 0664)
+		if err != nil {
+			log.Fatalf("failed to write PID file %s: %v", proxyConfig.PidFile, err)
+		}
+		defer os.Remove(proxyConfig.PidFile)
+	}
+	_, prefix, err := net.ParseCIDR(proxyConfig.Prefix)
+	if err != nil {
+		log.Fatalf("invalid NAT64 prefix %s: %v", proxyConfig.Prefix, err)
+	}
+	if ones, bits := prefix.Mask.Size(); ones != 96 || bits != 128 {
+		log.Fatalf("unsupported NAT64 prefix %s (only /96 is supported)", proxyConfig.Prefix)
+	}
+	var upstreamServers []string
+	for _, server := range proxyConfig.UpstreamServers {
+		if ip := net.ParseIP(server); ip == nil {
+			log.Fatalf("invalid upstream server IP %s", server)
+		}
+		upstreamServers = append(upstreamServers, net.JoinHostPort(server, "53"))
+	}
+	if len(upstreamServers) == 0 {
+		log.Fatal("no upstream servers configured")
+	}
+	proxy := &dns64Proxy{
+		prefix:          prefix,
+		upstreamServers: upstreamServers,
+	}
+
+	srvAddr := net.JoinHostPort(proxyConfig.ListenIP, "53")
+	udpConn, err := net.ListenPacket("udp", srvAddr)
+	if err != nil {
+		log.Fatalf("failed to listen on UDP %s: %v", srvAddr, err)
+	}
+	tcpListener, err := net.Listen("tcp", srvAddr)
+	if err != nil {
+		log.Fatalf("failed to listen on TCP %s: %v", srvAddr, err)
+	}
+	go func() {
+		log.Debugf("DNS64 proxy listening on UDP %s", srvAddr)
+		log.Fatalln(proxy.serveUDP(udpConn))
+	}()
+	go func() {
+		log.Debugf("DNS64 proxy listening on TCP %s", srvAddr)
+		log.Fatalln(proxy.serveTCP(tcpListener))
+	}()
+
+	cancelChan := make(chan os.Signal, 1)
+	// Catch termination or interrupt signal.
+	signal.Notify(cancelChan, syscall.SIGTERM, syscall.SIGINT)
+	sig := <-cancelChan
+	log.Infof("Caught terimation/interrupt signal: %v, exiting...", sig)
 }
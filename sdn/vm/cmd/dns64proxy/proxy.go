@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	upstreamTimeout = 3 * time.Second
+	maxUDPMsgSize   = 4096
+)
+
+type dns64Proxy struct {
+	// NAT64 prefix (/96).
+	prefix *net.IPNet
+	// Upstream servers in the host:port format.
+	upstreamServers []string
+}
+
+func (p *dns64Proxy) serveUDP(conn net.PacketConn) error {
+	buf := make([]byte, maxUDPMsgSize)
+	for {
+		n, raddr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		query := make([]byte, n)
+		copy(query, buf[:n])
+		go func() {
+			resp, err := p.handleQuery(query, false)
+			if err != nil {
+				log.Warnf("Failed to handle query from %s: %v", raddr, err)
+				return
+			}
+			if _, err = conn.WriteTo(resp, raddr); err != nil {
+				log.Warnf("Failed to send response to %s: %v", raddr, err)
+			}
+		}()
+	}
+}
+
+func (p *dns64Proxy) serveTCP(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			for {
+				query, err := readTCPMsg(conn)
+				if err != nil {
+					if err != io.EOF {
+						log.Warnf("Failed to read query from %s: %v", conn.RemoteAddr(), err)
+					}
+					return
+				}
+				resp, err := p.handleQuery(query, true)
+				if err != nil {
+					log.Warnf("Failed to handle query from %s: %v", conn.RemoteAddr(), err)
+					return
+				}
+				if err = writeTCPMsg(conn, resp); err != nil {
+					log.Warnf("Failed to send response to %s: %v", conn.RemoteAddr(), err)
+					return
+				}
+			}
+		}()
+	}
+}
+
+func readTCPMsg(conn net.Conn) ([]byte, error) {
+	var msgLen uint16
+	if err := binary.Read(conn, binary.BigEndian, &msgLen); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, msgLen)
+	if _, err := io.ReadFull(conn, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func writeTCPMsg(conn net.Conn, msg []byte) error {
+	buf := make([]byte, 2, 2+len(msg))
+	binary.BigEndian.PutUint16(buf, uint16(len(msg)))
+	_, err := conn.Write(append(buf, msg...))
+	return err
+}
+
+// handleQuery forwards query to upstream servers and in case of AAAA query
+// without AAAA records in the response, it synthesizes them from A records.
+func (p *dns64Proxy) handleQuery(query []byte, tcp bool) ([]byte, error) {
+	var parser dnsmessage.Parser
+	if _, err := parser.Start(query); err != nil {
+		return nil, fmt.Errorf("failed to parse query: %w", err)
+	}
+	question, err := parser.Question()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query question: %w", err)
+	}
+	log.Debugf("Received query: %s %s", question.Name, question.Type)
+	resp, err := p.forward(query, tcp)
+	if err != nil {
+		return nil, err
+	}
+	if question.Type != dnsmessage.TypeAAAA || question.Class != dnsmessage.ClassINET {
+		return resp, nil
+	}
+	var respMsg dnsmessage.Message
+	if err = respMsg.Unpack(resp); err != nil {
+		return nil, fmt.Errorf("failed to parse upstream response: %w", err)
+	}
+	if respMsg.RCode != dnsmessage.RCodeSuccess || respMsg.Truncated ||
+		hasAnswerOfType(respMsg, dnsmessage.TypeAAAA) {
+		return resp, nil
+	}
+	// Ask for A records and synthesize AAAA records.
+	aMsg, err := p.queryA(question.Name, tcp)
+	if err != nil {
+		log.Warnf("Failed to get A records for %s: %v", question.Name, err)
+		return resp, nil
+	}
+	if aMsg.RCode != dnsmessage.RCodeSuccess || !hasAnswerOfType(*aMsg, dnsmessage.TypeA) {
+		return resp, nil
+	}
+	respMsg.Answers = nil
+	for _, answer := range aMsg.Answers {
+		switch body := answer.Body.(type) {
+		case *dnsmessage.CNAMEResource:
+			respMsg.Answers = append(respMsg.Answers, answer)
+		case *dnsmessage.AResource:
+			aaaa := &dnsmessage.AAAAResource{}
+			copy(aaaa.AAAA[:12], p.prefix.IP.To16()[:12])
+			copy(aaaa.AAAA[12:], body.A[:])
+			header := answer.Header
+			header.Type = dnsmessage.TypeAAAA
+			respMsg.Answers = append(respMsg.Answers, dnsmessage.Resource{
+				Header: header,
+				Body:   aaaa,
+			})
+			log.Debugf("Synthesized AAAA record for %s: %s", answer.Header.Name,
+				net.IP(aaaa.AAAA[:]))
+		}
+	}
+	// Authority section contains SOA for the (empty) AAAA answer.
+	respMsg.Authorities = nil
+	respMsg.Additionals = nil
+	return respMsg.Pack()
+}
+
+func hasAnswerOfType(msg dnsmessage.Message, recordType dnsmessage.Type) bool {
+	for _, answer := range msg.Answers {
+		if answer.Header.Type == recordType {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *dns64Proxy) queryA(name dnsmessage.Name, tcp bool) (*dnsmessage.Message, error) {
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:               uint16(time.Now().UnixNano()),
+			RecursionDesired: true,
+		},
+		Questions: []dnsmessage.Question{
+			{
+				Name:  name,
+				Type:  dnsmessage.TypeA,
+				Class: dnsmessage.ClassINET,
+			},
+		},
+	}
+	queryBytes, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.forward(queryBytes, tcp)
+	if err != nil {
+		return nil, err
+	}
+	var respMsg dnsmessage.Message
+	if err = respMsg.Unpack(resp); err != nil {
+		return nil, err
+	}
+	if respMsg.ID != query.ID {
+		return nil, errors.New("response ID does not match the query")
+	}
+	return &respMsg, nil
+}
+
+// forward sends query to upstream servers (in the configured order)
+// and returns the first received response.
+func (p *dns64Proxy) forward(query []byte, tcp bool) (resp []byte, err error) {
+	for _, server := range p.upstreamServers {
+		resp, err = p.forwardTo(server, query, tcp)
+		if err == nil {
+			return resp, nil
+		}
+		log.Warnf("Failed to forward query to %s: %v", server, err)
+	}
+	return nil, fmt.Errorf("all upstream servers failed, last error: %w", err)
+}
+
+func (p *dns64Proxy) forwardTo(server string, query []byte, tcp bool) ([]byte, error) {
+	network := "udp"
+	if tcp {
+		network = "tcp"
+	}
+	conn, err := net.DialTimeout(network, server, upstreamTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err = conn.SetDeadline(time.Now().Add(upstreamTimeout)); err != nil {
+		return nil, err
+	}
+	if tcp {
+		if err = writeTCPMsg(conn, query); err != nil {
+			return nil, err
+		}
+		return readTCPMsg(conn)
+	}
+	if _, err = conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, maxUDPMsgSize)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+)
+
+// DnsFaultProxyConfig : DNS fault proxy configuration formatted with JSON
+// and passed to dnsfaultproxy using the "-c" command line argument.
+type DnsFaultProxyConfig struct {
+	// ListenIP : IP address to listen on.
+	// Leave empty to listen on all available interfaces instead of just
+	// the interface with the given host address.
+	ListenIP string `json:"listenIP"`
+	// LogFile : file to write all log messages into.
+	LogFile string `json:"logFile"`
+	// QueryLogFile : file to log every received query into (using the same
+	// format as dnsmasq with log-queries enabled).
+	QueryLogFile string `json:"queryLogFile"`
+	// PidFile : file to write dnsfaultproxy process PID.
+	PidFile string `json:"pidFile"`
+	// Verbose : enable to have all requests logged.
+	Verbose bool `json:"verbose"`
+	// UpstreamServer : address (host:port) of the DNS server to forward
+	// queries to (unless a fault is injected instead).
+	UpstreamServer string `json:"upstreamServer"`
+	// Faults : faults to inject into responses.
+	Faults []sdnapi.DNSFault `json:"faults"`
+}
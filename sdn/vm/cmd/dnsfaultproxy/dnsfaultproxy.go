@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/lf-edge/eden/sdn/vm/cmd/dnsfaultproxy/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// DNS Fault Proxy forwards DNS queries to the upstream server (dnsmasq running
+// as DNS server endpoint) and injects faults (SERVFAIL, NXDOMAIN, timeouts,
+// truncated responses, latency) into responses for selected domain names.
+// It is used to test DNS fallbacks of EVE under broken resolver conditions.
+func main() {
+	log.SetReportCaller(true)
+	configFile := flag.String("c", "/etc/dnsfaultproxy.conf", "DNS fault proxy config file")
+	flag.Parse()
+
+	// Read and parse config file.
+	configBytes, err := os.ReadFile(*configFile)
+	if err != nil {
+		log.Fatalf("failed to read config file %s: %v", *configFile, err)
+	}
+	var proxyConfig config.DnsFaultProxyConfig
+	if err = json.Unmarshal(configBytes, &proxyConfig); err != nil {
+		log.Fatalf("failed to unmarshal DNS fault proxy config: %v", err)
+	}
+
+	// Process DNS fault proxy config.
+	if proxyConfig.LogFile != "" {
+		logFile, err := os.OpenFile(proxyConfig.LogFile, os.O_WRONLY|os.O_CREATE, 0755)
+		if err != nil {
+			log.Fatalf("failed to open log file %s: %v", proxyConfig.LogFile, err)
+		}
+		log.SetOutput(logFile)
+	}
+	if proxyConfig.Verbose {
+		log.SetLevel(log.DebugLevel)
+	} else {
+		log.SetLevel(log.InfoLevel)
+	}
+	if proxyConfig.PidFile != "" {
+		pidBytes := []byte(fmt.Sprintf("%d", os.Getpid()))
+		err = os.WriteFile(proxyConfig.PidFile, pidBytes, 0664)
+		if err != nil {
+			log.Fatalf("failed to write PID file %s: %v", proxyConfig.PidFile, err)
+		}
+		defer os.Remove(proxyConfig.PidFile)
+	}
+	if _, _, err = net.SplitHostPort(proxyConfig.UpstreamServer); err != nil {
+		log.Fatalf("invalid upstream server address %s: %v", proxyConfig.UpstreamServer, err)
+	}
+	proxy := &dnsFaultProxy{
+		upstreamServer: proxyConfig.UpstreamServer,
+	}
+	for _, fault := range proxyConfig.Faults {
+		proxy.faults = append(proxy.faults, &dnsFault{DNSFault: fault})
+	}
+	if proxyConfig.QueryLogFile != "" {
+		proxy.queryLog, err = os.OpenFile(proxyConfig.QueryLogFile,
+			os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			log.Fatalf("failed to open query log file %s: %v", proxyConfig.QueryLogFile, err)
+		}
+		defer proxy.queryLog.Close()
+	}
+
+	srvAddr := net.JoinHostPort(proxyConfig.ListenIP, "53")
+	udpConn, err := net.ListenPacket("udp", srvAddr)
+	if err != nil {
+		log.Fatalf("failed to listen on UDP %s: %v", srvAddr, err)
+	}
+	tcpListener, err := net.Listen("tcp", srvAddr)
+	if err != nil {
+		log.Fatalf("failed to listen on TCP %s: %v", srvAddr, err)
+	}
+	go func() {
+		log.Debugf("DNS fault proxy listening on UDP %s", srvAddr)
+		log.Fatalln(proxy.serveUDP(udpConn))
+	}()
+	go func() {
+		log.Debugf("DNS fault proxy listening on TCP %s", srvAddr)
+		log.Fatalln(proxy.serveTCP(tcpListener))
+	}()
+
+	cancelChan := make(chan os.Signal, 1)
+	// Catch termination or interrupt signal.
+	signal.Notify(cancelChan, syscall.SIGTERM, syscall.SIGINT)
+	sig := <-cancelChan
+	log.Infof("Caught terimation/interrupt signal: %v, exiting...", sig)
+}
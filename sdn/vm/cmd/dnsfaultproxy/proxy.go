@@ -0,0 +1,266 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	upstreamTimeout = 3 * time.Second
+	maxUDPMsgSize   = 4096
+)
+
+type dnsFaultProxy struct {
+	// Upstream server in the host:port format.
+	upstreamServer string
+	// Faults to inject (the first matching fault is applied).
+	faults []*dnsFault
+	// Every received query is logged into this file (if not nil).
+	queryLog   *os.File
+	queryLogMx sync.Mutex
+}
+
+// dnsFault : fault with the number of matching queries received so far.
+type dnsFault struct {
+	sdnapi.DNSFault
+	queries uint32
+}
+
+// matches returns true if the fault applies to the given domain name.
+func (f *dnsFault) matches(name string) bool {
+	fqdn := strings.ToLower(strings.TrimSuffix(f.FQDN, "."))
+	if fqdn == "" {
+		return true
+	}
+	return name == fqdn || strings.HasSuffix(name, "."+fqdn)
+}
+
+func (p *dnsFaultProxy) serveUDP(conn net.PacketConn) error {
+	buf := make([]byte, maxUDPMsgSize)
+	for {
+		n, raddr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		query := make([]byte, n)
+		copy(query, buf[:n])
+		go func() {
+			resp, err := p.handleQuery(query, false, raddr)
+			if err != nil {
+				log.Warnf("Failed to handle query from %s: %v", raddr, err)
+				return
+			}
+			if resp == nil {
+				// Timeout injected.
+				return
+			}
+			if _, err = conn.WriteTo(resp, raddr); err != nil {
+				log.Warnf("Failed to send response to %s: %v", raddr, err)
+			}
+		}()
+	}
+}
+
+func (p *dnsFaultProxy) serveTCP(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			for {
+				query, err := readTCPMsg(conn)
+				if err != nil {
+					if err != io.EOF {
+						log.Warnf("Failed to read query from %s: %v", conn.RemoteAddr(), err)
+					}
+					return
+				}
+				resp, err := p.handleQuery(query, true, conn.RemoteAddr())
+				if err != nil {
+					log.Warnf("Failed to handle query from %s: %v", conn.RemoteAddr(), err)
+					return
+				}
+				if resp == nil {
+					// Timeout injected, leave the client waiting for the response.
+					continue
+				}
+				if err = writeTCPMsg(conn, resp); err != nil {
+					log.Warnf("Failed to send response to %s: %v", conn.RemoteAddr(), err)
+					return
+				}
+			}
+		}()
+	}
+}
+
+func readTCPMsg(conn net.Conn) ([]byte, error) {
+	var msgLen uint16
+	if err := binary.Read(conn, binary.BigEndian, &msgLen); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, msgLen)
+	if _, err := io.ReadFull(conn, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func writeTCPMsg(conn net.Conn, msg []byte) error {
+	buf := make([]byte, 2, 2+len(msg))
+	binary.BigEndian.PutUint16(buf, uint16(len(msg)))
+	_, err := conn.Write(append(buf, msg...))
+	return err
+}
+
+// handleQuery either forwards query to the upstream server or injects a fault.
+// Returns nil response (and nil error) if the query should not be answered.
+func (p *dnsFaultProxy) handleQuery(query []byte, tcp bool, client net.Addr) ([]byte, error) {
+	var parser dnsmessage.Parser
+	if _, err := parser.Start(query); err != nil {
+		return nil, fmt.Errorf("failed to parse query: %w", err)
+	}
+	question, err := parser.Question()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query question: %w", err)
+	}
+	name := strings.ToLower(strings.TrimSuffix(question.Name.String(), "."))
+	qType := strings.TrimPrefix(question.Type.String(), "Type")
+	p.logQuery(name, qType, client)
+	fault := p.matchFault(name, tcp)
+	if fault == nil {
+		return p.forward(query, tcp)
+	}
+	if delay := p.faultDelay(fault); delay > 0 {
+		log.Infof("Injected fault (delay %v) for query[%s] %s from %s",
+			delay, qType, name, client)
+		time.Sleep(delay)
+	}
+	switch fault.Response {
+	case sdnapi.DNSFaultServFail:
+		log.Infof("Injected fault (SERVFAIL) for query[%s] %s from %s", qType, name, client)
+		return faultyResponse(query, dnsmessage.RCodeServerFailure, false)
+	case sdnapi.DNSFaultNXDomain:
+		log.Infof("Injected fault (NXDOMAIN) for query[%s] %s from %s", qType, name, client)
+		return faultyResponse(query, dnsmessage.RCodeNameError, false)
+	case sdnapi.DNSFaultTimeout:
+		log.Infof("Injected fault (timeout) for query[%s] %s from %s", qType, name, client)
+		return nil, nil
+	case sdnapi.DNSFaultTruncated:
+		if !tcp {
+			log.Infof("Injected fault (truncated) for query[%s] %s from %s",
+				qType, name, client)
+			return faultyResponse(query, dnsmessage.RCodeSuccess, true)
+		}
+	}
+	return p.forward(query, tcp)
+}
+
+// matchFault returns the first matching fault which is not yet exhausted.
+// Queries received over TCP are not counted by faults with truncated response,
+// these are retries of queries already counted over UDP.
+func (p *dnsFaultProxy) matchFault(name string, tcp bool) *dnsFault {
+	for _, fault := range p.faults {
+		if !fault.matches(name) {
+			continue
+		}
+		var queryNum uint32
+		if tcp && fault.Response == sdnapi.DNSFaultTruncated {
+			queryNum = atomic.LoadUint32(&fault.queries)
+		} else {
+			queryNum = atomic.AddUint32(&fault.queries, 1)
+		}
+		if fault.FaultyQueries != 0 && queryNum > fault.FaultyQueries {
+			continue
+		}
+		return fault
+	}
+	return nil
+}
+
+func (p *dnsFaultProxy) faultDelay(fault *dnsFault) time.Duration {
+	delay := time.Duration(fault.Delay)
+	if jitter := time.Duration(fault.DelayJitter); jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(jitter) + 1))
+	}
+	return delay
+}
+
+// logQuery logs query using the same format as dnsmasq (with log-queries enabled).
+func (p *dnsFaultProxy) logQuery(name, qType string, client net.Addr) {
+	if p.queryLog == nil {
+		return
+	}
+	clientIP := client.String()
+	if host, _, err := net.SplitHostPort(clientIP); err == nil {
+		clientIP = host
+	}
+	p.queryLogMx.Lock()
+	defer p.queryLogMx.Unlock()
+	_, err := fmt.Fprintf(p.queryLog, "%s dnsfaultproxy[%d]: query[%s] %s from %s\n",
+		time.Now().Format(time.Stamp), os.Getpid(), qType, name, clientIP)
+	if err != nil {
+		log.Warnf("Failed to log query: %v", err)
+	}
+}
+
+// faultyResponse builds response to the query with the given RCode,
+// no answers and optionally with the TC (truncated) flag set.
+func faultyResponse(query []byte, rcode dnsmessage.RCode, truncated bool) ([]byte, error) {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(query); err != nil {
+		return nil, fmt.Errorf("failed to parse query: %w", err)
+	}
+	msg.Header.Response = true
+	msg.Header.RecursionAvailable = true
+	msg.Header.RCode = rcode
+	msg.Header.Truncated = truncated
+	msg.Answers = nil
+	msg.Authorities = nil
+	msg.Additionals = nil
+	return msg.Pack()
+}
+
+// forward sends query to the upstream server and returns the received response.
+func (p *dnsFaultProxy) forward(query []byte, tcp bool) ([]byte, error) {
+	network := "udp"
+	if tcp {
+		network = "tcp"
+	}
+	conn, err := net.DialTimeout(network, p.upstreamServer, upstreamTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err = conn.SetDeadline(time.Now().Add(upstreamTimeout)); err != nil {
+		return nil, err
+	}
+	if tcp {
+		if err = writeTCPMsg(conn, query); err != nil {
+			return nil, err
+		}
+		return readTCPMsg(conn)
+	}
+	if _, err = conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, maxUDPMsgSize)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
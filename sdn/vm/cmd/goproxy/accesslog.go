@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/elazarl/goproxy"
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	log "github.com/sirupsen/logrus"
+)
+
+// accessLog : logger of received requests, shared by all proxy instances.
+// Nil if access logging is disabled.
+var accessLog *accessLogger
+
+// accessLogger writes one JSON-encoded sdnapi.ProxyRequest per line.
+type accessLogger struct {
+	sync.Mutex
+	file *os.File
+}
+
+func newAccessLogger(logFile string) (*accessLogger, error) {
+	file, err := os.OpenFile(logFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &accessLogger{file: file}, nil
+}
+
+func (l *accessLogger) log(entry sdnapi.ProxyRequest) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Errorf("Failed to marshal access log entry: %v", err)
+		return
+	}
+	l.Lock()
+	defer l.Unlock()
+	if _, err = l.file.Write(append(line, '\n')); err != nil {
+		log.Errorf("Failed to write access log entry: %v", err)
+	}
+}
+
+// logRequest is installed as the first handler for non-CONNECT requests.
+func (l *accessLogger) logRequest(req *http.Request,
+	ctx *goproxy.ProxyCtx) (*http.Request, *http.Response) {
+	l.log(sdnapi.ProxyRequest{
+		Time:   time.Now(),
+		Client: req.RemoteAddr,
+		Method: req.Method,
+		Host:   req.Host,
+		URL:    req.URL.String(),
+	})
+	return req, nil // continue with other handlers
+}
+
+// logConnect is installed as the first handler for CONNECT requests.
+func (l *accessLogger) logConnect(host string,
+	ctx *goproxy.ProxyCtx) (*goproxy.ConnectAction, string) {
+	var client string
+	if ctx.Req != nil {
+		client = ctx.Req.RemoteAddr
+	}
+	l.log(sdnapi.ProxyRequest{
+		Time:   time.Now(),
+		Client: client,
+		Method: http.MethodConnect,
+		Host:   host,
+	})
+	return nil, host // continue with other handlers
+}
@@ -31,6 +31,9 @@ type ProxyConfig struct {
 	Transparent bool `json:"transparent"`
 	// LogFile : file to write all log messages into.
 	LogFile string `json:"logFile"`
+	// AccessLogFile : file to log all received requests into, one JSON-encoded
+	// sdnapi.ProxyRequest per line. Leave empty to disable.
+	AccessLogFile string `json:"accessLogFile"`
 	// PidFile : file to write goproxy process PID.
 	PidFile string `json:"pidFile"`
 	// Verbose : enable to have all proxied requests logged.
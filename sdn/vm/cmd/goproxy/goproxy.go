@@ -71,6 +71,13 @@ func main() {
 		}
 		log.SetOutput(logFile)
 	}
+	if proxyConfig.AccessLogFile != "" {
+		accessLog, err = newAccessLogger(proxyConfig.AccessLogFile)
+		if err != nil {
+			log.Fatalf("failed to open access log file %s: %v",
+				proxyConfig.AccessLogFile, err)
+		}
+	}
 	if proxyConfig.Verbose {
 		log.SetLevel(log.DebugLevel)
 	} else {
@@ -55,6 +55,11 @@ func installProxyHandler(rule sdnapi.ProxyRule, https bool, proxy *goproxy.Proxy
 
 func installProxyHandlers(proxyConfig config.ProxyConfig, https, transparent bool,
 	proxy *goproxy.ProxyHttpServer) {
+	// Log all received requests.
+	if accessLog != nil {
+		proxy.OnRequest().DoFunc(accessLog.logRequest)
+		proxy.OnRequest().HandleConnect(goproxy.FuncHttpsHandler(accessLog.logConnect))
+	}
 	// Add mark to differentiate between CONNECT and other HTTP methods.
 	proxy.OnRequest().HandleConnect(goproxy.FuncHttpsHandler(markConnect))
 	// Configure basic authentication if requested.
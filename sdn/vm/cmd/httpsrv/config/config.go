@@ -27,6 +27,14 @@ type HttpSrvConfig struct {
 	CertPEM string `json:"certPEM"`
 	// KeyPEM : Server key in the PEM format. Required for HTTPS.
 	KeyPEM string `json:"keyPEM"`
+	// CACertPEM : certificate of the CA which issued the server certificate.
+	// Optional, used to sign the expired server certificate.
+	CACertPEM string `json:"caCertPEM,omitempty"`
+	// CAKeyPEM : key of the CA which issued the server certificate.
+	CAKeyPEM string `json:"caKeyPEM,omitempty"`
+	// TLSFaultPorts : additional ports to listen for HTTPS requests,
+	// each presenting a faulty server certificate.
+	TLSFaultPorts map[sdnapi.TLSCertFault]uint16 `json:"tlsFaultPorts,omitempty"`
 	// Maps URL Path to a content to be returned inside the HTTP(S) response body.
 	Paths map[string]sdnapi.HTTPContent `json:"paths"`
 }
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	log "github.com/sirupsen/logrus"
+)
+
+// Throttled response body is sent in chunks, each followed by a pause.
+const throttleChunksPerSec = 10
+
+var errInjectedFault = errors.New("response interrupted by injected fault")
+
+type connContextKey struct{}
+
+// saveConnInContext is used as http.Server.ConnContext to make the underlying
+// TCP connection available to handlers (needed to inject connection faults).
+func saveConnInContext(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, conn)
+}
+
+func connFromContext(ctx context.Context) net.Conn {
+	conn, _ := ctx.Value(connContextKey{}).(net.Conn)
+	return conn
+}
+
+// faultyResponseWriter wraps http.ResponseWriter and injects faults
+// into the transfer of the response body.
+type faultyResponseWriter struct {
+	http.ResponseWriter
+	faults  *sdnapi.HTTPFaults
+	conn    net.Conn
+	reqURL  string
+	written uint64
+	failed  error
+}
+
+func newFaultyResponseWriter(w http.ResponseWriter, r *http.Request,
+	faults *sdnapi.HTTPFaults) *faultyResponseWriter {
+	return &faultyResponseWriter{
+		ResponseWriter: w,
+		faults:         faults,
+		conn:           connFromContext(r.Context()),
+		reqURL:         r.URL.String(),
+	}
+}
+
+// WriteHeader replaces Content-Length if requested.
+func (w *faultyResponseWriter) WriteHeader(statusCode int) {
+	if w.faults.ContentLength != nil && w.Header().Get("Content-Length") != "" {
+		w.Header().Set("Content-Length", strconv.FormatUint(*w.faults.ContentLength, 10))
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write sends (part of) the response body, possibly throttled, truncated
+// or interrupted by a connection reset.
+func (w *faultyResponseWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		if w.failed != nil {
+			return n, w.failed
+		}
+		chunk := p
+		rate := w.faults.ThrottleRate
+		if rate > 0 {
+			maxChunk := rate / throttleChunksPerSec
+			if maxChunk == 0 {
+				maxChunk = 1
+			}
+			if uint64(len(chunk)) > maxChunk {
+				chunk = chunk[:maxChunk]
+			}
+		}
+		limit, reset := w.interruptAt()
+		interrupt := limit != 0 && w.written+uint64(len(chunk)) >= limit
+		if interrupt {
+			chunk = chunk[:limit-w.written]
+		}
+		if len(chunk) > 0 {
+			var written int
+			written, err = w.ResponseWriter.Write(chunk)
+			n += written
+			w.written += uint64(written)
+			if err != nil {
+				return n, err
+			}
+		}
+		if interrupt {
+			w.interrupt(reset)
+			return n, w.failed
+		}
+		if rate > 0 {
+			w.flush()
+			time.Sleep(time.Duration(len(chunk)) * time.Second / time.Duration(rate))
+		}
+		p = p[len(chunk):]
+	}
+	return n, nil
+}
+
+// interruptAt returns the number of body bytes after which the transfer
+// should be interrupted (zero if it should not be interrupted) and whether
+// the connection should be reset or just closed.
+func (w *faultyResponseWriter) interruptAt() (limit uint64, reset bool) {
+	if w.faults.TruncateAfter != 0 {
+		limit = w.faults.TruncateAfter
+	}
+	if w.faults.ResetAfter != 0 && (limit == 0 || w.faults.ResetAfter < limit) {
+		limit = w.faults.ResetAfter
+		reset = true
+	}
+	return limit, reset
+}
+
+func (w *faultyResponseWriter) interrupt(reset bool) {
+	w.failed = errInjectedFault
+	w.flush()
+	if w.conn == nil {
+		log.Errorf("Missing connection for request %s, cannot inject fault", w.reqURL)
+		return
+	}
+	fault := "connection closed"
+	if reset {
+		fault = "connection reset"
+		conn := w.conn
+		if tlsConn, isTLS := conn.(*tls.Conn); isTLS {
+			conn = tlsConn.NetConn()
+		}
+		if tcpConn, isTCP := conn.(*net.TCPConn); isTCP {
+			// Discard unsent data and send RST instead of FIN.
+			if err := tcpConn.SetLinger(0); err != nil {
+				log.Errorf("Failed to set SO_LINGER for request %s: %v", w.reqURL, err)
+			}
+		}
+	}
+	log.Infof("Injected fault (%s) after %d bytes of response body for request %s",
+		fault, w.written, w.reqURL)
+	if err := w.conn.Close(); err != nil {
+		log.Errorf("Failed to close connection for request %s: %v", w.reqURL, err)
+	}
+}
+
+func (w *faultyResponseWriter) flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// generatedContent : pseudo-random content of a given size, generated on the fly.
+// Implements io.ReadSeeker.
+type generatedContent struct {
+	size   int64
+	offset int64
+}
+
+// Read generates the next part of the content.
+func (g *generatedContent) Read(p []byte) (n int, err error) {
+	if g.offset >= g.size {
+		return 0, io.EOF
+	}
+	if remaining := g.size - g.offset; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	for i := range p {
+		p[i] = generatedByte(g.offset + int64(i))
+	}
+	g.offset += int64(len(p))
+	return len(p), nil
+}
+
+// Seek moves offset for the next Read.
+func (g *generatedContent) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += g.offset
+	case io.SeekEnd:
+		offset += g.size
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	g.offset = offset
+	return offset, nil
+}
+
+// generatedByte returns byte of the generated content at the given offset.
+// The same offset always gives the same byte, so that interrupted downloads
+// can be resumed.
+func generatedByte(offset int64) byte {
+	return byte((uint64(offset) * 0x9E3779B97F4A7C15) >> 56)
+}
@@ -1,28 +1,83 @@
 package main
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
 	"github.com/lf-edge/eden/sdn/vm/cmd/httpsrv/config"
 	log "github.com/sirupsen/logrus"
 )
 
-func handler(content sdnapi.HTTPContent) func(http.ResponseWriter, *http.Request) {
-	return func(w http.ResponseWriter, r *http.Request) {
-		log.Debugf("Received request: %+v", r)
-		w.Header().Add("Content-Type", content.ContentType)
-		_, err := w.Write([]byte(content.Content))
-		if err != nil {
-			log.Errorf("Failed to write content for request %+v: %v", r, err)
+// pathHandler serves content configured for a URL path.
+type pathHandler struct {
+	content  sdnapi.HTTPContent
+	modTime  time.Time
+	requests uint32
+}
+
+func (h *pathHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Debugf("Received request: %+v", r)
+	reqNum := atomic.AddUint32(&h.requests, 1)
+	faults := h.content.Faults
+	if faults != nil && faults.FaultyRequests != 0 && reqNum > faults.FaultyRequests {
+		faults = nil
+	}
+	if faults != nil {
+		if faults.Delay > 0 {
+			select {
+			case <-time.After(time.Duration(faults.Delay)):
+			case <-r.Context().Done():
+				return
+			}
 		}
+		if faults.StatusCode != 0 {
+			log.Infof("Injected fault (status code %d) into response for request %s",
+				faults.StatusCode, r.URL)
+			http.Error(w, http.StatusText(faults.StatusCode), faults.StatusCode)
+			return
+		}
+		w = newFaultyResponseWriter(w, r, faults)
+	}
+	if h.content.ContentType != "" {
+		w.Header().Set("Content-Type", h.content.ContentType)
 	}
+	var body io.ReadSeeker
+	if h.content.GeneratedSize != 0 {
+		body = &generatedContent{size: int64(h.content.GeneratedSize)}
+	} else {
+		body = strings.NewReader(h.content.Content)
+	}
+	// ServeContent handles Range requests (needed to resume downloads).
+	http.ServeContent(w, r, "", h.modTime, body)
+}
+
+func listenAndServe(srvAddr string, handler http.Handler, cert *tls.Certificate) {
+	server := &http.Server{
+		Addr:        srvAddr,
+		Handler:     handler,
+		ConnContext: saveConnInContext,
+	}
+	if cert == nil {
+		log.Debugf("HTTP server listening on %s", srvAddr)
+		log.Fatalln(server.ListenAndServe())
+	}
+	server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{*cert}}
+	// Disable HTTP/2, otherwise requests would be multiplexed over a single
+	// connection and injected connection faults would affect all of them.
+	server.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+	log.Debugf("HTTPS server listening on %s", srvAddr)
+	log.Fatalln(server.ListenAndServeTLS("", ""))
 }
 
 func main() {
@@ -62,48 +117,51 @@ func main() {
 		defer os.Remove(httpSrvConfig.PidFile)
 	}
 
+	// Paths with TLS certificate fault are served only on the port of the fault.
+	modTime := time.Now()
+	mux := http.NewServeMux()
+	faultMuxes := make(map[sdnapi.TLSCertFault]*http.ServeMux)
+	for fault := range httpSrvConfig.TLSFaultPorts {
+		faultMuxes[fault] = http.NewServeMux()
+	}
 	for path, content := range httpSrvConfig.Paths {
-		http.HandleFunc(path, handler(content))
+		handler := &pathHandler{content: content, modTime: modTime}
+		if content.Faults != nil && content.Faults.TLSCert != "" {
+			faultMux, ok := faultMuxes[content.Faults.TLSCert]
+			if !ok {
+				log.Fatalf("missing port for TLS certificate fault %s (path %s)",
+					content.Faults.TLSCert, path)
+			}
+			faultMux.Handle(path, handler)
+			continue
+		}
+		mux.Handle(path, handler)
 	}
 
 	if httpSrvConfig.HTTPPort != 0 {
 		srvAddr := fmt.Sprintf("%s:%d", httpSrvConfig.ListenIP, httpSrvConfig.HTTPPort)
-		go func() {
-			log.Debugf("HTTP server listening on %s", srvAddr)
-			log.Fatalln(http.ListenAndServe(srvAddr, nil))
-		}()
+		go listenAndServe(srvAddr, mux, nil)
 	}
 
-	if httpSrvConfig.HTTPSPort != 0 {
-		srvAddr := fmt.Sprintf("%s:%d", httpSrvConfig.ListenIP, httpSrvConfig.HTTPSPort)
-		certFile, err := os.CreateTemp("", "httpsrv-*.cert")
+	if httpSrvConfig.HTTPSPort != 0 || len(httpSrvConfig.TLSFaultPorts) > 0 {
+		serverCert, err := tls.X509KeyPair([]byte(httpSrvConfig.CertPEM),
+			[]byte(httpSrvConfig.KeyPEM))
 		if err != nil {
-			log.Fatalf("failed to create temporary file for the certificate: %v", err)
+			log.Fatalf("failed to load server certificate: %v", err)
 		}
-		keyFile, err := os.CreateTemp("", "httpsrv-*.key")
-		if err != nil {
-			log.Fatalf("failed to create temporary file for the key: %v", err)
+		if httpSrvConfig.HTTPSPort != 0 {
+			srvAddr := fmt.Sprintf("%s:%d", httpSrvConfig.ListenIP, httpSrvConfig.HTTPSPort)
+			go listenAndServe(srvAddr, mux, &serverCert)
 		}
-		defer func() {
-			if err = os.Remove(certFile.Name()); err != nil {
-				log.Warnf("failed to remove temporary file %s: %v", certFile.Name(), err)
+		for fault, port := range httpSrvConfig.TLSFaultPorts {
+			cert, err := faultyCertificate(fault, serverCert,
+				httpSrvConfig.CACertPEM, httpSrvConfig.CAKeyPEM)
+			if err != nil {
+				log.Fatal(err)
 			}
-			if err = os.Remove(keyFile.Name()); err != nil {
-				log.Warnf("failed to remove temporary file %s: %v", keyFile.Name(), err)
-			}
-		}()
-		if _, err = certFile.WriteString(httpSrvConfig.CertPEM); err != nil {
-			log.Fatalf("failed to write server cert to file %s: %v", certFile.Name(), err)
-		}
-		if _, err = keyFile.WriteString(httpSrvConfig.KeyPEM); err != nil {
-			log.Fatalf("failed to write server key to file %s: %v", keyFile.Name(), err)
+			srvAddr := fmt.Sprintf("%s:%d", httpSrvConfig.ListenIP, port)
+			go listenAndServe(srvAddr, faultMuxes[fault], &cert)
 		}
-		log.Debugf("Storing server certificate to file %s", certFile.Name())
-		log.Debugf("Storing server key to file %s", keyFile.Name())
-		go func() {
-			log.Debugf("HTTPS server listening on %s", srvAddr)
-			log.Fatalln(http.ListenAndServeTLS(srvAddr, certFile.Name(), keyFile.Name(), nil))
-		}()
 	}
 
 	cancelChan := make(chan os.Signal, 1)
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"math/big"
+	"time"
+
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+)
+
+// faultyCertificate derives certificate with the given fault from the server certificate.
+// The faulty certificate uses the same subject, SANs and key as the server certificate.
+func faultyCertificate(fault sdnapi.TLSCertFault, serverCert tls.Certificate,
+	caCertPEM, caKeyPEM string) (cert tls.Certificate, err error) {
+	leaf, err := x509.ParseCertificate(serverCert.Certificate[0])
+	if err != nil {
+		return cert, fmt.Errorf("failed to parse server certificate: %w", err)
+	}
+	serverKey, ok := serverCert.PrivateKey.(crypto.Signer)
+	if !ok {
+		return cert, fmt.Errorf("unsupported server key type: %T", serverCert.PrivateKey)
+	}
+	template := *leaf
+	template.SerialNumber, err = rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return cert, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	// Let x509 select signature algorithm based on the signer key.
+	template.SignatureAlgorithm = x509.UnknownSignatureAlgorithm
+	parent := &template
+	signer := serverKey
+	switch fault {
+	case sdnapi.TLSCertExpired:
+		validity := leaf.NotAfter.Sub(leaf.NotBefore)
+		template.NotAfter = time.Now().Add(-24 * time.Hour)
+		template.NotBefore = template.NotAfter.Add(-validity)
+		if caCertPEM != "" {
+			caCert, err := tls.X509KeyPair([]byte(caCertPEM), []byte(caKeyPEM))
+			if err != nil {
+				return cert, fmt.Errorf("failed to load CA certificate: %w", err)
+			}
+			parent, err = x509.ParseCertificate(caCert.Certificate[0])
+			if err != nil {
+				return cert, fmt.Errorf("failed to parse CA certificate: %w", err)
+			}
+			if signer, ok = caCert.PrivateKey.(crypto.Signer); !ok {
+				return cert, fmt.Errorf("unsupported CA key type: %T", caCert.PrivateKey)
+			}
+		}
+	case sdnapi.TLSCertSelfSigned:
+		// Use the server key to sign the certificate.
+	default:
+		return cert, fmt.Errorf("unknown TLS certificate fault: %s", fault)
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, &template, parent,
+		serverKey.Public(), signer)
+	if err != nil {
+		return cert, fmt.Errorf("failed to create %s certificate: %w", fault, err)
+	}
+	return tls.Certificate{
+		Certificate: [][]byte{certDER},
+		PrivateKey:  serverCert.PrivateKey,
+	}, nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	downloadAttempts   = 10
+	downloadRetryDelay = 5 * time.Second
+)
+
+var errArtifactNotFound = errors.New("artifact not found")
+
+// artifact is a boot artifact downloaded from DownloadFromURL and stored locally.
+type artifact struct {
+	sdnapi.NetbootArtifact
+	path  string
+	ready chan struct{}
+	err   error
+}
+
+// artifactStore downloads boot artifacts and provides access to them
+// once they are available locally.
+type artifactStore struct {
+	dir       string
+	artifacts map[string]*artifact // key: filename
+}
+
+func newArtifactStore(dir string, artifacts []sdnapi.NetbootArtifact) (*artifactStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	store := &artifactStore{
+		dir:       dir,
+		artifacts: make(map[string]*artifact),
+	}
+	for _, item := range artifacts {
+		filename := normArtifactFilename(item.Filename)
+		store.artifacts[filename] = &artifact{
+			NetbootArtifact: item,
+			path:            filepath.Join(dir, filepath.FromSlash(filename)),
+			ready:           make(chan struct{}),
+		}
+	}
+	return store, nil
+}
+
+func normArtifactFilename(filename string) string {
+	return strings.TrimPrefix(path.Clean("/"+filename), "/")
+}
+
+// downloadAll starts download of all artifacts in the background.
+func (s *artifactStore) downloadAll() {
+	for _, a := range s.artifacts {
+		go a.download()
+	}
+}
+
+// open returns opened file with the artifact content.
+// It waits for the artifact to be downloaded.
+func (s *artifactStore) open(filename string) (*os.File, error) {
+	a, ok := s.artifacts[normArtifactFilename(filename)]
+	if !ok {
+		return nil, errArtifactNotFound
+	}
+	<-a.ready
+	if a.err != nil {
+		return nil, a.err
+	}
+	return os.Open(a.path)
+}
+
+func (a *artifact) download() {
+	defer close(a.ready)
+	for attempt := 1; attempt <= downloadAttempts; attempt++ {
+		if a.err = a.tryDownload(); a.err == nil {
+			log.Infof("Downloaded artifact %s from %s", a.Filename, a.DownloadFromURL)
+			return
+		}
+		log.Warnf("Attempt %d to download artifact %s failed: %v",
+			attempt, a.Filename, a.err)
+		time.Sleep(downloadRetryDelay)
+	}
+	log.Errorf("Failed to download artifact %s: %v", a.Filename, a.err)
+}
+
+func (a *artifact) tryDownload() error {
+	resp, err := http.Get(a.DownloadFromURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	if err = os.MkdirAll(filepath.Dir(a.path), 0755); err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(filepath.Dir(a.path), ".download-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFile.Name())
+	if _, err = io.Copy(tmpFile, resp.Body); err != nil {
+		tmpFile.Close()
+		return err
+	}
+	if err = tmpFile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpFile.Name(), a.path)
+}
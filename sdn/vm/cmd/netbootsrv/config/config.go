@@ -0,0 +1,32 @@
+package config
+
+import (
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+)
+
+// NetbootSrvConfig : Netboot server configuration formatted with JSON and passed
+// to netbootsrv using the "-c" command line argument.
+type NetbootSrvConfig struct {
+	// ListenIP : IP address to listen on.
+	// Leave empty to listen on all available interfaces instead of just
+	// the interface with the given host address.
+	ListenIP string `json:"listenIP"`
+	// LogFile : file to write all log messages into.
+	LogFile string `json:"logFile"`
+	// PidFile : file to write netbootsrv process PID.
+	PidFile string `json:"pidFile"`
+	// Verbose : enable to have all requests logged.
+	Verbose bool `json:"verbose"`
+	// RootDir : directory where downloaded artifacts are stored.
+	RootDir string `json:"rootDir"`
+	// HTTPPort : port to listen for HTTP requests.
+	// Zero value can be used to disable HTTP.
+	HTTPPort uint16 `json:"httpPort"`
+	// TFTPPort : port to listen for TFTP requests.
+	// Zero value can be used to disable TFTP.
+	TFTPPort uint16 `json:"tftpPort"`
+	// TFTPArtifacts : boot artifacts served by the TFTP server.
+	TFTPArtifacts []sdnapi.NetbootArtifact `json:"tftpArtifacts"`
+	// HTTPArtifacts : boot artifacts served by the HTTP server.
+	HTTPArtifacts []sdnapi.NetbootArtifact `json:"httpArtifacts"`
+}
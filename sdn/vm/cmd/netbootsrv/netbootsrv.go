@@ -1,12 +1,129 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"time"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/lf-edge/eden/sdn/vm/cmd/netbootsrv/config"
+	log "github.com/sirupsen/logrus"
 )
 
-// TODO: Implement NetBoot server
+func httpHandler(store *artifactStore) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Debugf("Received request: %+v", r)
+		file, err := store.open(r.URL.Path)
+		if err != nil {
+			log.Warnf("Failed to open %s for request %+v: %v", r.URL.Path, r, err)
+			if errors.Is(err, errArtifactNotFound) {
+				http.NotFound(w, r)
+			} else {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+			}
+			return
+		}
+		defer file.Close()
+		fileInfo, err := file.Stat()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http.ServeContent(w, r, fileInfo.Name(), fileInfo.ModTime(), file)
+	}
+}
+
 func main() {
-	fmt.Println("Netboot server: hello world")
-	time.Sleep(time.Hour)
+	log.SetReportCaller(true)
+	configFile := flag.String("c", "/etc/netbootsrv.conf", "Netboot server config file")
+	flag.Parse()
+
+	// Read and parse config file.
+	configBytes, err := os.ReadFile(*configFile)
+	if err != nil {
+		log.Fatalf("failed to read config file %s: %v", *configFile, err)
+	}
+	var netbootSrvConfig config.NetbootSrvConfig
+	if err = json.Unmarshal(configBytes, &netbootSrvConfig); err != nil {
+		log.Fatalf("failed to unmarshal Netboot server config: %v", err)
+	}
+
+	// Process Netboot server config.
+	if netbootSrvConfig.LogFile != "" {
+		logFile, err := os.OpenFile(netbootSrvConfig.LogFile, os.O_WRONLY|os.O_CREATE, 0755)
+		if err != nil {
+			log.Fatalf("failed to open log file %s: %v", netbootSrvConfig.LogFile, err)
+		}
+		log.SetOutput(logFile)
+	}
+	if netbootSrvConfig.Verbose {
+		log.SetLevel(log.DebugLevel)
+	} else {
+		log.SetLevel(log.InfoLevel)
+	}
+	if netbootSrvConfig.PidFile != "" {
+		pidBytes := []byte(fmt.Sprintf("%d", os.Getpid()))
+		err = os.WriteFile(netbootSrvConfig.PidFile, pidBytes, 0664)
+		if err != nil {
+			log.Fatalf("failed to write PID file %s: %v", netbootSrvConfig.PidFile, err)
+		}
+		defer os.Remove(netbootSrvConfig.PidFile)
+	}
+
+	// Artifacts are downloaded in the background, requests for artifacts
+	// not downloaded yet wait for them.
+	rootDir := netbootSrvConfig.RootDir
+	if rootDir == "" {
+		rootDir, err = os.MkdirTemp("", "netbootsrv-")
+		if err != nil {
+			log.Fatalf("failed to create directory for artifacts: %v", err)
+		}
+	}
+	defer func() {
+		if err = os.RemoveAll(rootDir); err != nil {
+			log.Warnf("failed to remove directory %s: %v", rootDir, err)
+		}
+	}()
+
+	if netbootSrvConfig.HTTPPort != 0 {
+		store, err := newArtifactStore(filepath.Join(rootDir, "http"), netbootSrvConfig.HTTPArtifacts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		store.downloadAll()
+		srvAddr := net.JoinHostPort(netbootSrvConfig.ListenIP, strconv.Itoa(int(netbootSrvConfig.HTTPPort)))
+		mux := http.NewServeMux()
+		mux.HandleFunc("/", httpHandler(store))
+		go func() {
+			log.Debugf("HTTP server listening on %s", srvAddr)
+			log.Fatalln(http.ListenAndServe(srvAddr, mux))
+		}()
+	}
+
+	if netbootSrvConfig.TFTPPort != 0 {
+		store, err := newArtifactStore(filepath.Join(rootDir, "tftp"), netbootSrvConfig.TFTPArtifacts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		store.downloadAll()
+		srvAddr := net.JoinHostPort(netbootSrvConfig.ListenIP, strconv.Itoa(int(netbootSrvConfig.TFTPPort)))
+		tftpSrv := &tftpServer{listenIP: netbootSrvConfig.ListenIP, store: store}
+		go func() {
+			log.Debugf("TFTP server listening on %s", srvAddr)
+			log.Fatalln(tftpSrv.serve(srvAddr))
+		}()
+	}
+
+	cancelChan := make(chan os.Signal, 1)
+	// Catch termination or interrupt signal.
+	signal.Notify(cancelChan, syscall.SIGTERM, syscall.SIGINT)
+	sig := <-cancelChan
+	log.Infof("Caught terimation/interrupt signal: %v, exiting...", sig)
 }
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Minimal read-only TFTP server (RFC 1350) with support for blksize, tsize
+// and timeout options (RFC 2347, 2348, 2349), which are commonly used
+// by PXE clients.
+
+const (
+	tftpOpRRQ   = 1
+	tftpOpWRQ   = 2
+	tftpOpDATA  = 3
+	tftpOpACK   = 4
+	tftpOpERROR = 5
+	tftpOpOACK  = 6
+
+	tftpErrUndefined    = 0
+	tftpErrNotFound     = 1
+	tftpErrAccess       = 2
+	tftpErrIllegalOp    = 4
+	tftpErrUnknownTID   = 5
+	tftpDefaultBlkSize  = 512
+	tftpMinBlkSize      = 8
+	tftpMaxBlkSize      = 65464
+	tftpDefaultTimeout  = 3 * time.Second
+	tftpRetransmissions = 5
+	tftpMaxPacketSize   = 65536
+)
+
+type tftpServer struct {
+	listenIP string
+	store    *artifactStore
+}
+
+type tftpRequest struct {
+	filename string
+	mode     string
+	options  map[string]string
+}
+
+func parseTFTPRequest(pkt []byte) (req tftpRequest, err error) {
+	fields := bytes.Split(pkt[2:], []byte{0})
+	// last field is empty (packet ends with zero byte)
+	if len(fields) < 3 || len(fields[len(fields)-1]) != 0 {
+		return req, errors.New("malformed request")
+	}
+	fields = fields[:len(fields)-1]
+	req.filename = string(fields[0])
+	req.mode = strings.ToLower(string(fields[1]))
+	req.options = make(map[string]string)
+	for i := 2; i+1 < len(fields); i += 2 {
+		req.options[strings.ToLower(string(fields[i]))] = string(fields[i+1])
+	}
+	return req, nil
+}
+
+// serve handles TFTP requests received on addr.
+func (s *tftpServer) serve(addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	buf := make([]byte, tftpMaxPacketSize)
+	for {
+		n, raddr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		pkt := make([]byte, n)
+		copy(pkt, buf[:n])
+		go s.handleRequest(pkt, raddr)
+	}
+}
+
+func (s *tftpServer) handleRequest(pkt []byte, raddr net.Addr) {
+	// Every transfer uses its own port (TID).
+	conn, err := net.ListenPacket("udp", net.JoinHostPort(s.listenIP, "0"))
+	if err != nil {
+		log.Errorf("TFTP: failed to open connection for %s: %v", raddr, err)
+		return
+	}
+	defer conn.Close()
+	if len(pkt) < 2 {
+		return
+	}
+	switch binary.BigEndian.Uint16(pkt) {
+	case tftpOpRRQ:
+	case tftpOpWRQ:
+		sendTFTPError(conn, raddr, tftpErrAccess, "read-only server")
+		return
+	default:
+		sendTFTPError(conn, raddr, tftpErrIllegalOp, "illegal operation")
+		return
+	}
+	req, err := parseTFTPRequest(pkt)
+	if err != nil {
+		sendTFTPError(conn, raddr, tftpErrIllegalOp, err.Error())
+		return
+	}
+	log.Debugf("TFTP: %s requested %s (mode: %s, options: %v)",
+		raddr, req.filename, req.mode, req.options)
+	if req.mode != "octet" && req.mode != "netascii" {
+		sendTFTPError(conn, raddr, tftpErrIllegalOp, "unsupported mode")
+		return
+	}
+	file, err := s.store.open(req.filename)
+	if err != nil {
+		if errors.Is(err, errArtifactNotFound) {
+			sendTFTPError(conn, raddr, tftpErrNotFound, "file not found")
+		} else {
+			sendTFTPError(conn, raddr, tftpErrUndefined, err.Error())
+		}
+		log.Warnf("TFTP: failed to open %s for %s: %v", req.filename, raddr, err)
+		return
+	}
+	defer file.Close()
+	fileInfo, err := file.Stat()
+	if err != nil {
+		sendTFTPError(conn, raddr, tftpErrUndefined, err.Error())
+		return
+	}
+	if err = sendTFTPFile(conn, raddr, req, file, fileInfo.Size()); err != nil {
+		log.Errorf("TFTP: failed to send %s to %s: %v", req.filename, raddr, err)
+		return
+	}
+	log.Infof("TFTP: sent %s to %s", req.filename, raddr)
+}
+
+func sendTFTPFile(conn net.PacketConn, raddr net.Addr, req tftpRequest,
+	file io.Reader, size int64) error {
+	blkSize := tftpDefaultBlkSize
+	timeout := tftpDefaultTimeout
+	// Negotiate options.
+	var oack []byte
+	for _, name := range []string{"blksize", "tsize", "timeout"} {
+		value, requested := req.options[name]
+		if !requested {
+			continue
+		}
+		switch name {
+		case "blksize":
+			requested, err := strconv.Atoi(value)
+			if err != nil || requested < tftpMinBlkSize {
+				continue
+			}
+			if requested > tftpMaxBlkSize {
+				requested = tftpMaxBlkSize
+			}
+			blkSize = requested
+			value = strconv.Itoa(blkSize)
+		case "tsize":
+			value = strconv.FormatInt(size, 10)
+		case "timeout":
+			requested, err := strconv.Atoi(value)
+			if err != nil || requested < 1 || requested > 255 {
+				continue
+			}
+			timeout = time.Duration(requested) * time.Second
+		}
+		oack = append(oack, []byte(name)...)
+		oack = append(oack, 0)
+		oack = append(oack, []byte(value)...)
+		oack = append(oack, 0)
+	}
+	if len(oack) > 0 {
+		pkt := append([]byte{0, tftpOpOACK}, oack...)
+		if err := sendTFTPPacket(conn, raddr, pkt, 0, timeout); err != nil {
+			return err
+		}
+	}
+	// Send data blocks, block number wraps around for large files.
+	data := make([]byte, 4+blkSize)
+	binary.BigEndian.PutUint16(data, tftpOpDATA)
+	var block uint16
+	for {
+		block++
+		n, err := io.ReadFull(file, data[4:])
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			sendTFTPError(conn, raddr, tftpErrUndefined, err.Error())
+			return err
+		}
+		binary.BigEndian.PutUint16(data[2:], block)
+		if err = sendTFTPPacket(conn, raddr, data[:4+n], block, timeout); err != nil {
+			return err
+		}
+		if n < blkSize {
+			return nil
+		}
+	}
+}
+
+// sendTFTPPacket sends packet and waits for ACK of the given block,
+// the packet is retransmitted on timeout.
+func sendTFTPPacket(conn net.PacketConn, raddr net.Addr, pkt []byte,
+	block uint16, timeout time.Duration) error {
+	buf := make([]byte, tftpMaxPacketSize)
+	for attempt := 0; attempt < tftpRetransmissions; attempt++ {
+		if _, err := conn.WriteTo(pkt, raddr); err != nil {
+			return err
+		}
+		deadline := time.Now().Add(timeout)
+		for {
+			if err := conn.SetReadDeadline(deadline); err != nil {
+				return err
+			}
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					break // retransmit
+				}
+				return err
+			}
+			if from.String() != raddr.String() {
+				sendTFTPError(conn, from, tftpErrUnknownTID, "unknown transfer ID")
+				continue
+			}
+			if n < 4 {
+				continue
+			}
+			switch binary.BigEndian.Uint16(buf) {
+			case tftpOpACK:
+				if binary.BigEndian.Uint16(buf[2:]) == block {
+					return nil
+				}
+				// duplicate ACK of previous block
+			case tftpOpERROR:
+				return fmt.Errorf("client error %d: %s",
+					binary.BigEndian.Uint16(buf[2:]), bytes.TrimRight(buf[4:n], "\x00"))
+			}
+		}
+	}
+	return fmt.Errorf("no ACK for block %d received", block)
+}
+
+func sendTFTPError(conn net.PacketConn, raddr net.Addr, code uint16, msg string) {
+	pkt := make([]byte, 4, 5+len(msg))
+	binary.BigEndian.PutUint16(pkt, tftpOpERROR)
+	binary.BigEndian.PutUint16(pkt[2:], code)
+	pkt = append(pkt, []byte(msg)...)
+	pkt = append(pkt, 0)
+	if _, err := conn.WriteTo(pkt, raddr); err != nil {
+		log.Warnf("TFTP: failed to send error to %s: %v", raddr, err)
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	syncInterval      = 64 * time.Second
+	syncRetryInterval = 5 * time.Second
+	upstreamTimeout   = 3 * time.Second
+	// Maximum stratum that still counts as synchronized.
+	maxStratum = 15
+	// Dispersion assumed for the local clock.
+	localClockDispersion = 10 * time.Millisecond
+	// Rate at which dispersion grows since the last synchronization (15 PPM).
+	dispersionRate = 15e-6
+)
+
+// Reference ID used when serving time from the local clock.
+var localClockRefID = binary.BigEndian.Uint32([]byte("LOCL"))
+
+// Reference ID used (with stratum 0) before the first synchronization.
+var initRefID = binary.BigEndian.Uint32([]byte("INIT"))
+
+// clock provides time to serve, which is the local time corrected by the offset
+// measured against upstream servers (if there are any), further adjusted
+// by the fake clock (if configured).
+type clock struct {
+	sync.Mutex
+	fakeClock *sdnapi.FakeClock
+	startTime time.Time
+	// Synchronization state.
+	synced         bool
+	offset         time.Duration
+	stratum        uint8
+	refID          uint32
+	refTime        time.Time // local time of the last synchronization
+	rootDelay      time.Duration
+	rootDispersion time.Duration
+}
+
+// upstreamSample : result of one query sent to an upstream server.
+type upstreamSample struct {
+	offset         time.Duration
+	delay          time.Duration
+	stratum        uint8
+	refID          uint32
+	rootDelay      time.Duration
+	rootDispersion time.Duration
+}
+
+func newClock(startTime time.Time, fakeClock *sdnapi.FakeClock) *clock {
+	return &clock{
+		fakeClock: fakeClock,
+		startTime: startTime,
+	}
+}
+
+// useLocalClock : serve local time as if it was a primary reference.
+func (c *clock) useLocalClock() {
+	c.Lock()
+	defer c.Unlock()
+	c.synced = true
+	c.offset = 0
+	c.stratum = 1
+	c.refID = localClockRefID
+	c.rootDelay = 0
+	c.rootDispersion = localClockDispersion
+}
+
+// now returns the time to serve.
+func (c *clock) now() time.Time {
+	c.Lock()
+	defer c.Unlock()
+	return c.served(time.Now())
+}
+
+// served converts local time to the time that should be served.
+// Call with the lock held.
+func (c *clock) served(localTime time.Time) time.Time {
+	t := localTime.Add(c.offset)
+	if c.fakeClock == nil {
+		return t
+	}
+	elapsed := t.Sub(c.startTime)
+	drift := time.Duration(float64(elapsed) * float64(c.fakeClock.DriftPPM) / 1e6)
+	return t.Add(time.Duration(c.fakeClock.Offset)*time.Second + drift)
+}
+
+// fillResponse prepares NTP response with the current synchronization state.
+// Timestamps related to the request are filled in by the caller.
+func (c *clock) fillResponse(version uint8, recvTime time.Time) *ntpPacket {
+	c.Lock()
+	defer c.Unlock()
+	resp := &ntpPacket{
+		Precision: ntpPrecision,
+	}
+	if !c.synced {
+		resp.setSettings(ntpLeapNotInSync, version, ntpModeServer)
+		resp.ReferenceID = initRefID
+		return resp
+	}
+	resp.setSettings(ntpLeapNoWarning, version, ntpModeServer)
+	resp.Stratum = c.stratum
+	resp.ReferenceID = c.refID
+	resp.RootDelay = toNtpShort(c.rootDelay)
+	if c.refID == localClockRefID {
+		resp.ReferenceTime = toNtpTime(recvTime)
+		resp.RootDispersion = toNtpShort(c.rootDispersion)
+	} else {
+		resp.ReferenceTime = toNtpTime(c.served(c.refTime))
+		sinceSync := time.Since(c.refTime)
+		dispersion := c.rootDispersion +
+			time.Duration(float64(sinceSync)*dispersionRate)
+		resp.RootDispersion = toNtpShort(dispersion)
+	}
+	return resp
+}
+
+// syncWithUpstream periodically measures offset of the local clock against
+// upstream servers. Servers are tried in the configured order and the first
+// one to respond is used.
+func (c *clock) syncWithUpstream(servers []string) {
+	for {
+		interval := syncInterval
+		if err := c.syncOnce(servers); err != nil {
+			log.Warn(err)
+			c.Lock()
+			synced := c.synced
+			c.Unlock()
+			if !synced {
+				interval = syncRetryInterval
+			}
+		}
+		time.Sleep(interval)
+	}
+}
+
+func (c *clock) syncOnce(servers []string) (err error) {
+	for _, server := range servers {
+		var sample *upstreamSample
+		sample, err = queryUpstream(server)
+		if err != nil {
+			log.Warnf("Failed to query upstream NTP server %s: %v", server, err)
+			continue
+		}
+		log.Debugf("Synchronized with upstream NTP server %s "+
+			"(offset: %v, delay: %v, stratum: %d)",
+			server, sample.offset, sample.delay, sample.stratum)
+		c.Lock()
+		c.synced = true
+		c.offset = sample.offset
+		c.stratum = sample.stratum + 1
+		c.refID = sample.refID
+		c.refTime = time.Now()
+		c.rootDelay = sample.rootDelay + sample.delay
+		c.rootDispersion = sample.rootDispersion
+		c.Unlock()
+		return nil
+	}
+	return fmt.Errorf("failed to synchronize with any upstream NTP server, last error: %w",
+		err)
+}
+
+// queryUpstream sends NTP request to the given upstream server and computes
+// offset of the local clock and round-trip delay.
+func queryUpstream(server string) (*upstreamSample, error) {
+	conn, err := net.DialTimeout("udp", net.JoinHostPort(server, ntpPort), upstreamTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err = conn.SetDeadline(time.Now().Add(upstreamTimeout)); err != nil {
+		return nil, err
+	}
+	req := &ntpPacket{}
+	req.setSettings(ntpLeapNoWarning, ntpVersion, ntpModeClient)
+	sendTime := time.Now()
+	req.TransmitTime = toNtpTime(sendTime)
+	if _, err = conn.Write(req.marshal()); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 1024)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	recvTime := time.Now()
+	if n < ntpPacketSize {
+		return nil, fmt.Errorf("response too short (%d bytes)", n)
+	}
+	var resp ntpPacket
+	if err = resp.unmarshal(buf[:ntpPacketSize]); err != nil {
+		return nil, err
+	}
+	if resp.mode() != ntpModeServer {
+		return nil, fmt.Errorf("unexpected mode in the response (%d)", resp.mode())
+	}
+	if resp.OriginTime != req.TransmitTime {
+		return nil, errors.New("response does not match the request")
+	}
+	if resp.leap() == ntpLeapNotInSync || resp.Stratum == 0 || resp.Stratum >= maxStratum {
+		return nil, fmt.Errorf("server is not synchronized (leap: %d, stratum: %d)",
+			resp.leap(), resp.Stratum)
+	}
+	if resp.TransmitTime == 0 {
+		return nil, errors.New("response with zero transmit time")
+	}
+	// Clock offset and round-trip delay as defined by RFC 5905.
+	t1, t2 := sendTime, resp.ReceiveTime.Time()
+	t3, t4 := resp.TransmitTime.Time(), recvTime
+	offset := (t2.Sub(t1) + t3.Sub(t4)) / 2
+	delay := t4.Sub(t1) - t3.Sub(t2)
+	if delay < 0 {
+		delay = 0
+	}
+	return &upstreamSample{
+		offset:         offset,
+		delay:          delay,
+		stratum:        resp.Stratum,
+		refID:          upstreamRefID(conn.RemoteAddr()),
+		rootDelay:      fromNtpShort(resp.RootDelay),
+		rootDispersion: fromNtpShort(resp.RootDispersion),
+	}, nil
+}
+
+// upstreamRefID returns reference ID identifying upstream server:
+// IPv4 address or the first 4 octets of the MD5 hash of the IPv6 address.
+func upstreamRefID(addr net.Addr) uint32 {
+	udpAddr, ok := addr.(*net.UDPAddr)
+	if !ok {
+		return 0
+	}
+	if ip4 := udpAddr.IP.To4(); ip4 != nil {
+		return binary.BigEndian.Uint32(ip4)
+	}
+	hash := md5.Sum(udpAddr.IP.To16())
+	return binary.BigEndian.Uint32(hash[:4])
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+)
+
+// NtpSrvConfig : NTP server configuration formatted with JSON and passed to ntpsrv
+// using the "-c" command line argument.
+type NtpSrvConfig struct {
+	// ListenIP : IP address to listen on.
+	// Leave empty to listen on all available interfaces instead of just
+	// the interface with the given host address.
+	ListenIP string `json:"listenIP"`
+	// LogFile : file to write all log messages into.
+	LogFile string `json:"logFile"`
+	// PidFile : file to write ntpsrv process PID.
+	PidFile string `json:"pidFile"`
+	// Verbose : enable to have all requests logged.
+	Verbose bool `json:"verbose"`
+	// UpstreamServers : NTP servers to synchronize with, each referenced
+	// by an IP address or a FQDN.
+	// Leave empty to serve time from the local clock.
+	UpstreamServers []string `json:"upstreamServers"`
+	// FakeClock : optional offset and drift to apply to served time.
+	FakeClock *sdnapi.FakeClock `json:"fakeClock"`
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/lf-edge/eden/sdn/vm/cmd/ntpsrv/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const ntpPort = "123"
+
+// NTP server serving time either from the local clock or synchronized with
+// upstream NTP servers, optionally shifted by a fake offset and/or drift.
+// Implements only the subset of NTPv4 (RFC 5905) needed to respond to client
+// requests (i.e. behaves like an SNTP server, see RFC 4330).
+func main() {
+	log.SetReportCaller(true)
+	configFile := flag.String("c", "/etc/ntpsrv.conf", "NTP server config file")
+	flag.Parse()
+
+	// Read and parse config file.
+	configBytes, err := os.ReadFile(*configFile)
+	if err != nil {
+		log.Fatalf("failed to read config file %s: %v", *configFile, err)
+	}
+	var ntpSrvConfig config.NtpSrvConfig
+	if err = json.Unmarshal(configBytes, &ntpSrvConfig); err != nil {
+		log.Fatalf("failed to unmarshal NTP server config: %v", err)
+	}
+
+	// Process NTP server config.
+	if ntpSrvConfig.LogFile != "" {
+		logFile, err := os.OpenFile(ntpSrvConfig.LogFile, os.O_WRONLY|os.O_CREATE, 0755)
+		if err != nil {
+			log.Fatalf("failed to open log file %s: %v", ntpSrvConfig.LogFile, err)
+		}
+		log.SetOutput(logFile)
+	}
+	if ntpSrvConfig.Verbose {
+		log.SetLevel(log.DebugLevel)
+	} else {
+		log.SetLevel(log.InfoLevel)
+	}
+	if ntpSrvConfig.PidFile != "" {
+		pidBytes := []byte(fmt.Sprintf("%d", os.Getpid()))
+		err = os.WriteFile(ntpSrvConfig.PidFile, pidBytes, 0664)
+		if err != nil {
+			log.Fatalf("failed to write PID file %s: %v", ntpSrvConfig.PidFile, err)
+		}
+		defer os.Remove(ntpSrvConfig.PidFile)
+	}
+
+	clock := newClock(time.Now(), ntpSrvConfig.FakeClock)
+	if fakeClock := ntpSrvConfig.FakeClock; fakeClock != nil {
+		log.Infof("Serving time from a fake clock (offset: %ds, drift: %dppm)",
+			fakeClock.Offset, fakeClock.DriftPPM)
+	}
+	if len(ntpSrvConfig.UpstreamServers) > 0 {
+		go clock.syncWithUpstream(ntpSrvConfig.UpstreamServers)
+	} else {
+		log.Info("Serving time from the local clock")
+		clock.useLocalClock()
+	}
+
+	srvAddr := net.JoinHostPort(ntpSrvConfig.ListenIP, ntpPort)
+	conn, err := net.ListenPacket("udp", srvAddr)
+	if err != nil {
+		log.Fatalf("failed to listen on UDP %s: %v", srvAddr, err)
+	}
+	server := &ntpServer{clock: clock}
+	go func() {
+		log.Debugf("NTP server listening on UDP %s", srvAddr)
+		log.Fatalln(server.serve(conn))
+	}()
+
+	cancelChan := make(chan os.Signal, 1)
+	// Catch termination or interrupt signal.
+	signal.Notify(cancelChan, syscall.SIGTERM, syscall.SIGINT)
+	sig := <-cancelChan
+	log.Infof("Caught terimation/interrupt signal: %v, exiting...", sig)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	ntpPacketSize = 48
+	ntpVersion    = 4
+	// Precision of the served time, expressed as log2 seconds (~1 microsecond).
+	ntpPrecision = -20
+
+	ntpModeClient = 3
+	ntpModeServer = 4
+
+	ntpLeapNoWarning = 0
+	ntpLeapNotInSync = 3
+)
+
+// NTP timestamps are relative to the prime epoch (1900-01-01 00:00 UTC).
+var ntpEpoch = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// ntpTime : NTP timestamp format - seconds since the NTP epoch
+// as 32.32 fixed-point number.
+type ntpTime uint64
+
+func toNtpTime(t time.Time) ntpTime {
+	nsec := uint64(t.Sub(ntpEpoch))
+	sec := nsec / uint64(time.Second)
+	frac := ((nsec % uint64(time.Second)) << 32) / uint64(time.Second)
+	return ntpTime(sec<<32 | frac)
+}
+
+func (t ntpTime) Time() time.Time {
+	sec := uint64(t >> 32)
+	frac := uint64(t & 0xffffffff)
+	nsec := (frac * uint64(time.Second)) >> 32
+	return ntpEpoch.Add(time.Duration(sec)*time.Second + time.Duration(nsec))
+}
+
+// toNtpShort converts duration to NTP short format - seconds
+// as 16.16 fixed-point number.
+func toNtpShort(d time.Duration) uint32 {
+	if d < 0 {
+		return 0
+	}
+	return uint32(d.Seconds() * (1 << 16))
+}
+
+func fromNtpShort(s uint32) time.Duration {
+	return time.Duration(float64(s) / (1 << 16) * float64(time.Second))
+}
+
+// ntpPacket : NTP packet header (extension fields and MAC are not supported).
+type ntpPacket struct {
+	// Leap indicator (2 bits) | Version number (3 bits) | Mode (3 bits)
+	Settings       uint8
+	Stratum        uint8
+	Poll           int8
+	Precision      int8
+	RootDelay      uint32
+	RootDispersion uint32
+	ReferenceID    uint32
+	ReferenceTime  ntpTime
+	OriginTime     ntpTime
+	ReceiveTime    ntpTime
+	TransmitTime   ntpTime
+}
+
+func (p *ntpPacket) leap() uint8 {
+	return p.Settings >> 6
+}
+
+func (p *ntpPacket) version() uint8 {
+	return (p.Settings >> 3) & 0x7
+}
+
+func (p *ntpPacket) mode() uint8 {
+	return p.Settings & 0x7
+}
+
+func (p *ntpPacket) setSettings(leap, version, mode uint8) {
+	p.Settings = leap<<6 | version<<3 | mode
+}
+
+func (p *ntpPacket) marshal() []byte {
+	var buf bytes.Buffer
+	// Writing into bytes.Buffer never fails.
+	_ = binary.Write(&buf, binary.BigEndian, p)
+	return buf.Bytes()
+}
+
+func (p *ntpPacket) unmarshal(data []byte) error {
+	return binary.Read(bytes.NewReader(data), binary.BigEndian, p)
+}
+
+type ntpServer struct {
+	clock *clock
+}
+
+func (s *ntpServer) serve(conn net.PacketConn) error {
+	buf := make([]byte, 1024)
+	for {
+		n, raddr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return err
+		}
+		recvTime := s.clock.now()
+		if n < ntpPacketSize {
+			log.Debugf("Ignoring too short packet from %s (%d bytes)", raddr, n)
+			continue
+		}
+		var req ntpPacket
+		if err = req.unmarshal(buf[:ntpPacketSize]); err != nil {
+			log.Warnf("Failed to parse packet from %s: %v", raddr, err)
+			continue
+		}
+		if req.mode() != ntpModeClient {
+			log.Debugf("Ignoring packet from %s with mode %d", raddr, req.mode())
+			continue
+		}
+		version := req.version()
+		if version < 1 || version > ntpVersion {
+			log.Debugf("Ignoring packet from %s with version %d", raddr, version)
+			continue
+		}
+		resp := s.clock.fillResponse(version, recvTime)
+		resp.Poll = req.Poll
+		resp.OriginTime = req.TransmitTime
+		resp.ReceiveTime = toNtpTime(recvTime)
+		transmitTime := s.clock.now()
+		resp.TransmitTime = toNtpTime(transmitTime)
+		if _, err = conn.WriteTo(resp.marshal(), raddr); err != nil {
+			log.Warnf("Failed to send response to %s: %v", raddr, err)
+			continue
+		}
+		log.Debugf("Served time %s to %s (stratum: %d, leap: %d)",
+			transmitTime.UTC().Format(time.RFC3339Nano), raddr, resp.Stratum, resp.leap())
+	}
+}
@@ -46,6 +46,23 @@ type agent struct {
 	failingItems  map[dg.ItemRef]error
 	networkIndex  map[string]int // key: network logical label
 
+	// Chaos scenario (runtime fault injection)
+	chaos         *chaosRun // nil if no scenario was started yet
+	chaosTriggers chan chaosTrigger
+
+	// Clients logged into captive portals (key: portal logical label)
+	portalClients map[string][]api.CaptivePortalClient
+
+	// Packet captures (key: capture ID)
+	captures    map[string]*pcapRun
+	pcapCounter int
+
+	// Counters of requests parsed from growing log files (key: log file path).
+	// Protected by logCountersMx instead of the agent lock.
+	logCountersMx    sync.Mutex
+	dnsQueryCounters map[string]*dnsQueryCounter
+	proxyCounters    map[string]*proxyRequestCounter
+
 	// Asynchronous operations
 	resumeReconciliation <-chan string      // nil if no async ops
 	cancelAsyncOps       context.CancelFunc // nil if no async ops
@@ -63,7 +80,13 @@ func (a *agent) init() error {
 	}
 	a.registry = registry
 	a.newNetModel = make(chan parsedNetModel, 10)
+	a.chaosTriggers = make(chan chaosTrigger, 10)
+	a.captures = make(map[string]*pcapRun)
+	a.portalClients = make(map[string][]api.CaptivePortalClient)
 	a.failingItems = make(map[dg.ItemRef]error)
+	a.dnsQueryCounters = make(map[string]*dnsQueryCounter)
+	a.proxyCounters = make(map[string]*proxyRequestCounter)
+	enableConntrackAcct()
 	// Initially start with an empty network model.
 	// Ever-present config items will get created.
 	// (e.g. DHCP client for the interface connecting SDN with the host)
@@ -82,10 +105,22 @@ func (a *agent) run(linkChan chan netlink.LinkUpdate) {
 			// Network model is already validated, applying...
 			a.Lock()
 			a.netModel = netModel
+			a.removeStaleCaptivePortalClients()
 			a.updateCurrentState()
 			a.updateIntendedState()
 			a.reconcile()
 			a.Unlock()
+			// Conntrack module may have been loaded just now by iptables rules
+			// of the network model.
+			enableConntrackAcct()
+
+		case trigger := <-a.chaosTriggers:
+			a.Lock()
+			if a.executeChaosAction(trigger) {
+				a.updateIntendedState()
+				a.reconcile()
+			}
+			a.Unlock()
 
 		case <-a.resumeReconciliation:
 			a.Lock()
@@ -305,17 +340,9 @@ func (a *agent) getNetConfig(w http.ResponseWriter, r *http.Request) {
 }
 
 func (a *agent) getSDNStatus(w http.ResponseWriter, r *http.Request) {
-	status := api.SDNStatus{
-		MgmtIPs: a.getMgmtIPs(),
-	}
-	a.Lock()
-	for itemRef, err := range a.failingItems {
-		status.ConfigErrors = append(status.ConfigErrors, api.ConfigError{
-			ItemRef: itemRef,
-			ErrMsg:  err.Error(),
-		})
-	}
-	a.Unlock()
+	mgmtIPs := a.getMgmtIPs()
+	status := a.getStatus()
+	status.MgmtIPs = mgmtIPs
 	resp, err := json.Marshal(status)
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to marshal SDN status to JSON: %v", err)
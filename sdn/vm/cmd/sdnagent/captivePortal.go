@@ -0,0 +1,297 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/lf-edge/eden/sdn/vm/pkg/configitems"
+	dg "github.com/lf-edge/eve/libs/depgraph"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultLoginPage = `<!DOCTYPE html>
+<html>
+<head><title>Eden-SDN Captive Portal</title></head>
+<body>
+<h1>Welcome to Eden-SDN</h1>
+<p>Internet access requires login. Please accept the terms of use.</p>
+</body>
+</html>
+`
+
+func (a *agent) getIntendedCaptivePortalEp(portal api.CaptivePortal) dg.Graph {
+	graphArgs := dg.InitArgs{Name: endpointSGPrefix + portal.LogicalLabel}
+	intendedCfg := dg.New(graphArgs)
+	a.putEpCommonConfig(intendedCfg, portal.Endpoint, nil)
+	nsName := a.endpointNsName(portal.LogicalLabel)
+	vethName, _, _ := a.endpointVethName(portal.LogicalLabel)
+	loginPage := portal.LoginPage
+	if loginPage == "" {
+		loginPage = defaultLoginPage
+	}
+	var portalHosts []string
+	if portal.FQDN != "" {
+		portalHosts = append(portalHosts, portal.FQDN)
+	}
+	portalHosts = append(portalHosts, portal.IP)
+	intendedCfg.PutItem(configitems.CaptivePortal{
+		PortalName:   portal.LogicalLabel,
+		NetNamespace: nsName,
+		VethName:     vethName,
+		ListenIP:     net.ParseIP(portal.IP),
+		PortalHosts:  portalHosts,
+		LoginPage:    loginPage,
+		CertPEM:      portal.CertPEM,
+		KeyPEM:       portal.KeyPEM,
+	}, nil)
+	return intendedCfg
+}
+
+// putCaptivePortalRules puts iptables rules into the network namespace, which redirect
+// HTTP(S) traffic of clients (not yet logged in) into the captive portal and drop
+// all other traffic, except for DNS and destinations from the walled garden.
+func (a *agent) putCaptivePortalRules(graph dg.Graph, network api.Network) {
+	portal := a.getCaptivePortal(network.CaptivePortal)
+	portalIP := net.ParseIP(portal.IP)
+	nsName := a.networkNsName(network.LogicalLabel)
+	brVethName, brInIfName, _ := a.networkBrVethName(network.LogicalLabel)
+	var walledGarden []string
+	for _, dst := range portal.WalledGarden {
+		if dst == api.AdamIPRef {
+			dst = a.netModel.hostIP.String()
+		}
+		walledGarden = append(walledGarden, dst)
+	}
+	for _, subnet := range a.getNetworkSubnets(network) {
+		var (
+			loggedInClients []api.CaptivePortalClient
+			allowRules      []configitems.IptablesRule
+		)
+		for _, client := range a.portalClients[portal.LogicalLabel] {
+			if ip := net.ParseIP(client.Client); ip != nil && (ip.To4() == nil) != subnet.ipv6 {
+				continue
+			}
+			loggedInClients = append(loggedInClients, client)
+		}
+		for _, dst := range walledGarden {
+			if isIPv6Dst(dst) != subnet.ipv6 {
+				continue
+			}
+			allowRules = append(allowRules, configitems.IptablesRule{
+				Args:        []string{"-d", dst, "-j", "RETURN"},
+				Description: "Allow access to walled garden destination " + dst,
+			})
+		}
+		// Redirect HTTP(S) traffic into the portal.
+		if (portalIP.To4() == nil) == subnet.ipv6 {
+			var dnatRules []configitems.IptablesRule
+			for _, client := range loggedInClients {
+				dnatRules = append(dnatRules, configitems.IptablesRule{
+					Args:        append(captivePortalClientMatch(client.Client), "-j", "RETURN"),
+					Description: "Do not intercept traffic of logged-in client " + client.Client,
+				})
+			}
+			dnatRules = append(dnatRules, allowRules...)
+			interceptPorts := []string{"80"}
+			if portal.CertPEM != "" {
+				interceptPorts = append(interceptPorts, "443")
+			}
+			for _, port := range interceptPorts {
+				dnatRules = append(dnatRules, configitems.IptablesRule{
+					Args: []string{"-i", brInIfName, "-p", "tcp", "--dport", port,
+						"-j", "DNAT", "--to-destination", portal.IP},
+					Description: fmt.Sprintf("Send traffic for port %s into the captive portal",
+						port),
+				})
+			}
+			graph.PutItem(configitems.IptablesChain{
+				NetNamespace: nsName,
+				ChainName:    "PREROUTING",
+				Table:        "nat",
+				ForIPv6:      subnet.ipv6,
+				Rules:        dnatRules,
+				RefersVeths:  []string{brVethName},
+			}, nil)
+		}
+		// Drop everything else except for DNS and the portal itself.
+		var fwdRules []configitems.IptablesRule
+		for _, client := range loggedInClients {
+			fwdRules = append(fwdRules, configitems.IptablesRule{
+				Args:        append(captivePortalClientMatch(client.Client), "-j", "RETURN"),
+				Description: "Allow all traffic of logged-in client " + client.Client,
+			})
+		}
+		fwdRules = append(fwdRules,
+			configitems.IptablesRule{
+				Args:        []string{"-i", brInIfName, "-p", "udp", "--dport", "53", "-j", "RETURN"},
+				Description: "Allow DNS over UDP",
+			},
+			configitems.IptablesRule{
+				Args:        []string{"-i", brInIfName, "-p", "tcp", "--dport", "53", "-j", "RETURN"},
+				Description: "Allow DNS over TCP",
+			})
+		if (portalIP.To4() == nil) == subnet.ipv6 {
+			fwdRules = append(fwdRules, configitems.IptablesRule{
+				Args:        []string{"-i", brInIfName, "-d", portal.IP, "-j", "RETURN"},
+				Description: "Allow access to the captive portal",
+			})
+		}
+		for _, rule := range allowRules {
+			fwdRules = append(fwdRules, configitems.IptablesRule{
+				Args:        append([]string{"-i", brInIfName}, rule.Args...),
+				Description: rule.Description,
+			})
+		}
+		fwdRules = append(fwdRules, configitems.IptablesRule{
+			Args:        []string{"-i", brInIfName, "-j", "DROP"},
+			Description: "Drop traffic of clients not logged into the captive portal",
+		})
+		graph.PutItem(configitems.IptablesChain{
+			NetNamespace: nsName,
+			ChainName:    "FORWARD",
+			Table:        "filter",
+			ForIPv6:      subnet.ipv6,
+			Rules:        fwdRules,
+			RefersVeths:  []string{brVethName},
+		}, nil)
+	}
+}
+
+// captivePortalClientMatch returns iptables arguments matching traffic
+// from the given client (IP or MAC address).
+func captivePortalClientMatch(client string) []string {
+	if net.ParseIP(client) != nil {
+		return []string{"-s", client}
+	}
+	return []string{"-m", "mac", "--mac-source", client}
+}
+
+// isIPv6Dst returns true if the destination (IP or CIDR) is IPv6.
+func isIPv6Dst(dst string) bool {
+	ip := net.ParseIP(dst)
+	if ip == nil {
+		ip, _, _ = net.ParseCIDR(dst)
+	}
+	return ip.To4() == nil
+}
+
+func (a *agent) getCaptivePortal(logicalLabel string) *api.CaptivePortal {
+	item := a.netModel.items.getItem(api.Endpoint{}.ItemType(), logicalLabel)
+	if item == nil || item.category != (api.CaptivePortal{}).ItemCategory() {
+		return nil
+	}
+	portal := item.LabeledItem.(api.CaptivePortal)
+	return &portal
+}
+
+// removeStaleCaptivePortalClients forgets clients of captive portals which were
+// removed from the network model.
+// Called with agent in locked state.
+func (a *agent) removeStaleCaptivePortalClients() {
+	for portalLL := range a.portalClients {
+		if a.getCaptivePortal(portalLL) == nil {
+			delete(a.portalClients, portalLL)
+		}
+	}
+}
+
+func (a *agent) getCaptivePortalStatus(portalLL string) api.CaptivePortalStatus {
+	return api.CaptivePortalStatus{
+		LogicalLabel: portalLL,
+		Clients:      append([]api.CaptivePortalClient(nil), a.portalClients[portalLL]...),
+	}
+}
+
+func (a *agent) loginCaptivePortal(w http.ResponseWriter, r *http.Request) {
+	a.handleCaptivePortalLogin(w, r, true)
+}
+
+func (a *agent) logoutCaptivePortal(w http.ResponseWriter, r *http.Request) {
+	a.handleCaptivePortalLogin(w, r, false)
+}
+
+func (a *agent) handleCaptivePortalLogin(w http.ResponseWriter, r *http.Request, login bool) {
+	portalLL := mux.Vars(r)["portal"]
+	var req api.CaptivePortalLogin
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to read captive portal login from HTTP request: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to unmarshal captive portal login from JSON: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	client, err := normalizeCaptivePortalClient(req.Client)
+	if err != nil {
+		errMsg := fmt.Sprintf("Invalid captive portal client: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	a.Lock()
+	defer a.Unlock()
+	if a.getCaptivePortal(portalLL) == nil {
+		errMsg := fmt.Sprintf("Unknown captive portal: %s", portalLL)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusNotFound)
+		return
+	}
+	a.removeStaleCaptivePortalClients()
+	clients := a.portalClients[portalLL]
+	var changed bool
+	if login {
+		changed = true
+		for _, loggedIn := range clients {
+			if loggedIn.Client == client {
+				changed = false
+				break
+			}
+		}
+		if changed {
+			log.Infof("Client %s logged into captive portal %s", client, portalLL)
+			a.portalClients[portalLL] = append(clients, api.CaptivePortalClient{
+				Client:    client,
+				LoginTime: time.Now(),
+			})
+		}
+	} else {
+		var remaining []api.CaptivePortalClient
+		for _, loggedIn := range clients {
+			if loggedIn.Client == client {
+				log.Infof("Client %s logged out of captive portal %s", client, portalLL)
+				changed = true
+				continue
+			}
+			remaining = append(remaining, loggedIn)
+		}
+		a.portalClients[portalLL] = remaining
+	}
+	if changed {
+		a.updateIntendedState()
+		a.reconcile()
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// normalizeCaptivePortalClient validates and normalizes client IP or MAC address.
+func normalizeCaptivePortalClient(client string) (string, error) {
+	if ip := net.ParseIP(client); ip != nil {
+		return ip.String(), nil
+	}
+	if mac, err := net.ParseMAC(client); err == nil {
+		return mac.String(), nil
+	}
+	return "", fmt.Errorf("%q is neither IP nor MAC address", client)
+}
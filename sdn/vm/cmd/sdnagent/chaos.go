@@ -0,0 +1,482 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/lf-edge/eden/sdn/vm/pkg/configitems"
+	log "github.com/sirupsen/logrus"
+)
+
+// chaosRun : state of the chaos scenario execution.
+type chaosRun struct {
+	id       int
+	scenario api.ChaosScenario
+	status   api.ChaosStatus
+	cancel   context.CancelFunc
+	// Steps with currently injected faults.
+	activeSteps map[int]struct{}
+	// Link state of active link-flap steps (true if link is currently down).
+	flapDown map[int]bool
+}
+
+// chaosTrigger : scheduled action of a chaos scenario, triggered when the time comes.
+type chaosTrigger struct {
+	runID  int
+	step   int
+	action api.ChaosAction
+	at     time.Duration
+}
+
+// startChaos starts execution of a (validated) chaos scenario.
+// Any previously running scenario is stopped first.
+// Called with agent in locked state.
+func (a *agent) startChaos(scenario api.ChaosScenario) {
+	if a.chaos != nil && a.chaos.status.Running {
+		a.stopChaos()
+	}
+	var runID int
+	if a.chaos != nil {
+		runID = a.chaos.id + 1
+	}
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.chaos = &chaosRun{
+		id:       runID,
+		scenario: scenario,
+		status: api.ChaosStatus{
+			Scenario:  &scenario,
+			StartedAt: time.Now(),
+			Running:   true,
+		},
+		cancel:      cancel,
+		activeSteps: make(map[int]struct{}),
+		flapDown:    make(map[int]bool),
+	}
+	a.addChaosEvent(-1, api.ChaosStart,
+		fmt.Sprintf("Started chaos scenario %q with %d steps",
+			scenario.Name, len(scenario.Steps)), nil)
+	go a.runChaosTimeline(ctx, a.chaos.status.StartedAt, a.chaosTimeline(runID, scenario))
+}
+
+// stopChaos stops the running chaos scenario and reverts all injected faults.
+// Called with agent in locked state.
+func (a *agent) stopChaos() {
+	if a.chaos == nil || !a.chaos.status.Running {
+		return
+	}
+	a.chaos.cancel()
+	for _, step := range a.activeChaosSteps() {
+		delete(a.chaos.activeSteps, step)
+		delete(a.chaos.flapDown, step)
+		a.addChaosEvent(step, api.ChaosRevert,
+			"Reverted "+a.chaos.scenario.Steps[step].String(), nil)
+	}
+	a.chaos.status.Running = false
+	a.addChaosEvent(-1, api.ChaosStop, "Chaos scenario was stopped", nil)
+}
+
+// chaosTimeline returns all actions of the scenario ordered by the time of execution.
+func (a *agent) chaosTimeline(runID int, scenario api.ChaosScenario) (triggers []chaosTrigger) {
+	var end time.Duration
+	for i, step := range scenario.Steps {
+		start := time.Duration(step.At)
+		stop := start + time.Duration(step.For)
+		triggers = append(triggers, chaosTrigger{
+			runID: runID, step: i, action: api.ChaosInject, at: start})
+		if step.LinkFlap != nil {
+			interval := time.Duration(step.LinkFlap.Interval)
+			for at := start + interval; at < stop; at += interval {
+				triggers = append(triggers, chaosTrigger{
+					runID: runID, step: i, action: api.ChaosLinkToggle, at: at})
+			}
+		}
+		triggers = append(triggers, chaosTrigger{
+			runID: runID, step: i, action: api.ChaosRevert, at: stop})
+		if stop > end {
+			end = stop
+		}
+	}
+	triggers = append(triggers, chaosTrigger{
+		runID: runID, step: -1, action: api.ChaosEnd, at: end})
+	// Stable sort keeps revert of one step before the end of the scenario
+	// if both are scheduled for the same time.
+	sort.SliceStable(triggers, func(i, j int) bool {
+		return triggers[i].at < triggers[j].at
+	})
+	return triggers
+}
+
+// runChaosTimeline waits for the scheduled time of each action and passes it
+// to the main agent loop for execution.
+func (a *agent) runChaosTimeline(ctx context.Context, startTime time.Time,
+	triggers []chaosTrigger) {
+	for _, trigger := range triggers {
+		timer := time.NewTimer(time.Until(startTime.Add(trigger.at)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case a.chaosTriggers <- trigger:
+		}
+	}
+}
+
+// executeChaosAction executes the given action of the running chaos scenario.
+// Returns true if the intended state should be updated.
+// Called with agent in locked state.
+func (a *agent) executeChaosAction(trigger chaosTrigger) (changed bool) {
+	run := a.chaos
+	if run == nil || run.id != trigger.runID || !run.status.Running {
+		// Stale trigger from an already stopped scenario.
+		return false
+	}
+	if trigger.action == api.ChaosEnd {
+		run.status.Running = false
+		run.cancel()
+		a.addChaosEvent(-1, api.ChaosEnd, "Chaos scenario finished", nil)
+		return false
+	}
+	step := run.scenario.Steps[trigger.step]
+	switch trigger.action {
+	case api.ChaosInject:
+		if err := a.checkChaosStepRefs(step); err != nil {
+			a.addChaosEvent(trigger.step, api.ChaosInject,
+				"Skipped "+step.String(), err)
+			return false
+		}
+		run.activeSteps[trigger.step] = struct{}{}
+		if step.LinkFlap != nil {
+			run.flapDown[trigger.step] = true
+		}
+		a.addChaosEvent(trigger.step, api.ChaosInject, "Injected "+step.String(), nil)
+		return true
+	case api.ChaosLinkToggle:
+		if _, active := run.activeSteps[trigger.step]; !active {
+			return false
+		}
+		linkDown := !run.flapDown[trigger.step]
+		run.flapDown[trigger.step] = linkDown
+		state := "up"
+		if linkDown {
+			state = "down"
+		}
+		a.addChaosEvent(trigger.step, api.ChaosLinkToggle,
+			fmt.Sprintf("Port %s is %s", step.LinkFlap.Port, state), nil)
+		return true
+	case api.ChaosRevert:
+		if _, active := run.activeSteps[trigger.step]; !active {
+			return false
+		}
+		delete(run.activeSteps, trigger.step)
+		delete(run.flapDown, trigger.step)
+		a.addChaosEvent(trigger.step, api.ChaosRevert, "Reverted "+step.String(), nil)
+		return true
+	}
+	return false
+}
+
+// Called with agent in locked state.
+func (a *agent) addChaosEvent(step int, action api.ChaosAction, msg string, err error) {
+	event := api.ChaosEvent{
+		Time:    time.Now(),
+		Step:    step,
+		Action:  action,
+		Message: msg,
+	}
+	if err != nil {
+		event.Error = err.Error()
+		log.Warnf("Chaos (step %d): %s: %v", step, msg, err)
+	} else {
+		log.Infof("Chaos (step %d): %s", step, msg)
+	}
+	a.chaos.status.Events = append(a.chaos.status.Events, event)
+}
+
+// validateChaosScenario validates chaos scenario against the current network model.
+// Called with agent in locked state.
+func (a *agent) validateChaosScenario(scenario api.ChaosScenario) error {
+	if len(scenario.Steps) == 0 {
+		return errors.New("chaos scenario without steps")
+	}
+	for i, step := range scenario.Steps {
+		var faults int
+		for _, defined := range []bool{step.TrafficControl != nil, step.LinkDown != nil,
+			step.LinkFlap != nil, step.Blackhole != nil} {
+			if defined {
+				faults++
+			}
+		}
+		if faults != 1 {
+			return fmt.Errorf("chaos step %d should define exactly one fault, found %d",
+				i, faults)
+		}
+		if step.At < 0 {
+			return fmt.Errorf("chaos step %d scheduled with negative time (%v)", i, step.At)
+		}
+		if step.For <= 0 {
+			return fmt.Errorf("chaos step %d without duration", i)
+		}
+		if step.TrafficControl != nil {
+			err := a.validateTrafficControl(step.TrafficControl.Port,
+				step.TrafficControl.TrafficControl)
+			if err != nil {
+				return fmt.Errorf("chaos step %d: %w", i, err)
+			}
+		}
+		if step.LinkFlap != nil && step.LinkFlap.Interval <= 0 {
+			return fmt.Errorf("chaos step %d with link flapping without interval", i)
+		}
+		if bh := step.Blackhole; bh != nil {
+			var destinations int
+			for _, defined := range []bool{bh.Endpoint != "", bh.Controller,
+				bh.DstSubnet != ""} {
+				if defined {
+					destinations++
+				}
+			}
+			if destinations != 1 {
+				return fmt.Errorf("chaos step %d should define exactly one blackhole "+
+					"destination, found %d", i, destinations)
+			}
+			if bh.DstSubnet != "" {
+				if _, _, err := net.ParseCIDR(bh.DstSubnet); err != nil {
+					return fmt.Errorf("chaos step %d with invalid blackhole subnet: %w",
+						i, err)
+				}
+			}
+			if len(bh.Ports) > 0 && bh.Protocol != api.TCP && bh.Protocol != api.UDP {
+				return fmt.Errorf("chaos step %d with blackhole ports but without "+
+					"TCP or UDP protocol", i)
+			}
+		}
+		if err := a.checkChaosStepRefs(step); err != nil {
+			return fmt.Errorf("chaos step %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// checkChaosStepRefs checks that all items referenced by the chaos step exist
+// in the current network model.
+// Called with agent in locked state.
+func (a *agent) checkChaosStepRefs(step api.ChaosStep) error {
+	var port string
+	switch {
+	case step.TrafficControl != nil:
+		port = step.TrafficControl.Port
+	case step.LinkDown != nil:
+		port = step.LinkDown.Port
+	case step.LinkFlap != nil:
+		port = step.LinkFlap.Port
+	}
+	if port != "" && a.netModel.items.getItem(api.Port{}.ItemType(), port) == nil {
+		return fmt.Errorf("port %s does not exist", port)
+	}
+	if bh := step.Blackhole; bh != nil {
+		if bh.Endpoint != "" &&
+			a.netModel.items.getItem(api.Endpoint{}.ItemType(), bh.Endpoint) == nil {
+			return fmt.Errorf("endpoint %s does not exist", bh.Endpoint)
+		}
+		if bh.Network != "" &&
+			a.netModel.items.getItem(api.Network{}.ItemType(), bh.Network) == nil {
+			return fmt.Errorf("network %s does not exist", bh.Network)
+		}
+		if bh.Controller && a.netModel.hostIP == nil {
+			return errors.New("controller IP is not known")
+		}
+	}
+	return nil
+}
+
+// revertStaleChaosSteps reverts active chaos steps which reference items
+// no longer present in the (updated) network model.
+// Called with agent in locked state.
+func (a *agent) revertStaleChaosSteps() {
+	for _, i := range a.activeChaosSteps() {
+		step := a.chaos.scenario.Steps[i]
+		if err := a.checkChaosStepRefs(step); err != nil {
+			delete(a.chaos.activeSteps, i)
+			delete(a.chaos.flapDown, i)
+			a.addChaosEvent(i, api.ChaosRevert, "Reverted "+step.String(), err)
+		}
+	}
+}
+
+// activeChaosSteps returns chaos steps with currently injected faults,
+// ordered by the step index.
+// Called with agent in locked state.
+func (a *agent) activeChaosSteps() (steps []int) {
+	if a.chaos == nil {
+		return nil
+	}
+	for step := range a.chaos.activeSteps {
+		steps = append(steps, step)
+	}
+	sort.Ints(steps)
+	return steps
+}
+
+// getPortTC returns traffic control parameters to apply for the port,
+// possibly overridden by a chaos step.
+func (a *agent) getPortTC(port api.Port) api.TrafficControl {
+	tc := port.TC
+	for _, i := range a.activeChaosSteps() {
+		step := a.chaos.scenario.Steps[i]
+		if step.TrafficControl != nil && step.TrafficControl.Port == port.LogicalLabel {
+			tc = step.TrafficControl.TrafficControl
+		}
+	}
+	return tc
+}
+
+// isPortAdminUP returns true if the port should be administratively UP,
+// taking links put down by chaos steps into account.
+func (a *agent) isPortAdminUP(port api.Port) bool {
+	if !port.AdminUP {
+		return false
+	}
+	for _, i := range a.activeChaosSteps() {
+		step := a.chaos.scenario.Steps[i]
+		if step.LinkDown != nil && step.LinkDown.Port == port.LogicalLabel {
+			return false
+		}
+		if step.LinkFlap != nil && step.LinkFlap.Port == port.LogicalLabel &&
+			a.chaos.flapDown[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// getChaosBlackholeRules returns iptables rules dropping traffic
+// blackholed by active chaos steps.
+func (a *agent) getChaosBlackholeRules(ipv6 bool) (rules []configitems.IptablesRule) {
+	for _, i := range a.activeChaosSteps() {
+		step := a.chaos.scenario.Steps[i]
+		bh := step.Blackhole
+		if bh == nil || a.checkChaosStepRefs(step) != nil {
+			// Steps with missing references are reverted by revertStaleChaosSteps.
+			continue
+		}
+		var dstSubnets []string
+		switch {
+		case bh.Endpoint != "":
+			ep := a.getEndpoint(bh.Endpoint)
+			dstSubnets = append(dstSubnets, hostIPNet(net.ParseIP(ep.IP)).String())
+		case bh.Controller:
+			dstSubnets = append(dstSubnets, hostIPNet(a.netModel.hostIP).String())
+		default:
+			dstSubnets = append(dstSubnets, bh.DstSubnet)
+		}
+		srcSubnets := []string{""}
+		if bh.Network != "" {
+			item := a.netModel.items.getItem(api.Network{}.ItemType(), bh.Network)
+			srcSubnets = nil
+			for _, subnet := range a.getNetworkSubnets(item.LabeledItem.(api.Network)) {
+				srcSubnets = append(srcSubnets, subnet.subnet.String())
+			}
+		}
+		for _, dstSubnet := range dstSubnets {
+			_, dst, _ := net.ParseCIDR(dstSubnet)
+			if (dst.IP.To4() == nil) != ipv6 {
+				continue
+			}
+			for _, srcSubnet := range srcSubnets {
+				if srcSubnet != "" {
+					_, src, _ := net.ParseCIDR(srcSubnet)
+					if (src.IP.To4() == nil) != ipv6 {
+						continue
+					}
+				}
+				rule := a.getIntendedFwRule(api.FwRule{
+					SrcSubnet: srcSubnet,
+					DstSubnet: dstSubnet,
+					Protocol:  bh.Protocol,
+					Ports:     bh.Ports,
+					Action:    api.FwDrop,
+				}, ipv6)
+				rule.Description = fmt.Sprintf("Chaos step %d: blackhole", i)
+				rules = append(rules, rule)
+			}
+		}
+	}
+	return rules
+}
+
+func (a *agent) runChaosScenario(w http.ResponseWriter, r *http.Request) {
+	var scenario api.ChaosScenario
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to read chaos scenario from HTTP request: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	err = json.Unmarshal(body, &scenario)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to unmarshal chaos scenario from JSON: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	a.Lock()
+	defer a.Unlock()
+	if err = a.validateChaosScenario(scenario); err != nil {
+		errMsg := fmt.Sprintf("Chaos scenario is invalid: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	a.startChaos(scenario)
+	// Revert faults of the previous scenario (if there was any running).
+	a.updateIntendedState()
+	a.reconcile()
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *agent) getChaosStatus(w http.ResponseWriter, r *http.Request) {
+	var status api.ChaosStatus
+	a.Lock()
+	if a.chaos != nil {
+		status = a.chaos.status
+	}
+	resp, err := json.Marshal(status)
+	a.Unlock()
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to marshal chaos status to JSON: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(resp); err != nil {
+		log.Errorf("Failed to write chaos status to HTTP response: %v", err)
+	}
+}
+
+func (a *agent) stopChaosScenario(w http.ResponseWriter, r *http.Request) {
+	a.Lock()
+	defer a.Unlock()
+	if a.chaos == nil || !a.chaos.status.Running {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	a.stopChaos()
+	a.updateIntendedState()
+	a.reconcile()
+	w.WriteHeader(http.StatusOK)
+}
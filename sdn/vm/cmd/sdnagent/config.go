@@ -36,13 +36,31 @@ const (
 	// Priority for IP rules directing traffic to per-network routing tables.
 	networkIPRulePriority = 500
 	networkRTBaseIndex    = 500
+
+	// TUN interface used by NAT64.
+	nat64TunIfName = "nat64"
 )
 
 var allIPv4, allIPv6 *net.IPNet
 
+// NAT64 config.
+var (
+	// Well-Known Prefix (RFC 6052).
+	nat64Prefix *net.IPNet
+	// IPv4 addresses assigned to IPv6 hosts accessing IPv4 destinations.
+	// Taken from the reserved 240.0.0.0/4 (end of the range, not used
+	// for veths).
+	nat64IPv4Pool *net.IPNet
+	nat64IPv4Addr = net.ParseIP("240.255.0.1")
+	// From the ULA subnet used for veths.
+	nat64IPv6Addr = net.ParseIP("fd00:ede0:ffff::1")
+)
+
 func init() {
 	_, allIPv4, _ = net.ParseCIDR("0.0.0.0/0")
 	_, allIPv6, _ = net.ParseCIDR("::/0")
+	_, nat64Prefix, _ = net.ParseCIDR("64:ff9b::/96")
+	_, nat64IPv4Pool, _ = net.ParseCIDR("240.255.0.0/16")
 }
 
 // Update external items inside the graph with the current state.
@@ -88,6 +106,7 @@ func (a *agent) updateCurrentState() (changed bool) {
 
 // Update graph with the intended state based on the network model stored in a.netModel
 func (a *agent) updateIntendedState() {
+	a.revertStaleChaosSteps()
 	a.allocNetworkIndexes()
 	graphArgs := dg.InitArgs{Name: configGraphName}
 	a.intendedState = dg.New(graphArgs)
@@ -114,8 +133,15 @@ func (a *agent) updateIntendedState() {
 	for _, httpSrv := range a.netModel.Endpoints.HTTPServers {
 		a.intendedState.PutSubGraph(a.getIntendedHttpSrvEp(httpSrv))
 	}
-
-	// TODO (ntp servers, netboot servers)
+	for _, portal := range a.netModel.Endpoints.CaptivePortals {
+		a.intendedState.PutSubGraph(a.getIntendedCaptivePortalEp(portal))
+	}
+	for _, netbootSrv := range a.netModel.Endpoints.NetbootServers {
+		a.intendedState.PutSubGraph(a.getIntendedNetbootSrvEp(netbootSrv))
+	}
+	for _, ntpSrv := range a.netModel.Endpoints.NTPServers {
+		a.intendedState.PutSubGraph(a.getIntendedNtpSrvEp(ntpSrv))
+	}
 }
 
 func (a *agent) getIntendedPhysIfs() dg.Graph {
@@ -183,6 +209,35 @@ func (a *agent) getIntendedHostConnectivity() dg.Graph {
 			},
 		},
 	}, nil)
+	var withIPv6, withIPv6Only bool
+	for _, network := range a.netModel.Networks {
+		withIPv6 = withIPv6 || a.networkHasIPv6(network)
+		withIPv6Only = withIPv6Only || a.isIPv6OnlyNetwork(network)
+	}
+	if withIPv6 {
+		intendedCfg.PutItem(configitems.IptablesChain{
+			ChainName: "POSTROUTING",
+			Table:     "nat",
+			ForIPv6:   true,
+			Rules: []configitems.IptablesRule{
+				{
+					Args:        []string{"-o", netIf.IfName, "-j", "MASQUERADE"},
+					Description: "S-NAT IPv6 traffic leaving SDN VM towards the host OS",
+				},
+			},
+		}, nil)
+	}
+	if withIPv6Only {
+		// IPv6-only networks access IPv4 destinations (e.g. controller) using NAT64.
+		// Translated traffic is S-NATed by the POSTROUTING rule above.
+		intendedCfg.PutItem(configitems.Nat64{
+			TunIfName: nat64TunIfName,
+			Prefix:    nat64Prefix,
+			IPv4Pool:  nat64IPv4Pool,
+			IPv4Addr:  nat64IPv4Addr,
+			IPv6Addr:  nat64IPv6Addr,
+		}, nil)
+	}
 	return intendedCfg
 }
 
@@ -191,13 +246,14 @@ func (a *agent) getIntendedTrafficControl() dg.Graph {
 	intendedCfg := dg.New(graphArgs)
 	emptyTC := api.TrafficControl{}
 	for _, port := range a.netModel.Ports {
-		if port.TC == emptyTC {
+		tc := a.getPortTC(port)
+		if tc == emptyTC {
 			continue
 		}
 		// MAC address is already validated
 		mac, _ := net.ParseMAC(port.MAC)
 		intendedCfg.PutItem(configitems.TrafficControl{
-			TrafficControl: port.TC,
+			TrafficControl: tc,
 			PhysIf: configitems.PhysIf{
 				LogicalLabel: port.LogicalLabel,
 				MAC:          mac,
@@ -232,7 +288,7 @@ func (a *agent) getIntendedBridges() dg.Graph {
 			},
 			ParentLL: masterID.logicalLabel,
 			Usage:    usage,
-			AdminUP:  port.AdminUP,
+			AdminUP:  a.isPortAdminUP(port),
 			MTU:      maxMTU,
 		}, nil)
 	}
@@ -307,19 +363,32 @@ func (a *agent) getIntendedNetwork(network api.Network) dg.Graph {
 
 	// Network namespace connected with the bridge using veth.
 	brVethName, brInIfName, brOutIfName := a.networkBrVethName(network.LogicalLabel)
-	_, subnet, _ := net.ParseCIDR(network.Subnet) // already validated
-	gwIP := &net.IPNet{IP: net.ParseIP(network.GwIP), Mask: subnet.Mask}
+	subnets := a.getNetworkSubnets(network)
+	var gwIPs []*net.IPNet
+	for _, subnet := range subnets {
+		gwIPs = append(gwIPs, subnet.gwIP)
+	}
+	hasIPv6 := a.networkHasIPv6(network)
+	ipv6Only := a.isIPv6OnlyNetwork(network)
 	nsName := a.networkNsName(network.LogicalLabel)
 	netNs := configitems.NetNamespace{
 		NsName: nsName,
 	}
 	intendedCfg.PutItem(netNs, nil)
+	if hasIPv6 {
+		// Required by radvd.
+		intendedCfg.PutItem(configitems.Sysctl{
+			NetNamespace:         nsName,
+			EnableIPv4Forwarding: true,
+			EnableIPv6Forwarding: true,
+		}, nil)
+	}
 	intendedCfg.PutItem(configitems.Veth{
 		VethName: brVethName,
 		Peer1: configitems.VethPeer{
 			IfName:       brInIfName,
 			NetNamespace: nsName,
-			IPAddresses:  []*net.IPNet{gwIP},
+			IPAddresses:  gwIPs,
 			MTU:          network.MTU,
 		},
 		Peer2: configitems.VethPeer{
@@ -334,38 +403,36 @@ func (a *agent) getIntendedNetwork(network api.Network) dg.Graph {
 	}, nil)
 
 	// Another veth used to connect network with the main "router".
+	// IPv4 addresses are assigned even to IPv6-only network, allowing DNS64 proxy
+	// to reach upstream IPv4 DNS servers.
 	rtVethName, rtInIfName, rtOutIfName := a.networkRtVethName(network.LogicalLabel)
-	isIPv6 := len(subnet.IP) == net.IPv6len
-	inIP, outIP := a.genVethIPsForNetwork(network.LogicalLabel, isIPv6)
+	inIP, outIP := a.genVethIPsForNetwork(network.LogicalLabel, false)
+	inIPs := []*net.IPNet{inIP}
+	outIPs := []*net.IPNet{outIP}
+	if hasIPv6 {
+		inIPv6, outIPv6 := a.genVethIPsForNetwork(network.LogicalLabel, true)
+		inIPs = append(inIPs, inIPv6)
+		outIPs = append(outIPs, outIPv6)
+	}
 	intendedCfg.PutItem(configitems.Veth{
 		VethName: rtVethName,
 		Peer1: configitems.VethPeer{
 			IfName:       rtInIfName,
 			NetNamespace: nsName,
-			IPAddresses:  []*net.IPNet{inIP},
+			IPAddresses:  inIPs,
 			MTU:          network.MTU,
 		},
 		Peer2: configitems.VethPeer{
 			IfName:       rtOutIfName,
 			NetNamespace: configitems.MainNsName,
-			IPAddresses:  []*net.IPNet{outIP},
+			IPAddresses:  outIPs,
 			MTU:          network.MTU,
 		},
 	}, nil)
 
-	// DHCP server.
+	// DHCP server(s), Router Advertisement and DNS64 proxy.
 	dhcp := network.DHCP
 	if dhcp.Enable {
-		ipRange := a.subnetToHostIPRange(subnet)
-		if dhcp.IPRange.FromIP != "" {
-			ipRange.FromIP = net.ParseIP(dhcp.IPRange.FromIP)
-			ipRange.ToIP = net.ParseIP(dhcp.IPRange.ToIP)
-		}
-		ntpServer := dhcp.PublicNTP
-		if dhcp.PrivateNTP != "" {
-			ep := a.getEndpoint(dhcp.PrivateNTP)
-			ntpServer = ep.IP // XXX Or FQDN?
-		}
 		var dnsServers []net.IP
 		for _, dnsServer := range dhcp.PublicDNS {
 			dnsServers = append(dnsServers, net.ParseIP(dnsServer))
@@ -374,61 +441,48 @@ func (a *agent) getIntendedNetwork(network api.Network) dg.Graph {
 			ep := a.getEndpoint(dnsServer)
 			dnsServers = append(dnsServers, net.ParseIP(ep.IP))
 		}
-		gatewayIP := gwIP.IP
-		if dhcp.WithoutDefaultRoute {
-			gatewayIP = nil
-		}
-		var staticEntries []configitems.MACToIP
-		for _, entry := range dhcp.StaticEntries {
-			mac, _ := net.ParseMAC(entry.MAC)
-			staticEntries = append(staticEntries, configitems.MACToIP{
-				MAC: mac,
-				IP:  net.ParseIP(entry.IP),
-			})
+		for _, subnet := range subnets {
+			a.putNetworkDhcpConfig(intendedCfg, network, subnet, dnsServers)
 		}
-		intendedCfg.PutItem(configitems.DhcpServer{
-			ServerName:     network.LogicalLabel,
-			NetNamespace:   nsName,
-			VethName:       brVethName,
-			VethPeerIfName: brInIfName,
-			Subnet:         subnet,
-			IPRange:        ipRange,
-			StaticEntries:  staticEntries,
-			GatewayIP:      gatewayIP,
-			DomainName:     dhcp.DomainName,
-			DNSServers:     dnsServers,
-			NTPServer:      ntpServer,
-			WPAD:           network.DHCP.WPAD,
-		}, nil)
 	}
 
 	// Routing.
 	rt := networkRTBaseIndex + index
-	intendedCfg.PutItem(configitems.IPRule{
-		SrcNet:   subnet,
-		Table:    rt,
-		Priority: networkIPRulePriority,
-	}, nil)
-	intendedCfg.PutItem(configitems.IPRule{
-		DstNet:   subnet,
-		Table:    rt,
-		Priority: networkIPRulePriority,
-	}, nil)
-	defaultDst := allIPv4
-	if isIPv6 {
-		defaultDst = allIPv6
+	for _, subnet := range subnets {
+		intendedCfg.PutItem(configitems.IPRule{
+			SrcNet:   subnet.subnet,
+			Table:    rt,
+			Priority: networkIPRulePriority,
+		}, nil)
+		intendedCfg.PutItem(configitems.IPRule{
+			DstNet:   subnet.subnet,
+			Table:    rt,
+			Priority: networkIPRulePriority,
+		}, nil)
+	}
+	if ipv6Only {
+		// Route traffic of DNS64 proxy using the routing table of the network.
+		for _, ip := range inIPs {
+			intendedCfg.PutItem(configitems.IPRule{
+				SrcNet:   hostIPNet(ip.IP),
+				Table:    rt,
+				Priority: networkIPRulePriority,
+			}, nil)
+		}
 	}
 	// - default route from inside of the network namespace
-	intendedCfg.PutItem(configitems.Route{
-		NetNamespace: nsName,
-		Table:        syscall.RT_TABLE_MAIN,
-		DstNet:       defaultDst,
-		OutputIf: configitems.RouteOutIf{
-			VethName:       rtVethName,
-			VethPeerIfName: rtInIfName,
-		},
-		GwIP: outIP.IP,
-	}, nil)
+	for i := range inIPs {
+		intendedCfg.PutItem(configitems.Route{
+			NetNamespace: nsName,
+			Table:        syscall.RT_TABLE_MAIN,
+			DstNet:       defaultDstNet(outIPs[i].IP),
+			OutputIf: configitems.RouteOutIf{
+				VethName:       rtVethName,
+				VethPeerIfName: rtInIfName,
+			},
+			GwIP: outIPs[i].IP,
+		}, nil)
+	}
 	// - route for every endpoint
 	epTypename := api.Endpoint{}.ItemType()
 	for itemID, item := range a.netModel.items {
@@ -461,40 +515,47 @@ func (a *agent) getIntendedNetwork(network api.Network) dg.Graph {
 	}
 	// - route for every other network (including itself)
 	for _, network2 := range a.netModel.Networks {
-		_, net2Subnet, _ := net.ParseCIDR(network2.Subnet)
 		reachable := network.Router == nil ||
 			network2.LogicalLabel == network.LogicalLabel ||
 			strListContains(network.Router.ReachableNetworks, network2.LogicalLabel)
-		if reachable {
-			net2VethName, _, net2OutIfName := a.networkRtVethName(network2.LogicalLabel)
-			net2InIP, _ := a.genVethIPsForNetwork(network2.LogicalLabel, isIPv6)
-			intendedCfg.PutItem(configitems.Route{
-				NetNamespace: configitems.MainNsName,
-				Table:        rt,
-				DstNet:       net2Subnet,
-				OutputIf: configitems.RouteOutIf{
-					VethName:       net2VethName,
-					VethPeerIfName: net2OutIfName,
-				},
-				GwIP: net2InIP.IP,
-			}, nil)
-		} else {
-			intendedCfg.PutItem(configitems.Route{
-				NetNamespace: configitems.MainNsName,
-				Table:        rt,
-				DstNet:       net2Subnet,
-			}, nil)
+		for _, net2Subnet := range a.getNetworkSubnets(network2) {
+			if reachable {
+				net2VethName, _, net2OutIfName := a.networkRtVethName(network2.LogicalLabel)
+				net2InIP, _ := a.genVethIPsForNetwork(network2.LogicalLabel, net2Subnet.ipv6)
+				intendedCfg.PutItem(configitems.Route{
+					NetNamespace: configitems.MainNsName,
+					Table:        rt,
+					DstNet:       net2Subnet.subnet,
+					OutputIf: configitems.RouteOutIf{
+						VethName:       net2VethName,
+						VethPeerIfName: net2OutIfName,
+					},
+					GwIP: net2InIP.IP,
+				}, nil)
+			} else {
+				intendedCfg.PutItem(configitems.Route{
+					NetNamespace: configitems.MainNsName,
+					Table:        rt,
+					DstNet:       net2Subnet.subnet,
+				}, nil)
+			}
 		}
 	}
 	// - route for the outside world if enabled
 	outsideRechability := network.Router == nil || network.Router.OutsideReachability
 	hostPort, hostPortfound := a.macLookup.GetInterfaceByMAC(hostPortMACPrefix, true)
-	hostGwIP := a.getHostGwIP(isIPv6)
-	if outsideRechability && hostPortfound && hostGwIP != nil {
+	for _, ipv6 := range []bool{false, true} {
+		hostGwIP := a.getHostGwIP(ipv6)
+		if !outsideRechability || !hostPortfound || hostGwIP == nil {
+			continue
+		}
+		if ipv6 && !hasIPv6 {
+			continue
+		}
 		intendedCfg.PutItem(configitems.Route{
 			NetNamespace: configitems.MainNsName,
 			Table:        rt,
-			DstNet:       defaultDst,
+			DstNet:       defaultDstNet(hostGwIP),
 			OutputIf: configitems.RouteOutIf{
 				PhysIf: configitems.PhysIf{
 					MAC:          hostPort.MAC,
@@ -504,6 +565,17 @@ func (a *agent) getIntendedNetwork(network api.Network) dg.Graph {
 			GwIP: hostGwIP,
 		}, nil)
 	}
+	// - route for IPv4 destinations mapped into IPv6 (for IPv6-only network)
+	if ipv6Only && outsideRechability {
+		intendedCfg.PutItem(configitems.Route{
+			NetNamespace: configitems.MainNsName,
+			Table:        rt,
+			DstNet:       nat64Prefix,
+			OutputIf: configitems.RouteOutIf{
+				TunIfName: nat64TunIfName,
+			},
+		}, nil)
+	}
 	// - routes towards EVE
 	var routesTowardsEVE []api.IPRoute
 	if network.Router != nil {
@@ -512,6 +584,10 @@ func (a *agent) getIntendedNetwork(network api.Network) dg.Graph {
 	for _, route := range routesTowardsEVE {
 		_, dstNetwork, _ := net.ParseCIDR(route.DstNetwork)
 		gatewayIP := net.ParseIP(route.Gateway)
+		rtInIP := inIP
+		if gatewayIP.To4() == nil {
+			rtInIP, _ = a.genVethIPsForNetwork(network.LogicalLabel, true)
+		}
 		intendedCfg.PutItem(configitems.IPRule{
 			SrcNet:   dstNetwork,
 			Table:    rt,
@@ -540,16 +616,18 @@ func (a *agent) getIntendedNetwork(network api.Network) dg.Graph {
 				VethName:       rtVethName,
 				VethPeerIfName: rtOutIfName,
 			},
-			GwIP: inIP.IP,
+			GwIP: rtInIP.IP,
 		}, nil)
 	}
 	// - everything else is unreachable
-	intendedCfg.PutItem(configitems.Route{
-		NetNamespace: configitems.MainNsName,
-		Table:        rt,
-		DstNet:       defaultDst,
-		Metric:       ^uint32(0), // Lowest prio.
-	}, nil)
+	for _, subnet := range subnets {
+		intendedCfg.PutItem(configitems.Route{
+			NetNamespace: configitems.MainNsName,
+			Table:        rt,
+			DstNet:       defaultDstNet(subnet.subnet.IP),
+			Metric:       ^uint32(0), // Lowest prio.
+		}, nil)
+	}
 
 	// Transparent proxy.
 	if network.TransparentProxy != "" {
@@ -579,33 +657,189 @@ func (a *agent) getIntendedNetwork(network api.Network) dg.Graph {
 			NetNamespace: nsName,
 			ChainName:    "PREROUTING",
 			Table:        "nat",
-			ForIPv6:      false,
+			ForIPv6:      net.ParseIP(ep.IP).To4() == nil,
 			Rules:        dnatRules,
 		}, nil)
 	}
+
+	// Captive portal.
+	if network.CaptivePortal != "" {
+		a.putCaptivePortalRules(intendedCfg, network)
+	}
 	return intendedCfg
 }
 
+// putNetworkDhcpConfig puts DHCP server for the given network subnet into the graph.
+// For IPv6 subnet, Router Advertisement is configured as well, and for IPv6-only
+// network DNS64 proxy is put in front of the DNS servers.
+func (a *agent) putNetworkDhcpConfig(graph dg.Graph, network api.Network,
+	subnet networkSubnet, dnsServers []net.IP) {
+	dhcp := network.DHCP
+	nsName := a.networkNsName(network.LogicalLabel)
+	brVethName, brInIfName, _ := a.networkBrVethName(network.LogicalLabel)
+	serverName := network.LogicalLabel
+	if subnet.ipv6 {
+		serverName += "-ipv6"
+	}
+	var ipRange configitems.IPRange
+	switch {
+	case !subnet.ipv6:
+		ipRange = a.subnetToHostIPRange(subnet.subnet)
+		if dhcp.IPRange.FromIP != "" {
+			ipRange.FromIP = net.ParseIP(dhcp.IPRange.FromIP)
+			ipRange.ToIP = net.ParseIP(dhcp.IPRange.ToIP)
+		}
+	case dhcp.IPv6AddrAssignment == api.IPv6DHCP:
+		ipRange = a.subnetToHostIPRange(subnet.subnet)
+		if ipRange.FromIP.Equal(subnet.gwIP.IP) {
+			ipRange.FromIP = ipToInt(ipRange.FromIP).Inc().ToIP()
+		}
+		if dhcp.IPv6Range.FromIP != "" {
+			ipRange.FromIP = net.ParseIP(dhcp.IPv6Range.FromIP)
+			ipRange.ToIP = net.ParseIP(dhcp.IPv6Range.ToIP)
+		}
+	}
+	// Announce only DNS servers of the same IP version.
+	// IPv6-only network is given DNS64 proxy instead, which uses all configured
+	// DNS servers as upstream.
+	var subnetDNSServers []net.IP
+	if a.isIPv6OnlyNetwork(network) {
+		if len(dnsServers) > 0 {
+			graph.PutItem(configitems.Dns64Proxy{
+				ProxyName:       network.LogicalLabel,
+				NetNamespace:    nsName,
+				VethName:        brVethName,
+				ListenIP:        subnet.gwIP.IP,
+				Prefix:          nat64Prefix,
+				UpstreamServers: dnsServers,
+			}, nil)
+			subnetDNSServers = []net.IP{subnet.gwIP.IP}
+		}
+	} else {
+		for _, dnsServer := range dnsServers {
+			if (dnsServer.To4() == nil) == subnet.ipv6 {
+				subnetDNSServers = append(subnetDNSServers, dnsServer)
+			}
+		}
+	}
+	ntpServer := dhcp.PublicNTP
+	if dhcp.PrivateNTP != "" {
+		ep := a.getEndpoint(dhcp.PrivateNTP)
+		ntpServer = ep.IP // XXX Or FQDN?
+		if (net.ParseIP(ep.IP).To4() == nil) != subnet.ipv6 {
+			ntpServer = ""
+		}
+	}
+	var gatewayIP net.IP
+	if !dhcp.WithoutDefaultRoute && !subnet.ipv6 {
+		gatewayIP = subnet.gwIP.IP
+	}
+	var staticEntries []configitems.MACToIP
+	for _, entry := range dhcp.StaticEntries {
+		mac, _ := net.ParseMAC(entry.MAC)
+		ip := net.ParseIP(entry.IP)
+		if !subnet.subnet.Contains(ip) {
+			continue
+		}
+		staticEntries = append(staticEntries, configitems.MACToIP{
+			MAC: mac,
+			IP:  ip,
+		})
+	}
+	var netboot *configitems.DhcpNetboot
+	if dhcp.NetbootServer != "" {
+		ep := a.getEndpoint(dhcp.NetbootServer)
+		if (net.ParseIP(ep.IP).To4() == nil) == subnet.ipv6 {
+			netboot = a.getDhcpNetboot(dhcp.NetbootServer, dhcp.PrivateDNS)
+		}
+	}
+	var wpad string
+	if !subnet.ipv6 {
+		wpad = dhcp.WPAD
+	}
+	graph.PutItem(configitems.DhcpServer{
+		ServerName:     serverName,
+		NetNamespace:   nsName,
+		VethName:       brVethName,
+		VethPeerIfName: brInIfName,
+		Subnet:         subnet.subnet,
+		IPRange:        ipRange,
+		StaticEntries:  staticEntries,
+		GatewayIP:      gatewayIP,
+		DomainName:     dhcp.DomainName,
+		DNSServers:     subnetDNSServers,
+		NTPServer:      ntpServer,
+		WPAD:           wpad,
+		Netboot:        netboot,
+	}, nil)
+	if subnet.ipv6 {
+		graph.PutItem(configitems.RouterAdvertisement{
+			ServerName:          network.LogicalLabel,
+			NetNamespace:        nsName,
+			VethName:            brVethName,
+			VethPeerIfName:      brInIfName,
+			Subnet:              subnet.subnet,
+			SLAAC:               dhcp.IPv6AddrAssignment == api.IPv6SLAAC,
+			ManagedFlag:         dhcp.IPv6AddrAssignment == api.IPv6DHCP,
+			OtherConfigFlag:     true,
+			WithoutDefaultRoute: dhcp.WithoutDefaultRoute,
+			DNSServers:          subnetDNSServers,
+			DomainName:          dhcp.DomainName,
+			MTU:                 network.MTU,
+		}, nil)
+	}
+}
+
 func (a *agent) getIntendedFirewall() dg.Graph {
 	graphArgs := dg.InitArgs{Name: firewallSG}
 	intendedCfg := dg.New(graphArgs)
+	a.putFirewallChains(intendedCfg, false)
+	if a.withIPv6() {
+		a.putFirewallChains(intendedCfg, true)
+	}
+	return intendedCfg
+}
+
+// withIPv6 returns true if any network or endpoint uses IPv6.
+func (a *agent) withIPv6() bool {
+	for _, network := range a.netModel.Networks {
+		if a.networkHasIPv6(network) {
+			return true
+		}
+	}
+	for _, ep := range a.netModel.Endpoints.GetAll() {
+		if ip := net.ParseIP(ep.IP); ip != nil && ip.To4() == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// putFirewallChains puts iptables (or ip6tables) chains implementing firewall
+// into the graph.
+func (a *agent) putFirewallChains(graph dg.Graph, ipv6 bool) {
 	iptablesRules := make([]configitems.IptablesRule, 0, 2+len(a.netModel.Firewall.Rules))
+	// Drop traffic blackholed by chaos scenario (even for established connections).
+	iptablesRules = append(iptablesRules, a.getChaosBlackholeRules(ipv6)...)
 	// Allow any subsequent traffic that results from an already allowed connection.
 	iptablesRules = append(iptablesRules, configitems.IptablesRule{
 		Args: []string{"-m", "conntrack", "--ctstate", "ESTABLISHED,RELATED", "-j", "ACCEPT"},
 	})
 	// Add explicitly configured firewall rules.
 	for _, rule := range a.netModel.Firewall.Rules {
-		iptablesRules = append(iptablesRules, a.getIntendedFwRule(rule))
+		if !fwRuleMatchesIPVersion(rule, ipv6) {
+			continue
+		}
+		iptablesRules = append(iptablesRules, a.getIntendedFwRule(rule, ipv6))
 	}
 	// Implicitly allow everything not matched by the rules above.
 	allowTheRest := api.FwRule{Action: api.FwAllow}
-	iptablesRules = append(iptablesRules, a.getIntendedFwRule(allowTheRest))
-	intendedCfg.PutItem(configitems.IptablesChain{
+	iptablesRules = append(iptablesRules, a.getIntendedFwRule(allowTheRest, ipv6))
+	graph.PutItem(configitems.IptablesChain{
 		NetNamespace: configitems.MainNsName,
 		ChainName:    fwIptablesChain,
 		Table:        "filter",
-		ForIPv6:      false,
+		ForIPv6:      ipv6,
 		Rules:        iptablesRules,
 	}, nil)
 	// Link the firewall chain with every network and endpoint (outside) interface.
@@ -625,19 +859,44 @@ func (a *agent) getIntendedFirewall() dg.Graph {
 			Args: []string{"-i", epOutIfName, "-j", fwIptablesChain},
 		})
 	}
-	intendedCfg.PutItem(configitems.IptablesChain{
+	if !ipv6 {
+		for _, network := range a.netModel.Networks {
+			if a.isIPv6OnlyNetwork(network) {
+				// Apply firewall also to traffic translated by NAT64.
+				iptablesRules = append(iptablesRules, configitems.IptablesRule{
+					Args: []string{"-i", nat64TunIfName, "-j", fwIptablesChain},
+				})
+				break
+			}
+		}
+	}
+	graph.PutItem(configitems.IptablesChain{
 		NetNamespace: configitems.MainNsName,
 		ChainName:    "FORWARD",
 		Table:        "filter",
-		ForIPv6:      false,
+		ForIPv6:      ipv6,
 		Rules:        iptablesRules,
 		RefersVeths:  veths,
 		RefersChains: []string{fwIptablesChain},
 	}, nil)
-	return intendedCfg
 }
 
-func (a *agent) getIntendedFwRule(rule api.FwRule) configitems.IptablesRule {
+// fwRuleMatchesIPVersion returns false if the rule matches subnets
+// of the other IP version.
+func fwRuleMatchesIPVersion(rule api.FwRule, ipv6 bool) bool {
+	for _, subnet := range []string{rule.SrcSubnet, rule.DstSubnet} {
+		if subnet == "" {
+			continue
+		}
+		ip, _, _ := net.ParseCIDR(subnet) // already validated
+		if (ip.To4() == nil) != ipv6 {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *agent) getIntendedFwRule(rule api.FwRule, ipv6 bool) configitems.IptablesRule {
 	var ruleArgs []string
 	if rule.SrcSubnet != "" {
 		ruleArgs = append(ruleArgs, "-s", rule.SrcSubnet)
@@ -649,7 +908,11 @@ func (a *agent) getIntendedFwRule(rule api.FwRule) configitems.IptablesRule {
 	case api.AnyProto:
 		ruleArgs = append(ruleArgs, "-p", "all")
 	case api.ICMP:
-		ruleArgs = append(ruleArgs, "-p", "icmp")
+		if ipv6 {
+			ruleArgs = append(ruleArgs, "-p", "ipv6-icmp")
+		} else {
+			ruleArgs = append(ruleArgs, "-p", "icmp")
+		}
 	case api.TCP:
 		ruleArgs = append(ruleArgs, "-p", "tcp")
 	case api.UDP:
@@ -698,17 +961,10 @@ func (a *agent) getIntendedDNSSrvEp(dnsSrv api.DNSServer) dg.Graph {
 		upstreamServers = append(upstreamServers, net.ParseIP(upstreamServer))
 	}
 	for _, staticEntry := range dnsSrv.StaticEntries {
-		var fqdn string
 		var ip net.IP
 		switch {
-		case strings.HasPrefix(staticEntry.FQDN, api.EndpointFQDNRefPrefix):
-			epLL := strings.TrimPrefix(staticEntry.FQDN, api.EndpointFQDNRefPrefix)
-			ep := a.getEndpoint(epLL)
-			fqdn = ep.FQDN
-		default:
-			fqdn = staticEntry.FQDN
-		}
-		switch {
+		case staticEntry.IP == "":
+			// Not an A/AAAA record.
 		case staticEntry.IP == api.AdamIPRef:
 			ip = a.netModel.hostIP
 		case strings.HasPrefix(staticEntry.IP, api.EndpointIPRefPrefix):
@@ -719,8 +975,15 @@ func (a *agent) getIntendedDNSSrvEp(dnsSrv api.DNSServer) dg.Graph {
 			ip = net.ParseIP(staticEntry.IP)
 		}
 		staticEntries = append(staticEntries, configitems.DnsEntry{
-			FQDN: fqdn,
-			IP:   ip,
+			FQDN:     a.resolveFQDNRef(staticEntry.FQDN),
+			Type:     staticEntry.Type,
+			IP:       ip,
+			Target:   a.resolveFQDNRef(staticEntry.Target),
+			Port:     staticEntry.Port,
+			Priority: staticEntry.Priority,
+			Weight:   staticEntry.Weight,
+			Text:     staticEntry.Text,
+			TTL:      staticEntry.TTL,
 		})
 	}
 	intendedCfg.PutItem(configitems.DnsServer{
@@ -730,10 +993,32 @@ func (a *agent) getIntendedDNSSrvEp(dnsSrv api.DNSServer) dg.Graph {
 		VethPeerIfName:  inIfName,
 		StaticEntries:   staticEntries,
 		UpstreamServers: upstreamServers,
+		TTL:             dnsSrv.TTL,
+		BehindProxy:     len(dnsSrv.Faults) > 0,
 	}, nil)
+	if len(dnsSrv.Faults) > 0 {
+		intendedCfg.PutItem(configitems.DnsFaultProxy{
+			ProxyName:      dnsSrv.LogicalLabel,
+			NetNamespace:   nsName,
+			VethName:       vethName,
+			ListenIP:       net.ParseIP(dnsSrv.IP),
+			UpstreamServer: configitems.DnsServerBehindProxyAddr,
+			Faults:         dnsSrv.Faults,
+		}, nil)
+	}
 	return intendedCfg
 }
 
+// resolveFQDNRef translates reference to endpoint FQDN (if used) to the actual FQDN.
+func (a *agent) resolveFQDNRef(fqdn string) string {
+	if strings.HasPrefix(fqdn, api.EndpointFQDNRefPrefix) {
+		epLL := strings.TrimPrefix(fqdn, api.EndpointFQDNRefPrefix)
+		ep := a.getEndpoint(epLL)
+		return ep.FQDN
+	}
+	return fqdn
+}
+
 func (a *agent) getIntendedExProxyEp(proxy api.ExplicitProxy) dg.Graph {
 	graphArgs := dg.InitArgs{Name: endpointSGPrefix + proxy.LogicalLabel}
 	intendedCfg := dg.New(graphArgs)
@@ -793,19 +1078,106 @@ func (a *agent) getIntendedHttpSrvEp(httpSrv api.HTTPServer) dg.Graph {
 	vethName, _, _ := a.endpointVethName(httpSrv.LogicalLabel)
 	epIP := net.ParseIP(httpSrv.IP)
 	intendedCfg.PutItem(configitems.HttpServer{
-		ServerName:   httpSrv.LogicalLabel,
-		NetNamespace: nsName,
-		VethName:     vethName,
-		ListenIP:     epIP,
-		HTTPPort:     httpSrv.HTTPPort,
-		HTTPSPort:    httpSrv.HTTPSPort,
-		CertPEM:      httpSrv.CertPEM,
-		KeyPEM:       httpSrv.KeyPEM,
-		Paths:        httpSrv.Paths,
+		ServerName:    httpSrv.LogicalLabel,
+		NetNamespace:  nsName,
+		VethName:      vethName,
+		ListenIP:      epIP,
+		HTTPPort:      httpSrv.HTTPPort,
+		HTTPSPort:     httpSrv.HTTPSPort,
+		CertPEM:       httpSrv.CertPEM,
+		KeyPEM:        httpSrv.KeyPEM,
+		CACertPEM:     httpSrv.CACertPEM,
+		CAKeyPEM:      httpSrv.CAKeyPEM,
+		TLSFaultPorts: httpSrv.TLSFaultPorts,
+		Paths:         httpSrv.Paths,
+	}, nil)
+	return intendedCfg
+}
+
+func (a *agent) getIntendedNetbootSrvEp(netbootSrv api.NetbootServer) dg.Graph {
+	graphArgs := dg.InitArgs{Name: endpointSGPrefix + netbootSrv.LogicalLabel}
+	intendedCfg := dg.New(graphArgs)
+	a.putEpCommonConfig(intendedCfg, netbootSrv.Endpoint, nil)
+	nsName := a.endpointNsName(netbootSrv.LogicalLabel)
+	vethName, _, _ := a.endpointVethName(netbootSrv.LogicalLabel)
+	epIP := net.ParseIP(netbootSrv.IP)
+	intendedCfg.PutItem(configitems.NetbootServer{
+		ServerName:    netbootSrv.LogicalLabel,
+		NetNamespace:  nsName,
+		VethName:      vethName,
+		ListenIP:      epIP,
+		TFTPArtifacts: netbootSrv.TFTPArtifacts,
+		HTTPArtifacts: netbootSrv.HTTPArtifacts,
+	}, nil)
+	return intendedCfg
+}
+
+func (a *agent) getIntendedNtpSrvEp(ntpSrv api.NTPServer) dg.Graph {
+	graphArgs := dg.InitArgs{Name: endpointSGPrefix + ntpSrv.LogicalLabel}
+	intendedCfg := dg.New(graphArgs)
+	a.putEpCommonConfig(intendedCfg, ntpSrv.Endpoint, &ntpSrv.DNSClientConfig)
+	nsName := a.endpointNsName(ntpSrv.LogicalLabel)
+	vethName, _, _ := a.endpointVethName(ntpSrv.LogicalLabel)
+	epIP := net.ParseIP(ntpSrv.IP)
+	intendedCfg.PutItem(configitems.NtpServer{
+		ServerName:      ntpSrv.LogicalLabel,
+		NetNamespace:    nsName,
+		VethName:        vethName,
+		ListenIP:        epIP,
+		UpstreamServers: ntpSrv.UpstreamServers,
+		FakeClock:       ntpSrv.FakeClock,
 	}, nil)
 	return intendedCfg
 }
 
+// getDhcpNetboot returns netboot configuration to announce with DHCP.
+// FQDN of the netboot server is announced only if one of the private DNS
+// servers is able to resolve it, otherwise IP address is used.
+func (a *agent) getDhcpNetboot(netbootSrvLL string, privateDNS []string) *configitems.DhcpNetboot {
+	item := a.netModel.items.getItem(api.Endpoint{}.ItemType(), netbootSrvLL)
+	netbootSrv := item.LabeledItem.(api.NetbootServer)
+	host := netbootSrv.IP
+	if netbootSrv.FQDN != "" && a.isResolvableByPrivateDNS(netbootSrv.Endpoint, privateDNS) {
+		host = netbootSrv.FQDN
+	}
+	netboot := &configitems.DhcpNetboot{
+		TFTPServer:   host,
+		TFTPServerIP: net.ParseIP(netbootSrv.IP),
+	}
+	for _, artifact := range netbootSrv.TFTPArtifacts {
+		if artifact.Entrypoint {
+			netboot.TFTPBootfile = artifact.Filename
+		}
+	}
+	for _, artifact := range netbootSrv.HTTPArtifacts {
+		if artifact.Entrypoint {
+			urlHost := host
+			if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+				urlHost = "[" + host + "]"
+			}
+			netboot.IPXEScriptURL = fmt.Sprintf("http://%s/%s", urlHost,
+				strings.TrimPrefix(artifact.Filename, "/"))
+		}
+	}
+	return netboot
+}
+
+// isResolvableByPrivateDNS returns true if any of the given DNS servers
+// has static entry for FQDN of the endpoint.
+func (a *agent) isResolvableByPrivateDNS(ep api.Endpoint, privateDNS []string) bool {
+	for _, dnsSrvLL := range privateDNS {
+		item := a.netModel.items.getItem(api.Endpoint{}.ItemType(), dnsSrvLL)
+		dnsSrv := item.LabeledItem.(api.DNSServer)
+		for _, entry := range dnsSrv.StaticEntries {
+			if entry.FQDN == ep.FQDN ||
+				entry.FQDN == api.EndpointFQDNRefPrefix+ep.LogicalLabel {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (a *agent) putEpCommonConfig(graph dg.Graph, ep api.Endpoint, dnsClient *api.DNSClientConfig) {
 	vethName, inIfName, outIfName := a.endpointVethName(ep.LogicalLabel)
 	_, subnet, _ := net.ParseCIDR(ep.Subnet) // already validated
@@ -861,6 +1233,72 @@ func (a *agent) putEpCommonConfig(graph dg.Graph, ep api.Endpoint, dnsClient *ap
 	}, nil)
 }
 
+// networkSubnet : IPv4 or IPv6 subnet of a network.
+type networkSubnet struct {
+	subnet *net.IPNet
+	gwIP   *net.IPNet
+	ipv6   bool
+}
+
+// getNetworkSubnets returns one subnet for a single-stack network
+// and two subnets (IPv4 + IPv6) for a dual-stack network.
+func (a *agent) getNetworkSubnets(network api.Network) (subnets []networkSubnet) {
+	// already validated
+	_, subnet, _ := net.ParseCIDR(network.Subnet)
+	subnets = append(subnets, networkSubnet{
+		subnet: subnet,
+		gwIP:   &net.IPNet{IP: net.ParseIP(network.GwIP), Mask: subnet.Mask},
+		ipv6:   len(subnet.IP) == net.IPv6len,
+	})
+	if network.IPv6Subnet != "" {
+		_, subnet, _ = net.ParseCIDR(network.IPv6Subnet)
+		subnets = append(subnets, networkSubnet{
+			subnet: subnet,
+			gwIP:   &net.IPNet{IP: net.ParseIP(network.IPv6GwIP), Mask: subnet.Mask},
+			ipv6:   true,
+		})
+	}
+	return subnets
+}
+
+// networkHasIPv6 returns true for IPv6-only and dual-stack network.
+func (a *agent) networkHasIPv6(network api.Network) bool {
+	for _, subnet := range a.getNetworkSubnets(network) {
+		if subnet.ipv6 {
+			return true
+		}
+	}
+	return false
+}
+
+// isIPv6OnlyNetwork returns true if network has no IPv4 subnet.
+// Such network uses DNS64 and NAT64 to access IPv4 destinations.
+func (a *agent) isIPv6OnlyNetwork(network api.Network) bool {
+	for _, subnet := range a.getNetworkSubnets(network) {
+		if !subnet.ipv6 {
+			return false
+		}
+	}
+	return true
+}
+
+// hostIPNet returns IPNet matching only the given IP address.
+func hostIPNet(ip net.IP) *net.IPNet {
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}
+
+// defaultDstNet returns IPNet matching all destinations of the same IP version
+// as the given IP address.
+func defaultDstNet(ip net.IP) *net.IPNet {
+	if ip.To4() != nil {
+		return allIPv4
+	}
+	return allIPv6
+}
+
 func (a *agent) bondIfName(logicalLabel string) string {
 	return a.genIfName("bond-", logicalLabel)
 }
@@ -928,6 +1366,8 @@ func (a *agent) labeledItemToEndpoint(item *labeledItem) api.Endpoint {
 		return item.LabeledItem.(api.TransparentProxy).Endpoint
 	case api.NetbootServer{}.ItemCategory():
 		return item.LabeledItem.(api.NetbootServer).Endpoint
+	case api.CaptivePortal{}.ItemCategory():
+		return item.LabeledItem.(api.CaptivePortal).Endpoint
 	default:
 		log.Fatalf("Unexpected endpoint category: %s", item.category)
 	}
@@ -46,7 +46,17 @@ func main() {
 	router.HandleFunc("/net-model.json", agent.applyNetModel).Methods("PUT")
 	router.HandleFunc("/net-config.gv", agent.getNetConfig).Methods("GET")
 	router.HandleFunc("/sdn-status.json", agent.getSDNStatus).Methods("GET")
-	// TODO: metrics?
+	router.HandleFunc("/chaos.json", agent.getChaosStatus).Methods("GET")
+	router.HandleFunc("/chaos.json", agent.runChaosScenario).Methods("PUT")
+	router.HandleFunc("/chaos.json", agent.stopChaosScenario).Methods("DELETE")
+	router.HandleFunc("/pcap.json", agent.listPacketCaptures).Methods("GET")
+	router.HandleFunc("/pcap.json", agent.startPacketCapture).Methods("POST")
+	router.HandleFunc("/pcap/{id}", agent.getPacketCapture).Methods("GET")
+	router.HandleFunc("/pcap/{id}", agent.removePacketCapture).Methods("DELETE")
+	router.HandleFunc("/pcap/{id}/stop", agent.stopPacketCapture).Methods("POST")
+	router.HandleFunc("/metrics", agent.getMetrics).Methods("GET")
+	router.HandleFunc("/captive-portal/{portal}/login", agent.loginCaptivePortal).Methods("POST")
+	router.HandleFunc("/captive-portal/{portal}/logout", agent.logoutCaptivePortal).Methods("POST")
 
 	srv := &http.Server{
 		Handler: router,
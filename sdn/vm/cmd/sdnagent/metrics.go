@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/lf-edge/eden/sdn/vm/api"
+	log "github.com/sirupsen/logrus"
+)
+
+// metricsWriter formats metrics in the Prometheus text exposition format.
+type metricsWriter struct {
+	buf bytes.Buffer
+}
+
+type metricType string
+
+const (
+	counter metricType = "counter"
+	gauge   metricType = "gauge"
+)
+
+// metricLabel : name-value pair identifying a metric sample.
+type metricLabel struct {
+	name, value string
+}
+
+// labelValueEscaper escapes label values as required by the text format
+// (only backslash, double-quote and line feed are escaped).
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// family starts a new metric family.
+func (w *metricsWriter) family(name string, typ metricType, help string) {
+	fmt.Fprintf(&w.buf, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(&w.buf, "# TYPE %s %s\n", name, typ)
+}
+
+// sample adds a sample to the current metric family.
+func (w *metricsWriter) sample(name string, value uint64, labels ...metricLabel) {
+	w.buf.WriteString(name)
+	if len(labels) > 0 {
+		var pairs []string
+		for _, label := range labels {
+			pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", label.name,
+				labelValueEscaper.Replace(label.value)))
+		}
+		w.buf.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+	fmt.Fprintf(&w.buf, " %d\n", value)
+}
+
+// writeIfCounters adds metric families with interface counters for all given labels.
+func (w *metricsWriter) writeIfCounters(prefix, labelName string,
+	counters map[string]api.IfCounters) {
+	keys := sortedKeys(counters)
+	for _, metric := range []struct {
+		suffix string
+		help   string
+		get    func(api.IfCounters) uint64
+	}{
+		{"rx_bytes_total", "Received bytes.",
+			func(c api.IfCounters) uint64 { return c.RxBytes }},
+		{"rx_packets_total", "Received packets.",
+			func(c api.IfCounters) uint64 { return c.RxPackets }},
+		{"rx_dropped_total", "Dropped received packets.",
+			func(c api.IfCounters) uint64 { return c.RxDropped }},
+		{"rx_errors_total", "Receive errors.",
+			func(c api.IfCounters) uint64 { return c.RxErrors }},
+		{"tx_bytes_total", "Transmitted bytes.",
+			func(c api.IfCounters) uint64 { return c.TxBytes }},
+		{"tx_packets_total", "Transmitted packets.",
+			func(c api.IfCounters) uint64 { return c.TxPackets }},
+		{"tx_dropped_total", "Dropped packets during transmission.",
+			func(c api.IfCounters) uint64 { return c.TxDropped }},
+		{"tx_errors_total", "Transmit errors.",
+			func(c api.IfCounters) uint64 { return c.TxErrors }},
+	} {
+		name := prefix + metric.suffix
+		w.family(name, counter, metric.help)
+		for _, key := range keys {
+			w.sample(name, metric.get(counters[key]), metricLabel{labelName, key})
+		}
+	}
+}
+
+func sortedKeys(m interface{}) (keys []string) {
+	switch v := m.(type) {
+	case map[string]api.IfCounters:
+		for key := range v {
+			keys = append(keys, key)
+		}
+	case map[string]uint64:
+		for key := range v {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeMetrics formats SDN status as metrics.
+func writeMetrics(status api.SDNStatus) []byte {
+	w := &metricsWriter{}
+	w.family("sdn_config_errors", gauge, "Number of failed configuration items.")
+	w.sample("sdn_config_errors", uint64(len(status.ConfigErrors)))
+
+	// Ports
+	portCounters := make(map[string]api.IfCounters)
+	for _, port := range status.Ports {
+		portCounters[port.LogicalLabel] = port.Counters
+	}
+	w.family("sdn_port_oper_up", gauge, "1 if the port is operationally up.")
+	for _, port := range status.Ports {
+		var up uint64
+		if port.OperUp {
+			up = 1
+		}
+		w.sample("sdn_port_oper_up", up, metricLabel{"port", port.LogicalLabel})
+	}
+	w.writeIfCounters("sdn_port_", "port", portCounters)
+	for _, metric := range []struct {
+		suffix string
+		typ    metricType
+		help   string
+		get    func(api.QdiscStats) uint64
+	}{
+		{"bytes_total", counter, "Bytes sent through the qdisc.",
+			func(q api.QdiscStats) uint64 { return q.Bytes }},
+		{"packets_total", counter, "Packets sent through the qdisc.",
+			func(q api.QdiscStats) uint64 { return q.Packets }},
+		{"drops_total", counter, "Packets dropped by the qdisc.",
+			func(q api.QdiscStats) uint64 { return q.Drops }},
+		{"overlimits_total", counter, "Overlimit events of the qdisc.",
+			func(q api.QdiscStats) uint64 { return q.Overlimits }},
+		{"requeues_total", counter, "Packets requeued by the qdisc.",
+			func(q api.QdiscStats) uint64 { return q.Requeues }},
+		{"backlog_bytes", gauge, "Bytes currently queued in the qdisc.",
+			func(q api.QdiscStats) uint64 { return q.Backlog }},
+		{"qlen", gauge, "Packets currently queued in the qdisc.",
+			func(q api.QdiscStats) uint64 { return q.Qlen }},
+	} {
+		name := "sdn_port_qdisc_" + metric.suffix
+		w.family(name, metric.typ, metric.help)
+		for _, port := range status.Ports {
+			for _, qdisc := range port.Qdiscs {
+				w.sample(name, metric.get(qdisc), metricLabel{"port", port.LogicalLabel},
+					metricLabel{"kind", qdisc.Kind}, metricLabel{"handle", qdisc.Handle})
+			}
+		}
+	}
+
+	// Networks
+	networkCounters := make(map[string]api.IfCounters)
+	for _, network := range status.Networks {
+		networkCounters[network.LogicalLabel] = network.Counters
+	}
+	w.writeIfCounters("sdn_network_", "network", networkCounters)
+	w.family("sdn_network_dhcp_leases", gauge, "Number of IP leases granted by DHCP.")
+	for _, network := range status.Networks {
+		w.sample("sdn_network_dhcp_leases", uint64(len(network.DHCPLeases)),
+			metricLabel{"network", network.LogicalLabel})
+	}
+
+	// DNS servers
+	w.family("sdn_dns_queries_total", counter, "DNS queries received by DNS server.")
+	for _, dnsSrv := range status.DNSServers {
+		for _, qtype := range sortedKeys(dnsSrv.QueriesByType) {
+			w.sample("sdn_dns_queries_total", dnsSrv.QueriesByType[qtype],
+				metricLabel{"endpoint", dnsSrv.LogicalLabel}, metricLabel{"type", qtype})
+		}
+	}
+
+	// Proxies
+	w.family("sdn_proxy_requests_total", counter, "Requests received by proxy.")
+	for _, proxy := range status.Proxies {
+		w.sample("sdn_proxy_requests_total", proxy.Requests,
+			metricLabel{"endpoint", proxy.LogicalLabel})
+	}
+
+	// Conntrack
+	w.family("sdn_conntrack_entries", gauge, "Number of tracked connections.")
+	for _, proto := range sortedKeys(status.Conntrack.EntriesByProto) {
+		w.sample("sdn_conntrack_entries", status.Conntrack.EntriesByProto[proto],
+			metricLabel{"proto", proto})
+	}
+	w.family("sdn_conntrack_packets", gauge,
+		"Packets of currently tracked connections (both directions).")
+	w.sample("sdn_conntrack_packets", status.Conntrack.Packets)
+	w.family("sdn_conntrack_bytes", gauge,
+		"Bytes of currently tracked connections (both directions).")
+	w.sample("sdn_conntrack_bytes", status.Conntrack.Bytes)
+	return w.buf.Bytes()
+}
+
+func (a *agent) getMetrics(w http.ResponseWriter, r *http.Request) {
+	status := a.getStatus()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(writeMetrics(status)); err != nil {
+		log.Errorf("Failed to write metrics to HTTP response: %v", err)
+	}
+}
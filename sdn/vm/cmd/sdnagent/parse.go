@@ -11,6 +11,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/url"
 	"reflect"
 	"strings"
 
@@ -20,7 +21,6 @@ import (
 
 const (
 	// Minimum accepted MTU value.
-	// Eventually, Eden-SDN will incorporate IPv6 support.
 	// As per RFC 8200, the MTU must not be less than 1280 bytes to accommodate IPv6 packets.
 	minMTU = 1280
 	// Maximum MTU supported by the e1000 driver (used for interfaces connecting
@@ -68,7 +68,8 @@ func (a *agent) parseNetModel(netModel api.NetworkModel) (parsedModel parsedNetM
 	eps := netModel.Endpoints
 	items := a.slicesToLabeledItems(netModel.Ports, netModel.Bonds, netModel.Bridges,
 		netModel.Networks, eps.DNSServers, eps.NTPServers, eps.NetbootServers,
-		eps.HTTPServers, eps.ExplicitProxies, eps.TransparentProxies, eps.Clients)
+		eps.HTTPServers, eps.ExplicitProxies, eps.TransparentProxies, eps.Clients,
+		eps.CaptivePortals)
 	parsedModel.items, err = a.parseLabeledItems(items)
 	if err != nil {
 		return
@@ -120,19 +121,22 @@ func (a *agent) validatePorts(netModel *parsedNetModel) (err error) {
 		}
 	}
 
-	// QueueLimit and BurstLimit are mandatory when RateLimit is set.
 	for _, port := range netModel.Ports {
-		if port.TC.RateLimit != 0 {
-			if port.TC.QueueLimit == 0 {
-				err = fmt.Errorf("RateLimit set for port %s without QueueLimit",
-					port.LogicalLabel)
-				return
-			}
-			if port.TC.BurstLimit == 0 {
-				err = fmt.Errorf("RateLimit set for port %s without BurstLimit",
-					port.LogicalLabel)
-				return
-			}
+		if err = a.validateTrafficControl(port.LogicalLabel, port.TC); err != nil {
+			return
+		}
+	}
+	return nil
+}
+
+func (a *agent) validateTrafficControl(portLL string, tc api.TrafficControl) error {
+	// QueueLimit and BurstLimit are mandatory when RateLimit is set.
+	if tc.RateLimit != 0 {
+		if tc.QueueLimit == 0 {
+			return fmt.Errorf("RateLimit set for port %s without QueueLimit", portLL)
+		}
+		if tc.BurstLimit == 0 {
+			return fmt.Errorf("RateLimit set for port %s without BurstLimit", portLL)
 		}
 	}
 	return nil
@@ -141,7 +145,8 @@ func (a *agent) validatePorts(netModel *parsedNetModel) (err error) {
 func (a *agent) validateNetworks(netModel *parsedNetModel) (err error) {
 	// Validate network Subnet, gateway IP and VLANs.
 	for _, network := range netModel.Networks {
-		if _, _, err = net.ParseCIDR(network.Subnet); err != nil {
+		var subnet *net.IPNet
+		if _, subnet, err = net.ParseCIDR(network.Subnet); err != nil {
 			err = fmt.Errorf("network %s has invalid subnet: %w",
 				network.LogicalLabel, err)
 			return
@@ -151,6 +156,32 @@ func (a *agent) validateNetworks(netModel *parsedNetModel) (err error) {
 				network.LogicalLabel, network.GwIP)
 			return
 		}
+		if network.IPv6Subnet == "" {
+			continue
+		}
+		// Dual-stack network.
+		if len(subnet.IP) != net.IPv4len {
+			err = fmt.Errorf("dual-stack network %s should have IPv4 subnet "+
+				"configured as Subnet", network.LogicalLabel)
+			return
+		}
+		var ipv6Subnet *net.IPNet
+		if _, ipv6Subnet, err = net.ParseCIDR(network.IPv6Subnet); err != nil {
+			err = fmt.Errorf("network %s has invalid IPv6 subnet: %w",
+				network.LogicalLabel, err)
+			return
+		}
+		if len(ipv6Subnet.IP) != net.IPv6len {
+			err = fmt.Errorf("network %s has IPv6Subnet which is not IPv6 (%s)",
+				network.LogicalLabel, network.IPv6Subnet)
+			return
+		}
+		gwIP := net.ParseIP(network.IPv6GwIP)
+		if gwIP == nil || !ipv6Subnet.Contains(gwIP) {
+			err = fmt.Errorf("network %s has invalid IPv6 gateway IP (%s)",
+				network.LogicalLabel, network.IPv6GwIP)
+			return
+		}
 	}
 
 	// Validate DHCP config.
@@ -160,6 +191,48 @@ func (a *agent) validateNetworks(netModel *parsedNetModel) (err error) {
 		if !dhcp.Enable {
 			continue
 		}
+		ipv6Subnet := subnet
+		if network.IPv6Subnet != "" {
+			_, ipv6Subnet, _ = net.ParseCIDR(network.IPv6Subnet)
+		} else if len(subnet.IP) != net.IPv6len {
+			ipv6Subnet = nil
+		}
+		if ipv6Subnet == subnet && dhcp.IPRange.FromIP != "" {
+			err = fmt.Errorf("network %s has DHCP IP range configured for IPv6 subnet "+
+				"(use IPv6Range instead)", network.LogicalLabel)
+			return
+		}
+		if ipv6Subnet != nil && dhcp.IPv6AddrAssignment == api.IPv6SLAAC {
+			if ones, _ := ipv6Subnet.Mask.Size(); ones != 64 {
+				err = fmt.Errorf("network %s uses SLAAC with IPv6 subnet "+
+					"prefix length other than 64", network.LogicalLabel)
+				return
+			}
+		}
+		if dhcp.IPv6Range.FromIP != "" {
+			if ipv6Subnet == nil || dhcp.IPv6AddrAssignment != api.IPv6DHCP {
+				err = fmt.Errorf("network %s has DHCP IPv6 range configured "+
+					"but does not use stateful DHCPv6", network.LogicalLabel)
+				return
+			}
+			fromIP := net.ParseIP(dhcp.IPv6Range.FromIP)
+			toIP := net.ParseIP(dhcp.IPv6Range.ToIP)
+			if fromIP == nil || toIP == nil {
+				err = fmt.Errorf("network %s has invalid DHCP IPv6 range (%+v)",
+					network.LogicalLabel, dhcp.IPv6Range)
+				return
+			}
+			if !ipv6Subnet.Contains(fromIP) || !ipv6Subnet.Contains(toIP) {
+				err = fmt.Errorf("network %s has DHCP IPv6 range outside of the subnet",
+					network.LogicalLabel)
+				return
+			}
+			if bytes.Compare(fromIP, toIP) > 0 {
+				err = fmt.Errorf("network %s has DHCP IPv6 range where FromIP > ToIP",
+					network.LogicalLabel)
+				return
+			}
+		}
 		if dhcp.IPRange.FromIP != "" {
 			fromIP := net.ParseIP(dhcp.IPRange.FromIP)
 			if fromIP == nil {
@@ -202,11 +275,17 @@ func (a *agent) validateNetworks(netModel *parsedNetModel) (err error) {
 					network.LogicalLabel, entry.MAC)
 				return
 			}
-			if ip := net.ParseIP(entry.IP); ip == nil {
+			ip := net.ParseIP(entry.IP)
+			if ip == nil {
 				err = fmt.Errorf("network %s has static DHCP entry with invalid IP (%s)",
 					network.LogicalLabel, entry.IP)
 				return
 			}
+			if !subnet.Contains(ip) && (ipv6Subnet == nil || !ipv6Subnet.Contains(ip)) {
+				err = fmt.Errorf("network %s has static DHCP entry with IP (%s) "+
+					"outside of the network subnet(s)", network.LogicalLabel, entry.IP)
+				return
+			}
 		}
 	}
 
@@ -273,15 +352,10 @@ func (a *agent) validateNetworks(netModel *parsedNetModel) (err error) {
 			return
 		}
 	}
-
-	// TODO: check that within a network it is IPv4 or IPv6, not both (for now)
 	return nil
 }
 
 func (a *agent) validateEndpoints(netModel *parsedNetModel) (err error) {
-	// TODO
-	//  - NetbootArtifacts:
-	//      - exactly one is entrypoint, Filename and URL are non-empty
 	for _, client := range netModel.Endpoints.Clients {
 		if err = a.validateEndpoint(client.Endpoint); err != nil {
 			return
@@ -299,22 +373,12 @@ func (a *agent) validateEndpoints(netModel *parsedNetModel) (err error) {
 			}
 		}
 		for _, entry := range dnsSrv.StaticEntries {
-			if entry.FQDN == "" {
-				err = fmt.Errorf("DNS server %s has static entry with empty FQDN",
-					dnsSrv.LogicalLabel)
+			if err = a.validateDNSEntry(dnsSrv.LogicalLabel, entry); err != nil {
 				return
 			}
-			if strings.HasPrefix(entry.IP, api.EndpointIPRefPrefix) ||
-				strings.HasPrefix(entry.IP, api.AdamIPRef) {
-				// Do not try to parse IP, it is a symbolic reference.
-				continue
-			}
-			if ip := net.ParseIP(entry.IP); ip == nil {
-				err = fmt.Errorf("DNS server %s has invalid static entry IP (%s)",
-					dnsSrv.LogicalLabel, entry.IP)
-				return
-			}
-
+		}
+		if err = a.validateDNSFaults(dnsSrv); err != nil {
+			return
 		}
 	}
 	for _, proxy := range netModel.Endpoints.ExplicitProxies {
@@ -386,6 +450,42 @@ func (a *agent) validateEndpoints(netModel *parsedNetModel) (err error) {
 			ruleHosts[rule.ReqHost] = struct{}{}
 		}
 	}
+	for _, portal := range netModel.Endpoints.CaptivePortals {
+		if err = a.validateEndpoint(portal.Endpoint); err != nil {
+			return
+		}
+		if portal.CertPEM != "" {
+			if err = a.validateCertPEM(portal.CertPEM, portal.KeyPEM, false); err != nil {
+				return
+			}
+		}
+		for _, dst := range portal.WalledGarden {
+			if dst == api.AdamIPRef || net.ParseIP(dst) != nil {
+				continue
+			}
+			if _, _, cidrErr := net.ParseCIDR(dst); cidrErr != nil {
+				err = fmt.Errorf("captive portal %s has invalid walled garden "+
+					"destination (%s)", portal.LogicalLabel, dst)
+				return
+			}
+		}
+	}
+	for _, network := range netModel.Networks {
+		if network.CaptivePortal == "" {
+			continue
+		}
+		if network.TransparentProxy != "" {
+			err = fmt.Errorf("network %s cannot use both transparent proxy "+
+				"and captive portal", network.LogicalLabel)
+			return
+		}
+		if network.Router != nil &&
+			!strListContains(network.Router.ReachableEndpoints, network.CaptivePortal) {
+			err = fmt.Errorf("captive portal %s is not reachable from network %s",
+				network.CaptivePortal, network.LogicalLabel)
+			return
+		}
+	}
 	for _, httpSrv := range netModel.Endpoints.HTTPServers {
 		if err = a.validateEndpoint(httpSrv.Endpoint); err != nil {
 			return
@@ -406,21 +506,105 @@ func (a *agent) validateEndpoints(netModel *parsedNetModel) (err error) {
 			if err = a.validateCertPEM(httpSrv.CertPEM, httpSrv.KeyPEM, false); err != nil {
 				return
 			}
-		} else if httpSrv.HTTPSPort != 0 {
+		} else if httpSrv.HTTPSPort != 0 || len(httpSrv.TLSFaultPorts) > 0 {
 			err = fmt.Errorf("HTTPS server %s without certificate",
 				httpSrv.LogicalLabel)
 			return
 		}
+		if httpSrv.CACertPEM != "" {
+			if err = a.validateCertPEM(httpSrv.CACertPEM, httpSrv.CAKeyPEM, true); err != nil {
+				return
+			}
+		}
+		if err = a.validateHTTPFaults(httpSrv); err != nil {
+			return
+		}
 	}
 	for _, netbootSrv := range netModel.Endpoints.NetbootServers {
 		if err = a.validateEndpoint(netbootSrv.Endpoint); err != nil {
 			return
 		}
+		if len(netbootSrv.TFTPArtifacts) == 0 && len(netbootSrv.HTTPArtifacts) == 0 {
+			err = fmt.Errorf("netboot server %s without artifacts",
+				netbootSrv.LogicalLabel)
+			return
+		}
+		err = a.validateNetbootArtifacts(netbootSrv.LogicalLabel, "TFTP",
+			netbootSrv.TFTPArtifacts)
+		if err != nil {
+			return
+		}
+		err = a.validateNetbootArtifacts(netbootSrv.LogicalLabel, "HTTP",
+			netbootSrv.HTTPArtifacts)
+		if err != nil {
+			return
+		}
 	}
 	for _, ntpSrv := range netModel.Endpoints.NTPServers {
 		if err = a.validateEndpoint(ntpSrv.Endpoint); err != nil {
 			return
 		}
+		for _, dns := range ntpSrv.PublicDNS {
+			if dnsIP := net.ParseIP(dns); dnsIP == nil {
+				err = fmt.Errorf("NTP server %s has invalid public DNS server IP (%s)",
+					ntpSrv.LogicalLabel, dns)
+				return
+			}
+		}
+		for _, server := range ntpSrv.UpstreamServers {
+			if server == "" {
+				err = fmt.Errorf("NTP server %s with empty upstream server entry",
+					ntpSrv.LogicalLabel)
+				return
+			}
+			if net.ParseIP(server) == nil && len(ntpSrv.PrivateDNS) == 0 &&
+				len(ntpSrv.PublicDNS) == 0 {
+				err = fmt.Errorf("NTP server %s references upstream server %s by FQDN "+
+					"but has no DNS server configured", ntpSrv.LogicalLabel, server)
+				return
+			}
+		}
+		if fakeClock := ntpSrv.FakeClock; fakeClock != nil {
+			// Drift of -100% or less would stop (or reverse) the clock.
+			if fakeClock.DriftPPM <= -1000000 {
+				err = fmt.Errorf("NTP server %s with invalid fake clock drift (%d PPM)",
+					ntpSrv.LogicalLabel, fakeClock.DriftPPM)
+				return
+			}
+		}
+	}
+	return nil
+}
+
+func (a *agent) validateNetbootArtifacts(netbootSrv, proto string,
+	artifacts []api.NetbootArtifact) (err error) {
+	if len(artifacts) == 0 {
+		return nil
+	}
+	var entrypoints int
+	filenames := make(map[string]struct{})
+	for _, artifact := range artifacts {
+		if artifact.Filename == "" {
+			return fmt.Errorf("netboot server %s has %s artifact without filename",
+				netbootSrv, proto)
+		}
+		if _, duplicate := filenames[artifact.Filename]; duplicate {
+			return fmt.Errorf("netboot server %s has duplicate %s artifact %s",
+				netbootSrv, proto, artifact.Filename)
+		}
+		filenames[artifact.Filename] = struct{}{}
+		downloadURL, parseErr := url.Parse(artifact.DownloadFromURL)
+		if parseErr != nil || (downloadURL.Scheme != "http" && downloadURL.Scheme != "https") {
+			return fmt.Errorf("netboot server %s has %s artifact %s with invalid URL (%s)",
+				netbootSrv, proto, artifact.Filename, artifact.DownloadFromURL)
+		}
+		if artifact.Entrypoint {
+			entrypoints++
+		}
+	}
+	if entrypoints != 1 {
+		return fmt.Errorf("netboot server %s should have exactly one %s entrypoint, found %d",
+			netbootSrv, proto, entrypoints)
 	}
 	return nil
 }
@@ -522,6 +706,120 @@ func (a *agent) validateHostConfig(netModel *parsedNetModel) (err error) {
 	return nil
 }
 
+func (a *agent) validateHTTPFaults(httpSrv api.HTTPServer) error {
+	usedPorts := make(map[uint16]struct{})
+	for _, port := range []uint16{httpSrv.HTTPPort, httpSrv.HTTPSPort} {
+		if port != 0 {
+			usedPorts[port] = struct{}{}
+		}
+	}
+	for fault, port := range httpSrv.TLSFaultPorts {
+		switch fault {
+		case api.TLSCertExpired, api.TLSCertSelfSigned:
+		default:
+			return fmt.Errorf("HTTP server %s with unknown TLS certificate fault: %s",
+				httpSrv.LogicalLabel, fault)
+		}
+		if port == 0 {
+			return fmt.Errorf("HTTP server %s without port number for TLS certificate fault %s",
+				httpSrv.LogicalLabel, fault)
+		}
+		if _, duplicate := usedPorts[port]; duplicate {
+			return fmt.Errorf("HTTP server %s with colliding ports", httpSrv.LogicalLabel)
+		}
+		usedPorts[port] = struct{}{}
+	}
+	for path, content := range httpSrv.Paths {
+		faults := content.Faults
+		if faults == nil {
+			continue
+		}
+		if faults.StatusCode != 0 && (faults.StatusCode < 100 || faults.StatusCode > 599) {
+			return fmt.Errorf("HTTP server %s with invalid status code for path %s: %d",
+				httpSrv.LogicalLabel, path, faults.StatusCode)
+		}
+		if faults.TLSCert != "" {
+			if _, hasPort := httpSrv.TLSFaultPorts[faults.TLSCert]; !hasPort {
+				return fmt.Errorf("HTTP server %s without port for TLS certificate "+
+					"fault %s (used by path %s)", httpSrv.LogicalLabel, faults.TLSCert, path)
+			}
+		}
+	}
+	return nil
+}
+
+func (a *agent) validateDNSEntry(dnsSrvLL string, entry api.DNSEntry) error {
+	if entry.FQDN == "" {
+		return fmt.Errorf("DNS server %s has static entry with empty FQDN", dnsSrvLL)
+	}
+	switch entry.Type {
+	case "", api.DNSRecordA, api.DNSRecordAAAA:
+		if strings.HasPrefix(entry.IP, api.EndpointIPRefPrefix) ||
+			strings.HasPrefix(entry.IP, api.AdamIPRef) {
+			// Do not try to parse IP, it is a symbolic reference.
+			return nil
+		}
+		ip := net.ParseIP(entry.IP)
+		if ip == nil {
+			return fmt.Errorf("DNS server %s has invalid static entry IP (%s)",
+				dnsSrvLL, entry.IP)
+		}
+		if (entry.Type == api.DNSRecordA && ip.To4() == nil) ||
+			(entry.Type == api.DNSRecordAAAA && ip.To4() != nil) {
+			return fmt.Errorf("DNS server %s has %s static entry %s with IP of wrong "+
+				"version (%s)", dnsSrvLL, entry.Type, entry.FQDN, entry.IP)
+		}
+		return nil
+	case api.DNSRecordCNAME, api.DNSRecordSRV:
+		if entry.Target == "" {
+			return fmt.Errorf("DNS server %s has %s static entry %s without target",
+				dnsSrvLL, entry.Type, entry.FQDN)
+		}
+		if entry.Type == api.DNSRecordSRV && entry.Port == 0 {
+			return fmt.Errorf("DNS server %s has SRV static entry %s without port",
+				dnsSrvLL, entry.FQDN)
+		}
+	case api.DNSRecordTXT:
+		if len(entry.Text) == 0 {
+			return fmt.Errorf("DNS server %s has TXT static entry %s without text",
+				dnsSrvLL, entry.FQDN)
+		}
+	default:
+		return fmt.Errorf("DNS server %s has static entry %s with unknown record type: %s",
+			dnsSrvLL, entry.FQDN, entry.Type)
+	}
+	if entry.IP != "" {
+		return fmt.Errorf("DNS server %s has %s static entry %s with IP address",
+			dnsSrvLL, entry.Type, entry.FQDN)
+	}
+	if entry.TTL != 0 && entry.Type != api.DNSRecordCNAME {
+		return fmt.Errorf("DNS server %s has %s static entry %s with unsupported TTL",
+			dnsSrvLL, entry.Type, entry.FQDN)
+	}
+	return nil
+}
+
+func (a *agent) validateDNSFaults(dnsSrv api.DNSServer) error {
+	for _, fault := range dnsSrv.Faults {
+		switch fault.Response {
+		case "", api.DNSFaultServFail, api.DNSFaultNXDomain, api.DNSFaultTimeout,
+			api.DNSFaultTruncated:
+		default:
+			return fmt.Errorf("DNS server %s with unknown fault response: %s",
+				dnsSrv.LogicalLabel, fault.Response)
+		}
+		if fault.Delay < 0 || fault.DelayJitter < 0 {
+			return fmt.Errorf("DNS server %s with negative fault delay",
+				dnsSrv.LogicalLabel)
+		}
+		if fault.Response == "" && fault.Delay == 0 && fault.DelayJitter == 0 {
+			return fmt.Errorf("DNS server %s with empty fault (FQDN: %s)",
+				dnsSrv.LogicalLabel, fault.FQDN)
+		}
+	}
+	return nil
+}
+
 func (a *agent) validateCertPEM(certPem, keyPem string, isCA bool) error {
 	// Check that certificate can be parsed.
 	block, _ := pem.Decode([]byte(certPem))
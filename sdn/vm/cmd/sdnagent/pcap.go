@@ -0,0 +1,420 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/lf-edge/eden/sdn/vm/pkg/configitems"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	pcapDir = "/run/pcap"
+	// How often to check the size of a pcap file.
+	pcapSizeCheckPeriod = time.Second
+	// How long to wait for tcpdump to start capturing.
+	pcapStartTimeout = 3 * time.Second
+	// How long to wait for tcpdump to flush packets and exit when stopped.
+	pcapStopTimeout = 5 * time.Second
+)
+
+// pcapRun : packet capture started by the agent.
+type pcapRun struct {
+	status api.PacketCaptureStatus
+	cmd    *exec.Cmd
+	stderr bytes.Buffer
+	// Used to request capture to stop (with the reason).
+	stop chan string
+	// Closed when tcpdump has exited and the status was finalized.
+	done chan struct{}
+}
+
+// startPcap starts tcpdump for a validated capture request.
+// Agent is locked only to prepare and register the capture, not while waiting
+// for tcpdump to start capturing.
+// Called with agent in unlocked state.
+func (a *agent) startPcap(capture api.PacketCapture) (*pcapRun, error) {
+	a.Lock()
+	run, err := a.preparePcap(capture)
+	a.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	exited, err := run.startTcpdump()
+	if err != nil {
+		return nil, err
+	}
+	a.Lock()
+	a.captures[run.status.ID] = run
+	a.Unlock()
+	go a.watchPcap(run, exited)
+	return run, nil
+}
+
+// preparePcap prepares tcpdump command for a validated capture request.
+// Called with agent in locked state.
+func (a *agent) preparePcap(capture api.PacketCapture) (*pcapRun, error) {
+	netNs, ifName, label, err := a.getPcapTarget(capture)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(pcapDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", pcapDir, err)
+	}
+	a.pcapCounter++
+	id := fmt.Sprintf("%s-%d", label, a.pcapCounter)
+	pcapFile := filepath.Join(pcapDir, id+".pcap")
+	args := []string{"-i", ifName, "-n", "-U", "-w", pcapFile}
+	if capture.SnapLen != 0 {
+		args = append(args, "-s", fmt.Sprintf("%d", capture.SnapLen))
+	}
+	if capture.MaxPackets != 0 {
+		args = append(args, "-c", fmt.Sprintf("%d", capture.MaxPackets))
+	}
+	if capture.Filter != "" {
+		args = append(args, capture.Filter)
+	}
+	var cmd *exec.Cmd
+	if netNs == configitems.MainNsName {
+		cmd = exec.Command("tcpdump", args...)
+	} else {
+		// "ip netns exec" execs tcpdump, signals are therefore delivered directly.
+		cmd = exec.Command("ip", append([]string{"netns", "exec", netNs, "tcpdump"},
+			args...)...)
+	}
+	run := &pcapRun{
+		status: api.PacketCaptureStatus{
+			ID:           id,
+			Request:      capture,
+			NetNamespace: netNs,
+			Interface:    ifName,
+			File:         pcapFile,
+			StartedAt:    time.Now(),
+			Running:      true,
+		},
+		cmd:  cmd,
+		stop: make(chan string, 1),
+		done: make(chan struct{}),
+	}
+	cmd.Stderr = &run.stderr
+	return run, nil
+}
+
+// startTcpdump starts tcpdump and waits until it starts capturing.
+// Returned channel receives the result of tcpdump once it exits.
+func (run *pcapRun) startTcpdump() (<-chan error, error) {
+	cmd, id, pcapFile := run.cmd, run.status.ID, run.status.File
+	log.Infof("Starting packet capture %s: %s", id, cmd)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start tcpdump: %w", err)
+	}
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+	// tcpdump creates the pcap file once it starts capturing.
+	startTime := time.Now()
+	for {
+		if _, err := os.Stat(pcapFile); err == nil {
+			break
+		}
+		select {
+		case err := <-exited:
+			_ = os.Remove(pcapFile)
+			return nil, fmt.Errorf("tcpdump failed to start (%v): %s",
+				err, strings.TrimSpace(run.stderr.String()))
+		case <-time.After(100 * time.Millisecond):
+		}
+		if time.Since(startTime) > pcapStartTimeout {
+			_ = cmd.Process.Kill()
+			<-exited
+			_ = os.Remove(pcapFile)
+			return nil, fmt.Errorf("tcpdump failed to start in time: %s",
+				strings.TrimSpace(run.stderr.String()))
+		}
+	}
+	return exited, nil
+}
+
+// watchPcap enforces capture limits, stops tcpdump when requested
+// and finalizes capture status once tcpdump exits.
+func (a *agent) watchPcap(run *pcapRun, exited <-chan error) {
+	ticker := time.NewTicker(pcapSizeCheckPeriod)
+	defer ticker.Stop()
+	var timeLimit <-chan time.Time
+	if run.status.Request.Duration != 0 {
+		timer := time.NewTimer(time.Duration(run.status.Request.Duration))
+		defer timer.Stop()
+		timeLimit = timer.C
+	}
+	maxSize := run.status.Request.MaxSize
+	if maxSize == 0 {
+		maxSize = api.DefaultPcapMaxSize
+	}
+	var stopReason string
+	var waitErr error
+	terminated := false
+	for !terminated {
+		select {
+		case waitErr = <-exited:
+			terminated = true
+		case stopReason = <-run.stop:
+			waitErr, terminated = a.terminatePcap(run, exited), true
+		case <-timeLimit:
+			stopReason = "duration elapsed"
+			waitErr, terminated = a.terminatePcap(run, exited), true
+		case <-ticker.C:
+			if pcapFileSize(run.status.File) >= maxSize {
+				stopReason = "size limit reached"
+				waitErr, terminated = a.terminatePcap(run, exited), true
+			}
+		}
+	}
+	a.Lock()
+	defer a.Unlock()
+	run.status.Running = false
+	run.status.StoppedAt = time.Now()
+	run.status.Size = pcapFileSize(run.status.File)
+	switch {
+	case stopReason != "":
+		run.status.StopReason = stopReason
+	case waitErr == nil && run.status.Request.MaxPackets != 0:
+		run.status.StopReason = "packet count limit reached"
+	default:
+		run.status.StopReason = "tcpdump exited"
+		if waitErr != nil {
+			run.status.Error = fmt.Sprintf("%v: %s", waitErr,
+				strings.TrimSpace(run.stderr.String()))
+		}
+	}
+	log.Infof("Packet capture %s stopped (%s), captured %d bytes",
+		run.status.ID, run.status.StopReason, run.status.Size)
+	close(run.done)
+}
+
+// terminatePcap asks tcpdump to flush captured packets and exit.
+// Tcpdump is killed if it does not exit in time.
+func (a *agent) terminatePcap(run *pcapRun, exited <-chan error) error {
+	if err := run.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		log.Warnf("Failed to send SIGTERM to tcpdump: %v", err)
+	}
+	select {
+	case <-exited:
+		// Exit status after SIGTERM is not an error.
+		return nil
+	case <-time.After(pcapStopTimeout):
+		log.Warnf("tcpdump of packet capture %s did not exit in time, killing it",
+			run.status.ID)
+		_ = run.cmd.Process.Kill()
+		return <-exited
+	}
+}
+
+// getPcapTarget returns network namespace and interface name for the capture target
+// and the logical label of the target.
+// Called with agent in locked state.
+func (a *agent) getPcapTarget(capture api.PacketCapture) (
+	netNs, ifName, label string, err error) {
+	var targets int
+	for _, target := range []string{capture.Port, capture.Bridge,
+		capture.Network, capture.Endpoint} {
+		if target != "" {
+			targets++
+		}
+	}
+	if targets != 1 {
+		err = errors.New("exactly one of port, bridge, network or endpoint " +
+			"must be selected for packet capture")
+		return
+	}
+	switch {
+	case capture.Port != "":
+		label = capture.Port
+		item := a.netModel.items.getItem(api.Port{}.ItemType(), label)
+		if item == nil {
+			err = fmt.Errorf("unknown port: %s", label)
+			return
+		}
+		mac, _ := net.ParseMAC(item.LabeledItem.(api.Port).MAC) // already validated
+		netIf, found := a.macLookup.GetInterfaceByMAC(mac, false)
+		if !found {
+			err = fmt.Errorf("missing interface for port %s (MAC: %s)", label, mac)
+			return
+		}
+		return configitems.MainNsName, netIf.IfName, label, nil
+	case capture.Bridge != "":
+		label = capture.Bridge
+		if a.netModel.items.getItem(api.Bridge{}.ItemType(), label) == nil {
+			err = fmt.Errorf("unknown bridge: %s", label)
+			return
+		}
+		return configitems.MainNsName, a.bridgeIfName(label), label, nil
+	case capture.Network != "":
+		label = capture.Network
+		if a.netModel.items.getItem(api.Network{}.ItemType(), label) == nil {
+			err = fmt.Errorf("unknown network: %s", label)
+			return
+		}
+		_, inIfName, _ := a.networkBrVethName(label)
+		return a.networkNsName(label), inIfName, label, nil
+	default:
+		label = capture.Endpoint
+		if a.netModel.items.getItem(api.Endpoint{}.ItemType(), label) == nil {
+			err = fmt.Errorf("unknown endpoint: %s", label)
+			return
+		}
+		_, inIfName, _ := a.endpointVethName(label)
+		return a.endpointNsName(label), inIfName, label, nil
+	}
+}
+
+func pcapFileSize(path string) uint64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return uint64(info.Size())
+}
+
+// Called with agent in locked state.
+func (a *agent) getPcapStatus(run *pcapRun) api.PacketCaptureStatus {
+	status := run.status
+	if status.Running {
+		status.Size = pcapFileSize(status.File)
+	}
+	return status
+}
+
+func (a *agent) startPacketCapture(w http.ResponseWriter, r *http.Request) {
+	var capture api.PacketCapture
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to read packet capture request: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	err = json.Unmarshal(body, &capture)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to unmarshal packet capture request from JSON: %v",
+			err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	run, err := a.startPcap(capture)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to start packet capture: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusBadRequest)
+		return
+	}
+	a.Lock()
+	status := a.getPcapStatus(run)
+	a.Unlock()
+	a.writePcapResponse(w, status)
+}
+
+func (a *agent) listPacketCaptures(w http.ResponseWriter, r *http.Request) {
+	a.Lock()
+	statuses := []api.PacketCaptureStatus{}
+	for _, run := range a.captures {
+		statuses = append(statuses, a.getPcapStatus(run))
+	}
+	a.Unlock()
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].StartedAt.Before(statuses[j].StartedAt)
+	})
+	a.writePcapResponse(w, statuses)
+}
+
+func (a *agent) getPacketCapture(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	a.Lock()
+	run, found := a.captures[id]
+	if !found {
+		a.Unlock()
+		http.Error(w, fmt.Sprintf("Unknown packet capture: %s", id), http.StatusNotFound)
+		return
+	}
+	status := a.getPcapStatus(run)
+	a.Unlock()
+	a.writePcapResponse(w, status)
+}
+
+func (a *agent) stopPacketCapture(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	a.Lock()
+	run, found := a.captures[id]
+	a.Unlock()
+	if !found {
+		http.Error(w, fmt.Sprintf("Unknown packet capture: %s", id), http.StatusNotFound)
+		return
+	}
+	select {
+	case run.stop <- "stopped by request":
+	default:
+		// Stop was already requested.
+	}
+	// Wait without holding the lock - watchPcap needs it to finalize the status.
+	<-run.done
+	a.Lock()
+	status := a.getPcapStatus(run)
+	a.Unlock()
+	a.writePcapResponse(w, status)
+}
+
+func (a *agent) removePacketCapture(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	a.Lock()
+	run, found := a.captures[id]
+	a.Unlock()
+	if !found {
+		http.Error(w, fmt.Sprintf("Unknown packet capture: %s", id), http.StatusNotFound)
+		return
+	}
+	select {
+	case run.stop <- "removed":
+	default:
+	}
+	<-run.done
+	a.Lock()
+	delete(a.captures, id)
+	a.Unlock()
+	if err := os.Remove(run.status.File); err != nil && !os.IsNotExist(err) {
+		errMsg := fmt.Sprintf("Failed to remove pcap file %s: %v", run.status.File, err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (a *agent) writePcapResponse(w http.ResponseWriter, response interface{}) {
+	resp, err := json.Marshal(response)
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to marshal packet capture status to JSON: %v", err)
+		log.Error(errMsg)
+		http.Error(w, errMsg, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(resp); err != nil {
+		log.Errorf("Failed to write packet capture status to HTTP response: %v", err)
+	}
+}
@@ -0,0 +1,484 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lf-edge/eden/sdn/vm/api"
+	"github.com/lf-edge/eden/sdn/vm/pkg/configitems"
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+)
+
+const conntrackAcctSysctl = "/proc/sys/net/netfilter/nf_conntrack_acct"
+
+// Matches DNS query logged by dnsmasq, e.g.:
+// "Oct 18 10:00:00 dnsmasq[123]: query[A] example.com from 10.0.0.5"
+var dnsmasqQueryRegex = regexp.MustCompile(`query\[(\S+)\] \S+ from (\S+)$`)
+
+// statusSources are parts of the agent state needed to collect status of network
+// model items. They are copied with the agent in locked state, while the (possibly slow)
+// collection itself runs unlocked, so that /status and /metrics requests do not block
+// the agent.
+type statusSources struct {
+	configErrors   []api.ConfigError
+	ports          []portStatusSource
+	networks       []networkStatusSource
+	dnsServers     []string
+	proxies        []string
+	captivePortals []api.CaptivePortalStatus
+}
+
+type portStatusSource struct {
+	logicalLabel string
+	ifName       string // empty if interface was not found
+}
+
+type networkStatusSource struct {
+	logicalLabel string
+	netNs        string
+	brInIfName   string
+	leaseFiles   []string
+}
+
+// getStatusSources copies what is needed to collect status of all network model items.
+// Called with agent in locked state.
+func (a *agent) getStatusSources() (sources statusSources) {
+	for itemRef, err := range a.failingItems {
+		sources.configErrors = append(sources.configErrors, api.ConfigError{
+			ItemRef: itemRef,
+			ErrMsg:  err.Error(),
+		})
+	}
+	for _, port := range a.netModel.Ports {
+		source := portStatusSource{logicalLabel: port.LogicalLabel}
+		mac, _ := net.ParseMAC(port.MAC) // already validated
+		if netIf, found := a.macLookup.GetInterfaceByMAC(mac, false); found {
+			source.ifName = netIf.IfName
+		}
+		sources.ports = append(sources.ports, source)
+	}
+	for _, network := range a.netModel.Networks {
+		_, brInIfName, _ := a.networkBrVethName(network.LogicalLabel)
+		source := networkStatusSource{
+			logicalLabel: network.LogicalLabel,
+			netNs:        a.networkNsName(network.LogicalLabel),
+			brInIfName:   brInIfName,
+		}
+		if network.DHCP.Enable {
+			source.leaseFiles = []string{configitems.DhcpServerLeaseFile(network.LogicalLabel)}
+			if a.networkHasIPv6(network) {
+				source.leaseFiles = append(source.leaseFiles,
+					configitems.DhcpServerLeaseFile(network.LogicalLabel+"-ipv6"))
+			}
+		}
+		sources.networks = append(sources.networks, source)
+	}
+	for _, dnsSrv := range a.netModel.Endpoints.DNSServers {
+		sources.dnsServers = append(sources.dnsServers, dnsSrv.LogicalLabel)
+	}
+	for _, proxy := range a.netModel.Endpoints.ExplicitProxies {
+		sources.proxies = append(sources.proxies, proxy.LogicalLabel)
+	}
+	for _, proxy := range a.netModel.Endpoints.TransparentProxies {
+		sources.proxies = append(sources.proxies, proxy.LogicalLabel)
+	}
+	for _, portal := range a.netModel.Endpoints.CaptivePortals {
+		sources.captivePortals = append(sources.captivePortals,
+			a.getCaptivePortalStatus(portal.LogicalLabel))
+	}
+	return sources
+}
+
+// collectStatus collects status of network model items.
+// Called with agent in unlocked state.
+func (a *agent) collectStatus(sources statusSources) (status api.SDNStatus) {
+	status.ConfigErrors = sources.configErrors
+	for _, port := range sources.ports {
+		status.Ports = append(status.Ports, getPortStatus(port))
+	}
+	for _, network := range sources.networks {
+		status.Networks = append(status.Networks, getNetworkStatus(network))
+	}
+	a.logCountersMx.Lock()
+	logFiles := make(map[string]struct{})
+	for _, dnsSrvLL := range sources.dnsServers {
+		status.DNSServers = append(status.DNSServers, a.getDNSServerStatus(dnsSrvLL, logFiles))
+	}
+	for _, proxyLL := range sources.proxies {
+		status.Proxies = append(status.Proxies, a.getProxyStatus(proxyLL, logFiles))
+	}
+	a.removeStaleLogCounters(logFiles)
+	a.logCountersMx.Unlock()
+	status.CaptivePortals = sources.captivePortals
+	status.Conntrack = getConntrackSummary()
+	return status
+}
+
+// getStatus collects status of all network model items.
+// Called with agent in unlocked state.
+func (a *agent) getStatus() api.SDNStatus {
+	a.Lock()
+	sources := a.getStatusSources()
+	a.Unlock()
+	return a.collectStatus(sources)
+}
+
+func getPortStatus(port portStatusSource) api.PortStatus {
+	status := api.PortStatus{LogicalLabel: port.logicalLabel}
+	if port.ifName == "" {
+		return status
+	}
+	status.IfName = port.ifName
+	link, err := netlink.LinkByName(port.ifName)
+	if err != nil {
+		log.Warnf("Failed to get link %s: %v", port.ifName, err)
+		return status
+	}
+	status.OperUp = link.Attrs().OperState == netlink.OperUp
+	status.Counters = linkCounters(link)
+	status.Qdiscs = getQdiscStats(port.ifName)
+	return status
+}
+
+func getNetworkStatus(network networkStatusSource) api.NetworkStatus {
+	status := api.NetworkStatus{LogicalLabel: network.logicalLabel}
+	link, err := linkFromNamespace(network.netNs, network.brInIfName)
+	if err != nil {
+		log.Warnf("Failed to get link %s of network %s: %v",
+			network.brInIfName, network.logicalLabel, err)
+	} else {
+		status.Counters = linkCounters(link)
+	}
+	for _, leaseFile := range network.leaseFiles {
+		status.DHCPLeases = append(status.DHCPLeases, parseDnsmasqLeases(leaseFile)...)
+	}
+	return status
+}
+
+// logTail remembers how far a log file was already processed,
+// so that only lines appended since the last status request are parsed.
+type logTail struct {
+	file   os.FileInfo
+	offset int64
+}
+
+// readNewLines calls handler for every complete line appended to the log file
+// since the last call. If the file was truncated or re-created in the meantime,
+// reset is called first and the file is read from the beginning.
+func (t *logTail) readNewLines(logFile string, reset func(), handler func(line []byte)) error {
+	file, err := os.Open(logFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if t.file != nil && (!os.SameFile(t.file, info) || info.Size() < t.offset) {
+		reset()
+		t.offset = 0
+	}
+	t.file = info
+	if _, err = file.Seek(t.offset, io.SeekStart); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			// Incomplete last line is read with the next request.
+			break
+		}
+		t.offset += int64(len(line))
+		handler(bytes.TrimSuffix(line, []byte("\n")))
+	}
+	return nil
+}
+
+// dnsQueryCounter counts DNS queries logged into a file.
+type dnsQueryCounter struct {
+	logTail
+	queries  uint64
+	byType   map[string]uint64
+	byClient map[string]uint64
+}
+
+func (c *dnsQueryCounter) reset() {
+	c.queries = 0
+	c.byType = make(map[string]uint64)
+	c.byClient = make(map[string]uint64)
+}
+
+// proxyRequestCounter counts requests logged into a proxy access log file.
+type proxyRequestCounter struct {
+	logTail
+	requests uint64
+	recent   []api.ProxyRequest
+}
+
+// Called with logCountersMx locked.
+func (a *agent) getDNSServerStatus(dnsSrvLL string, logFiles map[string]struct{}) api.DNSServerStatus {
+	status := api.DNSServerStatus{
+		LogicalLabel:    dnsSrvLL,
+		QueriesByType:   make(map[string]uint64),
+		QueriesByClient: make(map[string]uint64),
+	}
+	// With faults enabled, queries are logged by the DNS fault proxy instead of dnsmasq.
+	for _, logFile := range []string{
+		configitems.DnsServerLogFile(dnsSrvLL),
+		configitems.DnsFaultProxyQueryLogFile(dnsSrvLL),
+	} {
+		logFiles[logFile] = struct{}{}
+		counter := a.countDNSQueries(logFile)
+		if counter == nil {
+			continue
+		}
+		status.Queries += counter.queries
+		for qType, count := range counter.byType {
+			status.QueriesByType[qType] += count
+		}
+		for client, count := range counter.byClient {
+			status.QueriesByClient[client] += count
+		}
+	}
+	return status
+}
+
+// countDNSQueries updates counters of DNS queries with lines appended to the log file.
+// Returns nil if the log file does not exist (yet).
+// Called with logCountersMx locked.
+func (a *agent) countDNSQueries(logFile string) *dnsQueryCounter {
+	counter := a.dnsQueryCounters[logFile]
+	if counter == nil {
+		counter = &dnsQueryCounter{}
+		counter.reset()
+	}
+	var match [][]byte
+	err := counter.readNewLines(logFile, counter.reset, func(line []byte) {
+		if match = dnsmasqQueryRegex.FindSubmatch(line); match == nil {
+			return
+		}
+		counter.queries++
+		counter.byType[string(match[1])]++
+		counter.byClient[string(match[2])]++
+	})
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("Failed to read DNS server log file %s: %v", logFile, err)
+		}
+		delete(a.dnsQueryCounters, logFile)
+		return nil
+	}
+	a.dnsQueryCounters[logFile] = counter
+	return counter
+}
+
+// Called with logCountersMx locked.
+func (a *agent) getProxyStatus(proxyLL string, logFiles map[string]struct{}) api.ProxyStatus {
+	status := api.ProxyStatus{LogicalLabel: proxyLL}
+	logFile := configitems.HttpProxyAccessLogFile(proxyLL)
+	logFiles[logFile] = struct{}{}
+	counter := a.proxyCounters[logFile]
+	if counter == nil {
+		counter = &proxyRequestCounter{}
+	}
+	reset := func() {
+		counter.requests = 0
+		counter.recent = nil
+	}
+	err := counter.readNewLines(logFile, reset, func(line []byte) {
+		var request api.ProxyRequest
+		if err := json.Unmarshal(line, &request); err != nil {
+			return
+		}
+		counter.requests++
+		counter.recent = append(counter.recent, request)
+		if len(counter.recent) > api.MaxRecentProxyRequests {
+			counter.recent = counter.recent[1:]
+		}
+	})
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("Failed to read proxy access log file %s: %v", logFile, err)
+		}
+		delete(a.proxyCounters, logFile)
+		return status
+	}
+	a.proxyCounters[logFile] = counter
+	status.Requests = counter.requests
+	status.RecentRequests = append([]api.ProxyRequest(nil), counter.recent...)
+	return status
+}
+
+// removeStaleLogCounters removes counters of log files which are no longer
+// used by the network model.
+// Called with logCountersMx locked.
+func (a *agent) removeStaleLogCounters(logFiles map[string]struct{}) {
+	for logFile := range a.dnsQueryCounters {
+		if _, used := logFiles[logFile]; !used {
+			delete(a.dnsQueryCounters, logFile)
+		}
+	}
+	for logFile := range a.proxyCounters {
+		if _, used := logFiles[logFile]; !used {
+			delete(a.proxyCounters, logFile)
+		}
+	}
+}
+
+func getConntrackSummary() api.ConntrackSummary {
+	summary := api.ConntrackSummary{
+		EntriesByProto: make(map[string]uint64),
+	}
+	for _, family := range []netlink.InetFamily{syscall.AF_INET, syscall.AF_INET6} {
+		flows, err := netlink.ConntrackTableList(netlink.ConntrackTable, family)
+		if err != nil {
+			log.Warnf("Failed to list conntrack table (family %d): %v", family, err)
+			continue
+		}
+		for _, flow := range flows {
+			summary.Entries++
+			summary.EntriesByProto[protoName(flow.Forward.Protocol)]++
+			summary.Packets += flow.Forward.Packets + flow.Reverse.Packets
+			summary.Bytes += flow.Forward.Bytes + flow.Reverse.Bytes
+		}
+	}
+	return summary
+}
+
+// enableConntrackAcct enables accounting of packets and bytes for tracked connections
+// (disabled by default). Only connections created afterwards have counters.
+func enableConntrackAcct() {
+	value, err := os.ReadFile(conntrackAcctSysctl)
+	if err != nil || strings.TrimSpace(string(value)) == "1" {
+		// conntrack module not loaded yet or accounting already enabled
+		return
+	}
+	if err = os.WriteFile(conntrackAcctSysctl, []byte("1"), 0644); err != nil {
+		log.Warnf("Failed to enable conntrack accounting: %v", err)
+	}
+}
+
+func protoName(proto uint8) string {
+	switch proto {
+	case syscall.IPPROTO_TCP:
+		return "tcp"
+	case syscall.IPPROTO_UDP:
+		return "udp"
+	case syscall.IPPROTO_ICMP:
+		return "icmp"
+	case syscall.IPPROTO_ICMPV6:
+		return "icmpv6"
+	}
+	return strconv.Itoa(int(proto))
+}
+
+func linkCounters(link netlink.Link) (counters api.IfCounters) {
+	stats := link.Attrs().Statistics
+	if stats == nil {
+		return
+	}
+	return api.IfCounters{
+		RxBytes:   stats.RxBytes,
+		RxPackets: stats.RxPackets,
+		RxDropped: stats.RxDropped,
+		RxErrors:  stats.RxErrors,
+		TxBytes:   stats.TxBytes,
+		TxPackets: stats.TxPackets,
+		TxDropped: stats.TxDropped,
+		TxErrors:  stats.TxErrors,
+	}
+}
+
+// linkFromNamespace gets link by name from the given network namespace.
+func linkFromNamespace(netNs, ifName string) (netlink.Link, error) {
+	if netNs == configitems.MainNsName {
+		return netlink.LinkByName(ifName)
+	}
+	nsHandle, err := netns.GetFromName(netNs)
+	if err != nil {
+		return nil, err
+	}
+	defer nsHandle.Close()
+	handle, err := netlink.NewHandleAt(nsHandle)
+	if err != nil {
+		return nil, err
+	}
+	defer handle.Delete()
+	return handle.LinkByName(ifName)
+}
+
+// getQdiscStats returns statistics of queueing disciplines configured
+// for the given interface (in the main namespace).
+func getQdiscStats(ifName string) (qdiscs []api.QdiscStats) {
+	output, err := exec.Command("tc", "-s", "-j", "qdisc", "show", "dev", ifName).Output()
+	if err != nil {
+		log.Warnf("Failed to get qdisc statistics for interface %s: %v", ifName, err)
+		return nil
+	}
+	if err = json.Unmarshal(output, &qdiscs); err != nil {
+		log.Warnf("Failed to parse qdisc statistics for interface %s: %v", ifName, err)
+		return nil
+	}
+	return qdiscs
+}
+
+// parseDnsmasqLeases parses lease file written by dnsmasq.
+// IPv4 lease: "<expiry> <MAC> <IP> <hostname> <client-ID>"
+// IPv6 lease: "<expiry> <IAID> <IP> <hostname> <DUID>" (after "duid <server-DUID>")
+// Unknown values are replaced with "*".
+func parseDnsmasqLeases(leaseFile string) (leases []api.DHCPLease) {
+	file, err := os.Open(leaseFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("Failed to open DHCP lease file %s: %v", leaseFile, err)
+		}
+		return nil
+	}
+	defer file.Close()
+	optional := func(value string) string {
+		if value == "*" {
+			return ""
+		}
+		return value
+	}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[0] == "duid" {
+			continue
+		}
+		var lease api.DHCPLease
+		expiry, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			log.Warnf("Invalid expiry in DHCP lease file %s: %s", leaseFile, fields[0])
+			continue
+		}
+		if expiry != 0 {
+			lease.Expiry = time.Unix(expiry, 0)
+		}
+		if _, err := net.ParseMAC(fields[1]); err == nil {
+			lease.MAC = fields[1]
+		}
+		lease.IP = fields[2]
+		lease.Hostname = optional(fields[3])
+		if len(fields) > 4 {
+			lease.ClientID = optional(fields[4])
+		}
+		leases = append(leases, lease)
+	}
+	return leases
+}
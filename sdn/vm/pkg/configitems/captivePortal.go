@@ -0,0 +1,207 @@
+package configitems
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	captiveportalcfg "github.com/lf-edge/eden/sdn/vm/cmd/captiveportal/config"
+	"github.com/lf-edge/eve/libs/depgraph"
+	"github.com/lf-edge/eve/libs/reconciler"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	captivePortalBinary  = "/bin/captiveportal"
+	captivePortalConfDir = "/etc/captiveportal"
+	captivePortalRunDir  = "/run/captiveportal"
+
+	captivePortalStartTimeout = 3 * time.Second
+	captivePortalStopTimeout  = 10 * time.Second
+)
+
+// CaptivePortal : HTTP(S) server redirecting all requests to the login page.
+// Traffic is intercepted and redirected into the portal using iptables
+// (configured separately for every network using the portal).
+type CaptivePortal struct {
+	// PortalName : logical name for the captive portal.
+	PortalName string
+	// NetNamespace : network namespace where the portal should be running.
+	NetNamespace string
+	// VethName : logical name of the veth pair on which the portal operates.
+	// (other types of interfaces are currently not supported)
+	VethName string
+	// ListenIP : IP address on which the portal should listen.
+	ListenIP net.IP
+	// PortalHosts : host names (FQDN, IP) under which the portal is accessed.
+	PortalHosts []string
+	// LoginPage : HTML content of the login page.
+	LoginPage string
+	// CertPEM : portal certificate in the PEM format. Empty to disable HTTPS.
+	CertPEM string
+	// KeyPEM : portal key in the PEM format.
+	KeyPEM string
+}
+
+// Name
+func (p CaptivePortal) Name() string {
+	return p.PortalName
+}
+
+// Label
+func (p CaptivePortal) Label() string {
+	return p.PortalName + " (captive portal)"
+}
+
+// Type
+func (p CaptivePortal) Type() string {
+	return CaptivePortalTypename
+}
+
+// Equal is a comparison method for two equally-named CaptivePortal instances.
+func (p CaptivePortal) Equal(other depgraph.Item) bool {
+	p2 := other.(CaptivePortal)
+	return p.NetNamespace == p2.NetNamespace &&
+		p.VethName == p2.VethName &&
+		p.ListenIP.Equal(p2.ListenIP) &&
+		reflect.DeepEqual(p.PortalHosts, p2.PortalHosts) &&
+		p.LoginPage == p2.LoginPage &&
+		p.CertPEM == p2.CertPEM &&
+		p.KeyPEM == p2.KeyPEM
+}
+
+// External returns false.
+func (p CaptivePortal) External() bool {
+	return false
+}
+
+// String describes the captive portal.
+func (p CaptivePortal) String() string {
+	return fmt.Sprintf("Captive portal: %#+v", p)
+}
+
+// Dependencies lists the veth and network namespace as dependencies.
+func (p CaptivePortal) Dependencies() (deps []depgraph.Dependency) {
+	return []depgraph.Dependency{
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: NetNamespaceTypename,
+				ItemName: normNetNsName(p.NetNamespace),
+			},
+			Description: "Network namespace must exist",
+		},
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: VethTypename,
+				ItemName: p.VethName,
+			},
+			Description: "veth interface must exist",
+		},
+	}
+}
+
+// CaptivePortalConfigurator implements Configurator interface for CaptivePortal.
+type CaptivePortalConfigurator struct{}
+
+// Create starts captiveportal (see sdn/cmd/captiveportal).
+func (c *CaptivePortalConfigurator) Create(ctx context.Context, item depgraph.Item) error {
+	config := item.(CaptivePortal)
+	if err := c.createCaptivePortalConfFile(config); err != nil {
+		return err
+	}
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		err := startCaptivePortal(config.PortalName, config.NetNamespace)
+		done(err)
+	}()
+	return nil
+}
+
+func (c *CaptivePortalConfigurator) createCaptivePortalConfFile(portal CaptivePortal) error {
+	if err := ensureDir(captivePortalConfDir); err != nil {
+		return err
+	}
+	if err := ensureDir(captivePortalRunDir); err != nil {
+		return err
+	}
+	portalName := portal.PortalName
+	config := captiveportalcfg.CaptivePortalConfig{
+		ListenIP:    portal.ListenIP.String(),
+		LogFile:     captivePortalLogFile(portalName),
+		PidFile:     captivePortalPidFile(portalName),
+		Verbose:     true,
+		PortalHosts: portal.PortalHosts,
+		LoginPage:   portal.LoginPage,
+		CertPEM:     portal.CertPEM,
+		KeyPEM:      portal.KeyPEM,
+	}
+	configBytes, err := json.MarshalIndent(config, "", " ")
+	if err != nil {
+		err = fmt.Errorf("failed to marshal config to JSON: %w", err)
+		log.Error(err)
+		return err
+	}
+	cfgPath := captivePortalConfigPath(portalName)
+	err = os.WriteFile(cfgPath, configBytes, 0644)
+	if err != nil {
+		err = fmt.Errorf("failed to create config file %s: %w", cfgPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// Modify is not implemented.
+func (c *CaptivePortalConfigurator) Modify(ctx context.Context, oldItem, newItem depgraph.Item) (err error) {
+	return errors.New("not implemented")
+}
+
+// Delete stops captiveportal.
+func (c *CaptivePortalConfigurator) Delete(ctx context.Context, item depgraph.Item) error {
+	config := item.(CaptivePortal)
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		err := stopProcess(captivePortalPidFile(config.PortalName), captivePortalStopTimeout)
+		if err == nil {
+			// ignore errors from here
+			_ = os.Remove(captivePortalConfigPath(config.PortalName))
+			_ = os.Remove(captivePortalLogFile(config.PortalName))
+			_ = os.Remove(captivePortalPidFile(config.PortalName))
+		}
+		done(err)
+	}()
+	return nil
+}
+
+// NeedsRecreate always returns true - Modify is not implemented.
+func (c *CaptivePortalConfigurator) NeedsRecreate(oldItem, newItem depgraph.Item) (recreate bool) {
+	return true
+}
+
+func captivePortalConfigPath(portalName string) string {
+	return filepath.Join(captivePortalConfDir, portalName+".conf")
+}
+
+func captivePortalPidFile(portalName string) string {
+	return filepath.Join(captivePortalRunDir, portalName+".pid")
+}
+
+func captivePortalLogFile(portalName string) string {
+	return filepath.Join(captivePortalRunDir, portalName+".log")
+}
+
+func startCaptivePortal(portalName, netNamespace string) error {
+	args := []string{
+		"-c",
+		captivePortalConfigPath(portalName),
+	}
+	pidFile := captivePortalPidFile(portalName)
+	return startProcess(netNamespace, captivePortalBinary, args, pidFile,
+		captivePortalStartTimeout, true)
+}
@@ -43,11 +43,13 @@ type DhcpServer struct {
 	// Subnet : network address + netmask (IPv4 or IPv6).
 	Subnet *net.IPNet
 	// IPRange : a range of IP addresses to allocate from.
-	// Not applicable for IPv6 (SLAAC is used instead).
+	// For IPv6 leave empty to run stateless DHCPv6 (addresses are assigned
+	// using SLAAC instead), otherwise stateful DHCPv6 is used.
 	IPRange IPRange
 	// StaticEntries : list of MAC->IP entries statically configured for the DHCP server.
 	StaticEntries []MACToIP
 	// GatewayIP : address of the default gateway to advertise (DHCP option 3).
+	// Not applicable for IPv6 (see RouterAdvertisement).
 	GatewayIP net.IP
 	// DomainName : name of the domain assigned to the network.
 	// It is propagated to clients using the DHCP option 15 (24 in DHCPv6).
@@ -62,16 +64,26 @@ type DhcpServer struct {
 	// The client will learn the PAC file location using the DHCP option 252.
 	// Optional argument, leave empty to disable.
 	WPAD string
-	// TODO: Netboot
-	//  Example dnsmasq.conf:
-	//    # use custom tftp-server instead machine running dnsmasq
-	//    dhcp-boot=pxelinux,server.name,192.168.1.100
-	//    # Boot for iPXE. The idea is to send two different
-	//    # filenames, the first loads iPXE, and the second tells iPXE what to
-	//    # load. The dhcp-match sets the ipxe tag for requests from iPXE.
-	//    dhcp-boot=undionly.kpxe
-	//    dhcp-match=set:ipxe,175 # iPXE sends a 175 option.
-	//    dhcp-boot=tag:ipxe,http://boot.ipxe.org/demo/boot.php
+	// Netboot : netboot configuration to announce to clients.
+	// Optional argument, leave nil to disable.
+	Netboot *DhcpNetboot
+}
+
+// DhcpNetboot : netboot configuration announced by DHCP server.
+// Clients without iPXE are first pointed to the TFTP server to download
+// and boot the iPXE bootloader. Once iPXE is booted (it sends DHCP option 175),
+// it is pointed directly to the iPXE script served over HTTP (chainloading).
+type DhcpNetboot struct {
+	// TFTPServer : IP address or FQDN of the TFTP server (DHCP option 66).
+	TFTPServer string
+	// TFTPServerIP : IP address of the TFTP server (next server address).
+	TFTPServerIP net.IP
+	// TFTPBootfile : file to boot from the TFTP server (DHCP option 67).
+	// Leave empty if TFTP is not used.
+	TFTPBootfile string
+	// IPXEScriptURL : URL of the iPXE script for iPXE clients
+	// (DHCP option 67, 59 in DHCPv6). Leave empty if HTTP is not used.
+	IPXEScriptURL string
 }
 
 // IPRange : a range of IP addresses.
@@ -117,7 +129,8 @@ func (s DhcpServer) Equal(other depgraph.Item) bool {
 		s.DomainName == s2.DomainName &&
 		equalIPLists(s.DNSServers, s2.DNSServers) &&
 		s.NTPServer == s2.NTPServer &&
-		s.WPAD == s2.WPAD
+		s.WPAD == s2.WPAD &&
+		equalDhcpNetboot(s.Netboot, s2.Netboot)
 }
 
 // External returns false.
@@ -185,18 +198,29 @@ func (c *DhcpServerConfigurator) createDnsmasqConfFile(server DhcpServer) error
 	file.WriteString(fmt.Sprintf("pid-file=%s\n", dnsmasqPidFile(srvName)))
 	// To enable dnsmasq's DHCP server functionality.
 	if isIPv6 {
-		// Do Router Advertisements and stateless DHCPv6 for this subnet. Clients will
-		// not get addresses from DHCP, but they will get other configuration information.
-		// They will use SLAAC for addresses.
-		file.WriteString(fmt.Sprintf("dhcp-range=%s,ra-stateless\n", server.Subnet))
+		// Router Advertisements are sent by radvd (see RouterAdvertisement).
+		if server.IPRange.FromIP == nil {
+			// Stateless DHCPv6: clients will not get addresses from DHCP
+			// (they use SLAAC), but they will get other configuration information.
+			file.WriteString("dhcp-range=::,static\n")
+		} else {
+			prefixLen, _ := server.Subnet.Mask.Size()
+			file.WriteString(fmt.Sprintf("dhcp-range=%s,%s,%d,60m\n",
+				server.IPRange.FromIP, server.IPRange.ToIP, prefixLen))
+		}
 	} else {
 		netmask := net.IP(server.Subnet.Mask)
 		file.WriteString(fmt.Sprintf("dhcp-range=%s,%s,%s,60m\n",
 			server.IPRange.FromIP, server.IPRange.ToIP, netmask))
 	}
 	for _, entry := range server.StaticEntries {
-		file.WriteString(fmt.Sprintf("dhcp-host=%s,%s\n",
-			entry.MAC.String(), entry.IP.String()))
+		if isIPv6 {
+			file.WriteString(fmt.Sprintf("dhcp-host=%s,[%s]\n",
+				entry.MAC.String(), entry.IP.String()))
+		} else {
+			file.WriteString(fmt.Sprintf("dhcp-host=%s,%s\n",
+				entry.MAC.String(), entry.IP.String()))
+		}
 	}
 	file.WriteString(fmt.Sprintf("dhcp-leasefile=%s\n",
 		dnsmasqLeaseFile(srvName)))
@@ -210,7 +234,7 @@ func (c *DhcpServerConfigurator) createDnsmasqConfFile(server DhcpServer) error
 	// Domain name.
 	if server.DomainName != "" {
 		if isIPv6 {
-			file.WriteString(fmt.Sprintf("dhcp-option=option:domain-search,%s\n",
+			file.WriteString(fmt.Sprintf("dhcp-option=option6:domain-search,%s\n",
 				server.DomainName))
 		} else {
 			file.WriteString(fmt.Sprintf("dhcp-option=option:domain-name,%s\n",
@@ -231,19 +255,71 @@ func (c *DhcpServerConfigurator) createDnsmasqConfFile(server DhcpServer) error
 	if len(server.DNSServers) > 0 {
 		var addrList []string
 		for _, srvIP := range server.DNSServers {
-			addrList = append(addrList, srvIP.String())
+			if isIPv6 {
+				addrList = append(addrList, "["+srvIP.String()+"]")
+			} else {
+				addrList = append(addrList, srvIP.String())
+			}
+		}
+		if isIPv6 {
+			file.WriteString(fmt.Sprintf("dhcp-option=option6:dns-server,%s\n",
+				strings.Join(addrList, ",")))
+		} else {
+			file.WriteString(fmt.Sprintf("dhcp-option=option:dns-server,%s\n",
+				strings.Join(addrList, ",")))
 		}
-		file.WriteString(fmt.Sprintf("dhcp-option=option:dns-server,%s\n",
-			strings.Join(addrList, ",")))
 	}
 	// NTP Server.
 	if server.NTPServer != "" {
-		file.WriteString(fmt.Sprintf("dhcp-option=option:ntp-server,%s\n", server.NTPServer))
+		if isIPv6 {
+			ntpServer := server.NTPServer
+			if ip := net.ParseIP(ntpServer); ip != nil {
+				ntpServer = "[" + ntpServer + "]"
+			}
+			file.WriteString(fmt.Sprintf("dhcp-option=option6:ntp-server,%s\n", ntpServer))
+		} else {
+			file.WriteString(fmt.Sprintf("dhcp-option=option:ntp-server,%s\n", server.NTPServer))
+		}
 	}
-	// WPAD.
-	if server.WPAD != "" {
+	// WPAD (there is no DHCPv6 option for WPAD).
+	if server.WPAD != "" && !isIPv6 {
 		file.WriteString(fmt.Sprintf("dhcp-option=252,%s\n", server.WPAD))
 	}
+	// Netboot.
+	if netboot := server.Netboot; netboot != nil {
+		if isIPv6 {
+			// iPXE sends user class "iPXE".
+			file.WriteString("dhcp-userclass=set:ipxe,iPXE\n")
+			if netboot.TFTPBootfile != "" {
+				tftpServer := netboot.TFTPServer
+				if ip := net.ParseIP(tftpServer); ip != nil {
+					tftpServer = "[" + tftpServer + "]"
+				}
+				file.WriteString(fmt.Sprintf("dhcp-option=tag:!ipxe,option6:bootfile-url,tftp://%s/%s\n",
+					tftpServer, netboot.TFTPBootfile))
+			}
+			if netboot.IPXEScriptURL != "" {
+				file.WriteString(fmt.Sprintf("dhcp-option=tag:ipxe,option6:bootfile-url,%s\n",
+					netboot.IPXEScriptURL))
+			}
+		} else {
+			// iPXE sends option 175.
+			file.WriteString("dhcp-match=set:ipxe,175\n")
+			if netboot.TFTPBootfile != "" {
+				file.WriteString(fmt.Sprintf("dhcp-boot=tag:!ipxe,%s,,%s\n",
+					netboot.TFTPBootfile, netboot.TFTPServerIP))
+				file.WriteString(fmt.Sprintf("dhcp-option=tag:!ipxe,option:tftp-server,%s\n",
+					netboot.TFTPServer))
+				file.WriteString(fmt.Sprintf("dhcp-option=tag:!ipxe,option:bootfile-name,%s\n",
+					netboot.TFTPBootfile))
+			}
+			if netboot.IPXEScriptURL != "" {
+				file.WriteString(fmt.Sprintf("dhcp-boot=tag:ipxe,%s\n", netboot.IPXEScriptURL))
+				file.WriteString(fmt.Sprintf("dhcp-option=tag:ipxe,option:bootfile-name,%s\n",
+					netboot.IPXEScriptURL))
+			}
+		}
+	}
 	if err = file.Sync(); err != nil {
 		err = fmt.Errorf("failed to sync config file %s: %w", cfgPath, err)
 		log.Error(err)
@@ -297,6 +373,12 @@ func dnsmasqLeaseFile(srvName string) string {
 	return filepath.Join(dnsmasqRunDir, srvName+".leases")
 }
 
+// DhcpServerLeaseFile returns path to the file where DHCP server with the given name
+// stores granted leases (in the dnsmasq format).
+func DhcpServerLeaseFile(serverName string) string {
+	return dnsmasqLeaseFile(dhcpSrvNamePrefix + serverName)
+}
+
 func startDnsmasq(srvName, netNamespace string) error {
 	if err := ensureDir(dnsmasqRunDir); err != nil {
 		return err
@@ -479,3 +561,13 @@ func equalStaticEntries(list1, list2 []MACToIP) bool {
 	}
 	return true
 }
+
+func equalDhcpNetboot(netboot1, netboot2 *DhcpNetboot) bool {
+	if netboot1 == nil || netboot2 == nil {
+		return netboot1 == netboot2
+	}
+	return netboot1.TFTPServer == netboot2.TFTPServer &&
+		netboot1.TFTPServerIP.Equal(netboot2.TFTPServerIP) &&
+		netboot1.TFTPBootfile == netboot2.TFTPBootfile &&
+		netboot1.IPXEScriptURL == netboot2.IPXEScriptURL
+}
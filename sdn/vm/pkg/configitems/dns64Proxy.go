@@ -0,0 +1,202 @@
+package configitems
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	dns64proxycfg "github.com/lf-edge/eden/sdn/vm/cmd/dns64proxy/config"
+	"github.com/lf-edge/eve/libs/depgraph"
+	"github.com/lf-edge/eve/libs/reconciler"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	dns64ProxyBinary  = "/bin/dns64proxy"
+	dns64ProxyConfDir = "/etc/dns64proxy"
+	dns64ProxyRunDir  = "/run/dns64proxy"
+
+	dns64ProxyStartTimeout = 3 * time.Second
+	dns64ProxyStopTimeout  = 10 * time.Second
+)
+
+// Dns64Proxy : DNS proxy synthesizing AAAA records from A records (DNS64).
+// Used together with NAT64 to provide IPv6-only hosts with access
+// to IPv4-only destinations.
+type Dns64Proxy struct {
+	// ProxyName : logical name for the DNS64 proxy.
+	ProxyName string
+	// NetNamespace : network namespace where the proxy should be running.
+	NetNamespace string
+	// VethName : logical name of the veth pair on which the proxy operates.
+	// (other types of interfaces are currently not supported)
+	VethName string
+	// ListenIP : IP address on which the proxy should listen.
+	ListenIP net.IP
+	// Prefix : NAT64 prefix (/96) used to synthesize AAAA records.
+	Prefix *net.IPNet
+	// UpstreamServers : list of IP addresses of DNS servers to forward queries to.
+	UpstreamServers []net.IP
+}
+
+// Name
+func (p Dns64Proxy) Name() string {
+	return p.ProxyName
+}
+
+// Label
+func (p Dns64Proxy) Label() string {
+	return p.ProxyName + " (DNS64 proxy)"
+}
+
+// Type
+func (p Dns64Proxy) Type() string {
+	return Dns64ProxyTypename
+}
+
+// Equal is a comparison method for two equally-named Dns64Proxy instances.
+func (p Dns64Proxy) Equal(other depgraph.Item) bool {
+	p2 := other.(Dns64Proxy)
+	return p.NetNamespace == p2.NetNamespace &&
+		p.VethName == p2.VethName &&
+		p.ListenIP.Equal(p2.ListenIP) &&
+		equalIPNets(p.Prefix, p2.Prefix) &&
+		equalIPLists(p.UpstreamServers, p2.UpstreamServers)
+}
+
+// External returns false.
+func (p Dns64Proxy) External() bool {
+	return false
+}
+
+// String describes the DNS64 proxy.
+func (p Dns64Proxy) String() string {
+	return fmt.Sprintf("DNS64 proxy: %#+v", p)
+}
+
+// Dependencies lists the veth and network namespace as dependencies.
+func (p Dns64Proxy) Dependencies() (deps []depgraph.Dependency) {
+	return []depgraph.Dependency{
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: NetNamespaceTypename,
+				ItemName: normNetNsName(p.NetNamespace),
+			},
+			Description: "Network namespace must exist",
+		},
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: VethTypename,
+				ItemName: p.VethName,
+			},
+			Description: "veth interface must exist",
+		},
+	}
+}
+
+// Dns64ProxyConfigurator implements Configurator interface for Dns64Proxy.
+type Dns64ProxyConfigurator struct{}
+
+// Create starts dns64proxy (see sdn/cmd/dns64proxy).
+func (c *Dns64ProxyConfigurator) Create(ctx context.Context, item depgraph.Item) error {
+	config := item.(Dns64Proxy)
+	if err := c.createDns64ProxyConfFile(config); err != nil {
+		return err
+	}
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		err := startDns64Proxy(config.ProxyName, config.NetNamespace)
+		done(err)
+	}()
+	return nil
+}
+
+func (c *Dns64ProxyConfigurator) createDns64ProxyConfFile(proxy Dns64Proxy) error {
+	if err := ensureDir(dns64ProxyConfDir); err != nil {
+		return err
+	}
+	if err := ensureDir(dns64ProxyRunDir); err != nil {
+		return err
+	}
+	proxyName := proxy.ProxyName
+	var upstreamServers []string
+	for _, server := range proxy.UpstreamServers {
+		upstreamServers = append(upstreamServers, server.String())
+	}
+	config := dns64proxycfg.Dns64ProxyConfig{
+		ListenIP:        proxy.ListenIP.String(),
+		LogFile:         dns64ProxyLogFile(proxyName),
+		PidFile:         dns64ProxyPidFile(proxyName),
+		Verbose:         true,
+		Prefix:          proxy.Prefix.String(),
+		UpstreamServers: upstreamServers,
+	}
+	configBytes, err := json.MarshalIndent(config, "", " ")
+	if err != nil {
+		err = fmt.Errorf("failed to marshal config to JSON: %w", err)
+		log.Error(err)
+		return err
+	}
+	cfgPath := dns64ProxyConfigPath(proxyName)
+	err = os.WriteFile(cfgPath, configBytes, 0644)
+	if err != nil {
+		err = fmt.Errorf("failed to create config file %s: %w", cfgPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// Modify is not implemented.
+func (c *Dns64ProxyConfigurator) Modify(ctx context.Context, oldItem, newItem depgraph.Item) (err error) {
+	return errors.New("not implemented")
+}
+
+// Delete stops dns64proxy.
+func (c *Dns64ProxyConfigurator) Delete(ctx context.Context, item depgraph.Item) error {
+	config := item.(Dns64Proxy)
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		err := stopProcess(dns64ProxyPidFile(config.ProxyName), dns64ProxyStopTimeout)
+		if err == nil {
+			// ignore errors from here
+			_ = os.Remove(dns64ProxyConfigPath(config.ProxyName))
+			_ = os.Remove(dns64ProxyLogFile(config.ProxyName))
+			_ = os.Remove(dns64ProxyPidFile(config.ProxyName))
+		}
+		done(err)
+	}()
+	return nil
+}
+
+// NeedsRecreate always returns true - Modify is not implemented.
+func (c *Dns64ProxyConfigurator) NeedsRecreate(oldItem, newItem depgraph.Item) (recreate bool) {
+	return true
+}
+
+func dns64ProxyConfigPath(proxyName string) string {
+	return filepath.Join(dns64ProxyConfDir, proxyName+".conf")
+}
+
+func dns64ProxyPidFile(proxyName string) string {
+	return filepath.Join(dns64ProxyRunDir, proxyName+".pid")
+}
+
+func dns64ProxyLogFile(proxyName string) string {
+	return filepath.Join(dns64ProxyRunDir, proxyName+".log")
+}
+
+func startDns64Proxy(proxyName, netNamespace string) error {
+	args := []string{
+		"-c",
+		dns64ProxyConfigPath(proxyName),
+	}
+	pidFile := dns64ProxyPidFile(proxyName)
+	return startProcess(netNamespace, dns64ProxyBinary, args, pidFile,
+		dns64ProxyStartTimeout, true)
+}
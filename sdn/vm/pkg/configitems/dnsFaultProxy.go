@@ -0,0 +1,207 @@
+package configitems
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	dnsfaultproxycfg "github.com/lf-edge/eden/sdn/vm/cmd/dnsfaultproxy/config"
+	"github.com/lf-edge/eve/libs/depgraph"
+	"github.com/lf-edge/eve/libs/reconciler"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	dnsFaultProxyBinary  = "/bin/dnsfaultproxy"
+	dnsFaultProxyConfDir = "/etc/dnsfaultproxy"
+	dnsFaultProxyRunDir  = "/run/dnsfaultproxy"
+
+	dnsFaultProxyStartTimeout = 3 * time.Second
+	dnsFaultProxyStopTimeout  = 10 * time.Second
+)
+
+// DnsFaultProxy : DNS proxy running in front of DnsServer (with BehindProxy enabled)
+// and injecting faults into responses.
+type DnsFaultProxy struct {
+	// ProxyName : logical name for the DNS fault proxy.
+	ProxyName string
+	// NetNamespace : network namespace where the proxy should be running.
+	NetNamespace string
+	// VethName : logical name of the veth pair on which the proxy operates.
+	// (other types of interfaces are currently not supported)
+	VethName string
+	// ListenIP : IP address on which the proxy should listen.
+	ListenIP net.IP
+	// UpstreamServer : address (host:port) of the DNS server to forward queries to.
+	UpstreamServer string
+	// Faults : faults to inject into responses.
+	Faults []sdnapi.DNSFault
+}
+
+// Name
+func (p DnsFaultProxy) Name() string {
+	return p.ProxyName
+}
+
+// Label
+func (p DnsFaultProxy) Label() string {
+	return p.ProxyName + " (DNS fault proxy)"
+}
+
+// Type
+func (p DnsFaultProxy) Type() string {
+	return DnsFaultProxyTypename
+}
+
+// Equal is a comparison method for two equally-named DnsFaultProxy instances.
+func (p DnsFaultProxy) Equal(other depgraph.Item) bool {
+	p2 := other.(DnsFaultProxy)
+	return p.NetNamespace == p2.NetNamespace &&
+		p.VethName == p2.VethName &&
+		p.ListenIP.Equal(p2.ListenIP) &&
+		p.UpstreamServer == p2.UpstreamServer &&
+		reflect.DeepEqual(p.Faults, p2.Faults)
+}
+
+// External returns false.
+func (p DnsFaultProxy) External() bool {
+	return false
+}
+
+// String describes the DNS fault proxy.
+func (p DnsFaultProxy) String() string {
+	return fmt.Sprintf("DNS fault proxy: %#+v", p)
+}
+
+// Dependencies lists the veth and network namespace as dependencies.
+func (p DnsFaultProxy) Dependencies() (deps []depgraph.Dependency) {
+	return []depgraph.Dependency{
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: NetNamespaceTypename,
+				ItemName: normNetNsName(p.NetNamespace),
+			},
+			Description: "Network namespace must exist",
+		},
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: VethTypename,
+				ItemName: p.VethName,
+			},
+			Description: "veth interface must exist",
+		},
+	}
+}
+
+// DnsFaultProxyConfigurator implements Configurator interface for DnsFaultProxy.
+type DnsFaultProxyConfigurator struct{}
+
+// Create starts dnsfaultproxy (see sdn/cmd/dnsfaultproxy).
+func (c *DnsFaultProxyConfigurator) Create(ctx context.Context, item depgraph.Item) error {
+	config := item.(DnsFaultProxy)
+	if err := c.createDnsFaultProxyConfFile(config); err != nil {
+		return err
+	}
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		err := startDnsFaultProxy(config.ProxyName, config.NetNamespace)
+		done(err)
+	}()
+	return nil
+}
+
+func (c *DnsFaultProxyConfigurator) createDnsFaultProxyConfFile(proxy DnsFaultProxy) error {
+	if err := ensureDir(dnsFaultProxyConfDir); err != nil {
+		return err
+	}
+	if err := ensureDir(dnsFaultProxyRunDir); err != nil {
+		return err
+	}
+	proxyName := proxy.ProxyName
+	config := dnsfaultproxycfg.DnsFaultProxyConfig{
+		ListenIP:       proxy.ListenIP.String(),
+		LogFile:        dnsFaultProxyLogFile(proxyName),
+		QueryLogFile:   DnsFaultProxyQueryLogFile(proxyName),
+		PidFile:        dnsFaultProxyPidFile(proxyName),
+		Verbose:        true,
+		UpstreamServer: proxy.UpstreamServer,
+		Faults:         proxy.Faults,
+	}
+	configBytes, err := json.MarshalIndent(config, "", " ")
+	if err != nil {
+		err = fmt.Errorf("failed to marshal config to JSON: %w", err)
+		log.Error(err)
+		return err
+	}
+	cfgPath := dnsFaultProxyConfigPath(proxyName)
+	err = os.WriteFile(cfgPath, configBytes, 0644)
+	if err != nil {
+		err = fmt.Errorf("failed to create config file %s: %w", cfgPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// Modify is not implemented.
+func (c *DnsFaultProxyConfigurator) Modify(ctx context.Context, oldItem, newItem depgraph.Item) (err error) {
+	return errors.New("not implemented")
+}
+
+// Delete stops dnsfaultproxy.
+func (c *DnsFaultProxyConfigurator) Delete(ctx context.Context, item depgraph.Item) error {
+	config := item.(DnsFaultProxy)
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		err := stopProcess(dnsFaultProxyPidFile(config.ProxyName), dnsFaultProxyStopTimeout)
+		if err == nil {
+			// ignore errors from here
+			_ = os.Remove(dnsFaultProxyConfigPath(config.ProxyName))
+			_ = os.Remove(dnsFaultProxyLogFile(config.ProxyName))
+			_ = os.Remove(DnsFaultProxyQueryLogFile(config.ProxyName))
+			_ = os.Remove(dnsFaultProxyPidFile(config.ProxyName))
+		}
+		done(err)
+	}()
+	return nil
+}
+
+// NeedsRecreate always returns true - Modify is not implemented.
+func (c *DnsFaultProxyConfigurator) NeedsRecreate(oldItem, newItem depgraph.Item) (recreate bool) {
+	return true
+}
+
+// DnsFaultProxyQueryLogFile returns path to the file where DNS fault proxy
+// with the given name logs received queries (in the dnsmasq format).
+func DnsFaultProxyQueryLogFile(proxyName string) string {
+	return filepath.Join(dnsFaultProxyRunDir, proxyName+"-queries.log")
+}
+
+func dnsFaultProxyConfigPath(proxyName string) string {
+	return filepath.Join(dnsFaultProxyConfDir, proxyName+".conf")
+}
+
+func dnsFaultProxyPidFile(proxyName string) string {
+	return filepath.Join(dnsFaultProxyRunDir, proxyName+".pid")
+}
+
+func dnsFaultProxyLogFile(proxyName string) string {
+	return filepath.Join(dnsFaultProxyRunDir, proxyName+".log")
+}
+
+func startDnsFaultProxy(proxyName, netNamespace string) error {
+	args := []string{
+		"-c",
+		dnsFaultProxyConfigPath(proxyName),
+	}
+	pidFile := dnsFaultProxyPidFile(proxyName)
+	return startProcess(netNamespace, dnsFaultProxyBinary, args, pidFile,
+		dnsFaultProxyStartTimeout, true)
+}
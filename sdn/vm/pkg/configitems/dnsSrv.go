@@ -6,13 +6,21 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"reflect"
+	"strings"
 
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
 	"github.com/lf-edge/eve/libs/depgraph"
 	"github.com/lf-edge/eve/libs/reconciler"
 	log "github.com/sirupsen/logrus"
 )
 
-const dnsSrvNamePrefix = "dnssrv-"
+const (
+	dnsSrvNamePrefix = "dnssrv-"
+	// DnsServerBehindProxyAddr : address on which DNS server with BehindProxy enabled
+	// listens (inside its network namespace).
+	DnsServerBehindProxyAddr = "127.0.0.1:5353"
+)
 
 // DnsServer : DNS server.
 type DnsServer struct {
@@ -31,12 +39,44 @@ type DnsServer struct {
 	// UpstreamServers : list of IP addresses of public DNS servers to forward
 	// requests to (unless there is a static entry).
 	UpstreamServers []net.IP
+	// TTL : time-to-live of records returned for static entries.
+	TTL uint32
+	// BehindProxy : enable if the server runs behind DnsFaultProxy.
+	// The server then listens on DnsServerBehindProxyAddr instead of VethPeerIfName
+	// and does not log queries (they are logged by the proxy).
+	BehindProxy bool
 }
 
-// DnsEntry : Mapping between FQDN and an IP address.
+// DnsEntry : DNS record statically configured for the server.
 type DnsEntry struct {
 	FQDN string
-	IP   net.IP
+	// Type of the record. Empty for A/AAAA.
+	Type sdnapi.DNSRecordType
+	// IP address (A/AAAA records).
+	IP net.IP
+	// Target : canonical name (CNAME) or target host (SRV).
+	Target string
+	// SRV record parameters.
+	Port     uint16
+	Priority uint16
+	Weight   uint16
+	// Text : strings of the TXT record.
+	Text []string
+	// TTL : time-to-live of the record (A/AAAA/CNAME). Zero to use DnsServer.TTL.
+	TTL uint32
+}
+
+// Equal compares two DNS entries.
+func (e DnsEntry) Equal(e2 DnsEntry) bool {
+	return e.FQDN == e2.FQDN &&
+		e.Type == e2.Type &&
+		e.IP.Equal(e2.IP) &&
+		e.Target == e2.Target &&
+		e.Port == e2.Port &&
+		e.Priority == e2.Priority &&
+		e.Weight == e2.Weight &&
+		reflect.DeepEqual(e.Text, e2.Text) &&
+		e.TTL == e2.TTL
 }
 
 // Name
@@ -69,14 +109,15 @@ func (s DnsServer) Equal(other depgraph.Item) bool {
 		return false
 	}
 	for i := range s.StaticEntries {
-		if !s.StaticEntries[i].IP.Equal(s2.StaticEntries[i].IP) ||
-			s.StaticEntries[i].FQDN != s2.StaticEntries[i].FQDN {
+		if !s.StaticEntries[i].Equal(s2.StaticEntries[i]) {
 			return false
 		}
 	}
 	return s.NetNamespace == s2.NetNamespace &&
 		s.VethName == s2.VethName &&
-		s.VethPeerIfName == s2.VethPeerIfName
+		s.VethPeerIfName == s2.VethPeerIfName &&
+		s.TTL == s2.TTL &&
+		s.BehindProxy == s2.BehindProxy
 }
 
 // External returns false.
@@ -141,12 +182,26 @@ func (c *DnsServerConfigurator) createDnsmasqConfFile(server DnsServer) error {
 	defer file.Close()
 	// PID file is also used by Delete method.
 	file.WriteString(fmt.Sprintf("pid-file=%s\n", dnsmasqPidFile(srvName)))
-	// Set the interface on which dnsmasq operates.
-	file.WriteString(fmt.Sprintf("interface=%s\n", server.VethPeerIfName))
-	// Disable DHCP.
-	file.WriteString(fmt.Sprintf("no-dhcp-interface=%s\n", server.VethPeerIfName))
+	if server.BehindProxy {
+		// Receive queries only from the proxy.
+		host, port, err := net.SplitHostPort(DnsServerBehindProxyAddr)
+		if err != nil {
+			return err
+		}
+		file.WriteString(fmt.Sprintf("listen-address=%s\n", host))
+		file.WriteString(fmt.Sprintf("port=%s\n", port))
+		file.WriteString("bind-interfaces\n")
+		file.WriteString("no-dhcp-interface=lo\n")
+		// Queries are logged by the proxy.
+	} else {
+		// Set the interface on which dnsmasq operates.
+		file.WriteString(fmt.Sprintf("interface=%s\n", server.VethPeerIfName))
+		// Disable DHCP.
+		file.WriteString(fmt.Sprintf("no-dhcp-interface=%s\n", server.VethPeerIfName))
+		// Log queries (used to collect DNS server statistics).
+		file.WriteString("log-queries\n")
+	}
 	// Logging.
-	file.WriteString("log-queries\n")
 	file.WriteString(fmt.Sprintf("log-facility=%s\n", dnsmasqLogFile(srvName)))
 	// Upstream DNS servers.
 	for _, upstreamSrv := range server.UpstreamServers {
@@ -154,8 +209,11 @@ func (c *DnsServerConfigurator) createDnsmasqConfFile(server DnsServer) error {
 	}
 	file.WriteString("no-resolv\n")
 	// Static DNS entries.
+	if server.TTL != 0 {
+		file.WriteString(fmt.Sprintf("local-ttl=%d\n", server.TTL))
+	}
 	for _, entry := range server.StaticEntries {
-		file.WriteString(fmt.Sprintf("address=/%s/%s\n", entry.FQDN, entry.IP.String()))
+		file.WriteString(dnsmasqStaticEntry(entry))
 	}
 	file.WriteString("no-hosts\n")
 	if err = file.Sync(); err != nil {
@@ -166,6 +224,38 @@ func (c *DnsServerConfigurator) createDnsmasqConfFile(server DnsServer) error {
 	return nil
 }
 
+// dnsmasqStaticEntry returns dnsmasq config option for the static DNS entry.
+func dnsmasqStaticEntry(entry DnsEntry) string {
+	switch entry.Type {
+	case sdnapi.DNSRecordCNAME:
+		if entry.TTL != 0 {
+			return fmt.Sprintf("cname=%s,%s,%d\n", entry.FQDN, entry.Target, entry.TTL)
+		}
+		return fmt.Sprintf("cname=%s,%s\n", entry.FQDN, entry.Target)
+	case sdnapi.DNSRecordSRV:
+		return fmt.Sprintf("srv-host=%s,%s,%d,%d,%d\n", entry.FQDN, entry.Target,
+			entry.Port, entry.Priority, entry.Weight)
+	case sdnapi.DNSRecordTXT:
+		// Commas inside of quotes are taken by dnsmasq literally,
+		// quotes and backslashes must be escaped.
+		escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+		var quoted []string
+		for _, text := range entry.Text {
+			quoted = append(quoted, "\""+escaper.Replace(text)+"\"")
+		}
+		return fmt.Sprintf("txt-record=%s,%s\n", entry.FQDN, strings.Join(quoted, ","))
+	default:
+		// A or AAAA record.
+		// Address matches also all subdomains.
+		address := fmt.Sprintf("address=/%s/%s\n", entry.FQDN, entry.IP)
+		if entry.TTL != 0 {
+			// Host-record (with TTL) overrides address for the exact FQDN only.
+			return fmt.Sprintf("host-record=%s,%s,%d\n", entry.FQDN, entry.IP, entry.TTL) + address
+		}
+		return address
+	}
+}
+
 // Modify is not implemented.
 func (c *DnsServerConfigurator) Modify(ctx context.Context, oldItem, newItem depgraph.Item) (err error) {
 	return errors.New("not implemented")
@@ -193,3 +283,9 @@ func (c *DnsServerConfigurator) Delete(ctx context.Context, item depgraph.Item)
 func (c *DnsServerConfigurator) NeedsRecreate(oldItem, newItem depgraph.Item) (recreate bool) {
 	return true
 }
+
+// DnsServerLogFile returns path to the file where DNS server with the given name
+// logs received queries (in the dnsmasq format).
+func DnsServerLogFile(serverName string) string {
+	return dnsmasqLogFile(dnsSrvNamePrefix + serverName)
+}
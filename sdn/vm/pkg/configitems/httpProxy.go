@@ -168,18 +168,19 @@ func (c *HttpProxyConfigurator) createGoproxyConfFile(proxy HttpProxy) error {
 		listenIP = proxy.ListenIP.String()
 	}
 	config := goproxycfg.ProxyConfig{
-		ListenIP:    listenIP,
-		Hostname:    proxy.Hostname,
-		HTTPPort:    proxy.HTTPPort,
-		HTTPSPorts:  proxy.HTTPSPorts,
-		Transparent: proxy.Transparent,
-		LogFile:     goproxyLogFile(proxyName),
-		PidFile:     goproxyPidFile(proxyName),
-		Verbose:     true,
-		CACertPEM:   proxy.CACertPEM,
-		CAKeyPEM:    proxy.CAKeyPEM,
-		ProxyRules:  proxy.ProxyRules,
-		Users:       proxy.Users,
+		ListenIP:      listenIP,
+		Hostname:      proxy.Hostname,
+		HTTPPort:      proxy.HTTPPort,
+		HTTPSPorts:    proxy.HTTPSPorts,
+		Transparent:   proxy.Transparent,
+		LogFile:       goproxyLogFile(proxyName),
+		AccessLogFile: HttpProxyAccessLogFile(proxyName),
+		PidFile:       goproxyPidFile(proxyName),
+		Verbose:       true,
+		CACertPEM:     proxy.CACertPEM,
+		CAKeyPEM:      proxy.CAKeyPEM,
+		ProxyRules:    proxy.ProxyRules,
+		Users:         proxy.Users,
 	}
 	configBytes, err := json.MarshalIndent(config, "", " ")
 	if err != nil {
@@ -213,6 +214,7 @@ func (c *HttpProxyConfigurator) Delete(ctx context.Context, item depgraph.Item)
 			// ignore errors from here
 			_ = removeGoproxyConfFile(config.ProxyName)
 			_ = removeGoproxyLogFile(config.ProxyName)
+			_ = removeGoproxyAccessLogFile(config.ProxyName)
 			_ = removeGoproxyPidFile(config.ProxyName)
 		}
 		done(err)
@@ -237,6 +239,12 @@ func goproxyLogFile(proxyName string) string {
 	return filepath.Join(goproxyRunDir, proxyName+".log")
 }
 
+// HttpProxyAccessLogFile returns path to the file where HTTP proxy with the given name
+// logs received requests (one JSON-encoded api.ProxyRequest per line).
+func HttpProxyAccessLogFile(proxyName string) string {
+	return filepath.Join(goproxyRunDir, proxyName+".access.log")
+}
+
 func removeGoproxyConfFile(proxyName string) error {
 	cfgPath := goproxyConfigPath(proxyName)
 	if err := os.Remove(cfgPath); err != nil {
@@ -270,6 +278,17 @@ func removeGoproxyLogFile(proxyName string) error {
 	return nil
 }
 
+func removeGoproxyAccessLogFile(proxyName string) error {
+	logPath := HttpProxyAccessLogFile(proxyName)
+	if err := os.Remove(logPath); err != nil {
+		err = fmt.Errorf("failed to remove proxy access log file %s: %w",
+			logPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
 func startGoproxy(proxyName, netNamespace string) error {
 	if err := ensureDir(goproxyRunDir); err != nil {
 		return err
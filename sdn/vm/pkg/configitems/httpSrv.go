@@ -8,6 +8,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"reflect"
 	"time"
 
 	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
@@ -50,6 +51,13 @@ type HttpServer struct {
 	CertPEM string
 	// KeyPEM : Server key in the PEM format. Required for HTTPS.
 	KeyPEM string
+	// CACertPEM : certificate of the CA which issued the server certificate.
+	// Optional, used to sign the expired server certificate.
+	CACertPEM string
+	// CAKeyPEM : key of the CA which issued the server certificate.
+	CAKeyPEM string
+	// TLSFaultPorts : additional HTTPS ports, each presenting a faulty server certificate.
+	TLSFaultPorts map[sdnapi.TLSCertFault]uint16
 	// Maps URL Path to a content to be returned inside the HTTP(s) response body.
 	Paths map[string]sdnapi.HTTPContent
 }
@@ -76,7 +84,15 @@ func (s HttpServer) Equal(other depgraph.Item) bool {
 		return false
 	}
 	for path, content := range s.Paths {
-		if content2, ok := s2.Paths[path]; !ok || content != content2 {
+		if content2, ok := s2.Paths[path]; !ok || !reflect.DeepEqual(content, content2) {
+			return false
+		}
+	}
+	if len(s.TLSFaultPorts) != len(s2.TLSFaultPorts) {
+		return false
+	}
+	for fault, port := range s.TLSFaultPorts {
+		if port2, ok := s2.TLSFaultPorts[fault]; !ok || port != port2 {
 			return false
 		}
 	}
@@ -86,7 +102,9 @@ func (s HttpServer) Equal(other depgraph.Item) bool {
 		s.HTTPPort == s2.HTTPPort &&
 		s.HTTPSPort == s2.HTTPSPort &&
 		s.CertPEM == s2.CertPEM &&
-		s.KeyPEM == s2.KeyPEM
+		s.KeyPEM == s2.KeyPEM &&
+		s.CACertPEM == s2.CACertPEM &&
+		s.CAKeyPEM == s2.CAKeyPEM
 }
 
 // External returns false.
@@ -148,15 +166,18 @@ func (c *HttpServerConfigurator) createHttpSrvConfFile(httpSrv HttpServer) error
 		listenIP = httpSrv.ListenIP.String()
 	}
 	config := httpsrvcfg.HttpSrvConfig{
-		ListenIP:  listenIP,
-		LogFile:   httpSrvLogFile(serverName),
-		PidFile:   httpSrvPidFile(serverName),
-		Verbose:   true,
-		HTTPPort:  httpSrv.HTTPPort,
-		HTTPSPort: httpSrv.HTTPSPort,
-		CertPEM:   httpSrv.CertPEM,
-		KeyPEM:    httpSrv.KeyPEM,
-		Paths:     httpSrv.Paths,
+		ListenIP:      listenIP,
+		LogFile:       httpSrvLogFile(serverName),
+		PidFile:       httpSrvPidFile(serverName),
+		Verbose:       true,
+		HTTPPort:      httpSrv.HTTPPort,
+		HTTPSPort:     httpSrv.HTTPSPort,
+		CertPEM:       httpSrv.CertPEM,
+		KeyPEM:        httpSrv.KeyPEM,
+		CACertPEM:     httpSrv.CACertPEM,
+		CAKeyPEM:      httpSrv.CAKeyPEM,
+		TLSFaultPorts: httpSrv.TLSFaultPorts,
+		Paths:         httpSrv.Paths,
 	}
 	configBytes, err := json.MarshalIndent(config, "", " ")
 	if err != nil {
@@ -0,0 +1,226 @@
+package configitems
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eve/libs/depgraph"
+	"github.com/lf-edge/eve/libs/reconciler"
+	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+)
+
+const (
+	taygaBinary       = "/usr/sbin/tayga"
+	taygaStartTimeout = 3 * time.Second
+	taygaStopTimeout  = 10 * time.Second
+	taygaConfDir      = "/etc/tayga"
+	taygaRunDir       = "/run/tayga"
+)
+
+// Nat64 : stateless NAT64 translator (tayga) running in the main network namespace.
+// IPv6 traffic routed into TunIfName with destination from Prefix is translated
+// to IPv4, using addresses from IPv4Pool as the source. Translated traffic can be then
+// S-NATed when leaving SDN VM towards the host (and the controller).
+type Nat64 struct {
+	// TunIfName : name of the TUN interface created for the translator.
+	TunIfName string
+	// Prefix : NAT64 prefix used to map IPv4 addresses into IPv6.
+	// Typically the Well-Known Prefix 64:ff9b::/96.
+	Prefix *net.IPNet
+	// IPv4Pool : IPv4 addresses dynamically assigned to IPv6 hosts using NAT64.
+	IPv4Pool *net.IPNet
+	// IPv4Addr : IPv4 address used by the translator itself (for ICMP).
+	IPv4Addr net.IP
+	// IPv6Addr : IPv6 address used by the translator itself (for ICMP).
+	// Should not be from within the Prefix.
+	IPv6Addr net.IP
+}
+
+// Name
+func (n Nat64) Name() string {
+	return n.TunIfName
+}
+
+// Label
+func (n Nat64) Label() string {
+	return n.TunIfName + " (NAT64)"
+}
+
+// Type
+func (n Nat64) Type() string {
+	return Nat64Typename
+}
+
+// Equal is a comparison method for two equally-named Nat64 instances.
+func (n Nat64) Equal(other depgraph.Item) bool {
+	n2 := other.(Nat64)
+	return equalIPNets(n.Prefix, n2.Prefix) &&
+		equalIPNets(n.IPv4Pool, n2.IPv4Pool) &&
+		n.IPv4Addr.Equal(n2.IPv4Addr) &&
+		n.IPv6Addr.Equal(n2.IPv6Addr)
+}
+
+// External returns false.
+func (n Nat64) External() bool {
+	return false
+}
+
+// String describes the NAT64 config.
+func (n Nat64) String() string {
+	return fmt.Sprintf("NAT64: %#+v", n)
+}
+
+// Dependencies lists the main network namespace as the only dependency.
+func (n Nat64) Dependencies() (deps []depgraph.Dependency) {
+	return []depgraph.Dependency{
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: NetNamespaceTypename,
+				ItemName: MainNsName,
+			},
+			Description: "Main network namespace must exist",
+		},
+	}
+}
+
+// Nat64Configurator implements Configurator interface for Nat64.
+type Nat64Configurator struct{}
+
+// Create creates TUN interface and starts tayga.
+func (c *Nat64Configurator) Create(ctx context.Context, item depgraph.Item) error {
+	config := item.(Nat64)
+	if err := c.createTaygaConfFile(config); err != nil {
+		return err
+	}
+	cfgPath := taygaConfigPath(config.TunIfName)
+	out, err := namespacedCmd(MainNsName, taygaBinary, "-c", cfgPath, "--mktun").CombinedOutput()
+	if err != nil {
+		err = fmt.Errorf("failed to create NAT64 TUN interface %s: %s", config.TunIfName, out)
+		log.Error(err)
+		return err
+	}
+	link, err := netlink.LinkByName(config.TunIfName)
+	if err != nil {
+		err = fmt.Errorf("failed to get link for NAT64 TUN interface %s: %w",
+			config.TunIfName, err)
+		log.Error(err)
+		return err
+	}
+	if err = netlink.LinkSetUp(link); err != nil {
+		err = fmt.Errorf("failed to set NAT64 TUN interface %s UP: %w", config.TunIfName, err)
+		log.Error(err)
+		return err
+	}
+	// Route returning (IPv4) traffic into the translator.
+	// Routes for IPv6 traffic are configured separately (see RouteOutIf.TunIfName).
+	err = netlink.RouteAdd(&netlink.Route{
+		Dst:       config.IPv4Pool,
+		LinkIndex: link.Attrs().Index,
+	})
+	if err != nil {
+		err = fmt.Errorf("failed to add route for NAT64 IPv4 pool %v: %w", config.IPv4Pool, err)
+		log.Error(err)
+		return err
+	}
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		err := startTayga(config.TunIfName)
+		done(err)
+	}()
+	return nil
+}
+
+func (c *Nat64Configurator) createTaygaConfFile(nat64 Nat64) error {
+	if err := ensureDir(taygaConfDir); err != nil {
+		return err
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("tun-device %s\n", nat64.TunIfName))
+	sb.WriteString(fmt.Sprintf("ipv4-addr %s\n", nat64.IPv4Addr))
+	sb.WriteString(fmt.Sprintf("ipv6-addr %s\n", nat64.IPv6Addr))
+	sb.WriteString(fmt.Sprintf("prefix %s\n", nat64.Prefix))
+	sb.WriteString(fmt.Sprintf("dynamic-pool %s\n", nat64.IPv4Pool))
+	sb.WriteString(fmt.Sprintf("data-dir %s\n", taygaDataDir(nat64.TunIfName)))
+	// The controller (and other endpoints outside of SDN) typically uses private IPv4
+	// address, which should not be normally used with the Well-Known Prefix.
+	sb.WriteString("wkpf-strict no\n")
+	cfgPath := taygaConfigPath(nat64.TunIfName)
+	if err := os.WriteFile(cfgPath, []byte(sb.String()), 0644); err != nil {
+		err = fmt.Errorf("failed to create config file %s: %w", cfgPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// Modify is not implemented.
+func (c *Nat64Configurator) Modify(ctx context.Context, oldItem, newItem depgraph.Item) (err error) {
+	return errors.New("not implemented")
+}
+
+// Delete stops tayga and removes the TUN interface.
+func (c *Nat64Configurator) Delete(ctx context.Context, item depgraph.Item) error {
+	config := item.(Nat64)
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		err := stopTayga(config.TunIfName)
+		if err == nil {
+			cfgPath := taygaConfigPath(config.TunIfName)
+			out, rmErr := namespacedCmd(MainNsName, taygaBinary,
+				"-c", cfgPath, "--rmtun").CombinedOutput()
+			if rmErr != nil {
+				err = fmt.Errorf("failed to remove NAT64 TUN interface %s: %s",
+					config.TunIfName, out)
+				log.Error(err)
+			}
+			// ignore errors from here
+			_ = os.Remove(cfgPath)
+			_ = os.Remove(taygaPidFile(config.TunIfName))
+			_ = os.RemoveAll(taygaDataDir(config.TunIfName))
+		}
+		done(err)
+	}()
+	return nil
+}
+
+// NeedsRecreate always returns true - Modify is not implemented.
+func (c *Nat64Configurator) NeedsRecreate(oldItem, newItem depgraph.Item) (recreate bool) {
+	return true
+}
+
+func taygaConfigPath(tunIfName string) string {
+	return filepath.Join(taygaConfDir, tunIfName+".conf")
+}
+
+func taygaPidFile(tunIfName string) string {
+	return filepath.Join(taygaRunDir, tunIfName+".pid")
+}
+
+func taygaDataDir(tunIfName string) string {
+	return filepath.Join(taygaRunDir, tunIfName)
+}
+
+func startTayga(tunIfName string) error {
+	if err := ensureDir(taygaDataDir(tunIfName)); err != nil {
+		return err
+	}
+	args := []string{
+		"-c", taygaConfigPath(tunIfName),
+		"-p", taygaPidFile(tunIfName),
+	}
+	pidFile := taygaPidFile(tunIfName)
+	// Do not run in background - tayga will detach itself.
+	return startProcess(MainNsName, taygaBinary, args, pidFile, taygaStartTimeout, false)
+}
+
+func stopTayga(tunIfName string) error {
+	pidFile := taygaPidFile(tunIfName)
+	return stopProcess(pidFile, taygaStopTimeout)
+}
@@ -0,0 +1,275 @@
+package configitems
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	netbootsrvcfg "github.com/lf-edge/eden/sdn/vm/cmd/netbootsrv/config"
+	"github.com/lf-edge/eve/libs/depgraph"
+	"github.com/lf-edge/eve/libs/reconciler"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	netbootSrvBinary  = "/bin/netbootsrv"
+	netbootSrvConfDir = "/etc/netbootsrv"
+	netbootSrvRunDir  = "/run/netbootsrv"
+
+	netbootSrvStartTimeout = 3 * time.Second
+	netbootSrvStopTimeout  = 10 * time.Second
+
+	// NetbootHTTPPort : port on which netboot server serves HTTP artifacts.
+	NetbootHTTPPort = 80
+	// NetbootTFTPPort : port on which netboot server serves TFTP artifacts.
+	NetbootTFTPPort = 69
+)
+
+// NetbootServer : HTTP and TFTP server providing artifacts needed to boot EVE OS
+// over a network.
+type NetbootServer struct {
+	// ServerName : logical name for the Netboot server.
+	ServerName string
+	// NetNamespace : network namespace where the server should be running.
+	NetNamespace string
+	// VethName : logical name of the veth pair on which the server operates.
+	// (other types of interfaces are currently not supported)
+	// Can be empty (if the server is not associated with any particular interface).
+	VethName string
+	// ListenIP : IP address on which the server should listen.
+	// Can be empty to listen on all available interfaces instead of just
+	// the interface with the given host address
+	ListenIP net.IP
+	// TFTPArtifacts : boot artifacts served by the TFTP server.
+	TFTPArtifacts []sdnapi.NetbootArtifact
+	// HTTPArtifacts : boot artifacts served by the HTTP server.
+	HTTPArtifacts []sdnapi.NetbootArtifact
+}
+
+// Name
+func (s NetbootServer) Name() string {
+	return s.ServerName
+}
+
+// Label
+func (s NetbootServer) Label() string {
+	return s.ServerName + " (Netboot server)"
+}
+
+// Type
+func (s NetbootServer) Type() string {
+	return NetbootServerTypename
+}
+
+// Equal is a comparison method for two equally-named NetbootServer instances.
+func (s NetbootServer) Equal(other depgraph.Item) bool {
+	s2 := other.(NetbootServer)
+	return s.NetNamespace == s2.NetNamespace &&
+		s.VethName == s2.VethName &&
+		s.ListenIP.Equal(s2.ListenIP) &&
+		equalNetbootArtifacts(s.TFTPArtifacts, s2.TFTPArtifacts) &&
+		equalNetbootArtifacts(s.HTTPArtifacts, s2.HTTPArtifacts)
+}
+
+// External returns false.
+func (s NetbootServer) External() bool {
+	return false
+}
+
+// String describes the Netboot server.
+func (s NetbootServer) String() string {
+	return fmt.Sprintf("Netboot server: %#+v", s)
+}
+
+// Dependencies lists the (optional) veth and network namespace as dependencies.
+func (s NetbootServer) Dependencies() (deps []depgraph.Dependency) {
+	deps = append(deps, depgraph.Dependency{
+		RequiredItem: depgraph.ItemRef{
+			ItemType: NetNamespaceTypename,
+			ItemName: normNetNsName(s.NetNamespace),
+		},
+		Description: "Network namespace must exist",
+	})
+	if s.VethName != "" {
+		deps = append(deps, depgraph.Dependency{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: VethTypename,
+				ItemName: s.VethName,
+			},
+			Description: "veth interface must exist",
+		})
+	}
+	return deps
+}
+
+// NetbootServerConfigurator implements Configurator interface for NetbootServer.
+type NetbootServerConfigurator struct{}
+
+// Create starts netbootsrv (see sdn/cmd/netbootsrv).
+func (c *NetbootServerConfigurator) Create(ctx context.Context, item depgraph.Item) error {
+	config := item.(NetbootServer)
+	if err := c.createNetbootSrvConfFile(config); err != nil {
+		return err
+	}
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		err := startNetbootSrv(config.ServerName, config.NetNamespace)
+		done(err)
+	}()
+	return nil
+}
+
+func (c *NetbootServerConfigurator) createNetbootSrvConfFile(netbootSrv NetbootServer) error {
+	if err := ensureDir(netbootSrvConfDir); err != nil {
+		return err
+	}
+	serverName := netbootSrv.ServerName
+	// Prepare configuration.
+	var listenIP string
+	if netbootSrv.ListenIP != nil {
+		listenIP = netbootSrv.ListenIP.String()
+	}
+	config := netbootsrvcfg.NetbootSrvConfig{
+		ListenIP:      listenIP,
+		LogFile:       netbootSrvLogFile(serverName),
+		PidFile:       netbootSrvPidFile(serverName),
+		Verbose:       true,
+		RootDir:       netbootSrvRootDir(serverName),
+		TFTPArtifacts: netbootSrv.TFTPArtifacts,
+		HTTPArtifacts: netbootSrv.HTTPArtifacts,
+	}
+	if len(netbootSrv.TFTPArtifacts) > 0 {
+		config.TFTPPort = NetbootTFTPPort
+	}
+	if len(netbootSrv.HTTPArtifacts) > 0 {
+		config.HTTPPort = NetbootHTTPPort
+	}
+	configBytes, err := json.MarshalIndent(config, "", " ")
+	if err != nil {
+		err = fmt.Errorf("failed to marshal config to JSON: %w", err)
+		log.Error(err)
+		return err
+	}
+	// Write configuration to file.
+	cfgPath := netbootSrvConfigPath(serverName)
+	err = os.WriteFile(cfgPath, configBytes, 0644)
+	if err != nil {
+		err = fmt.Errorf("failed to create config file %s: %w", cfgPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// Modify is not implemented.
+func (c *NetbootServerConfigurator) Modify(ctx context.Context, oldItem, newItem depgraph.Item) (err error) {
+	return errors.New("not implemented")
+}
+
+// Delete stops netbootsrv.
+func (c *NetbootServerConfigurator) Delete(ctx context.Context, item depgraph.Item) error {
+	config := item.(NetbootServer)
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		err := stopNetbootSrv(config.ServerName)
+		if err == nil {
+			// ignore errors from here
+			_ = removeNetbootSrvConfFile(config.ServerName)
+			_ = removeNetbootSrvLogFile(config.ServerName)
+			_ = removeNetbootSrvPidFile(config.ServerName)
+		}
+		done(err)
+	}()
+	return nil
+}
+
+// NeedsRecreate always returns true - Modify is not implemented.
+func (c *NetbootServerConfigurator) NeedsRecreate(oldItem, newItem depgraph.Item) (recreate bool) {
+	return true
+}
+
+func netbootSrvConfigPath(srvName string) string {
+	return filepath.Join(netbootSrvConfDir, srvName+".conf")
+}
+
+func netbootSrvPidFile(srvName string) string {
+	return filepath.Join(netbootSrvRunDir, srvName+".pid")
+}
+
+func netbootSrvLogFile(srvName string) string {
+	return filepath.Join(netbootSrvRunDir, srvName+".log")
+}
+
+func netbootSrvRootDir(srvName string) string {
+	return filepath.Join(netbootSrvRunDir, srvName)
+}
+
+func removeNetbootSrvConfFile(srvName string) error {
+	cfgPath := netbootSrvConfigPath(srvName)
+	if err := os.Remove(cfgPath); err != nil {
+		err = fmt.Errorf("failed to remove Netboot server config %s: %w",
+			cfgPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+func removeNetbootSrvPidFile(srvName string) error {
+	pidPath := netbootSrvPidFile(srvName)
+	if err := os.Remove(pidPath); err != nil {
+		err = fmt.Errorf("failed to remove Netboot server PID file %s: %w",
+			pidPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+func removeNetbootSrvLogFile(srvName string) error {
+	logPath := netbootSrvLogFile(srvName)
+	if err := os.Remove(logPath); err != nil {
+		err = fmt.Errorf("failed to remove Netboot server log file %s: %w",
+			logPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+func startNetbootSrv(srvName, netNamespace string) error {
+	if err := ensureDir(netbootSrvRunDir); err != nil {
+		return err
+	}
+	cfgPath := netbootSrvConfigPath(srvName)
+	cmd := netbootSrvBinary
+	args := []string{
+		"-c",
+		cfgPath,
+	}
+	pidFile := netbootSrvPidFile(srvName)
+	return startProcess(netNamespace, cmd, args, pidFile, netbootSrvStartTimeout, true)
+}
+
+func stopNetbootSrv(srvName string) error {
+	pidFile := netbootSrvPidFile(srvName)
+	return stopProcess(pidFile, netbootSrvStopTimeout)
+}
+
+func equalNetbootArtifacts(list1, list2 []sdnapi.NetbootArtifact) bool {
+	if len(list1) != len(list2) {
+		return false
+	}
+	for i := range list1 {
+		if list1[i] != list2[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,236 @@
+package configitems
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	sdnapi "github.com/lf-edge/eden/sdn/vm/api"
+	ntpsrvcfg "github.com/lf-edge/eden/sdn/vm/cmd/ntpsrv/config"
+	"github.com/lf-edge/eve/libs/depgraph"
+	"github.com/lf-edge/eve/libs/reconciler"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	ntpSrvBinary  = "/bin/ntpsrv"
+	ntpSrvConfDir = "/etc/ntpsrv"
+	ntpSrvRunDir  = "/run/ntpsrv"
+
+	ntpSrvStartTimeout = 3 * time.Second
+	ntpSrvStopTimeout  = 10 * time.Second
+)
+
+// NtpServer : NTP server
+type NtpServer struct {
+	// ServerName : logical name for the NTP server.
+	ServerName string
+	// NetNamespace : network namespace where the server should be running.
+	NetNamespace string
+	// VethName : logical name of the veth pair on which the server operates.
+	// (other types of interfaces are currently not supported)
+	// Can be empty (if the server is not associated with any particular interface).
+	VethName string
+	// ListenIP : IP address on which the server should listen.
+	// Can be empty to listen on all available interfaces instead of just
+	// the interface with the given host address
+	ListenIP net.IP
+	// UpstreamServers : NTP servers to synchronize with, each referenced
+	// by an IP address or a FQDN.
+	// Leave empty to serve time from the local clock.
+	UpstreamServers []string
+	// FakeClock : optional offset and drift to apply to served time.
+	FakeClock *sdnapi.FakeClock
+}
+
+// Name
+func (s NtpServer) Name() string {
+	return s.ServerName
+}
+
+// Label
+func (s NtpServer) Label() string {
+	return s.ServerName + " (NTP server)"
+}
+
+// Type
+func (s NtpServer) Type() string {
+	return NTPServerTypename
+}
+
+// Equal is a comparison method for two equally-named NtpServer instances.
+func (s NtpServer) Equal(other depgraph.Item) bool {
+	s2 := other.(NtpServer)
+	if len(s.UpstreamServers) != len(s2.UpstreamServers) {
+		return false
+	}
+	for i := range s.UpstreamServers {
+		if s.UpstreamServers[i] != s2.UpstreamServers[i] {
+			return false
+		}
+	}
+	if (s.FakeClock == nil) != (s2.FakeClock == nil) {
+		return false
+	}
+	if s.FakeClock != nil && *s.FakeClock != *s2.FakeClock {
+		return false
+	}
+	return s.NetNamespace == s2.NetNamespace &&
+		s.VethName == s2.VethName &&
+		s.ListenIP.Equal(s2.ListenIP)
+}
+
+// External returns false.
+func (s NtpServer) External() bool {
+	return false
+}
+
+// String describes the NTP server.
+func (s NtpServer) String() string {
+	return fmt.Sprintf("NTP server: %#+v", s)
+}
+
+// Dependencies lists the (optional) veth and network namespace as dependencies.
+func (s NtpServer) Dependencies() (deps []depgraph.Dependency) {
+	deps = append(deps, depgraph.Dependency{
+		RequiredItem: depgraph.ItemRef{
+			ItemType: NetNamespaceTypename,
+			ItemName: normNetNsName(s.NetNamespace),
+		},
+		Description: "Network namespace must exist",
+	})
+	if s.VethName != "" {
+		deps = append(deps, depgraph.Dependency{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: VethTypename,
+				ItemName: s.VethName,
+			},
+			Description: "veth interface must exist",
+		})
+	}
+	return deps
+}
+
+// NtpServerConfigurator implements Configurator interface for NtpServer.
+type NtpServerConfigurator struct{}
+
+// Create starts ntpsrv (see sdn/cmd/ntpsrv).
+func (c *NtpServerConfigurator) Create(ctx context.Context, item depgraph.Item) error {
+	config := item.(NtpServer)
+	if err := c.createNtpSrvConfFile(config); err != nil {
+		return err
+	}
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		err := startNtpSrv(config.ServerName, config.NetNamespace)
+		done(err)
+	}()
+	return nil
+}
+
+func (c *NtpServerConfigurator) createNtpSrvConfFile(ntpSrv NtpServer) error {
+	if err := ensureDir(ntpSrvConfDir); err != nil {
+		return err
+	}
+	serverName := ntpSrv.ServerName
+	// Prepare configuration.
+	var listenIP string
+	if ntpSrv.ListenIP != nil {
+		listenIP = ntpSrv.ListenIP.String()
+	}
+	config := ntpsrvcfg.NtpSrvConfig{
+		ListenIP:        listenIP,
+		LogFile:         ntpSrvLogFile(serverName),
+		PidFile:         ntpSrvPidFile(serverName),
+		Verbose:         true,
+		UpstreamServers: ntpSrv.UpstreamServers,
+		FakeClock:       ntpSrv.FakeClock,
+	}
+	configBytes, err := json.MarshalIndent(config, "", " ")
+	if err != nil {
+		err = fmt.Errorf("failed to marshal config to JSON: %w", err)
+		log.Error(err)
+		return err
+	}
+	// Write configuration to file.
+	cfgPath := ntpSrvConfigPath(serverName)
+	err = os.WriteFile(cfgPath, configBytes, 0644)
+	if err != nil {
+		err = fmt.Errorf("failed to create config file %s: %w", cfgPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// Modify is not implemented.
+func (c *NtpServerConfigurator) Modify(ctx context.Context, oldItem, newItem depgraph.Item) (err error) {
+	return errors.New("not implemented")
+}
+
+// Delete stops ntpsrv.
+func (c *NtpServerConfigurator) Delete(ctx context.Context, item depgraph.Item) error {
+	config := item.(NtpServer)
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		err := stopNtpSrv(config.ServerName)
+		if err == nil {
+			// ignore errors from here
+			_ = removeNtpSrvFile(ntpSrvConfigPath(config.ServerName))
+			_ = removeNtpSrvFile(ntpSrvLogFile(config.ServerName))
+			_ = removeNtpSrvFile(ntpSrvPidFile(config.ServerName))
+		}
+		done(err)
+	}()
+	return nil
+}
+
+// NeedsRecreate always returns true - Modify is not implemented.
+func (c *NtpServerConfigurator) NeedsRecreate(oldItem, newItem depgraph.Item) (recreate bool) {
+	return true
+}
+
+func ntpSrvConfigPath(srvName string) string {
+	return filepath.Join(ntpSrvConfDir, srvName+".conf")
+}
+
+func ntpSrvPidFile(srvName string) string {
+	return filepath.Join(ntpSrvRunDir, srvName+".pid")
+}
+
+func ntpSrvLogFile(srvName string) string {
+	return filepath.Join(ntpSrvRunDir, srvName+".log")
+}
+
+func removeNtpSrvFile(path string) error {
+	if err := os.Remove(path); err != nil {
+		err = fmt.Errorf("failed to remove NTP server file %s: %w", path, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+func startNtpSrv(srvName, netNamespace string) error {
+	if err := ensureDir(ntpSrvRunDir); err != nil {
+		return err
+	}
+	cfgPath := ntpSrvConfigPath(srvName)
+	cmd := ntpSrvBinary
+	args := []string{
+		"-c",
+		cfgPath,
+	}
+	pidFile := ntpSrvPidFile(srvName)
+	return startProcess(netNamespace, cmd, args, pidFile, ntpSrvStartTimeout, true)
+}
+
+func stopNtpSrv(srvName string) error {
+	pidFile := ntpSrvPidFile(srvName)
+	return stopProcess(pidFile, ntpSrvStopTimeout)
+}
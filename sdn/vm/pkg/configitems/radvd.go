@@ -0,0 +1,263 @@
+package configitems
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lf-edge/eve/libs/depgraph"
+	"github.com/lf-edge/eve/libs/reconciler"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	radvdBinary       = "/usr/sbin/radvd"
+	radvdStartTimeout = 3 * time.Second
+	radvdStopTimeout  = 10 * time.Second
+	radvdConfDir      = "/etc/radvd"
+	radvdRunDir       = "/run/radvd"
+)
+
+// RouterAdvertisement : IPv6 Router Advertisement daemon (radvd) announcing
+// the network prefix, default gateway and DNS configuration.
+type RouterAdvertisement struct {
+	// ServerName : logical name for the RA daemon.
+	ServerName string
+	// NetNamespace : network namespace where the daemon should be running.
+	NetNamespace string
+	// VethName : logical name of the veth pair on which the daemon operates.
+	// (other types of interfaces are currently not supported)
+	VethName string
+	// VethPeerIfName : interface name of that side of the veth pair on which
+	// the daemon should send advertisements. It should be inside NetNamespace.
+	VethPeerIfName string
+	// Subnet : IPv6 network address + netmask to advertise.
+	Subnet *net.IPNet
+	// SLAAC : allow hosts to autoconfigure addresses from the Subnet.
+	// Subnet prefix length has to be 64.
+	SLAAC bool
+	// ManagedFlag : hosts should obtain addresses using stateful DHCPv6.
+	ManagedFlag bool
+	// OtherConfigFlag : hosts should obtain other configuration (NTP, netboot, etc.)
+	// using DHCPv6.
+	OtherConfigFlag bool
+	// WithoutDefaultRoute : do not advertise this router as the default gateway.
+	WithoutDefaultRoute bool
+	// DNSServers : list of IP addresses of DNS servers to announce (RDNSS option).
+	DNSServers []net.IP
+	// DomainName : name of the domain to announce (DNSSL option).
+	DomainName string
+	// MTU : MTU to announce. Zero value to not announce MTU.
+	MTU uint16
+}
+
+// Name
+func (r RouterAdvertisement) Name() string {
+	return r.ServerName
+}
+
+// Label
+func (r RouterAdvertisement) Label() string {
+	return r.ServerName + " (Router Advertisement)"
+}
+
+// Type
+func (r RouterAdvertisement) Type() string {
+	return RouterAdvertisementTypename
+}
+
+// Equal is a comparison method for two equally-named RouterAdvertisement instances.
+func (r RouterAdvertisement) Equal(other depgraph.Item) bool {
+	r2 := other.(RouterAdvertisement)
+	return r.NetNamespace == r2.NetNamespace &&
+		r.VethName == r2.VethName &&
+		r.VethPeerIfName == r2.VethPeerIfName &&
+		equalIPNets(r.Subnet, r2.Subnet) &&
+		r.SLAAC == r2.SLAAC &&
+		r.ManagedFlag == r2.ManagedFlag &&
+		r.OtherConfigFlag == r2.OtherConfigFlag &&
+		r.WithoutDefaultRoute == r2.WithoutDefaultRoute &&
+		equalIPLists(r.DNSServers, r2.DNSServers) &&
+		r.DomainName == r2.DomainName &&
+		r.MTU == r2.MTU
+}
+
+// External returns false.
+func (r RouterAdvertisement) External() bool {
+	return false
+}
+
+// String describes the Router Advertisement config.
+func (r RouterAdvertisement) String() string {
+	return fmt.Sprintf("Router Advertisement: %#+v", r)
+}
+
+// Dependencies lists the veth and network namespace as dependencies.
+func (r RouterAdvertisement) Dependencies() (deps []depgraph.Dependency) {
+	return []depgraph.Dependency{
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: NetNamespaceTypename,
+				ItemName: normNetNsName(r.NetNamespace),
+			},
+			Description: "Network namespace must exist",
+		},
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: VethTypename,
+				ItemName: r.VethName,
+			},
+			Description: "veth interface must exist",
+		},
+		{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: SysctlTypename,
+				ItemName: normNetNsName(r.NetNamespace),
+			},
+			MustSatisfy: func(item depgraph.Item) bool {
+				sysctl := item.(Sysctl)
+				return sysctl.EnableIPv6Forwarding
+			},
+			Description: "IPv6 forwarding must be enabled",
+		},
+	}
+}
+
+// RouterAdvertisementConfigurator implements Configurator interface
+// for RouterAdvertisement.
+type RouterAdvertisementConfigurator struct{}
+
+// Create starts radvd.
+func (c *RouterAdvertisementConfigurator) Create(ctx context.Context, item depgraph.Item) error {
+	config := item.(RouterAdvertisement)
+	if err := c.createRadvdConfFile(config); err != nil {
+		return err
+	}
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		err := startRadvd(config.ServerName, config.NetNamespace)
+		done(err)
+	}()
+	return nil
+}
+
+func (c *RouterAdvertisementConfigurator) createRadvdConfFile(ra RouterAdvertisement) error {
+	if err := ensureDir(radvdConfDir); err != nil {
+		return err
+	}
+	onOff := func(enabled bool) string {
+		if enabled {
+			return "on"
+		}
+		return "off"
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("interface %s {\n", ra.VethPeerIfName))
+	sb.WriteString("  AdvSendAdvert on;\n")
+	sb.WriteString("  MinRtrAdvInterval 3;\n")
+	sb.WriteString("  MaxRtrAdvInterval 10;\n")
+	sb.WriteString(fmt.Sprintf("  AdvManagedFlag %s;\n", onOff(ra.ManagedFlag)))
+	sb.WriteString(fmt.Sprintf("  AdvOtherConfigFlag %s;\n", onOff(ra.OtherConfigFlag)))
+	if ra.WithoutDefaultRoute {
+		sb.WriteString("  AdvDefaultLifetime 0;\n")
+	}
+	if ra.MTU != 0 {
+		sb.WriteString(fmt.Sprintf("  AdvLinkMTU %d;\n", ra.MTU))
+	}
+	sb.WriteString(fmt.Sprintf("  prefix %s {\n", ra.Subnet))
+	sb.WriteString("    AdvOnLink on;\n")
+	sb.WriteString(fmt.Sprintf("    AdvAutonomous %s;\n", onOff(ra.SLAAC)))
+	sb.WriteString("    AdvRouterAddr on;\n")
+	sb.WriteString("  };\n")
+	if len(ra.DNSServers) > 0 {
+		var addrList []string
+		for _, srvIP := range ra.DNSServers {
+			addrList = append(addrList, srvIP.String())
+		}
+		sb.WriteString(fmt.Sprintf("  RDNSS %s {\n  };\n", strings.Join(addrList, " ")))
+	}
+	if ra.DomainName != "" {
+		sb.WriteString(fmt.Sprintf("  DNSSL %s {\n  };\n", ra.DomainName))
+	}
+	sb.WriteString("};\n")
+	cfgPath := radvdConfigPath(ra.ServerName)
+	if err := os.WriteFile(cfgPath, []byte(sb.String()), 0644); err != nil {
+		err = fmt.Errorf("failed to create config file %s: %w", cfgPath, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// Modify is not implemented.
+func (c *RouterAdvertisementConfigurator) Modify(ctx context.Context, oldItem, newItem depgraph.Item) (err error) {
+	return errors.New("not implemented")
+}
+
+// Delete stops radvd.
+func (c *RouterAdvertisementConfigurator) Delete(ctx context.Context, item depgraph.Item) error {
+	config := item.(RouterAdvertisement)
+	done := reconciler.ContinueInBackground(ctx)
+	go func() {
+		err := stopRadvd(config.ServerName)
+		if err == nil {
+			// ignore errors from here
+			_ = removeRadvdFile(radvdConfigPath(config.ServerName))
+			_ = removeRadvdFile(radvdLogFile(config.ServerName))
+			_ = removeRadvdFile(radvdPidFile(config.ServerName))
+		}
+		done(err)
+	}()
+	return nil
+}
+
+// NeedsRecreate always returns true - Modify is not implemented.
+func (c *RouterAdvertisementConfigurator) NeedsRecreate(oldItem, newItem depgraph.Item) (recreate bool) {
+	return true
+}
+
+func radvdConfigPath(srvName string) string {
+	return filepath.Join(radvdConfDir, srvName+".conf")
+}
+
+func radvdPidFile(srvName string) string {
+	return filepath.Join(radvdRunDir, srvName+".pid")
+}
+
+func radvdLogFile(srvName string) string {
+	return filepath.Join(radvdRunDir, srvName+".log")
+}
+
+func removeRadvdFile(path string) error {
+	if err := os.Remove(path); err != nil {
+		err = fmt.Errorf("failed to remove radvd file %s: %w", path, err)
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+func startRadvd(srvName, netNamespace string) error {
+	if err := ensureDir(radvdRunDir); err != nil {
+		return err
+	}
+	args := []string{
+		"-C", radvdConfigPath(srvName),
+		"-p", radvdPidFile(srvName),
+		"-m", "logfile",
+		"-l", radvdLogFile(srvName),
+	}
+	pidFile := radvdPidFile(srvName)
+	// Do not run in background - radvd will detach itself.
+	return startProcess(netNamespace, radvdBinary, args, pidFile, radvdStartTimeout, false)
+}
+
+func stopRadvd(srvName string) error {
+	pidFile := radvdPidFile(srvName)
+	return stopProcess(pidFile, radvdStopTimeout)
+}
@@ -28,6 +28,13 @@ func RegisterItems(
 		{c: &IptablesChainConfigurator{}, t: IP6tablesChainTypename},
 		{c: &HttpProxyConfigurator{}, t: HTTPProxyTypename},
 		{c: &HttpServerConfigurator{}, t: HTTPServerTypename},
+		{c: &CaptivePortalConfigurator{}, t: CaptivePortalTypename},
+		{c: &NtpServerConfigurator{}, t: NTPServerTypename},
+		{c: &NetbootServerConfigurator{}, t: NetbootServerTypename},
+		{c: &RouterAdvertisementConfigurator{}, t: RouterAdvertisementTypename},
+		{c: &Nat64Configurator{}, t: Nat64Typename},
+		{c: &Dns64ProxyConfigurator{}, t: Dns64ProxyTypename},
+		{c: &DnsFaultProxyConfigurator{}, t: DnsFaultProxyTypename},
 		{c: &TrafficControlConfigurator{MacLookup: macLookup}, t: TrafficControlTypename},
 	}
 	for _, configurator := range configurators {
@@ -35,17 +35,21 @@ type Route struct {
 	Metric uint32
 }
 
-// RouteOutIf : output interface for the route - either veth or physical interface.
+// RouteOutIf : output interface for the route - either veth, physical interface
+// or NAT64 TUN interface.
 type RouteOutIf struct {
 	// VethName : logical name of the veth pair used as the output device for the route.
-	// Define either PhysIf or VethName + VethPeerIfName.
+	// Define either PhysIf, VethName + VethPeerIfName or TunIfName.
 	VethName string
 	// VethPeerIfName : interface name of that side of the veth pair which the routed
 	// traffic is entering.
 	VethPeerIfName string
 	// PhysIf : physical interface to use as the output device.
-	// Define either PhysIf or VethName + VethPeerIfName.
+	// Define either PhysIf, VethName + VethPeerIfName or TunIfName.
 	PhysIf PhysIf
+	// TunIfName : name of the TUN interface created for NAT64 (see Nat64).
+	// Define either PhysIf, VethName + VethPeerIfName or TunIfName.
+	TunIfName string
 }
 
 // Name
@@ -75,6 +79,9 @@ func (r Route) outputIfRef() string {
 	if len(r.OutputIf.PhysIf.MAC) > 0 {
 		return r.OutputIf.PhysIf.MAC.String()
 	}
+	if r.OutputIf.TunIfName != "" {
+		return r.OutputIf.TunIfName
+	}
 	return ""
 }
 
@@ -132,6 +139,14 @@ func (r Route) Dependencies() (deps []depgraph.Dependency) {
 			},
 			Description: "Physical network interface must exist and be used in the L3 mode",
 		})
+	} else if r.OutputIf.TunIfName != "" {
+		deps = append(deps, depgraph.Dependency{
+			RequiredItem: depgraph.ItemRef{
+				ItemType: Nat64Typename,
+				ItemName: r.OutputIf.TunIfName,
+			},
+			Description: "NAT64 TUN interface must exist",
+		})
 	}
 	return deps
 }
@@ -190,6 +205,13 @@ func (c *RouteConfigurator) buildNetlinkRoute(route Route) (*netlink.Route, erro
 			return nil, fmt.Errorf("failed to get physical interface with MAC %v", mac)
 		}
 		outLinkIndex = netIf.IfIndex
+	} else if route.OutputIf.TunIfName != "" {
+		ifName := route.OutputIf.TunIfName
+		link, err := netlink.LinkByName(ifName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get link for TUN interface %s: %w", ifName, err)
+		}
+		outLinkIndex = link.Attrs().Index
 	} else {
 		routeType = unix.RTN_UNREACHABLE
 	}
@@ -123,6 +123,10 @@ func (c *SysctlConfigurator) setIPForwarding(netNs string, v4, v6 bool) error {
 }
 
 func (c *SysctlConfigurator) setBridgeIptables(netNs string, v4, v6 bool) error {
+	if normNetNsName(netNs) != MainNsName {
+		// Bridges are only created in the main network namespace.
+		return nil
+	}
 	sysctlKV := fmt.Sprintf("%s=%s", bridgeIptablesKey, c.boolValueToStr(v4))
 	out, err := namespacedCmd(netNs, "sysctl", "-w", sysctlKV).CombinedOutput()
 	if err != nil {
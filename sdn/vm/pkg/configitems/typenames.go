@@ -36,6 +36,20 @@ const (
 	HTTPProxyTypename = "HTTP-Proxy"
 	// HTTPServerTypename : typename for HTTP server.
 	HTTPServerTypename = "HTTP-Server"
+	// CaptivePortalTypename : typename for captive portal server.
+	CaptivePortalTypename = "Captive-Portal"
+	// NTPServerTypename : typename for NTP server.
+	NTPServerTypename = "NTP-Server"
+	// NetbootServerTypename : typename for Netboot (HTTP + TFTP) server.
+	NetbootServerTypename = "Netboot-Server"
+	// RouterAdvertisementTypename : typename for IPv6 Router Advertisement daemon.
+	RouterAdvertisementTypename = "Router-Advertisement"
+	// Nat64Typename : typename for NAT64 translator.
+	Nat64Typename = "NAT64"
+	// Dns64ProxyTypename : typename for DNS64 proxy.
+	Dns64ProxyTypename = "DNS64-Proxy"
+	// DnsFaultProxyTypename : typename for DNS proxy injecting faults.
+	DnsFaultProxyTypename = "DNS-Fault-Proxy"
 	// TrafficControlTypename : typename for TC rules applied to physical interface.
 	TrafficControlTypename = "Traffic-Control"
 )
@@ -16,6 +16,7 @@ import (
 	"github.com/lf-edge/eden/pkg/controller/einfo"
 	"github.com/lf-edge/eden/pkg/controller/elog"
 	"github.com/lf-edge/eden/pkg/controller/emetric"
+	"github.com/lf-edge/eden/pkg/controller/equery"
 	"github.com/lf-edge/eden/pkg/controller/types"
 	"github.com/lf-edge/eden/pkg/device"
 	"github.com/lf-edge/eden/pkg/projects"
@@ -32,6 +33,7 @@ var (
 	timewait = flag.Duration("timewait", 10*time.Minute, "Timewait for items waiting")
 	out      = flag.String("out", "", "Parameters for out separated by ':'")
 	app      = flag.String("app", "", "Name of app for TestAppLogs")
+	expr     = flag.String("expr", "", "Expression to filter items in addition to field:regexp arguments")
 
 	// This context holds all the configuration items in the same
 	// way that Eden context works: the commands line options override
@@ -68,8 +70,11 @@ func mkquery() error {
 			query[s[0]] = s[1]
 		}
 	}
-
-	return nil
+	if *expr != "" {
+		query[equery.ExprKey] = *expr
+	}
+	_, err := equery.Compile(query)
+	return err
 }
 
 func count(msg string, node string) string {